@@ -0,0 +1,695 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        v5.29.3
+// source: tracker/v1/tracker.proto
+
+package trackerv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TriggerFetchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Fetch from YouTube but write nothing.
+	DryRun        bool `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerFetchRequest) Reset() {
+	*x = TriggerFetchRequest{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerFetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerFetchRequest) ProtoMessage() {}
+
+func (x *TriggerFetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerFetchRequest.ProtoReflect.Descriptor instead.
+func (*TriggerFetchRequest) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TriggerFetchRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type TriggerFetchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "success" or "dry_run".
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	RunId  string `protobuf:"bytes,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	// Records a dry run would have written, per table.
+	Counts        map[string]int64 `protobuf:"bytes,3,rep,name=counts,proto3" json:"counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerFetchResponse) Reset() {
+	*x = TriggerFetchResponse{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerFetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerFetchResponse) ProtoMessage() {}
+
+func (x *TriggerFetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerFetchResponse.ProtoReflect.Descriptor instead.
+func (*TriggerFetchResponse) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TriggerFetchResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TriggerFetchResponse) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *TriggerFetchResponse) GetCounts() map[string]int64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+type GetRunStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Run to look up in fetch_runs; empty for the latest run of the instance.
+	RunId         string `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRunStatusRequest) Reset() {
+	*x = GetRunStatusRequest{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRunStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunStatusRequest) ProtoMessage() {}
+
+func (x *GetRunStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetRunStatusRequest) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRunStatusRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type RunStatus struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	RunId             string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Scope             string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	DryRun            bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	StartedAt         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Running           bool                   `protobuf:"varint,6,opt,name=running,proto3" json:"running,omitempty"`
+	Error             string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	ChannelsSucceeded int64                  `protobuf:"varint,8,opt,name=channels_succeeded,json=channelsSucceeded,proto3" json:"channels_succeeded,omitempty"`
+	ChannelsFailed    int64                  `protobuf:"varint,9,opt,name=channels_failed,json=channelsFailed,proto3" json:"channels_failed,omitempty"`
+	VideosWritten     int64                  `protobuf:"varint,10,opt,name=videos_written,json=videosWritten,proto3" json:"videos_written,omitempty"`
+	QuotaUnits        int64                  `protobuf:"varint,11,opt,name=quota_units,json=quotaUnits,proto3" json:"quota_units,omitempty"`
+	FailedChannels    []string               `protobuf:"bytes,12,rep,name=failed_channels,json=failedChannels,proto3" json:"failed_channels,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RunStatus) Reset() {
+	*x = RunStatus{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStatus) ProtoMessage() {}
+
+func (x *RunStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStatus.ProtoReflect.Descriptor instead.
+func (*RunStatus) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RunStatus) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *RunStatus) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *RunStatus) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *RunStatus) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RunStatus) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *RunStatus) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *RunStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *RunStatus) GetChannelsSucceeded() int64 {
+	if x != nil {
+		return x.ChannelsSucceeded
+	}
+	return 0
+}
+
+func (x *RunStatus) GetChannelsFailed() int64 {
+	if x != nil {
+		return x.ChannelsFailed
+	}
+	return 0
+}
+
+func (x *RunStatus) GetVideosWritten() int64 {
+	if x != nil {
+		return x.VideosWritten
+	}
+	return 0
+}
+
+func (x *RunStatus) GetQuotaUnits() int64 {
+	if x != nil {
+		return x.QuotaUnits
+	}
+	return 0
+}
+
+func (x *RunStatus) GetFailedChannels() []string {
+	if x != nil {
+		return x.FailedChannels
+	}
+	return nil
+}
+
+type ListVideoStatsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	// First and last dates (YYYY-MM-DD); default to 30 days before to and
+	// today.
+	From string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// Maximum number of videos (default 50, at most 500).
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVideoStatsRequest) Reset() {
+	*x = ListVideoStatsRequest{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVideoStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVideoStatsRequest) ProtoMessage() {}
+
+func (x *ListVideoStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVideoStatsRequest.ProtoReflect.Descriptor instead.
+func (*ListVideoStatsRequest) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListVideoStatsRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *ListVideoStatsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListVideoStatsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListVideoStatsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListVideoStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Videos        []*VideoStat           `protobuf:"bytes,4,rep,name=videos,proto3" json:"videos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVideoStatsResponse) Reset() {
+	*x = ListVideoStatsResponse{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVideoStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVideoStatsResponse) ProtoMessage() {}
+
+func (x *ListVideoStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVideoStatsResponse.ProtoReflect.Descriptor instead.
+func (*ListVideoStatsResponse) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListVideoStatsResponse) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *ListVideoStatsResponse) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListVideoStatsResponse) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListVideoStatsResponse) GetVideos() []*VideoStat {
+	if x != nil {
+		return x.Videos
+	}
+	return nil
+}
+
+type VideoStat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Snapshot date (YYYY-MM-DD).
+	Dt            string                 `protobuf:"bytes,1,opt,name=dt,proto3" json:"dt,omitempty"`
+	ChannelId     string                 `protobuf:"bytes,2,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	ChannelName   string                 `protobuf:"bytes,3,opt,name=channel_name,json=channelName,proto3" json:"channel_name,omitempty"`
+	VideoId       string                 `protobuf:"bytes,4,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Title         string                 `protobuf:"bytes,5,opt,name=title,proto3" json:"title,omitempty"`
+	IsShort       bool                   `protobuf:"varint,6,opt,name=is_short,json=isShort,proto3" json:"is_short,omitempty"`
+	Views         int64                  `protobuf:"varint,7,opt,name=views,proto3" json:"views,omitempty"`
+	Likes         int64                  `protobuf:"varint,8,opt,name=likes,proto3" json:"likes,omitempty"`
+	Comments      int64                  `protobuf:"varint,9,opt,name=comments,proto3" json:"comments,omitempty"`
+	PublishedAt   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VideoStat) Reset() {
+	*x = VideoStat{}
+	mi := &file_tracker_v1_tracker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoStat) ProtoMessage() {}
+
+func (x *VideoStat) ProtoReflect() protoreflect.Message {
+	mi := &file_tracker_v1_tracker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoStat.ProtoReflect.Descriptor instead.
+func (*VideoStat) Descriptor() ([]byte, []int) {
+	return file_tracker_v1_tracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VideoStat) GetDt() string {
+	if x != nil {
+		return x.Dt
+	}
+	return ""
+}
+
+func (x *VideoStat) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *VideoStat) GetChannelName() string {
+	if x != nil {
+		return x.ChannelName
+	}
+	return ""
+}
+
+func (x *VideoStat) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *VideoStat) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *VideoStat) GetIsShort() bool {
+	if x != nil {
+		return x.IsShort
+	}
+	return false
+}
+
+func (x *VideoStat) GetViews() int64 {
+	if x != nil {
+		return x.Views
+	}
+	return 0
+}
+
+func (x *VideoStat) GetLikes() int64 {
+	if x != nil {
+		return x.Likes
+	}
+	return 0
+}
+
+func (x *VideoStat) GetComments() int64 {
+	if x != nil {
+		return x.Comments
+	}
+	return 0
+}
+
+func (x *VideoStat) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+var File_tracker_v1_tracker_proto protoreflect.FileDescriptor
+
+const file_tracker_v1_tracker_proto_rawDesc = "" +
+	"\n" +
+	"\x18tracker/v1/tracker.proto\x12\n" +
+	"tracker.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\".\n" +
+	"\x13TriggerFetchRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\"\xc6\x01\n" +
+	"\x14TriggerFetchResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x15\n" +
+	"\x06run_id\x18\x02 \x01(\tR\x05runId\x12D\n" +
+	"\x06counts\x18\x03 \x03(\v2,.tracker.v1.TriggerFetchResponse.CountsEntryR\x06counts\x1a9\n" +
+	"\vCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\",\n" +
+	"\x13GetRunStatusRequest\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\xc2\x03\n" +
+	"\tRunStatus\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x18\n" +
+	"\arunning\x18\x06 \x01(\bR\arunning\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12-\n" +
+	"\x12channels_succeeded\x18\b \x01(\x03R\x11channelsSucceeded\x12'\n" +
+	"\x0fchannels_failed\x18\t \x01(\x03R\x0echannelsFailed\x12%\n" +
+	"\x0evideos_written\x18\n" +
+	" \x01(\x03R\rvideosWritten\x12\x1f\n" +
+	"\vquota_units\x18\v \x01(\x03R\n" +
+	"quotaUnits\x12'\n" +
+	"\x0ffailed_channels\x18\f \x03(\tR\x0efailedChannels\"p\n" +
+	"\x15ListVideoStatsRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\x8a\x01\n" +
+	"\x16ListVideoStatsResponse\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12-\n" +
+	"\x06videos\x18\x04 \x03(\v2\x15.tracker.v1.VideoStatR\x06videos\"\xb0\x02\n" +
+	"\tVideoStat\x12\x0e\n" +
+	"\x02dt\x18\x01 \x01(\tR\x02dt\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x02 \x01(\tR\tchannelId\x12!\n" +
+	"\fchannel_name\x18\x03 \x01(\tR\vchannelName\x12\x19\n" +
+	"\bvideo_id\x18\x04 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05title\x18\x05 \x01(\tR\x05title\x12\x19\n" +
+	"\bis_short\x18\x06 \x01(\bR\aisShort\x12\x14\n" +
+	"\x05views\x18\a \x01(\x03R\x05views\x12\x14\n" +
+	"\x05likes\x18\b \x01(\x03R\x05likes\x12\x1a\n" +
+	"\bcomments\x18\t \x01(\x03R\bcomments\x12=\n" +
+	"\fpublished_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt2\xd6\x02\n" +
+	"\x0eTrackerService\x12\\\n" +
+	"\fTriggerFetch\x12\x1f.tracker.v1.TriggerFetchRequest\x1a .tracker.v1.TriggerFetchResponse\"\t\x82\xd3\xe4\x93\x02\x03\"\x01/\x12^\n" +
+	"\fGetRunStatus\x12\x1f.tracker.v1.GetRunStatusRequest\x1a\x15.tracker.v1.RunStatus\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/api/v1/status\x12\x85\x01\n" +
+	"\x0eListVideoStats\x12!.tracker.v1.ListVideoStatsRequest\x1a\".tracker.v1.ListVideoStatsResponse\",\x82\xd3\xe4\x93\x02&\x12$/api/v1/channels/{channel_id}/videosBFZDgithub.com/lancelop89/youtube-trend-tracker/api/tracker/v1;trackerv1b\x06proto3"
+
+var (
+	file_tracker_v1_tracker_proto_rawDescOnce sync.Once
+	file_tracker_v1_tracker_proto_rawDescData []byte
+)
+
+func file_tracker_v1_tracker_proto_rawDescGZIP() []byte {
+	file_tracker_v1_tracker_proto_rawDescOnce.Do(func() {
+		file_tracker_v1_tracker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tracker_v1_tracker_proto_rawDesc), len(file_tracker_v1_tracker_proto_rawDesc)))
+	})
+	return file_tracker_v1_tracker_proto_rawDescData
+}
+
+var file_tracker_v1_tracker_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_tracker_v1_tracker_proto_goTypes = []any{
+	(*TriggerFetchRequest)(nil),    // 0: tracker.v1.TriggerFetchRequest
+	(*TriggerFetchResponse)(nil),   // 1: tracker.v1.TriggerFetchResponse
+	(*GetRunStatusRequest)(nil),    // 2: tracker.v1.GetRunStatusRequest
+	(*RunStatus)(nil),              // 3: tracker.v1.RunStatus
+	(*ListVideoStatsRequest)(nil),  // 4: tracker.v1.ListVideoStatsRequest
+	(*ListVideoStatsResponse)(nil), // 5: tracker.v1.ListVideoStatsResponse
+	(*VideoStat)(nil),              // 6: tracker.v1.VideoStat
+	nil,                            // 7: tracker.v1.TriggerFetchResponse.CountsEntry
+	(*timestamppb.Timestamp)(nil),  // 8: google.protobuf.Timestamp
+}
+var file_tracker_v1_tracker_proto_depIdxs = []int32{
+	7, // 0: tracker.v1.TriggerFetchResponse.counts:type_name -> tracker.v1.TriggerFetchResponse.CountsEntry
+	8, // 1: tracker.v1.RunStatus.started_at:type_name -> google.protobuf.Timestamp
+	8, // 2: tracker.v1.RunStatus.finished_at:type_name -> google.protobuf.Timestamp
+	6, // 3: tracker.v1.ListVideoStatsResponse.videos:type_name -> tracker.v1.VideoStat
+	8, // 4: tracker.v1.VideoStat.published_at:type_name -> google.protobuf.Timestamp
+	0, // 5: tracker.v1.TrackerService.TriggerFetch:input_type -> tracker.v1.TriggerFetchRequest
+	2, // 6: tracker.v1.TrackerService.GetRunStatus:input_type -> tracker.v1.GetRunStatusRequest
+	4, // 7: tracker.v1.TrackerService.ListVideoStats:input_type -> tracker.v1.ListVideoStatsRequest
+	1, // 8: tracker.v1.TrackerService.TriggerFetch:output_type -> tracker.v1.TriggerFetchResponse
+	3, // 9: tracker.v1.TrackerService.GetRunStatus:output_type -> tracker.v1.RunStatus
+	5, // 10: tracker.v1.TrackerService.ListVideoStats:output_type -> tracker.v1.ListVideoStatsResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_tracker_v1_tracker_proto_init() }
+func file_tracker_v1_tracker_proto_init() {
+	if File_tracker_v1_tracker_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tracker_v1_tracker_proto_rawDesc), len(file_tracker_v1_tracker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tracker_v1_tracker_proto_goTypes,
+		DependencyIndexes: file_tracker_v1_tracker_proto_depIdxs,
+		MessageInfos:      file_tracker_v1_tracker_proto_msgTypes,
+	}.Build()
+	File_tracker_v1_tracker_proto = out.File
+	file_tracker_v1_tracker_proto_goTypes = nil
+	file_tracker_v1_tracker_proto_depIdxs = nil
+}
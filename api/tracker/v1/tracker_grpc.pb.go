@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: tracker/v1/tracker.proto
+
+package trackerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TrackerService_TriggerFetch_FullMethodName   = "/tracker.v1.TrackerService/TriggerFetch"
+	TrackerService_GetRunStatus_FullMethodName   = "/tracker.v1.TrackerService/GetRunStatus"
+	TrackerService_ListVideoStats_FullMethodName = "/tracker.v1.TrackerService/ListVideoStats"
+)
+
+// TrackerServiceClient is the client API for TrackerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TrackerService is the gRPC counterpart of the fetcher's HTTP API. Each
+// method runs the same code as the REST endpoint in its google.api.http
+// option, so a gateway (e.g. grpc-gateway or ESPv2) can serve both.
+type TrackerServiceClient interface {
+	// TriggerFetch fetches the channels that are due, like POST /. It returns
+	// once the run has finished.
+	TriggerFetch(ctx context.Context, in *TriggerFetchRequest, opts ...grpc.CallOption) (*TriggerFetchResponse, error)
+	// GetRunStatus returns a run: the latest one handled by the serving
+	// instance, like GET /api/v1/status, or the one recorded in fetch_runs
+	// under run_id.
+	GetRunStatus(ctx context.Context, in *GetRunStatusRequest, opts ...grpc.CallOption) (*RunStatus, error)
+	// ListVideoStats returns the latest snapshot of each video of a channel
+	// in a date range, like GET /api/v1/channels/{id}/videos.
+	ListVideoStats(ctx context.Context, in *ListVideoStatsRequest, opts ...grpc.CallOption) (*ListVideoStatsResponse, error)
+}
+
+type trackerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrackerServiceClient(cc grpc.ClientConnInterface) TrackerServiceClient {
+	return &trackerServiceClient{cc}
+}
+
+func (c *trackerServiceClient) TriggerFetch(ctx context.Context, in *TriggerFetchRequest, opts ...grpc.CallOption) (*TriggerFetchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerFetchResponse)
+	err := c.cc.Invoke(ctx, TrackerService_TriggerFetch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) GetRunStatus(ctx context.Context, in *GetRunStatusRequest, opts ...grpc.CallOption) (*RunStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunStatus)
+	err := c.cc.Invoke(ctx, TrackerService_GetRunStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) ListVideoStats(ctx context.Context, in *ListVideoStatsRequest, opts ...grpc.CallOption) (*ListVideoStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVideoStatsResponse)
+	err := c.cc.Invoke(ctx, TrackerService_ListVideoStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TrackerServiceServer is the server API for TrackerService service.
+// All implementations must embed UnimplementedTrackerServiceServer
+// for forward compatibility.
+//
+// TrackerService is the gRPC counterpart of the fetcher's HTTP API. Each
+// method runs the same code as the REST endpoint in its google.api.http
+// option, so a gateway (e.g. grpc-gateway or ESPv2) can serve both.
+type TrackerServiceServer interface {
+	// TriggerFetch fetches the channels that are due, like POST /. It returns
+	// once the run has finished.
+	TriggerFetch(context.Context, *TriggerFetchRequest) (*TriggerFetchResponse, error)
+	// GetRunStatus returns a run: the latest one handled by the serving
+	// instance, like GET /api/v1/status, or the one recorded in fetch_runs
+	// under run_id.
+	GetRunStatus(context.Context, *GetRunStatusRequest) (*RunStatus, error)
+	// ListVideoStats returns the latest snapshot of each video of a channel
+	// in a date range, like GET /api/v1/channels/{id}/videos.
+	ListVideoStats(context.Context, *ListVideoStatsRequest) (*ListVideoStatsResponse, error)
+	mustEmbedUnimplementedTrackerServiceServer()
+}
+
+// UnimplementedTrackerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTrackerServiceServer struct{}
+
+func (UnimplementedTrackerServiceServer) TriggerFetch(context.Context, *TriggerFetchRequest) (*TriggerFetchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TriggerFetch not implemented")
+}
+func (UnimplementedTrackerServiceServer) GetRunStatus(context.Context, *GetRunStatusRequest) (*RunStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRunStatus not implemented")
+}
+func (UnimplementedTrackerServiceServer) ListVideoStats(context.Context, *ListVideoStatsRequest) (*ListVideoStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListVideoStats not implemented")
+}
+func (UnimplementedTrackerServiceServer) mustEmbedUnimplementedTrackerServiceServer() {}
+func (UnimplementedTrackerServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeTrackerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TrackerServiceServer will
+// result in compilation errors.
+type UnsafeTrackerServiceServer interface {
+	mustEmbedUnimplementedTrackerServiceServer()
+}
+
+func RegisterTrackerServiceServer(s grpc.ServiceRegistrar, srv TrackerServiceServer) {
+	// If the following call panics, it indicates UnimplementedTrackerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TrackerService_ServiceDesc, srv)
+}
+
+func _TrackerService_TriggerFetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerFetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).TriggerFetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_TriggerFetch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).TriggerFetch(ctx, req.(*TriggerFetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_GetRunStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).GetRunStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_GetRunStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).GetRunStatus(ctx, req.(*GetRunStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_ListVideoStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVideoStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).ListVideoStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_ListVideoStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).ListVideoStats(ctx, req.(*ListVideoStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TrackerService_ServiceDesc is the grpc.ServiceDesc for TrackerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TrackerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tracker.v1.TrackerService",
+	HandlerType: (*TrackerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TriggerFetch",
+			Handler:    _TrackerService_TriggerFetch_Handler,
+		},
+		{
+			MethodName: "GetRunStatus",
+			Handler:    _TrackerService_GetRunStatus_Handler,
+		},
+		{
+			MethodName: "ListVideoStats",
+			Handler:    _TrackerService_ListVideoStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tracker/v1/tracker.proto",
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/channelsource"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// adminTokenHeader carries server.admin_token on /admin requests. It is not
+// Authorization, which Cloud Run uses for its own IAM check.
+const adminTokenHeader = "X-Admin-Token"
+
+// configPath is the configuration file given by --config, which the admin
+// API rewrites when the channels come from it.
+var configPath = "configs/config.yaml"
+
+var (
+	// adminChannelsMu serializes the edits of the channel list, each of
+	// which reads, changes and writes back the whole list.
+	adminChannelsMu sync.Mutex
+
+	// fileChannels is the channel list last written to configPath, which
+	// replaces the one loaded at startup.
+	fileChannels struct {
+		sync.Mutex
+		channels []config.ChannelConfig
+		saved    bool
+	}
+)
+
+// savedFileChannels returns the channel list last written to the
+// configuration file by the admin API, if any.
+func savedFileChannels() ([]config.ChannelConfig, bool) {
+	fileChannels.Lock()
+	defer fileChannels.Unlock()
+	return fileChannels.channels, fileChannels.saved
+}
+
+// requireAdmin rejects a request without server.admin_token in the
+// X-Admin-Token header. With no token configured the endpoints do not exist.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := cfg.Server.AdminToken
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(adminTokenHeader)), []byte(token)) != 1 {
+			log.Warning("Rejecting admin request: missing or wrong token", nil, map[string]string{
+				"path":       r.URL.Path,
+				"request_id": r.Header.Get(requestIDHeader),
+			})
+			writeJSONStatus(w, http.StatusUnauthorized, statusError{Status: "unauthorized", Error: "missing or wrong " + adminTokenHeader})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// channelListConflict returns why the channel list must not be saved to the
+// configuration file, or "" if it can be. Only a list that file defines can
+// be rewritten in it: one given with --channels is a subset of the
+// configured list, and one from a profile or an included file would
+// override the edit on the next load.
+func channelListConflict() string {
+	if cfg.App.ChannelConfigSource != "" {
+		return ""
+	}
+	from := cfg.ChannelsFrom()
+	if from == "--channels" {
+		return "The channel list was given with --channels; edit " + configPath + " directly"
+	}
+	if from == "" {
+		return ""
+	}
+	want, err1 := filepath.Abs(configPath)
+	got, err2 := filepath.Abs(from)
+	if err1 != nil || err2 != nil || want != got {
+		return fmt.Sprintf("The channel list is defined in %s, not %s; edit it directly", from, configPath)
+	}
+	return ""
+}
+
+// adminChannelsResponse is the body of the /admin/channels endpoints: where
+// the channel list is kept and its content.
+type adminChannelsResponse struct {
+	Source   string                 `json:"source"`
+	Channels []config.ChannelConfig `json:"channels"`
+}
+
+// channelListSource names where the channel list is read from and saved to.
+func channelListSource() string {
+	if cfg.App.ChannelConfigSource != "" {
+		return cfg.App.ChannelConfigSource
+	}
+	return configPath
+}
+
+// saveChannelList writes channels to where the channel list is kept: the
+// channel source if configured, otherwise the configuration file, whose
+// channels section is rewritten in place.
+func saveChannelList(ctx context.Context, channels []config.ChannelConfig) error {
+	if cfg.App.ChannelConfigSource != "" {
+		src, err := sharedChannelSource(ctx)
+		if err != nil {
+			return err
+		}
+		return src.Save(ctx, channels)
+	}
+
+	entries, err := channelEntriesYAML(channels)
+	if err != nil {
+		return err
+	}
+	orig, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	updated, err := replaceChannelEntries(orig, entries)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configPath, err)
+	}
+	if err := os.WriteFile(configPath, updated, 0o644); err != nil {
+		return err
+	}
+	fileChannels.Lock()
+	fileChannels.channels = channels
+	fileChannels.saved = true
+	fileChannels.Unlock()
+	return nil
+}
+
+// adminChannelsHandler serves GET /admin/channels: the tracked channel list.
+func adminChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := requestContext(r, "")
+	c, err := currentConfig(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("Error loading channel list", err, nil)
+		http.Error(w, "Failed to load channel list", http.StatusInternalServerError)
+		return
+	}
+	writeJSONStatus(w, http.StatusOK, adminChannelsResponse{Source: channelListSource(), Channels: c.Channels})
+}
+
+// adminAddChannelHandler serves POST /admin/channels: it adds the channel
+// in the body, or replaces the one with the same ID, and saves the list.
+func adminAddChannelHandler(w http.ResponseWriter, r *http.Request) {
+	var ch config.ChannelConfig
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ch); err != nil {
+		http.Error(w, "Invalid channel: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ch.ID == "" {
+		http.Error(w, "Invalid channel: id is required", http.StatusBadRequest)
+		return
+	}
+
+	code := http.StatusOK
+	editChannelList(w, r, func(channels []config.ChannelConfig) ([]config.ChannelConfig, bool) {
+		i := slices.IndexFunc(channels, func(c config.ChannelConfig) bool { return c.ID == ch.ID })
+		if i < 0 {
+			code = http.StatusCreated
+			return append(channels, ch), true
+		}
+		channels[i] = ch
+		return channels, true
+	}, func(ctx context.Context, channels []config.ChannelConfig) {
+		action := "updated"
+		if code == http.StatusCreated {
+			action = "added"
+		}
+		logger.FromContext(ctx).Info("Channel "+action+" through the admin API", map[string]string{"channel_id": ch.ID})
+		writeJSONStatus(w, code, adminChannelsResponse{Source: channelListSource(), Channels: channels})
+	})
+}
+
+// adminDeleteChannelHandler serves DELETE /admin/channels?id=: it removes
+// the channel from the list and saves it.
+func adminDeleteChannelHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+	editChannelList(w, r, func(channels []config.ChannelConfig) ([]config.ChannelConfig, bool) {
+		i := slices.IndexFunc(channels, func(c config.ChannelConfig) bool { return c.ID == id })
+		if i < 0 {
+			return nil, false
+		}
+		return slices.Delete(channels, i, i+1), true
+	}, func(ctx context.Context, channels []config.ChannelConfig) {
+		logger.FromContext(ctx).Info("Channel removed through the admin API", map[string]string{"channel_id": id})
+		writeJSONStatus(w, http.StatusOK, adminChannelsResponse{Source: channelListSource(), Channels: channels})
+	})
+}
+
+// editChannelList applies edit to a copy of the current channel list,
+// validates and saves the result, and calls done with it. edit returns
+// false when the channel to change does not exist, answered with 404.
+func editChannelList(w http.ResponseWriter, r *http.Request, edit func([]config.ChannelConfig) ([]config.ChannelConfig, bool), done func(context.Context, []config.ChannelConfig)) {
+	ctx := requestContext(r, "")
+	log := logger.FromContext(ctx)
+
+	if msg := channelListConflict(); msg != "" {
+		http.Error(w, msg, http.StatusConflict)
+		return
+	}
+
+	adminChannelsMu.Lock()
+	defer adminChannelsMu.Unlock()
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, nil)
+		http.Error(w, "Failed to load channel list", http.StatusInternalServerError)
+		return
+	}
+	channels, ok := edit(slices.Clone(c.Channels))
+	if !ok {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+	if err := config.ValidateChannels(channels); err != nil {
+		http.Error(w, "Invalid channel list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = saveChannelList(ctx, channels)
+	switch {
+	case errors.Is(err, channelsource.ErrReadOnly):
+		http.Error(w, "The channel source is read-only; edit it directly", http.StatusNotImplemented)
+		return
+	case errors.Is(err, channelsource.ErrUnsupported):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, channelsource.ErrConflict):
+		http.Error(w, "The channel list changed meanwhile; retry", http.StatusConflict)
+		return
+	case err != nil:
+		log.Error("Error saving channel list", err, map[string]string{"source": channelListSource()})
+		http.Error(w, "Failed to save channel list", http.StatusInternalServerError)
+		return
+	}
+	done(ctx, channels)
+}
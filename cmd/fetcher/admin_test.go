@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/channelsource"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/spf13/pflag"
+)
+
+const adminTestConfig = `app:
+  run_mode: server
+
+# Channels to track
+channels:
+  - id: UC1xxxxxxxxxxxxxxxxxxxxx
+    name: One
+    enabled: true
+
+keywords: []
+`
+
+// useAdminConfig points the admin API at a temporary configuration file
+// with one channel and returns its path and a mux serving the endpoints.
+func useAdminConfig(t *testing.T, token string) (string, *http.ServeMux) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(adminTestConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	originalCfg, originalPath := cfg, configPath
+	cfg = config.DefaultConfig()
+	cfg.Channels = []config.ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Name: "One", Enabled: true}}
+	cfg.Server.AdminToken = token
+	configPath = path
+	t.Cleanup(func() {
+		cfg, configPath = originalCfg, originalPath
+		fileChannels.Lock()
+		fileChannels.channels, fileChannels.saved = nil, false
+		fileChannels.Unlock()
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/channels", requireAdmin(adminChannelsHandler))
+	mux.HandleFunc("POST /admin/channels", requireAdmin(adminAddChannelHandler))
+	mux.HandleFunc("DELETE /admin/channels", requireAdmin(adminDeleteChannelHandler))
+	return path, mux
+}
+
+func adminRequest(mux *http.ServeMux, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set(adminTokenHeader, token)
+	}
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminChannels_Token(t *testing.T) {
+	_, mux := useAdminConfig(t, "")
+	if rr := adminRequest(mux, http.MethodGet, "/admin/channels", "anything", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("without admin_token status = %d, want 404", rr.Code)
+	}
+
+	cfg.Server.AdminToken = "s3cret"
+	if rr := adminRequest(mux, http.MethodGet, "/admin/channels", "", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("without header status = %d, want 401", rr.Code)
+	}
+	if rr := adminRequest(mux, http.MethodGet, "/admin/channels", "wrong", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("with a wrong token status = %d, want 401", rr.Code)
+	}
+	rr := adminRequest(mux, http.MethodGet, "/admin/channels", "s3cret", "")
+	var got adminChannelsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, body %s", rr.Code, rr.Body)
+	}
+	if got.Source != configPath || len(got.Channels) != 1 || got.Channels[0].ID != "UC1xxxxxxxxxxxxxxxxxxxxx" {
+		t.Errorf("GET = %+v, want UC1 from %s", got, configPath)
+	}
+}
+
+func TestAdminChannels_EditsConfigFile(t *testing.T) {
+	path, mux := useAdminConfig(t, "s3cret")
+	ctx := context.Background()
+
+	rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC2xxxxxxxxxxxxxxxxxxxxx", "name": "Two", "enabled": true}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST new channel status = %d: %s", rr.Code, rr.Body)
+	}
+	rr = adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC1xxxxxxxxxxxxxxxxxxxxx", "name": "One", "enabled": false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("POST existing channel status = %d: %s", rr.Code, rr.Body)
+	}
+
+	// The file keeps its other sections and comments.
+	saved, err := config.LoadUnvalidated(path)
+	if err != nil {
+		t.Fatalf("reloading the file: %v", err)
+	}
+	if len(saved.Channels) != 2 || saved.Channels[0].Enabled || saved.Channels[1].Name != "Two" {
+		t.Errorf("file channels = %+v, want UC1 disabled and UC2", saved.Channels)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "# Channels to track") || !strings.Contains(string(data), "keywords: []") {
+		t.Errorf("file lost its other content:\n%s", data)
+	}
+	// Runs use the saved list.
+	if c, _ := currentConfig(ctx); len(c.Channels) != 2 {
+		t.Errorf("currentConfig() channels = %+v, want 2", c.Channels)
+	}
+
+	// The last enabled channel cannot be removed.
+	if rr := adminRequest(mux, http.MethodDelete, "/admin/channels?id=UC2xxxxxxxxxxxxxxxxxxxxx", "s3cret", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("DELETE last enabled channel status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(mux, http.MethodDelete, "/admin/channels?id=UC1xxxxxxxxxxxxxxxxxxxxx", "s3cret", ""); rr.Code != http.StatusOK {
+		t.Errorf("DELETE status = %d: %s", rr.Code, rr.Body)
+	}
+	if rr := adminRequest(mux, http.MethodDelete, "/admin/channels?id=UC1xxxxxxxxxxxxxxxxxxxxx", "s3cret", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("DELETE missing channel status = %d, want 404", rr.Code)
+	}
+	if c, _ := currentConfig(ctx); len(c.Channels) != 1 || c.Channels[0].ID != "UC2xxxxxxxxxxxxxxxxxxxxx" {
+		t.Errorf("currentConfig() channels = %+v, want only UC2", c.Channels)
+	}
+
+	if rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC3", "enabled": true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("POST invalid ID status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC3xxxxxxxxxxxxxxxxxxxxx", "enable": true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("POST unknown field status = %d, want 400", rr.Code)
+	}
+}
+
+func TestAdminChannels_ListNotInConfigFile(t *testing.T) {
+	path, mux := useAdminConfig(t, "s3cret")
+	profile := config.ProfilePath(path, "staging")
+	if err := os.WriteFile(profile, []byte("channels:\n  - id: UC9xxxxxxxxxxxxxxxxxxxxx\n    enabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		goEnv string
+		args  []string
+	}{
+		{"profile", "staging", []string{"--config", path}},
+		{"--channels", "", []string{"--config", path, "--channels", "UC1xxxxxxxxxxxxxxxxxxxxx"}},
+	} {
+		t.Setenv("GO_ENV", tc.goEnv)
+		fs := pflag.NewFlagSet("fetcher", pflag.ContinueOnError)
+		flags := config.BindFlags(fs)
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.LoadUnvalidated(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := flags.Apply(c); err != nil {
+			t.Fatal(err)
+		}
+		c.Server.AdminToken = "s3cret"
+		cfg = c
+
+		rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC2xxxxxxxxxxxxxxxxxxxxx", "enabled": true}`)
+		if rr.Code != http.StatusConflict {
+			t.Errorf("%s: POST status = %d, want 409", tc.name, rr.Code)
+		}
+		if data, _ := os.ReadFile(path); string(data) != adminTestConfig {
+			t.Errorf("%s: config file rewritten:\n%s", tc.name, data)
+		}
+	}
+
+	// Without the profile the file defines the list, which can be edited.
+	t.Setenv("GO_ENV", "")
+	c, err := config.LoadUnvalidated(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Server.AdminToken = "s3cret"
+	cfg = c
+	if rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC2xxxxxxxxxxxxxxxxxxxxx", "enabled": true}`); rr.Code != http.StatusCreated {
+		t.Errorf("POST status = %d, want 201: %s", rr.Code, rr.Body)
+	}
+}
+
+func TestAdminChannels_ReadOnlySource(t *testing.T) {
+	_, mux := useAdminConfig(t, "s3cret")
+	cfg.App.ChannelConfigSource = "sheets://sheet/Channels!A:F"
+	originalSource := channelSource
+	channelSource = channelsource.NewSource(channelsource.FromRows(rowsFunc(func() [][]interface{} {
+		return [][]interface{}{{"id"}, {"UC1xxxxxxxxxxxxxxxxxxxxx"}}
+	})), 0)
+	t.Cleanup(func() { channelSource = originalSource })
+
+	rr := adminRequest(mux, http.MethodPost, "/admin/channels", "s3cret", `{"id": "UC2xxxxxxxxxxxxxxxxxxxxx", "enabled": true}`)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("POST to a spreadsheet source status = %d, want 501", rr.Code)
+	}
+}
+
+// rowsFunc is a channelsource.RowReader returning fixed rows.
+type rowsFunc func() [][]interface{}
+
+func (f rowsFunc) ReadRows(ctx context.Context) ([][]interface{}, error) { return f(), nil }
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// Query API defaults and limits.
+const (
+	apiDefaultRangeDays = 30
+	apiMaxRangeDays     = 366
+	apiDefaultLimit     = 50
+	apiMaxLimit         = 500
+	// apiMaxCompareChannels bounds the channels of one comparison.
+	apiMaxCompareChannels = 10
+	// A cohort query covers weeks of publications and follows them up to
+	// max_age days.
+	apiDefaultCohortWeeks = 12
+	apiMaxCohortWeeks     = 26
+	apiDefaultCohortAge   = 28
+	apiMaxCohortAge       = 90
+)
+
+// trendQuerier runs the read queries behind the query API.
+type trendQuerier interface {
+	ChannelSummaries(ctx context.Context, date civil.Date) ([]storage.ChannelSummary, error)
+	ChannelVideos(ctx context.Context, channelID string, from, to civil.Date, limit int) ([]storage.VideoSummary, error)
+	VideoTimeseries(ctx context.Context, videoID string, from, to civil.Date) ([]storage.TimeseriesPoint, error)
+	TopVideos(ctx context.Context, date civil.Date, metric string, limit int) ([]storage.VideoSummary, error)
+	RecentRuns(ctx context.Context, limit int) ([]storage.FetchRunRecord, error)
+	CompareChannels(ctx context.Context, channelIDs []string, from, to civil.Date, timezone string) ([]storage.ChannelDayStats, error)
+	TrendingVideos(ctx context.Context, date civil.Date, limit int) ([]storage.TrendingVideo, error)
+	PublishTimeStats(ctx context.Context, date civil.Date, channelID string) ([]storage.PublishTimeStatsRecord, error)
+	PublishWeekCohorts(ctx context.Context, channelID string, from, to civil.Date, maxAge int, timezone string) ([]storage.CohortPoint, error)
+}
+
+// newTrendQuerier creates the querier for a request; tests replace it.
+var newTrendQuerier = func(ctx context.Context) (trendQuerier, error) {
+	return newBigQueryWriter(cfg)
+}
+
+// Query API responses. They are also the schemas of the OpenAPI document
+// served at /openapi.json.
+type (
+	apiStatus struct {
+		LastRun  *runStatus `json:"last_run"`
+		Channels int        `json:"channels"`
+		Keywords int        `json:"keywords"`
+		Version  string     `json:"version"`
+	}
+	channelSummariesResponse struct {
+		Date     civil.Date               `json:"date"`
+		Channels []storage.ChannelSummary `json:"channels"`
+	}
+	channelVideosResponse struct {
+		ChannelID string                 `json:"channel_id"`
+		From      civil.Date             `json:"from"`
+		To        civil.Date             `json:"to"`
+		Videos    []storage.VideoSummary `json:"videos"`
+	}
+	videoTimeseriesResponse struct {
+		VideoID string                    `json:"video_id"`
+		From    civil.Date                `json:"from"`
+		To      civil.Date                `json:"to"`
+		Points  []storage.TimeseriesPoint `json:"points"`
+	}
+	publishTimesResponse struct {
+		ChannelID string                           `json:"channel_id"`
+		Date      civil.Date                       `json:"date"`
+		Slots     []storage.PublishTimeStatsRecord `json:"slots"`
+	}
+	topVideosResponse struct {
+		Date   civil.Date             `json:"date"`
+		Metric string                 `json:"metric"`
+		Videos []storage.VideoSummary `json:"videos"`
+	}
+	compareResponse struct {
+		From     civil.Date       `json:"from"`
+		To       civil.Date       `json:"to"`
+		Dates    []civil.Date     `json:"dates"`
+		Channels []*channelSeries `json:"channels"`
+	}
+	cohortsResponse struct {
+		ChannelID string          `json:"channel_id,omitempty"`
+		From      civil.Date      `json:"from"`
+		To        civil.Date      `json:"to"`
+		Ages      []int           `json:"ages"`
+		Cohorts   []*cohortSeries `json:"cohorts"`
+	}
+	runsResponse struct {
+		Runs []storage.FetchRunRecord `json:"runs"`
+	}
+)
+
+// registerAPI adds the read-only query API and the trending feed to mux.
+func registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/status", statusHandler)
+	mux.HandleFunc("GET /api/v1/channels", channelSummariesHandler)
+	mux.HandleFunc("GET /api/v1/channels/{id}/videos", channelVideosHandler)
+	mux.HandleFunc("GET /api/v1/channels/{id}/publish-times", publishTimesHandler)
+	mux.HandleFunc("GET /api/v1/videos/{id}/timeseries", videoTimeseriesHandler)
+	mux.HandleFunc("GET /api/v1/top", topVideosHandler)
+	mux.HandleFunc("GET /api/v1/compare", compareHandler)
+	mux.HandleFunc("GET /api/v1/cohorts", cohortsHandler)
+	mux.HandleFunc("GET /runs", runsHandler)
+	mux.HandleFunc("GET /feeds/trending.xml", trendingFeedHandler)
+}
+
+// statusHandler serves GET /api/v1/status: the latest run handled by this
+// instance and the number of enabled channels and keywords. Channels are
+// counted from the list a fetch would use, which may come from
+// app.channel_config_source or the admin API rather than the config file.
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := currentConfig(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Error loading channel list", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+		http.Error(w, "Failed to load channel list", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiStatus{
+		LastRun:  lastRun.get(),
+		Channels: len(c.GetEnabledChannelIDs()),
+		Keywords: len(c.GetEnabledKeywords()),
+		Version:  version,
+	})
+}
+
+// channelSummariesHandler serves GET /api/v1/channels?date=
+func channelSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	date, err := dateParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		channels, err := q.ChannelSummaries(ctx, date)
+		return &channelSummariesResponse{Date: date, Channels: channels}, err
+	})
+}
+
+// channelVideosHandler serves GET /api/v1/channels/{id}/videos?from=&to=&limit=
+func channelVideosHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := limitParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	channelID := r.PathValue("id")
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		videos, err := q.ChannelVideos(ctx, channelID, from, to, limit)
+		return &channelVideosResponse{ChannelID: channelID, From: from, To: to, Videos: videos}, err
+	})
+}
+
+// publishTimesHandler serves GET /api/v1/channels/{id}/publish-times?date=:
+// the early view velocity of the channel's recent videos by publish weekday
+// and hour, as last computed by analytics.publish_times up to date, best
+// slot first.
+func publishTimesHandler(w http.ResponseWriter, r *http.Request) {
+	date, err := dateParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	channelID := r.PathValue("id")
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		slots, err := q.PublishTimeStats(ctx, date, channelID)
+		return &publishTimesResponse{ChannelID: channelID, Date: date, Slots: slots}, err
+	})
+}
+
+// videoTimeseriesHandler serves GET /api/v1/videos/{id}/timeseries?from=&to=
+func videoTimeseriesHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	videoID := r.PathValue("id")
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		points, err := q.VideoTimeseries(ctx, videoID, from, to)
+		return &videoTimeseriesResponse{VideoID: videoID, From: from, To: to, Points: points}, err
+	})
+}
+
+// topVideosHandler serves GET /api/v1/top?date=&metric=views&limit=
+func topVideosHandler(w http.ResponseWriter, r *http.Request) {
+	date, err := dateParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = "views"
+	}
+	if !storage.TopMetrics[metric] {
+		http.Error(w, fmt.Sprintf("invalid metric %q (want views, likes or comments)", metric), http.StatusBadRequest)
+		return
+	}
+	limit, err := limitParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		videos, err := q.TopVideos(ctx, date, metric, limit)
+		return &topVideosResponse{Date: date, Metric: metric, Videos: videos}, err
+	})
+}
+
+// channelSeries is one channel's comparison series, aligned with the dates
+// of the response. Missing values are null.
+type channelSeries struct {
+	ChannelID      string                 `json:"channel_id"`
+	ChannelName    string                 `json:"channel_name"`
+	Uploads        []int64                `json:"uploads"`
+	ViewsGained    []bigquery.NullInt64   `json:"views_gained"`
+	EngagementRate []bigquery.NullFloat64 `json:"engagement_rate"`
+}
+
+// compareHandler serves GET /api/v1/compare?channels=a,b,c&from=&to=: daily
+// uploads, views gained and engagement rate of each channel, aligned on the
+// same dates.
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var channelIDs []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(r.URL.Query().Get("channels"), ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			channelIDs = append(channelIDs, id)
+		}
+	}
+	if len(channelIDs) == 0 || len(channelIDs) > apiMaxCompareChannels {
+		http.Error(w, fmt.Sprintf("channels must list 1 to %d channel IDs", apiMaxCompareChannels), http.StatusBadRequest)
+		return
+	}
+
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		rows, err := q.CompareChannels(ctx, channelIDs, from, to, cfg.App.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		dates, series := compareSeries(channelIDs, from, to, rows)
+		return &compareResponse{From: from, To: to, Dates: dates, Channels: series}, nil
+	})
+}
+
+// compareSeries arranges rows into one series per channel, in the order of
+// channelIDs, with a value for every date from from to to.
+func compareSeries(channelIDs []string, from, to civil.Date, rows []storage.ChannelDayStats) ([]civil.Date, []*channelSeries) {
+	var dates []civil.Date
+	index := make(map[civil.Date]int)
+	for d := from; !d.After(to); d = d.AddDays(1) {
+		index[d] = len(dates)
+		dates = append(dates, d)
+	}
+
+	byID := make(map[string]*channelSeries, len(channelIDs))
+	series := make([]*channelSeries, len(channelIDs))
+	for i, id := range channelIDs {
+		series[i] = &channelSeries{
+			ChannelID:      id,
+			Uploads:        make([]int64, len(dates)),
+			ViewsGained:    make([]bigquery.NullInt64, len(dates)),
+			EngagementRate: make([]bigquery.NullFloat64, len(dates)),
+		}
+		byID[id] = series[i]
+	}
+	for _, row := range rows {
+		s, ok := byID[row.ChannelID]
+		i, inRange := index[row.Dt]
+		if !ok || !inRange {
+			continue
+		}
+		if s.ChannelName == "" {
+			s.ChannelName = row.ChannelName
+		}
+		s.Uploads[i] = row.Uploads
+		s.ViewsGained[i] = row.ViewsGained
+		s.EngagementRate[i] = row.EngagementRate
+	}
+	return dates, series
+}
+
+// cohortSeries is the cumulative views of one week's publications, aligned
+// with the ages of the response. Ages without a snapshot are null.
+type cohortSeries struct {
+	PublishWeek civil.Date `json:"publish_week"`
+	// Videos counts the videos published in the week, and TrackedVideos
+	// those with a snapshot at each age.
+	Videos        int64                  `json:"videos"`
+	TrackedVideos []int64                `json:"tracked_videos"`
+	AvgViews      []bigquery.NullFloat64 `json:"avg_views"`
+	MedianViews   []bigquery.NullInt64   `json:"median_views"`
+}
+
+// cohortsHandler serves GET /api/v1/cohorts?channel=&to=&weeks=&max_age=:
+// the videos published in each of the weeks (from Monday, in app.timezone)
+// up to the week of to, with their average and median views at each age
+// from 0 to max_age days, so that recent weeks can be compared with older
+// ones. Without channel, every channel's videos are included.
+func cohortsHandler(w http.ResponseWriter, r *http.Request) {
+	to, err := dateParamNamed(r, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	weeks, err := intParam(r, "weeks", apiDefaultCohortWeeks, 1, apiMaxCohortWeeks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxAge, err := intParam(r, "max_age", apiDefaultCohortAge, 0, apiMaxCohortAge)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	channelID := strings.TrimSpace(r.URL.Query().Get("channel"))
+	last := weekStart(to)
+	first := last.AddDays(-7 * (weeks - 1))
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		points, err := q.PublishWeekCohorts(ctx, channelID, first, last, maxAge, cfg.App.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		ages, cohorts := cohortSeriesOf(first, last, maxAge, points)
+		return &cohortsResponse{ChannelID: channelID, From: first, To: last, Ages: ages, Cohorts: cohorts}, nil
+	})
+}
+
+// weekStart returns the Monday of d's week.
+func weekStart(d civil.Date) civil.Date {
+	weekday := d.In(time.UTC).Weekday()
+	return d.AddDays(-(int(weekday) + 6) % 7)
+}
+
+// cohortSeriesOf arranges points into one series per week from the Monday
+// first to the Monday last, with a value for every age from 0 to maxAge.
+func cohortSeriesOf(first, last civil.Date, maxAge int, points []storage.CohortPoint) ([]int, []*cohortSeries) {
+	ages := make([]int, maxAge+1)
+	for i := range ages {
+		ages[i] = i
+	}
+	byWeek := make(map[civil.Date]*cohortSeries)
+	var cohorts []*cohortSeries
+	for week := first; !week.After(last); week = week.AddDays(7) {
+		s := &cohortSeries{
+			PublishWeek:   week,
+			TrackedVideos: make([]int64, len(ages)),
+			AvgViews:      make([]bigquery.NullFloat64, len(ages)),
+			MedianViews:   make([]bigquery.NullInt64, len(ages)),
+		}
+		byWeek[week] = s
+		cohorts = append(cohorts, s)
+	}
+	for _, p := range points {
+		s, ok := byWeek[p.PublishWeek]
+		if !ok || p.AgeDays < 0 || p.AgeDays > int64(maxAge) {
+			continue
+		}
+		s.Videos = p.CohortVideos
+		s.TrackedVideos[p.AgeDays] = p.Videos
+		s.AvgViews[p.AgeDays] = bigquery.NullFloat64{Float64: p.AvgViews, Valid: true}
+		s.MedianViews[p.AgeDays] = bigquery.NullInt64{Int64: p.MedianViews, Valid: true}
+	}
+	return ages, cohorts
+}
+
+// runsHandler serves GET /runs?limit=: the most recent runs recorded in the
+// fetch_runs table by any instance, newest first.
+func runsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := limitParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	serveQuery(w, r, func(ctx context.Context, q trendQuerier) (interface{}, error) {
+		runs, err := q.RecentRuns(ctx, limit)
+		return &runsResponse{Runs: runs}, err
+	})
+}
+
+// serveQuery runs query against a new querier and writes its result as JSON.
+// With a query API cache, a cached response is served instead when there is
+// one, and a fresh one is cached; the X-Cache header tells which. Cache
+// errors are logged and the query runs as without a cache.
+func serveQuery(w http.ResponseWriter, r *http.Request, query func(context.Context, trendQuerier) (interface{}, error)) {
+	ctx := requestContext(r, "")
+	log := logger.FromContext(ctx)
+
+	cache, key := apiResponseCache, apiCacheKey(r)
+	if cache != nil {
+		body, ok, err := cache.Get(ctx, key)
+		if err != nil {
+			log.Warning("Failed to read the query API cache", err, map[string]string{"path": r.URL.Path})
+		} else if ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Cache", "HIT")
+			w.Write(body)
+			return
+		}
+	}
+
+	q, err := newTrendQuerier(ctx)
+	if err != nil {
+		log.Error("Error creating BigQuery client", err, nil)
+		http.Error(w, "Failed to create BigQuery client", http.StatusInternalServerError)
+		return
+	}
+	result, err := query(ctx, q)
+	if err != nil {
+		log.Error("Query API request failed", err, map[string]string{"path": r.URL.Path})
+		http.Error(w, "Query failed", http.StatusInternalServerError)
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		log.Error("Failed to encode query API response", err, map[string]string{"path": r.URL.Path})
+		http.Error(w, "Query failed", http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+
+	w.Header().Set("Content-Type", "application/json")
+	if cache != nil {
+		w.Header().Set("X-Cache", "MISS")
+		if err := cache.Set(ctx, key, body, cfg.Server.APICacheTTL); err != nil {
+			log.Warning("Failed to write the query API cache", err, map[string]string{"path": r.URL.Path})
+		}
+	}
+	w.Write(body)
+}
+
+// today returns the current date in the configured timezone.
+func today() civil.Date {
+	loc := time.UTC
+	if cfg != nil {
+		loc = cfg.Location()
+	}
+	return civil.DateOf(time.Now().In(loc))
+}
+
+// dateParam parses the date query parameter, which defaults to today.
+func dateParam(r *http.Request) (civil.Date, error) {
+	return dateParamNamed(r, "date")
+}
+
+// dateParamNamed parses the date query parameter name, which defaults to
+// today.
+func dateParamNamed(r *http.Request, name string) (civil.Date, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return today(), nil
+	}
+	d, err := civil.ParseDate(v)
+	if err != nil {
+		return d, fmt.Errorf("invalid %s %q (want YYYY-MM-DD)", name, v)
+	}
+	return d, nil
+}
+
+// dateRange parses the from and to query parameters. to defaults to today
+// and from to apiDefaultRangeDays before to.
+func dateRange(r *http.Request) (from, to civil.Date, err error) {
+	return parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+}
+
+// parseDateRange parses a from and to date given as YYYY-MM-DD, either
+// of which may be empty for its default.
+func parseDateRange(fromValue, toValue string) (from, to civil.Date, err error) {
+	to = today()
+	if toValue != "" {
+		if to, err = civil.ParseDate(toValue); err != nil {
+			return from, to, fmt.Errorf("invalid to %q (want YYYY-MM-DD)", toValue)
+		}
+	}
+	from = to.AddDays(-apiDefaultRangeDays)
+	if fromValue != "" {
+		if from, err = civil.ParseDate(fromValue); err != nil {
+			return from, to, fmt.Errorf("invalid from %q (want YYYY-MM-DD)", fromValue)
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("from must not be after to")
+	}
+	if to.DaysSince(from) > apiMaxRangeDays {
+		return from, to, fmt.Errorf("date range cannot exceed %d days", apiMaxRangeDays)
+	}
+	return from, to, nil
+}
+
+// limitParam parses the limit query parameter.
+func limitParam(r *http.Request) (int, error) {
+	return intParam(r, "limit", apiDefaultLimit, 1, apiMaxLimit)
+}
+
+// intParam parses the integer query parameter name, which must be between
+// min and max and defaults to def.
+func intParam(r *http.Request, name string, def, min, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, min, max)
+	}
+	return n, nil
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/pkg/client"
+)
+
+type fakeQuerier struct {
+	channelID string
+	channels  []string
+	from, to  civil.Date
+	metric    string
+	limit     int
+	maxAge    int
+}
+
+func (f *fakeQuerier) ChannelSummaries(ctx context.Context, date civil.Date) ([]storage.ChannelSummary, error) {
+	f.from = date
+	return []storage.ChannelSummary{{ChannelID: "UC1", Videos: 3}}, nil
+}
+
+func (f *fakeQuerier) ChannelVideos(ctx context.Context, channelID string, from, to civil.Date, limit int) ([]storage.VideoSummary, error) {
+	f.channelID, f.from, f.to, f.limit = channelID, from, to, limit
+	return []storage.VideoSummary{{Dt: to, VideoID: "v1", Views: 10}}, nil
+}
+
+func (f *fakeQuerier) VideoTimeseries(ctx context.Context, videoID string, from, to civil.Date) ([]storage.TimeseriesPoint, error) {
+	f.from, f.to = from, to
+	return []storage.TimeseriesPoint{{Views: 1}, {Views: 2}}, nil
+}
+
+func (f *fakeQuerier) RecentRuns(ctx context.Context, limit int) ([]storage.FetchRunRecord, error) {
+	f.limit = limit
+	return []storage.FetchRunRecord{{RunID: "run-2", Status: storage.RunStatusSuccess, QuotaUnits: 12}}, nil
+}
+
+func (f *fakeQuerier) TopVideos(ctx context.Context, date civil.Date, metric string, limit int) ([]storage.VideoSummary, error) {
+	f.from, f.metric, f.limit = date, metric, limit
+	return []storage.VideoSummary{}, nil
+}
+
+func (f *fakeQuerier) CompareChannels(ctx context.Context, channelIDs []string, from, to civil.Date, timezone string) ([]storage.ChannelDayStats, error) {
+	f.channels, f.from, f.to = channelIDs, from, to
+	return []storage.ChannelDayStats{
+		{ChannelID: "UC1", Dt: from, Uploads: 1, ViewsGained: bigquery.NullInt64{Int64: 500, Valid: true},
+			EngagementRate: bigquery.NullFloat64{Float64: 0.05, Valid: true}},
+		{ChannelID: "UC1", Dt: to},
+		{ChannelID: "UC2", ChannelName: "Two", Dt: to, ViewsGained: bigquery.NullInt64{Int64: 80, Valid: true}},
+	}, nil
+}
+
+func (f *fakeQuerier) TrendingVideos(ctx context.Context, date civil.Date, limit int) ([]storage.TrendingVideo, error) {
+	f.to, f.limit = date, limit
+	computed := time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC)
+	return []storage.TrendingVideo{
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ComputedAt: computed, ChannelID: "UC1", ChannelName: "One",
+			VideoID: "v1", Title: "Cats & dogs", Score: 12.5, Views: 123456, ViewsGained: 4000,
+			ThumbnailURL: "https://i.ytimg.com/vi/v1/hqdefault.jpg"},
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ComputedAt: computed, ChannelID: "UC2", VideoID: "v2", Score: 3},
+	}, nil
+}
+
+func (f *fakeQuerier) PublishTimeStats(ctx context.Context, date civil.Date, channelID string) ([]storage.PublishTimeStatsRecord, error) {
+	f.to, f.channelID = date, channelID
+	return []storage.PublishTimeStatsRecord{{Dt: date, ChannelID: channelID, Weekday: 5, Hour: 18, Videos: 3, ViewsPerHour24h: 420, Lift24h: 1.4}}, nil
+}
+
+func (f *fakeQuerier) PublishWeekCohorts(ctx context.Context, channelID string, from, to civil.Date, maxAge int, timezone string) ([]storage.CohortPoint, error) {
+	f.channelID, f.from, f.to, f.maxAge = channelID, from, to, maxAge
+	return []storage.CohortPoint{
+		{PublishWeek: from, CohortVideos: 4, AgeDays: 0, Videos: 4, AvgViews: 1000, MedianViews: 800},
+		{PublishWeek: from, CohortVideos: 4, AgeDays: 2, Videos: 3, AvgViews: 5000, MedianViews: 4500},
+		{PublishWeek: to, CohortVideos: 2, AgeDays: 0, Videos: 2, AvgViews: 300, MedianViews: 300},
+	}, nil
+}
+
+func serveAPI(t *testing.T, target string) (*httptest.ResponseRecorder, *fakeQuerier) {
+	t.Helper()
+	cfg = config.DefaultConfig()
+
+	fake := &fakeQuerier{}
+	orig := newTrendQuerier
+	newTrendQuerier = func(ctx context.Context) (trendQuerier, error) { return fake, nil }
+	t.Cleanup(func() { newTrendQuerier = orig })
+
+	mux := http.NewServeMux()
+	registerAPI(mux)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr, fake
+}
+
+func TestChannelVideosHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/api/v1/channels/UC1/videos?from=2025-08-01&to=2025-08-07&limit=5")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.channelID != "UC1" || fake.from.String() != "2025-08-01" || fake.to.String() != "2025-08-07" || fake.limit != 5 {
+		t.Errorf("query args = %+v", fake)
+	}
+
+	var body struct {
+		Videos []storage.VideoSummary `json:"videos"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Videos) != 1 || body.Videos[0].VideoID != "v1" {
+		t.Errorf("videos = %+v", body.Videos)
+	}
+}
+
+func TestPublishTimesHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/api/v1/channels/UC1/publish-times?date=2025-08-01")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.channelID != "UC1" || fake.to.String() != "2025-08-01" {
+		t.Errorf("query args = %+v", fake)
+	}
+	var body publishTimesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Slots) != 1 || body.Slots[0].Weekday != 5 || body.Slots[0].Lift24h != 1.4 {
+		t.Errorf("slots = %+v", body.Slots)
+	}
+}
+
+func TestVideoTimeseriesHandler_DefaultRange(t *testing.T) {
+	rr, fake := serveAPI(t, "/api/v1/videos/v1/timeseries?to=2025-08-31")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.from.String() != "2025-08-01" {
+		t.Errorf("from = %s, want 2025-08-01", fake.from)
+	}
+}
+
+func TestTopVideosHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/api/v1/top?date=2025-08-01&metric=likes")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.metric != "likes" || fake.limit != apiDefaultLimit {
+		t.Errorf("query args = %+v", fake)
+	}
+}
+
+func TestRunsHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/runs?limit=10")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.limit != 10 {
+		t.Errorf("limit = %d, want 10", fake.limit)
+	}
+	var body struct {
+		Runs []storage.FetchRunRecord `json:"runs"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Runs) != 1 || body.Runs[0].RunID != "run-2" || body.Runs[0].QuotaUnits != 12 {
+		t.Errorf("runs = %+v", body.Runs)
+	}
+}
+
+func TestCompareHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/api/v1/compare?channels=UC2,UC1,UC2&from=2025-08-01&to=2025-08-02")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if len(fake.channels) != 2 || fake.channels[0] != "UC2" || fake.channels[1] != "UC1" {
+		t.Errorf("channels = %v, want [UC2 UC1]", fake.channels)
+	}
+
+	var body struct {
+		Dates    []string `json:"dates"`
+		Channels []struct {
+			ChannelID      string     `json:"channel_id"`
+			ChannelName    string     `json:"channel_name"`
+			Uploads        []int64    `json:"uploads"`
+			ViewsGained    []*int64   `json:"views_gained"`
+			EngagementRate []*float64 `json:"engagement_rate"`
+		} `json:"channels"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Dates) != 2 || body.Dates[0] != "2025-08-01" || len(body.Channels) != 2 {
+		t.Fatalf("body = %s", rr.Body)
+	}
+	two, one := body.Channels[0], body.Channels[1]
+	if two.ChannelName != "Two" || two.ViewsGained[0] != nil || *two.ViewsGained[1] != 80 {
+		t.Errorf("UC2 series = %+v, want null then 80", two)
+	}
+	if one.Uploads[0] != 1 || *one.ViewsGained[0] != 500 || *one.EngagementRate[0] != 0.05 || one.EngagementRate[1] != nil {
+		t.Errorf("UC1 series = %+v", one)
+	}
+}
+
+func TestCohortsHandler(t *testing.T) {
+	// 2025-08-06 is a Wednesday.
+	rr, fake := serveAPI(t, "/api/v1/cohorts?channel=UC1&to=2025-08-06&weeks=2&max_age=3")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.channelID != "UC1" || fake.from.String() != "2025-07-28" || fake.to.String() != "2025-08-04" || fake.maxAge != 3 {
+		t.Errorf("query args = %+v", fake)
+	}
+
+	var body struct {
+		Ages    []int `json:"ages"`
+		Cohorts []struct {
+			PublishWeek   string     `json:"publish_week"`
+			Videos        int64      `json:"videos"`
+			TrackedVideos []int64    `json:"tracked_videos"`
+			AvgViews      []*float64 `json:"avg_views"`
+			MedianViews   []*int64   `json:"median_views"`
+		} `json:"cohorts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Ages) != 4 || len(body.Cohorts) != 2 {
+		t.Fatalf("body = %s", rr.Body)
+	}
+	older, recent := body.Cohorts[0], body.Cohorts[1]
+	if older.PublishWeek != "2025-07-28" || older.Videos != 4 || *older.AvgViews[2] != 5000 || older.AvgViews[1] != nil || older.TrackedVideos[2] != 3 {
+		t.Errorf("older cohort = %+v", older)
+	}
+	if recent.Videos != 2 || *recent.MedianViews[0] != 300 || recent.MedianViews[3] != nil {
+		t.Errorf("recent cohort = %+v", recent)
+	}
+
+	if _, fake := serveAPI(t, "/api/v1/cohorts?to=2025-08-04"); fake.channelID != "" || fake.from.String() != "2025-05-19" || fake.maxAge != apiDefaultCohortAge {
+		t.Errorf("default query args = %+v", fake)
+	}
+}
+
+func TestQueryAPI_BadRequest(t *testing.T) {
+	tests := []string{
+		"/api/v1/top?metric=title",
+		"/api/v1/top?date=yesterday",
+		"/api/v1/top?limit=0",
+		"/api/v1/channels/UC1/videos?from=2025-08-07&to=2025-08-01",
+		"/api/v1/channels/UC1/videos?from=2020-01-01&to=2025-08-01",
+		"/api/v1/videos/v1/timeseries?from=08/01/2025",
+		"/runs?limit=1000",
+		"/api/v1/compare",
+		"/api/v1/compare?channels=a,b,c,d,e,f,g,h,i,j,k",
+		"/api/v1/cohorts?weeks=0",
+		"/api/v1/cohorts?max_age=91",
+		"/api/v1/cohorts?to=soon",
+	}
+	for _, target := range tests {
+		if rr, _ := serveAPI(t, target); rr.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want 400", target, rr.Code)
+		}
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	_, finish := lastRun.start(context.Background(), "run-1", "all", false)
+	finish(nil)
+
+	rr, _ := serveAPI(t, "/api/v1/status")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	var body struct {
+		LastRun *runStatus `json:"last_run"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.LastRun == nil || body.LastRun.RunID != "run-1" || body.LastRun.Running || body.LastRun.FinishedAt == nil {
+		t.Errorf("last_run = %+v, want finished run-1", body.LastRun)
+	}
+
+	_, finish = lastRun.start(context.Background(), "run-2", "all", false)
+	defer finish(nil)
+	rr, _ = serveAPI(t, "/api/v1/status")
+	if strings.Contains(rr.Body.String(), "finished_at") {
+		t.Errorf("running run has a finish time: %s", rr.Body)
+	}
+}
+
+func TestStatusHandler_ChannelSource(t *testing.T) {
+	// Channels saved through the admin API replace the configured ones for
+	// fetches, so the status counts them too.
+	fileChannels.Lock()
+	fileChannels.channels = []config.ChannelConfig{
+		{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true},
+		{ID: "UC2xxxxxxxxxxxxxxxxxxxxx", Enabled: true},
+		{ID: "UC3xxxxxxxxxxxxxxxxxxxxx", Enabled: false},
+	}
+	fileChannels.saved = true
+	fileChannels.Unlock()
+	t.Cleanup(func() {
+		fileChannels.Lock()
+		fileChannels.channels, fileChannels.saved = nil, false
+		fileChannels.Unlock()
+	})
+
+	rr, _ := serveAPI(t, "/api/v1/status")
+	var body apiStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Channels != 2 {
+		t.Errorf("channels = %d, want the 2 enabled saved channels", body.Channels)
+	}
+}
+
+// TestQueryAPI_Client checks that pkg/client decodes what the handlers
+// write.
+func TestQueryAPI_Client(t *testing.T) {
+	cfg = config.DefaultConfig()
+	fake := &fakeQuerier{}
+	orig := newTrendQuerier
+	newTrendQuerier = func(ctx context.Context) (trendQuerier, error) { return fake, nil }
+	t.Cleanup(func() { newTrendQuerier = orig })
+
+	mux := http.NewServeMux()
+	registerAPI(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	ctx := context.Background()
+	aug1 := civil.Date{Year: 2025, Month: 8, Day: 1}
+
+	videos, err := c.ChannelVideos(ctx, "UC1", client.From(aug1), client.To(aug1.AddDays(6)), client.Limit(5))
+	if err != nil || len(videos) != 1 || videos[0].VideoID != "v1" || videos[0].Dt != aug1.AddDays(6) {
+		t.Errorf("ChannelVideos() = %+v, %v", videos, err)
+	}
+	if fake.channelID != "UC1" || fake.from != aug1 || fake.limit != 5 {
+		t.Errorf("query args = %+v", fake)
+	}
+
+	cmp, err := c.Compare(ctx, []string{"UC1", "UC2"}, client.From(aug1), client.To(aug1.AddDays(1)))
+	if err != nil || len(cmp.Channels) != 2 || *cmp.Channels[0].ViewsGained[0] != 500 || cmp.Channels[0].EngagementRate[1] != nil {
+		t.Errorf("Compare() = %+v, %v", cmp, err)
+	}
+
+	runs, err := c.Runs(ctx, client.Limit(10))
+	if err != nil || len(runs) != 1 || runs[0].RunID != "run-2" || runs[0].QuotaUnits != 12 {
+		t.Errorf("Runs() = %+v, %v", runs, err)
+	}
+
+	if _, err := c.TopVideos(ctx, aug1, client.Metric("title")); err == nil {
+		t.Error("TopVideos(metric=title) error = nil, want 400")
+	}
+}
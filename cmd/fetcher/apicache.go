@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// apiCache stores query API responses between requests and instances.
+type apiCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, body []byte, ttl time.Duration) error
+	// Invalidate drops every cached response.
+	Invalidate(ctx context.Context) error
+}
+
+// apiResponseCache is the query API cache, or nil when server.api_cache_url
+// is not set; tests replace it.
+var apiResponseCache apiCache
+
+// newAPICache connects to server.api_cache_url. The connection is made
+// lazily, so an unreachable Redis only costs the cache, not startup.
+func newAPICache() (apiCache, error) {
+	if cfg.Server.APICacheURL == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(cfg.Server.APICacheURL)
+	if err != nil {
+		return nil, err
+	}
+	// A cache that is slower than the query it saves is not worth waiting for.
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = 2 * time.Second
+	}
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = 500 * time.Millisecond
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = 500 * time.Millisecond
+	}
+	return &redisAPICache{
+		client: redis.NewClient(opts),
+		prefix: "ytt:api:" + cfg.GCP.ProjectID + "." + cfg.BigQuery.DatasetID + ":",
+	}, nil
+}
+
+// redisAPICache keeps responses under keys that include a generation
+// number. Invalidating increments the generation, which retires every
+// cached response at once without scanning for keys; the old entries
+// expire on their own.
+type redisAPICache struct {
+	client *redis.Client
+	prefix string
+}
+
+func (c *redisAPICache) generationKey() string {
+	return c.prefix + "generation"
+}
+
+// key returns the Redis key of a response in the current generation.
+func (c *redisAPICache) key(ctx context.Context, key string) (string, error) {
+	gen, err := c.client.Get(ctx, c.generationKey()).Result()
+	if errors.Is(err, redis.Nil) {
+		gen = "0"
+	} else if err != nil {
+		return "", err
+	}
+	return c.prefix + gen + ":" + key, nil
+}
+
+func (c *redisAPICache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	body, err := c.client.Get(ctx, k).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	return body, err == nil, err
+}
+
+func (c *redisAPICache) Set(ctx context.Context, key string, body []byte, ttl time.Duration) error {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, k, body, ttl).Err()
+}
+
+func (c *redisAPICache) Invalidate(ctx context.Context) error {
+	return c.client.Incr(ctx, c.generationKey()).Err()
+}
+
+// apiCacheKey identifies a query API response: the path, the normalized
+// query, and today's date, which is the default of the date parameters.
+func apiCacheKey(r *http.Request) string {
+	return r.URL.Path + "?" + r.URL.Query().Encode() + "@" + today().String()
+}
+
+// invalidateAPICache drops the cached query API responses once a run has
+// written new snapshots. Failures are only logged; the entries then expire
+// after server.api_cache_ttl.
+func invalidateAPICache(ctx context.Context, s *runStatus) {
+	if apiResponseCache == nil || s.VideosWritten == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := apiResponseCache.Invalidate(ctx); err != nil {
+		logger.FromContext(ctx).Warning("Failed to invalidate the query API cache", err, map[string]string{
+			"ttl": cfg.Server.APICacheTTL.String(),
+		})
+	}
+}
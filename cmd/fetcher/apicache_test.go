@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+type fakeAPICache struct {
+	entries     map[string][]byte
+	invalidated int
+}
+
+func (f *fakeAPICache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	body, ok := f.entries[key]
+	return body, ok, nil
+}
+
+func (f *fakeAPICache) Set(ctx context.Context, key string, body []byte, ttl time.Duration) error {
+	f.entries[key] = body
+	return nil
+}
+
+func (f *fakeAPICache) Invalidate(ctx context.Context) error {
+	f.invalidated++
+	f.entries = map[string][]byte{}
+	return nil
+}
+
+func TestServeQuery_Cache(t *testing.T) {
+	cache := &fakeAPICache{entries: map[string][]byte{}}
+	apiResponseCache = cache
+	t.Cleanup(func() { apiResponseCache = nil })
+
+	target := "/api/v1/videos/v1/timeseries?to=2025-08-07&from=2025-08-01"
+	rr, fake := serveAPI(t, target)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first request: status %d, X-Cache %q; want a 200 miss", rr.Code, rr.Header().Get("X-Cache"))
+	}
+	if fake.to.String() != "2025-08-07" {
+		t.Fatalf("first request did not query")
+	}
+
+	// The same query with its parameters in another order is a hit.
+	rr2, fake2 := serveAPI(t, "/api/v1/videos/v1/timeseries?from=2025-08-01&to=2025-08-07")
+	if rr2.Header().Get("X-Cache") != "HIT" || rr2.Body.String() != rr.Body.String() {
+		t.Errorf("second request: X-Cache %q, body %s; want the cached response", rr2.Header().Get("X-Cache"), rr2.Body)
+	}
+	if !fake2.to.IsZero() {
+		t.Errorf("second request queried BigQuery")
+	}
+
+	invalidateAPICache(context.Background(), &runStatus{VideosWritten: 0})
+	invalidateAPICache(context.Background(), &runStatus{VideosWritten: 3})
+	if cache.invalidated != 1 {
+		t.Errorf("invalidated %d times, want only after the run that wrote videos", cache.invalidated)
+	}
+	if rr, _ := serveAPI(t, target); rr.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("X-Cache after a run = %q, want MISS", rr.Header().Get("X-Cache"))
+	}
+}
+
+func TestNewAPICache(t *testing.T) {
+	cfg = config.DefaultConfig()
+	if c, err := newAPICache(); c != nil || err != nil {
+		t.Errorf("newAPICache() without a URL = %v, %v; want no cache", c, err)
+	}
+	cfg.Server.APICacheURL = "redis://localhost:6379/2"
+	c, err := newAPICache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts := c.(*redisAPICache).client.Options(); opts.DB != 2 || opts.ReadTimeout != 500*time.Millisecond {
+		t.Errorf("options = db %d, read timeout %s", opts.DB, opts.ReadTimeout)
+	}
+	if req := httptest.NewRequest(http.MethodGet, "/api/v1/top?b=2&a=1", nil); apiCacheKey(req) != "/api/v1/top?a=1&b=2@"+today().String() {
+		t.Errorf("apiCacheKey() = %q", apiCacheKey(req))
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// apiKeyRecheckInterval is how long a refused key stays refused before it
+// is checked again, e.g. after the API was enabled in its project. Valid
+// keys are not checked again.
+const apiKeyRecheckInterval = 10 * time.Minute
+
+// apiKeyChecks caches the verdicts of VerifyAPIKey by key, so that runs
+// and tenants sharing a key spend the quota unit once.
+var apiKeyChecks struct {
+	mu    sync.Mutex
+	byKey map[string]apiKeyCheck
+}
+
+type apiKeyCheck struct {
+	err       error // nil or an *youtube.APIKeyError
+	checkedAt time.Time
+}
+
+// verifyAPIKey calls the API to check the key of c; tests replace it.
+var verifyAPIKey = func(ctx context.Context, c *config.Config) error {
+	client, err := youtubeClient(c)
+	if err != nil {
+		return err
+	}
+	return client.VerifyAPIKey(ctx)
+}
+
+// checkAPIKey returns an *youtube.APIKeyError if the API refuses the key of
+// c, using the cached verdict when there is one. A check that fails for
+// another reason, such as a network error, is logged and not cached: it
+// says nothing about the key, and the run will report such errors itself.
+func checkAPIKey(ctx context.Context, c *config.Config) error {
+	key := c.YouTube.APIKey
+	apiKeyChecks.mu.Lock()
+	defer apiKeyChecks.mu.Unlock()
+	if check, ok := apiKeyChecks.byKey[key]; ok && (check.err == nil || time.Since(check.checkedAt) < apiKeyRecheckInterval) {
+		return check.err
+	}
+
+	err := verifyAPIKey(ctx, c)
+	var keyErr *youtube.APIKeyError
+	if err != nil && !stderrors.As(err, &keyErr) {
+		logger.FromContext(ctx).Warning("Could not verify the YouTube API key", err, nil)
+		return nil
+	}
+	if apiKeyChecks.byKey == nil {
+		apiKeyChecks.byKey = make(map[string]apiKeyCheck)
+	}
+	apiKeyChecks.byKey[key] = apiKeyCheck{err: err, checkedAt: time.Now()}
+	return err
+}
+
+// verifyAPIKeys checks youtube.api_key and the tenants' own keys at
+// startup, logging each refused key with how to fix it. It reports whether
+// every key was accepted.
+func verifyAPIKeys(ctx context.Context) bool {
+	ok := true
+	check := func(c *config.Config, labels map[string]string) {
+		err := checkAPIKey(ctx, c)
+		var keyErr *youtube.APIKeyError
+		if !stderrors.As(err, &keyErr) {
+			return
+		}
+		ok = false
+		labels["reason"] = keyErr.Reason
+		labels["hint"] = keyErr.Hint
+		log.Error("YouTube API key rejected: fetches will fail until it is fixed", err, labels)
+	}
+
+	check(cfg, map[string]string{})
+	for _, t := range cfg.Tenants {
+		if t.APIKey != "" {
+			check(cfg.ForTenant(t), map[string]string{"tenant": t.ID})
+		}
+	}
+	if ok {
+		log.Info("YouTube API keys verified", nil)
+	}
+	return ok
+}
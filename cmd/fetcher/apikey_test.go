@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+func TestCheckAPIKey(t *testing.T) {
+	calls := map[string]int{}
+	orig := verifyAPIKey
+	verifyAPIKey = func(ctx context.Context, c *config.Config) error {
+		calls[c.YouTube.APIKey]++
+		switch c.YouTube.APIKey {
+		case "refused":
+			return &youtube.APIKeyError{Reason: "accessNotConfigured", Hint: "enable the API"}
+		case "offline":
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		verifyAPIKey = orig
+		apiKeyChecks.byKey = nil
+	})
+	apiKeyChecks.byKey = nil
+	ctx := context.Background()
+	withKey := func(key string) *config.Config {
+		c := config.DefaultConfig()
+		c.YouTube.APIKey = key
+		return c
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := checkAPIKey(ctx, withKey("valid")); err != nil {
+			t.Errorf("checkAPIKey(valid) error = %v", err)
+		}
+		var keyErr *youtube.APIKeyError
+		if err := checkAPIKey(ctx, withKey("refused")); !errors.As(err, &keyErr) {
+			t.Errorf("checkAPIKey(refused) error = %v, want an APIKeyError", err)
+		}
+		// A check that says nothing about the key does not fail the run.
+		if err := checkAPIKey(ctx, withKey("offline")); err != nil {
+			t.Errorf("checkAPIKey(offline) error = %v, want nil", err)
+		}
+	}
+	if calls["valid"] != 1 || calls["refused"] != 1 || calls["offline"] != 2 {
+		t.Errorf("calls = %v, want the valid and refused verdicts cached", calls)
+	}
+
+	// A refused key is checked again once the recheck interval passed.
+	check := apiKeyChecks.byKey["refused"]
+	check.checkedAt = check.checkedAt.Add(-apiKeyRecheckInterval - time.Second)
+	apiKeyChecks.byKey["refused"] = check
+	checkAPIKey(ctx, withKey("refused"))
+	if calls["refused"] != 2 {
+		t.Errorf("refused key checked %d times, want 2", calls["refused"])
+	}
+
+	originalCfg := cfg
+	t.Cleanup(func() { cfg = originalCfg })
+	cfg = withKey("valid")
+	if !verifyAPIKeys(ctx) {
+		t.Error("verifyAPIKeys() = false with a valid key")
+	}
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", DatasetID: "acme", APIKey: "refused"}}
+	if verifyAPIKeys(ctx) {
+		t.Error("verifyAPIKeys() = true with a refused tenant key")
+	}
+}
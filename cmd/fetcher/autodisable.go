@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/fetcher"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// channelHealthStore keeps the failure streaks of channels.
+type channelHealthStore interface {
+	EnsureChannelHealthTable(ctx context.Context) error
+	ChannelHealth(ctx context.Context) ([]storage.ChannelHealth, error)
+	SaveChannelHealth(ctx context.Context, rows []storage.ChannelHealth) error
+	EnableChannel(ctx context.Context, channelID string) (bool, error)
+}
+
+// newChannelHealthStore creates the store in the dataset of the run
+// configuration; tests replace it.
+var newChannelHealthStore = func(ctx context.Context) (channelHealthStore, error) {
+	rc := runConfig(ctx)
+	return newBigQueryWriter(rc)
+}
+
+// nextChannelHealth applies the outcome of a run to the streaks in prev:
+// each failed channel gains a failure and each succeeded channel that had
+// some is reset. It returns the rows that changed, and the channels that
+// reached threshold failures in this run and are now disabled, both ordered
+// by channel ID. Channels neither failed nor succeeded, such as those
+// skipped for quota, keep their streak.
+func nextChannelHealth(prev []storage.ChannelHealth, succeeded []string, failed map[string]error, threshold int, now time.Time) ([]storage.ChannelHealth, []string) {
+	byID := make(map[string]storage.ChannelHealth, len(prev))
+	for _, h := range prev {
+		byID[h.ChannelID] = h
+	}
+
+	var changed []storage.ChannelHealth
+	var disabled []string
+	for _, id := range succeeded {
+		if h, ok := byID[id]; ok && h.ConsecutiveFailures > 0 {
+			h.ConsecutiveFailures = 0
+			changed = append(changed, h)
+		}
+	}
+	for id, err := range failed {
+		h, ok := byID[id]
+		if !ok {
+			h = storage.ChannelHealth{ChannelID: id}
+		}
+		h.ConsecutiveFailures++
+		h.LastFailedAt = now
+		if err != nil {
+			h.LastError = err.Error()
+		}
+		if !h.Disabled() && h.ConsecutiveFailures >= int64(threshold) {
+			h.DisabledAt = bigquery.NullTimestamp{Timestamp: now, Valid: true}
+			disabled = append(disabled, id)
+		}
+		changed = append(changed, h)
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].ChannelID < changed[j].ChannelID })
+	sort.Strings(disabled)
+	return changed, disabled
+}
+
+// recordChannelHealth updates the failure streaks with the outcome of a
+// run and disables the channels that failed app.auto_disable_after runs in
+// a row, logging an error and counting each in the metrics. Store errors
+// are only logged: they must not fail the run.
+func recordChannelHealth(ctx context.Context, result *fetcher.FetchResult) {
+	log := logger.FromContext(ctx)
+	if result == nil || (len(result.FailedChannels) == 0 && len(result.SuccessfulChannels) == 0) {
+		return
+	}
+	store, err := newChannelHealthStore(ctx)
+	if err != nil {
+		log.Warning("Failed to update channel health", err, nil)
+		return
+	}
+	if err := store.EnsureChannelHealthTable(ctx); err != nil {
+		log.Warning("Failed to update channel health", err, nil)
+		return
+	}
+	prev, err := store.ChannelHealth(ctx)
+	if err != nil {
+		log.Warning("Failed to update channel health", err, nil)
+		return
+	}
+
+	threshold := runConfig(ctx).App.AutoDisableAfter
+	changed, disabled := nextChannelHealth(prev, result.SuccessfulChannels, result.FailedChannels, threshold, time.Now().UTC())
+	if err := store.SaveChannelHealth(ctx, changed); err != nil {
+		log.Warning("Failed to update channel health", err, nil)
+		return
+	}
+	for _, id := range disabled {
+		log.Error(fmt.Sprintf("Channel disabled after %d consecutive failures", threshold), result.FailedChannels[id], map[string]string{
+			"channel_id": id,
+			"hint":       "fix or remove the channel, then POST /channels/" + id + "/enable",
+		})
+		appMetrics.RecordChannelDisabled(id)
+	}
+}
+
+// withoutDisabledChannels returns channelIDs without the channels disabled
+// for failing repeatedly. When the streaks cannot be read, every channel is
+// kept.
+func withoutDisabledChannels(ctx context.Context, channelIDs []string) []string {
+	if runConfig(ctx).App.AutoDisableAfter == 0 {
+		return channelIDs
+	}
+	log := logger.FromContext(ctx)
+	store, err := newChannelHealthStore(ctx)
+	if err == nil {
+		err = store.EnsureChannelHealthTable(ctx)
+	}
+	var health []storage.ChannelHealth
+	if err == nil {
+		health, err = store.ChannelHealth(ctx)
+	}
+	if err != nil {
+		log.Warning("Failed to read disabled channels, fetching all of them", err, nil)
+		return channelIDs
+	}
+
+	disabled := make(map[string]bool)
+	for _, h := range health {
+		if h.Disabled() {
+			disabled[h.ChannelID] = true
+		}
+	}
+	kept := make([]string, 0, len(channelIDs))
+	var skipped []string
+	for _, id := range channelIDs {
+		if disabled[id] {
+			skipped = append(skipped, id)
+		} else {
+			kept = append(kept, id)
+		}
+	}
+	if len(skipped) > 0 {
+		log.Info(fmt.Sprintf("Skipping %d channels disabled after repeated failures", len(skipped)), map[string]string{
+			"channels": strings.Join(skipped, ","),
+		})
+	}
+	return kept
+}
+
+// channelHealthResponse is the body of GET /channels/health.
+type channelHealthResponse struct {
+	Channels []storage.ChannelHealth `json:"channels"`
+}
+
+// channelEnableResponse is the body of POST /channels/{id}/enable.
+type channelEnableResponse struct {
+	Status    string `json:"status"`
+	ChannelID string `json:"channel_id"`
+}
+
+// channelHealthContext returns the request context for the dataset of the
+// tenant given by ?tenant=, if any, and false when there is no such tenant.
+func channelHealthContext(r *http.Request) (context.Context, bool) {
+	ctx := requestContext(r, "")
+	id := r.URL.Query().Get("tenant")
+	if id == "" {
+		return ctx, true
+	}
+	tenant, ok := cfg.Tenant(id)
+	if !ok {
+		return ctx, false
+	}
+	return withRunConfig(ctx, cfg.ForTenant(tenant)), true
+}
+
+// channelHealthHandler serves GET /channels/health: the channels that
+// failed their latest runs, with those disabled for it.
+func channelHealthHandler(w http.ResponseWriter, r *http.Request) {
+	if cfg.BigQuery.Disabled {
+		http.Error(w, "Channel health is kept in BigQuery, which is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	ctx, ok := channelHealthContext(r)
+	if !ok {
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return
+	}
+	log := logger.FromContext(ctx)
+
+	store, err := newChannelHealthStore(ctx)
+	if err == nil {
+		err = store.EnsureChannelHealthTable(ctx)
+	}
+	var health []storage.ChannelHealth
+	if err == nil {
+		health, err = store.ChannelHealth(ctx)
+	}
+	if err != nil {
+		log.Error("Error reading channel health", err, nil)
+		http.Error(w, "Failed to read channel health", http.StatusInternalServerError)
+		return
+	}
+	writeJSONStatus(w, http.StatusOK, channelHealthResponse{Channels: health})
+}
+
+// channelEnableHandler serves POST /channels/{id}/enable: it clears the
+// failure streak of a channel disabled for failing, so that the next runs
+// fetch it again.
+func channelEnableHandler(w http.ResponseWriter, r *http.Request) {
+	if cfg.BigQuery.Disabled {
+		http.Error(w, "Channel health is kept in BigQuery, which is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	ctx, ok := channelHealthContext(r)
+	if !ok {
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return
+	}
+	log := logger.FromContext(ctx)
+	id := r.PathValue("id")
+
+	store, err := newChannelHealthStore(ctx)
+	if err == nil {
+		err = store.EnsureChannelHealthTable(ctx)
+	}
+	var enabled bool
+	if err == nil {
+		enabled, err = store.EnableChannel(ctx, id)
+	}
+	if err != nil {
+		log.Error("Error enabling channel", err, map[string]string{"channel_id": id})
+		http.Error(w, "Failed to enable channel", http.StatusInternalServerError)
+		return
+	}
+	if !enabled {
+		http.Error(w, "Channel is not disabled", http.StatusNotFound)
+		return
+	}
+	log.Info("Channel re-enabled", map[string]string{"channel_id": id})
+	writeJSONStatus(w, http.StatusOK, channelEnableResponse{Status: "enabled", ChannelID: id})
+}
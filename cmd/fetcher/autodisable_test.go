@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/fetcher"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// fakeChannelHealth is an in-memory channelHealthStore.
+type fakeChannelHealth map[string]storage.ChannelHealth
+
+func (f fakeChannelHealth) EnsureChannelHealthTable(ctx context.Context) error { return nil }
+
+func (f fakeChannelHealth) ChannelHealth(ctx context.Context) ([]storage.ChannelHealth, error) {
+	var rows []storage.ChannelHealth
+	for _, h := range f {
+		rows = append(rows, h)
+	}
+	return rows, nil
+}
+
+func (f fakeChannelHealth) SaveChannelHealth(ctx context.Context, rows []storage.ChannelHealth) error {
+	for _, h := range rows {
+		f[h.ChannelID] = h
+	}
+	return nil
+}
+
+func (f fakeChannelHealth) EnableChannel(ctx context.Context, channelID string) (bool, error) {
+	h, ok := f[channelID]
+	if !ok || !h.Disabled() {
+		return false, nil
+	}
+	h.ConsecutiveFailures = 0
+	h.DisabledAt = bigquery.NullTimestamp{}
+	f[channelID] = h
+	return true, nil
+}
+
+func useFakeChannelHealth(t *testing.T, autoDisableAfter int) fakeChannelHealth {
+	t.Helper()
+	originalCfg := cfg
+	cfg = config.DefaultConfig()
+	cfg.App.AutoDisableAfter = autoDisableAfter
+	store := fakeChannelHealth{}
+	orig := newChannelHealthStore
+	newChannelHealthStore = func(ctx context.Context) (channelHealthStore, error) { return store, nil }
+	t.Cleanup(func() {
+		cfg = originalCfg
+		newChannelHealthStore = orig
+	})
+	return store
+}
+
+func TestNextChannelHealth(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	disabledAt := bigquery.NullTimestamp{Timestamp: now.Add(-time.Hour), Valid: true}
+	prev := []storage.ChannelHealth{
+		{ChannelID: "UC1", ConsecutiveFailures: 2},
+		{ChannelID: "UC2", ConsecutiveFailures: 1},
+		{ChannelID: "UC3", ConsecutiveFailures: 4, DisabledAt: disabledAt},
+		{ChannelID: "UC4", ConsecutiveFailures: 2},
+	}
+	failed := map[string]error{"UC1": errors.New("channel not found"), "UC3": nil, "UC5": errors.New("timeout")}
+
+	changed, disabled := nextChannelHealth(prev, []string{"UC2", "UC6"}, failed, 3, now)
+	want := []storage.ChannelHealth{
+		{ChannelID: "UC1", ConsecutiveFailures: 3, LastError: "channel not found", LastFailedAt: now, DisabledAt: bigquery.NullTimestamp{Timestamp: now, Valid: true}},
+		{ChannelID: "UC2", ConsecutiveFailures: 0},
+		// Already disabled: not disabled again.
+		{ChannelID: "UC3", ConsecutiveFailures: 5, LastFailedAt: now, DisabledAt: disabledAt},
+		{ChannelID: "UC5", ConsecutiveFailures: 1, LastError: "timeout", LastFailedAt: now},
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %+v, want %+v", changed, want)
+	}
+	if !reflect.DeepEqual(disabled, []string{"UC1"}) {
+		t.Errorf("disabled = %v, want [UC1]", disabled)
+	}
+}
+
+func TestRecordChannelHealth_DisablesAndSkips(t *testing.T) {
+	store := useFakeChannelHealth(t, 2)
+	ctx := context.Background()
+	result := &fetcher.FetchResult{
+		SuccessfulChannels: []string{"UC1"},
+		FailedChannels:     map[string]error{"UC2": errors.New("channel not found")},
+	}
+
+	recordChannelHealth(ctx, result)
+	if got := withoutDisabledChannels(ctx, []string{"UC1", "UC2"}); !reflect.DeepEqual(got, []string{"UC1", "UC2"}) {
+		t.Fatalf("after one failure channels = %v, want both", got)
+	}
+	recordChannelHealth(ctx, result)
+	if got := withoutDisabledChannels(ctx, []string{"UC1", "UC2"}); !reflect.DeepEqual(got, []string{"UC1"}) {
+		t.Fatalf("after two failures channels = %v, want [UC1]", got)
+	}
+	if !store["UC2"].Disabled() {
+		t.Error("UC2 is not disabled in the store")
+	}
+
+	// Re-enabling fetches it again.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/channels/UC2/enable", nil)
+	req.SetPathValue("id", "UC2")
+	channelEnableHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enable status = %d: %s", rr.Code, rr.Body)
+	}
+	if got := withoutDisabledChannels(ctx, []string{"UC1", "UC2"}); !reflect.DeepEqual(got, []string{"UC1", "UC2"}) {
+		t.Errorf("after enabling channels = %v, want both", got)
+	}
+
+	// A channel that is not disabled cannot be enabled.
+	rr = httptest.NewRecorder()
+	channelEnableHandler(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("enabling again status = %d, want 404", rr.Code)
+	}
+}
+
+func TestWithoutDisabledChannels_Off(t *testing.T) {
+	store := useFakeChannelHealth(t, 0)
+	store["UC1"] = storage.ChannelHealth{ChannelID: "UC1", ConsecutiveFailures: 9, DisabledAt: bigquery.NullTimestamp{Timestamp: time.Now(), Valid: true}}
+	if got := withoutDisabledChannels(context.Background(), []string{"UC1"}); !reflect.DeepEqual(got, []string{"UC1"}) {
+		t.Errorf("channels = %v, want [UC1] with auto_disable_after 0", got)
+	}
+}
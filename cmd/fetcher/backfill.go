@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/fetcher"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// exitBudgetExhausted signals that the backfill stopped early because the
+// quota budget ran out; rerunning the command resumes from the checkpoint.
+const exitBudgetExhausted = 3
+
+// channelList collects a repeatable -channel flag.
+type channelList []string
+
+func (c *channelList) String() string { return strings.Join(*c, ",") }
+
+func (c *channelList) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+// runBackfill loads the full upload history of channels into BigQuery using
+// load jobs. Progress is checkpointed to a local file so that an interrupted
+// or budget-limited run can be resumed.
+func runBackfill(args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	var channels channelList
+	fs.Var(&channels, "channel", "Channel ID to backfill (repeatable; defaults to all enabled channels)")
+	checkpointPath := fs.String("checkpoint", "backfill_checkpoint.json", "File used to persist backfill progress")
+	quotaBudget := fs.Int("quota-budget", 0, "Maximum YouTube API units to spend in this run (0 = unlimited)")
+	pageDelay := fs.Duration("page-delay", 0, "Delay between playlist pages to throttle requests")
+	flushSize := fs.Int("flush-size", 1000, "Number of records written per load job")
+	fs.Parse(args)
+
+	var err error
+	cfg, err = config.Load(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+
+	log := log.With(map[string]string{"run_id": newRunID()})
+	ctx, stop := signal.NotifyContext(logger.WithContext(context.Background(), log), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The channel list also says which IDs are playlists. Explicit -channel
+	// IDs can be backfilled without it, as channels.
+	channelIDs := []string(channels)
+	c, err := currentConfig(ctx)
+	if err != nil {
+		if len(channelIDs) == 0 {
+			log.Error("Error loading channel list", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+			return 1
+		}
+		log.Warning("Error loading channel list; backfilling the given IDs as channels", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+		c = cfg.WithChannels(nil)
+	}
+	if len(channelIDs) == 0 {
+		channelIDs = c.GetEnabledChannelIDs()
+	}
+	playlists := make(map[string]bool)
+	for _, id := range c.GetPlaylistIDs() {
+		playlists[id] = true
+	}
+	if len(channelIDs) == 0 {
+		log.Error("No channels to backfill", nil, nil)
+		return 1
+	}
+
+	ytClient, err := newYouTubeClient(ctx)
+	if err != nil {
+		log.Error("Error creating YouTube client", err, nil)
+		return 1
+	}
+
+	bqWriter, err := newTableWriter(ctx, cfg)
+	if err != nil {
+		log.Error("Error creating BigQuery writer", err, nil)
+		return 1
+	}
+	if err := bqWriter.EnsureTableExists(ctx); err != nil {
+		log.Error("Error ensuring BigQuery table exists", err, nil)
+		return 1
+	}
+
+	b := fetcher.NewBackfiller(ytClient, bqWriter, fetcher.NewFileCheckpointStore(*checkpointPath), fetcher.BackfillOptions{
+		QuotaBudget: *quotaBudget,
+		PageDelay:   *pageDelay,
+		FlushSize:   *flushSize,
+		Location:    cfg.Location(),
+		Playlists:   playlists,
+		CategoryNames: videoCategories.resolve(ctx, ytClient, bqWriter, cfg.YouTube.CategoryRegions,
+			cfg.YouTube.CategoryLanguage, time.Now()),
+	})
+
+	start := time.Now()
+	result, err := b.Backfill(ctx, channelIDs)
+	saveResponseCache(ctx, ytClient)
+	labels := map[string]string{
+		"videos_loaded":      fmt.Sprintf("%d", result.VideosLoaded),
+		"quota_used":         fmt.Sprintf("%d", result.QuotaUsed),
+		"completed_channels": fmt.Sprintf("%d/%d", len(result.CompletedChannels), len(channelIDs)),
+		"duration":           time.Since(start).String(),
+	}
+	if errors.Is(err, fetcher.ErrQuotaBudgetExhausted) {
+		log.Warning("Backfill paused: quota budget exhausted, rerun to resume", nil, labels)
+		return exitBudgetExhausted
+	}
+	if err != nil {
+		log.Error("Backfill failed, rerun to resume from the last checkpoint", err, labels)
+		return 1
+	}
+
+	log.Info("Backfill completed", labels)
+	return 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// responseCache is the YouTube response cache shared by every client of the
+// process, so that conditional requests also pay off across runs served by
+// the same Cloud Run instance.
+var responseCache struct {
+	once  sync.Once
+	cache *youtube.ResponseCache
+}
+
+// sharedResponseCache returns the process's response cache, loading it from
+// youtube.response_cache_file on first use, or nil when the cache is
+// disabled.
+func sharedResponseCache(ctx context.Context) *youtube.ResponseCache {
+	if cfg.YouTube.ResponseCacheSize <= 0 {
+		return nil
+	}
+	responseCache.once.Do(func() {
+		path := cfg.YouTube.ResponseCacheFile
+		if path == "" {
+			responseCache.cache = youtube.NewResponseCache(cfg.YouTube.ResponseCacheSize)
+			return
+		}
+		c, err := youtube.LoadResponseCache(path, cfg.YouTube.ResponseCacheSize)
+		if err != nil {
+			// Start empty rather than fail the run; the file is rewritten
+			// on the next save.
+			logger.FromContext(ctx).Warning("Failed to load YouTube response cache", err, map[string]string{"path": path})
+		}
+		responseCache.cache = c
+	})
+	return responseCache.cache
+}
+
+// saveResponseCache writes the response cache to
+// youtube.response_cache_file, if configured, and logs how many requests
+// client answered from it.
+func saveResponseCache(ctx context.Context, client *youtube.Client) {
+	log := logger.FromContext(ctx)
+	c := sharedResponseCache(ctx)
+	if c == nil {
+		return
+	}
+	log.Info("YouTube response cache", map[string]string{
+		"not_modified": fmt.Sprintf("%d", client.NotModified()),
+		"entries":      fmt.Sprintf("%d", c.Len()),
+	})
+	if path := cfg.YouTube.ResponseCacheFile; path != "" {
+		if err := c.Save(path); err != nil {
+			log.Warning("Failed to save YouTube response cache", err, map[string]string{"path": path})
+		}
+	}
+}
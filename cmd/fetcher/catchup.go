@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/scheduler"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// successHistory finds the last successful run in fetch_runs.
+type successHistory interface {
+	LastSuccessfulRun(ctx context.Context) (*storage.FetchRunRecord, error)
+}
+
+// newSuccessHistory creates the reader used by catch-up; tests replace it.
+var newSuccessHistory = func(ctx context.Context) (successHistory, error) {
+	return newBigQueryWriter(cfg)
+}
+
+type snapshotDateKey struct{}
+
+// withSnapshotDate makes the fetches run with ctx store their records under
+// dt d instead of the current date.
+func withSnapshotDate(ctx context.Context, d civil.Date) context.Context {
+	return context.WithValue(ctx, snapshotDateKey{}, d)
+}
+
+// snapshotDateFrom returns the date set by withSnapshotDate, or the zero
+// date.
+func snapshotDateFrom(ctx context.Context) civil.Date {
+	d, _ := ctx.Value(snapshotDateKey{}).(civil.Date)
+	return d
+}
+
+// missedDates returns the dates, oldest first, after the date of the last
+// successful run and before today (in loc) on which trigger fired, keeping
+// the most recent maxDays. Today is left to the next scheduled run. With no
+// successful run recorded there is nothing to catch up.
+func missedDates(last, now time.Time, trigger *scheduler.Schedule, loc *time.Location, maxDays int) []civil.Date {
+	if last.IsZero() || maxDays <= 0 {
+		return nil
+	}
+	today := civil.DateOf(now.In(loc))
+	var dates []civil.Date
+	for d := civil.DateOf(last.In(loc)).AddDays(1); d.Before(today); d = d.AddDays(1) {
+		start := d.In(loc)
+		next := trigger.Next(start.Add(-time.Minute))
+		if !next.IsZero() && next.Before(d.AddDays(1).In(loc)) {
+			dates = append(dates, d)
+		}
+	}
+	if len(dates) > maxDays {
+		dates = dates[len(dates)-maxDays:]
+	}
+	return dates
+}
+
+// runCatchUp fetches every enabled channel once for each date missedDates
+// finds, oldest first, as runs of scope "catchup:<date>" whose records carry
+// that date as dt. The statistics are those at the time of the catch-up; a
+// missed day cannot be observed after the fact. It returns the dates caught
+// up, stopping at the first failure.
+func runCatchUp(ctx context.Context, dry *storage.DryRunWriter) ([]civil.Date, error) {
+	log := logger.FromContext(ctx)
+	if cfg.BigQuery.Disabled {
+		return nil, errors.New("catch-up reads the fetch_runs table, which needs BigQuery")
+	}
+	trigger, err := scheduler.Parse(cfg.App.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := newSuccessHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client for run history: %w", err)
+	}
+	last, err := history.LastSuccessfulRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		log.Info("No successful run recorded, nothing to catch up", nil)
+		return nil, nil
+	}
+	dates := missedDates(last.StartedAt, time.Now(), trigger, cfg.Location(), cfg.App.CatchUpMaxDays)
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel list: %w", err)
+	}
+	channelIDs := c.GetEnabledChannelIDs()
+	if len(channelIDs) == 0 {
+		return nil, errors.New("no channels configured")
+	}
+
+	for i, d := range dates {
+		log.Info(fmt.Sprintf("Catching up missed date %s", d), map[string]string{
+			"last_success": last.StartedAt.Format(time.RFC3339),
+			"schedule":     cfg.App.Schedule,
+		})
+		release, err := acquireRunLock(ctx, "all", dry != nil)
+		if err != nil {
+			return dates[:i], err
+		}
+		runCtx, finish := lastRun.start(withSnapshotDate(ctx, d), newRunID(), "catchup:"+d.String(), dry != nil)
+		err = runFetchChannels(runCtx, channelIDs, cfg.App.MaxVideosPerChannel, dry)
+		finish(err)
+		release()
+		if err != nil {
+			return dates[:i], err
+		}
+	}
+	return dates, nil
+}
+
+// startupCatchUp runs the catch-up when App.CatchUp is set, logging its
+// outcome; a failure never stops the server or the job.
+func startupCatchUp() {
+	if !cfg.App.CatchUp || cfg.App.DryRun {
+		return
+	}
+	done, ok := tryStartFetch()
+	if !ok {
+		return
+	}
+	defer done()
+	log := log.With(map[string]string{"run_id": newRunID()})
+	timeout := time.Duration(cfg.App.CatchUpMaxDays) * cfg.App.FetchTimeout
+	ctx, cancel := context.WithTimeout(logger.WithContext(context.Background(), log), timeout)
+	defer cancel()
+
+	dates, err := runCatchUp(ctx, nil)
+	if err != nil {
+		log.Error("Catch-up failed", err, map[string]string{"dates_caught_up": fmt.Sprint(len(dates))})
+		return
+	}
+	if len(dates) > 0 {
+		log.Info(fmt.Sprintf("Caught up %d missed dates", len(dates)), nil)
+	}
+}
+
+// catchupResponse is the body of POST /catchup. Dates lists the dates
+// caught up, including those done before a failure.
+type catchupResponse struct {
+	Status string         `json:"status"`
+	Dates  []string       `json:"dates"`
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// catchupHandler serves POST /catchup: it runs the fetch for the recent
+// dates whose scheduled runs were all missed, with dt backdated to each
+// date.
+func catchupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := requestContext(r, newRunID())
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun || r.URL.Query().Get("dry_run") == "true" {
+		dry = storage.NewDryRunWriter()
+	}
+
+	dates, err := runCatchUp(ctx, dry)
+	caughtUp := make([]string, len(dates))
+	for i, d := range dates {
+		caughtUp[i] = d.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, errRunLocked):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(catchupResponse{Status: "locked", Dates: caughtUp, Error: err.Error()})
+		return
+	case err != nil:
+		logger.FromContext(ctx).Error("Catch-up failed", err, nil)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(catchupResponse{Status: "failed", Dates: caughtUp, Error: "catch-up failed"})
+		return
+	}
+
+	resp := catchupResponse{Status: "success", Dates: caughtUp}
+	if len(dates) == 0 {
+		resp.Status = "nothing_to_catch_up"
+	}
+	if dry != nil {
+		resp.Counts = dry.Counts()
+	}
+	json.NewEncoder(w).Encode(resp)
+}
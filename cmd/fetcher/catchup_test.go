@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/scheduler"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeSuccessHistory struct{ last *storage.FetchRunRecord }
+
+func (f fakeSuccessHistory) LastSuccessfulRun(ctx context.Context) (*storage.FetchRunRecord, error) {
+	return f.last, nil
+}
+
+func TestMissedDates(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 10, 30, 0, 0, tokyo)
+	hourly, _ := scheduler.Parse("0 * * * *")
+	weekdays, _ := scheduler.Parse("0 9 * * MON-FRI")
+	date := func(day int) civil.Date { return civil.Date{Year: 2026, Month: 10, Day: day} }
+
+	tests := []struct {
+		name    string
+		last    time.Time
+		trigger *scheduler.Schedule
+		maxDays int
+		want    []civil.Date
+	}{
+		{"ran today", time.Date(2026, 10, 17, 9, 0, 0, 0, tokyo), hourly, 7, nil},
+		{"ran yesterday", time.Date(2026, 10, 16, 23, 0, 0, 0, tokyo), hourly, 7, nil},
+		{"down three days", time.Date(2026, 10, 13, 12, 0, 0, 0, tokyo), hourly, 7, []civil.Date{date(14), date(15), date(16)}},
+		{"limited to the latest", time.Date(2026, 10, 13, 12, 0, 0, 0, tokyo), hourly, 2, []civil.Date{date(15), date(16)}},
+		// 2026-10-10 and 11 are a weekend, when the trigger does not fire.
+		{"weekdays only", time.Date(2026, 10, 9, 9, 0, 0, 0, tokyo), weekdays, 7, []civil.Date{date(12), date(13), date(14), date(15), date(16)}},
+		// The last run is a UTC time on the previous day in UTC.
+		{"dates in the zone", time.Date(2026, 10, 15, 16, 0, 0, 0, time.UTC), hourly, 7, nil},
+		{"never ran", time.Time{}, hourly, 7, nil},
+	}
+	for _, tt := range tests {
+		got := missedDates(tt.last, now, tt.trigger, tokyo, tt.maxDays)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: missedDates() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCatchupHandler(t *testing.T) {
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+	cfg = config.DefaultConfig()
+	cfg.Channels = []config.ChannelConfig{{ID: "UC1", Enabled: true}}
+
+	orig := newSuccessHistory
+	newSuccessHistory = func(ctx context.Context) (successHistory, error) {
+		return fakeSuccessHistory{last: &storage.FetchRunRecord{Scope: "all", StartedAt: time.Now()}}, nil
+	}
+	t.Cleanup(func() { newSuccessHistory = orig })
+
+	rec := httptest.NewRecorder()
+	catchupHandler(rec, httptest.NewRequest(http.MethodGet, "/catchup", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /catchup = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	catchupHandler(rec, httptest.NewRequest(http.MethodPost, "/catchup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /catchup = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["status"] != "nothing_to_catch_up" {
+		t.Errorf("status = %v, want nothing_to_catch_up", resp["status"])
+	}
+}
+
+func TestSnapshotDateContext(t *testing.T) {
+	if d := snapshotDateFrom(context.Background()); !d.IsZero() {
+		t.Errorf("snapshotDateFrom(background) = %v, want zero", d)
+	}
+	want := civil.Date{Year: 2026, Month: 10, Day: 16}
+	if d := snapshotDateFrom(withSnapshotDate(context.Background(), want)); d != want {
+		t.Errorf("snapshotDateFrom() = %v, want %v", d, want)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// categoryCacheTTL is how long resolved category names are reused. A job
+// resolves them once; a long-running server or worker refreshes them a few
+// times a day, which is plenty for a list that rarely changes.
+const categoryCacheTTL = 12 * time.Hour
+
+// categoryLister is the part of youtube.Client used to resolve categories.
+type categoryLister interface {
+	VideoCategories(ctx context.Context, regionCode, hl string) ([]*youtube.VideoCategory, error)
+}
+
+// categoryStore records the categories in the video_categories table.
+type categoryStore interface {
+	EnsureVideoCategoriesTable(ctx context.Context) error
+	UpsertVideoCategories(ctx context.Context, records []storage.VideoCategoryRecord) error
+}
+
+// categoryCache holds the category names resolved by the last lookup, for
+// every run of the process until they expire.
+type categoryCache struct {
+	mu        sync.Mutex
+	key       string
+	names     map[string]string
+	fetchedAt time.Time
+}
+
+// videoCategories is the process-wide category cache.
+var videoCategories = &categoryCache{}
+
+// resolve returns the category names by category ID for the regions, in
+// language hl, fetching them when the cache is empty, expired or was filled
+// for other settings. A category ID takes the name of the first region that
+// has it. Regions that fail are skipped with a warning; if all fail, the
+// previous names are kept. Freshly fetched categories are upserted into
+// store unless it is nil.
+func (c *categoryCache) resolve(ctx context.Context, client categoryLister, store categoryStore, regions []string, hl string, now time.Time) map[string]string {
+	if len(regions) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.Join(regions, ",") + "@" + hl
+	if c.names != nil && c.key == key && now.Sub(c.fetchedAt) < categoryCacheTTL {
+		return c.names
+	}
+
+	log := logger.FromContext(ctx)
+	names := map[string]string{}
+	var records []storage.VideoCategoryRecord
+	for _, region := range regions {
+		categories, err := client.VideoCategories(ctx, region, hl)
+		if err != nil {
+			log.Warning("Failed to fetch video categories", err, map[string]string{"region": region})
+			continue
+		}
+		for _, cat := range categories {
+			if _, ok := names[cat.ID]; !ok && cat.Title != "" {
+				names[cat.ID] = cat.Title
+			}
+			records = append(records, storage.VideoCategoryRecord{
+				RegionCode: region,
+				CategoryID: cat.ID,
+				Title:      cat.Title,
+				Assignable: cat.Assignable,
+				UpdatedAt:  now,
+			})
+		}
+	}
+	if len(records) == 0 {
+		return c.names
+	}
+
+	if store != nil {
+		if err := store.EnsureVideoCategoriesTable(ctx); err != nil {
+			log.Warning("Error ensuring video categories table exists", err, nil)
+		} else if err := store.UpsertVideoCategories(ctx, records); err != nil {
+			log.Warning("Failed to record video categories", err, nil)
+		}
+	}
+	c.key, c.names, c.fetchedAt = key, names, now
+	return names
+}
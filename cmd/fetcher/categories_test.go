@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type fakeCategoryLister struct {
+	categories map[string][]*youtube.VideoCategory
+	calls      []string
+}
+
+func (f *fakeCategoryLister) VideoCategories(_ context.Context, region, hl string) ([]*youtube.VideoCategory, error) {
+	f.calls = append(f.calls, region+"/"+hl)
+	cats, ok := f.categories[region]
+	if !ok {
+		return nil, errors.New("unavailable")
+	}
+	return cats, nil
+}
+
+type fakeCategoryStore struct {
+	records []storage.VideoCategoryRecord
+}
+
+func (f *fakeCategoryStore) EnsureVideoCategoriesTable(context.Context) error { return nil }
+
+func (f *fakeCategoryStore) UpsertVideoCategories(_ context.Context, records []storage.VideoCategoryRecord) error {
+	f.records = append(f.records, records...)
+	return nil
+}
+
+func TestCategoryCacheResolve(t *testing.T) {
+	client := &fakeCategoryLister{categories: map[string][]*youtube.VideoCategory{
+		"JP": {{ID: "10", Title: "音楽", Assignable: true}, {ID: "22", Title: "ブログ", Assignable: true}},
+		"US": {{ID: "22", Title: "People & Blogs", Assignable: true}, {ID: "44", Title: "Trailers"}},
+	}}
+	store := &fakeCategoryStore{}
+	cache := &categoryCache{}
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	names := cache.resolve(context.Background(), client, store, []string{"JP", "US", "KR"}, "ja", now)
+	want := map[string]string{"10": "音楽", "22": "ブログ", "44": "Trailers"}
+	if len(names) != len(want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+	for id, title := range want {
+		if names[id] != title {
+			t.Errorf("names[%s] = %q, want %q", id, names[id], title)
+		}
+	}
+	if len(store.records) != 4 || store.records[2].RegionCode != "US" || store.records[3].Assignable {
+		t.Errorf("stored records = %+v", store.records)
+	}
+
+	// Within the TTL the names come from the cache.
+	cache.resolve(context.Background(), client, store, []string{"JP", "US", "KR"}, "ja", now.Add(time.Hour))
+	if len(client.calls) != 3 {
+		t.Errorf("calls = %v, want one per region", client.calls)
+	}
+	// Other settings fetch again.
+	cache.resolve(context.Background(), client, nil, []string{"US"}, "", now.Add(time.Hour))
+	if got := client.calls[len(client.calls)-1]; got != "US/" {
+		t.Errorf("last call = %q, want US/", got)
+	}
+	if len(store.records) != 4 {
+		t.Errorf("nil store should not be written, got %d records", len(store.records))
+	}
+
+	// A failed refresh keeps the previous names.
+	client.categories = nil
+	names = cache.resolve(context.Background(), client, store, []string{"JP"}, "ja", now.Add(24*time.Hour))
+	if names["22"] != "People & Blogs" {
+		t.Errorf("names after failed refresh = %v", names)
+	}
+
+	if names := cache.resolve(context.Background(), client, store, nil, "ja", now); names != nil {
+		t.Errorf("no regions should disable the lookup, got %v", names)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/channelsource"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// channelSource serves the channel list when App.ChannelConfigSource is set.
+// It is created on first use and shared by all requests so its cache is too.
+var (
+	channelSourceMu sync.Mutex
+	channelSource   *channelsource.Source
+)
+
+// currentConfig returns cfg with its channel list replaced by the one from
+// App.ChannelConfigSource, if configured, or the one last saved through the
+// admin API. Otherwise it returns cfg as is. A tenant run gets the tenant's
+// configuration, set by withRunConfig.
+func currentConfig(ctx context.Context) (*config.Config, error) {
+	if c, ok := ctx.Value(runConfigKey{}).(*config.Config); ok {
+		return c, nil
+	}
+	if cfg.App.ChannelConfigSource == "" {
+		if channels, ok := savedFileChannels(); ok {
+			return cfg.WithChannels(channels), nil
+		}
+		return cfg, nil
+	}
+
+	src, err := sharedChannelSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := src.Channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.WithChannels(channels), nil
+}
+
+// sharedChannelSource returns channelSource, creating it on first use.
+func sharedChannelSource(ctx context.Context) (*channelsource.Source, error) {
+	channelSourceMu.Lock()
+	defer channelSourceMu.Unlock()
+	if channelSource == nil {
+		src, err := channelsource.New(ctx, cfg.App.ChannelConfigSource, cfg.App.ChannelConfigTTL, cfg.GCP.ProjectID, cfg.BigQuery.DatasetID)
+		if err != nil {
+			return nil, err
+		}
+		channelSource = src
+	}
+	return channelSource, nil
+}
+
+// runChannels manages the channels table used by CHANNEL_CONFIG_SOURCE=bigquery.
+//
+//	channels push  upserts the channels of the config file into the table
+//	channels list  prints the table as a YAML channels section
+func runChannels(args []string) int {
+	if len(args) == 0 || (args[0] != "push" && args[0] != "list") {
+		fmt.Fprintln(os.Stderr, "usage: fetcher channels push|list [-config path]")
+		return 2
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("channels "+sub, flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to spend")
+	fs.Parse(args[1:])
+
+	c, err := config.LoadUnvalidated(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	if c.GCP.ProjectID == "" {
+		log.Error("GCP project ID is required", nil, nil)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Error("Error creating BigQuery writer", err, nil)
+		return 1
+	}
+
+	if sub == "list" {
+		records, err := bqWriter.ReadChannels(ctx)
+		if err != nil {
+			log.Error("Failed to read channels table", err, nil)
+			return 1
+		}
+		out, err := yaml.Marshal(struct {
+			Channels []config.ChannelConfig `yaml:"channels"`
+		}{channelsource.FromRecords(records)})
+		if err != nil {
+			log.Error("Failed to encode channels", err, nil)
+			return 1
+		}
+		fmt.Print(string(out))
+		return 0
+	}
+
+	if err := config.ValidateChannels(c.Channels); err != nil {
+		log.Error("Invalid channel list", err, nil)
+		return 1
+	}
+	if err := bqWriter.EnsureChannelsTable(ctx); err != nil {
+		log.Error("Error ensuring channels table exists", err, nil)
+		return 1
+	}
+	if playlists := c.GetPlaylistIDs(); len(playlists) > 0 {
+		log.Warning(fmt.Sprintf("Skipping %d playlist entries; keep playlists in the configuration file or a spreadsheet", len(playlists)), nil, nil)
+	}
+	scheduled := 0
+	for _, ch := range c.Channels {
+		if ch.Schedule != "" && !ch.IsPlaylist() {
+			scheduled++
+		}
+	}
+	if scheduled > 0 {
+		log.Warning(fmt.Sprintf("The channels table has no schedule column; %d channels will be fetched on every run", scheduled), nil, nil)
+	}
+	records := channelsource.ToRecords(c.Channels, time.Now())
+	if err := bqWriter.UpsertChannels(ctx, records); err != nil {
+		log.Error("Failed to upsert channels", err, nil)
+		return 1
+	}
+	log.Info(fmt.Sprintf("Pushed %d channels", len(records)), map[string]string{
+		"dataset": c.BigQuery.DatasetID,
+		"table":   storage.ChannelsTableID,
+	})
+	return 0
+}
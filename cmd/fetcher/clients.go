@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// clientCheckTimeout bounds the startup check of the shared clients.
+const clientCheckTimeout = 10 * time.Second
+
+// sharedClients holds the API clients reused by every request instead of
+// being created per request: BigQuery clients by project and YouTube
+// clients by API key and options. Tenants with another project or key get
+// their own. Clients are created on first use, or at startup by
+// initClients, with a background context since they outlive requests.
+var sharedClients struct {
+	mu       sync.Mutex
+	bigquery map[string]*bigquery.Client
+	youtube  map[youtubeClientKey]*youtube.Client
+}
+
+// youtubeClientKey identifies the YouTube clients that can be shared.
+type youtubeClientKey struct {
+	apiKey  string
+	options youtube.ClientOptions
+}
+
+// bigQueryClient returns the shared BigQuery client for projectID.
+func bigQueryClient(projectID string) (*bigquery.Client, error) {
+	sharedClients.mu.Lock()
+	defer sharedClients.mu.Unlock()
+	if client, ok := sharedClients.bigquery[projectID]; ok {
+		return client, nil
+	}
+	client, err := storage.NewBigQueryClient(context.Background(), projectID)
+	if err != nil {
+		return nil, err
+	}
+	if sharedClients.bigquery == nil {
+		sharedClients.bigquery = make(map[string]*bigquery.Client)
+	}
+	sharedClients.bigquery[projectID] = client
+	return client, nil
+}
+
+// newBigQueryWriter creates a writer for the video trends table of c on the
+// shared BigQuery client of c's project.
+func newBigQueryWriter(c *config.Config) (*storage.BigQueryWriter, error) {
+	client, err := bigQueryClient(c.GCP.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewBigQueryWriterWithClient(client, c.BigQuery.DatasetID, c.BigQuery.TableID), nil
+}
+
+// youtubeClient returns a YouTube client for c with its own quota counters,
+// cloned from the shared client of c's API key and options.
+func youtubeClient(c *config.Config) (*youtube.Client, error) {
+	key := youtubeClientKey{apiKey: c.YouTube.APIKey, options: youtubeClientOptions(c)}
+	sharedClients.mu.Lock()
+	defer sharedClients.mu.Unlock()
+	if client, ok := sharedClients.youtube[key]; ok {
+		return client.Clone(), nil
+	}
+	client, err := youtube.NewClientWithOptions(context.Background(), key.apiKey, key.options)
+	if err != nil {
+		return nil, err
+	}
+	if sharedClients.youtube == nil {
+		sharedClients.youtube = make(map[youtubeClientKey]*youtube.Client)
+	}
+	sharedClients.youtube[key] = client
+	return client.Clone(), nil
+}
+
+// initClients creates the shared clients of the configuration at startup,
+// so the first request does not pay for it, and checks that the BigQuery
+// dataset is reachable. The YouTube API is only called when
+// youtube.verify_api_key is set, since each call spends quota. Failures are
+// logged; the clients are created again on first use.
+func initClients() {
+	if _, err := youtubeClient(cfg); err != nil {
+		log.Warning("Failed to create the YouTube client", err, nil)
+	}
+	if cfg.BigQuery.Disabled {
+		return
+	}
+	w, err := newBigQueryWriter(cfg)
+	if err != nil {
+		log.Warning("Failed to create the BigQuery client", err, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clientCheckTimeout)
+	defer cancel()
+	if err := w.CheckDataset(ctx); err != nil {
+		log.Warning("BigQuery connection check failed", err, map[string]string{"dataset": cfg.BigQuery.DatasetID})
+		return
+	}
+	log.Debug("BigQuery connection check passed", map[string]string{"dataset": cfg.BigQuery.DatasetID})
+}
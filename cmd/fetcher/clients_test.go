@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+func TestSharedClients(t *testing.T) {
+	t.Setenv("BIGQUERY_EMULATOR_HOST", "localhost:9050")
+	t.Cleanup(func() {
+		sharedClients.bigquery, sharedClients.youtube = nil, nil
+	})
+
+	a, err := bigQueryClient("project-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := bigQueryClient("project-a")
+	other, _ := bigQueryClient("project-b")
+	if a != again || a == other {
+		t.Error("BigQuery clients are not shared by project")
+	}
+
+	c := config.DefaultConfig()
+	c.YouTube.APIKey = "key-a"
+	first, err := youtubeClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := youtubeClient(c)
+	if first == second {
+		t.Error("runs share a YouTube client and its quota counters")
+	}
+	tenant := config.DefaultConfig()
+	tenant.YouTube.APIKey = "key-b"
+	youtubeClient(tenant)
+	if n := len(sharedClients.youtube); n != 2 {
+		t.Errorf("%d shared YouTube clients, want one per API key", n)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// dashboardFiles holds the static dashboard, a single page that reads the
+// query API from the browser.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// registerDashboard serves the dashboard under /dashboard/.
+func registerDashboard(mux *http.ServeMux) {
+	sub, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // the directory is embedded at build time
+	}
+	mux.Handle("GET /dashboard/", http.StripPrefix("/dashboard/", http.FileServerFS(sub)))
+	mux.Handle("GET /dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently))
+}
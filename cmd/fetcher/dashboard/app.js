@@ -0,0 +1,147 @@
+// Dashboard for the query API (/api/v1). Kept dependency-free so it can be
+// embedded in the binary.
+'use strict';
+
+const SPARK_DAYS = 14;
+const TOP_LIMIT = 10;
+
+const $ = (id) => document.getElementById(id);
+const fmt = (n) => Number(n).toLocaleString('ja-JP');
+
+async function getJSON(path) {
+  const res = await fetch(path);
+  if (!res.ok) throw new Error(`${path}: ${res.status} ${await res.text()}`);
+  return res.json();
+}
+
+function text(tag, value, className) {
+  const el = document.createElement(tag);
+  el.textContent = value;
+  if (className) el.className = className;
+  return el;
+}
+
+function row(cells) {
+  const tr = document.createElement('tr');
+  cells.forEach((c) => tr.appendChild(c));
+  return tr;
+}
+
+function showError(tbody, cols, err) {
+  const td = text('td', err.message, 'ng');
+  td.colSpan = cols;
+  tbody.replaceChildren(row([td]));
+}
+
+function sparkline(values) {
+  const ns = 'http://www.w3.org/2000/svg';
+  const svg = document.createElementNS(ns, 'svg');
+  svg.setAttribute('class', 'spark');
+  svg.setAttribute('viewBox', '0 0 120 24');
+  if (values.length < 2) return svg;
+  const min = Math.min(...values);
+  const span = Math.max(...values) - min || 1;
+  const points = values.map((v, i) => {
+    const x = (i / (values.length - 1)) * 118 + 1;
+    const y = 23 - ((v - min) / span) * 22;
+    return `${x.toFixed(1)},${y.toFixed(1)}`;
+  });
+  const line = document.createElementNS(ns, 'polyline');
+  line.setAttribute('points', points.join(' '));
+  svg.appendChild(line);
+  return svg;
+}
+
+async function loadStatus() {
+  const section = $('status');
+  try {
+    const s = await getJSON('/api/v1/status');
+    const run = s.last_run;
+    let summary;
+    if (!run) {
+      summary = text('p', 'このインスタンスではまだ実行されていません', 'muted');
+    } else if (run.running) {
+      summary = text('p', `実行中: ${run.run_id} (${run.scope}) ${run.started_at} 開始`);
+    } else if (run.error) {
+      summary = text('p', `失敗: ${run.run_id} (${run.scope}) ${run.finished_at} — ${run.error}`, 'ng');
+    } else {
+      summary = text('p', `成功: ${run.run_id} (${run.scope}) ${run.finished_at} 完了${run.dry_run ? ' [dry run]' : ''}`, 'ok');
+    }
+    const meta = text('p', `監視チャンネル ${s.channels} 件 / キーワード ${s.keywords} 件 / version ${s.version}`, 'muted');
+    section.replaceChildren(summary, meta);
+  } catch (err) {
+    section.replaceChildren(text('p', err.message, 'ng'));
+  }
+}
+
+async function loadChannels(date) {
+  const tbody = $('channels');
+  try {
+    const data = await getJSON(`/api/v1/channels?date=${date}`);
+    if (data.channels.length === 0) {
+      const td = text('td', 'データがありません', 'muted');
+      td.colSpan = 3;
+      tbody.replaceChildren(row([td]));
+      return;
+    }
+    tbody.replaceChildren(...data.channels.map((c) => row([
+      text('td', c.channel_name || c.channel_id),
+      text('td', fmt(c.videos), 'num'),
+      text('td', fmt(c.views), 'num'),
+    ])));
+  } catch (err) {
+    showError(tbody, 3, err);
+  }
+}
+
+function addDays(date, days) {
+  const d = new Date(`${date}T00:00:00Z`);
+  d.setUTCDate(d.getUTCDate() + days);
+  return d.toISOString().slice(0, 10);
+}
+
+async function loadTop(date, metric) {
+  const tbody = $('top');
+  $('metric-label').textContent = $('metric').selectedOptions[0].textContent;
+  try {
+    const data = await getJSON(`/api/v1/top?date=${date}&metric=${metric}&limit=${TOP_LIMIT}`);
+    const from = addDays(date, -(SPARK_DAYS - 1));
+    const rows = data.videos.map((v, i) => {
+      const spark = document.createElement('td');
+      getJSON(`/api/v1/videos/${encodeURIComponent(v.video_id)}/timeseries?from=${from}&to=${date}`)
+        .then((ts) => spark.appendChild(sparkline(ts.points.map((p) => p[metric]))))
+        .catch(() => spark.appendChild(text('span', '—', 'muted')));
+
+      const title = document.createElement('td');
+      const link = text('a', v.title || v.video_id);
+      link.href = `https://www.youtube.com/watch?v=${encodeURIComponent(v.video_id)}`;
+      link.target = '_blank';
+      link.rel = 'noopener';
+      title.appendChild(link);
+
+      return row([
+        text('td', i + 1, 'num'),
+        title,
+        text('td', v.channel_name || v.channel_id),
+        text('td', fmt(v[metric]), 'num'),
+        spark,
+      ]);
+    });
+    tbody.replaceChildren(...rows);
+  } catch (err) {
+    showError(tbody, 5, err);
+  }
+}
+
+function refresh() {
+  const date = $('date').value;
+  loadChannels(date);
+  loadTop(date, $('metric').value);
+}
+
+// sv-SE formats as YYYY-MM-DD in the browser's timezone.
+$('date').value = new Date().toLocaleDateString('sv-SE');
+$('date').addEventListener('change', refresh);
+$('metric').addEventListener('change', refresh);
+loadStatus();
+refresh();
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+	mux := http.NewServeMux()
+	registerDashboard(mux)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/dashboard/", wantCode: http.StatusOK, wantBody: "<title>YouTube Trend Tracker</title>"},
+		{path: "/dashboard/app.js", wantCode: http.StatusOK, wantBody: "/api/v1/status"},
+		{path: "/dashboard", wantCode: http.StatusMovedPermanently},
+		{path: "/dashboard/missing.js", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rr.Code != tt.wantCode {
+			t.Errorf("GET %s status = %d, want %d", tt.path, rr.Code, tt.wantCode)
+		}
+		if !strings.Contains(rr.Body.String(), tt.wantBody) {
+			t.Errorf("GET %s body does not contain %q", tt.path, tt.wantBody)
+		}
+	}
+}
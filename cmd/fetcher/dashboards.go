@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+)
+
+// runDashboards generates Grafana monitoring for the /metrics endpoint.
+//
+//	dashboards generate  prints the dashboard JSON, or with -out writes the
+//	                     dashboard and (given -datasource) alert rules
+func runDashboards(args []string) int {
+	if len(args) == 0 || args[0] != "generate" {
+		fmt.Fprintln(os.Stderr, "usage: fetcher dashboards generate [-datasource uid] [-out dir]")
+		return 2
+	}
+
+	defaults := metrics.DefaultGrafanaOptions()
+	fs := flag.NewFlagSet("dashboards generate", flag.ExitOnError)
+	title := fs.String("title", defaults.Title, "Dashboard title")
+	datasource := fs.String("datasource", "", "Prometheus datasource UID (empty: choose on import; required for alert rules)")
+	outDir := fs.String("out", "", "Directory to write dashboard.json and alerts.json to (default: print the dashboard)")
+	quotaWarning := fs.Float64("quota-warning", defaults.QuotaWarning, "Alert when fewer quota units remain")
+	staleAfter := fs.Duration("stale-after", time.Duration(defaults.StaleAfterSeconds)*time.Second, "Alert when the last successful run is older than this")
+	fs.Parse(args[1:])
+
+	opts := metrics.GrafanaOptions{
+		Title:             *title,
+		DatasourceUID:     *datasource,
+		QuotaWarning:      *quotaWarning,
+		StaleAfterSeconds: staleAfter.Seconds(),
+	}
+
+	dashboard, err := metrics.GrafanaDashboard(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate dashboard: %v\n", err)
+		return 1
+	}
+	if *outDir == "" {
+		os.Stdout.Write(dashboard)
+		return 0
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create %s: %v\n", *outDir, err)
+		return 1
+	}
+	type file struct {
+		name string
+		data []byte
+	}
+	files := []file{{"dashboard.json", dashboard}}
+	if opts.DatasourceUID != "" {
+		alerts, err := metrics.GrafanaAlertRules(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate alert rules: %v\n", err)
+			return 1
+		}
+		files = append(files, file{"alerts.json", alerts})
+	} else {
+		fmt.Fprintln(os.Stderr, "skipping alerts.json: -datasource is required for alert rules")
+	}
+	for _, f := range files {
+		path := filepath.Join(*outDir, f.name)
+		if err := os.WriteFile(path, f.data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Println(path)
+	}
+	return 0
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/digest"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// newDigestSource and newDigestSender create the digest dependencies; tests
+// replace them.
+var (
+	newDigestSource = func(ctx context.Context) (digest.Source, error) {
+		return newBigQueryWriter(cfg)
+	}
+	newDigestSender = digest.NewSender
+)
+
+// buildDigest renders the digest of the enabled channels for the period of
+// the given kind ending yesterday.
+func buildDigest(ctx context.Context, kind string) (*digest.Message, error) {
+	c, err := currentConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel list: %w", err)
+	}
+	var channels []config.ChannelConfig
+	for _, ch := range c.Channels {
+		if ch.Enabled {
+			channels = append(channels, ch)
+		}
+	}
+
+	src, err := newDigestSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := digest.Build(ctx, src, channels, digest.PeriodEnding(kind, today()), cfg.Location())
+	if err != nil {
+		return nil, err
+	}
+	return d.Message(cfg.Digest.From, cfg.Digest.Recipients)
+}
+
+// sendDigest builds the digest and sends it with the configured provider.
+func sendDigest(ctx context.Context, kind string) (*digest.Message, error) {
+	sender, err := newDigestSender(cfg.Digest)
+	if err != nil {
+		return nil, err
+	}
+	m, err := buildDigest(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := sender.Send(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// digestPeriod returns the period kind requested, defaulting to the
+// configured one.
+func digestPeriod(kind string) (string, error) {
+	switch kind {
+	case "":
+		return cfg.Digest.Period, nil
+	case config.DigestPeriodDaily, config.DigestPeriodWeekly:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("invalid period %q (must be %q or %q)", kind, config.DigestPeriodDaily, config.DigestPeriodWeekly)
+	}
+}
+
+// digestResponse is the body of POST /digest once the digest is sent.
+type digestResponse struct {
+	Status     string `json:"status"`
+	Subject    string `json:"subject"`
+	Recipients int    `json:"recipients"`
+}
+
+// digestHandler serves POST /digest?period=daily|weekly, typically called by
+// a Cloud Scheduler job. With preview=true (GET or POST) the digest is
+// returned as HTML instead of being sent, which also works without a
+// provider configured.
+func digestHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := requestContext(r, "")
+	log := logger.FromContext(ctx)
+
+	kind, err := digestPeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	preview := r.URL.Query().Get("preview") == "true"
+	if r.Method != http.MethodPost && !(preview && r.Method == http.MethodGet) {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if preview {
+		m, err := buildDigest(ctx, kind)
+		if err != nil {
+			log.Error("Failed to build digest", err, map[string]string{"period": kind})
+			http.Error(w, "Failed to build digest", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, m.HTML)
+		return
+	}
+
+	if cfg.Digest.Provider == "" {
+		http.Error(w, "Digest is not configured (set DIGEST_PROVIDER)", http.StatusBadRequest)
+		return
+	}
+	m, err := sendDigest(ctx, kind)
+	if err != nil {
+		log.Error("Failed to send digest", err, map[string]string{"period": kind})
+		http.Error(w, "Failed to send digest", http.StatusInternalServerError)
+		return
+	}
+	log.Info("Digest sent", map[string]string{
+		"period":     kind,
+		"recipients": fmt.Sprintf("%d", len(m.To)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(digestResponse{Status: "sent", Subject: m.Subject, Recipients: len(m.To)})
+}
+
+// runDigest sends the digest once, for Cloud Run Jobs or a local check.
+//
+//	digest [-period daily|weekly] [-preview]  sends the digest, or with
+//	                                           -preview prints its HTML
+func runDigest(args []string) int {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	period := fs.String("period", "", "daily or weekly (default: digest.period)")
+	preview := fs.Bool("preview", false, "Print the digest HTML instead of sending it")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Maximum time to spend")
+	fs.Parse(args)
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	cfg = c
+	kind, err := digestPeriod(*period)
+	if err != nil {
+		log.Error("Invalid digest period", err, nil)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if *preview {
+		m, err := buildDigest(ctx, kind)
+		if err != nil {
+			log.Error("Failed to build digest", err, nil)
+			return 1
+		}
+		fmt.Fprint(os.Stdout, m.HTML)
+		return 0
+	}
+	if cfg.Digest.Provider == "" {
+		log.Error("Digest is not configured (set DIGEST_PROVIDER)", nil, nil)
+		return 1
+	}
+	m, err := sendDigest(ctx, kind)
+	if err != nil {
+		log.Error("Failed to send digest", err, map[string]string{"period": kind})
+		return 1
+	}
+	log.Info("Digest sent", map[string]string{"period": kind, "subject": m.Subject})
+	return 0
+}
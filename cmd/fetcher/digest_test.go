@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/digest"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeDigestSource struct{}
+
+func (fakeDigestSource) ChannelPerformance(ctx context.Context, from, to civil.Date, timezone string) ([]storage.ChannelPerformance, error) {
+	return []storage.ChannelPerformance{{ChannelID: "UC1", ChannelName: "One", ViewsGained: 10}}, nil
+}
+
+func (fakeDigestSource) FailedRuns(ctx context.Context, from, to time.Time) ([]storage.FetchRunRecord, error) {
+	return nil, nil
+}
+
+func (fakeDigestSource) UploadTimes(ctx context.Context, date civil.Date) ([]storage.UploadTime, error) {
+	return nil, nil
+}
+
+type recordingSender struct {
+	sent []*digest.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, m *digest.Message) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func TestDigestHandler(t *testing.T) {
+	origCfg, origSource, origSender := cfg, newDigestSource, newDigestSender
+	defer func() { cfg, newDigestSource, newDigestSender = origCfg, origSource, origSender }()
+
+	cfg = config.DefaultConfig()
+	cfg.Channels = []config.ChannelConfig{{ID: "UC1", Enabled: true}, {ID: "UC2", Enabled: false}}
+	sender := &recordingSender{}
+	newDigestSource = func(ctx context.Context) (digest.Source, error) { return fakeDigestSource{}, nil }
+	newDigestSender = func(config.DigestConfig) (digest.Sender, error) { return sender, nil }
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		digestHandler(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/digest"); rec.Code != http.StatusBadRequest {
+		t.Errorf("without a provider: got %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/digest"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET without preview: got %d, want 405", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/digest?period=monthly"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid period: got %d, want 400", rec.Code)
+	}
+
+	rec := serve(http.MethodGet, "/digest?preview=true")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "One") || strings.Contains(rec.Body.String(), "UC2") {
+		t.Errorf("preview: got %d %s", rec.Code, rec.Body)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("preview sent %d messages", len(sender.sent))
+	}
+
+	cfg.Digest.Provider = config.DigestProviderSendGrid
+	cfg.Digest.From = "from@example.com"
+	cfg.Digest.Recipients = []string{"a@example.com"}
+	rec = serve(http.MethodPost, "/digest?period=weekly")
+	if rec.Code != http.StatusOK || len(sender.sent) != 1 {
+		t.Fatalf("send: got %d %s, sent %d", rec.Code, rec.Body, len(sender.sent))
+	}
+	if m := sender.sent[0]; !strings.Contains(m.Subject, " 〜 ") || m.To[0] != "a@example.com" {
+		t.Errorf("sent %+v, want a weekly digest to a@example.com", m)
+	}
+}
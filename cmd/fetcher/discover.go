@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/discovery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// runDiscover proposes channels related to the tracked ones: the channels
+// featured by the seed channels and, with -query, the results of channel
+// searches. The proposals are printed as entries of the channels section,
+// appended to the configuration file with -write so that the change can be
+// reviewed as a diff, or recorded in the discovered_channels table with
+// -out table.
+func runDiscover(args []string) int {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	var seeds, queries channelList
+	fs.Var(&seeds, "seed", "Seed channel ID (repeatable; defaults to all enabled channels)")
+	fs.Var(&queries, "query", "Channel search query (repeatable; 100 quota units each)")
+	searchResults := fs.Int64("search-results", 10, "Channels taken from each search (at most 50)")
+	minSubscribers := fs.Int64("min-subscribers", 0, "Skip channels with fewer subscribers")
+	limit := fs.Int("limit", 20, "Maximum number of channels to propose (0 = all)")
+	out := fs.String("out", "yaml", "Output: yaml (channels section entries) or table (discovered_channels)")
+	write := fs.Bool("write", false, "Append the proposed channels to the configuration file (with -out yaml)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Maximum time to spend")
+	fs.Parse(args)
+
+	if *out != "yaml" && *out != "table" {
+		fmt.Fprintf(os.Stderr, "invalid -out %q (must be yaml or table)\n", *out)
+		return 2
+	}
+
+	var err error
+	cfg, err = config.Load(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	if *out == "table" && cfg.BigQuery.Disabled {
+		log.Error("-out table needs BigQuery, which is disabled", nil, nil)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(logger.WithContext(context.Background(), log), *timeout)
+	defer cancel()
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+		return 1
+	}
+	opts := discovery.Options{
+		Seeds:          seeds,
+		Queries:        queries,
+		SearchResults:  *searchResults,
+		MinSubscribers: *minSubscribers,
+		Limit:          *limit,
+	}
+	if len(opts.Seeds) == 0 {
+		for _, ch := range c.Channels {
+			if ch.Enabled && !ch.IsPlaylist() {
+				opts.Seeds = append(opts.Seeds, ch.ID)
+			}
+		}
+	}
+	for _, ch := range c.Channels {
+		opts.Exclude = append(opts.Exclude, ch.ID)
+	}
+	if len(opts.Seeds) == 0 && len(opts.Queries) == 0 {
+		log.Error("No seed channels or queries to discover from", nil, nil)
+		return 1
+	}
+
+	ytClient, err := newYouTubeClient(ctx)
+	if err != nil {
+		log.Error("Error creating YouTube client", err, nil)
+		return 1
+	}
+	candidates, err := discovery.Discover(ctx, ytClient, opts)
+	if err != nil {
+		log.Error("Channel discovery failed", err, nil)
+		return 1
+	}
+	log.Info(fmt.Sprintf("Discovered %d channels", len(candidates)), map[string]string{
+		"seeds":      fmt.Sprint(len(opts.Seeds)),
+		"queries":    fmt.Sprint(len(opts.Queries)),
+		"quota_used": fmt.Sprint(ytClient.QuotaUsed()),
+	})
+
+	if *out == "table" {
+		bqWriter, err := newTableWriter(ctx, cfg)
+		if err != nil {
+			log.Error("Error creating BigQuery writer", err, nil)
+			return 1
+		}
+		if err := bqWriter.EnsureDiscoveredChannelsTable(ctx); err != nil {
+			log.Error("Error ensuring discovered channels table exists", err, nil)
+			return 1
+		}
+		if err := bqWriter.UpsertDiscoveredChannels(ctx, discoveredChannelRecords(candidates, time.Now())); err != nil {
+			log.Error("Failed to record discovered channels", err, nil)
+			return 1
+		}
+		return 0
+	}
+
+	if len(candidates) == 0 {
+		return 0
+	}
+	entries, err := channelEntriesYAML(discovery.ChannelConfigs(candidates))
+	if err != nil {
+		log.Error("Failed to encode channels", err, nil)
+		return 1
+	}
+	if !*write {
+		fmt.Print(string(entries))
+		return 0
+	}
+	orig, err := os.ReadFile(*configPath)
+	if err != nil {
+		log.Error("Failed to read configuration file", err, nil)
+		return 1
+	}
+	updated, err := appendChannelEntries(orig, entries)
+	if err != nil {
+		log.Error("Failed to add channels to configuration file", err, map[string]string{"path": *configPath})
+		return 1
+	}
+	if err := os.WriteFile(*configPath, updated, 0o644); err != nil {
+		log.Error("Failed to write configuration file", err, nil)
+		return 1
+	}
+	log.Info(fmt.Sprintf("Added %d channels to %s", len(candidates), *configPath), nil)
+	return 0
+}
+
+// discoveredChannelRecords converts candidates to discovered_channels rows.
+func discoveredChannelRecords(candidates []*discovery.Candidate, now time.Time) []storage.DiscoveredChannelRecord {
+	records := make([]storage.DiscoveredChannelRecord, len(candidates))
+	for i, c := range candidates {
+		records[i] = storage.DiscoveredChannelRecord{
+			ChannelID:    c.ID,
+			Title:        c.Title,
+			Country:      c.Country,
+			Subscribers:  c.Subscribers,
+			Videos:       c.Videos,
+			Views:        c.Views,
+			Sources:      c.Sources,
+			Score:        int64(c.Score()),
+			DiscoveredAt: now,
+		}
+	}
+	return records
+}
+
+// channelEntriesYAML encodes channels as list items indented to sit under
+// the top-level channels key, each preceded by a blank line like the
+// entries of configs/config.yaml.
+func channelEntriesYAML(channels []config.ChannelConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, ch := range channels {
+		var item bytes.Buffer
+		enc := yaml.NewEncoder(&item)
+		enc.SetIndent(2)
+		if err := enc.Encode([]config.ChannelConfig{ch}); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		for _, line := range strings.SplitAfter(item.String(), "\n") {
+			if line != "" {
+				buf.WriteString("  " + line)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// appendChannelEntries inserts entries at the end of the block of the
+// top-level channels key of a configuration file, leaving the rest of the
+// file, comments included, as it was.
+func appendChannelEntries(file, entries []byte) ([]byte, error) {
+	lines := strings.SplitAfter(string(file), "\n")
+	_, end, err := channelsBlock(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines[:end] {
+		buf.WriteString(line)
+	}
+	if end > 0 && !strings.HasSuffix(lines[end-1], "\n") {
+		buf.WriteString("\n")
+	}
+	buf.Write(entries)
+	for _, line := range lines[end:] {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
+
+// replaceChannelEntries replaces the content of the block of the top-level
+// channels key of a configuration file with entries, leaving the rest of
+// the file as it was. Comments inside the block are lost.
+func replaceChannelEntries(file, entries []byte) ([]byte, error) {
+	lines := strings.SplitAfter(string(file), "\n")
+	start, end, err := channelsBlock(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines[:start] {
+		buf.WriteString(line)
+	}
+	buf.WriteString("channels:\n")
+	buf.Write(entries)
+	for _, line := range lines[end:] {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
+
+// channelsBlock returns the line of the top-level channels key and the line
+// after its last entry.
+func channelsBlock(lines []string) (int, int, error) {
+	start := -1
+	for i, line := range lines {
+		if strings.TrimRight(line, " \r\n") == "channels:" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return 0, 0, errors.New("no top-level channels: block")
+	}
+	// The block ends at the next line starting at column 0, such as the
+	// next key or the comment above it; blank lines before it stay after
+	// the entries.
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if line := lines[i]; line != "" && line[0] != ' ' && line[0] != '\t' && strings.TrimSpace(line) != "" {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return start, end, nil
+}
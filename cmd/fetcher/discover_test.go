@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAppendChannelEntries(t *testing.T) {
+	file := `app:
+  environment: test
+
+channels:
+  - id: UC1
+    name: One
+    enabled: true
+    
+
+# Keywords
+keywords: []
+`
+	entries, err := channelEntriesYAML([]config.ChannelConfig{
+		{ID: "UC2", Name: "Two", Description: "Discovered via featured:UC1", Enabled: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := appendChannelEntries([]byte(file), entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `app:
+  environment: test
+
+channels:
+  - id: UC1
+    name: One
+    enabled: true
+
+  - id: UC2
+    name: Two
+    description: Discovered via featured:UC1
+    enabled: true
+    
+
+# Keywords
+keywords: []
+`
+	if string(got) != want {
+		t.Errorf("appendChannelEntries() =\n%s\nwant\n%s", got, want)
+	}
+
+	var parsed struct {
+		Channels []config.ChannelConfig `yaml:"channels"`
+	}
+	if err := yaml.Unmarshal(got, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Channels) != 2 || parsed.Channels[1].ID != "UC2" {
+		t.Errorf("parsed channels = %+v", parsed.Channels)
+	}
+}
+
+func TestAppendChannelEntries_NoChannels(t *testing.T) {
+	if _, err := appendChannelEntries([]byte("app: {}\n"), []byte("\n  - id: UC1\n")); err == nil {
+		t.Error("appendChannelEntries() error = nil, want an error without a channels block")
+	}
+}
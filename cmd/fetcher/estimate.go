@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/fetcher"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// runPlan returns the work of a run of channelIDs under c.
+func runPlan(c *config.Config, channelIDs []string) fetcher.RunPlan {
+	playlists := make(map[string]bool)
+	for _, id := range c.GetPlaylistIDs() {
+		playlists[id] = true
+	}
+	comments := make(map[string]bool)
+	for _, id := range c.GetCommentChannelIDs() {
+		comments[id] = true
+	}
+	plan := fetcher.RunPlan{
+		Channels:       make([]fetcher.PlannedChannel, 0, len(channelIDs)),
+		MaxVideos:      c.App.MaxVideosPerChannel,
+		StatusLookback: c.App.StatusLookbackDays > 0,
+		Keywords:       len(c.GetEnabledKeywords()),
+		ChannelStats:   c.Analytics.SubscriberMilestones,
+	}
+	for _, id := range channelIDs {
+		plan.Channels = append(plan.Channels, fetcher.PlannedChannel{ID: id, Playlist: playlists[id], Comments: comments[id]})
+	}
+	return plan
+}
+
+// runQuotaBudget returns the quota a run may spend: youtube.run_quota_budget
+// capped by what is left of youtube.quota_limit when the run spends that
+// quota. ok is false when neither applies.
+func runQuotaBudget(ctx context.Context, c *config.Config, now time.Time) (budget int64, ok bool) {
+	if c.YouTube.RunQuotaBudget > 0 {
+		budget, ok = int64(c.YouTube.RunQuotaBudget), true
+	}
+	if c.YouTube.QuotaLimit > 0 && sharesQuota(c) {
+		if left := quotaRemaining(ctx, now); !ok || left < budget {
+			budget, ok = left, true
+		}
+	}
+	return budget, ok
+}
+
+// applyQuotaBudget estimates the quota cost of fetching channelIDs, in
+// priority order, and returns the channels the run fetches. Over budget,
+// youtube.over_budget either defers the lowest-priority channels that do
+// not fit, like the in-run quota budget, or refuses the run; a run whose
+// first channel does not fit is refused either way. The estimate is logged,
+// kept in the run status and reported by dry runs.
+func applyQuotaBudget(ctx context.Context, c *config.Config, channelIDs []string, dry *storage.DryRunWriter) ([]string, error) {
+	log := logger.FromContext(ctx)
+	rc := runConfig(ctx)
+
+	est := fetcher.EstimateRun(runPlan(c, channelIDs))
+	lastRun.update(ctx, func(s *runStatus) { s.QuotaEstimate += est.Units })
+	if dry != nil {
+		dry.SetQuotaEstimate(est.Units, est.ByMethod)
+	}
+	labels := map[string]string{
+		"channels":  fmt.Sprintf("%d", len(channelIDs)),
+		"by_method": formatQuotaByMethod(est.ByMethod),
+	}
+	budget, ok := runQuotaBudget(ctx, rc, time.Now())
+	if ok {
+		labels["budget"] = fmt.Sprintf("%d", budget)
+	}
+	log.Info(fmt.Sprintf("Estimated quota cost of this run: %d units", est.Units), labels)
+	if !ok || est.Units <= budget {
+		return channelIDs, nil
+	}
+
+	n := est.Fit(budget)
+	if rc.YouTube.OverBudget == config.OverBudgetRefuse || n == 0 {
+		msg := fmt.Sprintf("Estimated quota cost of %d units exceeds the budget of %d units", est.Units, budget)
+		log.Error(msg, nil, labels)
+		return nil, &fetchError{message: msg}
+	}
+	deferred := channelIDs[n:]
+	log.Warning(fmt.Sprintf("Deferring %d channels to stay within the quota budget of %d units", len(deferred), budget), nil, map[string]string{
+		"deferred_channels": strings.Join(deferred, ","),
+	})
+	lastRun.update(ctx, func(s *runStatus) {
+		s.ChannelsDeferred += int64(len(deferred))
+		s.DeferredChannels = append(s.DeferredChannels, deferred...)
+	})
+	return channelIDs[:n], nil
+}
+
+// formatQuotaByMethod renders an estimate breakdown as "method=units,...".
+func formatQuotaByMethod(byMethod map[string]int64) string {
+	parts := make([]string, 0, len(byMethod))
+	for method, units := range byMethod {
+		parts = append(parts, fmt.Sprintf("%s=%d", method, units))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestApplyQuotaBudget(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+
+	cfg = config.DefaultConfig()
+	cfg.YouTube.QuotaLimit = 0
+	cfg.App.MaxVideosPerChannel = 50
+	cfg.Channels = []config.ChannelConfig{
+		{ID: "UC1", Enabled: true},
+		{ID: "UC2", Enabled: true, TrackComments: true},
+		{ID: "UC3", Enabled: true},
+	}
+	channels := []string{"UC1", "UC2", "UC3"}
+	ctx := context.Background()
+
+	// 3 units per channel and 50 for UC2's comments.
+	dry := storage.NewDryRunWriter()
+	got, err := applyQuotaBudget(ctx, cfg, channels, dry)
+	if err != nil || !slices.Equal(got, channels) {
+		t.Errorf("without a budget: applyQuotaBudget() = %v, %v", got, err)
+	}
+	if dry.QuotaEstimate != 59 || dry.QuotaEstimateByMethod["commentThreads.list"] != 50 {
+		t.Errorf("dry run estimate = %d %v, want 59", dry.QuotaEstimate, dry.QuotaEstimateByMethod)
+	}
+
+	cfg.YouTube.RunQuotaBudget = 58
+	if got, err := applyQuotaBudget(ctx, cfg, channels, nil); err != nil || !slices.Equal(got, channels[:2]) {
+		t.Errorf("trim: applyQuotaBudget() = %v, %v, want UC3 deferred", got, err)
+	}
+	cfg.YouTube.RunQuotaBudget = 2
+	var fe *fetchError
+	if _, err := applyQuotaBudget(ctx, cfg, channels, nil); !errors.As(err, &fe) {
+		t.Errorf("trim without room for a channel: applyQuotaBudget() error = %v", err)
+	}
+	cfg.YouTube.RunQuotaBudget = 58
+	cfg.YouTube.OverBudget = config.OverBudgetRefuse
+	if _, err := applyQuotaBudget(ctx, cfg, channels, nil); !errors.As(err, &fe) {
+		t.Errorf("refuse: applyQuotaBudget() error = %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// exportDefaultDays is the range exported when -from is not given.
+const exportDefaultDays = 30
+
+// runExport writes stored rows to files for analysis outside BigQuery, e.g.
+// in pandas or DuckDB.
+//
+//	export -format csv|jsonl|parquet -from 2025-01-01 -to 2025-02-01 -out gs://bucket/path
+//
+// gs:// destinations are written by BigQuery itself (EXPORT DATA), as one
+// or more files; other destinations are a local file, or stdout for "-",
+// and support csv and jsonl.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	format := fs.String("format", storage.ExportCSV, "csv, jsonl or parquet (parquet needs a gs:// -out)")
+	from := fs.String("from", "", "First dt to export, YYYY-MM-DD (default: 30 days before -to)")
+	to := fs.String("to", "", "Last dt to export, YYYY-MM-DD, inclusive (default: today)")
+	out := fs.String("out", "-", "gs://<bucket>/<path>, a local file, or - for stdout")
+	table := fs.String("table", "", "Table of the dataset to export (default: bigquery.table_id)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Maximum time to spend")
+	fs.Parse(args)
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	cfg = c
+
+	opts, err := exportOptions(*format, *from, *to, *table, today())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 2
+	}
+	toGCS := strings.HasPrefix(*out, "gs://")
+	if opts.Format == storage.ExportParquet && !toGCS {
+		fmt.Fprintln(os.Stderr, "export: parquet files are written by BigQuery; use a gs:// -out")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	w, err := newTableWriter(ctx, cfg)
+	if err != nil {
+		log.Error("Error creating BigQuery writer", err, nil)
+		return 1
+	}
+
+	if toGCS {
+		uri, err := w.ExportToGCS(ctx, *out, opts)
+		if err != nil {
+			log.Error("Export failed", err, nil)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "exported %s to %s to %s\n", opts.From, opts.To, uri)
+		return 0
+	}
+
+	var dst io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Error("Error creating export file", err, map[string]string{"path": *out})
+			return 1
+		}
+		defer f.Close()
+		dst = f
+	}
+	n, err := w.ExportRows(ctx, dst, opts)
+	if err != nil {
+		log.Error("Export failed", err, nil)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows from %s to %s\n", n, opts.From, opts.To)
+	return 0
+}
+
+// exportOptions validates the export flags; empty dates default to the 30
+// days up to today.
+func exportOptions(format, from, to, table string, today civil.Date) (storage.ExportOptions, error) {
+	opts := storage.ExportOptions{Format: strings.ToLower(format), Table: table, To: today}
+	var err error
+	if to != "" {
+		if opts.To, err = civil.ParseDate(to); err != nil {
+			return opts, fmt.Errorf("invalid -to %q: %w", to, err)
+		}
+	}
+	opts.From = opts.To.AddDays(-exportDefaultDays)
+	if from != "" {
+		if opts.From, err = civil.ParseDate(from); err != nil {
+			return opts, fmt.Errorf("invalid -from %q: %w", from, err)
+		}
+	}
+	switch opts.Format {
+	case storage.ExportCSV, storage.ExportJSONL, storage.ExportParquet:
+	default:
+		return opts, fmt.Errorf("invalid -format %q (must be csv, jsonl or parquet)", format)
+	}
+	if opts.To.Before(opts.From) {
+		return opts, fmt.Errorf("-to %s is before -from %s", opts.To, opts.From)
+	}
+	return opts, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestExportOptions(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 3, Day: 31}
+
+	opts, err := exportOptions("Parquet", "2025-01-01", "2025-02-01", "", today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Format != storage.ExportParquet || opts.From.String() != "2025-01-01" || opts.To.String() != "2025-02-01" {
+		t.Errorf("exportOptions() = %+v", opts)
+	}
+
+	opts, err = exportOptions("csv", "", "", "tag_trends", today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.To != today || opts.From != today.AddDays(-exportDefaultDays) || opts.Table != "tag_trends" {
+		t.Errorf("exportOptions() defaults = %+v", opts)
+	}
+
+	for _, bad := range [][3]string{
+		{"xlsx", "", ""},
+		{"csv", "2025-02-30", ""},
+		{"csv", "2025-02-01", "2025-01-01"},
+	} {
+		if _, err := exportOptions(bad[0], bad[1], bad[2], "", today); err == nil {
+			t.Errorf("exportOptions(%q, %q, %q) succeeded, want error", bad[0], bad[1], bad[2])
+		}
+	}
+}
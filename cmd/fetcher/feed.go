@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/digest"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/notify"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// feedDefaultLimit is the number of videos in the trending feed unless the
+// limit parameter says otherwise.
+const feedDefaultLimit = 20
+
+// Atom feed of the trending videos, with Media RSS thumbnails.
+type (
+	atomFeed struct {
+		XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+		MediaNS   string      `xml:"xmlns:media,attr"`
+		ID        string      `xml:"id"`
+		Title     string      `xml:"title"`
+		Updated   time.Time   `xml:"updated"`
+		Links     []atomLink  `xml:"link"`
+		Generator string      `xml:"generator"`
+		Entries   []atomEntry `xml:"entry"`
+	}
+	atomLink struct {
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr,omitempty"`
+		Href string `xml:"href,attr"`
+	}
+	atomEntry struct {
+		ID        string          `xml:"id"`
+		Title     string          `xml:"title"`
+		Link      atomLink        `xml:"link"`
+		Author    atomAuthor      `xml:"author"`
+		Published time.Time       `xml:"published"`
+		Updated   time.Time       `xml:"updated"`
+		Summary   string          `xml:"summary"`
+		Content   atomContent     `xml:"content"`
+		Thumbnail *mediaThumbnail `xml:"media:thumbnail,omitempty"`
+	}
+	atomAuthor struct {
+		Name string `xml:"name"`
+		URI  string `xml:"uri"`
+	}
+	atomContent struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	}
+	mediaThumbnail struct {
+		URL string `xml:"url,attr"`
+	}
+)
+
+// trendingFeedHandler serves GET /feeds/trending.xml?limit=: an Atom feed of
+// the videos with the highest latest trend score, for feed readers and
+// automation tools. Each day's ranking has its own entries, so a video
+// still trending the next day shows up again.
+func trendingFeedHandler(w http.ResponseWriter, r *http.Request) {
+	limit := feedDefaultLimit
+	if r.URL.Query().Get("limit") != "" {
+		var err error
+		if limit, err = limitParam(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := requestContext(r, "")
+	log := logger.FromContext(ctx)
+
+	q, err := newTrendQuerier(ctx)
+	if err != nil {
+		log.Error("Error creating BigQuery client", err, nil)
+		http.Error(w, "Failed to create BigQuery client", http.StatusInternalServerError)
+		return
+	}
+	videos, err := q.TrendingVideos(ctx, today(), limit)
+	if err != nil {
+		log.Error("Failed to read trending videos", err, nil)
+		http.Error(w, "Query failed", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := xml.MarshalIndent(trendingFeed(requestURL(r), videos, time.Now().UTC()), "", "  ")
+	if err != nil {
+		log.Error("Failed to encode trending feed", err, nil)
+		http.Error(w, "Query failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	fmt.Fprint(w, xml.Header)
+	w.Write(body)
+	w.Write([]byte("\n"))
+}
+
+// trendingFeed builds the feed served at self from videos, ranked. The feed
+// is updated when the scores were computed, or at now without videos.
+func trendingFeed(self string, videos []storage.TrendingVideo, now time.Time) *atomFeed {
+	feed := &atomFeed{
+		MediaNS:   "http://search.yahoo.com/mrss/",
+		ID:        self,
+		Title:     "YouTube トレンド動画",
+		Updated:   now,
+		Links:     []atomLink{{Rel: "self", Type: "application/atom+xml", Href: self}},
+		Generator: "youtube-trend-tracker",
+	}
+	if len(videos) > 0 {
+		feed.Title = fmt.Sprintf("YouTube トレンド動画 (%s)", videos[0].Dt)
+		feed.Updated = videos[0].ComputedAt.UTC()
+	}
+	for i, v := range videos {
+		url := notify.VideoURL(v.VideoID)
+		summary := fmt.Sprintf("%d 位 / スコア %.2f / %s 回再生 (+%s)",
+			i+1, v.Score, digest.FormatNumber(v.Views), digest.FormatNumber(v.ViewsGained))
+		var content strings.Builder
+		if v.ThumbnailURL != "" {
+			fmt.Fprintf(&content, `<p><a href="%s"><img src="%s" alt="%s"></a></p>`,
+				html.EscapeString(url), html.EscapeString(v.ThumbnailURL), html.EscapeString(v.Title))
+		}
+		fmt.Fprintf(&content, "<p>%s</p>", html.EscapeString(summary))
+
+		entry := atomEntry{
+			ID:        fmt.Sprintf("tag:youtube-trend-tracker,%s:%s", v.Dt, v.VideoID),
+			Title:     v.Title,
+			Link:      atomLink{Rel: "alternate", Href: url},
+			Author:    atomAuthor{Name: v.ChannelName, URI: "https://www.youtube.com/channel/" + v.ChannelID},
+			Published: v.PublishedAt.UTC(),
+			Updated:   v.ComputedAt.UTC(),
+			Summary:   summary,
+			Content:   atomContent{Type: "html", Body: content.String()},
+		}
+		if entry.Author.Name == "" {
+			entry.Author.Name = v.ChannelID
+		}
+		if v.ThumbnailURL != "" {
+			entry.Thumbnail = &mediaThumbnail{URL: v.ThumbnailURL}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// requestURL returns the URL r was sent to, as seen by the client behind
+// the Cloud Run proxy.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
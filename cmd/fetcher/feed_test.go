@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTrendingFeedHandler(t *testing.T) {
+	rr, fake := serveAPI(t, "/feeds/trending.xml")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	if fake.limit != feedDefaultLimit || fake.to != today() {
+		t.Errorf("query args = %+v", fake)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var feed struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID    string `xml:"id"`
+			Title string `xml:"title"`
+			Link  struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Author struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Thumbnail struct {
+				URL string `xml:"url,attr"`
+			} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(rr.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, rr.Body)
+	}
+	if feed.ID != "http://example.com/feeds/trending.xml" || feed.Updated != "2025-08-01T03:00:00Z" || !strings.Contains(feed.Title, "2025-08-01") {
+		t.Errorf("feed = %s, %s, %s", feed.ID, feed.Updated, feed.Title)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(feed.Entries))
+	}
+	e := feed.Entries[0]
+	if e.ID != "tag:youtube-trend-tracker,2025-08-01:v1" || e.Title != "Cats & dogs" || e.Link.Href != "https://www.youtube.com/watch?v=v1" || e.Author.Name != "One" {
+		t.Errorf("first entry = %+v", e)
+	}
+	if e.Thumbnail.URL != "https://i.ytimg.com/vi/v1/hqdefault.jpg" || !strings.Contains(e.Content, `<img src="https://i.ytimg.com/vi/v1/hqdefault.jpg" alt="Cats &amp; dogs">`) {
+		t.Errorf("first entry thumbnail = %q, content = %q", e.Thumbnail.URL, e.Content)
+	}
+	// Without a channel name or thumbnail, the channel ID is the author.
+	if e := feed.Entries[1]; e.Author.Name != "UC2" || e.Thumbnail.URL != "" {
+		t.Errorf("second entry = %+v", e)
+	}
+}
+
+func TestTrendingFeedHandler_InvalidLimit(t *testing.T) {
+	rr, _ := serveAPI(t, "/feeds/trending.xml?limit=0")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rr.Code)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// errNoSpillBuffer is returned by flushSpilled when bigquery.spill_buffer is
+// not set.
+var errNoSpillBuffer = errors.New("no spill buffer is configured (set BIGQUERY_SPILL_BUFFER)")
+
+// flushSpilled replays the rows spilled during BigQuery outages. It takes
+// the "flush" lock, so that two flushes never load the same file twice.
+func flushSpilled(ctx context.Context) (*storage.ReplayResult, error) {
+	if cfg.BigQuery.SpillBuffer == "" {
+		return nil, errNoSpillBuffer
+	}
+	release, err := acquireRunLock(ctx, "flush", false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	w, err := newTableWriter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := storage.NewSpillBuffer(ctx, cfg.BigQuery.SpillBuffer)
+	if err != nil {
+		return nil, err
+	}
+	return w.ReplaySpilled(ctx, buf)
+}
+
+// flushResponse is the body of POST /flush. A failed flush reports what it
+// loaded before the failure.
+type flushResponse struct {
+	Status string `json:"status"`
+	Files  int    `json:"files"`
+	Rows   int    `json:"rows"`
+	Error  string `json:"error,omitempty"`
+}
+
+// flushHandler serves POST /flush, typically called by a Cloud Scheduler
+// job once BigQuery is healthy again. A failed flush reports what it loaded
+// before the failure; the rest stays buffered for the next call.
+func flushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := requestContext(r, "")
+	log := logger.FromContext(ctx)
+
+	result, err := flushSpilled(ctx)
+	switch {
+	case errors.Is(err, errNoSpillBuffer):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errRunLocked):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(statusError{Status: "locked", Error: err.Error()})
+		return
+	case err != nil:
+		log.Error("Failed to flush spilled rows", err, nil)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		resp := flushResponse{Status: "error", Error: err.Error()}
+		if result != nil {
+			resp.Files, resp.Rows = result.Files, result.Rows
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	log.Info("Flushed spilled rows", map[string]string{
+		"files": fmt.Sprintf("%d", result.Files),
+		"rows":  fmt.Sprintf("%d", result.Rows),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flushResponse{Status: "flushed", Files: result.Files, Rows: result.Rows})
+}
+
+// runFlush replays the spill buffer once, for Cloud Run Jobs or a manual
+// recovery.
+//
+//	flush [-config path] [-timeout d]
+func runFlush(args []string) int {
+	fs := flag.NewFlagSet("flush", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Maximum time to spend")
+	fs.Parse(args)
+
+	c, err := config.Load(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	cfg = c
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := flushSpilled(ctx)
+	if result != nil {
+		fmt.Printf("replayed %d rows from %d files\n", result.Rows, result.Files)
+	}
+	if err != nil {
+		log.Error("Failed to flush spilled rows", err, nil)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+func TestFlushHandler(t *testing.T) {
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+	cfg = config.DefaultConfig()
+
+	tests := []struct {
+		name   string
+		method string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, http.StatusMethodNotAllowed},
+		{"no spill buffer", http.MethodPost, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(flushHandler).ServeHTTP(rr, httptest.NewRequest(tt.method, "/flush", nil))
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.want, rr.Body)
+			}
+		})
+	}
+}
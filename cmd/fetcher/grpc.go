@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	trackerv1 "github.com/lancelop89/youtube-trend-tracker/api/tracker/v1"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// trackerServer implements the gRPC API with the same guards and code as
+// the HTTP handlers it mirrors.
+type trackerServer struct {
+	trackerv1.UnimplementedTrackerServiceServer
+}
+
+// serveGRPC serves the gRPC API on server.grpc_port until the returned
+// server is stopped. Without server.admin_token it listens on localhost
+// only: the port is not behind Cloud Run's IAM check, so there would be
+// nothing to authenticate its calls with.
+func serveGRPC() (*grpc.Server, error) {
+	addr := ":" + cfg.Server.GRPCPort
+	if cfg.Server.AdminToken == "" {
+		addr = "127.0.0.1" + addr
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on gRPC port %s: %w", cfg.Server.GRPCPort, err)
+	}
+	srv := newGRPCServer()
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Error("gRPC server failed", err, nil)
+		}
+	}()
+	log.Info(fmt.Sprintf("Serving gRPC on %s", addr), nil)
+	return srv, nil
+}
+
+// newGRPCServer returns a gRPC server with the tracker service registered
+// behind requireGRPCAdmin.
+func newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(requireGRPCAdmin))
+	trackerv1.RegisterTrackerServiceServer(srv, &trackerServer{})
+	return srv
+}
+
+// requireGRPCAdmin is requireAdmin for gRPC: with server.admin_token set,
+// calls must send it in the x-admin-token metadata. Without one the server
+// only listens on localhost and every call is accepted.
+func requireGRPCAdmin(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	token := cfg.Server.AdminToken
+	if token == "" {
+		return handler(ctx, req)
+	}
+	var got string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(adminTokenHeader); len(v) > 0 {
+			got = v[0]
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		log.Warning("Rejecting gRPC call: missing or wrong token", nil, map[string]string{"method": info.FullMethod})
+		return nil, status.Error(codes.Unauthenticated, "missing or wrong "+strings.ToLower(adminTokenHeader)+" metadata")
+	}
+	return handler(ctx, req)
+}
+
+// rpcContext is requestContext for a gRPC call: the request ID comes from
+// the x-request-id metadata, and the call's cancellation does not abort a
+// fetch halfway.
+func rpcContext(ctx context.Context, runID string) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDHeader); len(v) > 0 {
+			requestID = v[0]
+		}
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	labels := map[string]string{"request_id": requestID}
+	if runID != "" {
+		labels["run_id"] = runID
+	}
+	return logger.WithContext(context.Background(), log.With(labels))
+}
+
+// TriggerFetch mirrors POST /: a fetch of the channels that are due.
+func (s *trackerServer) TriggerFetch(ctx context.Context, req *trackerv1.TriggerFetchRequest) (*trackerv1.TriggerFetchResponse, error) {
+	if ok, wait := allowTrigger("/", time.Now()); !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "triggered too recently; retry in %ds", int(math.Ceil(wait.Seconds())))
+	}
+	done, ok := tryStartFetch()
+	if !ok {
+		return nil, status.Error(codes.Aborted, "a fetch is already running")
+	}
+	defer done()
+
+	runID := newRunID()
+	ctx = rpcContext(ctx, runID)
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun || req.GetDryRun() {
+		dry = storage.NewDryRunWriter()
+	}
+
+	release, err := acquireRunLock(ctx, "all", dry != nil)
+	if errors.Is(err, errRunLocked) {
+		logger.FromContext(ctx).Warning("Rejecting fetch: another run is in progress", nil, nil)
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Error acquiring run lock", err, nil)
+		return nil, status.Error(codes.Internal, "Failed to acquire run lock")
+	}
+	defer release()
+
+	ctx, finish := lastRun.start(ctx, runID, "all", dry != nil)
+	err = runFetch(ctx, dry)
+	finish(err)
+	if err != nil {
+		var fe *fetchError
+		if errors.As(err, &fe) {
+			return nil, status.Error(codes.Internal, fe.message)
+		}
+		return nil, status.Error(codes.Internal, "An error occurred during the fetch and store process")
+	}
+
+	resp := &trackerv1.TriggerFetchResponse{Status: "success", RunId: runID}
+	if dry != nil {
+		resp.Status = "dry_run"
+		resp.Counts = make(map[string]int64)
+		for table, n := range dry.Counts() {
+			resp.Counts[table] = int64(n)
+		}
+	}
+	return resp, nil
+}
+
+// GetRunStatus mirrors GET /api/v1/status for the latest run of this
+// instance. A run_id that is not that run is looked up in fetch_runs, where
+// a run dispatched over Pub/Sub has one row per channel; they are merged.
+func (s *trackerServer) GetRunStatus(ctx context.Context, req *trackerv1.GetRunStatusRequest) (*trackerv1.RunStatus, error) {
+	last := lastRun.get()
+	if req.GetRunId() == "" || (last != nil && last.RunID == req.GetRunId()) {
+		if last == nil {
+			return nil, status.Error(codes.NotFound, "no run yet")
+		}
+		return runStatusProto(last), nil
+	}
+
+	ctx = rpcContext(ctx, "")
+	history, err := newRunHistory(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("Error creating BigQuery client for run history", err, nil)
+		return nil, status.Error(codes.Internal, "Failed to read run history")
+	}
+	records, err := history.RunRecords(ctx, req.GetRunId())
+	if err != nil {
+		logger.FromContext(ctx).Error("Error reading run history", err, map[string]string{"run_id": req.GetRunId()})
+		return nil, status.Error(codes.Internal, "Failed to read run history")
+	}
+	if len(records) == 0 {
+		return nil, status.Error(codes.NotFound, "Run not found")
+	}
+	return runStatusProto(mergeRunRecords(records)), nil
+}
+
+// mergeRunRecords combines the fetch_runs rows of one run, oldest first.
+func mergeRunRecords(records []storage.FetchRunRecord) *runStatus {
+	s := &runStatus{RunID: records[0].RunID, Scope: records[0].Scope, StartedAt: records[0].StartedAt}
+	var errs []string
+	for _, r := range records {
+		if s.FinishedAt == nil || r.FinishedAt.After(*s.FinishedAt) {
+			finishedAt := r.FinishedAt
+			s.FinishedAt = &finishedAt
+		}
+		s.ChannelsSucceeded += r.ChannelsSucceeded
+		s.ChannelsFailed += r.ChannelsFailed
+		s.VideosWritten += r.VideosWritten
+		s.QuotaUnits += r.QuotaUnits
+		s.FailedChannels = append(s.FailedChannels, r.FailedChannels...)
+		if r.Error != "" {
+			errs = append(errs, r.Error)
+		}
+	}
+	if len(records) > 1 {
+		// Channel tasks each record their own scope; the run covers them all.
+		s.Scope = "dispatch"
+	}
+	s.Error = strings.Join(errs, "; ")
+	return s
+}
+
+func runStatusProto(s *runStatus) *trackerv1.RunStatus {
+	p := &trackerv1.RunStatus{
+		RunId:             s.RunID,
+		Scope:             s.Scope,
+		DryRun:            s.DryRun,
+		StartedAt:         timestamppb.New(s.StartedAt),
+		Running:           s.Running,
+		Error:             s.Error,
+		ChannelsSucceeded: s.ChannelsSucceeded,
+		ChannelsFailed:    s.ChannelsFailed,
+		VideosWritten:     s.VideosWritten,
+		QuotaUnits:        s.QuotaUnits,
+		FailedChannels:    s.FailedChannels,
+	}
+	if s.FinishedAt != nil {
+		p.FinishedAt = timestamppb.New(*s.FinishedAt)
+	}
+	return p
+}
+
+// ListVideoStats mirrors GET /api/v1/channels/{id}/videos, with the same
+// defaults and limits. Responses are not cached.
+func (s *trackerServer) ListVideoStats(ctx context.Context, req *trackerv1.ListVideoStatsRequest) (*trackerv1.ListVideoStatsResponse, error) {
+	if req.GetChannelId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "channel_id is required")
+	}
+	from, to, err := parseDateRange(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = apiDefaultLimit
+	}
+	if limit < 1 || limit > apiMaxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", apiMaxLimit)
+	}
+
+	ctx = rpcContext(ctx, "")
+	q, err := newTrendQuerier(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("Error creating BigQuery client", err, nil)
+		return nil, status.Error(codes.Internal, "Failed to create BigQuery client")
+	}
+	videos, err := q.ChannelVideos(ctx, req.GetChannelId(), from, to, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("gRPC ListVideoStats failed", err, map[string]string{"channel_id": req.GetChannelId()})
+		return nil, status.Error(codes.Internal, "Query failed")
+	}
+
+	resp := &trackerv1.ListVideoStatsResponse{
+		ChannelId: req.GetChannelId(),
+		From:      from.String(),
+		To:        to.String(),
+		Videos:    make([]*trackerv1.VideoStat, len(videos)),
+	}
+	for i, v := range videos {
+		resp.Videos[i] = &trackerv1.VideoStat{
+			Dt:          v.Dt.String(),
+			ChannelId:   v.ChannelID,
+			ChannelName: v.ChannelName,
+			VideoId:     v.VideoID,
+			Title:       v.Title,
+			IsShort:     v.IsShort,
+			Views:       v.Views,
+			Likes:       v.Likes,
+			Comments:    v.Comments,
+			PublishedAt: timestamppb.New(v.PublishedAt),
+		}
+	}
+	return resp, nil
+}
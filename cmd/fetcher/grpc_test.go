@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	trackerv1 "github.com/lancelop89/youtube-trend-tracker/api/tracker/v1"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialTracker serves trackerServer in memory and returns a client for it.
+func dialTracker(t *testing.T) trackerv1.TrackerServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newGRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return trackerv1.NewTrackerServiceClient(conn)
+}
+
+func TestGRPCListVideoStats(t *testing.T) {
+	cfg = config.DefaultConfig()
+	fake := &fakeQuerier{}
+	orig := newTrendQuerier
+	newTrendQuerier = func(ctx context.Context) (trendQuerier, error) { return fake, nil }
+	t.Cleanup(func() { newTrendQuerier = orig })
+	client := dialTracker(t)
+	ctx := context.Background()
+
+	resp, err := client.ListVideoStats(ctx, &trackerv1.ListVideoStatsRequest{ChannelId: "UC1", From: "2025-08-01", To: "2025-08-10"})
+	if err != nil {
+		t.Fatalf("ListVideoStats() error = %v", err)
+	}
+	if fake.channelID != "UC1" || fake.limit != apiDefaultLimit || fake.from.String() != "2025-08-01" {
+		t.Errorf("query = %+v, want UC1 from 2025-08-01 with the default limit", fake)
+	}
+	if len(resp.Videos) != 1 || resp.Videos[0].VideoId != "v1" || resp.Videos[0].Dt != "2025-08-10" || resp.Videos[0].Views != 10 {
+		t.Errorf("videos = %v", resp.Videos)
+	}
+
+	for _, req := range []*trackerv1.ListVideoStatsRequest{
+		{},
+		{ChannelId: "UC1", From: "2025-08-10", To: "2025-08-01"},
+		{ChannelId: "UC1", Limit: apiMaxLimit + 1},
+	} {
+		if _, err := client.ListVideoStats(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListVideoStats(%v) code = %v, want InvalidArgument", req, status.Code(err))
+		}
+	}
+}
+
+func TestGRPCGetRunStatus(t *testing.T) {
+	cfg = config.DefaultConfig()
+	orig := newRunHistory
+	newRunHistory = func(ctx context.Context) (runHistory, error) {
+		return fakeRunHistory{
+			"run-dispatched": {
+				{RunID: "run-dispatched", Scope: "channel:UC1", ChannelsSucceeded: 1, VideosWritten: 5, QuotaUnits: 3},
+				{RunID: "run-dispatched", Scope: "channel:UC2", ChannelsFailed: 1, Error: "quota", FailedChannels: []string{"UC2"},
+					FinishedAt: time.Date(2025, 8, 1, 0, 5, 0, 0, time.UTC)},
+			},
+		}, nil
+	}
+	t.Cleanup(func() { newRunHistory = orig })
+	client := dialTracker(t)
+	ctx := context.Background()
+
+	got, err := client.GetRunStatus(ctx, &trackerv1.GetRunStatusRequest{RunId: "run-dispatched"})
+	if err != nil {
+		t.Fatalf("GetRunStatus() error = %v", err)
+	}
+	if got.Scope != "dispatch" || got.ChannelsSucceeded != 1 || got.ChannelsFailed != 1 || got.VideosWritten != 5 ||
+		got.Error != "quota" || len(got.FailedChannels) != 1 || got.FinishedAt.AsTime().Minute() != 5 {
+		t.Errorf("GetRunStatus(run-dispatched) = %v", got)
+	}
+
+	if _, err := client.GetRunStatus(ctx, &trackerv1.GetRunStatusRequest{RunId: "run-missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetRunStatus(run-missing) code = %v, want NotFound", status.Code(err))
+	}
+
+	_, finish := lastRun.start(ctx, "run-2", "all", false)
+	t.Cleanup(func() { finish(nil) })
+	got, err = client.GetRunStatus(ctx, &trackerv1.GetRunStatusRequest{})
+	if err != nil || got.RunId != "run-2" || !got.Running || got.FinishedAt != nil {
+		t.Errorf("GetRunStatus() = %v, %v; want the running run-2", got, err)
+	}
+}
+
+func TestGRPCTriggerFetch_Rejected(t *testing.T) {
+	cfg = config.DefaultConfig()
+	cfg.Server.TriggerInterval = 0
+	client := dialTracker(t)
+
+	done, _ := tryStartFetch()
+	_, err := client.TriggerFetch(context.Background(), &trackerv1.TriggerFetchRequest{DryRun: true})
+	done()
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("TriggerFetch() while running code = %v, want Aborted", status.Code(err))
+	}
+}
+
+func TestGRPCAdminToken(t *testing.T) {
+	cfg = config.DefaultConfig()
+	cfg.Server.AdminToken = "secret"
+	lastRun = runTracker{}
+	lastRun.start(context.Background(), "run-1", "all", false)
+	client := dialTracker(t)
+
+	for _, token := range []string{"", "wrong"} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-admin-token", token)
+		if _, err := client.GetRunStatus(ctx, &trackerv1.GetRunStatusRequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("GetRunStatus() with token %q code = %v, want Unauthenticated", token, status.Code(err))
+		}
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-admin-token", "secret")
+	if got, err := client.GetRunStatus(ctx, &trackerv1.GetRunStatusRequest{}); err != nil || got.RunId != "run-1" {
+		t.Errorf("GetRunStatus() with the token = %v, %v; want run-1", got, err)
+	}
+}
+
+func TestMergeRunRecords(t *testing.T) {
+	s := mergeRunRecords([]storage.FetchRunRecord{{RunID: "r", Scope: "all", QuotaUnits: 7}})
+	if s.Scope != "all" || s.QuotaUnits != 7 || s.Error != "" {
+		t.Errorf("mergeRunRecords() = %+v", s)
+	}
+}
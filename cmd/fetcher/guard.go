@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fetchInFlight is set while this instance runs a pipeline over all
+// channels: a scheduled fetch, a retry or a catch-up. Unlike the run lock
+// it needs no BigQuery and is always on, but it only sees this instance.
+var fetchInFlight atomic.Bool
+
+// tryStartFetch marks a pipeline as running, returning false if one already
+// is. The returned function marks it finished.
+func tryStartFetch() (func(), bool) {
+	if !fetchInFlight.CompareAndSwap(false, true) {
+		return nil, false
+	}
+	return func() { fetchInFlight.Store(false) }, true
+}
+
+// singleFlight rejects a request with 409 while another pipeline runs on
+// this instance, e.g. a manual curl while the scheduled run is still going.
+func singleFlight(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		done, ok := tryStartFetch()
+		if !ok {
+			log.Warning("Rejecting request: a fetch is already running on this instance", nil, map[string]string{
+				"path":       r.URL.Path,
+				"request_id": r.Header.Get(requestIDHeader),
+			})
+			writeJSONStatus(w, http.StatusConflict, statusError{Status: "running", Error: "a fetch is already running"})
+			return
+		}
+		defer done()
+		next(w, r)
+	}
+}
+
+// triggerLimiters pace each trigger endpoint to one request per
+// server.trigger_interval.
+var triggerLimiters struct {
+	mu       sync.Mutex
+	interval time.Duration
+	byName   map[string]*rate.Limiter
+}
+
+// allowTrigger reports whether a request to the endpoint may start now, and
+// if not, how long until it may.
+func allowTrigger(endpoint string, now time.Time) (bool, time.Duration) {
+	interval := cfg.Server.TriggerInterval
+	if interval <= 0 {
+		return true, 0
+	}
+	triggerLimiters.mu.Lock()
+	if triggerLimiters.byName == nil || triggerLimiters.interval != interval {
+		triggerLimiters.byName = map[string]*rate.Limiter{}
+		triggerLimiters.interval = interval
+	}
+	l, ok := triggerLimiters.byName[endpoint]
+	if !ok {
+		l = rate.NewLimiter(rate.Every(interval), 1)
+		triggerLimiters.byName[endpoint] = l
+	}
+	triggerLimiters.mu.Unlock()
+
+	res := l.ReserveN(now, 1)
+	if delay := res.DelayFrom(now); delay > 0 {
+		res.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// rateLimited rejects a request with 429 and Retry-After when the endpoint
+// was triggered less than server.trigger_interval ago. Requests are counted
+// by endpoint rather than by path, since "/" serves every unknown path.
+func rateLimited(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := allowTrigger(endpoint, time.Now()); !ok {
+			rejectTooSoon(w, r, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rejectTooSoon answers a trigger rejected by allowTrigger with 429 and
+// Retry-After.
+func rejectTooSoon(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	log.Warning("Rejecting request: endpoint triggered too recently", nil, map[string]string{
+		"path":       r.URL.Path,
+		"request_id": r.Header.Get(requestIDHeader),
+		"retry_in":   wait.Round(time.Second).String(),
+	})
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	writeJSONStatus(w, http.StatusTooManyRequests, statusError{Status: "rate_limited", Error: "triggered too recently"})
+}
+
+// statusError is the body of a trigger rejected because of another run
+// ("locked", "running") or the trigger rate limit ("rate_limited").
+type statusError struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// writeJSONStatus writes v as a JSON response with the given status.
+func writeJSONStatus(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+func TestSingleFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := singleFlight(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	first := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		first <- rec.Code
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("concurrent request = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if _, ok := tryStartFetch(); ok {
+		t.Error("tryStartFetch() succeeded while a request runs")
+	}
+
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first request = %d, want %d", code, http.StatusOK)
+	}
+	done, ok := tryStartFetch()
+	if !ok {
+		t.Fatal("tryStartFetch() failed after the request finished")
+	}
+	done()
+}
+
+func TestAllowTrigger(t *testing.T) {
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+	cfg = config.DefaultConfig()
+	cfg.Server.TriggerInterval = time.Minute
+
+	now := time.Now()
+	if ok, _ := allowTrigger("/retry", now); !ok {
+		t.Fatal("first trigger rejected")
+	}
+	ok, wait := allowTrigger("/retry", now.Add(10*time.Second))
+	if ok || wait < 49*time.Second || wait > 50*time.Second {
+		t.Errorf("second trigger after 10s = %v, wait %s; want rejected with 50s to wait", ok, wait)
+	}
+	if ok, _ := allowTrigger("/catchup", now.Add(10*time.Second)); !ok {
+		t.Error("another endpoint was rejected")
+	}
+	if ok, _ := allowTrigger("/retry", now.Add(time.Minute)); !ok {
+		t.Error("trigger after the interval rejected")
+	}
+
+	// Rejected requests get 429 with Retry-After.
+	h := rateLimited("/dispatch", func(w http.ResponseWriter, r *http.Request) {})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/dispatch", nil))
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/dispatch", nil))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("second /dispatch = %d, Retry-After %q; want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	cfg.Server.TriggerInterval = 0
+	for i := 0; i < 3; i++ {
+		if ok, _ := allowTrigger("/", now); !ok {
+			t.Fatal("trigger rejected with the limit disabled")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// exitChannelsFailed is the exit code of a job whose run completed but
+// failed some channels, so schedulers and alerts can tell it from a run that
+// failed outright (1).
+const exitChannelsFailed = 2
+
+// runJob runs a single fetch pass and returns the process exit code. It is
+// used by Cloud Run Jobs and cron-on-VM deployments where no HTTP trigger is
+// available.
+func runJob() int {
+	runID := newRunID()
+	log := log.With(map[string]string{"run_id": runID})
+	ctx, cancel := context.WithTimeout(logger.WithContext(context.Background(), log), cfg.App.FetchTimeout)
+	defer cancel()
+
+	log.Info("Running in job mode", map[string]string{
+		"environment": cfg.App.Environment,
+		"project_id":  cfg.GCP.ProjectID,
+		"timeout":     cfg.App.FetchTimeout.String(),
+	})
+
+	// Missed dates are caught up first, with their own timeout, so that
+	// today's run still records the latest snapshot.
+	startupCatchUp()
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun {
+		dry = storage.NewDryRunWriter()
+	}
+
+	release, err := acquireRunLock(ctx, "all", dry != nil)
+	if errors.Is(err, errRunLocked) {
+		// Not a failure: exiting non-zero would make Cloud Run Jobs retry.
+		log.Warning("Skipping job: another run is in progress", nil, nil)
+		return 0
+	}
+	if err != nil {
+		log.Error("Error acquiring run lock", err, nil)
+		return 1
+	}
+	defer release()
+
+	start := time.Now()
+	ctx, finish := lastRun.start(ctx, runID, "all", dry != nil)
+	err = runFetch(ctx, dry)
+	finish(err)
+	if dry == nil {
+		pushMetrics(ctx)
+	}
+	if err != nil {
+		log.Error("Job failed", err, map[string]string{"duration": time.Since(start).String()})
+		return 1
+	}
+	if failed := runFailedChannels(ctx); len(failed) > 0 {
+		log.Error("Job completed with failed channels", nil, map[string]string{
+			"duration":        time.Since(start).String(),
+			"failed_channels": strings.Join(failed, ","),
+		})
+		return exitChannelsFailed
+	}
+
+	if dry != nil {
+		labels := map[string]string{"duration": time.Since(start).String()}
+		for table, n := range dry.Counts() {
+			labels[table] = fmt.Sprintf("%d", n)
+		}
+		labels["quota_estimate"] = fmt.Sprintf("%d", dry.QuotaEstimate)
+		log.Info("Dry run completed; nothing was written to BigQuery", labels)
+		return 0
+	}
+
+	log.Info("Job completed", map[string]string{"duration": time.Since(start).String()})
+	return 0
+}
+
+// runFailedChannels returns the channels that the run carried by ctx failed,
+// including those skipped when the quota ran out. Deferred channels are not
+// failures.
+func runFailedChannels(ctx context.Context) []string {
+	var failed []string
+	lastRun.update(ctx, func(s *runStatus) {
+		failed = append(failed, s.FailedChannels...)
+	})
+	return failed
+}
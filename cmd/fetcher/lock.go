@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// errRunLocked is returned by acquireRunLock while another run holds the lease.
+var errRunLocked = errors.New("another run is in progress")
+
+// runLocker takes and releases the leases that keep runs from overlapping.
+type runLocker interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// newRunLocker creates the locker for a run; tests replace it.
+var newRunLocker = func(ctx context.Context) (runLocker, error) {
+	w, err := newBigQueryWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.EnsureRunLocksTable(ctx); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// acquireRunLock takes the lease for scope when App.RunLock is enabled,
+// returning errRunLocked if another invocation holds it. Each call is its own
+// holder, so a redelivered task with the same run ID is rejected too. Dry
+// runs write nothing and are never locked. The returned function releases
+// the lease.
+func acquireRunLock(ctx context.Context, scope string, dryRun bool) (func(), error) {
+	if !cfg.App.RunLock || dryRun {
+		return func() {}, nil
+	}
+	locker, err := newRunLocker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Outlive the longest run, so the lease only expires if the holder died.
+	ttl := cfg.App.FetchTimeout + time.Minute
+	name, holder := "fetch:"+scope, newRunID()
+	ok, err := locker.AcquireLease(ctx, name, holder, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errRunLocked
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := locker.ReleaseLease(ctx, name, holder); err != nil {
+			logger.FromContext(ctx).Warning("Failed to release run lock; it expires on its own", err, map[string]string{
+				"lock": name,
+				"ttl":  ttl.String(),
+			})
+		}
+	}, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+type fakeLocker struct {
+	held       map[string]string
+	acquired   []string
+	released   []string
+	ttl        time.Duration
+	acquireErr error
+}
+
+func (f *fakeLocker) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if f.acquireErr != nil {
+		return false, f.acquireErr
+	}
+	f.ttl = ttl
+	if _, ok := f.held[name]; ok {
+		return false, nil
+	}
+	f.held[name] = holder
+	f.acquired = append(f.acquired, holder)
+	return true, nil
+}
+
+func (f *fakeLocker) ReleaseLease(ctx context.Context, name, holder string) error {
+	if f.held[name] == holder {
+		delete(f.held, name)
+	}
+	f.released = append(f.released, holder)
+	return nil
+}
+
+func useFakeLocker(t *testing.T) *fakeLocker {
+	t.Helper()
+	cfg = config.DefaultConfig()
+	cfg.App.RunLock = true
+	fake := &fakeLocker{held: map[string]string{}}
+	orig := newRunLocker
+	newRunLocker = func(ctx context.Context) (runLocker, error) { return fake, nil }
+	t.Cleanup(func() { newRunLocker = orig })
+	return fake
+}
+
+func TestAcquireRunLock(t *testing.T) {
+	fake := useFakeLocker(t)
+	ctx := context.Background()
+
+	release, err := acquireRunLock(ctx, "all", false)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if fake.ttl != cfg.App.FetchTimeout+time.Minute {
+		t.Errorf("ttl = %v, want fetch timeout + 1m", fake.ttl)
+	}
+	if _, err := acquireRunLock(ctx, "all", false); !errors.Is(err, errRunLocked) {
+		t.Errorf("second acquire error = %v, want errRunLocked", err)
+	}
+	if _, err := acquireRunLock(ctx, "channel:UC1", false); err != nil {
+		t.Errorf("other scope should not be locked: %v", err)
+	}
+
+	release()
+	if len(fake.released) != 1 || fake.released[0] != fake.acquired[0] {
+		t.Errorf("released %v, want the first holder %v", fake.released, fake.acquired[0])
+	}
+	if _, err := acquireRunLock(ctx, "all", false); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+}
+
+func TestAcquireRunLock_Skipped(t *testing.T) {
+	fake := useFakeLocker(t)
+	fake.acquireErr = errors.New("should not be called")
+
+	if _, err := acquireRunLock(context.Background(), "all", true); err != nil {
+		t.Errorf("dry run should not lock: %v", err)
+	}
+	cfg.App.RunLock = false
+	if _, err := acquireRunLock(context.Background(), "all", false); err != nil {
+		t.Errorf("disabled lock should not be taken: %v", err)
+	}
+}
+
+func TestHandler_Locked(t *testing.T) {
+	fake := useFakeLocker(t)
+	fake.held["fetch:all"] = "other-run"
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409: %s", rr.Code, rr.Body)
+	}
+}
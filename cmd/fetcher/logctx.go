@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// newRunID returns an identifier for one fetch run: its UTC start time, so
+// IDs sort by time, and a random suffix, so runs started in the same second
+// get different IDs.
+func newRunID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// newRequestID returns a random identifier for an incoming request.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestContext returns a context whose logger carries the request's trace,
+// a request ID and the run ID, so every downstream log entry includes them.
+// It derives from context.Background rather than the request so that a
+// client disconnect does not abort a fetch halfway.
+func requestContext(r *http.Request, runID string) context.Context {
+	requestID := r.Header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	var projectID string
+	if cfg != nil {
+		projectID = cfg.GCP.ProjectID
+	}
+	trace, spanID := logger.TraceFromRequest(r, projectID)
+
+	labels := map[string]string{"request_id": requestID}
+	if runID != "" {
+		labels["run_id"] = runID
+	}
+	return logger.WithContext(context.Background(), log.WithTrace(trace, spanID).With(labels))
+}
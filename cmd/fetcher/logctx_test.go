@@ -0,0 +1,16 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewRunID(t *testing.T) {
+	a, b := newRunID(), newRunID()
+	if !regexp.MustCompile(`^\d{8}T\d{6}Z-[0-9a-f]{8}$`).MatchString(a) {
+		t.Errorf("newRunID() = %q, want the start time and a random suffix", a)
+	}
+	if a == b {
+		t.Errorf("runs started in the same second share the ID %q", a)
+	}
+}
@@ -3,55 +3,168 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
+	_ "time/tzdata" // embed zone data so App.Timezone works in minimal images
 
+	"github.com/lancelop89/youtube-trend-tracker/internal/analytics"
 	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errorreport"
 	"github.com/lancelop89/youtube-trend-tracker/internal/fetcher"
 	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/report"
+	"github.com/lancelop89/youtube-trend-tracker/internal/scheduler"
+	"github.com/lancelop89/youtube-trend-tracker/internal/sentiment"
 	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
 	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
 )
 
 // Global configuration
 var (
-	cfg *config.Config
-	log = logger.New()
+	cfg        *config.Config
+	log        = logger.New()
+	appMetrics = metrics.NewMetrics()
 )
 
 func main() {
+	// Dispatch tooling subcommands before starting the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed-demo":
+			os.Exit(runSeedDemo(os.Args[2:]))
+		case "backfill":
+			os.Exit(runBackfill(os.Args[2:]))
+		case "validate-config":
+			os.Exit(runValidateConfig(os.Args[2:]))
+		case "channels":
+			os.Exit(runChannels(os.Args[2:]))
+		case "dashboards":
+			os.Exit(runDashboards(os.Args[2:]))
+		case "digest":
+			os.Exit(runDigest(os.Args[2:]))
+		case "flush":
+			os.Exit(runFlush(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "discover":
+			os.Exit(runDiscover(os.Args[2:]))
+		case "setup":
+			os.Exit(runSetup(os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags
-	configPath := flag.String("config", "configs/config.yaml", "Path to configuration file")
-	flag.Parse()
+	fs := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	flags := config.BindFlags(fs)
+	once := fs.Bool("once", false, "Run a single fetch and exit instead of starting the HTTP server (same as RUN_MODE=job)")
+	debug := fs.Bool("debug", false, "Enable debug logging")
+	migrate := fs.Bool("migrate", false, "Create or migrate the BigQuery table to the current schema and exit")
+	forceEnsure := fs.Bool("force-ensure", false, "Check and migrate the BigQuery table on every run instead of once per process")
+	// Older scripts pass -once / -config; keep them working.
+	fs.Parse(config.NormalizeArgs(os.Args[1:]))
+
+	if *debug {
+		os.Setenv("LOG_LEVEL", "debug")
+	}
+	storage.SetForceEnsure(*forceEnsure)
 
 	// Load configuration
 	var err error
-	cfg, err = config.Load(*configPath)
+	cfg, err = flags.Load()
 	if err != nil {
 		log.Fatal("Failed to load configuration", err, nil)
 	}
+	configPath = flags.ConfigPath
 
 	// Update logger based on configuration
 	log = logger.New()
+	flushErrors := setupErrorReporting()
+	setupMetricsExport()
+	setupQuotaLedger()
+	lastRun.save = finishRun
+	if apiResponseCache, err = newAPICache(); err != nil {
+		log.Warning("Invalid api_cache_url, query API responses will not be cached", err, nil)
+	}
+
+	if *migrate {
+		os.Exit(runMigrate())
+	}
+	initClients()
+
+	if cfg.YouTube.VerifyAPIKey {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.YouTube.RequestTimeout)
+		ok := verifyAPIKeys(ctx)
+		cancel()
+		// A server keeps running so that /readyz reports the problem.
+		if !ok && (*once || cfg.IsJobMode()) {
+			flushErrors()
+			os.Exit(1)
+		}
+	}
+
+	if cfg.YouTube.VerifyChannels {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.YouTube.RequestTimeout)
+		ok := verifyChannels(ctx)
+		cancel()
+		if !ok && (*once || cfg.IsJobMode()) {
+			flushErrors()
+			os.Exit(1)
+		}
+	}
+
+	if *once || cfg.IsJobMode() {
+		code := runJob()
+		flushErrors()
+		os.Exit(code)
+	}
 
 	// Setup HTTP handlers
-	http.HandleFunc("/", handler)
+	http.HandleFunc("/", rateLimited("/", singleFlight(handler)))
 	http.HandleFunc("/healthz", healthzHandler)
+	http.HandleFunc("/readyz", readyzHandler)
 	http.HandleFunc("/info", infoHandler)
+	http.HandleFunc("/dispatch", rateLimited("/dispatch", dispatchHandler))
+	http.HandleFunc("/retry", rateLimited("/retry", singleFlight(retryHandler)))
+	http.HandleFunc("/catchup", rateLimited("/catchup", singleFlight(catchupHandler)))
+	http.HandleFunc("POST /tenants/{id}/fetch", singleFlight(tenantFetchHandler))
+	http.HandleFunc("/tasks/channel", channelTaskHandler)
+	http.HandleFunc("GET /channels/health", channelHealthHandler)
+	http.HandleFunc("POST /channels/{id}/enable", channelEnableHandler)
+	http.HandleFunc("GET /admin/channels", requireAdmin(adminChannelsHandler))
+	http.HandleFunc("POST /admin/channels", requireAdmin(adminAddChannelHandler))
+	http.HandleFunc("DELETE /admin/channels", requireAdmin(adminDeleteChannelHandler))
+	http.HandleFunc("/digest", digestHandler)
+	http.HandleFunc("/flush", flushHandler)
+	http.Handle("/metrics", appMetrics.Handler())
+	registerAPI(http.DefaultServeMux)
+	registerDashboard(http.DefaultServeMux)
+	registerOpenAPI(http.DefaultServeMux)
 
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:           ":" + cfg.Server.Port,
+		Handler:        withMiddleware(http.DefaultServeMux),
 		ReadTimeout:    cfg.Server.ReadTimeout,
 		WriteTimeout:   cfg.Server.WriteTimeout,
 		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
 	}
 
+	var grpcSrv *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		if grpcSrv, err = serveGRPC(); err != nil {
+			log.Fatal("gRPC server failed to start", err, nil)
+		}
+	}
+
 	// Setup graceful shutdown
 	idleConnsClosed := make(chan struct{})
 	go func() {
@@ -66,6 +179,9 @@ func main() {
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Error("Server shutdown error", err, nil)
 		}
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
 		close(idleConnsClosed)
 	}()
 
@@ -75,14 +191,44 @@ func main() {
 		"project_id":  cfg.GCP.ProjectID,
 	})
 
+	// Cloud Run throttles the CPU outside requests unless CPU is always
+	// allocated; without it, trigger POST /catchup instead.
+	go startupCatchUp()
+
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal("Server failed to start", err, nil)
 	}
 
 	<-idleConnsClosed
+	flushErrors()
 	log.Info("Server stopped", nil)
 }
 
+// setupErrorReporting installs the configured error reporting sink on the
+// logger and returns a function that flushes pending reports.
+func setupErrorReporting() func() {
+	service := os.Getenv("K_SERVICE")
+	if service == "" {
+		service = "youtube-trend-tracker"
+	}
+	sink, err := errorreport.New(context.Background(), cfg.Logging, errorreport.Service{
+		ProjectID:   cfg.GCP.ProjectID,
+		Name:        service,
+		Version:     version,
+		Environment: cfg.App.Environment,
+	})
+	if err != nil {
+		log.Warning("Error reporting disabled", err, map[string]string{"provider": cfg.Logging.ErrorReporting})
+		return func() {}
+	}
+	if sink == nil {
+		return func() {}
+	}
+	logger.SetReporter(sink)
+	log.Info("Error reporting enabled", map[string]string{"provider": cfg.Logging.ErrorReporting})
+	return func() { sink.Flush(5 * time.Second) }
+}
+
 func healthzHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
@@ -103,51 +249,629 @@ func infoHandler(w http.ResponseWriter, r *http.Request) {
 		"os":        runtime.GOOS,
 		"arch":      runtime.GOARCH,
 	}
+	if cfg != nil {
+		if s, err := scheduler.Parse(cfg.App.Schedule); err == nil {
+			info["schedule"] = s.String()
+			info["scheduleDescription"] = s.Describe()
+			info["timezone"] = cfg.App.Timezone
+			if next := s.NextIn(time.Now(), cfg.Location()); !next.IsZero() {
+				info["nextRun"] = next.Format(time.RFC3339)
+			}
+		}
+	}
 	json.NewEncoder(w).Encode(info)
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	runID := newRunID()
+	ctx := requestContext(r, runID)
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun || r.URL.Query().Get("dry_run") == "true" {
+		dry = storage.NewDryRunWriter()
+	}
+
+	release, err := acquireRunLock(ctx, "all", dry != nil)
+	if errors.Is(err, errRunLocked) {
+		logger.FromContext(ctx).Warning("Rejecting fetch: another run is in progress", nil, nil)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(statusError{Status: "locked", Error: err.Error()})
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Error acquiring run lock", err, nil)
+		http.Error(w, "Failed to acquire run lock", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	ctx, finish := lastRun.start(ctx, runID, "all", dry != nil)
+	err = runFetch(ctx, dry)
+	finish(err)
+	if err != nil {
+		var fe *fetchError
+		if errors.As(err, &fe) {
+			http.Error(w, fe.message, http.StatusInternalServerError)
+		} else {
+			http.Error(w, "An error occurred during the fetch and store process", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// --- Response ---
+	w.Header().Set("Content-Type", "application/json")
+	if dry != nil {
+		json.NewEncoder(w).Encode(fetchResponse{
+			Status:        "dry_run",
+			Counts:        dry.Counts(),
+			QuotaEstimate: &fetcher.RunEstimate{Units: dry.QuotaEstimate, ByMethod: dry.QuotaEstimateByMethod},
+			Records:       dry,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(fetchResponse{Status: "success"})
+}
+
+// fetchResponse is the body of a finished fetch. A dry run also returns
+// the records that would have been written, their number per table and
+// the quota the run was estimated to cost.
+type fetchResponse struct {
+	Status        string                `json:"status"`
+	Counts        map[string]int        `json:"counts,omitempty"`
+	QuotaEstimate *fetcher.RunEstimate  `json:"quota_estimate,omitempty"`
+	Records       *storage.DryRunWriter `json:"records,omitempty"`
+}
+
+// fetchError carries the client-facing message for a failed pipeline stage.
+type fetchError struct {
+	message string
+	err     error
+}
+
+func (e *fetchError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%s: %v", e.message, e.err)
+	}
+	return e.message
+}
+
+func (e *fetchError) Unwrap() error {
+	return e.err
+}
+
+// runFetch runs a single fetch-and-store pass over the enabled channels. It is
+// shared by the HTTP handler and the one-shot job mode. When dry is non-nil
+// nothing is written to BigQuery.
+func runFetch(ctx context.Context, dry *storage.DryRunWriter) error {
+	log := logger.FromContext(ctx)
 
 	// Get enabled channel IDs from configuration
-	channelIDs := cfg.GetEnabledChannelIDs()
-	if len(channelIDs) == 0 {
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, map[string]string{"source": runConfig(ctx).App.ChannelConfigSource})
+		return &fetchError{message: "Failed to load channel list", err: err}
+	}
+	if c.YouTube.VerifyAPIKey {
+		if err := checkAPIKey(ctx, c); err != nil {
+			log.Error("YouTube API key rejected", err, nil)
+			return &fetchError{message: err.Error()}
+		}
+	}
+	enabled := c.GetEnabledChannelIDs()
+	if len(enabled) == 0 {
 		log.Error("No enabled channels in configuration", nil, nil)
-		http.Error(w, "No channels configured", http.StatusInternalServerError)
+		return &fetchError{message: "No channels configured"}
+	}
+	channelIDs := withoutDisabledChannels(ctx, c.DueChannelIDs(time.Now()))
+	if len(channelIDs) < len(enabled) {
+		log.Info(fmt.Sprintf("%d of %d channels are due in this run", len(channelIDs), len(enabled)), map[string]string{
+			"schedule": c.App.Schedule,
+		})
+	}
+	if len(channelIDs) == 0 {
+		return nil
+	}
+	channelIDs, err = applyQuotaBudget(ctx, c, channelIDs, dry)
+	if err != nil {
+		return err
+	}
+
+	// Keywords do not depend on the channel pass, so they are tracked even
+	// if it failed; the run then fails with both errors.
+	if err := runFetchChannels(ctx, channelIDs, c.App.MaxVideosPerChannel, dry); err != nil {
+		return errors.Join(err, runTrackKeywords(ctx, dry))
+	}
+	if c.Analytics.TrendScore && dry == nil {
+		runTrendScores(ctx)
+	}
+	if c.Analytics.TagTrends && dry == nil {
+		runTagTrends(ctx)
+	}
+	if c.Analytics.ChannelDailyStats && dry == nil {
+		runChannelDailyStats(ctx)
+	}
+	if c.Analytics.PublishTimes && dry == nil {
+		runPublishTimes(ctx)
+	}
+	if c.Analytics.SubscriberMilestones && dry == nil {
+		runSubscriberMilestones(ctx, channelIDs)
+	}
+	if c.Analytics.CommentSentiment && dry == nil {
+		runCommentSentiment(ctx)
+	}
+	if c.Report.Destination != "" && dry == nil {
+		runReport(ctx)
+	}
+	if (len(c.Notifications.Rules) > 0 || c.Notifications.NewUploads.Enabled) && dry == nil {
+		runNotifications(ctx, channelIDs)
+	}
+	return runTrackKeywords(ctx, dry)
+}
+
+// runReport writes today's top-N report. Like trend scores, a failure is
+// logged without failing the run.
+func runReport(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+	labels := map[string]string{"destination": c.Report.Destination}
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for report", err, labels)
+		return
+	}
+	dst, err := report.NewDestination(ctx, c.Report.Destination)
+	if err != nil {
+		log.Warning("Error creating report destination", err, labels)
+		return
+	}
+	var publishTimes report.PublishTimeSource
+	if c.Analytics.PublishTimes {
+		publishTimes = bqWriter
+	}
+	if err := report.Run(ctx, bqWriter, dst, today(), c.Report.TopN, publishTimes); err != nil {
+		log.Warning("Failed to write report", err, labels)
+		return
+	}
+	log.Info("Report written", labels)
+}
+
+// runTrendScores scores the videos snapshotted today. Scores are derived
+// data, so failures are logged without failing the run.
+func runTrendScores(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for trend scores", err, nil)
+		return
+	}
+	if err := bqWriter.EnsureVideoTrendScoresTable(ctx); err != nil {
+		log.Warning("Error ensuring trend scores table exists", err, nil)
+		return
+	}
+	scorer, err := analytics.NewTrendScorer(bqWriter, c.Analytics)
+	if err != nil {
+		log.Warning("Error creating trend scorer", err, nil)
+		return
+	}
+	if _, err := scorer.Score(ctx, today()); err != nil {
+		log.Warning("Failed to compute trend scores", err, nil)
+	}
+}
+
+// runTagTrends aggregates today's tags into tag_trends. Like trend scores,
+// failures are logged without failing the run.
+func runTagTrends(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for tag trends", err, nil)
+		return
+	}
+	if err := bqWriter.EnsureTagTrendsTable(ctx); err != nil {
+		log.Warning("Error ensuring tag trends table exists", err, nil)
+		return
+	}
+	if _, err := analytics.TagTrends(ctx, bqWriter, today()); err != nil {
+		log.Warning("Failed to aggregate tag trends", err, nil)
+	}
+}
+
+// runChannelDailyStats aggregates today's snapshots per channel into
+// channel_daily_stats. Like trend scores, failures are logged without failing
+// the run.
+func runChannelDailyStats(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for channel daily stats", err, nil)
+		return
+	}
+	if err := bqWriter.EnsureChannelDailyStatsTable(ctx); err != nil {
+		log.Warning("Error ensuring channel daily stats table exists", err, nil)
+		return
+	}
+	if _, err := analytics.ChannelDailyStats(ctx, bqWriter, today(), c.Location()); err != nil {
+		log.Warning("Failed to aggregate channel daily stats", err, nil)
+	}
+}
+
+// runPublishTimes averages the early view velocity of recent videos by
+// publish slot into publish_time_stats. Like trend scores, failures are
+// logged without failing the run.
+func runPublishTimes(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for publish time stats", err, nil)
+		return
+	}
+	if err := bqWriter.EnsurePublishTimeStatsTable(ctx); err != nil {
+		log.Warning("Error ensuring publish time stats table exists", err, nil)
+		return
+	}
+	if _, err := analytics.PublishTimeStats(ctx, bqWriter, today(), c.Location()); err != nil {
+		log.Warning("Failed to compute publish time stats", err, nil)
+	}
+}
+
+// runCommentSentiment scores today's captured comments into
+// video_comment_sentiment. Like trend scores, failures are logged without
+// failing the run.
+func runCommentSentiment(ctx context.Context) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	bqWriter, err := newBigQueryWriter(c)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for comment sentiment", err, nil)
+		return
+	}
+	if err := bqWriter.EnsureCommentSentimentTable(ctx); err != nil {
+		log.Warning("Error ensuring comment sentiment table exists", err, nil)
 		return
 	}
+	analyzer, err := sentiment.NewNaturalLanguage(ctx)
+	if err != nil {
+		log.Warning("Error creating sentiment analyzer", err, nil)
+		return
+	}
+	if _, err := analytics.CommentSentiment(ctx, bqWriter, analyzer, today()); err != nil {
+		log.Warning("Failed to score comment sentiment", err, nil)
+	}
+}
+
+// runTrackKeywords stores the top search results of the enabled keywords.
+// It is a no-op when no keywords are configured.
+func runTrackKeywords(ctx context.Context, dry *storage.DryRunWriter) error {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+
+	var keywords []fetcher.Keyword
+	for _, kw := range c.GetEnabledKeywords() {
+		keywords = append(keywords, fetcher.Keyword{Query: kw.Query, MaxResults: kw.MaxResults})
+	}
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	ytClient, err := newYouTubeClient(ctx)
+	if err != nil {
+		log.Error("Error creating YouTube client", err, nil)
+		return &fetchError{message: "Failed to create YouTube client", err: err}
+	}
+
+	sink, bqWriter, err := newRecordSink(ctx, dry)
+	if err != nil {
+		log.Error("Error creating BigQuery writer", err, nil)
+		return &fetchError{message: "Failed to create BigQuery writer", err: err}
+	}
+	if bqWriter != nil {
+		if err := bqWriter.EnsureKeywordTrendsTable(ctx); err != nil {
+			log.Error("Error ensuring keyword trends table exists", err, nil)
+			return &fetchError{message: "Failed to setup BigQuery table", err: err}
+		}
+	}
+
+	err = fetcher.NewKeywordTracker(ytClient, sink, c.Location()).Track(ctx, keywords)
+	lastRun.update(ctx, func(s *runStatus) { s.QuotaUnits += ytClient.QuotaUsed() })
+	if err != nil {
+		log.Error("An error occurred during keyword tracking", err, nil)
+		return &fetchError{message: "An error occurred during keyword tracking", err: err}
+	}
+	return nil
+}
+
+// newYouTubeClient returns a YouTube client configured from the run's
+// configuration, on the shared connection of its API key.
+func newYouTubeClient(ctx context.Context) (*youtube.Client, error) {
+	c := runConfig(ctx)
+	client, err := youtubeClient(c)
+	if err != nil {
+		return nil, err
+	}
+	client.SetMetrics(appMetrics)
+	if cache := sharedResponseCache(ctx); cache != nil {
+		client.SetResponseCache(cache)
+	}
+	if limiter := sharedRateLimiter(); limiter != nil {
+		client.SetRateLimiter(limiter)
+	}
+	// Per-channel parts come from the configuration file only; channel
+	// lists read from Sheets or BigQuery have no such column.
+	client.DisableParts("", c.YouTube.DisabledParts...)
+	for _, ch := range c.Channels {
+		client.DisableParts(ch.ID, ch.DisabledParts...)
+	}
+	if c.App.ShortsURLCheck {
+		client.EnableShortsURLCheck(nil)
+	}
+	return client, nil
+}
+
+// youtubeClientOptions returns the YouTube client options for c.
+func youtubeClientOptions(c *config.Config) youtube.ClientOptions {
+	return youtube.ClientOptions{
+		Timeout:   c.YouTube.RequestTimeout,
+		UserAgent: "youtube-trend-tracker/" + version,
+	}
+}
+
+// recordSink receives the records produced by a run: the BigQuery writer, or
+// a DryRunWriter that only collects them.
+type recordSink interface {
+	fetcher.StatsWriter
+	fetcher.MetadataChangeStore
+	fetcher.CommentStore
+	fetcher.KeywordTrendStore
+}
+
+// newThumbnailArchive creates the video_thumbnails table if needed and
+// returns the fetcher's thumbnail archival into the bucket at uri.
+func newThumbnailArchive(ctx context.Context, bqWriter *storage.BigQueryWriter, uri string) (*fetcher.ThumbnailArchive, error) {
+	if err := bqWriter.EnsureVideoThumbnailsTable(ctx); err != nil {
+		return nil, err
+	}
+	bucket, err := storage.NewGCSThumbnailArchive(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return &fetcher.ThumbnailArchive{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Bucket:     bucket,
+		Store:      bqWriter,
+	}, nil
+}
+
+// newRecordSink returns the BigQuery writer, or dry wrapped around it when a
+// dry run is requested. Tables are only created for real runs, which keep
+// failed inserts in the spill buffer when one is configured. With sinks
+// configured, real runs write through a MultiWriter that copies the records
+// to them; a sink that cannot be created is skipped with a warning. With
+// BigQuery disabled, records are written to the sinks only and no BigQuery
+// writer is returned.
+func newRecordSink(ctx context.Context, dry *storage.DryRunWriter) (recordSink, *storage.BigQueryWriter, error) {
+	c := runConfig(ctx)
+	if c.BigQuery.Disabled {
+		if dry != nil {
+			return dry, nil, nil
+		}
+		sinks := newSinks(ctx)
+		if len(sinks) == 0 {
+			return nil, nil, fmt.Errorf("BigQuery is disabled and no sink could be created")
+		}
+		multi := storage.NewSinkWriter(c.BigQuery.TableID, sinks...)
+		multi.SetMetrics(appMetrics)
+		return multi, nil, nil
+	}
+
+	bqWriter, err := newTableWriter(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	bqWriter.SetMetrics(appMetrics)
+	if dry != nil {
+		dry.SetReader(bqWriter)
+		return dry, nil, nil
+	}
+	if c.BigQuery.SpillBuffer != "" {
+		buf, err := storage.NewSpillBuffer(ctx, c.BigQuery.SpillBuffer)
+		if err != nil {
+			logger.FromContext(ctx).Warning("Error creating spill buffer, failed inserts will not be kept", err, nil)
+		} else {
+			bqWriter.SetSpillBuffer(buf)
+		}
+	}
+	if len(c.Sinks) == 0 {
+		return bqWriter, bqWriter, nil
+	}
+	multi := storage.NewMultiWriter(bqWriter, newSinks(ctx)...)
+	multi.SetMetrics(appMetrics)
+	return multi, bqWriter, nil
+}
+
+// newSinks creates the configured sinks, skipping with a warning those that
+// cannot be created.
+func newSinks(ctx context.Context) []storage.RecordSink {
+	c := runConfig(ctx)
+	var sinks []storage.RecordSink
+	for _, uri := range c.Sinks {
+		s, err := storage.NewSink(ctx, c.GCP.ProjectID, uri)
+		if err != nil {
+			logger.FromContext(ctx).Warning("Error creating sink, records will not be copied to it", err, map[string]string{"sink": uri})
+			continue
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks
+}
+
+// newTableWriter creates the writer for the video trends table of c, using
+// c's schema file if one is configured and c's retention settings.
+func newTableWriter(ctx context.Context, c *config.Config) (*storage.BigQueryWriter, error) {
+	w, err := newBigQueryWriter(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.BigQuery.SchemaPath != "" {
+		if err := w.SetSchemaFile(c.BigQuery.SchemaPath); err != nil {
+			return nil, err
+		}
+	}
+	w.SetRetention(storage.RetentionPolicy{
+		PartitionExpiration:    time.Duration(c.BigQuery.PartitionExpirationDays) * 24 * time.Hour,
+		ExpirationTime:         c.TableExpirationTime(),
+		RequirePartitionFilter: c.BigQuery.RequirePartitionFilter,
+	})
+	return w, nil
+}
+
+// failedChannels returns the channels in channelIDs that are not in
+// succeeded, in order.
+func failedChannels(channelIDs, succeeded []string) []string {
+	ok := make(map[string]bool, len(succeeded))
+	for _, id := range succeeded {
+		ok[id] = true
+	}
+	var failed []string
+	for _, id := range channelIDs {
+		if !ok[id] {
+			failed = append(failed, id)
+		}
+	}
+	return failed
+}
+
+// runFetchChannels runs the fetch-and-store pipeline for the given channels.
+// When dry is non-nil nothing is written; the records are collected in dry.
+func runFetchChannels(ctx context.Context, channelIDs []string, maxVideosPerChannel int64, dry *storage.DryRunWriter) error {
+	log := logger.FromContext(ctx)
+	rc := runConfig(ctx)
 
 	// --- Initialization ---
-	ytClient, err := youtube.NewClient(ctx, cfg.YouTube.APIKey)
+	ytClient, err := newYouTubeClient(ctx)
 	if err != nil {
 		log.Error("Error creating YouTube client", err, nil)
-		http.Error(w, "Failed to create YouTube client", http.StatusInternalServerError)
-		return
+		return &fetchError{message: "Failed to create YouTube client", err: err}
 	}
 
-	bqWriter, err := storage.NewBigQueryWriterWithConfig(ctx, cfg.GCP.ProjectID, cfg.BigQuery.DatasetID, cfg.BigQuery.TableID)
+	sink, bqWriter, err := newRecordSink(ctx, dry)
 	if err != nil {
 		log.Error("Error creating BigQuery writer", err, nil)
-		http.Error(w, "Failed to create BigQuery writer", http.StatusInternalServerError)
-		return
+		return &fetchError{message: "Failed to create BigQuery writer", err: err}
 	}
 
 	// Ensure the table exists before proceeding.
-	if err := bqWriter.EnsureTableExists(ctx); err != nil {
-		log.Error("Error ensuring BigQuery table exists", err, nil)
-		http.Error(w, "Failed to setup BigQuery table", http.StatusInternalServerError)
-		return
+	if bqWriter != nil {
+		if err := bqWriter.EnsureTableExists(ctx); err != nil {
+			log.Error("Error ensuring BigQuery table exists", err, nil)
+			return &fetchError{message: "Failed to setup BigQuery table", err: err}
+		}
+		if err := bqWriter.EnsureViews(ctx); err != nil {
+			log.Warning("Failed to ensure analysis views", err, nil)
+		}
+	}
+
+	opts := fetcher.Options{
+		StatusLookbackDays: rc.App.StatusLookbackDays,
+		Location:           rc.Location(),
+		SnapshotDate:       snapshotDateFrom(ctx),
+	}
+	if rc.YouTube.QuotaLimit > 0 && sharesQuota(rc) {
+		opts.QuotaBudget = &fetcher.QuotaBudget{
+			Remaining:   quotaRemaining(ctx, time.Now()),
+			Used:        ytClient.QuotaUsed,
+			ChannelCost: fetcher.EstimateChannelCost(maxVideosPerChannel),
+		}
+	}
+	if rc.App.TrackMetadataChanges {
+		if bqWriter != nil {
+			if err := bqWriter.EnsureMetadataChangesTable(ctx); err != nil {
+				log.Error("Error ensuring metadata changes table exists", err, nil)
+				return &fetchError{message: "Failed to setup BigQuery table", err: err}
+			}
+		}
+		opts.MetadataChanges = sink
+	}
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, map[string]string{"source": rc.App.ChannelConfigSource})
+		return &fetchError{message: "Failed to load channel list", err: err}
+	}
+	var categoryTable categoryStore
+	if bqWriter != nil {
+		categoryTable = bqWriter
+	}
+	opts.CategoryNames = videoCategories.resolve(ctx, ytClient, categoryTable, rc.YouTube.CategoryRegions, rc.YouTube.CategoryLanguage, time.Now())
+	if playlists := c.GetPlaylistIDs(); len(playlists) > 0 {
+		opts.Playlists = make(map[string]bool, len(playlists))
+		for _, id := range playlists {
+			opts.Playlists[id] = true
+		}
+	}
+	if commentChannels := c.GetCommentChannelIDs(); len(commentChannels) > 0 {
+		if bqWriter != nil {
+			if err := bqWriter.EnsureVideoCommentsTable(ctx); err != nil {
+				log.Error("Error ensuring video comments table exists", err, nil)
+				return &fetchError{message: "Failed to setup BigQuery table", err: err}
+			}
+		}
+		opts.Comments = &fetcher.CommentCapture{
+			Client:   ytClient,
+			Store:    sink,
+			Channels: make(map[string]bool, len(commentChannels)),
+			PerVideo: rc.App.TopCommentsPerVideo,
+		}
+		for _, id := range commentChannels {
+			opts.Comments.Channels[id] = true
+		}
+	}
+
+	if rc.App.ThumbnailArchive != "" && bqWriter != nil {
+		if thumbnails, err := newThumbnailArchive(ctx, bqWriter, rc.App.ThumbnailArchive); err != nil {
+			// Archival is optional; the snapshot is still taken without it.
+			log.Warning("Error setting up thumbnail archive", err, nil)
+		} else {
+			opts.Thumbnails = thumbnails
+		}
 	}
 
 	// --- Execution ---
-	f := fetcher.NewFetcher(ytClient, bqWriter)
-	if err := f.FetchAndStore(ctx, channelIDs, cfg.App.MaxVideosPerChannel); err != nil {
+	f := fetcher.NewFetcherWithOptions(ytClient, sink, opts)
+	if dry == nil {
+		// Like the run metrics, dry runs are left out of the channel metrics.
+		f.SetMetrics(appMetrics)
+	}
+	result, err := f.FetchAndStoreResult(ctx, channelIDs, maxVideosPerChannel)
+	lastRun.update(ctx, func(s *runStatus) {
+		// Deferred channels are neither successes nor failures.
+		attempted := append(append([]string(nil), result.SuccessfulChannels...), result.DeferredChannels...)
+		s.ChannelsSucceeded += int64(len(result.SuccessfulChannels))
+		s.ChannelsFailed += int64(len(channelIDs) - len(attempted))
+		s.ChannelsSkipped += int64(len(result.SkippedChannels))
+		s.ChannelsDeferred += int64(len(result.DeferredChannels))
+		s.FailedChannels = append(s.FailedChannels, failedChannels(channelIDs, attempted)...)
+		s.DeferredChannels = append(s.DeferredChannels, result.DeferredChannels...)
+		s.VideosWritten += int64(result.TotalVideos)
+		s.QuotaUnits += ytClient.QuotaUsed()
+	})
+	if dry == nil && rc.App.AutoDisableAfter > 0 {
+		recordChannelHealth(ctx, result)
+	}
+	saveResponseCache(ctx, ytClient)
+	if err != nil {
 		log.Error("An error occurred during the fetch and store process", err, nil)
-		http.Error(w, "An error occurred during the fetch and store process", http.StatusInternalServerError)
-		return
+		return &fetchError{message: "An error occurred during the fetch and store process", err: err}
 	}
 
-	// --- Response ---
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	return nil
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/lancelop89/youtube-trend-tracker/internal/config"
@@ -95,3 +97,65 @@ func TestHandler_NoChannels(t *testing.T) {
 			body, expectedBody)
 	}
 }
+
+func TestRunJob_NoChannels(t *testing.T) {
+	// Save original config
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+
+	cfg = config.DefaultConfig()
+	cfg.App.RunMode = config.RunModeJob
+	cfg.Channels = []config.ChannelConfig{}
+
+	if code := runJob(); code != 1 {
+		t.Errorf("runJob() = %d, want 1 on failure", code)
+	}
+}
+
+func TestDispatchHandler_NoTopic(t *testing.T) {
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+
+	cfg = config.DefaultConfig()
+	cfg.PubSub.TopicID = ""
+
+	req := httptest.NewRequest("POST", "/dispatch", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(dispatchHandler).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestChannelTaskHandler_MalformedMessage(t *testing.T) {
+	req := httptest.NewRequest("POST", "/tasks/channel", strings.NewReader(`{"message":{"data":"not-base64!"}}`))
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(channelTaskHandler).ServeHTTP(rr, req)
+
+	// Malformed messages are acknowledged so Pub/Sub does not redeliver them
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNoContent)
+	}
+}
+
+func TestRunFailedChannels(t *testing.T) {
+	ctx, finish := lastRun.start(context.Background(), "run", "all", true)
+	defer finish(nil)
+	if failed := runFailedChannels(ctx); len(failed) != 0 {
+		t.Errorf("runFailedChannels() = %v before any failure", failed)
+	}
+	lastRun.update(ctx, func(s *runStatus) {
+		s.DeferredChannels = append(s.DeferredChannels, "later")
+		s.FailedChannels = append(s.FailedChannels, "bad")
+	})
+	if failed := runFailedChannels(ctx); len(failed) != 1 || failed[0] != "bad" {
+		t.Errorf("runFailedChannels() = %v, want [bad]", failed)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// minExportInterval keeps concurrent channel tasks on one instance from
+// writing points more often than Cloud Monitoring accepts; a skipped export
+// is caught up by the next run.
+const minExportInterval = 10 * time.Second
+
+// metricsExport holds the Cloud Monitoring exporter, if enabled.
+var metricsExport struct {
+	mu       sync.Mutex
+	exporter *metrics.CloudMonitoringExporter
+	last     time.Time
+}
+
+// setupMetricsExport creates the Cloud Monitoring exporter when
+// monitoring.cloud_monitoring is set. Failures only disable the export.
+func setupMetricsExport() {
+	if !cfg.Monitoring.CloudMonitoring {
+		return
+	}
+	e, err := metrics.NewCloudMonitoringExporter(context.Background(), appMetrics, monitoredTask())
+	if err != nil {
+		log.Warning("Cloud Monitoring export disabled", err, nil)
+		return
+	}
+	metricsExport.exporter = e
+	log.Info("Cloud Monitoring export enabled", nil)
+}
+
+// monitoredTask describes this process using the variables Cloud Run sets
+// for services (K_SERVICE, K_REVISION) and jobs (CLOUD_RUN_JOB,
+// CLOUD_RUN_EXECUTION, CLOUD_RUN_TASK_INDEX). Each process gets a random
+// task ID so that instances never write to the same time series.
+func monitoredTask() metrics.MonitoredTask {
+	namespace, job := os.Getenv("K_SERVICE"), os.Getenv("K_REVISION")
+	if name := os.Getenv("CLOUD_RUN_JOB"); name != "" {
+		namespace, job = name, os.Getenv("CLOUD_RUN_EXECUTION")
+	}
+	if namespace == "" {
+		namespace = "youtube-trend-tracker"
+	}
+	if job == "" {
+		job = "local"
+	}
+	taskID := newRequestID()
+	if index := os.Getenv("CLOUD_RUN_TASK_INDEX"); index != "" {
+		taskID = index + "-" + taskID
+	}
+	return metrics.MonitoredTask{
+		ProjectID: cfg.GCP.ProjectID,
+		Location:  cfg.GCP.Region,
+		Namespace: namespace,
+		Job:       job,
+		TaskID:    taskID,
+	}
+}
+
+// quotaDay tracks the quota spent on the current YouTube quota day, which
+// starts at midnight Pacific time. Without a quota ledger only this
+// instance's usage is included; with one, used is the last total it
+// returned and pending the units it has not recorded yet.
+var quotaDay struct {
+	mu      sync.Mutex
+	date    string
+	used    int64
+	pending int64
+	ledger  storage.QuotaLedger
+}
+
+// quotaLedgerTimeout bounds a ledger update, so that an unreachable ledger
+// only falls back to this instance's count.
+const quotaLedgerTimeout = 10 * time.Second
+
+var pacific = mustLoadLocation("America/Los_Angeles")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// setupQuotaLedger opens youtube.quota_ledger when set. Failures only leave
+// each instance counting its own usage.
+func setupQuotaLedger() {
+	if cfg.YouTube.QuotaLedger == "" {
+		return
+	}
+	l, err := storage.NewQuotaLedger(context.Background(), cfg.GCP.ProjectID, cfg.YouTube.QuotaLedger)
+	if err != nil {
+		log.Warning("Quota ledger disabled", err, map[string]string{"ledger": cfg.YouTube.QuotaLedger})
+		return
+	}
+	quotaDay.ledger = l
+	log.Info("Quota ledger enabled", map[string]string{"ledger": l.Name()})
+}
+
+// addQuotaUsed adds units spent at now and returns the total for the quota
+// day: across all instances when a quota ledger is set, otherwise of this
+// instance. Units the ledger failed to record are retried with the next
+// call; meanwhile the total is the last known one plus the pending units.
+// The lock only covers the local counters: concurrent calls update the
+// ledger in parallel and rely on its additions being atomic.
+func addQuotaUsed(ctx context.Context, now time.Time, units int64) int64 {
+	date := now.In(pacific).Format("2006-01-02")
+	quotaDay.mu.Lock()
+	if date != quotaDay.date {
+		quotaDay.date, quotaDay.used, quotaDay.pending = date, 0, 0
+	}
+	ledger := quotaDay.ledger
+	if ledger == nil {
+		quotaDay.used += units
+		used := quotaDay.used
+		quotaDay.mu.Unlock()
+		return used
+	}
+	// Take the pending units; they are put back if the ledger fails.
+	pending := quotaDay.pending + units
+	quotaDay.pending = 0
+	quotaDay.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), quotaLedgerTimeout)
+	defer cancel()
+	used, err := ledger.AddQuota(ctx, date, pending)
+
+	quotaDay.mu.Lock()
+	defer quotaDay.mu.Unlock()
+	if date != quotaDay.date {
+		// The quota day ended during the call; its total no longer matters.
+		if err != nil {
+			return pending
+		}
+		return used
+	}
+	if err != nil {
+		quotaDay.pending += pending
+		logger.FromContext(ctx).Warning("Failed to update the quota ledger", err, map[string]string{
+			"ledger":  ledger.Name(),
+			"pending": strconv.FormatInt(quotaDay.pending, 10),
+		})
+		return quotaDay.used + quotaDay.pending
+	}
+	// Calls that overlap may return out of order; the ledger total only
+	// grows within a day, so the largest is the latest.
+	quotaDay.used = max(quotaDay.used, used)
+	return quotaDay.used + quotaDay.pending
+}
+
+// quotaRemaining returns the part of youtube.quota_limit not yet spent on
+// the quota day of now, and updates the API quota gauge with it.
+func quotaRemaining(ctx context.Context, now time.Time) int64 {
+	left := int64(cfg.YouTube.QuotaLimit) - addQuotaUsed(ctx, now, 0)
+	appMetrics.SetAPIQuotaRemaining(float64(left))
+	return left
+}
+
+// recordRunMetrics updates the run metrics from a finished run and exports
+// them when Cloud Monitoring export is enabled.
+func recordRunMetrics(ctx context.Context, s *runStatus) {
+	appMetrics.RecordVideosProcessed(int(s.VideosWritten))
+	if s.ChannelsFailed > 0 {
+		appMetrics.ErrorsTotal.WithLabelValues("fetch", "channel").Add(float64(s.ChannelsFailed))
+	}
+	if s.Error != "" {
+		appMetrics.RecordError("fetch", "run")
+	} else {
+		appMetrics.SetLastRunTimestamp()
+	}
+	// A tenant with its own API key spends its own quota.
+	if sharesQuota(runConfig(ctx)) {
+		used := addQuotaUsed(ctx, *s.FinishedAt, s.QuotaUnits)
+		appMetrics.SetAPIQuotaRemaining(float64(int64(cfg.YouTube.QuotaLimit) - used))
+	}
+
+	exportMetrics(ctx)
+}
+
+// exportMetrics pushes the metrics to Cloud Monitoring, at most once per
+// minExportInterval. Failures are only logged.
+func exportMetrics(ctx context.Context) {
+	metricsExport.mu.Lock()
+	e := metricsExport.exporter
+	if e == nil || time.Since(metricsExport.last) < minExportInterval {
+		metricsExport.mu.Unlock()
+		return
+	}
+	metricsExport.last = time.Now()
+	metricsExport.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := e.Export(ctx); err != nil {
+		logger.FromContext(ctx).Warning("Failed to export metrics to Cloud Monitoring", err, nil)
+	}
+}
+
+// pushJob is the Pushgateway job label of the pushed metrics.
+const pushJob = "youtube-trend-tracker"
+
+// pushMetrics pushes the metrics to the Pushgateway when
+// monitoring.pushgateway_url is set. Parallel Cloud Run job tasks push to
+// separate groups by task index. Failures are only logged: the run itself
+// has already finished.
+func pushMetrics(ctx context.Context) {
+	if cfg.Monitoring.PushgatewayURL == "" {
+		return
+	}
+	instance := os.Getenv("CLOUD_RUN_TASK_INDEX")
+	if instance == "" {
+		instance = "0"
+	}
+	p := metrics.NewPusher(appMetrics, cfg.Monitoring.PushgatewayURL, pushJob, map[string]string{"instance": instance}, nil)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := p.Push(ctx); err != nil {
+		logger.FromContext(ctx).Warning("Failed to push metrics to the Pushgateway", err, map[string]string{"url": cfg.Monitoring.PushgatewayURL})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddQuotaUsed(t *testing.T) {
+	ctx := context.Background()
+	// 23:30 and 23:50 Pacific are the same quota day; 00:10 starts a new one.
+	base := time.Date(2025, 8, 2, 6, 30, 0, 0, time.UTC)
+	if got := addQuotaUsed(ctx, base, 100); got != 100 {
+		t.Errorf("first run: %d, want 100", got)
+	}
+	if got := addQuotaUsed(ctx, base.Add(20*time.Minute), 50); got != 150 {
+		t.Errorf("same day: %d, want 150", got)
+	}
+	if got := addQuotaUsed(ctx, base.Add(40*time.Minute), 7); got != 7 {
+		t.Errorf("after midnight Pacific: %d, want 7", got)
+	}
+}
+
+// fakeQuotaLedger is a ledger shared with other instances, which have
+// already spent other units.
+type fakeQuotaLedger struct {
+	totals map[string]int64
+	fail   bool
+}
+
+func (l *fakeQuotaLedger) Name() string { return "fake://ledger" }
+
+func (l *fakeQuotaLedger) AddQuota(ctx context.Context, day string, units int64) (int64, error) {
+	if l.fail {
+		return 0, errors.New("unavailable")
+	}
+	l.totals[day] += units
+	return l.totals[day], nil
+}
+
+func TestAddQuotaUsed_Ledger(t *testing.T) {
+	ledger := &fakeQuotaLedger{totals: map[string]int64{"2025-08-01": 1000}}
+	quotaDay.ledger = ledger
+	defer func() { quotaDay.ledger = nil }()
+
+	ctx := context.Background()
+	base := time.Date(2025, 8, 2, 6, 30, 0, 0, time.UTC)
+	if got := addQuotaUsed(ctx, base, 100); got != 1100 {
+		t.Errorf("with other instances' usage: %d, want 1100", got)
+	}
+
+	// An unavailable ledger keeps the units and the last known total.
+	ledger.fail = true
+	if got := addQuotaUsed(ctx, base.Add(time.Minute), 50); got != 1150 {
+		t.Errorf("ledger down: %d, want 1150", got)
+	}
+	ledger.fail = false
+	ledger.totals["2025-08-01"] += 200
+	if got := addQuotaUsed(ctx, base.Add(2*time.Minute), 0); got != 1350 {
+		t.Errorf("ledger back: %d, want 1350 including the pending 50", got)
+	}
+	if got := ledger.totals["2025-08-01"]; got != 1350 {
+		t.Errorf("ledger total = %d, want 1350", got)
+	}
+}
+
+// slowQuotaLedger blocks the first AddQuota until release is closed.
+type slowQuotaLedger struct {
+	mu      sync.Mutex
+	total   int64
+	calls   int
+	release chan struct{}
+}
+
+func (l *slowQuotaLedger) Name() string { return "slow://ledger" }
+
+func (l *slowQuotaLedger) AddQuota(ctx context.Context, day string, units int64) (int64, error) {
+	l.mu.Lock()
+	l.calls++
+	first := l.calls == 1
+	l.mu.Unlock()
+	if first {
+		<-l.release
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total += units
+	return l.total, nil
+}
+
+func TestAddQuotaUsed_LedgerNotSerialized(t *testing.T) {
+	ledger := &slowQuotaLedger{release: make(chan struct{})}
+	quotaDay.ledger = ledger
+	defer func() { quotaDay.ledger = nil }()
+
+	ctx := context.Background()
+	base := time.Date(2025, 8, 3, 6, 30, 0, 0, time.UTC)
+	slow := make(chan int64)
+	go func() { slow <- addQuotaUsed(ctx, base, 100) }()
+	for {
+		ledger.mu.Lock()
+		calls := ledger.calls
+		ledger.mu.Unlock()
+		if calls == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A second call goes to the ledger while the first is still waiting.
+	if got := addQuotaUsed(ctx, base, 50); got != 50 {
+		t.Errorf("concurrent call: %d, want 50", got)
+	}
+	close(ledger.release)
+	if got := <-slow; got != 150 {
+		t.Errorf("slow call: %d, want 150", got)
+	}
+	if got := addQuotaUsed(ctx, base, 0); got != 150 {
+		t.Errorf("after both: %d, want 150", got)
+	}
+}
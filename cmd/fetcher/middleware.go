@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// requestIDHeader carries the request ID in requests and responses.
+const requestIDHeader = "X-Request-Id"
+
+// middleware wraps a handler with behaviour shared by every endpoint.
+type middleware func(http.Handler) http.Handler
+
+// chain applies mws to h, the first one outermost.
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// withMiddleware wraps the server's handler: every request gets a request
+// ID, is access-logged, and a panic in a handler becomes a 500 response
+// with the stack in the log instead of a dropped connection.
+func withMiddleware(h http.Handler) http.Handler {
+	return chain(h, withRequestID, withAccessLog, withRecovery)
+}
+
+// withRequestID keeps the caller's X-Request-Id, or generates one, and
+// returns it in the response. requestContext reads the header, so the log
+// entries of the request carry the same ID.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(requestIDHeader, id)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// quietPaths are probed constantly; their access logs are debug entries.
+var quietPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// withAccessLog logs each request once it is served, with its status,
+// response size and latency.
+func withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		var projectID string
+		if cfg != nil {
+			projectID = cfg.GCP.ProjectID
+		}
+		trace, spanID := logger.TraceFromRequest(r, projectID)
+		labels := map[string]string{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     strconv.Itoa(rec.status()),
+			"bytes":      strconv.FormatInt(rec.bytes, 10),
+			"latency_ms": strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+			"request_id": r.Header.Get(requestIDHeader),
+		}
+		msg := fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, rec.status())
+		if quietPaths[r.URL.Path] {
+			log.WithTrace(trace, spanID).Debug(msg, labels)
+		} else {
+			log.WithTrace(trace, spanID).Info(msg, labels)
+		}
+	})
+}
+
+// withRecovery turns a panic in a handler into a 500 response, logging the
+// panic with its stack. http.ErrAbortHandler, used to abort a response on
+// purpose, is passed on.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			err, ok := p.(error)
+			if !ok {
+				err = fmt.Errorf("%v", p)
+			}
+			log.Error("Handler panicked", err, map[string]string{
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"request_id": r.Header.Get(requestIDHeader),
+				"stack":      string(debug.Stack()),
+			})
+			if rec, ok := w.(*statusRecorder); !ok || rec.code == 0 {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder records the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the recorder.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// status is the response status, 200 when the handler wrote nothing.
+func (r *statusRecorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// captureLog runs fn and returns the log entries it wrote to stdout.
+func captureLog(t *testing.T, fn func()) []logger.Entry {
+	t.Helper()
+	old := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	var entries []logger.Entry
+	sc := bufio.NewScanner(&buf)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e logger.Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("invalid log line %q: %v", sc.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestMiddleware_RequestIDAndAccessLog(t *testing.T) {
+	h := withMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(requestIDHeader) == "" {
+			t.Error("handler saw no request ID")
+		}
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	var rec *httptest.ResponseRecorder
+	entries := captureLog(t, func() {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/retry", nil))
+	})
+	id := rec.Header().Get(requestIDHeader)
+	if id == "" {
+		t.Fatal("response has no X-Request-Id")
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1 access log", len(entries))
+	}
+	labels := entries[0].Labels
+	if labels["status"] != "418" || labels["path"] != "/retry" || labels["bytes"] != "15" || labels["request_id"] != id || labels["latency_ms"] == "" {
+		t.Errorf("access log labels = %v", labels)
+	}
+
+	// A caller's request ID is kept.
+	captureLog(t, func() {
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/info", nil)
+		req.Header.Set(requestIDHeader, "caller-id")
+		h.ServeHTTP(rec, req)
+	})
+	if got := rec.Header().Get(requestIDHeader); got != "caller-id" {
+		t.Errorf("X-Request-Id = %q, want caller-id", got)
+	}
+}
+
+func TestMiddleware_Recovery(t *testing.T) {
+	h := withMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	var rec *httptest.ResponseRecorder
+	entries := captureLog(t, func() {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	})
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries, want the panic and the access log", len(entries))
+	}
+	if entries[0].Severity != "ERROR" || entries[0].Error != "boom" || !strings.Contains(entries[0].Labels["stack"], "middleware_test.go") {
+		t.Errorf("panic entry = %+v", entries[0])
+	}
+	if entries[1].Labels["status"] != "500" {
+		t.Errorf("access log status = %s, want 500", entries[1].Labels["status"])
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// migrateTimeout bounds the metadata calls of a migration.
+const migrateTimeout = 2 * time.Minute
+
+// runMigrate creates the video trends table or adds the columns it is
+// missing, applies the retention settings, creates or updates the views and
+// scheduled queries, and reports what changed. Fetch runs migrate the table too; the
+// -migrate flag lets a deployment do it up front.
+func runMigrate() int {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	w, err := newTableWriter(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create BigQuery writer: %v\n", err)
+		return 1
+	}
+	result, err := w.Migrate(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		return 1
+	}
+
+	table := cfg.BigQuery.DatasetID + "." + cfg.BigQuery.TableID
+	switch {
+	case result.Created:
+		fmt.Printf("%s: created at schema version %d\n", table, result.ToVersion)
+	case len(result.Added) > 0:
+		fmt.Printf("%s: schema version %d -> %d, added %s\n", table, result.FromVersion, result.ToVersion, strings.Join(result.Added, ", "))
+		for _, m := range result.Applied {
+			fmt.Printf("  applied %s\n", m)
+		}
+	case result.FromVersion != result.ToVersion:
+		fmt.Printf("%s: columns up to date, labelled schema version %d\n", table, result.ToVersion)
+	default:
+		fmt.Printf("%s: up to date at schema version %d\n", table, result.ToVersion)
+	}
+	if result.RetentionUpdated {
+		fmt.Printf("%s: updated partition expiration, table expiration and partition filter settings\n", table)
+	}
+	if err := w.EnsureViews(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create views: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s: views up to date\n", cfg.BigQuery.DatasetID)
+
+	if len(cfg.BigQuery.ScheduledQueries) == 0 {
+		return 0
+	}
+	scheduled, err := w.EnsureScheduledQueries(ctx, cfg.BigQuery.Location, scheduledQueries(cfg.BigQuery.ScheduledQueries))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to provision scheduled queries: %v\n", err)
+		return 1
+	}
+	for _, name := range scheduled.Created {
+		fmt.Printf("scheduled query %q: created\n", name)
+	}
+	for _, name := range scheduled.Updated {
+		fmt.Printf("scheduled query %q: updated\n", name)
+	}
+	fmt.Printf("%s: scheduled queries up to date\n", cfg.BigQuery.Location)
+	return 0
+}
+
+// scheduledQueries converts the configured scheduled queries.
+func scheduledQueries(configs []config.ScheduledQueryConfig) []storage.ScheduledQuery {
+	queries := make([]storage.ScheduledQuery, 0, len(configs))
+	for _, c := range configs {
+		queries = append(queries, storage.ScheduledQuery{
+			Name:              c.Name,
+			Query:             c.Query,
+			Schedule:          c.Schedule,
+			DestinationTable:  c.DestinationTable,
+			WriteDisposition:  c.WriteDisposition,
+			PartitioningField: c.PartitioningField,
+			Disabled:          !c.Enabled,
+		})
+	}
+	return queries
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/analytics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/webhook"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// milestoneStore keeps the channel snapshots and milestones.
+type milestoneStore interface {
+	analytics.MilestoneStore
+	EnsureChannelMilestoneTables(ctx context.Context) error
+}
+
+// newMilestoneStore and fetchChannelDetails read and write the subscriber
+// milestones for the run configuration; tests replace them.
+var (
+	newMilestoneStore = func(ctx context.Context) (milestoneStore, error) {
+		rc := runConfig(ctx)
+		return newBigQueryWriter(rc)
+	}
+	fetchChannelDetails = func(ctx context.Context, channelIDs []string) ([]*youtube.ChannelInfo, error) {
+		client, err := newYouTubeClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return client.ChannelDetails(ctx, channelIDs)
+	}
+)
+
+// channelMilestones is the data of a channel.milestone event.
+type channelMilestones struct {
+	Milestones []*storage.ChannelMilestoneRecord `json:"milestones"`
+}
+
+// runSubscriberMilestones snapshots the subscriber counts of channelIDs,
+// records the milestones they reached and sends them to the webhooks
+// subscribed to channel.milestone. It costs one channels.list call per 50
+// channels. Like trend scores, failures are logged without failing the
+// run; catch-up runs, which snapshot past days, do nothing.
+func runSubscriberMilestones(ctx context.Context, channelIDs []string) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+	if !snapshotDateFrom(ctx).IsZero() {
+		return
+	}
+
+	store, err := newMilestoneStore(ctx)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for subscriber milestones", err, nil)
+		return
+	}
+	if err := store.EnsureChannelMilestoneTables(ctx); err != nil {
+		log.Warning("Error ensuring channel milestone tables exist", err, nil)
+		return
+	}
+	infos, err := fetchChannelDetails(ctx, channelIDs)
+	if err != nil {
+		log.Warning("Failed to fetch channel statistics", err, nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	dt := today()
+	snapshots := make([]*storage.ChannelSnapshotRecord, 0, len(infos))
+	for _, info := range infos {
+		snapshots = append(snapshots, &storage.ChannelSnapshotRecord{
+			Dt:          dt,
+			SnapshotTs:  now,
+			ChannelID:   info.ID,
+			ChannelName: info.Title,
+			Subscribers: bigquery.NullInt64{Int64: info.Subscribers, Valid: !info.SubscribersHidden},
+			Videos:      info.Videos,
+			Views:       info.Views,
+		})
+	}
+	milestones, err := analytics.SubscriberMilestones(ctx, store, snapshots, c.Analytics.MilestoneThresholds)
+	if err != nil {
+		log.Warning("Failed to record subscriber milestones", err, nil)
+		return
+	}
+	for _, m := range milestones {
+		log.Info(fmt.Sprintf("%s reached %d subscribers", m.ChannelName, m.Milestone), map[string]string{
+			"channel_id":  m.ChannelID,
+			"subscribers": fmt.Sprint(m.Subscribers),
+		})
+	}
+
+	if len(milestones) == 0 || !c.Webhooks.Subscribed(config.WebhookEventChannelMilestone) {
+		return
+	}
+	sender, err := newWebhookSender(ctx, c.Webhooks)
+	if err != nil {
+		log.Warning("Error creating webhook dispatcher", err, nil)
+		return
+	}
+	_ = sender.Send(ctx, webhook.NewEvent(config.WebhookEventChannelMilestone, channelMilestones{Milestones: milestones}))
+}
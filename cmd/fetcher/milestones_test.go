@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// fakeMilestoneStore has a previous subscriber count for UC1 and keeps the
+// rows written.
+type fakeMilestoneStore struct {
+	snapshots  []*storage.ChannelSnapshotRecord
+	milestones []*storage.ChannelMilestoneRecord
+}
+
+func (f *fakeMilestoneStore) EnsureChannelMilestoneTables(ctx context.Context) error { return nil }
+
+func (f *fakeMilestoneStore) LatestSubscribers(ctx context.Context, date civil.Date, channelIDs []string) ([]storage.ChannelSubscribers, error) {
+	return []storage.ChannelSubscribers{{ChannelID: "UC1", Subscribers: 990000}}, nil
+}
+
+func (f *fakeMilestoneStore) ChannelMilestones(ctx context.Context, channelIDs []string) ([]storage.ChannelMilestoneRecord, error) {
+	return nil, nil
+}
+
+func (f *fakeMilestoneStore) InsertChannelSnapshots(ctx context.Context, records []*storage.ChannelSnapshotRecord) error {
+	f.snapshots = append(f.snapshots, records...)
+	return nil
+}
+
+func (f *fakeMilestoneStore) InsertChannelMilestones(ctx context.Context, records []*storage.ChannelMilestoneRecord) error {
+	f.milestones = append(f.milestones, records...)
+	return nil
+}
+
+func TestRunSubscriberMilestones(t *testing.T) {
+	originalCfg, originalStore, originalDetails, originalSender := cfg, newMilestoneStore, fetchChannelDetails, newWebhookSender
+	t.Cleanup(func() {
+		cfg, newMilestoneStore, fetchChannelDetails, newWebhookSender = originalCfg, originalStore, originalDetails, originalSender
+	})
+	cfg = config.DefaultConfig()
+	cfg.Analytics.SubscriberMilestones = true
+	cfg.Webhooks.Endpoints = []config.WebhookEndpointConfig{{URL: "https://example.com/hook", Events: []string{config.WebhookEventChannelMilestone}}}
+
+	store := &fakeMilestoneStore{}
+	newMilestoneStore = func(ctx context.Context) (milestoneStore, error) { return store, nil }
+	var requested []string
+	fetchChannelDetails = func(ctx context.Context, channelIDs []string) ([]*youtube.ChannelInfo, error) {
+		requested = channelIDs
+		return []*youtube.ChannelInfo{
+			{ID: "UC1", Title: "One", Subscribers: 1010000},
+			{ID: "UC2", Title: "Two", SubscribersHidden: true},
+		}, nil
+	}
+	sender := &fakeWebhookSender{}
+	newWebhookSender = func(ctx context.Context, c config.WebhooksConfig) (webhookSender, error) { return sender, nil }
+
+	runSubscriberMilestones(context.Background(), []string{"UC1", "UC2"})
+
+	if len(requested) != 2 || len(store.snapshots) != 2 {
+		t.Fatalf("requested %v, stored %d snapshots", requested, len(store.snapshots))
+	}
+	if s := store.snapshots[1]; s.ChannelName != "Two" || s.Subscribers.Valid || s.Dt != today() {
+		t.Errorf("hidden snapshot = %+v, want NULL subscribers", s)
+	}
+	if len(store.milestones) != 1 || store.milestones[0].Milestone != 1000000 {
+		t.Fatalf("milestones = %+v, want UC1 at 1M", store.milestones)
+	}
+	if len(sender.events) != 1 || sender.events[0].Type != config.WebhookEventChannelMilestone {
+		t.Fatalf("sent %+v, want one channel.milestone event", sender.events)
+	}
+	if data, ok := sender.events[0].Data.(channelMilestones); !ok || len(data.Milestones) != 1 || data.Milestones[0].ChannelID != "UC1" {
+		t.Errorf("event data = %+v", sender.events[0].Data)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/notify"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// notificationStore provides the facts notification rules are evaluated
+// with and keeps the notifications sent.
+type notificationStore interface {
+	EnsureNotificationsTable(ctx context.Context) error
+	VideoFacts(ctx context.Context, date civil.Date, channelIDs []string) ([]storage.VideoFacts, error)
+	NotificationsSince(ctx context.Context, since time.Time) ([]storage.NotificationRecord, error)
+	InsertNotifications(ctx context.Context, records []*storage.NotificationRecord) error
+}
+
+// newNotificationStore and newNotifyActions create the store and actions
+// for the run configuration; tests replace them.
+var (
+	newNotificationStore = func(ctx context.Context) (notificationStore, error) {
+		rc := runConfig(ctx)
+		return newBigQueryWriter(rc)
+	}
+	newNotifyActions = func(ctx context.Context, c *config.Config) (map[string]notify.Action, error) {
+		return notify.NewActions(ctx, c)
+	}
+)
+
+// runNotifications evaluates the notification rules for today's snapshots
+// of channelIDs and notifies the videos that match, also as trend.detected
+// and video.uploaded webhook events. Like trend scores,
+// failures are logged without failing the run. Catch-up runs, which
+// snapshot past days, notify nothing.
+func runNotifications(ctx context.Context, channelIDs []string) {
+	c := runConfig(ctx)
+	log := logger.FromContext(ctx)
+	if !snapshotDateFrom(ctx).IsZero() {
+		return
+	}
+
+	rs, err := notify.Compile(c.Notifications)
+	if err != nil {
+		log.Warning("Invalid notification rules", err, nil)
+		return
+	}
+	store, err := newNotificationStore(ctx)
+	if err != nil {
+		log.Warning("Error creating BigQuery writer for notifications", err, nil)
+		return
+	}
+	if err := store.EnsureNotificationsTable(ctx); err != nil {
+		log.Warning("Error ensuring notifications table exists", err, nil)
+		return
+	}
+	videos, err := store.VideoFacts(ctx, today(), channelIDs)
+	if err != nil {
+		log.Warning("Failed to read videos for notification rules", err, nil)
+		return
+	}
+	now := time.Now().UTC()
+	sent, err := store.NotificationsSince(ctx, now.Add(-notify.MaxCooldown(rs)))
+	if err != nil {
+		log.Warning("Failed to read sent notifications", err, nil)
+		return
+	}
+
+	events := notify.Match(rs, videos, sent, now)
+	if len(events) == 0 {
+		return
+	}
+	actions, err := newNotifyActions(ctx, c)
+	if err != nil {
+		log.Warning("Error creating notification actions", err, nil)
+		return
+	}
+	trendHooks := c.Webhooks.Subscribed(config.WebhookEventTrendDetected)
+	uploadHooks := c.Webhooks.Subscribed(config.WebhookEventVideoUploaded)
+	if trendHooks || uploadHooks {
+		// A webhook problem must not hold back the other actions.
+		if sender, err := newWebhookSender(ctx, c.Webhooks); err != nil {
+			log.Warning("Error creating webhook dispatcher, events will not be sent to webhooks", err, nil)
+		} else {
+			// Every rule sends its events to the webhooks too, as
+			// video.uploaded for the new_uploads rule.
+			actions[config.WebhooksActionName] = webhookAction{sender: sender}
+			for i := range rs {
+				if rs[i].Name == config.NewUploadsRuleName && !uploadHooks || rs[i].Name != config.NewUploadsRuleName && !trendHooks {
+					continue
+				}
+				rs[i].Actions = append(slices.Clip(rs[i].Actions), config.WebhooksActionName)
+			}
+		}
+	}
+	records, failed := notify.Dispatch(ctx, rs, actions, events, now)
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Warning("Failed to send notifications", failed[name], map[string]string{"action": name})
+	}
+	if err := store.InsertNotifications(ctx, records); err != nil {
+		// The videos may be notified again by the next run.
+		log.Warning("Failed to record sent notifications", err, nil)
+	}
+	if len(records) > 0 {
+		log.Info(fmt.Sprintf("Sent %d notifications", len(records)), map[string]string{
+			"rules": strings.Join(notifiedRules(records), ","),
+		})
+	}
+}
+
+// notifiedRules returns the rules of records, sorted and without duplicates.
+func notifiedRules(records []*storage.NotificationRecord) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range records {
+		if !seen[r.Rule] {
+			seen[r.Rule] = true
+			names = append(names, r.Rule)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/notify"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// fakeNotificationStore serves fixed videos and keeps the notifications
+// sent in memory.
+type fakeNotificationStore struct {
+	videos []storage.VideoFacts
+	sent   []storage.NotificationRecord
+}
+
+func (f *fakeNotificationStore) EnsureNotificationsTable(ctx context.Context) error { return nil }
+
+func (f *fakeNotificationStore) VideoFacts(ctx context.Context, date civil.Date, channelIDs []string) ([]storage.VideoFacts, error) {
+	return f.videos, nil
+}
+
+func (f *fakeNotificationStore) NotificationsSince(ctx context.Context, since time.Time) ([]storage.NotificationRecord, error) {
+	return f.sent, nil
+}
+
+func (f *fakeNotificationStore) InsertNotifications(ctx context.Context, records []*storage.NotificationRecord) error {
+	for _, r := range records {
+		f.sent = append(f.sent, *r)
+	}
+	return nil
+}
+
+// countingAction counts the events it is sent, or fails.
+type countingAction struct {
+	events int
+	err    error
+}
+
+func (a *countingAction) Notify(ctx context.Context, events []notify.Event) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.events += len(events)
+	return nil
+}
+
+func TestRunNotifications(t *testing.T) {
+	originalCfg, originalStore, originalActions := cfg, newNotificationStore, newNotifyActions
+	t.Cleanup(func() {
+		cfg, newNotificationStore, newNotifyActions = originalCfg, originalStore, originalActions
+	})
+	cfg = config.DefaultConfig()
+	cfg.Notifications.Rules = []config.NotifyRuleConfig{{Name: "viral", When: "views_gained_24h > 100000", Actions: []string{"team"}}}
+
+	store := &fakeNotificationStore{videos: []storage.VideoFacts{
+		{ChannelID: "UC1", VideoID: "v1", ViewsGained24h: 200000},
+		{ChannelID: "UC1", VideoID: "v2", ViewsGained24h: 10},
+	}}
+	action := &countingAction{err: errors.New("slack down")}
+	newNotificationStore = func(ctx context.Context) (notificationStore, error) { return store, nil }
+	newNotifyActions = func(ctx context.Context, c *config.Config) (map[string]notify.Action, error) {
+		return map[string]notify.Action{"team": action}, nil
+	}
+	ctx := context.Background()
+
+	// Undelivered notifications are not recorded, so the next run retries.
+	runNotifications(ctx, []string{"UC1"})
+	if len(store.sent) != 0 {
+		t.Fatalf("recorded %d notifications after a failed action, want 0", len(store.sent))
+	}
+
+	action.err = nil
+	runNotifications(ctx, []string{"UC1"})
+	if action.events != 1 || len(store.sent) != 1 || store.sent[0].VideoID != "v1" {
+		t.Fatalf("sent %d events, recorded %+v; want v1 once", action.events, store.sent)
+	}
+
+	// Within the cooldown, v1 is not notified again.
+	runNotifications(ctx, []string{"UC1"})
+	if action.events != 1 {
+		t.Errorf("sent %d events after a second run, want 1", action.events)
+	}
+
+	// Catch-up runs notify nothing.
+	store.sent = nil
+	runNotifications(withSnapshotDate(ctx, civil.Date{Year: 2026, Month: 9, Day: 1}), []string{"UC1"})
+	if action.events != 1 {
+		t.Errorf("sent %d events after a catch-up run, want 1", action.events)
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/openapi"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// registerOpenAPI serves the OpenAPI document of the HTTP API at
+// /openapi.json and a Swagger UI page rendering it at /docs.
+func registerOpenAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /openapi.json", openAPIHandler)
+	mux.HandleFunc("GET /docs", swaggerUIHandler)
+}
+
+// apiSpec is built on first use; it depends only on types and constants.
+var apiSpec = sync.OnceValue(func() []byte {
+	body, err := json.MarshalIndent(buildAPISpec(), "", "  ")
+	if err != nil {
+		panic(err) // the document contains only encodable types
+	}
+	return append(body, '\n')
+})
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(apiSpec())
+}
+
+// swaggerUIPage loads Swagger UI from a CDN, so the binary does not embed
+// its assets; the page only needs /openapi.json from this server.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>YouTube Trend Tracker API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, swaggerUIPage)
+}
+
+// buildAPISpec describes the HTTP API. Response schemas are generated from
+// the types the handlers encode.
+func buildAPISpec() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:       "YouTube Trend Tracker",
+		Description: "Fetch triggers, jobs and the read-only query API of the fetcher service. Dates are YYYY-MM-DD in the configured timezone.",
+		Version:     version,
+	})
+	d.Tags = []openapi.Tag{
+		{Name: "fetch", Description: "Trigger fetches of the configured channels"},
+		{Name: "jobs", Description: "Maintenance jobs, usually called by Cloud Scheduler"},
+		{Name: "admin", Description: "Manage the tracked channels; requires server.admin_token"},
+		{Name: "query", Description: "Read stored snapshots and run history from BigQuery"},
+		{Name: "service", Description: "Health and build information"},
+	}
+	d.Define(bigquery.NullInt64{}, &openapi.Schema{Type: "integer", Format: "int64", Nullable: true})
+	d.Define(bigquery.NullFloat64{}, &openapi.Schema{Type: "number", Format: "double", Nullable: true})
+	d.Define(bigquery.NullString{}, &openapi.Schema{Type: "string", Nullable: true})
+	d.Define(bigquery.NullBool{}, &openapi.Schema{Type: "boolean", Nullable: true})
+	d.Define(bigquery.NullTimestamp{}, &openapi.Schema{Type: "string", Format: "date-time", Nullable: true})
+
+	addTriggerOperations(d)
+	addChannelOperations(d)
+	addAdminOperations(d)
+	addJobOperations(d)
+	addQueryOperations(d)
+	addServiceOperations(d)
+	return d
+}
+
+// Parameters shared by several operations.
+var (
+	dryRunQuery = openapi.QueryParam("dry_run", "Fetch from YouTube but write nothing; the response lists the records instead", &openapi.Schema{Type: "boolean"})
+	dateQuery   = openapi.QueryParam("date", "Snapshot date (default: today)", &openapi.Schema{Type: "string", Format: "date"})
+	fromQuery   = openapi.QueryParam("from", fmt.Sprintf("First date (default: %d days before to; at most %d days before to)", apiDefaultRangeDays, apiMaxRangeDays), &openapi.Schema{Type: "string", Format: "date"})
+	toQuery     = openapi.QueryParam("to", "Last date (default: today)", &openapi.Schema{Type: "string", Format: "date"})
+	limitQuery  = openapi.QueryParam("limit", "Maximum number of items", &openapi.Schema{
+		Type: "integer", Default: apiDefaultLimit, Minimum: bound(1), Maximum: bound(apiMaxLimit),
+	})
+)
+
+// bound returns a pointer to a schema minimum or maximum.
+func bound(v float64) *float64 { return &v }
+
+// triggerRejections are the responses of a trigger guarded by the run lock,
+// singleFlight and rateLimited.
+func triggerRejections(d *openapi.Document, responses map[string]*openapi.Response) map[string]*openapi.Response {
+	responses[strconv.Itoa(http.StatusConflict)] = openapi.JSON(`Another run is in progress ("locked" or "running")`, d.SchemaOf(statusError{}))
+	responses[strconv.Itoa(http.StatusTooManyRequests)] = openapi.JSON(`Triggered again within server.trigger_interval ("rate_limited"); see Retry-After`, d.SchemaOf(statusError{}))
+	responses[strconv.Itoa(http.StatusInternalServerError)] = openapi.Text("The run failed")
+	return responses
+}
+
+func addTriggerOperations(d *openapi.Document) {
+	d.Post("/", &openapi.Operation{
+		OperationID: "fetch",
+		Summary:     "Fetch the channels that are due",
+		Description: "Fetches the enabled channels without a schedule and those whose schedule came due, and the enabled keywords. Any method is accepted.",
+		Tags:        []string{"fetch"},
+		Parameters:  []*openapi.Parameter{dryRunQuery},
+		Responses: triggerRejections(d, map[string]*openapi.Response{
+			"200": openapi.JSON(`The run finished ("success" or "dry_run")`, d.SchemaOf(fetchResponse{})),
+		}),
+	})
+	d.Post("/tenants/{id}/fetch", &openapi.Operation{
+		OperationID: "fetchTenant",
+		Summary:     "Fetch the channels of a tenant that are due",
+		Description: "Like POST / for the channels and keywords of one tenant, with its API key, into its dataset. The run's scope is tenant:{id}.",
+		Tags:        []string{"fetch"},
+		Parameters:  []*openapi.Parameter{openapi.PathParam("id", "Tenant ID"), dryRunQuery},
+		Responses: triggerRejections(d, map[string]*openapi.Response{
+			"200": openapi.JSON(`The run finished ("success" or "dry_run")`, d.SchemaOf(fetchResponse{})),
+			"404": openapi.Text("No tenant has this ID"),
+		}),
+	})
+	d.Post("/retry", &openapi.Operation{
+		OperationID: "retry",
+		Summary:     "Fetch again the channels that failed in a run",
+		Tags:        []string{"fetch"},
+		Parameters: []*openapi.Parameter{
+			{Name: "run_id", In: "query", Description: "Run whose failed channels are fetched", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			dryRunQuery,
+		},
+		Responses: triggerRejections(d, map[string]*openapi.Response{
+			"200": openapi.JSON(`The retry finished ("success", "dry_run" or "nothing_to_retry")`, d.SchemaOf(retryResponse{})),
+			"400": openapi.Text("run_id is missing"),
+			"404": openapi.Text("The run is not in fetch_runs"),
+		}),
+	})
+	d.Post("/catchup", &openapi.Operation{
+		OperationID: "catchUp",
+		Summary:     "Fetch the recent dates whose scheduled runs were missed",
+		Tags:        []string{"fetch"},
+		Parameters:  []*openapi.Parameter{dryRunQuery},
+		Responses: triggerRejections(d, map[string]*openapi.Response{
+			"200": openapi.JSON(`The catch-up finished ("success" or "nothing_to_catch_up")`, d.SchemaOf(catchupResponse{})),
+		}),
+	})
+	dispatch := triggerRejections(d, map[string]*openapi.Response{
+		"200": openapi.JSON("One Pub/Sub task per due channel was published", d.SchemaOf(dispatchResponse{})),
+		"503": openapi.Text("pubsub.topic_id is not configured"),
+	})
+	delete(dispatch, strconv.Itoa(http.StatusConflict))
+	d.Post("/dispatch", &openapi.Operation{
+		OperationID: "dispatch",
+		Summary:     "Fan the due channels out to workers over Pub/Sub",
+		Tags:        []string{"fetch"},
+		Responses:   dispatch,
+	})
+}
+
+func addChannelOperations(d *openapi.Document) {
+	tenant := openapi.QueryParam("tenant", "Tenant whose dataset keeps the streaks (default: bigquery.dataset_id)", &openapi.Schema{Type: "string"})
+	unavailable := openapi.Text("BigQuery is disabled")
+	d.Get("/channels/health", &openapi.Operation{
+		OperationID: "channelHealth",
+		Summary:     "Channels failing in a row, and those disabled for it",
+		Description: "Channels that failed app.auto_disable_after runs in a row are skipped until re-enabled.",
+		Tags:        []string{"fetch"},
+		Parameters:  []*openapi.Parameter{tenant},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Channels with a failure streak, by ID", d.SchemaOf(channelHealthResponse{})),
+			"404": openapi.Text("No tenant has this ID"),
+			"500": openapi.Text("The query failed"),
+			"503": unavailable,
+		},
+	})
+	d.Post("/channels/{id}/enable", &openapi.Operation{
+		OperationID: "enableChannel",
+		Summary:     "Fetch a channel disabled for failing again",
+		Tags:        []string{"fetch"},
+		Parameters:  []*openapi.Parameter{openapi.PathParam("id", "Channel ID"), tenant},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON(`The channel was re-enabled ("enabled")`, d.SchemaOf(channelEnableResponse{})),
+			"404": openapi.Text("The channel is not disabled, or no tenant has this ID"),
+			"500": openapi.Text("The update failed"),
+			"503": unavailable,
+		},
+	})
+}
+
+func addAdminOperations(d *openapi.Document) {
+	token := openapi.HeaderParam(adminTokenHeader, "server.admin_token")
+	list := d.SchemaOf(adminChannelsResponse{})
+	unauthorized := openapi.JSON(`The token is missing or wrong ("unauthorized")`, d.SchemaOf(statusError{}))
+	// Responses of the operations that save the list.
+	saved := func(responses map[string]*openapi.Response) map[string]*openapi.Response {
+		responses["400"] = openapi.Text("The list would be invalid, or the channel source cannot hold it")
+		responses["401"] = unauthorized
+		responses["409"] = openapi.Text("The channel source changed since it was read; retry")
+		responses["500"] = openapi.Text("Saving failed")
+		responses["501"] = openapi.Text("The channel source is read-only, such as a spreadsheet")
+		return responses
+	}
+	d.Get("/admin/channels", &openapi.Operation{
+		OperationID: "adminListChannels",
+		Summary:     "List the tracked channels",
+		Description: "The endpoints under /admin return 404 unless server.admin_token is set.",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{token},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Where the list is kept (app.channel_config_source or the configuration file) and the channels", list),
+			"401": unauthorized,
+			"500": openapi.Text("The channel source could not be read"),
+		},
+	})
+	d.Post("/admin/channels", &openapi.Operation{
+		OperationID: "adminSaveChannel",
+		Summary:     "Add a channel, or replace the one with the same ID",
+		Description: "Saves the list to app.channel_config_source, or rewrites the channels section of the configuration file. The next runs use it.",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{token},
+		RequestBody: openapi.JSONBody("A channel entry, as in the channels section of the configuration", d.SchemaOf(config.ChannelConfig{})),
+		Responses: saved(map[string]*openapi.Response{
+			"200": openapi.JSON("The channel was replaced; the saved list", list),
+			"201": openapi.JSON("The channel was added; the saved list", list),
+		}),
+	})
+	d.Delete("/admin/channels", &openapi.Operation{
+		OperationID: "adminDeleteChannel",
+		Summary:     "Stop tracking a channel",
+		Tags:        []string{"admin"},
+		Parameters: []*openapi.Parameter{token, {
+			Name: "id", In: "query", Description: "Channel or playlist ID", Required: true, Schema: &openapi.Schema{Type: "string"},
+		}},
+		Responses: saved(map[string]*openapi.Response{
+			"200": openapi.JSON("The channel was removed; the saved list", list),
+			"404": openapi.Text("The list has no channel with this ID"),
+		}),
+	})
+}
+
+func addJobOperations(d *openapi.Document) {
+	period := openapi.QueryParam("period", "Period summarised (default: digest.period)", &openapi.Schema{Type: "string", Enum: []string{"daily", "weekly"}})
+	d.Post("/digest", &openapi.Operation{
+		OperationID: "sendDigest",
+		Summary:     "Send the email digest",
+		Description: "With preview=true the digest is returned as HTML instead of being sent; GET is accepted then.",
+		Tags:        []string{"jobs"},
+		Parameters: []*openapi.Parameter{
+			period,
+			openapi.QueryParam("preview", "Return the digest as HTML without sending it", &openapi.Schema{Type: "boolean"}),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "The digest was sent, or its preview",
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: d.SchemaOf(digestResponse{})},
+					"text/html":        {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+			"400": openapi.Text("Invalid period, or no digest provider configured"),
+			"500": openapi.Text("Building or sending the digest failed"),
+		},
+	})
+	d.Post("/flush", &openapi.Operation{
+		OperationID: "flush",
+		Summary:     "Load the rows kept in the spill buffer into BigQuery",
+		Tags:        []string{"jobs"},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON(`The buffer was replayed ("flushed")`, d.SchemaOf(flushResponse{})),
+			"400": openapi.Text("No spill buffer is configured"),
+			"409": openapi.JSON(`Another run is in progress ("locked")`, d.SchemaOf(statusError{})),
+			"500": openapi.JSON(`The flush failed after loading part of the buffer ("error")`, d.SchemaOf(flushResponse{})),
+		},
+	})
+}
+
+func addQueryOperations(d *openapi.Document) {
+	failed := openapi.Text("The query failed")
+	invalid := openapi.Text("Invalid parameter")
+
+	d.Get("/api/v1/status", &openapi.Operation{
+		OperationID: "getStatus",
+		Summary:     "Latest run handled by this instance",
+		Tags:        []string{"query"},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Status; last_run is null before the first run", d.SchemaOf(apiStatus{})),
+		},
+	})
+	d.Get("/api/v1/channels", &openapi.Operation{
+		OperationID: "listChannels",
+		Summary:     "Videos and total views per channel on a date",
+		Tags:        []string{"query"},
+		Parameters:  []*openapi.Parameter{dateQuery},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Channels, most viewed first", d.SchemaOf(channelSummariesResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/api/v1/channels/{id}/videos", &openapi.Operation{
+		OperationID: "listChannelVideos",
+		Summary:     "Latest snapshot of each video of a channel in a date range",
+		Tags:        []string{"query"},
+		Parameters:  []*openapi.Parameter{openapi.PathParam("id", "Channel ID"), fromQuery, toQuery, limitQuery},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Videos, most viewed first", d.SchemaOf(channelVideosResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/api/v1/channels/{id}/publish-times", &openapi.Operation{
+		OperationID: "getChannelPublishTimes",
+		Summary:     "Early view velocity of a channel's recent videos by publish weekday and hour",
+		Description: "Latest stats computed in the week up to date; needs analytics.publish_times. Weekday is 0 for Sunday, hours are in app.timezone.",
+		Tags:        []string{"query"},
+		Parameters:  []*openapi.Parameter{openapi.PathParam("id", "Channel ID"), dateQuery},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Publish slots, highest lift first", d.SchemaOf(publishTimesResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/api/v1/videos/{id}/timeseries", &openapi.Operation{
+		OperationID: "getVideoTimeseries",
+		Summary:     "Every snapshot of a video in a date range",
+		Tags:        []string{"query"},
+		Parameters:  []*openapi.Parameter{openapi.PathParam("id", "Video ID"), fromQuery, toQuery},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Snapshots, oldest first", d.SchemaOf(videoTimeseriesResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	metrics := slices.Sorted(maps.Keys(storage.TopMetrics))
+	d.Get("/api/v1/top", &openapi.Operation{
+		OperationID: "listTopVideos",
+		Summary:     "Top videos on a date",
+		Tags:        []string{"query"},
+		Parameters: []*openapi.Parameter{
+			dateQuery,
+			openapi.QueryParam("metric", "Ranking metric", &openapi.Schema{Type: "string", Enum: metrics, Default: "views"}),
+			limitQuery,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Videos ranked by the metric", d.SchemaOf(topVideosResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/api/v1/compare", &openapi.Operation{
+		OperationID: "compareChannels",
+		Summary:     "Daily uploads, views gained and engagement rate of several channels",
+		Description: "Each channel's arrays are aligned with dates; days without snapshots are null.",
+		Tags:        []string{"query"},
+		Parameters: []*openapi.Parameter{
+			{
+				Name: "channels", In: "query", Required: true,
+				Description: fmt.Sprintf("Comma-separated channel IDs (1 to %d)", apiMaxCompareChannels),
+				Schema:      &openapi.Schema{Type: "string"},
+			},
+			fromQuery, toQuery,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("One series per channel, in the order requested", d.SchemaOf(compareResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/api/v1/cohorts", &openapi.Operation{
+		OperationID: "listPublishWeekCohorts",
+		Summary:     "Views by age in days of the videos published in each week",
+		Description: "Weeks start on Monday in app.timezone and end with the week of to. Each cohort's arrays are aligned with ages; ages without snapshots are null.",
+		Tags:        []string{"query"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParam("channel", "Channel ID (default: every channel)", &openapi.Schema{Type: "string"}),
+			toQuery,
+			openapi.QueryParam("weeks", "Number of publish weeks", &openapi.Schema{
+				Type: "integer", Default: apiDefaultCohortWeeks, Minimum: bound(1), Maximum: bound(apiMaxCohortWeeks),
+			}),
+			openapi.QueryParam("max_age", "Last age in days", &openapi.Schema{
+				Type: "integer", Default: apiDefaultCohortAge, Minimum: bound(0), Maximum: bound(apiMaxCohortAge),
+			}),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("One series per publish week, oldest first", d.SchemaOf(cohortsResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/runs", &openapi.Operation{
+		OperationID: "listRuns",
+		Summary:     "Runs recorded in fetch_runs in the last 90 days",
+		Tags:        []string{"query"},
+		Parameters:  []*openapi.Parameter{limitQuery},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Runs, newest first", d.SchemaOf(runsResponse{})),
+			"400": invalid,
+			"500": failed,
+		},
+	})
+	d.Get("/feeds/trending.xml", &openapi.Operation{
+		OperationID: "trendingFeed",
+		Summary:     "Atom feed of the videos with the highest latest trend score",
+		Description: "Ranks the latest date scored in the last week; needs analytics.trend_score. Entries have links and Media RSS thumbnails.",
+		Tags:        []string{"query"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParam("limit", "Number of videos", &openapi.Schema{
+				Type: "integer", Default: feedDefaultLimit, Minimum: bound(1), Maximum: bound(apiMaxLimit),
+			}),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "The feed, highest score first",
+				Content:     map[string]*openapi.MediaType{"application/atom+xml": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+			"400": invalid,
+			"500": failed,
+		},
+	})
+}
+
+func addServiceOperations(d *openapi.Document) {
+	d.Get("/healthz", &openapi.Operation{
+		OperationID: "healthz",
+		Summary:     "Liveness check",
+		Tags:        []string{"service"},
+		Responses:   map[string]*openapi.Response{"200": {Description: "The server is up"}},
+	})
+	d.Get("/readyz", &openapi.Operation{
+		OperationID: "readyz",
+		Summary:     "Readiness check of the YouTube API key and BigQuery access",
+		Description: "Results are cached for 30 seconds.",
+		Tags:        []string{"service"},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("Every check passed", d.SchemaOf(readiness{})),
+			"503": openapi.JSON("A check failed", d.SchemaOf(readiness{})),
+		},
+	})
+	d.Get("/info", &openapi.Operation{
+		OperationID: "info",
+		Summary:     "Build information and the fetch schedule",
+		Tags:        []string{"service"},
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSON("version, commit, buildTime, goVersion, os, arch, and schedule, scheduleDescription, timezone and nextRun when a schedule is set",
+				d.SchemaOf(map[string]string{})),
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	registerOpenAPI(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /openapi.json = %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Type     string `json:"type"`
+					Nullable bool   `json:"nullable"`
+					Items    *struct {
+						Type     string `json:"type"`
+						Nullable bool   `json:"nullable"`
+					} `json:"items"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	// Every query API route and trigger is documented.
+	wantOps := map[string]string{
+		"/api/v1/status":                      "get",
+		"/api/v1/channels":                    "get",
+		"/api/v1/channels/{id}/videos":        "get",
+		"/api/v1/videos/{id}/timeseries":      "get",
+		"/api/v1/top":                         "get",
+		"/api/v1/compare":                     "get",
+		"/runs":                               "get",
+		"/feeds/trending.xml":                 "get",
+		"/api/v1/channels/{id}/publish-times": "get",
+		"/api/v1/cohorts":                     "get",
+		"/":                                   "post",
+		"/tenants/{id}/fetch":                 "post",
+		"/retry":                              "post",
+		"/channels/health":                    "get",
+		"/channels/{id}/enable":               "post",
+		"/admin/channels":                     "get",
+		"/catchup":                            "post",
+		"/dispatch":                           "post",
+		"/digest":                             "post",
+		"/flush":                              "post",
+		"/readyz":                             "get",
+	}
+	if _, ok := doc.Paths["/admin/channels"]["delete"]; !ok {
+		t.Error("DELETE /admin/channels is not documented")
+	}
+	for path, method := range wantOps {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("%s %s is not documented", strings.ToUpper(method), path)
+		}
+	}
+
+	// Every reference resolves.
+	for _, m := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(rr.Body.String(), -1) {
+		if _, ok := doc.Components.Schemas[m[1]]; !ok {
+			t.Errorf("unresolved reference to %s", m[1])
+		}
+	}
+
+	// Schemas follow the encoded types, including the BigQuery nullables.
+	series := doc.Components.Schemas["ChannelSeries"].Properties
+	if vg := series["views_gained"]; vg.Items == nil || vg.Items.Type != "integer" || !vg.Items.Nullable {
+		t.Errorf("ChannelSeries.views_gained = %+v, want an array of nullable integers", vg)
+	}
+	if _, ok := doc.Components.Schemas["VideoSummary"].Properties["published_at"]; !ok {
+		t.Error("VideoSummary has no published_at")
+	}
+	if lastRun := doc.Components.Schemas["ApiStatus"].Properties["last_run"]; lastRun.Type != "" {
+		t.Errorf("ApiStatus.last_run = %+v, want a reference", lastRun)
+	}
+}
+
+func TestSwaggerUIHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	registerOpenAPI(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `url: "/openapi.json"`) {
+		t.Errorf("GET /docs = %d, body does not load /openapi.json", rr.Code)
+	}
+}
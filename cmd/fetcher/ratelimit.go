@@ -0,0 +1,28 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// rateLimiter is the YouTube API rate limiter shared by every client of the
+// process, so that concurrent channel tasks on one instance are paced
+// together.
+var rateLimiter struct {
+	once    sync.Once
+	limiter *youtube.RateLimiter
+}
+
+// sharedRateLimiter returns the process's rate limiter, or nil when
+// youtube.rate_limit_qps is 0.
+func sharedRateLimiter() *youtube.RateLimiter {
+	if cfg.YouTube.RateLimitQPS <= 0 {
+		return nil
+	}
+	rateLimiter.once.Do(func() {
+		rateLimiter.limiter = youtube.NewRateLimiter(cfg.YouTube.RateLimitQPS, cfg.YouTube.RateLimitBurst, youtube.DefaultRateLimitCooldown)
+		rateLimiter.limiter.SetMetrics(appMetrics)
+	})
+	return rateLimiter.limiter
+}
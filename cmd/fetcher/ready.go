@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// readyCheckTimeout bounds each dependency check.
+	readyCheckTimeout = 5 * time.Second
+	// readyCacheTTL reuses a result for repeated probes, so a frequent uptime
+	// check does not spend a quota unit every time.
+	readyCacheTTL = 30 * time.Second
+)
+
+// readinessChecks verify the service's dependencies by name; tests replace them.
+var readinessChecks = map[string]func(ctx context.Context) error{
+	"youtube": func(ctx context.Context) error {
+		client, err := newYouTubeClient(ctx)
+		if err != nil {
+			return err
+		}
+		return client.Ping(ctx)
+	},
+	"bigquery": func(ctx context.Context) error {
+		if cfg.BigQuery.Disabled {
+			return nil
+		}
+		w, err := newBigQueryWriter(cfg)
+		if err != nil {
+			return err
+		}
+		return w.CheckDataset(ctx)
+	},
+}
+
+// dependencyStatus is the result of one readiness check.
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// readiness is the /readyz response body.
+type readiness struct {
+	Status    string                      `json:"status"`
+	CheckedAt time.Time                   `json:"checked_at"`
+	Checks    map[string]dependencyStatus `json:"checks"`
+}
+
+var readyCache struct {
+	sync.Mutex
+	result *readiness
+}
+
+// readyzHandler serves /readyz: 200 if every dependency check passes and
+// 503 otherwise, with per-dependency status in the body. Unlike /healthz it
+// catches a bad API key or missing BigQuery access before the first run.
+// The endpoint is unauthenticated, so callers cannot bypass the cache: at
+// most one check per readyCacheTTL spends quota, however often it is hit.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	readyCache.Lock()
+	result := readyCache.result
+	if result == nil || time.Since(result.CheckedAt) > readyCacheTTL {
+		result = checkReadiness(r.Context())
+		readyCache.result = result
+	}
+	readyCache.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// checkReadiness runs all readiness checks concurrently.
+func checkReadiness(ctx context.Context) *readiness {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	result := &readiness{Status: "ready", CheckedAt: time.Now(), Checks: make(map[string]dependencyStatus)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range readinessChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			st := dependencyStatus{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				st.Status = "error"
+				st.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[name] = st
+			if err != nil {
+				result.Status = "not_ready"
+				log.Warning("Readiness check failed", err, map[string]string{"dependency": name})
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyzHandler(t *testing.T) {
+	orig := readinessChecks
+	defer func() { readinessChecks = orig }()
+
+	calls := 0
+	youtubeErr := errors.New("API key not valid")
+	readinessChecks = map[string]func(ctx context.Context) error{
+		"youtube":  func(ctx context.Context) error { calls++; return youtubeErr },
+		"bigquery": func(ctx context.Context) error { return nil },
+	}
+
+	get := func(target string) (*httptest.ResponseRecorder, readiness) {
+		rec := httptest.NewRecorder()
+		readyzHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var body readiness
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return rec, body
+	}
+
+	readyCache.result = nil
+	rec, body := get("/readyz")
+	if rec.Code != http.StatusServiceUnavailable || body.Status != "not_ready" {
+		t.Errorf("got %d %q, want 503 not_ready", rec.Code, body.Status)
+	}
+	if yt := body.Checks["youtube"]; yt.Status != "error" || yt.Error != youtubeErr.Error() {
+		t.Errorf("youtube check = %+v", yt)
+	}
+	if bq := body.Checks["bigquery"]; bq.Status != "ok" {
+		t.Errorf("bigquery check = %+v", bq)
+	}
+
+	// A second probe within the TTL reuses the result, even if it asks
+	// for a refresh.
+	youtubeErr = nil
+	get("/readyz?refresh=true")
+	if calls != 1 {
+		t.Errorf("checks ran %d times, want 1", calls)
+	}
+
+	readyCache.result.CheckedAt = readyCache.result.CheckedAt.Add(-readyCacheTTL - time.Second)
+	rec, body = get("/readyz")
+	if rec.Code != http.StatusOK || body.Status != "ready" {
+		t.Errorf("got %d %q after the TTL, want 200 ready", rec.Code, body.Status)
+	}
+}
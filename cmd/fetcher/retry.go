@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// runHistory reads the fetch_runs rows of a past run.
+type runHistory interface {
+	RunRecords(ctx context.Context, runID string) ([]storage.FetchRunRecord, error)
+}
+
+// newRunHistory creates the run history reader for a request; tests
+// replace it.
+var newRunHistory = func(ctx context.Context) (runHistory, error) {
+	return newBigQueryWriter(cfg)
+}
+
+// retryResponse is the body of POST /retry.
+type retryResponse struct {
+	Status       string                `json:"status"`
+	RunID        string                `json:"run_id,omitempty"`
+	RetriedRunID string                `json:"retried_run_id"`
+	Channels     []string              `json:"channels,omitempty"`
+	Counts       map[string]int        `json:"counts,omitempty"`
+	Records      *storage.DryRunWriter `json:"records,omitempty"`
+}
+
+// retryHandler serves POST /retry?run_id=: it fetches again only the
+// channels that failed in the given run, as recorded in fetch_runs. The
+// retry is a run of its own, with a new run ID and the scope
+// "retry:<run_id>", and takes the same lock as a full fetch.
+func retryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	retriedID := r.URL.Query().Get("run_id")
+	if retriedID == "" {
+		http.Error(w, "run_id is required", http.StatusBadRequest)
+		return
+	}
+
+	runID := newRunID()
+	ctx := requestContext(r, runID)
+	log := logger.FromContext(ctx)
+	labels := map[string]string{"retried_run_id": retriedID}
+
+	history, err := newRunHistory(ctx)
+	if err != nil {
+		log.Error("Error creating BigQuery client for run history", err, labels)
+		http.Error(w, "Failed to read run history", http.StatusInternalServerError)
+		return
+	}
+	records, err := history.RunRecords(ctx, retriedID)
+	if err != nil {
+		log.Error("Error reading run history", err, labels)
+		http.Error(w, "Failed to read run history", http.StatusInternalServerError)
+		return
+	}
+	if len(records) == 0 {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+		http.Error(w, "Failed to load channel list", http.StatusInternalServerError)
+		return
+	}
+	channelIDs := retryChannels(records, c.GetEnabledChannelIDs())
+	if len(channelIDs) == 0 {
+		log.Info("Nothing to retry: no failed channels that are still enabled", labels)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(retryResponse{Status: "nothing_to_retry", RetriedRunID: retriedID})
+		return
+	}
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun || r.URL.Query().Get("dry_run") == "true" {
+		dry = storage.NewDryRunWriter()
+	}
+
+	release, err := acquireRunLock(ctx, "all", dry != nil)
+	if errors.Is(err, errRunLocked) {
+		log.Warning("Rejecting retry: another run is in progress", nil, labels)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(statusError{Status: "locked", Error: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Error("Error acquiring run lock", err, labels)
+		http.Error(w, "Failed to acquire run lock", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	log.Info("Retrying failed channels", map[string]string{
+		"retried_run_id": retriedID,
+		"channels":       strings.Join(channelIDs, ","),
+	})
+	ctx, finish := lastRun.start(ctx, runID, "retry:"+retriedID, dry != nil)
+	err = runFetchChannels(ctx, channelIDs, cfg.App.MaxVideosPerChannel, dry)
+	finish(err)
+	if err != nil {
+		var fe *fetchError
+		if errors.As(err, &fe) {
+			http.Error(w, fe.message, http.StatusInternalServerError)
+		} else {
+			http.Error(w, "An error occurred during the fetch and store process", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := retryResponse{
+		Status:       "success",
+		RunID:        runID,
+		RetriedRunID: retriedID,
+		Channels:     channelIDs,
+	}
+	if dry != nil {
+		resp.Status = "dry_run"
+		resp.Counts = dry.Counts()
+		resp.Records = dry
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// retryChannels returns the channels of enabled, in that order, that failed
+// in the run recorded by records (oldest first). A Pub/Sub run has a row per
+// channel task: a failed task counts its channel even if the failure came
+// before any channel was fetched, and a later successful redelivery of the
+// same task clears it. Channels disabled since the run are left out.
+func retryChannels(records []storage.FetchRunRecord, enabled []string) []string {
+	failed := make(map[string]bool)
+	for _, rec := range records {
+		if channelID, ok := strings.CutPrefix(rec.Scope, "channel:"); ok {
+			failed[channelID] = rec.Status == storage.RunStatusFailed || len(rec.FailedChannels) > 0
+			continue
+		}
+		for _, id := range rec.FailedChannels {
+			failed[id] = true
+		}
+	}
+
+	var channelIDs []string
+	for _, id := range enabled {
+		if failed[id] {
+			channelIDs = append(channelIDs, id)
+		}
+	}
+	return channelIDs
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeRunHistory map[string][]storage.FetchRunRecord
+
+func (f fakeRunHistory) RunRecords(ctx context.Context, runID string) ([]storage.FetchRunRecord, error) {
+	return f[runID], nil
+}
+
+func TestRetryChannels(t *testing.T) {
+	records := []storage.FetchRunRecord{
+		{Scope: "all", Status: storage.RunStatusSuccess, FailedChannels: []string{"UC3", "UC1", "UCgone"}},
+		// Pub/Sub tasks of the same run: UC4 failed before fetching, UC5
+		// failed and then succeeded on redelivery.
+		{Scope: "channel:UC4", Status: storage.RunStatusFailed},
+		{Scope: "channel:UC5", Status: storage.RunStatusFailed, FailedChannels: []string{"UC5"}},
+		{Scope: "channel:UC5", Status: storage.RunStatusSuccess},
+	}
+	got := retryChannels(records, []string{"UC1", "UC2", "UC3", "UC4", "UC5"})
+	want := []string{"UC1", "UC3", "UC4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("retryChannels() = %v, want %v", got, want)
+	}
+}
+
+func TestRetryHandler(t *testing.T) {
+	originalCfg := cfg
+	defer func() {
+		cfg = originalCfg
+	}()
+	cfg = config.DefaultConfig()
+	cfg.Channels = []config.ChannelConfig{{ID: "UC1", Enabled: true}}
+
+	orig := newRunHistory
+	newRunHistory = func(ctx context.Context) (runHistory, error) {
+		return fakeRunHistory{
+			"run-ok": {{RunID: "run-ok", Scope: "all", Status: storage.RunStatusSuccess}},
+			// UC9 failed but has been disabled since.
+			"run-disabled": {{RunID: "run-disabled", Scope: "all", Status: storage.RunStatusSuccess, FailedChannels: []string{"UC9"}}},
+		}, nil
+	}
+	t.Cleanup(func() { newRunHistory = orig })
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+		status string
+	}{
+		{"wrong method", http.MethodGet, "/retry?run_id=run-ok", http.StatusMethodNotAllowed, ""},
+		{"missing run id", http.MethodPost, "/retry", http.StatusBadRequest, ""},
+		{"unknown run", http.MethodPost, "/retry?run_id=run-missing", http.StatusNotFound, ""},
+		{"no failed channels", http.MethodPost, "/retry?run_id=run-ok", http.StatusOK, "nothing_to_retry"},
+		{"failed channel disabled", http.MethodPost, "/retry?run_id=run-disabled", http.StatusOK, "nothing_to_retry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(retryHandler).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+			if rr.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.want, rr.Body)
+			}
+			if tt.status == "" {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["status"] != tt.status {
+				t.Errorf("status = %v, want %q", body["status"], tt.status)
+			}
+		})
+	}
+}
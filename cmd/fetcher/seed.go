@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/demo"
+)
+
+// runSeedDemo loads the bundled sample dataset into the configured BigQuery
+// table. Set BIGQUERY_EMULATOR_HOST to target the local emulator; no YouTube
+// API key is required.
+func runSeedDemo(args []string) int {
+	fs := flag.NewFlagSet("seed-demo", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Maximum time to spend seeding")
+	fs.Parse(args)
+
+	seedCfg, err := config.LoadUnvalidated(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	if seedCfg.GCP.ProjectID == "" {
+		log.Error("GCP project ID is required to seed the demo dataset", nil, nil)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	bqWriter, err := newTableWriter(ctx, seedCfg)
+	if err != nil {
+		log.Error("Error creating BigQuery writer", err, nil)
+		return 1
+	}
+
+	n, err := demo.Seed(ctx, bqWriter, time.Now())
+	if err != nil {
+		log.Error("Failed to seed demo dataset", err, nil)
+		return 1
+	}
+
+	log.Info(fmt.Sprintf("Seeded %d demo records", n), map[string]string{
+		"project_id": seedCfg.GCP.ProjectID,
+		"dataset":    seedCfg.BigQuery.DatasetID,
+		"table":      seedCfg.BigQuery.TableID,
+		"emulator":   os.Getenv("BIGQUERY_EMULATOR_HOST"),
+	})
+	return 0
+}
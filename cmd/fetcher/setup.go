@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/cloudscheduler"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/scheduler"
+	cs "google.golang.org/api/cloudscheduler/v1"
+)
+
+// runSetup provisions the Google Cloud resources around the service from
+// the configuration, so that re-running it after a config change applies
+// the change.
+//
+//	setup scheduler  creates or updates the Cloud Scheduler job that
+//	                 triggers the fetch on app.schedule
+func runSetup(args []string) int {
+	if len(args) == 0 || args[0] != "scheduler" {
+		fmt.Fprintln(os.Stderr, "usage: fetcher setup scheduler [-config path] [-url https://...] [-dry-run]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("setup scheduler", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	url := fs.String("url", "", "URL of the Cloud Run service (default: scheduler.target_url)")
+	dryRun := fs.Bool("dry-run", false, "Print the job instead of provisioning it")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to spend")
+	fs.Parse(args[1:])
+
+	c, err := config.LoadUnvalidated(*configPath)
+	if err != nil {
+		log.Error("Failed to load configuration", err, nil)
+		return 1
+	}
+	if *url != "" {
+		c.Scheduler.TargetURL = *url
+	}
+	if err := c.ValidateScheduler(); err != nil {
+		log.Error("Invalid scheduler configuration", err, nil)
+		return 1
+	}
+	if c.Scheduler.TargetURL == "" {
+		log.Error("No target URL: pass -url or set scheduler.target_url", nil, nil)
+		return 1
+	}
+
+	schedule, _ := scheduler.Parse(c.App.Schedule) // checked by ValidateScheduler
+	job := schedulerJob(c, schedule)
+	if *dryRun {
+		fmt.Printf("job:              projects/%s/locations/%s/jobs/%s\n", c.GCP.ProjectID, c.GCP.Region, job.Name)
+		fmt.Printf("schedule:         %s (%s, %s)\n", job.Schedule, schedule.Describe(), job.TimeZone)
+		fmt.Printf("next runs:       ")
+		for _, t := range schedule.NextN(time.Now().In(c.Location()), 3) {
+			fmt.Printf(" %s", t.Format(time.RFC3339))
+		}
+		fmt.Println()
+		fmt.Printf("target:           POST %s\n", job.URL)
+		fmt.Printf("service account:  %s\n", job.ServiceAccount)
+		fmt.Printf("attempt deadline: %s\n", job.AttemptDeadline)
+		fmt.Printf("retries:          %d (backoff %s to %s)\n", job.RetryCount, job.MinBackoff, job.MaxBackoff)
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	svc, err := cs.NewService(ctx)
+	if err != nil {
+		log.Error("Error creating Cloud Scheduler client", err, nil)
+		return 1
+	}
+	outcome, err := cloudscheduler.Ensure(ctx, svc, c.GCP.ProjectID, c.GCP.Region, job)
+	if err != nil {
+		log.Error("Failed to provision scheduler job", err, nil)
+		return 1
+	}
+	fmt.Printf("scheduler job %q: %s (%s, %s)\n", job.Name, outcome, schedule.Describe(), job.TimeZone)
+	return 0
+}
+
+// schedulerJob returns the scheduler job described by the configuration.
+// The schedule is sent in its plain numeric form, with macros expanded.
+func schedulerJob(c *config.Config, schedule *scheduler.Schedule) cloudscheduler.Job {
+	return cloudscheduler.Job{
+		Name:            c.Scheduler.JobName,
+		Description:     "Triggers the YouTube trend tracker fetch",
+		Schedule:        schedule.String(),
+		TimeZone:        c.App.Timezone,
+		URL:             c.Scheduler.TargetURL,
+		ServiceAccount:  c.SchedulerServiceAccount(),
+		AttemptDeadline: c.Scheduler.AttemptDeadline,
+		RetryCount:      int64(c.Scheduler.RetryCount),
+		MinBackoff:      c.Scheduler.MinBackoff,
+		MaxBackoff:      c.Scheduler.MaxBackoff,
+	}
+}
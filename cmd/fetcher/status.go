@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// runStatus describes the most recent fetch handled by this instance.
+type runStatus struct {
+	RunID     string    `json:"run_id"`
+	Scope     string    `json:"scope"`
+	DryRun    bool      `json:"dry_run"`
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is nil while the run is in progress.
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+	Running           bool       `json:"running"`
+	Error             string     `json:"error,omitempty"`
+	ChannelsSucceeded int64      `json:"channels_succeeded"`
+	ChannelsFailed    int64      `json:"channels_failed"`
+	// ChannelsSkipped counts the failed channels that were not attempted
+	// because the daily API quota ran out.
+	ChannelsSkipped int64 `json:"channels_skipped"`
+	// ChannelsDeferred counts the channels left for a later run because the
+	// quota budget was predicted to run out; they are not failures.
+	ChannelsDeferred int64    `json:"channels_deferred"`
+	DeferredChannels []string `json:"deferred_channels,omitempty"`
+	VideosWritten    int64    `json:"videos_written"`
+	QuotaUnits       int64    `json:"quota_units"`
+	// QuotaEstimate is the quota the run was estimated to cost before it
+	// started.
+	QuotaEstimate  int64    `json:"quota_estimate,omitempty"`
+	FailedChannels []string `json:"failed_channels,omitempty"`
+}
+
+// record converts a finished run to its fetch_runs row.
+func (s *runStatus) record() *storage.FetchRunRecord {
+	status := storage.RunStatusSuccess
+	if s.Error != "" {
+		status = storage.RunStatusFailed
+	}
+	var finishedAt time.Time
+	if s.FinishedAt != nil {
+		finishedAt = *s.FinishedAt
+	}
+	return &storage.FetchRunRecord{
+		RunID:             s.RunID,
+		Scope:             s.Scope,
+		StartedAt:         s.StartedAt,
+		FinishedAt:        finishedAt,
+		Status:            status,
+		ChannelsSucceeded: s.ChannelsSucceeded,
+		ChannelsFailed:    s.ChannelsFailed,
+		VideosWritten:     s.VideosWritten,
+		QuotaUnits:        s.QuotaUnits,
+		Error:             s.Error,
+		FailedChannels:    s.FailedChannels,
+	}
+}
+
+// runTracker keeps the latest runStatus. Each Cloud Run instance has its
+// own, so with several instances it reflects only the runs this one served.
+type runTracker struct {
+	mu   sync.Mutex
+	last *runStatus
+	// save, if set, persists every finished run that is not a dry run.
+	save func(ctx context.Context, s *runStatus)
+}
+
+var lastRun runTracker
+
+type runStatusKey struct{}
+
+// start records a run as in progress. It returns ctx carrying the run, for
+// update, and a function that marks the run finished with the given error.
+func (t *runTracker) start(ctx context.Context, runID, scope string, dryRun bool) (context.Context, func(error)) {
+	s := &runStatus{RunID: runID, Scope: scope, DryRun: dryRun, StartedAt: time.Now().UTC(), Running: true}
+	t.mu.Lock()
+	t.last = s
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, runStatusKey{}, s), func(err error) {
+		t.mu.Lock()
+		s.Running = false
+		finishedAt := time.Now().UTC()
+		s.FinishedAt = &finishedAt
+		if err != nil {
+			s.Error = err.Error()
+		}
+		finished := *s
+		finished.FailedChannels = append([]string(nil), s.FailedChannels...)
+		finished.DeferredChannels = append([]string(nil), s.DeferredChannels...)
+		t.mu.Unlock()
+
+		if t.save != nil && !dryRun {
+			t.save(ctx, &finished)
+		}
+	}
+}
+
+// update applies fn to the run carried by ctx, if any.
+func (t *runTracker) update(ctx context.Context, fn func(s *runStatus)) {
+	s, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(s)
+}
+
+// get returns a copy of the latest status, or nil if nothing has run yet.
+func (t *runTracker) get() *runStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		return nil
+	}
+	s := *t.last
+	s.FailedChannels = append([]string(nil), t.last.FailedChannels...)
+	s.DeferredChannels = append([]string(nil), t.last.DeferredChannels...)
+	return &s
+}
+
+// finishRun records a finished run that was not a dry run: its fetch_runs
+// row and its metrics, and sends its summary to the webhooks. The query API
+// cache is invalidated, since the run wrote new snapshots.
+func finishRun(ctx context.Context, s *runStatus) {
+	saveRun(ctx, s)
+	recordRunMetrics(ctx, s)
+	invalidateAPICache(ctx, s)
+	sendRunWebhook(ctx, s)
+}
+
+// saveRun writes a finished run to the fetch_runs table. Failures are only
+// logged: losing a history row must not fail the run itself.
+func saveRun(ctx context.Context, s *runStatus) {
+	if cfg.BigQuery.Disabled {
+		return
+	}
+	log := logger.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	w, err := newBigQueryWriter(cfg)
+	if err != nil {
+		log.Warning("Failed to record fetch run", err, nil)
+		return
+	}
+	if err := w.EnsureFetchRunsTable(ctx); err != nil {
+		log.Warning("Failed to record fetch run", err, nil)
+		return
+	}
+	if err := w.InsertFetchRun(ctx, s.record()); err != nil {
+		log.Warning("Failed to record fetch run", err, nil)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestRunTracker_Save(t *testing.T) {
+	var saved []*runStatus
+	tracker := &runTracker{save: func(ctx context.Context, s *runStatus) { saved = append(saved, s) }}
+
+	ctx, finish := tracker.start(context.Background(), "run-1", "all", false)
+	tracker.update(ctx, func(s *runStatus) {
+		s.ChannelsSucceeded, s.ChannelsFailed, s.VideosWritten, s.QuotaUnits = 2, 1, 40, 7
+		s.FailedChannels = failedChannels([]string{"UC1", "UC2", "UC3"}, []string{"UC3", "UC1"})
+	})
+	// A context without a run is ignored.
+	tracker.update(context.Background(), func(s *runStatus) { s.QuotaUnits = 1000 })
+	finish(errors.New("All channels failed"))
+
+	_, finishDry := tracker.start(context.Background(), "run-2", "all", true)
+	finishDry(nil)
+
+	if len(saved) != 1 {
+		t.Fatalf("saved %d runs, want 1 (dry runs are not saved)", len(saved))
+	}
+	rec := saved[0].record()
+	if rec.RunID != "run-1" || rec.Status != storage.RunStatusFailed || rec.Error != "All channels failed" {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.ChannelsSucceeded != 2 || rec.ChannelsFailed != 1 || rec.VideosWritten != 40 || rec.QuotaUnits != 7 {
+		t.Errorf("record counters = %+v", rec)
+	}
+	if len(rec.FailedChannels) != 1 || rec.FailedChannels[0] != "UC2" {
+		t.Errorf("failed channels = %v, want [UC2]", rec.FailedChannels)
+	}
+	if rec.FinishedAt.Before(rec.StartedAt) {
+		t.Errorf("finished_at %v before started_at %v", rec.FinishedAt, rec.StartedAt)
+	}
+}
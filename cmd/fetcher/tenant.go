@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type runConfigKey struct{}
+
+// withRunConfig makes the fetches run with ctx use c instead of cfg, e.g.
+// a tenant's configuration.
+func withRunConfig(ctx context.Context, c *config.Config) context.Context {
+	return context.WithValue(ctx, runConfigKey{}, c)
+}
+
+// runConfig returns the configuration set by withRunConfig, or cfg.
+func runConfig(ctx context.Context) *config.Config {
+	if c, ok := ctx.Value(runConfigKey{}).(*config.Config); ok {
+		return c
+	}
+	return cfg
+}
+
+// sharesQuota reports whether c fetches with youtube.api_key, whose quota
+// youtube.quota_limit and the quota metrics track.
+func sharesQuota(c *config.Config) bool {
+	return c.YouTube.APIKey == cfg.YouTube.APIKey
+}
+
+// tenantFetchHandler serves POST /tenants/{id}/fetch: a fetch of the due
+// channels of one tenant into its dataset. The run has the scope
+// "tenant:<id>" and is otherwise like POST /: it takes the run lock under
+// that scope, is recorded in fetch_runs and honours ?dry_run=true. Like
+// the other triggers it is rejected while a fetch runs on this instance.
+func tenantFetchHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tenant, ok := cfg.Tenant(id)
+	if !ok {
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return
+	}
+	// Tenants are paced separately, so that a scheduler job per tenant
+	// can fire at the same time.
+	if ok, wait := allowTrigger("/tenants/"+id+"/fetch", time.Now()); !ok {
+		rejectTooSoon(w, r, wait)
+		return
+	}
+
+	runID := newRunID()
+	ctx := requestContext(r, runID)
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(map[string]string{"tenant": id}))
+	ctx = withRunConfig(ctx, cfg.ForTenant(tenant))
+	scope := "tenant:" + id
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun || r.URL.Query().Get("dry_run") == "true" {
+		dry = storage.NewDryRunWriter()
+	}
+
+	release, err := acquireRunLock(ctx, scope, dry != nil)
+	if errors.Is(err, errRunLocked) {
+		logger.FromContext(ctx).Warning("Rejecting tenant fetch: another run is in progress", nil, nil)
+		writeJSONStatus(w, http.StatusConflict, statusError{Status: "locked", Error: err.Error()})
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Error acquiring run lock", err, nil)
+		http.Error(w, "Failed to acquire run lock", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	ctx, finish := lastRun.start(ctx, runID, scope, dry != nil)
+	err = runFetch(ctx, dry)
+	finish(err)
+	if err != nil {
+		var fe *fetchError
+		if errors.As(err, &fe) {
+			http.Error(w, fe.message, http.StatusInternalServerError)
+		} else {
+			http.Error(w, "An error occurred during the fetch and store process", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if dry != nil {
+		writeJSONStatus(w, http.StatusOK, fetchResponse{Status: "dry_run", Counts: dry.Counts(), Records: dry})
+		return
+	}
+	writeJSONStatus(w, http.StatusOK, fetchResponse{Status: "success"})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+func TestTenantFetchHandler(t *testing.T) {
+	originalCfg := cfg
+	t.Cleanup(func() { cfg = originalCfg })
+	cfg = config.DefaultConfig()
+	cfg.YouTube.APIKey = "shared-key"
+	cfg.GCP.ProjectID = "test-project"
+	cfg.Channels = []config.ChannelConfig{{ID: "UC1", Enabled: true}}
+	cfg.Tenants = []config.TenantConfig{{ID: "acme", DatasetID: "acme", Channels: []config.ChannelConfig{{ID: "UC2"}}}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tenants/{id}/fetch", tenantFetchHandler)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/tenants/other/fetch", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown tenant = %d, want 404", rr.Code)
+	}
+
+	// The run uses the tenant's channels, none of which is enabled, not
+	// the top-level ones.
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/tenants/acme/fetch", nil))
+	if rr.Code != http.StatusInternalServerError || rr.Body.String() != "No channels configured\n" {
+		t.Errorf("tenant fetch = %d %q, want 500 No channels configured", rr.Code, rr.Body.String())
+	}
+	if s := lastRun.get(); s == nil || s.Scope != "tenant:acme" {
+		t.Errorf("last run = %+v, want scope tenant:acme", s)
+	}
+}
+
+func TestNewRecordSink_Tenant(t *testing.T) {
+	t.Setenv("BIGQUERY_EMULATOR_HOST", "localhost:9050")
+	t.Cleanup(func() { sharedClients.bigquery = nil })
+	originalCfg := cfg
+	t.Cleanup(func() { cfg = originalCfg })
+	cfg = config.DefaultConfig()
+	cfg.GCP.ProjectID = "test-project"
+	cfg.BigQuery.DatasetID = "default"
+
+	ctx := withRunConfig(context.Background(), cfg.ForTenant(config.TenantConfig{ID: "acme", DatasetID: "acme"}))
+	_, w, err := newRecordSink(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.DatasetID() != "acme" {
+		t.Errorf("tenant run writes to dataset %q, want acme", w.DatasetID())
+	}
+	if _, w, _ := newRecordSink(context.Background(), nil); w.DatasetID() != "default" {
+		t.Errorf("run without a tenant writes to dataset %q, want default", w.DatasetID())
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	originalCfg := cfg
+	t.Cleanup(func() { cfg = originalCfg })
+	cfg = config.DefaultConfig()
+	cfg.YouTube.APIKey = "shared-key"
+
+	ctx := context.Background()
+	if runConfig(ctx) != cfg || !sharesQuota(runConfig(ctx)) {
+		t.Errorf("runConfig() without a tenant should be cfg")
+	}
+	own := withRunConfig(ctx, cfg.ForTenant(config.TenantConfig{ID: "acme", DatasetID: "acme", APIKey: "acme-key"}))
+	if c := runConfig(own); c.BigQuery.DatasetID != "acme" || sharesQuota(c) {
+		t.Errorf("runConfig() = dataset %s, shares quota %v; want acme with its own quota", c.BigQuery.DatasetID, sharesQuota(c))
+	}
+	shared := withRunConfig(ctx, cfg.ForTenant(config.TenantConfig{ID: "beta", DatasetID: "beta"}))
+	if !sharesQuota(runConfig(shared)) {
+		t.Errorf("a tenant without its own key should share the quota")
+	}
+}
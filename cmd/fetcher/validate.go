@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/channelsource"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// runValidateConfig checks a configuration file without running a fetch. It
+// runs Config.Validate, reports duplicated or disabled channels and, unless
+// -offline is given, reads the external channel source (if any) and checks
+// that every channel ID exists on YouTube. With
+// -schema it prints the JSON Schema of the file instead.
+func runValidateConfig(args []string) int {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configPath := fs.String("config", "configs/config.yaml", "Path to configuration file")
+	schema := fs.Bool("schema", false, "Print the JSON Schema of the configuration file and exit")
+	offline := fs.Bool("offline", false, "Skip checking channel IDs against the YouTube API")
+	timeout := fs.Duration("timeout", 30*time.Second, "Maximum time to spend on API checks")
+	fs.Parse(args)
+
+	if *schema {
+		data, err := config.JSONSchema()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate schema: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	c, err := config.LoadUnvalidated(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+
+	failed := false
+	if err := c.Validate(); err != nil {
+		// Channel problems are reported together, one per line.
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("ERROR   %s\n", line)
+		}
+		failed = true
+	}
+	if c.App.ChannelConfigSource != "" && !*offline {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		src, err := channelsource.New(ctx, c.App.ChannelConfigSource, c.App.ChannelConfigTTL, c.GCP.ProjectID, c.BigQuery.DatasetID)
+		if err == nil {
+			c.Channels, err = src.Channels(ctx)
+		}
+		if err != nil {
+			fmt.Printf("ERROR   reading channels from %s: %v\n", c.App.ChannelConfigSource, err)
+			failed = true
+		}
+	}
+	for _, issue := range c.ChannelIssues() {
+		fmt.Printf("WARNING %s\n", issue)
+	}
+
+	if !*offline && c.YouTube.APIKey != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		missing, err := missingChannels(ctx, c)
+		if err != nil {
+			fmt.Printf("ERROR   checking channel IDs: %v\n", err)
+			failed = true
+		}
+		for _, ch := range missing {
+			fmt.Printf("ERROR   %s does not exist\n", ch.Ref(-1))
+			failed = true
+		}
+	} else if !*offline {
+		fmt.Println("WARNING YouTube API key is not set; channel IDs were not checked")
+	}
+
+	if failed {
+		return 1
+	}
+	fmt.Printf("%s is valid\n", *configPath)
+	return 0
+}
+
+// verifyChannels checks at startup that the channels and playlists of the
+// configuration and its tenants exist, logging every missing one. It
+// reports whether all of them were found; a check that could not be made
+// is logged and not counted as a failure.
+func verifyChannels(ctx context.Context) bool {
+	ok := true
+	check := func(c *config.Config, labels map[string]string) {
+		missing, err := missingChannels(ctx, c)
+		if err != nil {
+			log.Warning("Could not verify the configured channels", err, labels)
+			return
+		}
+		for _, ch := range missing {
+			ok = false
+			log.Error(fmt.Sprintf("%s does not exist: it will fail on every run", ch.Ref(-1)), nil, labels)
+		}
+	}
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Warning("Could not verify the configured channels", err, nil)
+		return true
+	}
+	check(c, map[string]string{})
+	for _, t := range cfg.Tenants {
+		check(cfg.ForTenant(t), map[string]string{"tenant": t.ID})
+	}
+	if ok {
+		log.Info("Configured channels verified", nil)
+	}
+	return ok
+}
+
+// missingChannels returns the configured channel and playlist entries whose
+// IDs the API does not resolve, in configuration order.
+func missingChannels(ctx context.Context, c *config.Config) ([]config.ChannelConfig, error) {
+	var entries []config.ChannelConfig
+	var channelIDs, playlistIDs []string
+	seen := make(map[string]bool, len(c.Channels))
+	for _, ch := range c.Channels {
+		if ch.ID == "" || seen[ch.ID] {
+			continue
+		}
+		seen[ch.ID] = true
+		entries = append(entries, ch)
+		if ch.IsPlaylist() {
+			playlistIDs = append(playlistIDs, ch.ID)
+		} else {
+			channelIDs = append(channelIDs, ch.ID)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	ytClient, err := youtube.NewClientWithOptions(ctx, c.YouTube.APIKey, youtubeClientOptions(c))
+	if err != nil {
+		return nil, err
+	}
+	found, err := ytClient.ExistingChannels(ctx, channelIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(playlistIDs) > 0 {
+		playlists, err := ytClient.ExistingPlaylists(ctx, playlistIDs)
+		if err != nil {
+			return nil, err
+		}
+		for id := range playlists {
+			found[id] = true
+		}
+	}
+
+	var missing []config.ChannelConfig
+	for _, ch := range entries {
+		if !found[ch.ID] {
+			missing = append(missing, ch)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/notify"
+	"github.com/lancelop89/youtube-trend-tracker/internal/webhook"
+)
+
+// webhookSender delivers webhook events.
+type webhookSender interface {
+	Send(ctx context.Context, e webhook.Event) error
+}
+
+// newWebhookSender creates the webhook dispatcher; tests replace it.
+var newWebhookSender = func(ctx context.Context, c config.WebhooksConfig) (webhookSender, error) {
+	return webhook.New(ctx, c, appMetrics)
+}
+
+// webhookTimeout bounds the deliveries of a run summary, retries included.
+const webhookTimeout = 5 * time.Minute
+
+// sendRunWebhook sends the summary of a finished run to the webhooks
+// subscribed to run.completed. Failed deliveries are logged and
+// dead-lettered by the dispatcher, so they do not affect the run.
+func sendRunWebhook(ctx context.Context, s *runStatus) {
+	if !cfg.Webhooks.Subscribed(config.WebhookEventRunCompleted) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookTimeout)
+	defer cancel()
+
+	sender, err := newWebhookSender(ctx, cfg.Webhooks)
+	if err != nil {
+		logger.FromContext(ctx).Warning("Error creating webhook dispatcher", err, nil)
+		return
+	}
+	_ = sender.Send(ctx, webhook.NewEvent(config.WebhookEventRunCompleted, s))
+}
+
+// webhookAction is the implicit notification action sending the events of
+// a run to the webhooks as one trend.detected event, and the new uploads
+// as one video.uploaded event.
+type webhookAction struct {
+	sender webhookSender
+}
+
+// trendDetected is the data of a trend.detected event.
+type trendDetected struct {
+	Events []notify.Event `json:"events"`
+}
+
+// videoUploaded is the data of a video.uploaded event.
+type videoUploaded struct {
+	Events []notify.Event `json:"events"`
+}
+
+// Notify implements notify.Action.
+func (a webhookAction) Notify(ctx context.Context, events []notify.Event) error {
+	var trends, uploads []notify.Event
+	for _, e := range events {
+		if e.Rule == config.NewUploadsRuleName {
+			uploads = append(uploads, e)
+		} else {
+			trends = append(trends, e)
+		}
+	}
+	var errs []error
+	if len(trends) > 0 {
+		errs = append(errs, a.sender.Send(ctx, webhook.NewEvent(config.WebhookEventTrendDetected, trendDetected{Events: trends})))
+	}
+	if len(uploads) > 0 {
+		errs = append(errs, a.sender.Send(ctx, webhook.NewEvent(config.WebhookEventVideoUploaded, videoUploaded{Events: uploads})))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/notify"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/webhook"
+)
+
+// fakeWebhookSender records the events sent.
+type fakeWebhookSender struct {
+	events []webhook.Event
+}
+
+func (f *fakeWebhookSender) Send(ctx context.Context, e webhook.Event) error {
+	f.events = append(f.events, e)
+	return nil
+}
+
+func TestWebhooks(t *testing.T) {
+	originalCfg, originalStore, originalActions, originalSender := cfg, newNotificationStore, newNotifyActions, newWebhookSender
+	t.Cleanup(func() {
+		cfg, newNotificationStore, newNotifyActions, newWebhookSender = originalCfg, originalStore, originalActions, originalSender
+	})
+	cfg = config.DefaultConfig()
+	cfg.Webhooks.Endpoints = []config.WebhookEndpointConfig{{URL: "https://example.com/hook"}}
+	// A rule without actions notifies the webhooks only.
+	cfg.Notifications.Rules = []config.NotifyRuleConfig{{Name: "viral", When: "views_gained_24h > 100000"}}
+
+	sender := &fakeWebhookSender{}
+	newWebhookSender = func(ctx context.Context, c config.WebhooksConfig) (webhookSender, error) { return sender, nil }
+	store := &fakeNotificationStore{videos: []storage.VideoFacts{{ChannelID: "UC1", VideoID: "v1", ViewsGained24h: 200000}}}
+	newNotificationStore = func(ctx context.Context) (notificationStore, error) { return store, nil }
+	newNotifyActions = func(ctx context.Context, c *config.Config) (map[string]notify.Action, error) {
+		return map[string]notify.Action{}, nil
+	}
+	ctx := context.Background()
+
+	runNotifications(ctx, []string{"UC1"})
+	sendRunWebhook(ctx, &runStatus{RunID: "run-1", VideosWritten: 3})
+
+	if len(sender.events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(sender.events))
+	}
+	trend, ok := sender.events[0].Data.(trendDetected)
+	if sender.events[0].Type != config.WebhookEventTrendDetected || !ok || len(trend.Events) != 1 || trend.Events[0].Video.VideoID != "v1" {
+		t.Errorf("first event = %+v, want trend.detected for v1", sender.events[0])
+	}
+	if len(store.sent) != 1 || store.sent[0].Actions[0] != config.WebhooksActionName {
+		t.Errorf("recorded %+v, want v1 sent by %s", store.sent, config.WebhooksActionName)
+	}
+	run, ok := sender.events[1].Data.(*runStatus)
+	if sender.events[1].Type != config.WebhookEventRunCompleted || !ok || run.RunID != "run-1" {
+		t.Errorf("second event = %+v, want run.completed for run-1", sender.events[1])
+	}
+	if cfg.Notifications.Rules[0].Actions != nil {
+		t.Errorf("configured rule actions = %v, want them left unchanged", cfg.Notifications.Rules[0].Actions)
+	}
+}
+
+func TestWebhooks_NewUploads(t *testing.T) {
+	originalCfg, originalStore, originalActions, originalSender := cfg, newNotificationStore, newNotifyActions, newWebhookSender
+	t.Cleanup(func() {
+		cfg, newNotificationStore, newNotifyActions, newWebhookSender = originalCfg, originalStore, originalActions, originalSender
+	})
+	cfg = config.DefaultConfig()
+	cfg.Webhooks.Endpoints = []config.WebhookEndpointConfig{{URL: "https://example.com/hook"}}
+	cfg.Notifications.Rules = []config.NotifyRuleConfig{{Name: "viral", When: "views_gained_24h > 100000"}}
+	cfg.Notifications.NewUploads.Enabled = true
+
+	sender := &fakeWebhookSender{}
+	newWebhookSender = func(ctx context.Context, c config.WebhooksConfig) (webhookSender, error) { return sender, nil }
+	store := &fakeNotificationStore{videos: []storage.VideoFacts{
+		{ChannelID: "UC1", VideoID: "v1", ViewsGained24h: 200000},
+		{ChannelID: "UC1", VideoID: "v2", Title: "Just uploaded", NewUpload: true},
+	}}
+	newNotificationStore = func(ctx context.Context) (notificationStore, error) { return store, nil }
+	newNotifyActions = func(ctx context.Context, c *config.Config) (map[string]notify.Action, error) {
+		return map[string]notify.Action{}, nil
+	}
+
+	runNotifications(context.Background(), []string{"UC1"})
+
+	if len(sender.events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(sender.events))
+	}
+	if _, ok := sender.events[0].Data.(trendDetected); sender.events[0].Type != config.WebhookEventTrendDetected || !ok {
+		t.Errorf("first event = %+v, want trend.detected", sender.events[0])
+	}
+	uploaded, ok := sender.events[1].Data.(videoUploaded)
+	if sender.events[1].Type != config.WebhookEventVideoUploaded || !ok || len(uploaded.Events) != 1 || uploaded.Events[0].Video.VideoID != "v2" {
+		t.Errorf("second event = %+v, want video.uploaded for v2", sender.events[1])
+	}
+	if len(store.sent) != 2 || store.sent[1].Rule != config.NewUploadsRuleName {
+		t.Errorf("recorded %+v, want v1 by viral and v2 by %s", store.sent, config.NewUploadsRuleName)
+	}
+}
+
+func TestWebhooks_SenderError(t *testing.T) {
+	originalCfg, originalStore, originalActions, originalSender := cfg, newNotificationStore, newNotifyActions, newWebhookSender
+	t.Cleanup(func() {
+		cfg, newNotificationStore, newNotifyActions, newWebhookSender = originalCfg, originalStore, originalActions, originalSender
+	})
+	cfg = config.DefaultConfig()
+	cfg.Webhooks.Endpoints = []config.WebhookEndpointConfig{{URL: "https://example.com/hook"}}
+	cfg.Notifications.Rules = []config.NotifyRuleConfig{{Name: "viral", When: "views_gained_24h > 100000", Actions: []string{"team"}}}
+
+	newWebhookSender = func(ctx context.Context, c config.WebhooksConfig) (webhookSender, error) {
+		return nil, errors.New("spill buffer unavailable")
+	}
+	store := &fakeNotificationStore{videos: []storage.VideoFacts{{ChannelID: "UC1", VideoID: "v1", ViewsGained24h: 200000}}}
+	newNotificationStore = func(ctx context.Context) (notificationStore, error) { return store, nil }
+	action := &countingAction{}
+	newNotifyActions = func(ctx context.Context, c *config.Config) (map[string]notify.Action, error) {
+		return map[string]notify.Action{"team": action}, nil
+	}
+
+	// The other actions are still notified without the webhooks.
+	runNotifications(context.Background(), []string{"UC1"})
+	if action.events != 1 || len(store.sent) != 1 || len(store.sent[0].Actions) != 1 || store.sent[0].Actions[0] != "team" {
+		t.Errorf("sent %d events, recorded %+v; want v1 sent by team only", action.events, store.sent)
+	}
+}
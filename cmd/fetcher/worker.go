@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/queue"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// dispatchResponse is the body of POST /dispatch.
+type dispatchResponse struct {
+	Status string `json:"status"`
+	RunID  string `json:"run_id"`
+	Tasks  int    `json:"tasks"`
+}
+
+// dispatchHandler publishes one Pub/Sub message per enabled channel so that
+// worker instances can process channels in parallel.
+func dispatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if cfg.PubSub.TopicID == "" {
+		http.Error(w, "Pub/Sub topic not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	runID := newRunID()
+	ctx := requestContext(r, runID)
+	log := logger.FromContext(ctx)
+
+	c, err := currentConfig(ctx)
+	if err != nil {
+		log.Error("Error loading channel list", err, map[string]string{"source": cfg.App.ChannelConfigSource})
+		http.Error(w, "Failed to load channel list", http.StatusInternalServerError)
+		return
+	}
+	if len(c.GetEnabledChannelIDs()) == 0 {
+		log.Error("No enabled channels in configuration", nil, nil)
+		http.Error(w, "No channels configured", http.StatusInternalServerError)
+		return
+	}
+	channelIDs := withoutDisabledChannels(ctx, c.DueChannelIDs(time.Now()))
+
+	publisher, err := queue.NewPublisher(ctx, cfg.GCP.ProjectID, cfg.PubSub.TopicID)
+	if err != nil {
+		log.Error("Error creating Pub/Sub publisher", err, nil)
+		http.Error(w, "Failed to create Pub/Sub publisher", http.StatusInternalServerError)
+		return
+	}
+
+	tasks := make([]queue.ChannelTask, 0, len(channelIDs))
+	for _, id := range channelIDs {
+		tasks = append(tasks, queue.ChannelTask{
+			ChannelID: id,
+			MaxVideos: cfg.App.MaxVideosPerChannel,
+			RunID:     runID,
+		})
+	}
+
+	ids, err := publisher.PublishChannelTasks(ctx, tasks)
+	if err != nil {
+		log.Error("Error publishing channel tasks", err, map[string]string{
+			"published": fmt.Sprintf("%d", len(ids)),
+		})
+		http.Error(w, "Failed to publish channel tasks", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info(fmt.Sprintf("Dispatched %d channel tasks", len(ids)), map[string]string{
+		"topic": cfg.PubSub.TopicID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dispatchResponse{Status: "dispatched", RunID: runID, Tasks: len(ids)})
+}
+
+// channelTaskHandler is the Pub/Sub push endpoint that processes a single
+// channel. A non-2xx response makes Pub/Sub redeliver the message; malformed
+// messages are acknowledged so they are not retried forever.
+func channelTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	task, err := queue.DecodePushRequest(r.Body)
+	if err != nil {
+		logger.FromContext(requestContext(r, "")).Error("Dropping malformed channel task", err, nil)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Tasks of one dispatch share the dispatcher's run ID.
+	ctx := requestContext(r, task.RunID)
+	log := logger.FromContext(ctx)
+
+	maxVideos := task.MaxVideos
+	if maxVideos <= 0 {
+		maxVideos = cfg.App.MaxVideosPerChannel
+	}
+
+	labels := map[string]string{"channel_id": task.ChannelID}
+	log.Info(fmt.Sprintf("Processing channel task: %s", task.ChannelID), labels)
+
+	var dry *storage.DryRunWriter
+	if cfg.App.DryRun {
+		dry = storage.NewDryRunWriter()
+	}
+	scope := "channel:" + task.ChannelID
+	release, err := acquireRunLock(ctx, scope, dry != nil)
+	if errors.Is(err, errRunLocked) {
+		// A redelivery of a task that is still running; acknowledge it so
+		// Pub/Sub does not deliver it again once the first one finishes.
+		log.Warning("Skipping channel task: already running", nil, labels)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "skipped", "channel_id": task.ChannelID})
+		return
+	}
+	if err != nil {
+		log.Error("Error acquiring run lock", err, labels)
+		http.Error(w, "Failed to acquire run lock", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	ctx, finish := lastRun.start(ctx, task.RunID, scope, dry != nil)
+	err = runFetchChannels(ctx, []string{task.ChannelID}, maxVideos, dry)
+	finish(err)
+	if errors.Is(err, apperrors.ErrQuotaExceeded) {
+		// Redelivery cannot succeed before the quota resets; acknowledge the
+		// task and leave the channel to the next dispatch.
+		log.Warning("Skipping channel task: YouTube API quota exceeded", err, labels)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "skipped", "channel_id": task.ChannelID})
+		return
+	}
+	if err != nil {
+		log.Error("Channel task failed", err, labels)
+		http.Error(w, "Channel task failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "channel_id": task.ChannelID})
+}
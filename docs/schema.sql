@@ -5,7 +5,10 @@
 -- スキーマ定義を管理します。
 --
 -- データセット: youtube
--- テーブル: videos, channels
+-- テーブル: videos, channels, discovered_channels, video_categories, fetch_runs, run_locks,
+--           video_trend_scores, tag_trends, channel_daily_stats, channel_health,
+--           notifications, publish_time_stats, channel_snapshots, channel_milestones,
+--           video_comment_sentiment, video_thumbnails
 -- ============================================================================
 
 -- ----------------------------------------------------------------------------
@@ -45,7 +48,32 @@ CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_trends` (
   -- 追加メタデータ
   duration_sec INT64 OPTIONS(description="動画の長さ（秒）"),
   content_details STRING OPTIONS(description="コンテンツ詳細"),
-  topic_details ARRAY<STRING> OPTIONS(description="トピック詳細")
+  region_allowed ARRAY<STRING> OPTIONS(description="視聴可能な地域（ISO 3166-1、設定時はこの地域のみ視聴可）"),
+  region_blocked ARRAY<STRING> OPTIONS(description="視聴がブロックされている地域（ISO 3166-1）"),
+  content_rating ARRAY<STRUCT<system STRING, rating STRING>> OPTIONS(description="レーティング（system: yt, mpaa など、年齢制限は yt / ytAgeRestricted）"),
+  topic_details ARRAY<STRING> OPTIONS(description="トピック詳細"),
+  status STRING OPTIONS(description="公開状態 (public/unlisted/private/unavailable)"),
+  description STRING OPTIONS(description="動画の説明文"),
+  thumbnail_url STRING OPTIONS(description="最高解像度のサムネイルURL"),
+  chapters ARRAY<STRUCT<start_sec INT64, title STRING>> OPTIONS(description="説明文のチャプター（0:00から始まる3つ以上のタイムスタンプ、YouTubeの表示と同じ条件）"),
+  privacy_status STRING OPTIONS(description="取得時の公開設定（status.privacyStatus、statusと違いunavailableにはならない）"),
+  made_for_kids BOOL OPTIONS(description="子ども向け設定（コメント・パーソナライズ広告が無効）"),
+  license STRING OPTIONS(description="ライセンス（youtube または creativeCommon）"),
+  embeddable BOOL OPTIONS(description="外部サイトへの埋め込み可否"),
+  paid_promotion BOOL OPTIONS(description="有料プロモーション（プロダクトプレースメント）を含むと申告されているか"),
+  category_id STRING OPTIONS(description="動画カテゴリID（videoCategories.list 参照、例: 22=People & Blogs）"),
+  default_language STRING OPTIONS(description="タイトル・説明文の言語（投稿者設定、未設定は空）"),
+  title_language STRING OPTIONS(description="タイトルから推定した言語（ja, en など、判定できない場合は空）"),
+  title_keywords ARRAY<STRING> OPTIONS(description="タイトルの正規化キーワード（NFKC・小文字化、ストップワード除外、漢字・カタカナ単位で分割）"),
+  playlist_id STRING OPTIONS(description="監視対象プレイリストのID（type: playlist のエントリで取得した行のみ、channel_idは投稿チャンネル）"),
+  category_name STRING OPTIONS(description="動画カテゴリ名（youtube.category_regions の先頭の地域・category_languageの言語、解決できない場合は空）"),
+  snapshot_ts TIMESTAMP OPTIONS(description="取得実行の開始時刻（dtより細かい粒度）"),
+  shorts_confidence FLOAT64 OPTIONS(description="ショート判定の確信度 0〜1（0.5以上でis_short=TRUE）"),
+
+  -- 書き込み時に計算する派生値（再生回数0・公開日時不明の場合はNULL）
+  like_rate FLOAT64 OPTIONS(description="高評価率（likes / views）"),
+  comment_rate FLOAT64 OPTIONS(description="コメント率（comments / views）"),
+  views_per_hour FLOAT64 OPTIONS(description="公開からの1時間あたり再生回数（snapshot_ts時点、1時間未満は1時間として計算）")
 )
 PARTITION BY dt  -- dtフィールドでパーティショニング
 CLUSTER BY channel_id, video_id
@@ -54,9 +82,311 @@ OPTIONS(
 );
 
 -- ----------------------------------------------------------------------------
--- 注意: 現在の実装ではvideo_trendsテーブルのみ使用
--- channelsテーブルは将来の拡張用（未実装）
+-- video_metadata_changes テーブル: タイトル・説明・タグ・サムネイルの変更履歴
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_metadata_changes` (
+  dt DATE NOT NULL OPTIONS(description="検出日"),
+  detected_at TIMESTAMP NOT NULL OPTIONS(description="検出日時"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  field STRING NOT NULL OPTIONS(description="変更されたフィールド (title/description/tags/thumbnail_url)"),
+  old_value STRING OPTIONS(description="変更前の値（tagsはJSON配列）"),
+  new_value STRING OPTIONS(description="変更後の値（tagsはJSON配列）")
+)
+PARTITION BY dt
+CLUSTER BY channel_id, video_id;
+
+-- ----------------------------------------------------------------------------
+-- video_thumbnails テーブル: Cloud Storage に保存したサムネイル (app.thumbnail_archive 設定時)
+-- 初めて取得したものと内容が変わったものだけが記録される
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_thumbnails` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  archived_at TIMESTAMP NOT NULL OPTIONS(description="取得実行の開始時刻"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  thumbnail_url STRING OPTIONS(description="サムネイルのURL"),
+  sha256 STRING NOT NULL OPTIONS(description="画像のSHA-256（16進）"),
+  previous_sha256 STRING OPTIONS(description="変更前の画像のSHA-256（初回はNULL）"),
+  gcs_path STRING NOT NULL OPTIONS(description="保存先 (gs://<bucket>/<object>)"),
+  content_type STRING OPTIONS(description="画像の Content-Type"),
+  bytes INT64 OPTIONS(description="画像のサイズ (バイト)")
+)
+PARTITION BY dt
+CLUSTER BY channel_id, video_id;
+
+-- ----------------------------------------------------------------------------
+-- keyword_trends テーブル: キーワード検索の上位結果 (config の keywords)
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.keyword_trends` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  snapshot_ts TIMESTAMP NOT NULL OPTIONS(description="取得実行の開始時刻"),
+  keyword STRING NOT NULL OPTIONS(description="検索キーワード"),
+  rank INT64 NOT NULL OPTIONS(description="検索結果の順位（1始まり）"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  channel_id STRING OPTIONS(description="YouTubeチャンネルID"),
+  channel_name STRING OPTIONS(description="チャンネル名"),
+  title STRING OPTIONS(description="動画タイトル"),
+  views INT64 OPTIONS(description="再生回数"),
+  likes INT64 OPTIONS(description="高評価数"),
+  comments INT64 OPTIONS(description="コメント数"),
+  published_at TIMESTAMP OPTIONS(description="動画の公開日時"),
+  created_at TIMESTAMP NOT NULL OPTIONS(description="データ取得日時")
+)
+PARTITION BY dt
+CLUSTER BY keyword, video_id;
+
+-- ----------------------------------------------------------------------------
+-- video_comments テーブル: 上位コメント (track_comments を有効にしたチャンネル)
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_comments` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  snapshot_ts TIMESTAMP NOT NULL OPTIONS(description="取得実行の開始時刻"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  comment_id STRING NOT NULL OPTIONS(description="コメントスレッドID"),
+  rank INT64 NOT NULL OPTIONS(description="関連度順の順位（1始まり）"),
+  author_name STRING OPTIONS(description="投稿者の表示名"),
+  author_channel_id STRING OPTIONS(description="投稿者のチャンネルID"),
+  text STRING OPTIONS(description="コメント本文（プレーンテキスト）"),
+  likes INT64 OPTIONS(description="コメントの高評価数"),
+  reply_count INT64 OPTIONS(description="返信数"),
+  published_at TIMESTAMP OPTIONS(description="コメントの投稿日時"),
+  created_at TIMESTAMP NOT NULL OPTIONS(description="データ取得日時")
+)
+PARTITION BY dt
+CLUSTER BY channel_id, video_id;
+
+-- ----------------------------------------------------------------------------
+-- channels テーブル: 監視対象チャンネル (CHANNEL_CONFIG_SOURCE=bigquery の場合)
+-- `fetcher channels push` で設定ファイルから登録、コンソールや dbt seed で編集可
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.channels` (
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  name STRING OPTIONS(description="表示用のチャンネル名"),
+  description STRING OPTIONS(description="メモ"),
+  enabled BOOL OPTIONS(description="監視対象か（NULLは有効扱い）"),
+  track_comments BOOL OPTIONS(description="上位コメントを保存するか"),
+  updated_at TIMESTAMP OPTIONS(description="最終更新日時")
+);
+
+-- ----------------------------------------------------------------------------
+-- discovered_channels テーブル: 追加候補のチャンネル (`fetcher discover --out table`)
+-- 監視中チャンネルの紹介チャンネルとチャンネル検索の結果、channel_id ごとに 1 行
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.discovered_channels` (
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  title STRING OPTIONS(description="チャンネル名"),
+  country STRING OPTIONS(description="チャンネルの国"),
+  subscribers INT64 OPTIONS(description="登録者数（非公開は0）"),
+  videos INT64 OPTIONS(description="動画数"),
+  views INT64 OPTIONS(description="総再生回数"),
+  sources ARRAY<STRING> OPTIONS(description="発見元（featured:<シードのチャンネルID> または search:<検索語>）"),
+  score INT64 OPTIONS(description="発見元の数（多いほど関連が強い）"),
+  first_discovered_at TIMESTAMP OPTIONS(description="初回発見日時"),
+  discovered_at TIMESTAMP OPTIONS(description="最終発見日時")
+);
+
+-- ----------------------------------------------------------------------------
+-- video_categories テーブル: 動画カテゴリのディメンション (取得の実行時に videoCategories.list から更新)
+-- category_id を地域ごとのカテゴリ名に結合する。(region_code, category_id) ごとに 1 行
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_categories` (
+  region_code STRING NOT NULL OPTIONS(description="地域（ISO 3166-1 alpha-2）"),
+  category_id STRING NOT NULL OPTIONS(description="動画カテゴリID"),
+  title STRING OPTIONS(description="カテゴリ名（youtube.category_language の言語）"),
+  assignable BOOL OPTIONS(description="投稿時に選択できるか（FALSEは旧カテゴリ）"),
+  updated_at TIMESTAMP OPTIONS(description="最終更新日時")
+);
+
+-- ----------------------------------------------------------------------------
+-- fetch_runs テーブル: 取得実行の履歴 (ドライランを除く全実行、`GET /runs` で参照)
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.fetch_runs` (
+  run_id STRING NOT NULL OPTIONS(description="実行ID（ログの run_id ラベルと同じ）"),
+  scope STRING NOT NULL OPTIONS(description="対象（all、channel:<チャンネルID>、または retry:<再実行元の実行ID>）"),
+  started_at TIMESTAMP NOT NULL OPTIONS(description="開始日時"),
+  finished_at TIMESTAMP NOT NULL OPTIONS(description="終了日時"),
+  status STRING NOT NULL OPTIONS(description="success または failed"),
+  channels_succeeded INT64 OPTIONS(description="成功したチャンネル数"),
+  channels_failed INT64 OPTIONS(description="失敗・スキップしたチャンネル数"),
+  videos_written INT64 OPTIONS(description="書き込んだ動画レコード数"),
+  quota_units INT64 OPTIONS(description="消費した YouTube API クォータ（リトライ含む）"),
+  error STRING OPTIONS(description="失敗時のエラーメッセージ"),
+  failed_channels ARRAY<STRING> OPTIONS(description="失敗・スキップしたチャンネルID")
+)
+PARTITION BY DATE(started_at)
+CLUSTER BY scope;
+
+-- ----------------------------------------------------------------------------
+-- video_trend_scores テーブル: 動画のトレンドスコア (analytics.trend_score 有効時)
+-- 実行のたびに追記されるため、日付・動画ごとに computed_at が最新の行を使う
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_trend_scores` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  computed_at TIMESTAMP NOT NULL OPTIONS(description="計算日時"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  title STRING OPTIONS(description="動画タイトル"),
+  formula STRING NOT NULL OPTIONS(description="計算式 (velocity / relative_velocity / decayed)"),
+  score FLOAT64 NOT NULL OPTIONS(description="トレンドスコア（大きいほど伸びている）"),
+  views INT64 OPTIONS(description="再生回数"),
+  views_gained INT64 OPTIONS(description="基準時点からの再生増加数"),
+  hours FLOAT64 OPTIONS(description="基準時点からの経過時間"),
+  age_hours FLOAT64 OPTIONS(description="公開からの経過時間"),
+  channel_median_views INT64 OPTIONS(description="チャンネルの動画再生数の中央値"),
+  forecast_views_7d INT64 OPTIONS(description="7日後の予測再生回数 (analytics.forecast 有効時)"),
+  forecast_views_30d INT64 OPTIONS(description="30日後の予測再生回数 (analytics.forecast 有効時)")
+)
+PARTITION BY dt
+CLUSTER BY channel_id, video_id;
+
+-- ----------------------------------------------------------------------------
+-- tag_trends テーブル: タグ・ハッシュタグの日別集計 (analytics.tag_trends 有効時)
+-- タグは NFKC 正規化・小文字化済み。実行のたびに当日のパーティションを置き換える
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.tag_trends` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  tag STRING NOT NULL OPTIONS(description="タグ（tags とタイトル・説明文の #ハッシュタグ）"),
+  video_count INT64 NOT NULL OPTIONS(description="タグが付いた動画数"),
+  channel_count INT64 NOT NULL OPTIONS(description="タグが付いた動画のチャンネル数"),
+  total_views INT64 NOT NULL OPTIONS(description="タグが付いた動画の総再生回数"),
+  views_gained INT64 NOT NULL OPTIONS(description="前日以前の直近スナップショットからの再生増加数の合計（初出の動画は総再生回数）"),
+  computed_at TIMESTAMP NOT NULL OPTIONS(description="集計日時")
+)
+PARTITION BY dt
+CLUSTER BY tag;
+
+-- ----------------------------------------------------------------------------
+-- channel_daily_stats テーブル: チャンネル別の日次集計 (analytics.channel_daily_stats 有効時)
+-- 動画ごとに当日の最新スナップショットを集計する。実行のたびに当日のパーティションを置き換える
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.channel_daily_stats` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  channel_id STRING NOT NULL OPTIONS(description="チャンネルID"),
+  channel_name STRING OPTIONS(description="チャンネル名"),
+  videos INT64 NOT NULL OPTIONS(description="当日スナップショットのある動画数"),
+  uploads INT64 NOT NULL OPTIONS(description="そのうち当日（アプリのタイムゾーン）に公開された動画数"),
+  shorts INT64 NOT NULL OPTIONS(description="そのうちショート動画の数"),
+  shorts_share FLOAT64 NOT NULL OPTIONS(description="ショート動画の割合（shorts / videos）"),
+  total_views INT64 NOT NULL OPTIONS(description="総再生回数"),
+  total_likes INT64 NOT NULL OPTIONS(description="総高評価数"),
+  total_comments INT64 NOT NULL OPTIONS(description="総コメント数"),
+  computed_at TIMESTAMP NOT NULL OPTIONS(description="集計日時"),
+  uploads_per_week FLOAT64 OPTIONS(description="直近4週間の週あたり投稿数"),
+  avg_upload_gap_days FLOAT64 OPTIONS(description="直近13週間の投稿の平均間隔（日、2本未満は NULL）"),
+  upload_streak_weeks INT64 OPTIONS(description="当日まで7日ごとに投稿が続いた週数"),
+  last_upload_at TIMESTAMP OPTIONS(description="最後の投稿日時")
+)
+PARTITION BY dt
+CLUSTER BY channel_id;
+
+-- ----------------------------------------------------------------------------
+-- run_locks テーブル: 実行の重複防止用リース (RUN_LOCK=true の場合)
+-- 名前 (fetch:all, fetch:channel:<ID>) ごとに1行、終了時に削除される
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.run_locks` (
+  name STRING NOT NULL OPTIONS(description="ロック名"),
+  holder STRING NOT NULL OPTIONS(description="取得した呼び出しのID"),
+  acquired_at TIMESTAMP NOT NULL OPTIONS(description="取得日時"),
+  expires_at TIMESTAMP NOT NULL OPTIONS(description="失効日時（fetch_timeout + 1分）")
+);
+
+-- ----------------------------------------------------------------------------
+-- channel_health テーブル: チャンネルごとの連続失敗回数 (AUTO_DISABLE_AFTER > 0 の場合)
+-- 失敗したことのあるチャンネルごとに1行、成功すると連続失敗回数は 0 に戻る
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.channel_health` (
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  consecutive_failures INT64 NOT NULL OPTIONS(description="連続して失敗した実行の回数"),
+  last_error STRING OPTIONS(description="直近の失敗のエラーメッセージ"),
+  last_failed_at TIMESTAMP OPTIONS(description="直近の失敗日時"),
+  disabled_at TIMESTAMP OPTIONS(description="自動で無効化された日時（POST /channels/{id}/enable で NULL に戻る）")
+);
+
+-- ----------------------------------------------------------------------------
+-- notifications テーブル: 通知ルールで通知した動画 (notifications.rules 設定時)
+-- ルールごとのクールダウン中に同じ動画を再通知しないために参照する
 -- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.notifications` (
+  sent_at TIMESTAMP NOT NULL OPTIONS(description="通知日時"),
+  rule STRING NOT NULL OPTIONS(description="ルール名"),
+  channel_id STRING OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  actions ARRAY<STRING> OPTIONS(description="通知に成功したアクション名")
+)
+PARTITION BY DATE(sent_at)
+CLUSTER BY rule;
+
+-- ----------------------------------------------------------------------------
+-- publish_time_stats テーブル: 公開曜日・時間帯ごとの動画の初速 (analytics.publish_times 有効時)
+-- 実行のたびに当日のパーティションが置き換えられる
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.publish_time_stats` (
+  dt DATE NOT NULL OPTIONS(description="集計日"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  channel_name STRING OPTIONS(description="チャンネル名"),
+  weekday INT64 NOT NULL OPTIONS(description="公開曜日（0=日曜〜6=土曜、アプリのタイムゾーン）"),
+  hour INT64 NOT NULL OPTIONS(description="公開時刻の時（0〜23）"),
+  videos INT64 NOT NULL OPTIONS(description="24時間の初速がある動画数"),
+  views_per_hour_24h FLOAT64 NOT NULL OPTIONS(description="公開後12〜24時間の1時間あたり再生回数の平均"),
+  lift_24h FLOAT64 NOT NULL OPTIONS(description="views_per_hour_24h をチャンネルの全動画の平均で割った値"),
+  videos_72h INT64 NOT NULL OPTIONS(description="72時間の初速がある動画数"),
+  views_per_hour_72h FLOAT64 OPTIONS(description="公開後36〜72時間の1時間あたり再生回数の平均"),
+  computed_at TIMESTAMP NOT NULL OPTIONS(description="集計日時")
+)
+PARTITION BY dt
+CLUSTER BY channel_id;
+
+-- ----------------------------------------------------------------------------
+-- channel_snapshots テーブル: 実行ごとのチャンネルの統計 (analytics.subscriber_milestones 有効時)
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.channel_snapshots` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  snapshot_ts TIMESTAMP NOT NULL OPTIONS(description="取得日時"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  channel_name STRING OPTIONS(description="チャンネル名"),
+  subscribers INT64 OPTIONS(description="登録者数（非公開の場合は NULL）"),
+  videos INT64 OPTIONS(description="公開動画数"),
+  views INT64 OPTIONS(description="総再生回数")
+)
+PARTITION BY dt
+CLUSTER BY channel_id;
+
+-- ----------------------------------------------------------------------------
+-- channel_milestones テーブル: チャンネルが超えた登録者数の節目 (analytics.subscriber_milestones 有効時)
+-- 節目ごとに1回だけ記録される
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.channel_milestones` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  reached_at TIMESTAMP NOT NULL OPTIONS(description="節目を超えたスナップショットの取得日時"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  channel_name STRING OPTIONS(description="チャンネル名"),
+  milestone INT64 NOT NULL OPTIONS(description="節目の登録者数"),
+  subscribers INT64 NOT NULL OPTIONS(description="節目を超えたときの登録者数"),
+  previous_subscribers INT64 NOT NULL OPTIONS(description="前回のスナップショットの登録者数")
+)
+PARTITION BY dt
+CLUSTER BY channel_id;
+
+-- ----------------------------------------------------------------------------
+-- video_comment_sentiment テーブル: 動画ごとの上位コメントの感情 (analytics.comment_sentiment 有効時)
+-- 同じ日の再実行では当日分が置き換えられる
+-- ----------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS `${PROJECT_ID}.youtube.video_comment_sentiment` (
+  dt DATE NOT NULL OPTIONS(description="スナップショット日付"),
+  channel_id STRING NOT NULL OPTIONS(description="YouTubeチャンネルID"),
+  video_id STRING NOT NULL OPTIONS(description="YouTube動画ID"),
+  comments INT64 NOT NULL OPTIONS(description="感情を判定できたコメント数"),
+  positive INT64 NOT NULL OPTIONS(description="肯定的なコメント数 (スコア 0.25 以上)"),
+  negative INT64 NOT NULL OPTIONS(description="否定的なコメント数 (スコア -0.25 以下)"),
+  positive_ratio FLOAT64 NOT NULL OPTIONS(description="肯定的なコメントの割合"),
+  negative_ratio FLOAT64 NOT NULL OPTIONS(description="否定的なコメントの割合"),
+  avg_score FLOAT64 NOT NULL OPTIONS(description="平均スコア (-1 〜 1)"),
+  computed_at TIMESTAMP NOT NULL OPTIONS(description="計算日時")
+)
+PARTITION BY dt
+CLUSTER BY channel_id, video_id;
 
 -- ----------------------------------------------------------------------------
 -- video_trends_analysis ビュー: 動画のトレンド分析用
@@ -90,6 +420,130 @@ FROM
 WHERE
   created_at >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 30 DAY);
 
+-- ----------------------------------------------------------------------------
+-- video_trends_daily ビュー: 1日1動画につき最後のスナップショットのみ
+-- 毎時実行などで1日に複数スナップショットがある場合も日次集計に使える
+-- (フェッチャー起動時に存在しなければ自動作成)
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.video_trends_daily` AS
+SELECT * EXCEPT(snapshot_rank)
+FROM (
+  SELECT
+    *,
+    ROW_NUMBER() OVER (
+      PARTITION BY dt, video_id
+      ORDER BY COALESCE(snapshot_ts, created_at) DESC
+    ) AS snapshot_rank
+  FROM `${PROJECT_ID}.youtube.video_trends`
+)
+WHERE snapshot_rank = 1;
+
+-- ----------------------------------------------------------------------------
+-- latest_snapshot ビュー: 直近90日に取得した各動画の最新スナップショット
+-- (トゥームストーン行を含む。フェッチャー実行時に自動作成・更新)
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.latest_snapshot` AS
+SELECT * EXCEPT(snapshot_rank)
+FROM (
+  SELECT
+    *,
+    ROW_NUMBER() OVER (
+      PARTITION BY video_id
+      ORDER BY dt DESC, COALESCE(snapshot_ts, created_at) DESC
+    ) AS snapshot_rank
+  FROM `${PROJECT_ID}.youtube.video_trends`
+  WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL 90 DAY)
+)
+WHERE snapshot_rank = 1;
+
+-- ----------------------------------------------------------------------------
+-- daily_deltas ビュー: 動画ごとの日次の増加数（直近90日）
+-- 各日の最後のスナップショットを前回取得日 (prev_dt) と比較。初回取得日の差分は NULL
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.daily_deltas` AS
+WITH daily AS (
+  SELECT dt, channel_id, channel_name, video_id, title, is_short, views, likes, comments
+  FROM `${PROJECT_ID}.youtube.video_trends`
+  WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL 90 DAY)
+    AND views IS NOT NULL
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+SELECT
+  *,
+  LAG(dt) OVER video AS prev_dt,
+  views - LAG(views) OVER video AS views_delta,
+  likes - LAG(likes) OVER video AS likes_delta,
+  comments - LAG(comments) OVER video AS comments_delta
+FROM daily
+WINDOW video AS (PARTITION BY video_id ORDER BY dt);
+
+-- ----------------------------------------------------------------------------
+-- channel_daily_rollup ビュー: チャンネル・日付ごとの動画数・ショート数・再生/高評価/コメントの合計
+-- dt で絞り込むと該当パーティションのみスキャンされる
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.channel_daily_rollup` AS
+SELECT
+  dt,
+  channel_id,
+  ANY_VALUE(channel_name) AS channel_name,
+  COUNT(*) AS videos,
+  COUNTIF(is_short) AS shorts,
+  SUM(views) AS views,
+  SUM(likes) AS likes,
+  SUM(comments) AS comments
+FROM (
+  SELECT dt, channel_id, channel_name, video_id, is_short, views, likes, comments
+  FROM `${PROJECT_ID}.youtube.video_trends`
+  WHERE views IS NOT NULL
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+GROUP BY dt, channel_id;
+
+-- ----------------------------------------------------------------------------
+-- title_keyword_daily ビュー: タイトルキーワード・日付ごとの動画数・チャンネル数・再生回数の合計
+-- dt で絞り込むと該当パーティションのみスキャンされる
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.title_keyword_daily` AS
+SELECT
+  dt,
+  keyword,
+  COUNT(*) AS videos,
+  COUNT(DISTINCT channel_id) AS channels,
+  SUM(views) AS views
+FROM (
+  SELECT dt, channel_id, video_id, title_keywords, views
+  FROM `${PROJECT_ID}.youtube.video_trends`
+  WHERE views IS NOT NULL
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+), UNNEST(title_keywords) AS keyword
+GROUP BY dt, keyword;
+
+-- ----------------------------------------------------------------------------
+-- publish_week_cohorts ビュー: 公開週（月曜始まり、UTC）・公開からの経過日数ごとの再生回数
+-- 直近 26 週に公開された動画の、経過日数ごとの最新スナップショットを集計する
+-- ----------------------------------------------------------------------------
+CREATE OR REPLACE VIEW `${PROJECT_ID}.youtube.publish_week_cohorts` AS
+SELECT
+  DATE_TRUNC(DATE(published_at), WEEK(MONDAY)) AS publish_week,
+  channel_id,
+  age_days,
+  COUNT(*) AS videos,
+  SUM(views) AS views,
+  AVG(views) AS avg_views
+FROM (
+  SELECT channel_id, video_id, published_at, views, age_days
+  FROM (
+    SELECT *, DIV(TIMESTAMP_DIFF(COALESCE(snapshot_ts, created_at), published_at, HOUR), 24) AS age_days
+    FROM `${PROJECT_ID}.youtube.video_trends`
+    WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL 182 DAY)
+      AND views IS NOT NULL
+      AND published_at >= TIMESTAMP(DATE_SUB(CURRENT_DATE(), INTERVAL 182 DAY))
+  )
+  WHERE age_days >= 0
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id, age_days ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+GROUP BY publish_week, channel_id, age_days;
+
 -- ----------------------------------------------------------------------------
 -- daily_summary ビュー: 日次サマリー
 -- ----------------------------------------------------------------------------
@@ -129,4 +583,50 @@ GROUP BY
 -- ----------------------------------------------------------------------------
 -- 2024-01-XX: 初版作成
 -- 2024-01-XX: is_shortカラムを追加
--- 2024-01-XX: パーティショニングとクラスタリングを追加
\ No newline at end of file
+-- 2024-01-XX: パーティショニングとクラスタリングを追加
+-- 2025-08-XX: statusカラムを追加（削除・非公開動画のトゥームストーン記録用）
+--   既存のテーブルには実行時に自動で追加されます。手動で追加する場合:
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN status STRING;
+-- 2025-08-XX: description, thumbnail_urlカラムを追加（メタデータ変更履歴用）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN description STRING, ADD COLUMN thumbnail_url STRING;
+-- 2025-08-XX: snapshot_tsカラムを追加（dtは設定タイムゾーン基準の日付に変更、既定はAsia/Tokyo）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN snapshot_ts TIMESTAMP;
+-- 2025-08-XX: shorts_confidenceカラムを追加（is_shortは60秒判定から複数シグナルの判定に変更）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN shorts_confidence FLOAT64;
+-- 2026-10-XX: fetch_runsにfailed_channelsカラムを追加（メールダイジェストのチャンネル別失敗件数用）
+--   ALTER TABLE `${PROJECT_ID}.youtube.fetch_runs` ADD COLUMN failed_channels ARRAY<STRING>;
+-- 2026-10-XX: like_rate, comment_rate, views_per_hourカラムを追加（書き込み時に計算する派生値）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN like_rate FLOAT64, ADD COLUMN comment_rate FLOAT64, ADD COLUMN views_per_hour FLOAT64;
+-- 2026-10-XX: category_id, default_languageカラムを追加（カテゴリ別の集計用）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN category_id STRING, ADD COLUMN default_language STRING;
+-- 2026-10-XX: video_trendsにschema_versionラベルを追加（スキーマバージョン7）
+--   以降、video_trendsの不足カラムは取得の実行時または `fetcher --migrate` で自動追加される
+-- 2026-10-XX: playlist_idカラムを追加（プレイリストの監視、スキーマバージョン8）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN playlist_id STRING;
+-- 2026-10-XX: category_nameカラムを追加（動画カテゴリ名、スキーマバージョン9）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN category_name STRING;
+-- 2026-10-XX: region_allowed, region_blocked, content_ratingカラムを追加（地域制限とレーティング、スキーマバージョン10）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN region_allowed ARRAY<STRING>, ADD COLUMN region_blocked ARRAY<STRING>,
+--     ADD COLUMN content_rating ARRAY<STRUCT<system STRING, rating STRING>>;
+-- 2026-10-XX: privacy_status, made_for_kids, license, embeddable, paid_promotionカラムを追加（スキーマバージョン11）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN privacy_status STRING, ADD COLUMN made_for_kids BOOL, ADD COLUMN license STRING,
+--     ADD COLUMN embeddable BOOL, ADD COLUMN paid_promotion BOOL;
+-- 2026-10-XX: chaptersカラムを追加（説明文のチャプター、スキーマバージョン12）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends` ADD COLUMN chapters ARRAY<STRUCT<start_sec INT64, title STRING>>;
+-- 2026-10-XX: title_language, title_keywordsカラムとtitle_keyword_dailyビューを追加（スキーマバージョン13）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trends`
+--     ADD COLUMN title_language STRING, ADD COLUMN title_keywords ARRAY<STRING>;
+-- 2026-10-XX: channel_daily_statsに投稿ペースのカラムを追加（実行時に自動追加される）
+--   ALTER TABLE `${PROJECT_ID}.youtube.channel_daily_stats`
+--     ADD COLUMN uploads_per_week FLOAT64, ADD COLUMN avg_upload_gap_days FLOAT64,
+--     ADD COLUMN upload_streak_weeks INT64, ADD COLUMN last_upload_at TIMESTAMP;
+-- 2026-10-XX: video_trend_scoresに予測再生回数のカラムを追加（実行時に自動追加される）
+--   ALTER TABLE `${PROJECT_ID}.youtube.video_trend_scores`
+--     ADD COLUMN forecast_views_7d INT64, ADD COLUMN forecast_views_30d INT64;
+-- 2026-10-XX: video_comment_sentimentテーブルを追加（コメントの感情分析）
+-- 2026-10-XX: video_thumbnailsテーブルを追加（サムネイルのアーカイブ）
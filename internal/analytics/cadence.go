@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// cadenceRateWeeks is the number of recent weeks UploadsPerWeek averages
+// over; the gap and the streak use all of storage.UploadCadenceDays.
+const cadenceRateWeeks = 4
+
+// Cadence describes how regularly a channel uploads up to a date. Weeks are
+// the seven days ending on the date, the seven before them, and so on, so
+// they do not depend on which day a week starts.
+type Cadence struct {
+	// Uploads counts the uploads in the storage.UploadCadenceDays up to
+	// the date.
+	Uploads int
+	// UploadsPerWeek averages the uploads of the last cadenceRateWeeks.
+	UploadsPerWeek float64
+	// AvgGapDays is the mean time between consecutive uploads in days, 0
+	// with fewer than two uploads.
+	AvgGapDays float64
+	// StreakWeeks counts the consecutive weeks up to the date with at
+	// least one upload: 0 when the channel has not uploaded in the last
+	// seven days.
+	StreakWeeks int
+	// LastUpload is the latest upload, zero without any.
+	LastUpload time.Time
+}
+
+// UploadCadence computes the cadence of the uploads published, in any
+// order, as of date in loc. Uploads outside the storage.UploadCadenceDays up
+// to date are ignored.
+func UploadCadence(published []time.Time, date civil.Date, loc *time.Location) Cadence {
+	var c Cadence
+	var times []time.Time
+	weeks := make(map[int]bool)
+	for _, p := range published {
+		days := date.DaysSince(civil.DateOf(p.In(loc)))
+		if days < 0 || days >= storage.UploadCadenceDays {
+			continue
+		}
+		times = append(times, p)
+		weeks[days/7] = true
+		if days < cadenceRateWeeks*7 {
+			c.UploadsPerWeek++
+		}
+	}
+	c.Uploads = len(times)
+	c.UploadsPerWeek /= cadenceRateWeeks
+	for weeks[c.StreakWeeks] {
+		c.StreakWeeks++
+	}
+	if len(times) == 0 {
+		return c
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	c.LastUpload = times[len(times)-1]
+	if len(times) > 1 {
+		c.AvgGapDays = c.LastUpload.Sub(times[0]).Hours() / 24 / float64(len(times)-1)
+	}
+	return c
+}
+
+// UploadCadences groups uploads by channel and computes the cadence of each
+// channel as of date in loc.
+func UploadCadences(uploads []storage.UploadTime, date civil.Date, loc *time.Location) map[string]Cadence {
+	published := make(map[string][]time.Time)
+	for _, u := range uploads {
+		published[u.ChannelID] = append(published[u.ChannelID], u.PublishedAt)
+	}
+	cadences := make(map[string]Cadence, len(published))
+	for id, times := range published {
+		cadences[id] = UploadCadence(times, date, loc)
+	}
+	return cadences
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestUploadCadence(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	date := civil.Date{Year: 2025, Month: 8, Day: 1}
+	day := func(daysAgo int) time.Time {
+		return time.Date(2025, 8, 1, 12, 0, 0, 0, tokyo).AddDate(0, 0, -daysAgo)
+	}
+
+	// Every three days for four weeks, then a month off, then two older
+	// uploads that still count towards the gap.
+	var published []time.Time
+	for d := 0; d < 28; d += 3 {
+		published = append(published, day(d))
+	}
+	published = append(published, day(70), day(80),
+		// Outside the window on either side.
+		day(storage.UploadCadenceDays), day(-1))
+	c := UploadCadence(published, date, tokyo)
+	if c.Uploads != 12 || c.UploadsPerWeek != 10.0/4 || c.StreakWeeks != 4 || !c.LastUpload.Equal(day(0)) {
+		t.Errorf("cadence = %+v", c)
+	}
+	if want := 80.0 / 11; c.AvgGapDays != want {
+		t.Errorf("average gap = %v days, want %v", c.AvgGapDays, want)
+	}
+
+	// Published 2025-07-26 00:30 in Tokyo, six days before, but seven days
+	// before in UTC: the week's streak only holds in Tokyo.
+	late := time.Date(2025, 7, 25, 15, 30, 0, 0, time.UTC)
+	if c := UploadCadence([]time.Time{late}, date, tokyo); c.StreakWeeks != 1 || c.AvgGapDays != 0 {
+		t.Errorf("cadence in Tokyo = %+v, want a week's streak", c)
+	}
+	if c := UploadCadence([]time.Time{late}, date, time.UTC); c.StreakWeeks != 0 {
+		t.Errorf("cadence in UTC = %+v, want no streak", c)
+	}
+
+	if c := UploadCadence(nil, date, tokyo); c != (Cadence{}) {
+		t.Errorf("cadence without uploads = %+v", c)
+	}
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// ChannelStatsStore reads the day's snapshots and recent uploads and stores
+// the per-channel aggregates.
+type ChannelStatsStore interface {
+	ChannelStatsInputs(ctx context.Context, date civil.Date) ([]storage.ChannelStatsInput, error)
+	UploadTimes(ctx context.Context, date civil.Date) ([]storage.UploadTime, error)
+	ReplaceChannelDailyStats(ctx context.Context, date civil.Date, records []*storage.ChannelDailyStatsRecord) error
+}
+
+// ChannelDailyStats aggregates the latest snapshot of every video on date
+// per channel and replaces the date's channel_daily_stats. A video counts
+// as an upload when it was published on date in loc, the timezone dt is in.
+// Each channel's upload cadence up to date is added from the recent uploads.
+// It returns how many channels were written.
+func ChannelDailyStats(ctx context.Context, store ChannelStatsStore, date civil.Date, loc *time.Location) (int, error) {
+	inputs, err := store.ChannelStatsInputs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	uploads, err := store.UploadTimes(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	cadences := UploadCadences(uploads, date, loc)
+
+	computedAt := time.Now()
+	byChannel := make(map[string]*storage.ChannelDailyStatsRecord)
+	for _, in := range inputs {
+		r, ok := byChannel[in.ChannelID]
+		if !ok {
+			r = &storage.ChannelDailyStatsRecord{Dt: date, ChannelID: in.ChannelID, ComputedAt: computedAt}
+			byChannel[in.ChannelID] = r
+		}
+		if r.ChannelName == "" {
+			r.ChannelName = in.ChannelName
+		}
+		r.Videos++
+		if in.IsShort {
+			r.Shorts++
+		}
+		if in.PublishedAt.Valid && civil.DateOf(in.PublishedAt.Timestamp.In(loc)) == date {
+			r.Uploads++
+		}
+		r.TotalViews += in.Views
+		r.TotalLikes += in.Likes
+		r.TotalComments += in.Comments
+	}
+
+	records := make([]*storage.ChannelDailyStatsRecord, 0, len(byChannel))
+	for _, r := range byChannel {
+		r.ShortsShare = float64(r.Shorts) / float64(r.Videos)
+		c := cadences[r.ChannelID]
+		r.UploadsPerWeek = c.UploadsPerWeek
+		r.UploadStreakWeeks = int64(c.StreakWeeks)
+		if c.Uploads > 1 {
+			r.AvgUploadGapDays = bigquery.NullFloat64{Float64: c.AvgGapDays, Valid: true}
+		}
+		if !c.LastUpload.IsZero() {
+			r.LastUploadAt = bigquery.NullTimestamp{Timestamp: c.LastUpload, Valid: true}
+		}
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ChannelID < records[j].ChannelID })
+
+	if err := store.ReplaceChannelDailyStats(ctx, date, records); err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored daily stats of %d channels from %d videos", len(records), len(inputs)), map[string]string{
+		"dt": date.String(),
+	})
+	return len(records), nil
+}
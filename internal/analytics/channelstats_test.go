@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeChannelStatsStore struct {
+	inputs  []storage.ChannelStatsInput
+	uploads []storage.UploadTime
+	date    civil.Date
+	records []*storage.ChannelDailyStatsRecord
+}
+
+func (f *fakeChannelStatsStore) ChannelStatsInputs(ctx context.Context, date civil.Date) ([]storage.ChannelStatsInput, error) {
+	return f.inputs, nil
+}
+
+func (f *fakeChannelStatsStore) UploadTimes(ctx context.Context, date civil.Date) ([]storage.UploadTime, error) {
+	return f.uploads, nil
+}
+
+func (f *fakeChannelStatsStore) ReplaceChannelDailyStats(ctx context.Context, date civil.Date, records []*storage.ChannelDailyStatsRecord) error {
+	f.date, f.records = date, records
+	return nil
+}
+
+func TestChannelDailyStats(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	published := func(ts time.Time) bigquery.NullTimestamp {
+		return bigquery.NullTimestamp{Timestamp: ts, Valid: true}
+	}
+	store := &fakeChannelStatsStore{inputs: []storage.ChannelStatsInput{
+		// 2025-08-01 00:30 in Tokyo, still July 31 in UTC: an upload.
+		{ChannelID: "UC2", ChannelName: "Two", VideoID: "v1", IsShort: true, Views: 100, Likes: 10, Comments: 1,
+			PublishedAt: published(time.Date(2025, 7, 31, 15, 30, 0, 0, time.UTC))},
+		{ChannelID: "UC2", ChannelName: "Two", VideoID: "v2", Views: 1000, Likes: 50, Comments: 5,
+			PublishedAt: published(time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC))},
+		{ChannelID: "UC2", VideoID: "v3", IsShort: true, Views: 10},
+		{ChannelID: "UC1", ChannelName: "One", VideoID: "v4", Views: 5},
+	}, uploads: []storage.UploadTime{
+		{ChannelID: "UC2", VideoID: "v1", PublishedAt: time.Date(2025, 7, 31, 15, 30, 0, 0, time.UTC)},
+		{ChannelID: "UC2", VideoID: "v2", PublishedAt: time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC)},
+	}}
+	date := civil.Date{Year: 2025, Month: 8, Day: 1}
+
+	n, err := ChannelDailyStats(context.Background(), store, date, tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(store.records) != 2 || store.date != date {
+		t.Fatalf("wrote %d records for %s, want 2 for %s", len(store.records), store.date, date)
+	}
+	one, two := store.records[0], store.records[1]
+	if one.ChannelID != "UC1" || one.Videos != 1 || one.Uploads != 0 || one.ShortsShare != 0 || one.TotalViews != 5 {
+		t.Errorf("UC1 = %+v", one)
+	}
+	if two.ChannelName != "Two" || two.Videos != 3 || two.Uploads != 1 || two.Shorts != 2 ||
+		two.TotalViews != 1110 || two.TotalLikes != 60 || two.TotalComments != 6 || two.Dt != date {
+		t.Errorf("UC2 = %+v", two)
+	}
+	if one.UploadsPerWeek != 0 || one.AvgUploadGapDays.Valid || one.LastUploadAt.Valid {
+		t.Errorf("UC1 cadence = %+v, want no uploads", one)
+	}
+	if two.UploadsPerWeek != 0.5 || two.UploadStreakWeeks != 2 || !two.AvgUploadGapDays.Valid ||
+		!two.LastUploadAt.Timestamp.Equal(time.Date(2025, 7, 31, 15, 30, 0, 0, time.UTC)) {
+		t.Errorf("UC2 cadence = %+v", two)
+	}
+	if want := 2.0 / 3; two.ShortsShare != want {
+		t.Errorf("UC2 shorts share = %v, want %v", two.ShortsShare, want)
+	}
+}
@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Forecast horizons stored with the trend scores and offered to the
+// conditions of notification rules.
+const (
+	ForecastHorizon7d  = 7 * 24 * time.Hour
+	ForecastHorizon30d = 30 * 24 * time.Hour
+)
+
+// ViewPoint is a video's views at a time.
+type ViewPoint struct {
+	At    time.Time
+	Views int64
+}
+
+// ForecastViews projects a video's views h after the last of points, which
+// must be in time order. It fits views = a + b·ln(1 + age in days), the
+// usual shape of a video's growth, by least squares to the points, or to a
+// single point and no views at publication, and extends the latest views
+// along that curve. Without a publication time, the views grow linearly at
+// the rate between the first and the last point. Views never drop, so the
+// projection is at least the latest views.
+func ForecastViews(publishedAt time.Time, points []ViewPoint, h time.Duration) int64 {
+	if len(points) == 0 {
+		return 0
+	}
+	last := points[len(points)-1]
+	if publishedAt.IsZero() || publishedAt.Unix() <= 0 {
+		first := points[0]
+		elapsed := last.At.Sub(first.At)
+		if elapsed <= 0 || last.Views <= first.Views {
+			return last.Views
+		}
+		rate := float64(last.Views-first.Views) / elapsed.Hours()
+		return last.Views + int64(math.Round(rate*h.Hours()))
+	}
+
+	x := func(at time.Time) float64 {
+		return math.Log1p(math.Max(at.Sub(publishedAt).Hours(), 0) / 24)
+	}
+	var xs, ys []float64
+	if len(points) < 2 {
+		xs, ys = []float64{0}, []float64{0}
+	}
+	for _, p := range points {
+		xs = append(xs, x(p.At))
+		ys = append(ys, float64(p.Views))
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+	var cov, variance float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 || cov <= 0 {
+		return last.Views
+	}
+	slope := cov / variance
+	return last.Views + int64(math.Round(slope*(x(last.At.Add(h))-x(last.At))))
+}
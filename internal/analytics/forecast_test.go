@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecastViews(t *testing.T) {
+	published := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d float64) time.Time { return published.Add(time.Duration(d * 24 * float64(time.Hour))) }
+	// Exactly 1000·ln(1 + days): the fit recovers the curve.
+	logViews := func(d float64) int64 { return int64(math.Round(1000 * math.Log1p(d))) }
+	points := []ViewPoint{{day(1), logViews(1)}, {day(2), logViews(2)}, {day(3), logViews(3)}}
+
+	if got, want := ForecastViews(published, points, ForecastHorizon7d), logViews(10); math.Abs(float64(got-want)) > 1 {
+		t.Errorf("7-day forecast = %d, want about %d", got, want)
+	}
+	if got, want := ForecastViews(published, points, ForecastHorizon30d), logViews(33); math.Abs(float64(got-want)) > 1 {
+		t.Errorf("30-day forecast = %d, want about %d", got, want)
+	}
+
+	// A single point is fitted together with no views at publication.
+	if got, want := ForecastViews(published, points[2:], ForecastHorizon7d), logViews(10); math.Abs(float64(got-want)) > 1 {
+		t.Errorf("forecast from one point = %d, want about %d", got, want)
+	}
+
+	// A drop (YouTube removed invalid views) does not project a decline.
+	dropped := []ViewPoint{{day(1), 5000}, {day(2), 4000}}
+	if got := ForecastViews(published, dropped, ForecastHorizon7d); got != 4000 {
+		t.Errorf("forecast after a drop = %d, want 4000", got)
+	}
+	if got := ForecastViews(published, []ViewPoint{{day(1), 0}, {day(2), 0}}, ForecastHorizon7d); got != 0 {
+		t.Errorf("forecast without views = %d, want 0", got)
+	}
+
+	// Without a publication time the growth is linear: 100 views a day.
+	linear := []ViewPoint{{day(1), 1000}, {day(3), 1200}}
+	if got := ForecastViews(time.Time{}, linear, ForecastHorizon7d); got != 1900 {
+		t.Errorf("linear forecast = %d, want 1900", got)
+	}
+	if got := ForecastViews(time.Unix(0, 0), linear[1:], ForecastHorizon7d); got != 1200 {
+		t.Errorf("forecast from one point = %d, want the latest views", got)
+	}
+	if got := ForecastViews(published, nil, ForecastHorizon7d); got != 0 {
+		t.Errorf("forecast without points = %d", got)
+	}
+}
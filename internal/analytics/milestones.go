@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// MilestoneStore keeps the channel snapshots and the milestones reached.
+type MilestoneStore interface {
+	LatestSubscribers(ctx context.Context, date civil.Date, channelIDs []string) ([]storage.ChannelSubscribers, error)
+	ChannelMilestones(ctx context.Context, channelIDs []string) ([]storage.ChannelMilestoneRecord, error)
+	InsertChannelSnapshots(ctx context.Context, records []*storage.ChannelSnapshotRecord) error
+	InsertChannelMilestones(ctx context.Context, records []*storage.ChannelMilestoneRecord) error
+}
+
+// SubscriberMilestones stores snapshots and records the thresholds each
+// channel's subscriber count reached since its previous snapshot. A
+// channel's first snapshot is its baseline and reaches nothing, and a
+// milestone is reached once even if the count drops below it and recovers.
+// It returns the milestones reached, by channel then threshold.
+func SubscriberMilestones(ctx context.Context, store MilestoneStore, snapshots []*storage.ChannelSnapshotRecord, thresholds []int64) ([]*storage.ChannelMilestoneRecord, error) {
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	channelIDs := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		channelIDs[i] = s.ChannelID
+	}
+	previous, err := store.LatestSubscribers(ctx, snapshots[0].Dt, channelIDs)
+	if err != nil {
+		return nil, err
+	}
+	history, err := store.ChannelMilestones(ctx, channelIDs)
+	if err != nil {
+		return nil, err
+	}
+	prevByChannel := make(map[string]int64, len(previous))
+	for _, p := range previous {
+		prevByChannel[p.ChannelID] = p.Subscribers
+	}
+	reached := make(map[string]map[int64]bool)
+	for _, m := range history {
+		if reached[m.ChannelID] == nil {
+			reached[m.ChannelID] = make(map[int64]bool)
+		}
+		reached[m.ChannelID][m.Milestone] = true
+	}
+
+	sorted := append([]int64(nil), thresholds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var records []*storage.ChannelMilestoneRecord
+	for _, s := range snapshots {
+		prev, ok := prevByChannel[s.ChannelID]
+		if !ok || !s.Subscribers.Valid {
+			continue
+		}
+		for _, t := range sorted {
+			if prev < t && s.Subscribers.Int64 >= t && !reached[s.ChannelID][t] {
+				records = append(records, &storage.ChannelMilestoneRecord{
+					Dt:                  s.Dt,
+					ReachedAt:           s.SnapshotTs,
+					ChannelID:           s.ChannelID,
+					ChannelName:         s.ChannelName,
+					Milestone:           t,
+					Subscribers:         s.Subscribers.Int64,
+					PreviousSubscribers: prev,
+				})
+			}
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].ChannelID < records[j].ChannelID })
+
+	if err := store.InsertChannelSnapshots(ctx, snapshots); err != nil {
+		return nil, err
+	}
+	if err := store.InsertChannelMilestones(ctx, records); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored subscriber counts of %d channels, %d reached a milestone", len(snapshots), len(records)), map[string]string{
+		"dt": snapshots[0].Dt.String(),
+	})
+	return records, nil
+}
@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeMilestoneStore struct {
+	previous   []storage.ChannelSubscribers
+	history    []storage.ChannelMilestoneRecord
+	snapshots  []*storage.ChannelSnapshotRecord
+	milestones []*storage.ChannelMilestoneRecord
+}
+
+func (f *fakeMilestoneStore) LatestSubscribers(ctx context.Context, date civil.Date, channelIDs []string) ([]storage.ChannelSubscribers, error) {
+	return f.previous, nil
+}
+
+func (f *fakeMilestoneStore) ChannelMilestones(ctx context.Context, channelIDs []string) ([]storage.ChannelMilestoneRecord, error) {
+	return f.history, nil
+}
+
+func (f *fakeMilestoneStore) InsertChannelSnapshots(ctx context.Context, records []*storage.ChannelSnapshotRecord) error {
+	f.snapshots = records
+	return nil
+}
+
+func (f *fakeMilestoneStore) InsertChannelMilestones(ctx context.Context, records []*storage.ChannelMilestoneRecord) error {
+	f.milestones = records
+	return nil
+}
+
+func TestSubscriberMilestones(t *testing.T) {
+	date := civil.Date{Year: 2026, Month: 10, Day: 17}
+	now := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	snapshot := func(channelID string, subscribers int64) *storage.ChannelSnapshotRecord {
+		return &storage.ChannelSnapshotRecord{Dt: date, SnapshotTs: now, ChannelID: channelID, ChannelName: channelID + " name",
+			Subscribers: bigquery.NullInt64{Int64: subscribers, Valid: true}}
+	}
+	store := &fakeMilestoneStore{
+		previous: []storage.ChannelSubscribers{
+			{ChannelID: "UC1", Subscribers: 99000},
+			{ChannelID: "UC2", Subscribers: 90000},
+			{ChannelID: "UC3", Subscribers: 99500},
+			{ChannelID: "UC4", Subscribers: 500000},
+		},
+		// UC3 reached 100k before and dropped below it.
+		history: []storage.ChannelMilestoneRecord{{ChannelID: "UC3", Milestone: 100000}},
+	}
+	snapshots := []*storage.ChannelSnapshotRecord{
+		// Crossed two thresholds since the previous snapshot.
+		snapshot("UC2", 1010000),
+		snapshot("UC1", 100000),
+		snapshot("UC3", 101000),
+		// Hidden count.
+		{Dt: date, SnapshotTs: now, ChannelID: "UC4"},
+		// First snapshot: the baseline.
+		snapshot("UC5", 2000000),
+	}
+
+	got, err := SubscriberMilestones(context.Background(), store, snapshots, []int64{1000000, 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || len(store.milestones) != 3 || len(store.snapshots) != 5 {
+		t.Fatalf("milestones = %+v, stored %d snapshots", got, len(store.snapshots))
+	}
+	if m := got[0]; m.ChannelID != "UC1" || m.Milestone != 100000 || m.Subscribers != 100000 || m.PreviousSubscribers != 99000 {
+		t.Errorf("first milestone = %+v", m)
+	}
+	if m := got[1]; m.ChannelID != "UC2" || m.Milestone != 100000 {
+		t.Errorf("second milestone = %+v", m)
+	}
+	if m := got[2]; m.ChannelID != "UC2" || m.Milestone != 1000000 || m.ChannelName != "UC2 name" || !m.ReachedAt.Equal(now) || m.Dt != date {
+		t.Errorf("third milestone = %+v", m)
+	}
+}
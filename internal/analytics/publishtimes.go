@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// PublishTimeStore reads the early view velocity of recent videos and
+// stores it by publish slot.
+type PublishTimeStore interface {
+	PublishTimeInputs(ctx context.Context, date civil.Date) ([]storage.PublishTimeInput, error)
+	ReplacePublishTimeStats(ctx context.Context, date civil.Date, records []*storage.PublishTimeStatsRecord) error
+}
+
+// publishSlot is a channel's weekday and hour of publication.
+type publishSlot struct {
+	channelID     string
+	weekday, hour int
+}
+
+// PublishTimeStats averages the early view velocity of the videos each
+// channel published recently by weekday and hour of publication in loc, the
+// timezone dt is in, and replaces the date's publish_time_stats. It returns
+// how many slots were written.
+func PublishTimeStats(ctx context.Context, store PublishTimeStore, date civil.Date, loc *time.Location) (int, error) {
+	inputs, err := store.PublishTimeInputs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	type sums struct {
+		sum24, sum72 float64
+		n24, n72     int64
+	}
+	computedAt := time.Now()
+	channels := make(map[string]*sums)
+	slots := make(map[publishSlot]*sums)
+	records := make(map[publishSlot]*storage.PublishTimeStatsRecord)
+	for _, in := range inputs {
+		published := in.PublishedAt.In(loc)
+		key := publishSlot{channelID: in.ChannelID, weekday: int(published.Weekday()), hour: published.Hour()}
+		r, ok := records[key]
+		if !ok {
+			r = &storage.PublishTimeStatsRecord{
+				Dt:         date,
+				ChannelID:  in.ChannelID,
+				Weekday:    int64(key.weekday),
+				Hour:       int64(key.hour),
+				ComputedAt: computedAt,
+			}
+			records[key] = r
+			slots[key] = &sums{}
+		}
+		if r.ChannelName == "" {
+			r.ChannelName = in.ChannelName
+		}
+		if channels[in.ChannelID] == nil {
+			channels[in.ChannelID] = &sums{}
+		}
+		s, c := slots[key], channels[in.ChannelID]
+		if in.Velocity24h.Valid {
+			s.sum24 += in.Velocity24h.Float64
+			s.n24++
+			c.sum24 += in.Velocity24h.Float64
+			c.n24++
+		}
+		if in.Velocity72h.Valid {
+			s.sum72 += in.Velocity72h.Float64
+			s.n72++
+		}
+	}
+
+	out := make([]*storage.PublishTimeStatsRecord, 0, len(records))
+	for key, r := range records {
+		s, c := slots[key], channels[key.channelID]
+		r.Videos, r.Videos72h = s.n24, s.n72
+		if s.n24 > 0 {
+			r.ViewsPerHour24h = s.sum24 / float64(s.n24)
+			if c.sum24 > 0 {
+				r.Lift24h = r.ViewsPerHour24h / (c.sum24 / float64(c.n24))
+			}
+		}
+		if s.n72 > 0 {
+			r.ViewsPerHour72h = bigquery.NullFloat64{Float64: s.sum72 / float64(s.n72), Valid: true}
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.ChannelID != b.ChannelID {
+			return a.ChannelID < b.ChannelID
+		}
+		if a.Weekday != b.Weekday {
+			return a.Weekday < b.Weekday
+		}
+		return a.Hour < b.Hour
+	})
+
+	if err := store.ReplacePublishTimeStats(ctx, date, out); err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored publish time stats of %d channels from %d videos", len(channels), len(inputs)), map[string]string{
+		"dt": date.String(),
+	})
+	return len(out), nil
+}
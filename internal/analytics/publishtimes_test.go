@@ -0,0 +1,61 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakePublishTimeStore struct {
+	inputs  []storage.PublishTimeInput
+	records []*storage.PublishTimeStatsRecord
+}
+
+func (f *fakePublishTimeStore) PublishTimeInputs(ctx context.Context, date civil.Date) ([]storage.PublishTimeInput, error) {
+	return f.inputs, nil
+}
+
+func (f *fakePublishTimeStore) ReplacePublishTimeStats(ctx context.Context, date civil.Date, records []*storage.PublishTimeStatsRecord) error {
+	f.records = records
+	return nil
+}
+
+func TestPublishTimeStats(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	velocity := func(v float64) bigquery.NullFloat64 { return bigquery.NullFloat64{Float64: v, Valid: true} }
+	// Friday 2026-10-09 18:xx in Tokyo, 09:xx in UTC.
+	friday := time.Date(2026, 10, 9, 9, 5, 0, 0, time.UTC)
+	store := &fakePublishTimeStore{inputs: []storage.PublishTimeInput{
+		{ChannelID: "UC1", ChannelName: "One", VideoID: "v1", PublishedAt: friday, Velocity24h: velocity(300), Velocity72h: velocity(200)},
+		{ChannelID: "UC1", VideoID: "v2", PublishedAt: friday.Add(7 * 24 * time.Hour), Velocity24h: velocity(500)},
+		// Monday 2026-10-12 09:30 in Tokyo.
+		{ChannelID: "UC1", VideoID: "v3", PublishedAt: time.Date(2026, 10, 12, 0, 30, 0, 0, time.UTC), Velocity24h: velocity(100)},
+		// Only a 72-hour velocity.
+		{ChannelID: "UC2", VideoID: "v4", PublishedAt: friday, Velocity72h: velocity(50)},
+	}}
+	date := civil.Date{Year: 2026, Month: 10, Day: 17}
+
+	n, err := PublishTimeStats(context.Background(), store, date, tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(store.records) != 3 {
+		t.Fatalf("wrote %d slots, want 3: %+v", len(store.records), store.records)
+	}
+	monday, fri, other := store.records[0], store.records[1], store.records[2]
+	if monday.ChannelID != "UC1" || monday.Weekday != 1 || monday.Hour != 9 || monday.Videos != 1 || monday.Lift24h != 100.0/300 {
+		t.Errorf("Monday slot = %+v", monday)
+	}
+	// The channel averages 300 views/hour over its three videos.
+	if fri.Weekday != 5 || fri.Hour != 18 || fri.Videos != 2 || fri.ViewsPerHour24h != 400 || fri.Lift24h != 400.0/300 ||
+		fri.Videos72h != 1 || fri.ViewsPerHour72h.Float64 != 200 || fri.ChannelName != "One" || fri.Dt != date {
+		t.Errorf("Friday slot = %+v", fri)
+	}
+	if other.ChannelID != "UC2" || other.Videos != 0 || other.Lift24h != 0 || other.Videos72h != 1 {
+		t.Errorf("UC2 slot = %+v", other)
+	}
+}
@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/sentiment"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// SentimentThreshold is the score from which a comment counts as positive,
+// or, negated, negative. Comments in between are neutral.
+const SentimentThreshold = 0.25
+
+// sentimentWorkers bounds the comments scored concurrently.
+const sentimentWorkers = 8
+
+// CommentSentimentStore reads captured comments and stores their sentiment.
+type CommentSentimentStore interface {
+	CommentTexts(ctx context.Context, date civil.Date) ([]storage.CommentText, error)
+	ReplaceCommentSentiment(ctx context.Context, date civil.Date, records []*storage.CommentSentimentRecord) error
+}
+
+// CommentSentiment scores the top comments captured at each video's latest
+// snapshot of date with analyzer and replaces the date's
+// video_comment_sentiment with the positive and negative ratios per video.
+// Comments the analyzer does not support are left out; any other error
+// stops the run. It returns how many videos were written.
+func CommentSentiment(ctx context.Context, store CommentSentimentStore, analyzer sentiment.Analyzer, date civil.Date) (int, error) {
+	comments, err := store.CommentTexts(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	scores := make([]float64, len(comments))
+	scored := make([]bool, len(comments))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, sentimentWorkers)
+	for i, c := range comments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			score, err := analyzer.Sentiment(ctx, c.Text)
+			switch {
+			case errors.Is(err, sentiment.ErrUnsupported):
+			case err != nil:
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to score comment %s of video %s: %w", c.CommentID, c.VideoID, err)
+					cancel()
+				})
+			default:
+				scores[i], scored[i] = score, true
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return 0, firstErr
+	}
+
+	type sums struct {
+		r     *storage.CommentSentimentRecord
+		total float64
+	}
+	computedAt := time.Now()
+	var order []string
+	videos := make(map[string]*sums)
+	for i, c := range comments {
+		if !scored[i] {
+			continue
+		}
+		s, ok := videos[c.VideoID]
+		if !ok {
+			s = &sums{r: &storage.CommentSentimentRecord{Dt: date, ChannelID: c.ChannelID, VideoID: c.VideoID, ComputedAt: computedAt}}
+			videos[c.VideoID] = s
+			order = append(order, c.VideoID)
+		}
+		s.r.Comments++
+		s.total += scores[i]
+		switch {
+		case scores[i] >= SentimentThreshold:
+			s.r.Positive++
+		case scores[i] <= -SentimentThreshold:
+			s.r.Negative++
+		}
+	}
+
+	records := make([]*storage.CommentSentimentRecord, 0, len(order))
+	for _, id := range order {
+		s := videos[id]
+		n := float64(s.r.Comments)
+		s.r.PositiveRatio = float64(s.r.Positive) / n
+		s.r.NegativeRatio = float64(s.r.Negative) / n
+		s.r.AvgScore = s.total / n
+		records = append(records, s.r)
+	}
+
+	if err := store.ReplaceCommentSentiment(ctx, date, records); err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored comment sentiment of %d videos from %d comments", len(records), len(comments)), map[string]string{
+		"dt": date.String(),
+	})
+	return len(records), nil
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/sentiment"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeCommentSentimentStore struct {
+	comments []storage.CommentText
+	records  []*storage.CommentSentimentRecord
+}
+
+func (f *fakeCommentSentimentStore) CommentTexts(ctx context.Context, date civil.Date) ([]storage.CommentText, error) {
+	return f.comments, nil
+}
+
+func (f *fakeCommentSentimentStore) ReplaceCommentSentiment(ctx context.Context, date civil.Date, records []*storage.CommentSentimentRecord) error {
+	f.records = records
+	return nil
+}
+
+// fakeAnalyzer scores texts from a table; other texts are unsupported.
+type fakeAnalyzer map[string]float64
+
+func (f fakeAnalyzer) Sentiment(ctx context.Context, text string) (float64, error) {
+	if text == "fail" {
+		return 0, errors.New("permission denied")
+	}
+	score, ok := f[text]
+	if !ok {
+		return 0, sentiment.ErrUnsupported
+	}
+	return score, nil
+}
+
+func TestCommentSentiment(t *testing.T) {
+	analyzer := fakeAnalyzer{"great": 0.8, "ok": 0.1, "bad": -0.6, "good": 0.25}
+	store := &fakeCommentSentimentStore{comments: []storage.CommentText{
+		{ChannelID: "UC1", VideoID: "v1", CommentID: "c1", Text: "great"},
+		{ChannelID: "UC1", VideoID: "v1", CommentID: "c2", Text: "ok"},
+		{ChannelID: "UC1", VideoID: "v1", CommentID: "c3", Text: "bad"},
+		{ChannelID: "UC1", VideoID: "v1", CommentID: "c4", Text: "good"},
+		{ChannelID: "UC1", VideoID: "v1", CommentID: "c5", Text: "???"},
+		// Nothing scored: the video is left out.
+		{ChannelID: "UC2", VideoID: "v2", CommentID: "c6", Text: "???"},
+	}}
+	date := civil.Date{Year: 2026, Month: 10, Day: 17}
+
+	n, err := CommentSentiment(context.Background(), store, analyzer, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(store.records) != 1 {
+		t.Fatalf("wrote %d videos, want 1: %+v", len(store.records), store.records)
+	}
+	r := store.records[0]
+	if r.VideoID != "v1" || r.Dt != date || r.Comments != 4 || r.Positive != 2 || r.Negative != 1 ||
+		r.PositiveRatio != 0.5 || r.NegativeRatio != 0.25 || r.AvgScore != (0.8+0.1-0.6+0.25)/4 {
+		t.Errorf("record = %+v", r)
+	}
+
+	store.comments = append(store.comments, storage.CommentText{VideoID: "v3", Text: "fail"})
+	store.records = nil
+	if _, err := CommentSentiment(context.Background(), store, analyzer, date); err == nil || store.records != nil {
+		t.Errorf("CommentSentiment() = %v with %d records, want the analyzer error", err, len(store.records))
+	}
+}
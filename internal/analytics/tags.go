@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxTagLength drops tags longer than YouTube allows; anything longer is
+// not a real tag (e.g. a sentence pasted into the tags field).
+const maxTagLength = 100
+
+// NormalizeTag folds a tag or hashtag to the form tags are counted under:
+// NFKC (so full-width "ＡＩ" and "AI" match), lower case, without a leading
+// '#' and with runs of spaces collapsed. It returns "" for unusable tags.
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(norm.NFKC.String(tag))
+	tag = strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(tag), "#")), " ")
+	if tag == "" || len([]rune(tag)) > maxTagLength {
+		return ""
+	}
+	return tag
+}
+
+// ExtractHashtags returns the normalized hashtags in text, in order of first
+// appearance. A hashtag is '#' (or full-width '＃') not preceded by a letter
+// or digit, followed by letters, digits, marks and underscores, at least one
+// of them a letter; "#1" and "C#" are not hashtags.
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' && runes[i] != '＃' {
+			continue
+		}
+		if i > 0 && isTagRune(runes[i-1]) {
+			continue
+		}
+		j, letters := i+1, 0
+		for j < len(runes) && isTagRune(runes[j]) {
+			if unicode.IsLetter(runes[j]) {
+				letters++
+			}
+			j++
+		}
+		if letters > 0 {
+			if tag := NormalizeTag(string(runes[i+1 : j])); tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		i = j - 1
+	}
+	return tags
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// VideoTags returns the distinct normalized tags of a video: its tags field
+// plus the hashtags in its title and description.
+func VideoTags(in storage.TagInput) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, t := range in.Tags {
+		add(NormalizeTag(t))
+	}
+	for _, t := range ExtractHashtags(in.Title + "\n" + in.Description) {
+		add(t)
+	}
+	return tags
+}
+
+// TagStore reads tag inputs and stores the aggregates.
+type TagStore interface {
+	TagInputs(ctx context.Context, date civil.Date) ([]storage.TagInput, error)
+	ReplaceTagTrends(ctx context.Context, date civil.Date, records []*storage.TagTrendRecord) error
+}
+
+// TagTrends aggregates the tags of every video snapshotted on date and
+// replaces the date's tag trends. Views gained are measured against the
+// latest earlier snapshot; videos first seen on date count all their views.
+// It returns how many tags were written.
+func TagTrends(ctx context.Context, store TagStore, date civil.Date) (int, error) {
+	inputs, err := store.TagInputs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	computedAt := time.Now()
+	byTag := make(map[string]*storage.TagTrendRecord)
+	channels := make(map[string]map[string]bool)
+	for _, in := range inputs {
+		gained := in.Views
+		if in.PrevViews.Valid {
+			gained = max(in.Views-in.PrevViews.Int64, 0)
+		}
+		for _, tag := range VideoTags(in) {
+			r, ok := byTag[tag]
+			if !ok {
+				r = &storage.TagTrendRecord{Dt: date, Tag: tag, ComputedAt: computedAt}
+				byTag[tag] = r
+				channels[tag] = make(map[string]bool)
+			}
+			r.VideoCount++
+			r.TotalViews += in.Views
+			r.ViewsGained += gained
+			channels[tag][in.ChannelID] = true
+		}
+	}
+
+	records := make([]*storage.TagTrendRecord, 0, len(byTag))
+	for tag, r := range byTag {
+		r.ChannelCount = int64(len(channels[tag]))
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Tag < records[j].Tag })
+
+	if err := store.ReplaceTagTrends(ctx, date, records); err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored %d tag trends from %d videos", len(records), len(inputs)), map[string]string{
+		"dt": date.String(),
+	})
+	return len(records), nil
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"新作 #生成AI と #ChatGPT の比較 #生成ai", []string{"生成ai", "chatgpt"}},
+		{"全角 ＃ＡＩニュース です", []string{"aiニュース"}},
+		{"#shorts\n#Shorts #vlog_2025", []string{"shorts", "vlog_2025"}},
+		{"C# と F# は対象外、#1 も #2025 も", nil},
+		{"#", nil},
+	}
+	for _, tt := range tests {
+		if got := ExtractHashtags(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractHashtags(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestVideoTags(t *testing.T) {
+	in := storage.TagInput{
+		Title:       "AI 特集 #AI",
+		Description: "詳しくは概要欄 #テック解説",
+		Tags:        []string{"ＡＩ", "  Tech   News ", "#テック解説", ""},
+	}
+	want := []string{"ai", "tech news", "テック解説"}
+	if got := VideoTags(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("VideoTags() = %q, want %q", got, want)
+	}
+}
+
+type fakeTagStore struct {
+	inputs  []storage.TagInput
+	date    civil.Date
+	records []*storage.TagTrendRecord
+}
+
+func (f *fakeTagStore) TagInputs(ctx context.Context, date civil.Date) ([]storage.TagInput, error) {
+	return f.inputs, nil
+}
+
+func (f *fakeTagStore) ReplaceTagTrends(ctx context.Context, date civil.Date, records []*storage.TagTrendRecord) error {
+	f.date, f.records = date, records
+	return nil
+}
+
+func TestTagTrends(t *testing.T) {
+	store := &fakeTagStore{inputs: []storage.TagInput{
+		{ChannelID: "UC1", VideoID: "v1", Tags: []string{"AI", "news"}, Views: 1000, PrevViews: bigquery.NullInt64{Int64: 400, Valid: true}},
+		{ChannelID: "UC1", VideoID: "v2", Title: "#ai", Views: 300},
+		{ChannelID: "UC2", VideoID: "v3", Tags: []string{"ai"}, Views: 50, PrevViews: bigquery.NullInt64{Int64: 80, Valid: true}},
+	}}
+	date := civil.Date{Year: 2025, Month: 8, Day: 1}
+
+	n, err := TagTrends(context.Background(), store, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(store.records) != 2 || store.date != date {
+		t.Fatalf("wrote %d records for %s, want 2 for %s", len(store.records), store.date, date)
+	}
+	ai, news := store.records[0], store.records[1]
+	// v1 gained 600, v2 is new (300), v3 lost views (0).
+	if ai.Tag != "ai" || ai.VideoCount != 3 || ai.ChannelCount != 2 || ai.TotalViews != 1350 || ai.ViewsGained != 900 {
+		t.Errorf("ai = %+v", ai)
+	}
+	if news.Tag != "news" || news.VideoCount != 1 || news.ViewsGained != 600 || news.Dt != date {
+		t.Errorf("news = %+v", news)
+	}
+}
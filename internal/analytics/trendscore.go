@@ -0,0 +1,149 @@
+// Package analytics computes derived metrics from stored snapshots.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// minHours keeps velocities of just-published videos or closely spaced
+// snapshots from exploding.
+const minHours = 1.0
+
+// Inputs are the per-video values a trend formula is computed from.
+type Inputs struct {
+	// ViewsGained over Hours: since the previous snapshot, or since
+	// publication for videos first seen on the date.
+	ViewsGained int64
+	Hours       float64
+	// AgeHours is the time since the video was published.
+	AgeHours float64
+	// ChannelMedianViews is the median views of the channel's videos.
+	ChannelMedianViews int64
+}
+
+// Formula computes a trend score; higher means trending more.
+type Formula func(in Inputs) float64
+
+// NewFormula returns the formula selected in cfg.
+func NewFormula(cfg config.AnalyticsConfig) (Formula, error) {
+	velocity := func(in Inputs) float64 {
+		return float64(in.ViewsGained) / math.Max(in.Hours, minHours)
+	}
+	relative := func(in Inputs) float64 {
+		return velocity(in) / math.Max(float64(in.ChannelMedianViews), 1)
+	}
+
+	switch cfg.TrendFormula {
+	case config.TrendFormulaVelocity:
+		return velocity, nil
+	case config.TrendFormulaRelative:
+		return relative, nil
+	case config.TrendFormulaDecayed:
+		gravity := cfg.TrendGravity
+		return func(in Inputs) float64 {
+			return relative(in) / math.Pow(in.AgeHours+2, gravity)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown trend formula %q", cfg.TrendFormula)
+	}
+}
+
+// ScoreStore reads scoring inputs and stores the scores.
+type ScoreStore interface {
+	TrendInputs(ctx context.Context, date civil.Date) ([]storage.TrendInput, error)
+	InsertTrendScores(ctx context.Context, records []*storage.TrendScoreRecord) error
+}
+
+// TrendScorer computes a trend score for every video snapshotted on a date.
+type TrendScorer struct {
+	store   ScoreStore
+	formula Formula
+	name    string
+	// forecast adds the projected views to the scores.
+	forecast bool
+}
+
+// NewTrendScorer creates a scorer using the formula selected in cfg.
+func NewTrendScorer(store ScoreStore, cfg config.AnalyticsConfig) (*TrendScorer, error) {
+	formula, err := NewFormula(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TrendScorer{store: store, formula: formula, name: cfg.TrendFormula, forecast: cfg.Forecast}, nil
+}
+
+// Score computes and stores the scores for date and returns how many were
+// written.
+func (s *TrendScorer) Score(ctx context.Context, date civil.Date) (int, error) {
+	inputs, err := s.store.TrendInputs(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	computedAt := time.Now()
+	records := make([]*storage.TrendScoreRecord, 0, len(inputs))
+	for _, in := range inputs {
+		fi := formulaInputs(in)
+		r := &storage.TrendScoreRecord{
+			Dt:                 date,
+			ComputedAt:         computedAt,
+			ChannelID:          in.ChannelID,
+			VideoID:            in.VideoID,
+			Title:              in.Title,
+			Formula:            s.name,
+			Score:              s.formula(fi),
+			Views:              in.Views,
+			ViewsGained:        fi.ViewsGained,
+			Hours:              fi.Hours,
+			AgeHours:           fi.AgeHours,
+			ChannelMedianViews: in.ChannelMedianViews,
+		}
+		if s.forecast {
+			points := []ViewPoint{{At: in.SnapshotTs, Views: in.Views}}
+			if in.PrevViews.Valid && in.PrevSnapshotTs.Valid {
+				points = append([]ViewPoint{{At: in.PrevSnapshotTs.Timestamp, Views: in.PrevViews.Int64}}, points...)
+			}
+			r.ForecastViews7d = bigquery.NullInt64{Int64: ForecastViews(in.PublishedAt, points, ForecastHorizon7d), Valid: true}
+			r.ForecastViews30d = bigquery.NullInt64{Int64: ForecastViews(in.PublishedAt, points, ForecastHorizon30d), Valid: true}
+		}
+		records = append(records, r)
+	}
+
+	if err := s.store.InsertTrendScores(ctx, records); err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Info(fmt.Sprintf("Stored %d trend scores", len(records)), map[string]string{
+		"dt":      date.String(),
+		"formula": s.name,
+	})
+	return len(records), nil
+}
+
+// formulaInputs derives the formula inputs from a stored snapshot pair.
+// View counts can drop when YouTube removes invalid views; the gain is then
+// treated as zero.
+func formulaInputs(in storage.TrendInput) Inputs {
+	var age float64
+	if !in.PublishedAt.IsZero() && in.PublishedAt.Unix() > 0 {
+		age = math.Max(in.SnapshotTs.Sub(in.PublishedAt).Hours(), 0)
+	}
+
+	fi := Inputs{AgeHours: age, ChannelMedianViews: in.ChannelMedianViews}
+	if in.PrevViews.Valid && in.PrevSnapshotTs.Valid {
+		fi.ViewsGained = max(in.Views-in.PrevViews.Int64, 0)
+		fi.Hours = in.SnapshotTs.Sub(in.PrevSnapshotTs.Timestamp).Hours()
+	} else {
+		fi.ViewsGained = in.Views
+		fi.Hours = age
+	}
+	return fi
+}
@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+func TestNewFormula(t *testing.T) {
+	in := Inputs{ViewsGained: 2400, Hours: 24, AgeHours: 7, ChannelMedianViews: 1000}
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{config.TrendFormulaVelocity, 100},
+		{config.TrendFormulaRelative, 0.1},
+		{config.TrendFormulaDecayed, 0.1 / 3}, // (7+2)^0.5 = 3
+	}
+	for _, tt := range tests {
+		f, err := NewFormula(config.AnalyticsConfig{TrendFormula: tt.formula, TrendGravity: 0.5})
+		if err != nil {
+			t.Fatalf("NewFormula(%s): %v", tt.formula, err)
+		}
+		if got := f(in); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.formula, got, tt.want)
+		}
+	}
+
+	if _, err := NewFormula(config.AnalyticsConfig{TrendFormula: "hot"}); err == nil {
+		t.Error("unknown formula should fail")
+	}
+}
+
+func TestNewFormula_Clamps(t *testing.T) {
+	f, _ := NewFormula(config.AnalyticsConfig{TrendFormula: config.TrendFormulaRelative})
+	// Sub-hour spans count as an hour and a zero median as one view.
+	if got := f(Inputs{ViewsGained: 50, Hours: 0.1}); got != 50 {
+		t.Errorf("score = %v, want 50", got)
+	}
+}
+
+type fakeScoreStore struct {
+	inputs []storage.TrendInput
+	stored []*storage.TrendScoreRecord
+}
+
+func (f *fakeScoreStore) TrendInputs(ctx context.Context, date civil.Date) ([]storage.TrendInput, error) {
+	return f.inputs, nil
+}
+
+func (f *fakeScoreStore) InsertTrendScores(ctx context.Context, records []*storage.TrendScoreRecord) error {
+	f.stored = records
+	return nil
+}
+
+func TestTrendScorer_Score(t *testing.T) {
+	now := time.Date(2025, 8, 2, 9, 0, 0, 0, time.UTC)
+	store := &fakeScoreStore{inputs: []storage.TrendInput{
+		{
+			// Seen yesterday: the gain since then counts.
+			ChannelID: "UC1", VideoID: "old", Views: 1500, SnapshotTs: now,
+			PublishedAt:        now.Add(-72 * time.Hour),
+			PrevViews:          bigquery.NullInt64{Int64: 1000, Valid: true},
+			PrevSnapshotTs:     bigquery.NullTimestamp{Timestamp: now.Add(-25 * time.Hour), Valid: true},
+			ChannelMedianViews: 1000,
+		},
+		{
+			// First seen today: everything since publication counts.
+			ChannelID: "UC1", VideoID: "new", Views: 300, SnapshotTs: now,
+			PublishedAt:        now.Add(-6 * time.Hour),
+			ChannelMedianViews: 1000,
+		},
+		{
+			// Views dropped after invalid views were removed.
+			ChannelID: "UC1", VideoID: "drop", Views: 90, SnapshotTs: now,
+			PublishedAt:        now.Add(-100 * time.Hour),
+			PrevViews:          bigquery.NullInt64{Int64: 100, Valid: true},
+			PrevSnapshotTs:     bigquery.NullTimestamp{Timestamp: now.Add(-24 * time.Hour), Valid: true},
+			ChannelMedianViews: 1000,
+		},
+	}}
+
+	scorer, err := NewTrendScorer(store, config.AnalyticsConfig{TrendFormula: config.TrendFormulaVelocity})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt := civil.Date{Year: 2025, Month: 8, Day: 2}
+	n, err := scorer.Score(context.Background(), dt)
+	if err != nil || n != 3 {
+		t.Fatalf("Score() = %d, %v, want 3 records", n, err)
+	}
+
+	byID := map[string]*storage.TrendScoreRecord{}
+	for _, r := range store.stored {
+		byID[r.VideoID] = r
+		if r.Dt != dt || r.Formula != config.TrendFormulaVelocity {
+			t.Errorf("record %s dt/formula = %v/%s", r.VideoID, r.Dt, r.Formula)
+		}
+	}
+	if r := byID["old"]; r.ViewsGained != 500 || r.Hours != 25 || r.Score != 20 || r.AgeHours != 72 {
+		t.Errorf("old = %+v", r)
+	}
+	if r := byID["new"]; r.ViewsGained != 300 || r.Hours != 6 || r.Score != 50 {
+		t.Errorf("new = %+v", r)
+	}
+	if r := byID["drop"]; r.ViewsGained != 0 || r.Score != 0 {
+		t.Errorf("drop = %+v", r)
+	}
+	if r := byID["old"]; r.ForecastViews7d.Valid || r.ForecastViews30d.Valid {
+		t.Errorf("old forecast = %+v, want none without analytics.forecast", r)
+	}
+}
+
+func TestTrendScorer_Forecast(t *testing.T) {
+	now := time.Date(2025, 8, 2, 9, 0, 0, 0, time.UTC)
+	in := storage.TrendInput{
+		ChannelID: "UC1", VideoID: "v1", Views: 1500, SnapshotTs: now,
+		PublishedAt:    now.Add(-72 * time.Hour),
+		PrevViews:      bigquery.NullInt64{Int64: 1000, Valid: true},
+		PrevSnapshotTs: bigquery.NullTimestamp{Timestamp: now.Add(-24 * time.Hour), Valid: true},
+	}
+	store := &fakeScoreStore{inputs: []storage.TrendInput{in}}
+	scorer, err := NewTrendScorer(store, config.AnalyticsConfig{TrendFormula: config.TrendFormulaVelocity, Forecast: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scorer.Score(context.Background(), civil.Date{Year: 2025, Month: 8, Day: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := store.stored[0]
+	points := []ViewPoint{{now.Add(-24 * time.Hour), 1000}, {now, 1500}}
+	if want := ForecastViews(in.PublishedAt, points, ForecastHorizon7d); !r.ForecastViews7d.Valid || r.ForecastViews7d.Int64 != want {
+		t.Errorf("7-day forecast = %+v, want %d", r.ForecastViews7d, want)
+	}
+	if r.ForecastViews30d.Int64 <= r.ForecastViews7d.Int64 || r.ForecastViews7d.Int64 <= 1500 {
+		t.Errorf("forecasts = %d, %d, want growing beyond 1500", r.ForecastViews7d.Int64, r.ForecastViews30d.Int64)
+	}
+}
@@ -0,0 +1,106 @@
+package channelsource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+const bigQueryScheme = "bigquery"
+
+// ChannelReader reads the channels table.
+type ChannelReader interface {
+	ReadChannels(ctx context.Context) ([]storage.ChannelRecord, error)
+}
+
+// ChannelWriter replaces the contents of the channels table.
+type ChannelWriter interface {
+	EnsureChannelsTable(ctx context.Context) error
+	ReplaceChannels(ctx context.Context, records []storage.ChannelRecord) error
+}
+
+// BigQueryLoader reads and writes the channel list in the channels table.
+type BigQueryLoader struct {
+	reader ChannelReader
+	writer ChannelWriter
+}
+
+// NewBigQueryLoader creates a loader for a bigquery[://<dataset>] URI.
+func NewBigQueryLoader(ctx context.Context, uri, projectID, defaultDataset string) (*BigQueryLoader, error) {
+	dataset := defaultDataset
+	if rest, ok := strings.CutPrefix(uri, bigQueryScheme+"://"); ok && rest != "" {
+		dataset = rest
+	}
+	if dataset == "" {
+		return nil, fmt.Errorf("no dataset for channel config source %q", uri)
+	}
+	w, err := storage.NewBigQueryWriterWithConfig(ctx, projectID, dataset, storage.ChannelsTableID)
+	if err != nil {
+		return nil, err
+	}
+	return &BigQueryLoader{reader: w, writer: w}, nil
+}
+
+// LoadChannels implements Loader.
+func (l *BigQueryLoader) LoadChannels(ctx context.Context) ([]config.ChannelConfig, error) {
+	records, err := l.reader.ReadChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records), nil
+}
+
+// SaveChannels implements Saver. The table has no columns for playlist
+// entries, priorities or schedules, so a list using them is refused rather
+// than saved without them.
+func (l *BigQueryLoader) SaveChannels(ctx context.Context, channels []config.ChannelConfig) error {
+	for _, ch := range channels {
+		if ch.IsPlaylist() || ch.Priority != 0 || ch.Schedule != "" || len(ch.DisabledParts) > 0 {
+			return fmt.Errorf("%w: channel %s: the channels table has no type, priority, schedule or disabled_parts", ErrUnsupported, ch.ID)
+		}
+	}
+	if err := l.writer.EnsureChannelsTable(ctx); err != nil {
+		return err
+	}
+	return l.writer.ReplaceChannels(ctx, ToRecords(channels, time.Now()))
+}
+
+// FromRecords converts channels table rows to channel configs.
+func FromRecords(records []storage.ChannelRecord) []config.ChannelConfig {
+	channels := make([]config.ChannelConfig, 0, len(records))
+	for _, r := range records {
+		channels = append(channels, config.ChannelConfig{
+			ID:            r.ChannelID,
+			Name:          r.Name,
+			Description:   r.Description,
+			Enabled:       r.Enabled,
+			TrackComments: r.TrackComments,
+		})
+	}
+	return channels
+}
+
+// ToRecords converts channel configs to channels table rows stamped with now.
+// Entries without an ID are dropped, and so are playlist entries, which the
+// channels table cannot mark as such.
+func ToRecords(channels []config.ChannelConfig, now time.Time) []storage.ChannelRecord {
+	records := make([]storage.ChannelRecord, 0, len(channels))
+	for _, ch := range channels {
+		if ch.ID == "" || ch.IsPlaylist() {
+			continue
+		}
+		records = append(records, storage.ChannelRecord{
+			ChannelID:     ch.ID,
+			Name:          ch.Name,
+			Description:   ch.Description,
+			Enabled:       ch.Enabled,
+			TrackComments: ch.TrackComments,
+			UpdatedAt:     now,
+		})
+	}
+	return records
+}
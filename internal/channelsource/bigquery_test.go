@@ -0,0 +1,43 @@
+package channelsource
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeChannelReader struct {
+	records []storage.ChannelRecord
+}
+
+func (f *fakeChannelReader) ReadChannels(ctx context.Context) ([]storage.ChannelRecord, error) {
+	return f.records, nil
+}
+
+func TestBigQueryLoader_LoadChannels(t *testing.T) {
+	now := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	channels := []config.ChannelConfig{
+		{ID: "UC1", Name: "One", Enabled: true, TrackComments: true},
+		{ID: "", Name: "no id", Enabled: true},
+		{ID: "UC2", Description: "paused", Enabled: false},
+	}
+
+	records := ToRecords(channels, now)
+	if len(records) != 2 || !records[0].UpdatedAt.Equal(now) {
+		t.Fatalf("ToRecords() = %+v, want 2 records stamped %v", records, now)
+	}
+
+	l := &BigQueryLoader{reader: &fakeChannelReader{records: records}}
+	got, err := l.LoadChannels(context.Background())
+	if err != nil {
+		t.Fatalf("LoadChannels() error = %v", err)
+	}
+	want := []config.ChannelConfig{channels[0], channels[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadChannels() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package channelsource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+	"gopkg.in/yaml.v3"
+)
+
+const gcsScheme = "gs://"
+
+// channelsFile is the content of a channel list object: the channels
+// section of a configuration file.
+type channelsFile struct {
+	Channels []config.ChannelConfig `yaml:"channels"`
+}
+
+// GCSLoader reads and writes the channel list as a YAML object in Cloud
+// Storage, in the format of the channels section of a configuration file.
+type GCSLoader struct {
+	service *gcs.Service
+	bucket  string
+	object  string
+
+	mu sync.Mutex
+	// generation is that of the object last read or written, so that a
+	// save does not overwrite a change made since.
+	generation int64
+}
+
+// NewGCSLoader creates a loader for a gs://<bucket>/<object> URI using
+// Application Default Credentials.
+func NewGCSLoader(ctx context.Context, uri string, opts ...option.ClientOption) (*GCSLoader, error) {
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(uri, gcsScheme), "/")
+	if bucket == "" || object == "" {
+		return nil, fmt.Errorf("GCS channel source must be gs://<bucket>/<object>: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(gcs.DevstorageReadWriteScope))
+	svc, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewService: %w", err)
+	}
+	return &GCSLoader{service: svc, bucket: bucket, object: object}, nil
+}
+
+// LoadChannels implements Loader.
+func (l *GCSLoader) LoadChannels(ctx context.Context) ([]config.ChannelConfig, error) {
+	resp, err := l.service.Objects.Get(l.bucket, l.object).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("storage.objects.get %s: %w", l.object, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", l.object, err)
+	}
+
+	var file channelsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", l.object, err)
+	}
+	generation, err := strconv.ParseInt(resp.Header.Get("X-Goog-Generation"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: no object generation: %w", l.object, err)
+	}
+	l.mu.Lock()
+	l.generation = generation
+	l.mu.Unlock()
+	return file.Channels, nil
+}
+
+// SaveChannels implements Saver. It fails with ErrConflict when the object
+// changed since it was last read.
+func (l *GCSLoader) SaveChannels(ctx context.Context, channels []config.ChannelConfig) error {
+	data, err := yaml.Marshal(channelsFile{Channels: channels})
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	call := l.service.Objects.Insert(l.bucket, &gcs.Object{Name: l.object, ContentType: "application/yaml"}).
+		Media(bytes.NewReader(data)).
+		IfGenerationMatch(l.generation). // 0: the object must not exist yet
+		Context(ctx)
+	obj, err := call.Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %s%s/%s", ErrConflict, gcsScheme, l.bucket, l.object)
+	}
+	if err != nil {
+		return fmt.Errorf("storage.objects.insert %s: %w", l.object, err)
+	}
+	l.generation = obj.Generation
+	return nil
+}
@@ -0,0 +1,62 @@
+package channelsource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/api/option"
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+const sheetsScheme = "sheets://"
+
+// ParseSheetsURI splits sheets://<spreadsheetId>/<range> into its parts. The
+// range uses A1 notation (e.g. Channels!A:E) and may be URL-escaped.
+func ParseSheetsURI(uri string) (spreadsheetID, readRange string, err error) {
+	rest, ok := strings.CutPrefix(uri, sheetsScheme)
+	if !ok {
+		return "", "", fmt.Errorf("not a sheets URI: %q", uri)
+	}
+	spreadsheetID, readRange, _ = strings.Cut(rest, "/")
+	if readRange, err = url.PathUnescape(readRange); err != nil {
+		return "", "", fmt.Errorf("invalid range in %q: %w", uri, err)
+	}
+	if spreadsheetID == "" || readRange == "" {
+		return "", "", fmt.Errorf("sheets URI must be sheets://<spreadsheetId>/<range>: %q", uri)
+	}
+	return spreadsheetID, readRange, nil
+}
+
+// SheetsReader reads a channel list from a Google Sheets range. The sheet
+// must be shared with the service account the fetcher runs as.
+type SheetsReader struct {
+	service       *sheets.Service
+	spreadsheetID string
+	readRange     string
+}
+
+// NewSheetsReader creates a reader for a sheets:// URI using Application
+// Default Credentials.
+func NewSheetsReader(ctx context.Context, uri string, opts ...option.ClientOption) (*SheetsReader, error) {
+	spreadsheetID, readRange, err := ParseSheetsURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, option.WithScopes(sheets.SpreadsheetsReadonlyScope))
+	svc, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("sheets.NewService: %w", err)
+	}
+	return &SheetsReader{service: svc, spreadsheetID: spreadsheetID, readRange: readRange}, nil
+}
+
+// ReadRows returns the values in the configured range.
+func (r *SheetsReader) ReadRows(ctx context.Context) ([][]interface{}, error) {
+	resp, err := r.service.Spreadsheets.Values.Get(r.spreadsheetID, r.readRange).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("spreadsheets.values.get: %w", err)
+	}
+	return resp.Values, nil
+}
@@ -0,0 +1,242 @@
+// Package channelsource loads the tracked channel list from outside the
+// configuration file, so it can be edited without a redeploy.
+package channelsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// Loader returns the current channel list from an external source.
+type Loader interface {
+	LoadChannels(ctx context.Context) ([]config.ChannelConfig, error)
+}
+
+// Saver is implemented by the loaders whose channel list can be written
+// back; a spreadsheet is edited by hand instead.
+type Saver interface {
+	SaveChannels(ctx context.Context, channels []config.ChannelConfig) error
+}
+
+var (
+	// ErrReadOnly is returned when saving to a source that cannot be written.
+	ErrReadOnly = errors.New("channel source is read-only")
+	// ErrUnsupported is returned when the source cannot hold some of the
+	// settings of the channels saved.
+	ErrUnsupported = errors.New("channel source cannot hold these settings")
+	// ErrConflict is returned when the channel list changed in the source
+	// since it was read; read it again and retry.
+	ErrConflict = errors.New("channel list changed since it was read")
+)
+
+// RowReader returns the raw rows of a tabular channel list, header first.
+type RowReader interface {
+	ReadRows(ctx context.Context) ([][]interface{}, error)
+}
+
+// FromRows returns a Loader that parses the rows of r with ParseRows.
+func FromRows(r RowReader) Loader {
+	return rowLoader{r}
+}
+
+type rowLoader struct{ RowReader }
+
+func (l rowLoader) LoadChannels(ctx context.Context) ([]config.ChannelConfig, error) {
+	rows, err := l.ReadRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRows(rows)
+}
+
+// New returns a cached source for the given URI:
+//
+//	sheets://<spreadsheetId>/<range>  a Google Sheets range
+//	bigquery[://<dataset>]            the channels table in the dataset
+//	                                  (defaults to defaultDataset)
+//	gs://<bucket>/<object>            a YAML object with a channels list
+func New(ctx context.Context, uri string, ttl time.Duration, projectID, defaultDataset string) (*Source, error) {
+	var loader Loader
+	switch {
+	case strings.HasPrefix(uri, sheetsScheme):
+		r, err := NewSheetsReader(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		loader = FromRows(r)
+	case uri == bigQueryScheme || strings.HasPrefix(uri, bigQueryScheme+"://"):
+		l, err := NewBigQueryLoader(ctx, uri, projectID, defaultDataset)
+		if err != nil {
+			return nil, err
+		}
+		loader = l
+	case strings.HasPrefix(uri, gcsScheme):
+		l, err := NewGCSLoader(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		loader = l
+	default:
+		return nil, fmt.Errorf("unsupported channel config source %q", uri)
+	}
+	return NewSource(loader, ttl), nil
+}
+
+// Source reads and validates a channel list and caches it for a TTL. If a
+// refresh fails, the previous list keeps being served.
+type Source struct {
+	loader Loader
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	channels  []config.ChannelConfig
+	fetchedAt time.Time
+}
+
+// NewSource creates a Source reading from l.
+func NewSource(l Loader, ttl time.Duration) *Source {
+	return &Source{loader: l, ttl: ttl, now: time.Now}
+}
+
+// Channels returns the current channel list, reading it again once the
+// cached copy is older than the TTL.
+func (s *Source) Channels(ctx context.Context) ([]config.ChannelConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channels != nil && s.now().Sub(s.fetchedAt) < s.ttl {
+		return s.channels, nil
+	}
+
+	channels, err := s.read(ctx)
+	if err != nil {
+		if s.channels == nil {
+			return nil, err
+		}
+		logger.FromContext(ctx).Warning("Failed to refresh channel list; using cached copy", err, map[string]string{
+			"fetched_at": s.fetchedAt.Format(time.RFC3339),
+		})
+		return s.channels, nil
+	}
+
+	s.channels = channels
+	s.fetchedAt = s.now()
+	return channels, nil
+}
+
+// Save validates channels and writes them to the source, which then serves
+// them without reading them again. It returns ErrReadOnly when the source
+// cannot be written. After a failed save the list is read again on next use.
+func (s *Source) Save(ctx context.Context, channels []config.ChannelConfig) error {
+	saver, ok := s.loader.(Saver)
+	if !ok {
+		return ErrReadOnly
+	}
+	if err := config.ValidateChannels(channels); err != nil {
+		return fmt.Errorf("invalid channel list: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := saver.SaveChannels(ctx, channels); err != nil {
+		s.fetchedAt = time.Time{}
+		return err
+	}
+	s.channels = channels
+	s.fetchedAt = s.now()
+	return nil
+}
+
+func (s *Source) read(ctx context.Context) ([]config.ChannelConfig, error) {
+	channels, err := s.loader.LoadChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ValidateChannels(channels); err != nil {
+		return nil, fmt.Errorf("invalid channel list: %w", err)
+	}
+	return channels, nil
+}
+
+// ParseRows converts rows whose first row is a header into channel configs.
+// Recognised columns are id, name, description, enabled, track_comments,
+// priority, type and schedule (case-insensitive); only id is required. A blank enabled
+// cell counts as enabled, a blank priority as 0, a blank type as a channel,
+// and rows with a blank id are skipped.
+func ParseRows(rows [][]interface{}) ([]config.ChannelConfig, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("channel list is empty")
+	}
+
+	cols := make(map[string]int)
+	for i, h := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(fmt.Sprint(h)))
+		cols[strings.ReplaceAll(name, " ", "_")] = i
+	}
+	if _, ok := cols["id"]; !ok {
+		return nil, fmt.Errorf("channel list has no id column")
+	}
+
+	cell := func(row []interface{}, col string) string {
+		i, ok := cols[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(row[i]))
+	}
+
+	channels := []config.ChannelConfig{}
+	for n, row := range rows[1:] {
+		id := cell(row, "id")
+		if id == "" {
+			continue
+		}
+		enabled, err := parseBool(cell(row, "enabled"), true)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: enabled: %w", n+2, err)
+		}
+		comments, err := parseBool(cell(row, "track_comments"), false)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: track_comments: %w", n+2, err)
+		}
+		var priority int
+		if v := cell(row, "priority"); v != "" {
+			if priority, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("row %d: priority: invalid integer %q", n+2, v)
+			}
+		}
+		channels = append(channels, config.ChannelConfig{
+			ID:            id,
+			Name:          cell(row, "name"),
+			Description:   cell(row, "description"),
+			Enabled:       enabled,
+			TrackComments: comments,
+			Priority:      priority,
+			Type:          strings.ToLower(cell(row, "type")),
+			Schedule:      cell(row, "schedule"),
+		})
+	}
+	return channels, nil
+}
+
+// parseBool accepts the values spreadsheet checkboxes and people produce.
+func parseBool(v string, blank bool) (bool, error) {
+	switch strings.ToLower(v) {
+	case "":
+		return blank, nil
+	case "true", "yes", "y", "1", "on":
+		return true, nil
+	case "false", "no", "n", "0", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
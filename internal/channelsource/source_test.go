@@ -0,0 +1,177 @@
+package channelsource
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+type fakeReader struct {
+	rows  [][]interface{}
+	err   error
+	calls int
+}
+
+func (f *fakeReader) ReadRows(ctx context.Context) ([][]interface{}, error) {
+	f.calls++
+	return f.rows, f.err
+}
+
+func TestParseSheetsURI(t *testing.T) {
+	tests := []struct {
+		uri       string
+		wantID    string
+		wantRange string
+		wantErr   bool
+	}{
+		{uri: "sheets://abc123/Channels!A:E", wantID: "abc123", wantRange: "Channels!A:E"},
+		{uri: "sheets://abc123/My%20Channels!A1:E100", wantID: "abc123", wantRange: "My Channels!A1:E100"},
+		{uri: "sheets://abc123", wantErr: true},
+		{uri: "sheets:///A:E", wantErr: true},
+		{uri: "gs://bucket/channels.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		id, rng, err := ParseSheetsURI(tt.uri)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSheetsURI(%q) error = %v, wantErr %v", tt.uri, err, tt.wantErr)
+			continue
+		}
+		if id != tt.wantID || rng != tt.wantRange {
+			t.Errorf("ParseSheetsURI(%q) = %q, %q, want %q, %q", tt.uri, id, rng, tt.wantID, tt.wantRange)
+		}
+	}
+}
+
+func TestParseRows(t *testing.T) {
+	rows := [][]interface{}{
+		{"ID", "Name", "Enabled", "Track Comments", "Priority", "Type"},
+		{"UC1xxxxxxxxxxxxxxxxxxxxx", "One", "TRUE", "TRUE", "10"},
+		{"UC2xxxxxxxxxxxxxxxxxxxxx", "Two", "FALSE"},
+		{"", "blank row"},
+		{"UC3xxxxxxxxxxxxxxxxxxxxx"},
+		{"PL1", "Best of", "", "", "", "Playlist"},
+	}
+
+	got, err := ParseRows(rows)
+	if err != nil {
+		t.Fatalf("ParseRows() error = %v", err)
+	}
+	want := []config.ChannelConfig{
+		{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Name: "One", Enabled: true, TrackComments: true, Priority: 10},
+		{ID: "UC2xxxxxxxxxxxxxxxxxxxxx", Name: "Two", Enabled: false},
+		{ID: "UC3xxxxxxxxxxxxxxxxxxxxx", Enabled: true},
+		{ID: "PL1", Name: "Best of", Enabled: true, Type: config.ChannelTypePlaylist},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRows() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseRows([][]interface{}{{"name"}, {"x"}}); err == nil {
+		t.Error("ParseRows() without an id column should fail")
+	}
+	if _, err := ParseRows([][]interface{}{{"id", "enabled"}, {"UC1xxxxxxxxxxxxxxxxxxxxx", "maybe"}}); err == nil {
+		t.Error("ParseRows() with an invalid boolean should fail")
+	}
+	if _, err := ParseRows([][]interface{}{{"id", "priority"}, {"UC1xxxxxxxxxxxxxxxxxxxxx", "high"}}); err == nil {
+		t.Error("ParseRows() with an invalid priority should fail")
+	}
+}
+
+func TestSource_Channels(t *testing.T) {
+	r := &fakeReader{rows: [][]interface{}{{"id"}, {"UC1xxxxxxxxxxxxxxxxxxxxx"}}}
+	s := NewSource(FromRows(r), time.Minute)
+	now := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := s.Channels(ctx); err != nil {
+		t.Fatalf("Channels() error = %v", err)
+	}
+	if _, err := s.Channels(ctx); err != nil {
+		t.Fatalf("Channels() error = %v", err)
+	}
+	if r.calls != 1 {
+		t.Errorf("reads within TTL = %d, want 1", r.calls)
+	}
+
+	// An expired cache is refreshed; a failed refresh serves the old list.
+	now = now.Add(2 * time.Minute)
+	r.err = errors.New("sheets unavailable")
+	got, err := s.Channels(ctx)
+	if err != nil {
+		t.Fatalf("Channels() with stale cache error = %v", err)
+	}
+	if r.calls != 2 || len(got) != 1 || got[0].ID != "UC1xxxxxxxxxxxxxxxxxxxxx" {
+		t.Errorf("Channels() = %+v after %d reads, want cached UC1 after 2", got, r.calls)
+	}
+}
+
+func TestSource_ChannelsInvalid(t *testing.T) {
+	r := &fakeReader{rows: [][]interface{}{{"id", "enabled"}, {"UC1xxxxxxxxxxxxxxxxxxxxx", "FALSE"}}}
+	if _, err := NewSource(FromRows(r), time.Minute).Channels(context.Background()); err == nil {
+		t.Error("Channels() with no enabled channel should fail")
+	}
+}
+
+// fakeSaver is a writable Loader.
+type fakeSaver struct {
+	channels []config.ChannelConfig
+	err      error
+	loads    int
+}
+
+func (f *fakeSaver) LoadChannels(ctx context.Context) ([]config.ChannelConfig, error) {
+	f.loads++
+	return f.channels, nil
+}
+
+func (f *fakeSaver) SaveChannels(ctx context.Context, channels []config.ChannelConfig) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.channels = channels
+	return nil
+}
+
+func TestSource_Save(t *testing.T) {
+	ctx := context.Background()
+	one := []config.ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+	two := append(one, config.ChannelConfig{ID: "UC2xxxxxxxxxxxxxxxxxxxxx", Enabled: true})
+
+	l := &fakeSaver{channels: one}
+	s := NewSource(l, time.Hour)
+	if _, err := s.Channels(ctx); err != nil {
+		t.Fatalf("Channels() error = %v", err)
+	}
+	if err := s.Save(ctx, two); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// The saved list is served without reading the source again.
+	got, err := s.Channels(ctx)
+	if err != nil || !reflect.DeepEqual(got, two) || l.loads != 1 {
+		t.Errorf("Channels() after Save = %+v, %v after %d loads, want the saved list after 1", got, err, l.loads)
+	}
+
+	if err := s.Save(ctx, []config.ChannelConfig{{ID: "bad"}}); err == nil {
+		t.Error("Save() of an invalid list should fail")
+	}
+
+	// A failed save reads the source again on next use.
+	l.err = ErrConflict
+	if err := s.Save(ctx, one); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() error = %v, want ErrConflict", err)
+	}
+	if _, err := s.Channels(ctx); err != nil || l.loads != 2 {
+		t.Errorf("Channels() after a failed save: %v after %d loads, want a reload", err, l.loads)
+	}
+
+	// Spreadsheets cannot be written.
+	if err := NewSource(FromRows(&fakeReader{}), time.Hour).Save(ctx, one); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Save() to a spreadsheet error = %v, want ErrReadOnly", err)
+	}
+}
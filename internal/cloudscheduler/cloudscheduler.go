@@ -0,0 +1,166 @@
+// Package cloudscheduler provisions the Cloud Scheduler HTTP jobs that
+// trigger the fetcher, so that their schedule, target and retry policy are
+// declared in the configuration file rather than in shell scripts.
+package cloudscheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cs "google.golang.org/api/cloudscheduler/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Job is the desired state of a Cloud Scheduler HTTP job.
+type Job struct {
+	// Name is the job ID within the location, e.g. "trend-tracker-hourly".
+	Name        string
+	Description string
+	// Schedule is a 5-field cron expression evaluated in TimeZone (an IANA
+	// name).
+	Schedule string
+	TimeZone string
+	// URL receives a POST with an OIDC token for ServiceAccount, whose
+	// audience is Audience (URL when empty).
+	URL            string
+	ServiceAccount string
+	Audience       string
+	// AttemptDeadline is how long Cloud Scheduler waits for a response
+	// (15s to 30m).
+	AttemptDeadline time.Duration
+	// RetryCount is how many times a failed attempt is retried, with
+	// exponential backoff from MinBackoff to MaxBackoff.
+	RetryCount int64
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Outcomes reported by Ensure.
+const (
+	Created   = "created"
+	Updated   = "updated"
+	Unchanged = "unchanged"
+)
+
+// Ensure creates the job in projects/<project>/locations/<location>, or
+// updates the fields of an existing one that differ from job. A paused job
+// stays paused. It returns Created, Updated or Unchanged.
+func Ensure(ctx context.Context, svc *cs.Service, project, location string, job Job) (string, error) {
+	parent := fmt.Sprintf("projects/%s/locations/%s", project, location)
+	want := job.resource(parent)
+
+	current, err := svc.Projects.Locations.Jobs.Get(want.Name).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		if _, err := svc.Projects.Locations.Jobs.Create(parent, want).Context(ctx).Do(); err != nil {
+			return "", fmt.Errorf("failed to create scheduler job %s: %w", job.Name, err)
+		}
+		return Created, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get scheduler job %s: %w", job.Name, err)
+	}
+
+	paths := changes(current, want)
+	if len(paths) == 0 {
+		return Unchanged, nil
+	}
+	if _, err := svc.Projects.Locations.Jobs.Patch(want.Name, want).UpdateMask(strings.Join(paths, ",")).Context(ctx).Do(); err != nil {
+		return "", fmt.Errorf("failed to update scheduler job %s: %w", job.Name, err)
+	}
+	return Updated, nil
+}
+
+// resource returns the API representation of the job under parent.
+func (j Job) resource(parent string) *cs.Job {
+	audience := j.Audience
+	if audience == "" {
+		audience = j.URL
+	}
+	return &cs.Job{
+		Name:        parent + "/jobs/" + j.Name,
+		Description: j.Description,
+		Schedule:    j.Schedule,
+		TimeZone:    j.TimeZone,
+		HttpTarget: &cs.HttpTarget{
+			Uri:        j.URL,
+			HttpMethod: http.MethodPost,
+			OidcToken:  &cs.OidcToken{ServiceAccountEmail: j.ServiceAccount, Audience: audience},
+		},
+		AttemptDeadline: duration(j.AttemptDeadline),
+		RetryConfig: &cs.RetryConfig{
+			RetryCount:         j.RetryCount,
+			MinBackoffDuration: duration(j.MinBackoff),
+			MaxBackoffDuration: duration(j.MaxBackoff),
+		},
+	}
+}
+
+// duration formats d the way the API does, e.g. "300s"; zero leaves the
+// service default.
+func duration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%ds", int64(d/time.Second))
+}
+
+// sameDuration compares API durations, which the service may return in
+// another format than they were sent in ("300s" and "300.000s"). An empty
+// want means the service default and matches anything.
+func sameDuration(current, want string) bool {
+	if want == "" {
+		return true
+	}
+	c, err1 := time.ParseDuration(current)
+	w, err2 := time.ParseDuration(want)
+	return err1 == nil && err2 == nil && c == w
+}
+
+// changes returns the update mask paths of the fields in which current
+// differs from want.
+func changes(current, want *cs.Job) []string {
+	var paths []string
+	if current.Description != want.Description {
+		paths = append(paths, "description")
+	}
+	if current.Schedule != want.Schedule {
+		paths = append(paths, "schedule")
+	}
+	if current.TimeZone != want.TimeZone {
+		paths = append(paths, "time_zone")
+	}
+	if !sameTarget(current.HttpTarget, want.HttpTarget) {
+		paths = append(paths, "http_target")
+	}
+	if !sameDuration(current.AttemptDeadline, want.AttemptDeadline) {
+		paths = append(paths, "attempt_deadline")
+	}
+	if !sameRetry(current.RetryConfig, want.RetryConfig) {
+		paths = append(paths, "retry_config")
+	}
+	return paths
+}
+
+func sameTarget(current, want *cs.HttpTarget) bool {
+	if current == nil || current.OidcToken == nil {
+		return false
+	}
+	return current.Uri == want.Uri &&
+		current.HttpMethod == want.HttpMethod &&
+		current.OidcToken.ServiceAccountEmail == want.OidcToken.ServiceAccountEmail &&
+		current.OidcToken.Audience == want.OidcToken.Audience
+}
+
+func sameRetry(current, want *cs.RetryConfig) bool {
+	if current == nil {
+		current = &cs.RetryConfig{}
+	}
+	return current.RetryCount == want.RetryCount &&
+		sameDuration(current.MinBackoffDuration, want.MinBackoffDuration) &&
+		sameDuration(current.MaxBackoffDuration, want.MaxBackoffDuration)
+}
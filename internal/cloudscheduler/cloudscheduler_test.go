@@ -0,0 +1,108 @@
+package cloudscheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cs "google.golang.org/api/cloudscheduler/v1"
+	"google.golang.org/api/option"
+)
+
+var testJob = Job{
+	Name:            "trend-tracker-hourly",
+	Schedule:        "0 * * * *",
+	TimeZone:        "Asia/Tokyo",
+	URL:             "https://fetcher.example.run.app",
+	ServiceAccount:  "scheduler-sa@p.iam.gserviceaccount.com",
+	AttemptDeadline: 10 * time.Minute,
+	RetryCount:      1,
+	MinBackoff:      30 * time.Second,
+	MaxBackoff:      5 * time.Minute,
+}
+
+// fakeScheduler serves one job, recording the create and patch calls.
+type fakeScheduler struct {
+	job     *cs.Job
+	created bool
+	mask    string
+}
+
+func (f *fakeScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet:
+		if f.job == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(f.job)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/jobs"):
+		f.job = &cs.Job{}
+		json.NewDecoder(r.Body).Decode(f.job)
+		f.created = true
+		json.NewEncoder(w).Encode(f.job)
+	case r.Method == http.MethodPatch:
+		f.mask = r.URL.Query().Get("updateMask")
+		json.NewDecoder(r.Body).Decode(f.job)
+		json.NewEncoder(w).Encode(f.job)
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func ensure(t *testing.T, f *fakeScheduler, job Job) string {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	svc, err := cs.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := Ensure(context.Background(), svc, "p", "asia-northeast1", job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outcome
+}
+
+func TestEnsure(t *testing.T) {
+	f := &fakeScheduler{}
+	if got := ensure(t, f, testJob); got != Created || !f.created {
+		t.Fatalf("first Ensure = %s, want %s", got, Created)
+	}
+	if f.job.Name != "projects/p/locations/asia-northeast1/jobs/trend-tracker-hourly" {
+		t.Errorf("job name = %s", f.job.Name)
+	}
+	target := f.job.HttpTarget
+	if target.HttpMethod != "POST" || target.OidcToken.ServiceAccountEmail != testJob.ServiceAccount || target.OidcToken.Audience != testJob.URL {
+		t.Errorf("target = %+v, token = %+v", target, target.OidcToken)
+	}
+	if f.job.AttemptDeadline != "600s" || f.job.RetryConfig.MinBackoffDuration != "30s" {
+		t.Errorf("deadline = %s, retry = %+v", f.job.AttemptDeadline, f.job.RetryConfig)
+	}
+
+	// The service returns durations in its own format, which is not a
+	// change.
+	f.job.AttemptDeadline = "600.000s"
+	if got := ensure(t, f, testJob); got != Unchanged {
+		t.Errorf("second Ensure = %s, want %s", got, Unchanged)
+	}
+
+	changed := testJob
+	changed.Schedule = "*/30 * * * *"
+	changed.RetryCount = 3
+	if got := ensure(t, f, changed); got != Updated {
+		t.Fatalf("third Ensure = %s, want %s", got, Updated)
+	}
+	if f.mask != "schedule,retry_config" {
+		t.Errorf("update mask = %q, want schedule,retry_config", f.mask)
+	}
+	if f.job.Schedule != "*/30 * * * *" {
+		t.Errorf("schedule = %s after update", f.job.Schedule)
+	}
+}
@@ -1,18 +1,30 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/lancelop89/youtube-trend-tracker/internal/rules"
+	"github.com/lancelop89/youtube-trend-tracker/internal/scheduler"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
+	// Include lists files, relative to the including file, loaded before
+	// it; the including file's own settings override theirs.
+	Include []string `yaml:"include,omitempty"`
+
 	// Application settings
 	App AppConfig `yaml:"app"`
 
@@ -25,32 +37,196 @@ type Config struct {
 	// BigQuery settings
 	BigQuery BigQueryConfig `yaml:"bigquery"`
 
+	// Additional destinations the records of each run are copied to:
+	// gs://<bucket>[/<prefix>], pubsub://<topic>,
+	// kafka://<broker>[;<broker>...]/<topic>, s3://<bucket>[/<prefix>] or
+	// firestore://<collection>.
+	// BigQuery remains the primary store unless bigquery.disabled is set; a
+	// failing sink does not fail the run.
+	Sinks []string `yaml:"sinks"`
+
+	// Pub/Sub settings for fan-out worker mode
+	PubSub PubSubConfig `yaml:"pubsub"`
+
 	// Server settings
 	Server ServerConfig `yaml:"server"`
 
 	// Logging settings
 	Logging LoggingConfig `yaml:"logging"`
 
+	// Metrics export settings
+	Monitoring MonitoringConfig `yaml:"monitoring"`
+
+	// Scores computed from stored snapshots after each run
+	Analytics AnalyticsConfig `yaml:"analytics"`
+
+	// Stakeholder report written after each run
+	Report ReportConfig `yaml:"report"`
+
+	// Channel performance email digest
+	Digest DigestConfig `yaml:"digest"`
+
+	// Rules notifying Slack, webhooks, Pub/Sub or email of the videos of
+	// each run that match a condition
+	Notifications NotificationsConfig `yaml:"notifications"`
+
+	// Signed JSON events POSTed to external systems such as Zapier or n8n
+	Webhooks WebhooksConfig `yaml:"webhooks"`
+
+	// Cloud Scheduler job provisioned by `fetcher setup scheduler`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+
 	// Channel configuration
 	Channels []ChannelConfig `yaml:"channels"`
+
+	// Keyword searches tracked alongside channels
+	Keywords []KeywordConfig `yaml:"keywords"`
+
+	// Tenants tracked by the same deployment, each fetched on its own by
+	// POST /tenants/{id}/fetch
+	Tenants []TenantConfig `yaml:"tenants"`
+
+	// channelsFrom is where Channels was set: the file that last defined
+	// it, or "--channels" for the command-line flag; empty for the default.
+	channelsFrom string
+}
+
+// ChannelsFrom returns where the channel list was set: the configuration
+// file that defines it (which may be a profile or an included file),
+// "--channels" when the command-line flag replaced it, or "" when no file
+// sets it.
+func (c *Config) ChannelsFrom() string {
+	return c.channelsFrom
 }
 
 // AppConfig contains application-level settings
 type AppConfig struct {
 	Environment         string        `yaml:"environment"`
+	RunMode             string        `yaml:"run_mode"`
 	MaxVideosPerChannel int64         `yaml:"max_videos_per_channel"`
 	FetchTimeout        time.Duration `yaml:"fetch_timeout"`
+	// StatusLookbackDays re-checks videos stored within this many days and
+	// records a tombstone for those no longer available (0 disables).
+	StatusLookbackDays int `yaml:"status_lookback_days"`
+	// TrackMetadataChanges records title/description/tags/thumbnail changes
+	// into the video_metadata_changes table.
+	TrackMetadataChanges bool `yaml:"track_metadata_changes"`
+	// TopCommentsPerVideo is the number of top comments captured per video
+	// for channels with track_comments enabled.
+	TopCommentsPerVideo int64 `yaml:"top_comments_per_video"`
+	// ThumbnailArchive stores every version of each video's thumbnail in
+	// gs://<bucket>[/<prefix>] and records it in video_thumbnails. Every
+	// thumbnail is downloaded on each run to detect changes. Empty disables
+	// it.
+	ThumbnailArchive string `yaml:"thumbnail_archive"`
+	// ShortsURLCheck probes youtube.com/shorts/{id} to classify Shorts
+	// (one HTTP request per video of three minutes or less).
+	ShortsURLCheck bool `yaml:"shorts_url_check"`
+	// DryRun fetches from YouTube but writes nothing to BigQuery; the records
+	// that would have been written are logged (and returned over HTTP).
+	DryRun bool `yaml:"dry_run"`
+	// Timezone is the IANA zone used to derive the daily dt partition.
+	Timezone string `yaml:"timezone"`
+	// ChannelConfigSource, when set, replaces the channels list with one read
+	// from an external source: sheets://<spreadsheetId>/<range>, bigquery
+	// (optionally bigquery://<dataset>) for the channels table, or
+	// gs://<bucket>/<object> for a YAML file with a channels list.
+	ChannelConfigSource string `yaml:"channel_config_source"`
+	// ChannelConfigTTL is how long a channel list read from
+	// ChannelConfigSource is cached before it is read again.
+	ChannelConfigTTL time.Duration `yaml:"channel_config_ttl"`
+	// RunLock takes a lease in the run_locks table before each run so that
+	// overlapping invocations (e.g. a Cloud Scheduler retry) are rejected
+	// instead of inserting the same snapshot twice.
+	RunLock bool `yaml:"run_lock"`
+	// Schedule is the cron expression (in Timezone) the fetch is triggered
+	// on, e.g. by Cloud Scheduler. A run serves the channels whose own
+	// schedule came due since the previous trigger.
+	Schedule string `yaml:"schedule"`
+	// CatchUp runs the fetch, at server or job start, once for each recent
+	// date on which Schedule fired but no run succeeded (e.g. because the
+	// service was down), with dt backdated to that date. POST /catchup does
+	// the same on demand.
+	CatchUp bool `yaml:"catch_up"`
+	// CatchUpMaxDays is how many of the most recent missed dates are caught
+	// up; each costs a full run of quota.
+	CatchUpMaxDays int `yaml:"catch_up_max_days"`
+	// AutoDisableAfter skips a channel once it has failed this many runs in
+	// a row (tracked in the channel_health table) until it is re-enabled
+	// with POST /channels/{id}/enable. Zero keeps failing channels.
+	AutoDisableAfter int `yaml:"auto_disable_after"`
 }
 
 // YouTubeConfig contains YouTube API settings
 type YouTubeConfig struct {
-	APIKey         string        `yaml:"api_key"`
-	QuotaLimit     int           `yaml:"quota_limit"`
+	APIKey     string `yaml:"api_key"`
+	QuotaLimit int    `yaml:"quota_limit"`
+	// RunQuotaBudget caps the quota units a single run is estimated to
+	// spend; the quota left of QuotaLimit caps it as well. 0 only applies
+	// QuotaLimit.
+	RunQuotaBudget int `yaml:"run_quota_budget"`
+	// OverBudget is what a run whose estimate exceeds its budget does, one
+	// of the OverBudget* constants.
+	OverBudget string `yaml:"over_budget"`
+	// QuotaLedger, when set, keeps the quota spent on each quota day in
+	// firestore://<collection> or gs://<bucket>[/<prefix>], so that every
+	// instance and manual run sharing the API key draws on one QuotaLimit.
+	QuotaLedger    string        `yaml:"quota_ledger"`
 	RequestTimeout time.Duration `yaml:"request_timeout"`
 	MaxRetries     int           `yaml:"max_retries"`
 	RetryDelay     time.Duration `yaml:"retry_delay"`
+	// ResponseCacheSize is how many channels.list and playlistItems.list
+	// responses are kept with their ETags so that repeated requests are
+	// conditional (0 disables the cache).
+	ResponseCacheSize int `yaml:"response_cache_size"`
+	// ResponseCacheFile, when set, persists the response cache across
+	// restarts: it is loaded on first use and saved after each run.
+	ResponseCacheFile string `yaml:"response_cache_file"`
+	// DisabledParts lists optional videos.list parts (the VideoPart*
+	// constants) not to request for any channel.
+	DisabledParts []string `yaml:"disabled_parts"`
+	// RateLimitQPS caps the API requests per second of the whole process,
+	// shared by all channel fetches (0 disables the limiter).
+	RateLimitQPS float64 `yaml:"rate_limit_qps"`
+	// RateLimitBurst is how many requests may be made at once before the
+	// RateLimitQPS pace applies.
+	RateLimitBurst int `yaml:"rate_limit_burst"`
+	// CategoryRegions are the regions (ISO 3166-1 alpha-2) whose video
+	// categories are fetched once per run to fill category_name and the
+	// video_categories table; a category ID takes the name of the first
+	// region that has it. Empty disables the lookup.
+	CategoryRegions []string `yaml:"category_regions"`
+	// CategoryLanguage is the language of the category names (e.g. "ja";
+	// empty for English).
+	CategoryLanguage string `yaml:"category_language"`
+	// VerifyAPIKey checks at startup, with one i18nLanguages.list call (1
+	// quota unit) per key, that the API keys are valid and enabled for the
+	// Data API, and fails runs early with the fix while a key is refused.
+	VerifyAPIKey bool `yaml:"verify_api_key"`
+	// VerifyChannels checks at startup, with channels.list and
+	// playlists.list calls (1 quota unit per 50 IDs), that every configured
+	// channel and playlist exists, and lists the ones that do not.
+	VerifyChannels bool `yaml:"verify_channels"`
 }
 
+// Actions of a run estimated to exceed its quota budget
+const (
+	// OverBudgetTrim defers the lowest-priority channels that do not fit.
+	OverBudgetTrim = "trim"
+	// OverBudgetRefuse fails the run before anything is fetched.
+	OverBudgetRefuse = "refuse"
+)
+
+// Optional videos.list parts. snippet, statistics, status and player are
+// always requested.
+const (
+	// VideoPartContentDetails holds the duration; without it Shorts are
+	// classified by hashtag and aspect ratio only and duration_sec is 0.
+	VideoPartContentDetails = "contentDetails"
+	// VideoPartTopicDetails holds the topic_details categories.
+	VideoPartTopicDetails = "topicDetails"
+)
+
 // GCPConfig contains Google Cloud Platform settings
 type GCPConfig struct {
 	ProjectID      string `yaml:"project_id"`
@@ -65,6 +241,56 @@ type BigQueryConfig struct {
 	Location     string        `yaml:"location"`
 	BatchSize    int           `yaml:"batch_size"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// SchemaPath, when set, is a BigQuery JSON schema file used instead of
+	// the built-in video trends schema, e.g. to add custom columns.
+	SchemaPath string `yaml:"schema_path"`
+	// PartitionExpirationDays deletes snapshot partitions once they are
+	// this many days old; 0 keeps them forever.
+	PartitionExpirationDays int `yaml:"partition_expiration_days"`
+	// TableExpiration deletes the whole video trends table at this time
+	// (YYYY-MM-DD or RFC 3339), e.g. for a trial deployment; empty never.
+	TableExpiration string `yaml:"table_expiration"`
+	// RequirePartitionFilter makes BigQuery reject queries on the table
+	// that do not filter on dt, guarding against full scans.
+	RequirePartitionFilter bool `yaml:"require_partition_filter"`
+	// SpillBuffer is where video stats and tombstone rows that could not be
+	// inserted are kept until replayed by POST /flush or the flush
+	// command: gs://<bucket>[/<prefix>] or a local directory. Empty fails
+	// the channel as before.
+	SpillBuffer string `yaml:"spill_buffer"`
+	// ScheduledQueries are created or updated in bigquery.location by
+	// --migrate, e.g. to dedup snapshots or maintain rollup tables.
+	ScheduledQueries []ScheduledQueryConfig `yaml:"scheduled_queries"`
+	// Disabled writes the records of a run to the sinks only, e.g. to S3
+	// for Athena. Features that read BigQuery are unavailable, and
+	// deletions and metadata changes are not detected since previous
+	// snapshots cannot be looked up.
+	Disabled bool `yaml:"disabled"`
+}
+
+// ScheduledQueryConfig is a BigQuery scheduled query provisioned by the
+// binary. The query may reference ${PROJECT_ID}, ${DATASET_ID} and
+// ${TABLE_ID}.
+type ScheduledQueryConfig struct {
+	// Name identifies the scheduled query in the project; renaming one
+	// creates a new query and leaves the old one in place.
+	Name     string `yaml:"name"`
+	Query    string `yaml:"query"`
+	Schedule string `yaml:"schedule,omitempty"`
+	// DestinationTable is the table in dataset_id the results replace or
+	// are appended to; DML queries such as a dedup leave it empty.
+	DestinationTable  string `yaml:"destination_table,omitempty"`
+	WriteDisposition  string `yaml:"write_disposition,omitempty"`
+	PartitioningField string `yaml:"partitioning_field,omitempty"`
+	// Enabled false pauses the scheduled query.
+	Enabled bool `yaml:"enabled"`
+}
+
+// PubSubConfig contains settings for dispatching per-channel tasks
+type PubSubConfig struct {
+	// TopicID is the topic /dispatch publishes one message per channel to.
+	// Workers receive them through a push subscription on /tasks/channel.
+	TopicID string `yaml:"topic_id"`
 }
 
 // ServerConfig contains HTTP server settings
@@ -74,6 +300,26 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 	MaxHeaderBytes  int           `yaml:"max_header_bytes"`
+	// APICacheURL, when set, caches query API responses in Redis
+	// (redis://[:password@]host:port[/db], or rediss:// for TLS) so that
+	// repeated page views of a dashboard do not each run a BigQuery query.
+	// Cached responses are dropped after every fetch run.
+	APICacheURL string `yaml:"api_cache_url"`
+	// APICacheTTL is how long a cached response is served.
+	APICacheTTL time.Duration `yaml:"api_cache_ttl"`
+	// TriggerInterval is the minimum time between two accepted requests to
+	// the same endpoint that starts a fetch (/, /retry, /catchup,
+	// /dispatch, /tenants/{id}/fetch per tenant); sooner ones get 429. 0
+	// disables the limit.
+	TriggerInterval time.Duration `yaml:"trigger_interval"`
+	// GRPCPort, when set, also serves the gRPC API (tracker.v1.TrackerService)
+	// on this port, on localhost only unless AdminToken is set. Empty
+	// disables it.
+	GRPCPort string `yaml:"grpc_port"`
+	// AdminToken enables the /admin endpoints for requests that send it in
+	// the X-Admin-Token header, and is required of every gRPC call. Empty
+	// disables the endpoints.
+	AdminToken string `yaml:"admin_token"`
 }
 
 // LoggingConfig contains logging settings
@@ -81,14 +327,365 @@ type LoggingConfig struct {
 	Level      string `yaml:"level"`
 	Format     string `yaml:"format"`
 	OutputPath string `yaml:"output_path"`
+	// ErrorReporting forwards ERROR and FATAL entries to an error tracker:
+	// "cloud" for Cloud Error Reporting or "sentry". Empty disables it.
+	ErrorReporting string `yaml:"error_reporting"`
+	// SentryDSN is required when ErrorReporting is "sentry".
+	SentryDSN string `yaml:"sentry_dsn"`
+}
+
+// MonitoringConfig contains settings for exporting metrics besides the
+// Prometheus /metrics endpoint
+type MonitoringConfig struct {
+	// CloudMonitoring pushes the key metrics (videos processed, errors,
+	// quota remaining, last run time) to Cloud Monitoring after each run.
+	CloudMonitoring bool `yaml:"cloud_monitoring"`
+	// PushgatewayURL pushes the ytt_* metrics to a Prometheus Pushgateway at
+	// the end of a job-mode run, where nothing can scrape /metrics. Empty
+	// disables it.
+	PushgatewayURL string `yaml:"pushgateway_url"`
+}
+
+// AnalyticsConfig contains settings for scores computed after each run
+type AnalyticsConfig struct {
+	// TrendScore computes a score per video into video_trend_scores after
+	// each full run.
+	TrendScore bool `yaml:"trend_score"`
+	// TrendFormula selects the scoring formula, one of the TrendFormula*
+	// constants.
+	TrendFormula string `yaml:"trend_formula"`
+	// TrendGravity is the age exponent of the decayed formula: higher values
+	// favour newer videos more strongly.
+	TrendGravity float64 `yaml:"trend_gravity"`
+	// Forecast stores each scored video's projected views 7 and 30 days
+	// ahead with its trend score.
+	Forecast bool `yaml:"forecast"`
+	// TagTrends aggregates tags and title/description hashtags into
+	// tag_trends after each full run.
+	TagTrends bool `yaml:"tag_trends"`
+	// ChannelDailyStats aggregates per-channel daily totals into
+	// channel_daily_stats after each full run.
+	ChannelDailyStats bool `yaml:"channel_daily_stats"`
+	// PublishTimes averages the early view velocity of each channel's
+	// recent videos by publish weekday and hour into publish_time_stats
+	// after each full run.
+	PublishTimes bool `yaml:"publish_times"`
+	// SubscriberMilestones snapshots the subscriber counts of the channels
+	// of each run into channel_snapshots and records the MilestoneThresholds
+	// they reached since their previous snapshot into channel_milestones.
+	SubscriberMilestones bool `yaml:"subscriber_milestones"`
+	// MilestoneThresholds are the subscriber counts that are milestones.
+	MilestoneThresholds []int64 `yaml:"milestone_thresholds"`
+	// CommentSentiment scores the top comments of the channels with
+	// track_comments with the Cloud Natural Language API and stores the
+	// positive and negative ratios per video into video_comment_sentiment
+	// after each full run.
+	CommentSentiment bool `yaml:"comment_sentiment"`
+}
+
+// Trend score formulas
+const (
+	// TrendFormulaVelocity is views gained per hour.
+	TrendFormulaVelocity = "velocity"
+	// TrendFormulaRelative is views gained per hour relative to the channel's
+	// median views per video.
+	TrendFormulaRelative = "relative_velocity"
+	// TrendFormulaDecayed is the relative velocity divided by
+	// (age in hours + 2) ^ gravity.
+	TrendFormulaDecayed = "decayed"
+)
+
+// ReportConfig contains settings for the top-N report written after each run
+type ReportConfig struct {
+	// Destination is sheets://<spreadsheetId> for a Google Sheet or
+	// gs://<bucket>[/<prefix>] for CSV files in Cloud Storage. Empty disables
+	// the report.
+	Destination string `yaml:"destination"`
+	// TopN is the number of videos in each report table.
+	TopN int `yaml:"top_n"`
+}
+
+// DigestConfig contains settings for the channel performance email digest
+type DigestConfig struct {
+	// Provider sends the digest, one of the DigestProvider* constants. Empty
+	// disables the digest.
+	Provider string `yaml:"provider"`
+	// Period is the range a digest covers, one of the DigestPeriod*
+	// constants. When it is sent is up to the caller of /digest.
+	Period     string   `yaml:"period"`
+	Recipients []string `yaml:"recipients"`
+	From       string   `yaml:"from"`
+	// SMTP settings, used when Provider is "smtp". STARTTLS is used when the
+	// server offers it.
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	// SendGridAPIKey is required when Provider is "sendgrid".
+	SendGridAPIKey string `yaml:"sendgrid_api_key"`
+}
+
+// NotificationsConfig contains the rules evaluated for the videos of each
+// run and the actions they notify
+type NotificationsConfig struct {
+	// Actions are the destinations of notifications, referred to by name
+	// from the rules.
+	Actions []NotifyActionConfig `yaml:"actions"`
+	// Rules are evaluated after each run that wrote to BigQuery. A video
+	// matching several rules is notified once by each.
+	Rules []NotifyRuleConfig `yaml:"rules"`
+	// NewUploads notifies the videos uploaded since the previous run
+	// without writing a rule for them.
+	NewUploads NewUploadsConfig `yaml:"new_uploads"`
+}
+
+// NewUploadsConfig notifies actions of each video first snapshotted within
+// a week of its publication, as the implicit rule NewUploadsRuleName
+type NewUploadsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Channels limits the notifications to these channel IDs, such as
+	// competitors; empty notifies uploads of every channel.
+	Channels []string `yaml:"channels"`
+	// Actions are names of notifications.actions. They may be left out
+	// when webhooks receive video.uploaded events.
+	Actions []string `yaml:"actions"`
+}
+
+// NewUploadsRuleName is the name of the rule notifications.new_uploads
+// adds. No rule of notifications.rules may be named so.
+const NewUploadsRuleName = "new_upload"
+
+// NotifyActionConfig is a destination of notifications
+type NotifyActionConfig struct {
+	Name string `yaml:"name"`
+	// Type is one of the NotifyAction* constants.
+	Type string `yaml:"type"`
+	// URL is the Slack incoming webhook URL, or the endpoint a webhook
+	// action POSTs JSON to.
+	URL string `yaml:"url"`
+	// Topic is the Pub/Sub topic, in gcp.project_id, a pubsub action
+	// publishes JSON to.
+	Topic string `yaml:"topic"`
+	// To are the recipients of an email action, sent through the digest
+	// provider.
+	To []string `yaml:"to"`
+}
+
+// NotifyRuleConfig notifies actions of the videos matching a condition
+type NotifyRuleConfig struct {
+	Name string `yaml:"name"`
+	// When is the condition, such as "views_gained_24h > 100000" or
+	// "new_upload and is_short"; rules.VideoKinds lists the variables.
+	When string `yaml:"when"`
+	// Actions are names of notifications.actions. They may be left out
+	// when webhooks receive trend.detected events, which every rule sends.
+	Actions []string `yaml:"actions"`
+	// Cooldown is how long a video the rule notified is not notified by it
+	// again; 0 is DefaultNotifyCooldown.
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// Notification action types
+const (
+	NotifyActionSlack   = "slack"
+	NotifyActionWebhook = "webhook"
+	NotifyActionPubSub  = "pubsub"
+	NotifyActionEmail   = "email"
+)
+
+// DefaultNotifyCooldown is the cooldown of a rule that sets none.
+const DefaultNotifyCooldown = 24 * time.Hour
+
+// WebhooksActionName is the action every notification rule implicitly
+// uses when webhooks receive trend.detected events, and the new_uploads
+// rule when they receive video.uploaded events. No action of
+// notifications.actions may be named so.
+const WebhooksActionName = "webhooks"
+
+// WebhooksConfig contains the endpoints run summaries and trend events are
+// POSTed to, and how failed deliveries are retried
+type WebhooksConfig struct {
+	Endpoints []WebhookEndpointConfig `yaml:"endpoints"`
+	// MaxAttempts, InitialBackoff and MaxBackoff bound the retries of a
+	// delivery, whose delay doubles from InitialBackoff up to MaxBackoff.
+	// Only network errors, 429 and 5xx responses are retried.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	// DeadLetter is where the deliveries that failed every attempt are kept
+	// as JSONL: gs://<bucket>[/<prefix>] or a local directory. Empty only
+	// logs them.
+	DeadLetter string `yaml:"dead_letter"`
 }
 
+// WebhookEndpointConfig is a URL webhook events are POSTed to
+type WebhookEndpointConfig struct {
+	URL string `yaml:"url"`
+	// Secret signs each delivery with HMAC-SHA256 in the
+	// X-Webhook-Signature header; empty sends it unsigned.
+	Secret string `yaml:"secret"`
+	// Events are the WebhookEvent* types sent to the endpoint; empty sends
+	// all of them.
+	Events []string `yaml:"events"`
+}
+
+// Webhook event types
+const (
+	// WebhookEventRunCompleted carries the summary of each finished run.
+	WebhookEventRunCompleted = "run.completed"
+	// WebhookEventTrendDetected carries the videos matched by the
+	// notification rules after a run.
+	WebhookEventTrendDetected = "trend.detected"
+	// WebhookEventVideoUploaded carries the new uploads notified by
+	// notifications.new_uploads after a run.
+	WebhookEventVideoUploaded = "video.uploaded"
+	// WebhookEventChannelMilestone carries the subscriber milestones
+	// reached by the channels of a run.
+	WebhookEventChannelMilestone = "channel.milestone"
+)
+
+// webhookEvents are the WebhookEvent* types.
+var webhookEvents = []string{WebhookEventRunCompleted, WebhookEventTrendDetected, WebhookEventVideoUploaded, WebhookEventChannelMilestone}
+
+// Subscribed reports whether an endpoint receives events of eventType.
+func (w *WebhooksConfig) Subscribed(eventType string) bool {
+	for _, e := range w.Endpoints {
+		if len(e.Events) == 0 || slices.Contains(e.Events, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SchedulerConfig describes the Cloud Scheduler job that triggers the
+// fetch. The job runs on app.schedule in app.timezone, in gcp.region.
+type SchedulerConfig struct {
+	// JobName is the job ID within the region.
+	JobName string `yaml:"job_name"`
+	// TargetURL is the URL of the Cloud Run service the job POSTs to.
+	TargetURL string `yaml:"target_url"`
+	// ServiceAccount is the identity whose OIDC token authenticates the
+	// request; empty uses scheduler-sa@<project>.iam.gserviceaccount.com.
+	ServiceAccount string `yaml:"service_account"`
+	// AttemptDeadline is how long the job waits for the fetch to respond
+	// (15s to 30m).
+	AttemptDeadline time.Duration `yaml:"attempt_deadline"`
+	// RetryCount is how many times a failed trigger is retried, waiting
+	// from MinBackoff to MaxBackoff between attempts.
+	RetryCount int           `yaml:"retry_count"`
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// SchedulerServiceAccount returns the service account of the scheduler
+// job.
+func (c *Config) SchedulerServiceAccount() string {
+	if c.Scheduler.ServiceAccount != "" {
+		return c.Scheduler.ServiceAccount
+	}
+	return "scheduler-sa@" + c.GCP.ProjectID + ".iam.gserviceaccount.com"
+}
+
+// Digest providers
+const (
+	DigestProviderSMTP     = "smtp"
+	DigestProviderSendGrid = "sendgrid"
+)
+
+// Digest periods
+const (
+	// DigestPeriodDaily covers yesterday.
+	DigestPeriodDaily = "daily"
+	// DigestPeriodWeekly covers the seven days up to yesterday.
+	DigestPeriodWeekly = "weekly"
+)
+
+// Error reporting providers
+const (
+	ErrorReportingCloud  = "cloud"
+	ErrorReportingSentry = "sentry"
+)
+
+// Run modes
+const (
+	// RunModeServer starts the HTTP server and fetches on each request
+	RunModeServer = "server"
+	// RunModeJob runs a single fetch and exits
+	RunModeJob = "job"
+)
+
+// KeywordConfig represents a search query whose top results are tracked.
+// Each keyword costs 100 quota units per fetch (one search.list call).
+type KeywordConfig struct {
+	Query      string `yaml:"query"`
+	MaxResults int64  `yaml:"max_results,omitempty"`
+	Enabled    bool   `yaml:"enabled"`
+}
+
+// TenantConfig is a client tracked by a shared deployment, e.g. one of an
+// agency's customers. A tenant run uses the top-level settings except for
+// the channels, keywords, API key and dataset below; its records are not
+// copied to the sinks, the spill buffer or the report, which are shared.
+type TenantConfig struct {
+	// ID identifies the tenant in /tenants/{id}/fetch: lowercase letters,
+	// digits, '-' and '_'.
+	ID   string `yaml:"id"`
+	Name string `yaml:"name,omitempty"`
+	// APIKey is the tenant's YouTube Data API key; empty uses
+	// youtube.api_key. A tenant with its own key has its own daily quota,
+	// which is not counted against youtube.quota_limit.
+	APIKey string `yaml:"api_key,omitempty"`
+	// DatasetID is the BigQuery dataset the tenant's tables are written
+	// to. It must differ from bigquery.dataset_id and from the other
+	// tenants' datasets.
+	DatasetID string          `yaml:"dataset_id"`
+	Channels  []ChannelConfig `yaml:"channels"`
+	Keywords  []KeywordConfig `yaml:"keywords,omitempty"`
+}
+
+// DefaultKeywordMaxResults is used when a keyword does not set max_results.
+const DefaultKeywordMaxResults = 10
+
 // ChannelConfig represents a YouTube channel to monitor
 type ChannelConfig struct {
-	ID          string `yaml:"id"`
-	Name        string `yaml:"name,omitempty"`
-	Description string `yaml:"description,omitempty"`
-	Enabled     bool   `yaml:"enabled"`
+	// ID is the channel ID, or the playlist ID for a playlist entry.
+	ID          string `yaml:"id" json:"id"`
+	Name        string `yaml:"name,omitempty" json:"name,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Enabled     bool   `yaml:"enabled" json:"enabled"`
+	// TrackComments captures the top comments of this channel's videos
+	// (one extra quota unit per video).
+	TrackComments bool `yaml:"track_comments,omitempty" json:"track_comments,omitempty"`
+	// DisabledParts lists optional videos.list parts not to request for
+	// this channel, in addition to youtube.disabled_parts.
+	DisabledParts []string `yaml:"disabled_parts,omitempty" json:"disabled_parts,omitempty"`
+	// Priority orders processing: higher first, equal priorities in list
+	// order. When the remaining quota will not cover every channel, the
+	// lowest-priority channels are deferred to a later run.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+	// Type is "channel" (the default), which tracks the channel's uploads,
+	// or "playlist", which tracks the videos of any playlist, such as a
+	// curated "best of" list, with the same snapshot pipeline.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// Schedule is a cron expression (in app.timezone) for fetching this
+	// channel less often than every run, e.g. "0 */6 * * *"; empty fetches
+	// it on every run.
+	Schedule string `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
+	// pos is where the entry was defined, e.g. "configs/config.yaml:12",
+	// for error messages; empty when unknown.
+	pos string
+}
+
+// Channel entry types.
+const (
+	ChannelTypeChannel  = "channel"
+	ChannelTypePlaylist = "playlist"
+)
+
+// IsPlaylist reports whether the entry tracks a playlist.
+func (ch ChannelConfig) IsPlaylist() bool {
+	return ch.Type == ChannelTypePlaylist
 }
 
 // DefaultConfig returns a configuration with default values
@@ -96,14 +693,26 @@ func DefaultConfig() *Config {
 	return &Config{
 		App: AppConfig{
 			Environment:         "development",
+			RunMode:             RunModeServer,
 			MaxVideosPerChannel: 10,
 			FetchTimeout:        5 * time.Minute,
+			TopCommentsPerVideo: 20,
+			Timezone:            "Asia/Tokyo",
+			ChannelConfigTTL:    10 * time.Minute,
+			Schedule:            "0 * * * *",
+			CatchUpMaxDays:      3,
 		},
 		YouTube: YouTubeConfig{
-			QuotaLimit:     10000,
-			RequestTimeout: 30 * time.Second,
-			MaxRetries:     5,
-			RetryDelay:     1 * time.Second,
+			QuotaLimit:        10000,
+			OverBudget:        OverBudgetTrim,
+			RequestTimeout:    30 * time.Second,
+			MaxRetries:        5,
+			RetryDelay:        1 * time.Second,
+			ResponseCacheSize: 1000,
+			RateLimitQPS:      10,
+			RateLimitBurst:    20,
+			CategoryRegions:   []string{"JP"},
+			CategoryLanguage:  "ja",
 		},
 		GCP: GCPConfig{
 			Region: "asia-northeast1",
@@ -121,55 +730,194 @@ func DefaultConfig() *Config {
 			WriteTimeout:    10 * time.Second,
 			ShutdownTimeout: 30 * time.Second,
 			MaxHeaderBytes:  1 << 20, // 1 MB
+			APICacheTTL:     time.Minute,
+			TriggerInterval: 10 * time.Second,
 		},
 		Logging: LoggingConfig{
 			Level:      "info",
 			Format:     "json",
 			OutputPath: "stdout",
 		},
+		Analytics: AnalyticsConfig{
+			TrendFormula:        TrendFormulaDecayed,
+			TrendGravity:        0.5,
+			MilestoneThresholds: []int64{100000, 1000000, 10000000},
+		},
+		Report: ReportConfig{
+			TopN: 20,
+		},
+		Digest: DigestConfig{
+			Period:   DigestPeriodDaily,
+			SMTPPort: 587,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Minute,
+		},
+		Scheduler: SchedulerConfig{
+			JobName:         "trend-tracker-hourly",
+			AttemptDeadline: 10 * time.Minute,
+			RetryCount:      1,
+			MinBackoff:      30 * time.Second,
+			MaxBackoff:      5 * time.Minute,
+		},
 		Channels: []ChannelConfig{},
 	}
 }
 
 // Load loads configuration from multiple sources with priority:
 // 1. Environment variables (highest priority)
-// 2. Configuration file
-// 3. Default values (lowest priority)
+// 2. The profile file for GO_ENV, e.g. config.production.yaml
+// 3. Configuration file
+// 4. Default values (lowest priority)
+//
+// Command-line flags, when used, take precedence over all of these; see
+// Flags.Load.
 func Load(configPath string) (*Config, error) {
+	cfg, err := LoadUnvalidated(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadUnvalidated loads configuration like Load but skips validation.
+// It is intended for tooling commands that only need a subset of settings.
+func LoadUnvalidated(configPath string) (*Config, error) {
 	// Start with default configuration
 	cfg := DefaultConfig()
 
-	// Load from configuration file if provided
+	// Load from configuration file if provided, then its profile overlay
 	if configPath != "" {
 		if err := loadFromFile(cfg, configPath); err != nil {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
+		if profile := ProfilePath(configPath, os.Getenv("GO_ENV")); profile != "" {
+			if _, err := os.Stat(profile); err == nil {
+				if err := loadFromFile(cfg, profile); err != nil {
+					return nil, fmt.Errorf("failed to load profile config file: %w", err)
+				}
+			}
+		}
 	}
 
 	// Override with environment variables
 	loadFromEnv(cfg)
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
-
 	return cfg, nil
 }
 
-// loadFromFile loads configuration from a YAML file
+// ProfilePath returns the overlay of configPath for the environment env:
+// configs/config.yaml and "production" give configs/config.production.yaml.
+// It returns "" when env is empty.
+func ProfilePath(configPath, env string) string {
+	if env == "" {
+		return ""
+	}
+	ext := filepath.Ext(configPath)
+	return strings.TrimSuffix(configPath, ext) + "." + env + ext
+}
+
+// loadFromFile loads configuration from a YAML file over cfg, after the
+// files it includes. ${NAME} placeholders are expanded (see expandEnv).
+// Settings merge deeply: a file sets only the keys it has, down to single
+// fields of nested sections, while a list, such as channels, replaces the
+// list as a whole.
 func loadFromFile(cfg *Config, path string) error {
-	file, err := os.Open(path)
+	return loadFile(cfg, path, nil)
+}
+
+// loadFile is loadFromFile; stack holds the files being included, to
+// reject include cycles.
+func loadFile(cfg *Config, path string, stack []string) error {
+	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if slices.Contains(stack, abs) {
+		return fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+	}
 
-	decoder := yaml.NewDecoder(file)
-	if err := decoder.Decode(cfg); err != nil {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to decode YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil // empty file
+	}
+	if err := expandEnv(&doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var head struct {
+		Include []string `yaml:"include"`
+	}
+	if err := doc.Decode(&head); err != nil {
 		return fmt.Errorf("failed to decode YAML: %w", err)
 	}
+	for _, inc := range head.Include {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		if err := loadFile(cfg, inc, append(stack, abs)); err != nil {
+			return fmt.Errorf("%s: include %s: %w", path, inc, err)
+		}
+	}
+
+	if err := doc.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to decode YAML: %w", err)
+	}
+	cfg.Include = head.Include
+	if mappingValue(doc.Content[0], "channels") != nil {
+		cfg.channelsFrom = path
+	}
+	markChannelPositions(cfg, doc.Content[0], path)
+	return nil
+}
+
+// markChannelPositions records the file and line of the channel entries
+// that the file at path defined, from its document root.
+func markChannelPositions(cfg *Config, root *yaml.Node, path string) {
+	mark := func(channels []ChannelConfig, seq *yaml.Node) {
+		for i, item := range seq.Content {
+			if i < len(channels) {
+				channels[i].pos = fmt.Sprintf("%s:%d", path, item.Line)
+			}
+		}
+	}
+	if seq := mappingValue(root, "channels"); seq != nil && seq.Kind == yaml.SequenceNode {
+		mark(cfg.Channels, seq)
+	}
+	if seq := mappingValue(root, "tenants"); seq != nil && seq.Kind == yaml.SequenceNode {
+		for i, tenant := range seq.Content {
+			if ch := mappingValue(tenant, "channels"); i < len(cfg.Tenants) && ch != nil && ch.Kind == yaml.SequenceNode {
+				mark(cfg.Tenants[i].Channels, ch)
+			}
+		}
+	}
+}
 
+// mappingValue returns the value of key in the mapping node n, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
 	return nil
 }
 
@@ -184,16 +932,140 @@ func loadFromEnv(cfg *Config) {
 	if env := os.Getenv("GO_ENV"); env != "" {
 		cfg.App.Environment = env
 	}
+	if env := os.Getenv("RUN_MODE"); env != "" {
+		cfg.App.RunMode = strings.ToLower(env)
+	}
 	if env := os.Getenv("MAX_VIDEOS_PER_CHANNEL"); env != "" {
 		if val, err := strconv.ParseInt(env, 10, 64); err == nil {
 			cfg.App.MaxVideosPerChannel = val
 		}
 	}
+	if env := os.Getenv("STATUS_LOOKBACK_DAYS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.App.StatusLookbackDays = val
+		}
+	}
+	if env := os.Getenv("TRACK_METADATA_CHANGES"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.App.TrackMetadataChanges = val
+		}
+	}
+	if env := os.Getenv("TOP_COMMENTS_PER_VIDEO"); env != "" {
+		if val, err := strconv.ParseInt(env, 10, 64); err == nil {
+			cfg.App.TopCommentsPerVideo = val
+		}
+	}
+	if env := os.Getenv("THUMBNAIL_ARCHIVE"); env != "" {
+		cfg.App.ThumbnailArchive = env
+	}
+	if env := os.Getenv("SHORTS_URL_CHECK"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.App.ShortsURLCheck = val
+		}
+	}
+	if env := os.Getenv("DRY_RUN"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.App.DryRun = val
+		}
+	}
+	if env := os.Getenv("APP_TIMEZONE"); env != "" {
+		cfg.App.Timezone = env
+	}
+	if env := os.Getenv("FETCH_SCHEDULE"); env != "" {
+		cfg.App.Schedule = env
+	}
+	if env := os.Getenv("CATCH_UP"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.App.CatchUp = val
+		}
+	}
+	if env := os.Getenv("CATCH_UP_MAX_DAYS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.App.CatchUpMaxDays = val
+		}
+	}
+	if env := os.Getenv("AUTO_DISABLE_AFTER"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.App.AutoDisableAfter = val
+		}
+	}
+
+	if env := os.Getenv("CHANNEL_CONFIG_SOURCE"); env != "" {
+		cfg.App.ChannelConfigSource = env
+	}
+	if env := os.Getenv("CHANNEL_CONFIG_TTL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil {
+			cfg.App.ChannelConfigTTL = val
+		}
+	}
+	if env := os.Getenv("RUN_LOCK"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.App.RunLock = val
+		}
+	}
 
 	// YouTube settings
 	if env := os.Getenv("YOUTUBE_API_KEY"); env != "" {
 		cfg.YouTube.APIKey = env
 	}
+	if env := os.Getenv("YOUTUBE_RESPONSE_CACHE_SIZE"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.YouTube.ResponseCacheSize = val
+		}
+	}
+	if env := os.Getenv("YOUTUBE_RESPONSE_CACHE_FILE"); env != "" {
+		cfg.YouTube.ResponseCacheFile = env
+	}
+	if env := os.Getenv("YOUTUBE_RUN_QUOTA_BUDGET"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.YouTube.RunQuotaBudget = val
+		}
+	}
+	if env := os.Getenv("YOUTUBE_OVER_BUDGET"); env != "" {
+		cfg.YouTube.OverBudget = env
+	}
+	if env := os.Getenv("YOUTUBE_QUOTA_LEDGER"); env != "" {
+		cfg.YouTube.QuotaLedger = env
+	}
+	if env := os.Getenv("YOUTUBE_RATE_LIMIT_QPS"); env != "" {
+		if val, err := strconv.ParseFloat(env, 64); err == nil {
+			cfg.YouTube.RateLimitQPS = val
+		}
+	}
+	if env := os.Getenv("YOUTUBE_RATE_LIMIT_BURST"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.YouTube.RateLimitBurst = val
+		}
+	}
+	if env, ok := os.LookupEnv("YOUTUBE_CATEGORY_REGIONS"); ok {
+		cfg.YouTube.CategoryRegions = nil
+		for _, r := range strings.Split(env, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				cfg.YouTube.CategoryRegions = append(cfg.YouTube.CategoryRegions, strings.ToUpper(r))
+			}
+		}
+	}
+	if env, ok := os.LookupEnv("YOUTUBE_CATEGORY_LANGUAGE"); ok {
+		cfg.YouTube.CategoryLanguage = env
+	}
+	if env := os.Getenv("YOUTUBE_VERIFY_API_KEY"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.YouTube.VerifyAPIKey = val
+		}
+	}
+	if env := os.Getenv("YOUTUBE_VERIFY_CHANNELS"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.YouTube.VerifyChannels = val
+		}
+	}
+	if env := os.Getenv("YOUTUBE_DISABLED_PARTS"); env != "" {
+		cfg.YouTube.DisabledParts = nil
+		for _, p := range strings.Split(env, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.YouTube.DisabledParts = append(cfg.YouTube.DisabledParts, p)
+			}
+		}
+	}
 
 	// GCP settings
 	if env := os.Getenv("GOOGLE_CLOUD_PROJECT"); env != "" {
@@ -213,11 +1085,51 @@ func loadFromEnv(cfg *Config) {
 	if env := os.Getenv("BIGQUERY_TABLE"); env != "" {
 		cfg.BigQuery.TableID = env
 	}
+	if env := os.Getenv("BIGQUERY_SCHEMA_PATH"); env != "" {
+		cfg.BigQuery.SchemaPath = env
+	}
+	if env := os.Getenv("BIGQUERY_PARTITION_EXPIRATION_DAYS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.BigQuery.PartitionExpirationDays = val
+		}
+	}
+	if env := os.Getenv("BIGQUERY_TABLE_EXPIRATION"); env != "" {
+		cfg.BigQuery.TableExpiration = env
+	}
+	if env := os.Getenv("BIGQUERY_REQUIRE_PARTITION_FILTER"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.BigQuery.RequirePartitionFilter = val
+		}
+	}
+
+	// Pub/Sub settings
+	if env := os.Getenv("PUBSUB_TOPIC"); env != "" {
+		cfg.PubSub.TopicID = env
+	}
 
 	// Server settings
 	if env := os.Getenv("PORT"); env != "" {
 		cfg.Server.Port = env
 	}
+	if env := os.Getenv("API_CACHE_URL"); env != "" {
+		cfg.Server.APICacheURL = env
+	}
+	if env := os.Getenv("API_CACHE_TTL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil {
+			cfg.Server.APICacheTTL = val
+		}
+	}
+	if env := os.Getenv("TRIGGER_INTERVAL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil {
+			cfg.Server.TriggerInterval = val
+		}
+	}
+	if env := os.Getenv("GRPC_PORT"); env != "" {
+		cfg.Server.GRPCPort = env
+	}
+	if env := os.Getenv("ADMIN_TOKEN"); env != "" {
+		cfg.Server.AdminToken = env
+	}
 
 	// Logging settings
 	if env := os.Getenv("LOG_LEVEL"); env != "" {
@@ -226,6 +1138,155 @@ func loadFromEnv(cfg *Config) {
 	if env := os.Getenv("LOG_FORMAT"); env != "" {
 		cfg.Logging.Format = env
 	}
+	if env := os.Getenv("ERROR_REPORTING"); env != "" {
+		cfg.Logging.ErrorReporting = strings.ToLower(env)
+	}
+	if env := os.Getenv("SENTRY_DSN"); env != "" {
+		cfg.Logging.SentryDSN = env
+	}
+
+	// Monitoring settings
+	if env := os.Getenv("CLOUD_MONITORING"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Monitoring.CloudMonitoring = val
+		}
+	}
+	if env := os.Getenv("PUSHGATEWAY_URL"); env != "" {
+		cfg.Monitoring.PushgatewayURL = env
+	}
+
+	// Analytics settings
+	if env := os.Getenv("TREND_SCORE"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.TrendScore = val
+		}
+	}
+	if env := os.Getenv("TREND_FORMULA"); env != "" {
+		cfg.Analytics.TrendFormula = env
+	}
+	if env := os.Getenv("TREND_GRAVITY"); env != "" {
+		if val, err := strconv.ParseFloat(env, 64); err == nil {
+			cfg.Analytics.TrendGravity = val
+		}
+	}
+	if env := os.Getenv("FORECAST"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.Forecast = val
+		}
+	}
+	if env := os.Getenv("TAG_TRENDS"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.TagTrends = val
+		}
+	}
+	if env := os.Getenv("CHANNEL_DAILY_STATS"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.ChannelDailyStats = val
+		}
+	}
+	if env := os.Getenv("PUBLISH_TIMES"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.PublishTimes = val
+		}
+	}
+	if env := os.Getenv("SUBSCRIBER_MILESTONES"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.SubscriberMilestones = val
+		}
+	}
+	if env := os.Getenv("COMMENT_SENTIMENT"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.Analytics.CommentSentiment = val
+		}
+	}
+
+	if env := os.Getenv("BIGQUERY_SPILL_BUFFER"); env != "" {
+		cfg.BigQuery.SpillBuffer = env
+	}
+	if env := os.Getenv("BIGQUERY_DISABLED"); env != "" {
+		if val, err := strconv.ParseBool(env); err == nil {
+			cfg.BigQuery.Disabled = val
+		}
+	}
+	if env := os.Getenv("SINKS"); env != "" {
+		cfg.Sinks = nil
+		for _, s := range strings.Split(env, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				cfg.Sinks = append(cfg.Sinks, s)
+			}
+		}
+	}
+
+	// Webhook settings: WEBHOOK_URLS replaces the endpoints with URLs that
+	// receive every event, signed with WEBHOOK_SECRET.
+	if env := os.Getenv("WEBHOOK_URLS"); env != "" {
+		cfg.Webhooks.Endpoints = nil
+		for _, u := range strings.Split(env, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				cfg.Webhooks.Endpoints = append(cfg.Webhooks.Endpoints, WebhookEndpointConfig{URL: u, Secret: os.Getenv("WEBHOOK_SECRET")})
+			}
+		}
+	}
+	if env := os.Getenv("WEBHOOK_DEAD_LETTER"); env != "" {
+		cfg.Webhooks.DeadLetter = env
+	}
+
+	// Report settings
+	if env := os.Getenv("REPORT_DESTINATION"); env != "" {
+		cfg.Report.Destination = env
+	}
+	if env := os.Getenv("REPORT_TOP_N"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.Report.TopN = val
+		}
+	}
+
+	// Digest settings
+	if env := os.Getenv("DIGEST_PROVIDER"); env != "" {
+		cfg.Digest.Provider = strings.ToLower(env)
+	}
+	if env := os.Getenv("DIGEST_PERIOD"); env != "" {
+		cfg.Digest.Period = strings.ToLower(env)
+	}
+	if env := os.Getenv("DIGEST_RECIPIENTS"); env != "" {
+		cfg.Digest.Recipients = nil
+		for _, r := range strings.Split(env, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				cfg.Digest.Recipients = append(cfg.Digest.Recipients, r)
+			}
+		}
+	}
+	if env := os.Getenv("DIGEST_FROM"); env != "" {
+		cfg.Digest.From = env
+	}
+	if env := os.Getenv("SMTP_HOST"); env != "" {
+		cfg.Digest.SMTPHost = env
+	}
+	if env := os.Getenv("SMTP_PORT"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			cfg.Digest.SMTPPort = val
+		}
+	}
+	if env := os.Getenv("SMTP_USERNAME"); env != "" {
+		cfg.Digest.SMTPUsername = env
+	}
+	if env := os.Getenv("SMTP_PASSWORD"); env != "" {
+		cfg.Digest.SMTPPassword = env
+	}
+	if env := os.Getenv("SENDGRID_API_KEY"); env != "" {
+		cfg.Digest.SendGridAPIKey = env
+	}
+
+	// Scheduler settings
+	if env := os.Getenv("SCHEDULER_JOB_NAME"); env != "" {
+		cfg.Scheduler.JobName = env
+	}
+	if env := os.Getenv("SCHEDULER_TARGET_URL"); env != "" {
+		cfg.Scheduler.TargetURL = env
+	}
+	if env := os.Getenv("SCHEDULER_SERVICE_ACCOUNT"); env != "" {
+		cfg.Scheduler.ServiceAccount = env
+	}
 }
 
 // Validate validates the configuration
@@ -234,20 +1295,99 @@ func (c *Config) Validate() error {
 	if c.YouTube.APIKey == "" {
 		return fmt.Errorf("YouTube API key is required")
 	}
-	if c.GCP.ProjectID == "" {
+	if c.GCP.ProjectID == "" && !c.BigQuery.Disabled {
 		return fmt.Errorf("GCP project ID is required")
 	}
 
+	if c.App.RunMode != RunModeServer && c.App.RunMode != RunModeJob {
+		return fmt.Errorf("invalid run_mode: %s (must be %q or %q)", c.App.RunMode, RunModeServer, RunModeJob)
+	}
+
+	if _, err := time.LoadLocation(c.App.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", c.App.Timezone, err)
+	}
+	if _, err := scheduler.Parse(c.App.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
 	// Validate numeric ranges
 	if c.App.MaxVideosPerChannel <= 0 {
 		return fmt.Errorf("max_videos_per_channel must be positive")
 	}
+	if c.App.StatusLookbackDays < 0 {
+		return fmt.Errorf("status_lookback_days cannot be negative")
+	}
+	if c.App.CatchUpMaxDays < 0 {
+		return fmt.Errorf("catch_up_max_days cannot be negative")
+	}
+	if c.App.AutoDisableAfter < 0 {
+		return fmt.Errorf("auto_disable_after cannot be negative")
+	}
+	if c.App.AutoDisableAfter > 0 && c.BigQuery.Disabled {
+		return fmt.Errorf("auto_disable_after tracks failures in BigQuery and cannot be used with bigquery.disabled")
+	}
+	if c.App.TopCommentsPerVideo < 1 || c.App.TopCommentsPerVideo > 100 {
+		return fmt.Errorf("top_comments_per_video must be between 1 and 100")
+	}
 	if c.YouTube.MaxRetries < 0 {
 		return fmt.Errorf("max_retries cannot be negative")
 	}
+	if c.YouTube.ResponseCacheSize < 0 {
+		return fmt.Errorf("response_cache_size cannot be negative")
+	}
+	if err := validateParts(c.YouTube.DisabledParts); err != nil {
+		return err
+	}
+	if c.YouTube.RateLimitQPS < 0 {
+		return fmt.Errorf("rate_limit_qps cannot be negative")
+	}
+	if c.YouTube.RunQuotaBudget < 0 {
+		return fmt.Errorf("run_quota_budget cannot be negative")
+	}
+	if c.YouTube.OverBudget != OverBudgetTrim && c.YouTube.OverBudget != OverBudgetRefuse {
+		return fmt.Errorf("invalid over_budget %q (must be %s or %s)", c.YouTube.OverBudget, OverBudgetTrim, OverBudgetRefuse)
+	}
+	if l := c.YouTube.QuotaLedger; l != "" && !strings.HasPrefix(l, "firestore://") && !strings.HasPrefix(l, "gs://") {
+		return fmt.Errorf("invalid quota_ledger: %s (must be firestore://<collection> or gs://<bucket>[/<prefix>])", l)
+	}
+	if c.YouTube.RateLimitQPS > 0 && c.YouTube.RateLimitBurst < 1 {
+		return fmt.Errorf("rate_limit_burst must be positive when rate_limit_qps is set")
+	}
+	for _, r := range c.YouTube.CategoryRegions {
+		if len(r) != 2 || strings.ToUpper(r) != r {
+			return fmt.Errorf("invalid category region %q (must be an ISO 3166-1 alpha-2 code such as JP)", r)
+		}
+	}
 	if c.BigQuery.BatchSize <= 0 {
 		return fmt.Errorf("batch_size must be positive")
 	}
+	if c.BigQuery.PartitionExpirationDays < 0 {
+		return fmt.Errorf("partition_expiration_days cannot be negative")
+	}
+	if _, err := parseTableExpiration(c.BigQuery.TableExpiration); err != nil {
+		return err
+	}
+	scheduled := make(map[string]bool, len(c.BigQuery.ScheduledQueries))
+	for _, sq := range c.BigQuery.ScheduledQueries {
+		if strings.TrimSpace(sq.Name) == "" {
+			return fmt.Errorf("scheduled query name is required")
+		}
+		if scheduled[sq.Name] {
+			return fmt.Errorf("scheduled query %q is defined more than once", sq.Name)
+		}
+		scheduled[sq.Name] = true
+		if strings.TrimSpace(sq.Query) == "" {
+			return fmt.Errorf("scheduled query %q: query is required", sq.Name)
+		}
+		switch sq.WriteDisposition {
+		case "", "WRITE_TRUNCATE", "WRITE_APPEND":
+		default:
+			return fmt.Errorf("scheduled query %q: invalid write_disposition %s (must be WRITE_TRUNCATE or WRITE_APPEND)", sq.Name, sq.WriteDisposition)
+		}
+		if sq.DestinationTable == "" && (sq.WriteDisposition != "" || sq.PartitioningField != "") {
+			return fmt.Errorf("scheduled query %q: write_disposition and partitioning_field need a destination_table", sq.Name)
+		}
+	}
 
 	// Validate log level
 	validLogLevels := map[string]bool{
@@ -260,35 +1400,587 @@ func (c *Config) Validate() error {
 	if !validLogLevels[c.Logging.Level] {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
 	}
+	switch c.Logging.ErrorReporting {
+	case "", ErrorReportingCloud:
+	case ErrorReportingSentry:
+		if c.Logging.SentryDSN == "" {
+			return fmt.Errorf("sentry_dsn is required when error_reporting is %q", ErrorReportingSentry)
+		}
+	default:
+		return fmt.Errorf("invalid error_reporting: %s (must be %q or %q)", c.Logging.ErrorReporting, ErrorReportingCloud, ErrorReportingSentry)
+	}
+
+	if u := c.Monitoring.PushgatewayURL; u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return fmt.Errorf("invalid pushgateway_url: %s (must start with http:// or https://)", u)
+	}
+
+	switch c.Analytics.TrendFormula {
+	case TrendFormulaVelocity, TrendFormulaRelative, TrendFormulaDecayed:
+	default:
+		return fmt.Errorf("invalid trend_formula: %s (must be %s, %s or %s)",
+			c.Analytics.TrendFormula, TrendFormulaVelocity, TrendFormulaRelative, TrendFormulaDecayed)
+	}
+	if c.Analytics.TrendGravity < 0 {
+		return fmt.Errorf("trend_gravity cannot be negative")
+	}
+	if c.Analytics.Forecast && !c.Analytics.TrendScore {
+		return fmt.Errorf("forecast is stored with the trend scores and needs trend_score")
+	}
+	if c.Analytics.SubscriberMilestones && len(c.Analytics.MilestoneThresholds) == 0 {
+		return fmt.Errorf("subscriber_milestones needs at least one milestone_thresholds value")
+	}
+	for _, t := range c.Analytics.MilestoneThresholds {
+		if t <= 0 {
+			return fmt.Errorf("milestone_thresholds must be positive, got %d", t)
+		}
+	}
+	for _, s := range c.Sinks {
+		if !slices.ContainsFunc(sinkSchemes, func(scheme string) bool { return strings.HasPrefix(s, scheme) }) {
+			return fmt.Errorf("invalid sink: %s (must be gs://<bucket>[/<prefix>], pubsub://<topic>, kafka://<brokers>/<topic>, s3://<bucket>[/<prefix>] or firestore://<collection>)", s)
+		}
+		// A flush replays every file of the buffer, archives included.
+		if c.BigQuery.SpillBuffer != "" && strings.TrimRight(s, "/") == strings.TrimRight(c.BigQuery.SpillBuffer, "/") {
+			return fmt.Errorf("spill_buffer %s cannot also be a sink", c.BigQuery.SpillBuffer)
+		}
+	}
+	if u := c.Server.APICacheURL; u != "" {
+		if !strings.HasPrefix(u, "redis://") && !strings.HasPrefix(u, "rediss://") {
+			return fmt.Errorf("invalid api_cache_url (must be redis://<host>:<port>[/<db>] or rediss://...)")
+		}
+		if c.Server.APICacheTTL <= 0 {
+			return fmt.Errorf("api_cache_ttl must be positive")
+		}
+	}
+	if c.Server.TriggerInterval < 0 {
+		return fmt.Errorf("trigger_interval cannot be negative")
+	}
+	if p := c.Server.GRPCPort; p != "" {
+		if n, err := strconv.Atoi(p); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid grpc_port %q", p)
+		}
+		if p == c.Server.Port {
+			return fmt.Errorf("grpc_port must differ from the HTTP port")
+		}
+	}
+	if err := c.validateBigQueryDisabled(); err != nil {
+		return err
+	}
+	if a := c.App.ThumbnailArchive; a != "" && (!strings.HasPrefix(a, "gs://") || strings.TrimPrefix(a, "gs://") == "") {
+		return fmt.Errorf("invalid thumbnail_archive: %s (must be gs://<bucket>[/<prefix>])", a)
+	}
+	if d := c.Report.Destination; d != "" && !strings.HasPrefix(d, "sheets://") && !strings.HasPrefix(d, "gs://") {
+		return fmt.Errorf("invalid report destination: %s (must be sheets://<spreadsheetId> or gs://<bucket>[/<prefix>])", d)
+	}
+	if c.Report.TopN < 1 || c.Report.TopN > 1000 {
+		return fmt.Errorf("report top_n must be between 1 and 1000")
+	}
 
-	// At least one channel must be configured
+	if err := c.Digest.validate(); err != nil {
+		return err
+	}
+	if err := c.Notifications.validate(c.Digest.Provider != "", c.Webhooks.Subscribed(WebhookEventTrendDetected),
+		c.Webhooks.Subscribed(WebhookEventVideoUploaded)); err != nil {
+		return err
+	}
+	if err := c.Webhooks.validate(); err != nil {
+		return err
+	}
+	if err := c.Scheduler.validate(); err != nil {
+		return err
+	}
+
+	// Channels read from an external source are validated when loaded
+	if c.App.ChannelConfigSource != "" {
+		if c.App.ChannelConfigTTL < 0 {
+			return fmt.Errorf("channel_config_ttl cannot be negative")
+		}
+	} else if err := ValidateChannels(c.Channels); err != nil {
+		return err
+	}
+
+	if err := validateKeywords(c.Keywords); err != nil {
+		return err
+	}
+	return c.validateTenants()
+}
+
+// validateKeywords checks the enabled keywords.
+func validateKeywords(keywords []KeywordConfig) error {
+	for _, kw := range keywords {
+		if !kw.Enabled {
+			continue
+		}
+		if strings.TrimSpace(kw.Query) == "" {
+			return fmt.Errorf("keyword query is required")
+		}
+		// 0 is an omitted max_results, which GetEnabledKeywords replaces
+		// with the default.
+		if kw.MaxResults < 0 || kw.MaxResults > 50 {
+			return fmt.Errorf("keyword %q: max_results must be between 1 and 50, or omitted for %d", kw.Query, DefaultKeywordMaxResults)
+		}
+	}
+	return nil
+}
+
+// validateTenants checks that tenant IDs and datasets are unique and that
+// every tenant has valid channels and keywords of its own.
+func (c *Config) validateTenants() error {
+	if len(c.Tenants) > 0 && c.BigQuery.Disabled {
+		return fmt.Errorf("tenants need BigQuery and cannot be used with bigquery.disabled")
+	}
+	ids := make(map[string]bool, len(c.Tenants))
+	datasets := map[string]bool{c.BigQuery.DatasetID: true}
+	for _, t := range c.Tenants {
+		if !validTenantID(t.ID) {
+			return fmt.Errorf("invalid tenant id %q (must be lowercase letters, digits, '-' and '_')", t.ID)
+		}
+		if ids[t.ID] {
+			return fmt.Errorf("tenant %q is defined more than once", t.ID)
+		}
+		ids[t.ID] = true
+		if t.DatasetID == "" {
+			return fmt.Errorf("tenant %s: dataset_id is required", t.ID)
+		}
+		if datasets[t.DatasetID] {
+			return fmt.Errorf("tenant %s: dataset %s is already used by bigquery.dataset_id or another tenant", t.ID, t.DatasetID)
+		}
+		datasets[t.DatasetID] = true
+		if err := ValidateChannels(t.Channels); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+		if err := validateKeywords(t.Keywords); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+func validTenantID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// sinkSchemes are the URI schemes of the supported sinks.
+var sinkSchemes = []string{"gs://", "pubsub://", "kafka://", "s3://", "firestore://"}
+
+// validateBigQueryDisabled rejects the features that read or write BigQuery
+// when bigquery.disabled is set, since they would fail on every run.
+func (c *Config) validateBigQueryDisabled() error {
+	if !c.BigQuery.Disabled {
+		return nil
+	}
+	if len(c.Sinks) == 0 {
+		return fmt.Errorf("bigquery.disabled requires at least one sink")
+	}
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"bigquery.spill_buffer", c.BigQuery.SpillBuffer != ""},
+		{"app.run_lock", c.App.RunLock},
+		{"app.thumbnail_archive", c.App.ThumbnailArchive != ""},
+		{"app.channel_config_source bigquery", strings.HasPrefix(c.App.ChannelConfigSource, "bigquery")},
+		{"analytics.trend_score", c.Analytics.TrendScore},
+		{"analytics.tag_trends", c.Analytics.TagTrends},
+		{"analytics.channel_daily_stats", c.Analytics.ChannelDailyStats},
+		{"analytics.publish_times", c.Analytics.PublishTimes},
+		{"analytics.subscriber_milestones", c.Analytics.SubscriberMilestones},
+		{"analytics.comment_sentiment", c.Analytics.CommentSentiment},
+		{"report.destination", c.Report.Destination != ""},
+		{"notifications.rules", len(c.Notifications.Rules) > 0},
+		{"notifications.new_uploads", c.Notifications.NewUploads.Enabled},
+	} {
+		if f.on {
+			return fmt.Errorf("%s needs BigQuery and cannot be used with bigquery.disabled", f.name)
+		}
+	}
+	return nil
+}
+
+// validate checks the digest settings of the configured provider.
+func (d *DigestConfig) validate() error {
+	if d.Period != DigestPeriodDaily && d.Period != DigestPeriodWeekly {
+		return fmt.Errorf("invalid digest period: %s (must be %q or %q)", d.Period, DigestPeriodDaily, DigestPeriodWeekly)
+	}
+	switch d.Provider {
+	case "":
+		return nil
+	case DigestProviderSMTP:
+		if d.SMTPHost == "" {
+			return fmt.Errorf("digest smtp_host is required when provider is %q", DigestProviderSMTP)
+		}
+		if d.SMTPPort < 1 || d.SMTPPort > 65535 {
+			return fmt.Errorf("digest smtp_port must be between 1 and 65535")
+		}
+	case DigestProviderSendGrid:
+		if d.SendGridAPIKey == "" {
+			return fmt.Errorf("digest sendgrid_api_key is required when provider is %q", DigestProviderSendGrid)
+		}
+	default:
+		return fmt.Errorf("invalid digest provider: %s (must be %q or %q)", d.Provider, DigestProviderSMTP, DigestProviderSendGrid)
+	}
+	if len(d.Recipients) == 0 {
+		return fmt.Errorf("digest recipients are required")
+	}
+	if d.From == "" {
+		return fmt.Errorf("digest from address is required")
+	}
+	return nil
+}
+
+// validate checks that the actions are complete and that the rules parse
+// and refer to defined actions. Email actions need a digest provider.
+func (n *NotificationsConfig) validate(hasMailer, hasWebhooks, hasUploadWebhooks bool) error {
+	actions := make(map[string]bool, len(n.Actions))
+	for i, a := range n.Actions {
+		if a.Name == "" {
+			return fmt.Errorf("notifications.actions[%d]: name is required", i)
+		}
+		if a.Name == WebhooksActionName {
+			return fmt.Errorf("notifications.actions: name %q is reserved for webhooks", a.Name)
+		}
+		if actions[a.Name] {
+			return fmt.Errorf("notifications.actions: duplicate name %q", a.Name)
+		}
+		actions[a.Name] = true
+		switch a.Type {
+		case NotifyActionSlack, NotifyActionWebhook:
+			if u, err := url.Parse(a.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf("notifications action %q: url must be an http(s) URL", a.Name)
+			}
+		case NotifyActionPubSub:
+			if a.Topic == "" {
+				return fmt.Errorf("notifications action %q: topic is required", a.Name)
+			}
+		case NotifyActionEmail:
+			if len(a.To) == 0 {
+				return fmt.Errorf("notifications action %q: to is required", a.Name)
+			}
+			if !hasMailer {
+				return fmt.Errorf("notifications action %q: email needs digest.provider and its settings", a.Name)
+			}
+		default:
+			return fmt.Errorf("notifications action %q: invalid type %q (must be %q, %q, %q or %q)", a.Name, a.Type,
+				NotifyActionSlack, NotifyActionWebhook, NotifyActionPubSub, NotifyActionEmail)
+		}
+	}
+
+	names := make(map[string]bool, len(n.Rules))
+	for i, r := range n.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("notifications.rules[%d]: name is required", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("notifications.rules: duplicate name %q", r.Name)
+		}
+		if r.Name == NewUploadsRuleName {
+			return fmt.Errorf("notifications.rules: name %q is reserved for new_uploads", r.Name)
+		}
+		names[r.Name] = true
+		if _, err := rules.Parse(r.When, rules.VideoKinds); err != nil {
+			return fmt.Errorf("notifications rule %q: invalid when: %w", r.Name, err)
+		}
+		if len(r.Actions) == 0 && !hasWebhooks {
+			return fmt.Errorf("notifications rule %q: at least one action is required", r.Name)
+		}
+		for _, a := range r.Actions {
+			if !actions[a] {
+				return fmt.Errorf("notifications rule %q: unknown action %q", r.Name, a)
+			}
+		}
+		if r.Cooldown < 0 {
+			return fmt.Errorf("notifications rule %q: cooldown cannot be negative", r.Name)
+		}
+	}
+
+	if u := n.NewUploads; u.Enabled {
+		if len(u.Actions) == 0 && !hasUploadWebhooks {
+			return fmt.Errorf("notifications new_uploads: at least one action is required")
+		}
+		for _, a := range u.Actions {
+			if !actions[a] {
+				return fmt.Errorf("notifications new_uploads: unknown action %q", a)
+			}
+		}
+		for _, id := range u.Channels {
+			if !channelIDPattern.MatchString(id) {
+				return fmt.Errorf("notifications new_uploads: invalid channel ID %q", id)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *WebhooksConfig) validate() error {
+	for i, e := range w.Endpoints {
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("webhooks.endpoints[%d]: url must be an http(s) URL", i)
+		}
+		for _, ev := range e.Events {
+			if !slices.Contains(webhookEvents, ev) {
+				return fmt.Errorf("webhooks.endpoints[%d]: invalid event %q (must be one of %s)", i, ev,
+					strings.Join(webhookEvents, ", "))
+			}
+		}
+	}
+	if w.MaxAttempts < 1 {
+		return fmt.Errorf("webhooks max_attempts must be at least 1")
+	}
+	if w.InitialBackoff <= 0 || w.MaxBackoff < w.InitialBackoff {
+		return fmt.Errorf("webhooks initial_backoff must be positive and no greater than max_backoff")
+	}
+	if d := w.DeadLetter; strings.HasPrefix(d, "gs://") && strings.TrimPrefix(d, "gs://") == "" {
+		return fmt.Errorf("invalid webhooks dead_letter %q (must be gs://<bucket>[/<prefix>] or a directory)", d)
+	}
+	return nil
+}
+
+// ValidateScheduler checks the settings the scheduler job is provisioned
+// from, without requiring the rest of the configuration, such as the API
+// key, to be set.
+func (c *Config) ValidateScheduler() error {
+	if c.GCP.ProjectID == "" {
+		return fmt.Errorf("GCP project ID is required")
+	}
+	if c.GCP.Region == "" {
+		return fmt.Errorf("GCP region is required")
+	}
+	if err := scheduler.Validate(c.App.Schedule, c.App.Timezone); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	return c.Scheduler.validate()
+}
+
+func (s *SchedulerConfig) validate() error {
+	if strings.TrimSpace(s.JobName) == "" {
+		return fmt.Errorf("scheduler job_name is required")
+	}
+	if u := s.TargetURL; u != "" && !strings.HasPrefix(u, "https://") {
+		return fmt.Errorf("invalid scheduler target_url: %s (must start with https://)", u)
+	}
+	if s.AttemptDeadline != 0 && (s.AttemptDeadline < 15*time.Second || s.AttemptDeadline > 30*time.Minute) {
+		return fmt.Errorf("scheduler attempt_deadline must be between 15s and 30m")
+	}
+	if s.RetryCount < 0 || s.RetryCount > 5 {
+		return fmt.Errorf("scheduler retry_count must be between 0 and 5")
+	}
+	if s.MinBackoff < 0 || s.MaxBackoff < 0 {
+		return fmt.Errorf("scheduler min_backoff and max_backoff cannot be negative")
+	}
+	if s.MaxBackoff != 0 && s.MinBackoff > s.MaxBackoff {
+		return fmt.Errorf("scheduler min_backoff cannot exceed max_backoff")
+	}
+	return nil
+}
+
+// validateParts checks that every part can be disabled.
+func validateParts(parts []string) error {
+	for _, p := range parts {
+		if p != VideoPartContentDetails && p != VideoPartTopicDetails {
+			return fmt.Errorf("invalid disabled part: %s (must be %q or %q)", p, VideoPartContentDetails, VideoPartTopicDetails)
+		}
+	}
+	return nil
+}
+
+// channelIDPattern matches YouTube channel IDs.
+var channelIDPattern = regexp.MustCompile(`^UC[0-9A-Za-z_-]{22}$`)
+
+// ValidateChannels checks that at least one channel is enabled, that channel
+// IDs are unique and well-formed, and that every enabled channel has an ID
+// and valid settings. It reports all the problems it finds, one per line.
+func ValidateChannels(channels []ChannelConfig) error {
+	var errs []error
 	enabledChannels := 0
-	for _, ch := range c.Channels {
+	seen := make(map[string]ChannelConfig, len(channels))
+	for i, ch := range channels {
+		ref := ch.Ref(i)
+		if ch.ID != "" {
+			if first, ok := seen[ch.ID]; ok {
+				errs = append(errs, fmt.Errorf("%s: duplicate of %s", ref, first.Ref(-1)))
+			} else {
+				seen[ch.ID] = ch
+			}
+			if strings.TrimSpace(ch.ID) != ch.ID {
+				errs = append(errs, fmt.Errorf("%s: ID has surrounding spaces", ref))
+			} else if !ch.IsPlaylist() && !channelIDPattern.MatchString(ch.ID) {
+				errs = append(errs, fmt.Errorf("%s: invalid channel ID (must be \"UC\" followed by 22 letters, digits, - or _)", ref))
+			}
+		}
+		if err := validateParts(ch.DisabledParts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref, err))
+		}
+		if ch.Type != "" && ch.Type != ChannelTypeChannel && ch.Type != ChannelTypePlaylist {
+			errs = append(errs, fmt.Errorf("%s: invalid type %q (must be %q or %q)", ref, ch.Type, ChannelTypeChannel, ChannelTypePlaylist))
+		}
+		if ch.Schedule != "" {
+			if _, err := scheduler.Parse(ch.Schedule); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid schedule: %w", ref, err))
+			}
+		}
 		if ch.Enabled {
 			enabledChannels++
 			if ch.ID == "" {
-				return fmt.Errorf("channel ID is required")
+				errs = append(errs, fmt.Errorf("%s: channel ID is required", ref))
 			}
 		}
 	}
 	if enabledChannels == 0 {
-		return fmt.Errorf("at least one enabled channel is required")
+		errs = append(errs, fmt.Errorf("at least one enabled channel is required"))
 	}
+	return errors.Join(errs...)
+}
 
-	return nil
+// Ref names the entry in messages: its ID, or its position in the list (i,
+// from zero; negative to leave out) when it has none, followed by where it
+// was defined when known, e.g. "channel UCxxx (configs/config.yaml:12)".
+func (ch ChannelConfig) Ref(i int) string {
+	kind := ChannelTypeChannel
+	if ch.IsPlaylist() {
+		kind = ChannelTypePlaylist
+	}
+	ref := fmt.Sprintf("%s %s", kind, ch.ID)
+	if ch.ID == "" && i >= 0 {
+		ref = fmt.Sprintf("channels entry %d", i+1)
+	}
+	if ch.pos != "" {
+		ref += " (" + ch.pos + ")"
+	}
+	return ref
+}
+
+// WithChannels returns a shallow copy of the configuration using the given
+// channel list.
+func (c *Config) WithChannels(channels []ChannelConfig) *Config {
+	cp := *c
+	cp.Channels = channels
+	return &cp
+}
+
+// Tenant returns the tenant with the given ID.
+func (c *Config) Tenant(id string) (TenantConfig, bool) {
+	for _, t := range c.Tenants {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return TenantConfig{}, false
+}
+
+// ForTenant returns a copy of the configuration that fetches the tenant's
+// channels and keywords with its API key into its dataset. The channel
+// list comes from the tenant entry, not app.channel_config_source, and the
+// shared sinks, spill buffer and report are left out.
+func (c *Config) ForTenant(t TenantConfig) *Config {
+	cp := *c
+	if t.APIKey != "" {
+		cp.YouTube.APIKey = t.APIKey
+	}
+	cp.BigQuery.DatasetID = t.DatasetID
+	cp.BigQuery.SpillBuffer = ""
+	cp.BigQuery.ScheduledQueries = nil
+	cp.App.ChannelConfigSource = ""
+	cp.Channels = t.Channels
+	cp.Keywords = t.Keywords
+	cp.Sinks = nil
+	cp.Report.Destination = ""
+	cp.Tenants = nil
+	return &cp
 }
 
-// GetEnabledChannelIDs returns a list of enabled channel IDs
+// GetEnabledChannelIDs returns the enabled channel IDs, highest priority
+// first; channels of equal priority keep their list order
 func (c *Config) GetEnabledChannelIDs() []string {
+	var enabled []ChannelConfig
+	for _, ch := range c.Channels {
+		if ch.Enabled {
+			enabled = append(enabled, ch)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool { return enabled[i].Priority > enabled[j].Priority })
 	var ids []string
+	for _, ch := range enabled {
+		ids = append(ids, ch.ID)
+	}
+	return ids
+}
+
+// DueChannelIDs returns the enabled channels to fetch in a run at time at,
+// in the order of GetEnabledChannelIDs: channels without a schedule, and
+// channels whose schedule came due between the trigger before this run's
+// and this run's, the triggers being the times of app.schedule. A run at
+// 12:03 on an hourly trigger thus serves the channel schedules of
+// (11:00, 12:00]. Schedules are evaluated in app.timezone.
+func (c *Config) DueChannelIDs(at time.Time) []string {
+	trigger, err := scheduler.Parse(c.App.Schedule)
+	if err != nil {
+		return c.GetEnabledChannelIDs()
+	}
+	to := trigger.PrevIn(at, c.Location())
+	from := trigger.Prev(to.Add(-time.Minute))
+
+	schedules := make(map[string]string, len(c.Channels))
 	for _, ch := range c.Channels {
 		if ch.Enabled {
+			schedules[ch.ID] = ch.Schedule
+		}
+	}
+	var ids []string
+	for _, id := range c.GetEnabledChannelIDs() {
+		if expr := schedules[id]; expr != "" {
+			s, err := scheduler.Parse(expr)
+			if err == nil && !s.Due(from, to) {
+				continue
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// GetPlaylistIDs returns the IDs of the enabled playlist entries
+func (c *Config) GetPlaylistIDs() []string {
+	var ids []string
+	for _, ch := range c.Channels {
+		if ch.Enabled && ch.IsPlaylist() {
+			ids = append(ids, ch.ID)
+		}
+	}
+	return ids
+}
+
+// GetCommentChannelIDs returns the enabled channels with comment capture on
+func (c *Config) GetCommentChannelIDs() []string {
+	var ids []string
+	for _, ch := range c.Channels {
+		if ch.Enabled && ch.TrackComments {
 			ids = append(ids, ch.ID)
 		}
 	}
 	return ids
 }
 
+// GetEnabledKeywords returns the enabled keywords with defaults applied
+func (c *Config) GetEnabledKeywords() []KeywordConfig {
+	var keywords []KeywordConfig
+	for _, kw := range c.Keywords {
+		if kw.Enabled {
+			if kw.MaxResults == 0 {
+				kw.MaxResults = DefaultKeywordMaxResults
+			}
+			keywords = append(keywords, kw)
+		}
+	}
+	return keywords
+}
+
 // IsProduction returns true if running in production environment
 func (c *Config) IsProduction() bool {
 	return c.App.Environment == "production" || c.App.Environment == "prod"
@@ -299,6 +1991,44 @@ func (c *Config) IsDevelopment() bool {
 	return c.App.Environment == "development" || c.App.Environment == "dev"
 }
 
+// Location returns the configured timezone, falling back to UTC if it
+// cannot be loaded.
+func (c *Config) Location() *time.Location {
+	loc, err := time.LoadLocation(c.App.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// TableExpirationTime returns when the video trends table expires, or the
+// zero time if it does not.
+func (c *Config) TableExpirationTime() time.Time {
+	t, _ := parseTableExpiration(c.BigQuery.TableExpiration)
+	return t
+}
+
+// parseTableExpiration parses table_expiration: a date (midnight UTC) or an
+// RFC 3339 timestamp.
+func parseTableExpiration(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid table_expiration %q: use YYYY-MM-DD or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// IsJobMode returns true if the binary should run a single fetch and exit
+func (c *Config) IsJobMode() bool {
+	return c.App.RunMode == RunModeJob
+}
+
 // IsLocal returns true if running in local environment
 func (c *Config) IsLocal() bool {
 	return c.App.Environment == "local"
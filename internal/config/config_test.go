@@ -0,0 +1,542 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelIssues(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Channels = []ChannelConfig{
+		{ID: "UC1", Name: "One", Enabled: true},
+		{ID: "UC2", Enabled: false},
+	}
+
+	want := []string{"channel UC2 is disabled"}
+	if got := cfg.ChannelIssues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChannelIssues() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateChannels_IDs(t *testing.T) {
+	channels := []ChannelConfig{
+		{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Enabled: true, pos: "config.yaml:3"},
+		{ID: "UC123", Enabled: true, pos: "config.yaml:5"},
+		{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Enabled: false, pos: "config.yaml:7"},
+		{Enabled: true},
+		{ID: "PLnot-a-channel-id", Enabled: true, Type: ChannelTypePlaylist},
+	}
+	err := ValidateChannels(channels)
+	if err == nil {
+		t.Fatal("ValidateChannels() should fail")
+	}
+	// Every problem is reported, not just the first.
+	want := []string{
+		`channel UC123 (config.yaml:5): invalid channel ID (must be "UC" followed by 22 letters, digits, - or _)`,
+		"channel UCaaaaaaaaaaaaaaaaaaaaaa (config.yaml:7): duplicate of channel UCaaaaaaaaaaaaaaaaaaaaaa (config.yaml:3)",
+		"channels entry 4: channel ID is required",
+	}
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateChannels() error =\n%s\nwant\n%s", err, strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadUnvalidated_ChannelPositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `app:
+  max_videos_per_channel: 10
+channels:
+  - id: UCaaaaaaaaaaaaaaaaaaaaaa
+    enabled: true
+  - id: UCbad
+    enabled: true
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadUnvalidated(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateChannels(cfg.Channels)
+	if err == nil || !strings.Contains(err.Error(), "channel UCbad ("+path+":6)") {
+		t.Errorf("ValidateChannels() error = %v, want a reference to line 6", err)
+	}
+}
+
+func TestGetEnabledChannelIDs_Priority(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Channels = []ChannelConfig{
+		{ID: "UC1", Enabled: true},
+		{ID: "UC2", Enabled: true, Priority: 5},
+		{ID: "UC3", Enabled: false, Priority: 10},
+		{ID: "UC4", Enabled: true},
+		{ID: "UC5", Enabled: true, Priority: -1},
+	}
+
+	want := []string{"UC2", "UC1", "UC4", "UC5"}
+	if got := cfg.GetEnabledChannelIDs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEnabledChannelIDs() = %q, want %q", got, want)
+	}
+}
+
+func TestDueChannelIDs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Channels = []ChannelConfig{
+		{ID: "UC1", Enabled: true},
+		{ID: "UC2", Enabled: true, Schedule: "0 */6 * * *"},
+		{ID: "UC3", Enabled: true, Schedule: "30 9 * * *", Priority: 1},
+		{ID: "UC4", Enabled: false},
+	}
+	tokyo := cfg.Location()
+
+	tests := []struct {
+		at   time.Time
+		want []string
+	}{
+		// The 12:00 trigger serves (11:00, 12:00], which includes 12:00.
+		{time.Date(2026, 10, 17, 12, 3, 0, 0, tokyo), []string{"UC1", "UC2"}},
+		{time.Date(2026, 10, 17, 13, 0, 0, 0, tokyo), []string{"UC1"}},
+		// 09:30 falls in (09:00, 10:00].
+		{time.Date(2026, 10, 17, 10, 1, 0, 0, tokyo), []string{"UC3", "UC1"}},
+		// Evaluated in app.timezone: 03:00 UTC is 12:00 in Tokyo.
+		{time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC), []string{"UC1", "UC2"}},
+	}
+	for _, tt := range tests {
+		if got := cfg.DueChannelIDs(tt.at); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DueChannelIDs(%s) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+
+	cfg.Channels[1].Schedule = "0 */6 * *"
+	if err := ValidateChannels(cfg.Channels); err == nil {
+		t.Error("ValidateChannels() with an invalid schedule should fail")
+	}
+}
+
+func TestValidateChannels_Type(t *testing.T) {
+	channels := []ChannelConfig{
+		{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true},
+		{ID: "PL1", Enabled: true, Type: ChannelTypePlaylist},
+		{ID: "PL2", Enabled: false, Type: ChannelTypePlaylist},
+	}
+	if err := ValidateChannels(channels); err != nil {
+		t.Fatalf("ValidateChannels() error = %v", err)
+	}
+	cfg := DefaultConfig().WithChannels(channels)
+	if got := cfg.GetPlaylistIDs(); !reflect.DeepEqual(got, []string{"PL1"}) {
+		t.Errorf("GetPlaylistIDs() = %q, want [PL1]", got)
+	}
+
+	channels = append(channels, ChannelConfig{ID: "X", Enabled: true, Type: "video"})
+	if err := ValidateChannels(channels); err == nil {
+		t.Error("ValidateChannels() with an unknown type should fail")
+	}
+}
+
+func TestTableExpirationTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2027-03-31", time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC), false},
+		{"2027-03-31T09:00:00+09:00", time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC), false},
+		{"next year", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.BigQuery.TableExpiration = tt.value
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() with table_expiration %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got := cfg.TableExpirationTime(); !got.Equal(tt.want) {
+			t.Errorf("TableExpirationTime() for %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateScheduledQueries(t *testing.T) {
+	dedup := ScheduledQueryConfig{Name: "dedup", Query: "DELETE FROM t WHERE FALSE", Enabled: true}
+	rollup := ScheduledQueryConfig{Name: "rollup", Query: "SELECT 1", DestinationTable: "rollup", WriteDisposition: "WRITE_TRUNCATE", Enabled: true}
+	tests := []struct {
+		name    string
+		queries []ScheduledQueryConfig
+		wantErr bool
+	}{
+		{"valid", []ScheduledQueryConfig{dedup, rollup}, false},
+		{"duplicate name", []ScheduledQueryConfig{dedup, dedup}, true},
+		{"missing query", []ScheduledQueryConfig{{Name: "empty"}}, true},
+		{"invalid write disposition", []ScheduledQueryConfig{{Name: "r", Query: "SELECT 1", DestinationTable: "r", WriteDisposition: "WRITE_EMPTY"}}, true},
+		{"write disposition without destination", []ScheduledQueryConfig{{Name: "r", Query: "SELECT 1", WriteDisposition: "WRITE_APPEND"}}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.BigQuery.ScheduledQueries = tt.queries
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateMilestoneThresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		thresholds []int64
+		wantErr    bool
+	}{
+		{"defaults", true, DefaultConfig().Analytics.MilestoneThresholds, false},
+		{"none", true, nil, true},
+		{"none while disabled", false, nil, false},
+		{"zero", true, []int64{0, 100000}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.Analytics.SubscriberMilestones = tt.enabled
+		cfg.Analytics.MilestoneThresholds = tt.thresholds
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateForecast(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.YouTube.APIKey = "key"
+	cfg.GCP.ProjectID = "project"
+	cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+	cfg.Analytics.Forecast = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "trend_score") {
+		t.Errorf("forecast without trend scores: Validate() error = %v", err)
+	}
+	cfg.Analytics.TrendScore = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("forecast with trend scores: Validate() error = %v", err)
+	}
+}
+
+func TestValidateThumbnailArchive(t *testing.T) {
+	for archive, ok := range map[string]bool{
+		"":                        true,
+		"gs://ytt-thumbs":         true,
+		"gs://ytt-thumbs/archive": true,
+		"gs://":                   false,
+		"s3://ytt-thumbs":         false,
+	} {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.App.ThumbnailArchive = archive
+		if err := cfg.Validate(); (err == nil) != ok {
+			t.Errorf("thumbnail_archive %q: Validate() error = %v", archive, err)
+		}
+	}
+}
+
+func TestValidateBigQueryDisabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"sink only", func(c *Config) {}, false},
+		{"no project", func(c *Config) { c.GCP.ProjectID = "" }, false},
+		{"no sink", func(c *Config) { c.Sinks = nil }, true},
+		{"trend score", func(c *Config) { c.Analytics.TrendScore = true }, true},
+		{"run lock", func(c *Config) { c.App.RunLock = true }, true},
+		{"channels table", func(c *Config) { c.App.ChannelConfigSource = "bigquery" }, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.BigQuery.Disabled = true
+		cfg.Sinks = []string{"s3://ytt/raw"}
+		tt.modify(cfg)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	slack := NotifyActionConfig{Name: "team", Type: NotifyActionSlack, URL: "https://hooks.slack.com/services/T/B/X"}
+	viral := NotifyRuleConfig{Name: "viral", When: "views_gained_24h > 100000", Actions: []string{"team"}}
+	tests := []struct {
+		name    string
+		modify  func(*NotificationsConfig)
+		wantErr bool
+	}{
+		{"valid", func(n *NotificationsConfig) {}, false},
+		{"duplicate action", func(n *NotificationsConfig) { n.Actions = append(n.Actions, slack) }, true},
+		{"slack without url", func(n *NotificationsConfig) { n.Actions[0].URL = "" }, true},
+		{"invalid type", func(n *NotificationsConfig) { n.Actions[0].Type = "sms" }, true},
+		{"pubsub without topic", func(n *NotificationsConfig) { n.Actions[0].Type = NotifyActionPubSub }, true},
+		{"email without digest provider", func(n *NotificationsConfig) {
+			n.Actions[0] = NotifyActionConfig{Name: "team", Type: NotifyActionEmail, To: []string{"a@example.com"}}
+		}, true},
+		{"invalid condition", func(n *NotificationsConfig) { n.Rules[0].When = "views_gained > 1" }, true},
+		{"unknown action", func(n *NotificationsConfig) { n.Rules[0].Actions = []string{"ops"} }, true},
+		{"no action", func(n *NotificationsConfig) { n.Rules[0].Actions = nil }, true},
+		{"duplicate rule", func(n *NotificationsConfig) { n.Rules = append(n.Rules, viral) }, true},
+		{"negative cooldown", func(n *NotificationsConfig) { n.Rules[0].Cooldown = -time.Hour }, true},
+		{"reserved rule name", func(n *NotificationsConfig) { n.Rules[0].Name = NewUploadsRuleName }, true},
+		{"new uploads", func(n *NotificationsConfig) {
+			n.NewUploads = NewUploadsConfig{Enabled: true, Channels: []string{"UC2xxxxxxxxxxxxxxxxxxxxx"}, Actions: []string{"team"}}
+		}, false},
+		{"new uploads without action", func(n *NotificationsConfig) { n.NewUploads = NewUploadsConfig{Enabled: true} }, true},
+		{"new uploads with unknown action", func(n *NotificationsConfig) {
+			n.NewUploads = NewUploadsConfig{Enabled: true, Actions: []string{"ops"}}
+		}, true},
+		{"new uploads with invalid channel", func(n *NotificationsConfig) {
+			n.NewUploads = NewUploadsConfig{Enabled: true, Channels: []string{"competitor"}, Actions: []string{"team"}}
+		}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.Notifications = NotificationsConfig{
+			Actions: []NotifyActionConfig{slack},
+			Rules:   []NotifyRuleConfig{viral},
+		}
+		tt.modify(&cfg.Notifications)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"valid", func(c *Config) {}, false},
+		{"invalid url", func(c *Config) { c.Webhooks.Endpoints[0].URL = "ftp://example.com" }, true},
+		{"invalid event", func(c *Config) { c.Webhooks.Endpoints[0].Events = []string{"video.deleted"} }, true},
+		{"milestone event", func(c *Config) { c.Webhooks.Endpoints[0].Events = []string{WebhookEventChannelMilestone} }, false},
+		{"no attempts", func(c *Config) { c.Webhooks.MaxAttempts = 0 }, true},
+		{"backoff above max", func(c *Config) { c.Webhooks.InitialBackoff = 2 * time.Minute }, true},
+		{"dead letter without bucket", func(c *Config) { c.Webhooks.DeadLetter = "gs://" }, true},
+		{"rule without action", func(c *Config) {
+			c.Notifications.Rules = []NotifyRuleConfig{{Name: "viral", When: "views > 1"}}
+		}, false},
+		{"rule without action or trend webhooks", func(c *Config) {
+			c.Notifications.Rules = []NotifyRuleConfig{{Name: "viral", When: "views > 1"}}
+			c.Webhooks.Endpoints[0].Events = []string{WebhookEventRunCompleted}
+		}, true},
+		{"new uploads without action", func(c *Config) {
+			c.Notifications.NewUploads.Enabled = true
+			c.Webhooks.Endpoints[0].Events = []string{WebhookEventVideoUploaded}
+		}, false},
+		{"new uploads without action or upload webhooks", func(c *Config) {
+			c.Notifications.NewUploads.Enabled = true
+			c.Webhooks.Endpoints[0].Events = []string{WebhookEventTrendDetected}
+		}, true},
+		{"reserved action name", func(c *Config) {
+			c.Notifications.Actions = []NotifyActionConfig{{Name: WebhooksActionName, Type: NotifyActionWebhook, URL: "https://example.com/hook"}}
+		}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.Webhooks.Endpoints = []WebhookEndpointConfig{{URL: "https://hooks.zapier.com/hooks/catch/1/a", Secret: "s3cret"}}
+		tt.modify(cfg)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateTenants(t *testing.T) {
+	acme := TenantConfig{ID: "acme", DatasetID: "acme", Channels: []ChannelConfig{{ID: "UC2xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}}
+	tests := []struct {
+		name    string
+		tenants []TenantConfig
+		wantErr bool
+	}{
+		{"valid", []TenantConfig{acme}, false},
+		{"duplicate id", []TenantConfig{acme, acme}, true},
+		{"invalid id", []TenantConfig{{ID: "Acme Corp", DatasetID: "acme", Channels: acme.Channels}}, true},
+		{"missing dataset", []TenantConfig{{ID: "acme", Channels: acme.Channels}}, true},
+		{"shared dataset", []TenantConfig{{ID: "acme", DatasetID: "youtube", Channels: acme.Channels}}, true},
+		{"no enabled channel", []TenantConfig{{ID: "acme", DatasetID: "acme"}}, true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.YouTube.APIKey = "key"
+		cfg.GCP.ProjectID = "project"
+		cfg.Channels = []ChannelConfig{{ID: "UC1xxxxxxxxxxxxxxxxxxxxx", Enabled: true}}
+		cfg.Tenants = tt.tenants
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestForTenant(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.YouTube.APIKey = "shared"
+	cfg.Sinks = []string{"gs://bucket"}
+	cfg.App.ChannelConfigSource = "bigquery"
+	tenant := TenantConfig{ID: "acme", DatasetID: "acme_trends", Channels: []ChannelConfig{{ID: "UC2", Enabled: true}}}
+
+	c := cfg.ForTenant(tenant)
+	if c.YouTube.APIKey != "shared" || c.BigQuery.DatasetID != "acme_trends" || c.BigQuery.TableID != "video_trends" {
+		t.Errorf("ForTenant() = key %s, dataset %s, table %s", c.YouTube.APIKey, c.BigQuery.DatasetID, c.BigQuery.TableID)
+	}
+	if len(c.Sinks) != 0 || c.App.ChannelConfigSource != "" || len(c.GetEnabledChannelIDs()) != 1 {
+		t.Errorf("ForTenant() should drop the shared sinks and channel source and use the tenant's channels")
+	}
+	tenant.APIKey = "acme"
+	if c := cfg.ForTenant(tenant); c.YouTube.APIKey != "acme" || cfg.YouTube.APIKey != "shared" {
+		t.Errorf("ForTenant() key = %s, original %s", c.YouTube.APIKey, cfg.YouTube.APIKey)
+	}
+}
+
+func TestLoadUnvalidated_Layers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("shared/channels.yaml", `
+channels:
+  - id: UC1
+    enabled: true
+  - id: UC2
+    enabled: true
+app:
+  max_videos_per_channel: 30
+`)
+	base := write("config.yaml", `
+include: [shared/channels.yaml]
+app:
+  timezone: UTC
+bigquery:
+  dataset_id: youtube_dev
+`)
+	write("config.production.yaml", `
+bigquery:
+  dataset_id: youtube
+app:
+  dry_run: true
+`)
+
+	t.Setenv("GO_ENV", "production")
+	cfg, err := LoadUnvalidated(base)
+	if err != nil {
+		t.Fatalf("LoadUnvalidated() error = %v", err)
+	}
+	if len(cfg.Channels) != 2 || cfg.App.MaxVideosPerChannel != 30 {
+		t.Errorf("included settings = %d channels, max videos %d", len(cfg.Channels), cfg.App.MaxVideosPerChannel)
+	}
+	if want := filepath.Join(dir, "shared/channels.yaml"); cfg.ChannelsFrom() != want {
+		t.Errorf("ChannelsFrom() = %q, want %q", cfg.ChannelsFrom(), want)
+	}
+	// The overlay sets single fields of a section and keeps the others.
+	if cfg.BigQuery.DatasetID != "youtube" || !cfg.App.DryRun || cfg.App.Timezone != "UTC" || cfg.BigQuery.TableID != "video_trends" {
+		t.Errorf("merged = dataset %s, dry run %v, timezone %s, table %s", cfg.BigQuery.DatasetID, cfg.App.DryRun, cfg.App.Timezone, cfg.BigQuery.TableID)
+	}
+
+	t.Setenv("GO_ENV", "staging") // no such profile
+	if cfg, err := LoadUnvalidated(base); err != nil || cfg.BigQuery.DatasetID != "youtube_dev" {
+		t.Errorf("without a profile file: dataset %s, error %v", cfg.BigQuery.DatasetID, err)
+	}
+
+	cycle := write("a.yaml", "include: [b.yaml]\n")
+	write("b.yaml", "include: [a.yaml]\n")
+	if _, err := LoadUnvalidated(cycle); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("include cycle error = %v", err)
+	}
+}
+
+func TestProfilePath(t *testing.T) {
+	if got := ProfilePath("configs/config.yaml", "production"); got != "configs/config.production.yaml" {
+		t.Errorf("ProfilePath() = %s", got)
+	}
+	if got := ProfilePath("configs/config.yaml", ""); got != "" {
+		t.Errorf("ProfilePath() without env = %s", got)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Type       string                            `json:"type"`
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Items      struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	if got := schema.Properties["channels"].Type; got != "array" {
+		t.Errorf("channels type = %q, want array", got)
+	}
+	if got := schema.Properties["channels"].Items.Properties["track_comments"]["type"]; got != "boolean" {
+		t.Errorf("channels[].track_comments type = %v, want boolean", got)
+	}
+	if got := schema.Properties["app"].Properties["fetch_timeout"]["type"]; got != "string" {
+		t.Errorf("app.fetch_timeout type = %v, want string", got)
+	}
+	if got := schema.Properties["youtube"].Properties["quota_limit"]["type"]; got != "integer" {
+		t.Errorf("youtube.quota_limit type = %v, want integer", got)
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		maxResults int64
+		wantErr    bool
+	}{
+		{0, false}, // omitted: the default
+		{1, false},
+		{50, false},
+		{-1, true},
+		{51, true},
+	}
+	for _, tt := range tests {
+		err := validateKeywords([]KeywordConfig{{Query: "q", MaxResults: tt.maxResults, Enabled: true}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("max_results %d: validateKeywords() error = %v, wantErr %v", tt.maxResults, err, tt.wantErr)
+		}
+	}
+}
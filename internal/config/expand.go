@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expandEnv replaces ${NAME} placeholders in the scalar values of a YAML
+// document with environment variables, so that secrets such as api_key can
+// stay out of committed files. ${NAME:-default} uses default when NAME is
+// unset or empty, and $$ is a literal $. Any other unset variable is an
+// error, reported with its line. The query of bigquery.scheduled_queries is
+// left as is: its ${PROJECT_ID}, ${DATASET_ID} and ${TABLE_ID} are filled in
+// per query by --migrate.
+func expandEnv(doc *yaml.Node) error {
+	var errs []error
+	var walk func(n *yaml.Node, inScheduledQuery bool)
+	walk = func(n *yaml.Node, inScheduledQuery bool) {
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c, inScheduledQuery)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if inScheduledQuery && key.Value == "query" {
+					continue
+				}
+				walk(value, inScheduledQuery || key.Value == "scheduled_queries")
+			}
+		case yaml.ScalarNode:
+			expanded, err := expandString(n.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %w", n.Line, err))
+				return
+			}
+			if expanded != n.Value {
+				n.Value = expanded
+				if n.Style == 0 {
+					// Resolve the type again, e.g. so that ${QUOTA_LIMIT}
+					// can set an integer.
+					n.Tag = ""
+				}
+			}
+		}
+	}
+	walk(doc, false)
+	return errors.Join(errs...)
+}
+
+// expandString expands the placeholders of one value.
+func expandString(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated placeholder in %q", s)
+			}
+			name, def, hasDefault := strings.Cut(s[i+2:i+end], ":-")
+			if !validEnvName(name) {
+				return "", fmt.Errorf("invalid placeholder ${%s}", s[i+2:i+end])
+			}
+			v := os.Getenv(name)
+			if v == "" {
+				if !hasDefault {
+					return "", fmt.Errorf("environment variable %s is not set", name)
+				}
+				v = def
+			}
+			b.WriteString(v)
+			i += end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandString(t *testing.T) {
+	t.Setenv("YTT_TEST_KEY", "AIza-test")
+	t.Setenv("YTT_TEST_EMPTY", "")
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"plain", "plain", false},
+		{"${YTT_TEST_KEY}", "AIza-test", false},
+		{"key=${YTT_TEST_KEY}!", "key=AIza-test!", false},
+		{"${YTT_TEST_UNSET:-fallback}", "fallback", false},
+		{"${YTT_TEST_EMPTY:-fallback}", "fallback", false},
+		{"$$5 and $HOME", "$5 and $HOME", false},
+		{"${YTT_TEST_UNSET}", "", true},
+		{"${YTT_TEST_KEY", "", true},
+		{"${not-a-name}", "", true},
+	}
+	for _, tt := range tests {
+		got, err := expandString(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("expandString(%q) = %q, %v; want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadUnvalidated_EnvPlaceholders(t *testing.T) {
+	t.Setenv("YTT_TEST_KEY", "AIza-test")
+	t.Setenv("YTT_TEST_QUOTA", "5000")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+youtube:
+  api_key: ${YTT_TEST_KEY}
+  quota_limit: ${YTT_TEST_QUOTA}
+bigquery:
+  scheduled_queries:
+    - name: dedup
+      query: DELETE FROM ` + "`${PROJECT_ID}.${DATASET_ID}.${TABLE_ID}`" + ` WHERE FALSE
+`)
+	cfg, err := LoadUnvalidated(path)
+	if err != nil {
+		t.Fatalf("LoadUnvalidated() error = %v", err)
+	}
+	if cfg.YouTube.APIKey != "AIza-test" || cfg.YouTube.QuotaLimit != 5000 {
+		t.Errorf("api_key = %q, quota_limit = %d", cfg.YouTube.APIKey, cfg.YouTube.QuotaLimit)
+	}
+	if q := cfg.BigQuery.ScheduledQueries[0].Query; !strings.Contains(q, "${DATASET_ID}") {
+		t.Errorf("scheduled query was expanded: %s", q)
+	}
+
+	write("youtube:\n  api_key: ${YTT_TEST_UNSET}\n")
+	if _, err := LoadUnvalidated(path); err == nil || !strings.Contains(err.Error(), "line 2: environment variable YTT_TEST_UNSET is not set") {
+		t.Errorf("LoadUnvalidated() with an unset variable error = %v", err)
+	}
+}
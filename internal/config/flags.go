@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Flags are the command-line settings. They are the highest-priority
+// source: a flag that is given overrides the configuration file and the
+// environment variables.
+type Flags struct {
+	fs *pflag.FlagSet
+
+	// ConfigPath is the configuration file (--config).
+	ConfigPath string
+	// Channels replaces the channel list with these IDs (--channels). A
+	// configured entry with the same ID keeps its other settings.
+	Channels []string
+	// MaxVideos overrides app.max_videos_per_channel (--max-videos).
+	MaxVideos int64
+	// DryRun overrides app.dry_run (--dry-run).
+	DryRun bool
+}
+
+// BindFlags registers the configuration flags on fs.
+func BindFlags(fs *pflag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.ConfigPath, "config", "configs/config.yaml", "Path to configuration file")
+	fs.StringSliceVar(&f.Channels, "channels", nil, "Fetch only these channel IDs (comma-separated) instead of the configured list")
+	fs.Int64Var(&f.MaxVideos, "max-videos", 0, "Maximum videos fetched per channel (overrides MAX_VIDEOS_PER_CHANNEL)")
+	fs.BoolVar(&f.DryRun, "dry-run", false, "Fetch from YouTube but write nothing to BigQuery (same as DRY_RUN=true)")
+	return f
+}
+
+// Load loads the configuration file given by --config like Load, applying
+// the flags over it before it is validated.
+func (f *Flags) Load() (*Config, error) {
+	cfg, err := LoadUnvalidated(f.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Apply(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// Apply sets the settings of the flags that were given on cfg.
+func (f *Flags) Apply(cfg *Config) error {
+	if f.fs.Changed("channels") {
+		channels := make([]ChannelConfig, 0, len(f.Channels))
+		for _, id := range f.Channels {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			ch := ChannelConfig{ID: id, pos: "--channels"}
+			for _, configured := range cfg.Channels {
+				if configured.ID == id {
+					ch = configured
+					break
+				}
+			}
+			ch.Enabled = true
+			channels = append(channels, ch)
+		}
+		if len(channels) == 0 {
+			return fmt.Errorf("--channels needs at least one channel ID")
+		}
+		cfg.Channels = channels
+		cfg.channelsFrom = "--channels"
+		// The flag's list is the one to fetch, not the external source's.
+		cfg.App.ChannelConfigSource = ""
+	}
+	if f.fs.Changed("max-videos") {
+		cfg.App.MaxVideosPerChannel = f.MaxVideos
+	}
+	if f.fs.Changed("dry-run") {
+		cfg.App.DryRun = f.DryRun
+	}
+	return nil
+}
+
+// NormalizeArgs rewrites single-dash long flags such as -once, as accepted
+// by the standard flag package, to the --once form pflag expects. Short
+// flags (one letter) and arguments after "--" are left alone.
+func NormalizeArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if a == "--" {
+			copy(out[i:], args[i:])
+			break
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(a, "-"), "=")
+		if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && len(name) > 1 {
+			a = "-" + a
+		}
+		out[i] = a
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestFlagsApply(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.App.MaxVideosPerChannel = 50
+	cfg.App.ChannelConfigSource = "bigquery"
+	cfg.Channels = []ChannelConfig{
+		{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Name: "A", Enabled: false, Priority: 3},
+		{ID: "UCbbbbbbbbbbbbbbbbbbbbbb", Name: "B", Enabled: true},
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--channels", "UCaaaaaaaaaaaaaaaaaaaaaa,UCcccccccccccccccccccccc", "--max-videos=5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ChannelConfig{
+		{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Name: "A", Enabled: true, Priority: 3},
+		{ID: "UCcccccccccccccccccccccc", Enabled: true, pos: "--channels"},
+	}
+	if !reflect.DeepEqual(cfg.Channels, want) {
+		t.Errorf("Channels = %+v, want %+v", cfg.Channels, want)
+	}
+	if cfg.App.ChannelConfigSource != "" {
+		t.Errorf("ChannelConfigSource = %q, want cleared", cfg.App.ChannelConfigSource)
+	}
+	if cfg.App.MaxVideosPerChannel != 5 {
+		t.Errorf("MaxVideosPerChannel = %d, want 5", cfg.App.MaxVideosPerChannel)
+	}
+	if cfg.App.DryRun {
+		t.Error("DryRun set although --dry-run was not given")
+	}
+}
+
+func TestFlagsApply_Unset(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.App.DryRun = true
+	before := *cfg
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := BindFlags(fs).Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cfg, before) {
+		t.Error("Apply changed the configuration without any flags")
+	}
+}
+
+func TestFlagsApply_EmptyChannels(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--channels="}); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Apply(DefaultConfig()); err == nil {
+		t.Error("expected an error for an empty --channels")
+	}
+}
+
+func TestFlagsLoad_OverridesEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("app:\n  max_videos_per_channel: 20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("YOUTUBE_API_KEY", "test-key")
+	t.Setenv("PROJECT_ID", "test-project")
+	t.Setenv("MAX_VIDEOS_PER_CHANNEL", "30")
+	t.Setenv("DRY_RUN", "false")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--config", path, "--channels", "UCaaaaaaaaaaaaaaaaaaaaaa", "--max-videos", "7", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := flags.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.App.MaxVideosPerChannel != 7 {
+		t.Errorf("MaxVideosPerChannel = %d, want 7", cfg.App.MaxVideosPerChannel)
+	}
+	if !cfg.App.DryRun {
+		t.Error("DryRun = false, want true")
+	}
+}
+
+func TestNormalizeArgs(t *testing.T) {
+	got := NormalizeArgs([]string{"-once", "-config=c.yaml", "--debug", "-h", "-", "--", "-x"})
+	want := []string{"--once", "--config=c.yaml", "--debug", "-h", "-", "--", "-x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "fmt"
+
+// ChannelIssues reports problems in the channel list that Validate accepts
+// but that are probably mistakes, such as disabled channels.
+func (c *Config) ChannelIssues() []string {
+	var issues []string
+	for _, ch := range c.Channels {
+		label := ch.ID
+		if ch.Name != "" {
+			label = fmt.Sprintf("%s (%s)", ch.ID, ch.Name)
+		}
+		if !ch.Enabled {
+			issues = append(issues, fmt.Sprintf("channel %s is disabled", label))
+		}
+	}
+	return issues
+}
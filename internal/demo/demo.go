@@ -0,0 +1,111 @@
+// Package demo bundles an anonymized sample dataset that can be loaded into
+// the configured storage backend so new users can explore queries and
+// dashboards before wiring real API keys.
+package demo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+//go:embed sample_video_trends.jsonl
+var sampleVideoTrends []byte
+
+// sampleRow is a single line of the bundled dataset. Dates are stored as
+// offsets relative to the seed day so that the data always looks recent.
+type sampleRow struct {
+	DayOffset        int      `json:"day_offset"`
+	ChannelID        string   `json:"channel_id"`
+	ChannelName      string   `json:"channel_name"`
+	VideoID          string   `json:"video_id"`
+	Title            string   `json:"title"`
+	Tags             []string `json:"tags"`
+	IsShort          bool     `json:"is_short"`
+	Views            int64    `json:"views"`
+	Likes            int64    `json:"likes"`
+	Comments         int64    `json:"comments"`
+	PublishedDaysAgo int      `json:"published_days_ago"`
+	DurationSec      int64    `json:"duration_sec"`
+}
+
+// Writer is the subset of the storage layer needed to seed the dataset.
+type Writer interface {
+	EnsureTableExists(ctx context.Context) error
+	InsertVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error
+}
+
+// Records returns the bundled sample dataset as storage records, with
+// snapshot dates anchored to the given day.
+func Records(now time.Time) ([]*storage.VideoStatsRecord, error) {
+	today := civil.DateOf(now)
+
+	var records []*storage.VideoStatsRecord
+	scanner := bufio.NewScanner(bytes.NewReader(sampleVideoTrends))
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var row sampleRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("sample dataset line %d: %w", line, err)
+		}
+
+		dt := today.AddDays(row.DayOffset)
+		var shortsConfidence float64
+		if row.IsShort {
+			shortsConfidence = 1
+		}
+		records = append(records, &storage.VideoStatsRecord{
+			Dt:          dt,
+			ChannelID:   row.ChannelID,
+			VideoID:     row.VideoID,
+			Title:       row.Title,
+			ChannelName: row.ChannelName,
+			Tags:        row.Tags,
+			IsShort:     row.IsShort,
+			Views:       row.Views,
+			Likes:       row.Likes,
+			Comments:    row.Comments,
+			PublishedAt: today.AddDays(-row.PublishedDaysAgo).In(time.UTC),
+			CreatedAt:   dt.In(time.UTC),
+			SnapshotTs:  dt.In(time.UTC),
+
+			ShortsConfidence: shortsConfidence,
+			DurationSec:      row.DurationSec,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sample dataset: %w", err)
+	}
+
+	return records, nil
+}
+
+// Seed creates the destination table if needed and loads the sample dataset.
+// It returns the number of records written.
+func Seed(ctx context.Context, w Writer, now time.Time) (int, error) {
+	records, err := Records(now)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.EnsureTableExists(ctx); err != nil {
+		return 0, fmt.Errorf("failed to prepare table: %w", err)
+	}
+	if err := w.InsertVideoStats(ctx, records); err != nil {
+		return 0, fmt.Errorf("failed to insert sample records: %w", err)
+	}
+
+	return len(records), nil
+}
@@ -0,0 +1,70 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeWriter struct {
+	ensureErr error
+	inserted  []*storage.VideoStatsRecord
+}
+
+func (f *fakeWriter) EnsureTableExists(ctx context.Context) error {
+	return f.ensureErr
+}
+
+func (f *fakeWriter) InsertVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error {
+	f.inserted = append(f.inserted, records...)
+	return nil
+}
+
+func TestRecords(t *testing.T) {
+	now := time.Date(2025, 8, 20, 9, 0, 0, 0, time.UTC)
+	records, err := Records(now)
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(records) == 0 {
+		t.Fatal("Records() returned no records")
+	}
+
+	today := civil.DateOf(now)
+	for _, r := range records {
+		if r.ChannelID == "" || r.VideoID == "" {
+			t.Errorf("record missing identifiers: %+v", r)
+		}
+		if r.Dt.After(today) {
+			t.Errorf("record dated in the future: %v", r.Dt)
+		}
+		if r.PublishedAt.After(r.CreatedAt) {
+			t.Errorf("video %s published after snapshot", r.VideoID)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	w := &fakeWriter{}
+	n, err := Seed(context.Background(), w, time.Now())
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	if n != len(w.inserted) {
+		t.Errorf("Seed() = %d, inserted %d", n, len(w.inserted))
+	}
+}
+
+func TestSeed_EnsureTableFails(t *testing.T) {
+	w := &fakeWriter{ensureErr: errors.New("boom")}
+	if _, err := Seed(context.Background(), w, time.Now()); err == nil {
+		t.Error("Seed() expected error when table setup fails")
+	}
+	if len(w.inserted) != 0 {
+		t.Errorf("Seed() inserted %d records after setup failure", len(w.inserted))
+	}
+}
@@ -0,0 +1,251 @@
+// Package digest builds and sends an HTML email summarising each tracked
+// channel's performance over a day or a week.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/analytics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// Source provides the data a digest summarises.
+type Source interface {
+	ChannelPerformance(ctx context.Context, from, to civil.Date, timezone string) ([]storage.ChannelPerformance, error)
+	FailedRuns(ctx context.Context, from, to time.Time) ([]storage.FetchRunRecord, error)
+	UploadTimes(ctx context.Context, date civil.Date) ([]storage.UploadTime, error)
+}
+
+// Period is the inclusive date range a digest covers.
+type Period struct {
+	From civil.Date
+	To   civil.Date
+}
+
+// PeriodEnding returns the period of the given kind (config.DigestPeriod*)
+// that ends the day before today: yesterday, or the seven days to yesterday.
+func PeriodEnding(kind string, today civil.Date) Period {
+	p := Period{To: today.AddDays(-1)}
+	p.From = p.To
+	if kind == config.DigestPeriodWeekly {
+		p.From = p.To.AddDays(-6)
+	}
+	return p
+}
+
+// String formats the period for the subject and heading.
+func (p Period) String() string {
+	if p.From == p.To {
+		return p.From.String()
+	}
+	return p.From.String() + " 〜 " + p.To.String()
+}
+
+// ChannelSummary is one channel's row in a digest.
+type ChannelSummary struct {
+	ChannelID   string
+	Name        string
+	NewUploads  int64
+	ViewsGained int64
+	// Best is the video with the most views gained; empty without data.
+	BestVideoID     string
+	BestTitle       string
+	BestViewsGained int64
+	// HasData is false when the channel has no snapshot in the period.
+	HasData bool
+	// Failures counts the runs in which the channel failed or was skipped.
+	Failures int
+	// Cadence is how regularly the channel uploaded up to the end of the
+	// period.
+	Cadence analytics.Cadence
+}
+
+// Digest is a summary of the tracked channels over a period.
+type Digest struct {
+	Period   Period
+	Channels []ChannelSummary
+	// FailedRuns are the runs in the period that failed or had a failed
+	// channel, oldest first.
+	FailedRuns []storage.FetchRunRecord
+}
+
+// Build summarises channels over p, most views gained first. Channels
+// without snapshots in p are listed last. Dates are in loc.
+func Build(ctx context.Context, src Source, channels []config.ChannelConfig, p Period, loc *time.Location) (*Digest, error) {
+	perf, err := src.ChannelPerformance(ctx, p.From, p.To, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	runs, err := src.FailedRuns(ctx, p.From.In(loc), p.To.AddDays(1).In(loc))
+	if err != nil {
+		return nil, err
+	}
+	uploads, err := src.UploadTimes(ctx, p.To)
+	if err != nil {
+		return nil, err
+	}
+	cadences := analytics.UploadCadences(uploads, p.To, loc)
+
+	failures := make(map[string]int)
+	for _, run := range runs {
+		for _, id := range run.FailedChannels {
+			failures[id]++
+		}
+	}
+	byID := make(map[string]storage.ChannelPerformance, len(perf))
+	for _, c := range perf {
+		byID[c.ChannelID] = c
+	}
+
+	d := &Digest{Period: p, FailedRuns: runs}
+	for _, ch := range channels {
+		s := ChannelSummary{ChannelID: ch.ID, Name: ch.Name, Failures: failures[ch.ID], Cadence: cadences[ch.ID]}
+		if c, ok := byID[ch.ID]; ok {
+			s.HasData = true
+			s.NewUploads = c.NewUploads
+			s.ViewsGained = c.ViewsGained
+			s.BestVideoID = c.BestVideoID
+			s.BestTitle = c.BestTitle
+			s.BestViewsGained = c.BestViewsGained
+			if c.ChannelName != "" {
+				s.Name = c.ChannelName
+			}
+		}
+		if s.Name == "" {
+			s.Name = ch.ID
+		}
+		d.Channels = append(d.Channels, s)
+	}
+	sort.SliceStable(d.Channels, func(i, j int) bool {
+		a, b := d.Channels[i], d.Channels[j]
+		if a.HasData != b.HasData {
+			return a.HasData
+		}
+		return a.ViewsGained > b.ViewsGained
+	})
+	return d, nil
+}
+
+// Message is an email ready to be sent.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+}
+
+// Sender delivers a message.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// NewSender returns the sender for the configured provider.
+func NewSender(cfg config.DigestConfig) (Sender, error) {
+	switch cfg.Provider {
+	case config.DigestProviderSMTP:
+		return NewSMTPSender(cfg), nil
+	case config.DigestProviderSendGrid:
+		return NewSendGridSender(cfg.SendGridAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest provider %q", cfg.Provider)
+	}
+}
+
+// Message renders d as an email from from to the given recipients.
+func (d *Digest) Message(from string, to []string) (*Message, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, d); err != nil {
+		return nil, fmt.Errorf("failed to render digest: %w", err)
+	}
+	return &Message{
+		From:    from,
+		To:      to,
+		Subject: "YouTube トレンドダイジェスト " + d.Period.String(),
+		HTML:    buf.String(),
+	}, nil
+}
+
+// maxFailedRuns limits the failed runs listed in a digest.
+const maxFailedRuns = 20
+
+var page = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"num": FormatNumber,
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04 MST")
+	},
+	"days": func(d float64) string {
+		return fmt.Sprintf("%.1f 日", d)
+	},
+	"recent": func(runs []storage.FetchRunRecord) []storage.FetchRunRecord {
+		if len(runs) > maxFailedRuns {
+			return runs[len(runs)-maxFailedRuns:]
+		}
+		return runs
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="UTF-8"><title>YouTube トレンドダイジェスト {{.Period}}</title></head>
+<body style="font-family: sans-serif; color: #202124;">
+<h2 style="margin-bottom: 4px;">YouTube トレンドダイジェスト</h2>
+<p style="margin-top: 0; color: #5f6368;">{{.Period}}</p>
+<table cellpadding="6" cellspacing="0" style="border-collapse: collapse; font-size: 14px;">
+<tr style="background: #f1f3f4; text-align: left;">
+<th>チャンネル</th><th style="text-align: right;">新規投稿</th><th style="text-align: right;">再生増加数</th><th>最も伸びた動画</th><th style="text-align: right;">週あたり投稿</th><th style="text-align: right;">平均投稿間隔</th><th style="text-align: right;">連続投稿週</th><th style="text-align: right;">取得失敗</th>
+</tr>
+{{- range .Channels}}
+<tr style="border-top: 1px solid #dadce0;">
+<td>{{.Name}}</td>
+{{- if .HasData}}
+<td style="text-align: right;">{{num .NewUploads}}</td>
+<td style="text-align: right;">{{num .ViewsGained}}</td>
+<td>{{if .BestVideoID}}<a href="https://www.youtube.com/watch?v={{.BestVideoID}}">{{.BestTitle}}</a> (+{{num .BestViewsGained}}){{end}}</td>
+{{- else}}
+<td colspan="3" style="color: #5f6368;">データなし</td>
+{{- end}}
+<td style="text-align: right;">{{printf "%.1f" .Cadence.UploadsPerWeek}}</td>
+<td style="text-align: right;">{{if gt .Cadence.Uploads 1}}{{days .Cadence.AvgGapDays}}{{else}}-{{end}}</td>
+<td style="text-align: right;">{{.Cadence.StreakWeeks}}</td>
+<td style="text-align: right;{{if .Failures}} color: #d93025;{{end}}">{{.Failures}}</td>
+</tr>
+{{- end}}
+</table>
+<p style="font-size: 12px; color: #5f6368;">週あたり投稿は直近4週間の平均、平均投稿間隔は直近13週間、連続投稿週は期間末日までの7日ごとに投稿が続いた週数です。</p>
+{{- if .FailedRuns}}
+<h3>取得エラー ({{len .FailedRuns}} 件)</h3>
+<ul style="font-size: 14px;">
+{{- range recent .FailedRuns}}
+<li>{{time .StartedAt}} {{.Scope}}: {{if .Error}}{{.Error}}{{else}}{{.ChannelsFailed}} チャンネル失敗{{end}}{{if .FailedChannels}} ({{range $i, $id := .FailedChannels}}{{if $i}}, {{end}}{{$id}}{{end}}){{end}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>期間中の取得エラーはありません。</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// FormatNumber formats n with thousands separators.
+func FormatNumber(n int64) string {
+	s := fmt.Sprintf("%d", n)
+	start := 0
+	if n < 0 {
+		start = 1
+	}
+	var buf bytes.Buffer
+	buf.WriteString(s[:start])
+	digits := s[start:]
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
@@ -0,0 +1,157 @@
+package digest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeSource struct {
+	from, to     civil.Date
+	runsFrom     time.Time
+	runsTo       time.Time
+	performances []storage.ChannelPerformance
+	runs         []storage.FetchRunRecord
+	uploads      []storage.UploadTime
+}
+
+func (f *fakeSource) ChannelPerformance(ctx context.Context, from, to civil.Date, timezone string) ([]storage.ChannelPerformance, error) {
+	f.from, f.to = from, to
+	return f.performances, nil
+}
+
+func (f *fakeSource) FailedRuns(ctx context.Context, from, to time.Time) ([]storage.FetchRunRecord, error) {
+	f.runsFrom, f.runsTo = from, to
+	return f.runs, nil
+}
+
+func (f *fakeSource) UploadTimes(ctx context.Context, date civil.Date) ([]storage.UploadTime, error) {
+	return f.uploads, nil
+}
+
+func TestPeriodEnding(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 8, Day: 4}
+	if p := PeriodEnding(config.DigestPeriodDaily, today); p.String() != "2025-08-03" {
+		t.Errorf("daily = %s", p)
+	}
+	if p := PeriodEnding(config.DigestPeriodWeekly, today); p.String() != "2025-07-28 〜 2025-08-03" {
+		t.Errorf("weekly = %s", p)
+	}
+}
+
+func TestBuildAndMessage(t *testing.T) {
+	src := &fakeSource{
+		performances: []storage.ChannelPerformance{
+			{ChannelID: "UC2", ChannelName: "Second", NewUploads: 1, ViewsGained: 500,
+				BestVideoID: "v2", BestTitle: "small", BestViewsGained: 500},
+			{ChannelID: "UC1", ChannelName: "<First>", NewUploads: 3, ViewsGained: 1234567,
+				BestVideoID: "v1", BestTitle: "big", BestViewsGained: 1000000},
+		},
+		runs: []storage.FetchRunRecord{
+			{Scope: "all", ChannelsFailed: 1, FailedChannels: []string{"UC3"}},
+			{Scope: "channel:UC3", Status: storage.RunStatusFailed, Error: "quota exceeded", FailedChannels: []string{"UC3"}},
+		},
+		uploads: []storage.UploadTime{
+			{ChannelID: "UC1", VideoID: "v1", PublishedAt: time.Date(2025, 8, 3, 10, 0, 0, 0, time.UTC)},
+			{ChannelID: "UC1", VideoID: "v3", PublishedAt: time.Date(2025, 7, 30, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+	channels := []config.ChannelConfig{{ID: "UC3", Name: "Broken"}, {ID: "UC2"}, {ID: "UC1"}}
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	p := PeriodEnding(config.DigestPeriodDaily, civil.Date{Year: 2025, Month: 8, Day: 4})
+
+	d, err := Build(context.Background(), src, channels, p, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 8, 3, 0, 0, 0, 0, loc); !src.runsFrom.Equal(want) || !src.runsTo.Equal(want.AddDate(0, 0, 1)) {
+		t.Errorf("failed runs range = %v - %v", src.runsFrom, src.runsTo)
+	}
+	var order []string
+	for _, c := range d.Channels {
+		order = append(order, c.ChannelID)
+	}
+	if strings.Join(order, ",") != "UC1,UC2,UC3" {
+		t.Errorf("channel order = %v, want most views gained first and no data last", order)
+	}
+	if broken := d.Channels[2]; broken.HasData || broken.Name != "Broken" || broken.Failures != 2 {
+		t.Errorf("channel without data = %+v", broken)
+	}
+
+	if c := d.Channels[0].Cadence; c.Uploads != 2 || c.StreakWeeks != 1 || c.AvgGapDays != 4 {
+		t.Errorf("UC1 cadence = %+v", c)
+	}
+
+	m, err := d.Message("from@example.com", []string{"a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Subject != "YouTube トレンドダイジェスト 2025-08-03" {
+		t.Errorf("subject = %q", m.Subject)
+	}
+	for _, want := range []string{"&lt;First&gt;", "1,234,567", "watch?v=v1", "データなし", "quota exceeded", "取得エラー (2 件)", "4.0 日"} {
+		if !strings.Contains(m.HTML, want) {
+			t.Errorf("HTML does not contain %q", want)
+		}
+	}
+}
+
+func TestSendGridSender(t *testing.T) {
+	var got sendGridMail
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer SG.key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := NewSendGridSender("SG.key")
+	s.endpoint = srv.URL
+	m := &Message{From: "from@example.com", To: []string{"a@example.com", "b@example.com"}, Subject: "件名", HTML: "<p>hi</p>"}
+	if err := s.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Personalizations) != 1 || len(got.Personalizations[0].To) != 2 || got.Subject != "件名" || got.Content[0].Type != "text/html" {
+		t.Errorf("request = %+v", got)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":[{"message":"bad from"}]}`, http.StatusBadRequest)
+	})
+	if err := s.Send(context.Background(), m); err == nil || !strings.Contains(err.Error(), "bad from") {
+		t.Errorf("Send() error = %v, want the SendGrid error", err)
+	}
+}
+
+func TestMIMEMessage(t *testing.T) {
+	m := &Message{From: "from@example.com", To: []string{"a@example.com", "b@example.com"}, Subject: "ダイジェスト", HTML: strings.Repeat("<p>本文</p>", 20)}
+	raw := string(mimeMessage(m, time.Date(2025, 8, 4, 9, 0, 0, 0, time.UTC)))
+
+	head, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatal("no header/body separator")
+	}
+	if !strings.Contains(head, "To: a@example.com, b@example.com\r\n") || !strings.Contains(head, "Subject: =?UTF-8?b?") {
+		t.Errorf("header = %q", head)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(body), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("body line longer than 76 characters: %q", line)
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if err != nil || string(decoded) != m.HTML {
+		t.Errorf("decoded body = %q, %v", decoded, err)
+	}
+}
@@ -0,0 +1,82 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+)
+
+// sendGridEndpoint is SendGrid's v3 Mail Send API.
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridSender sends messages through the SendGrid API.
+type SendGridSender struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+// NewSendGridSender returns a sender authenticating with apiKey.
+func NewSendGridSender(apiKey string) *SendGridSender {
+	return &SendGridSender{client: &http.Client{}, endpoint: sendGridEndpoint, apiKey: apiKey}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Send delivers m to all recipients in a single request.
+func (s *SendGridSender) Send(ctx context.Context, m *Message) error {
+	var p sendGridPersonalization
+	for _, to := range m.To {
+		p.To = append(p.To, sendGridAddress{Email: to})
+	}
+	body, err := json.Marshal(sendGridMail{
+		Personalizations: []sendGridPersonalization{p},
+		From:             sendGridAddress{Email: m.From},
+		Subject:          m.Subject,
+		Content:          []sendGridContent{{Type: "text/html", Value: m.HTML}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Temporary("failed to call SendGrid", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.API(fmt.Sprintf("SendGrid returned %s: %s", resp.Status, bytes.TrimSpace(detail)), nil)
+	}
+	return nil
+}
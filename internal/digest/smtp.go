@@ -0,0 +1,119 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+)
+
+// smtpsPort is the port on which the connection is TLS from the start
+// instead of upgraded with STARTTLS.
+const smtpsPort = 465
+
+// SMTPSender sends messages through an SMTP server.
+type SMTPSender struct {
+	host     string
+	port     int
+	username string
+	password string
+}
+
+// NewSMTPSender returns a sender for the configured SMTP server.
+func NewSMTPSender(cfg config.DigestConfig) *SMTPSender {
+	return &SMTPSender{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+	}
+}
+
+// Send delivers m, using STARTTLS when the server offers it and
+// authenticating when a username is configured.
+func (s *SMTPSender) Send(ctx context.Context, m *Message) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return errors.Temporary("failed to connect to SMTP server", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if s.port == smtpsPort {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.host})
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return errors.Temporary("failed to start SMTP session", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && s.port != smtpsPort {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return errors.API("SMTP STARTTLS failed", err)
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return errors.Config("SMTP authentication failed", err)
+		}
+	}
+	if err := c.Mail(m.From); err != nil {
+		return errors.API("SMTP server rejected the sender", err)
+	}
+	for _, to := range m.To {
+		if err := c.Rcpt(to); err != nil {
+			return errors.API(fmt.Sprintf("SMTP server rejected recipient %s", to), err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.API("SMTP DATA failed", err)
+	}
+	if _, err := w.Write(mimeMessage(m, time.Now())); err != nil {
+		w.Close()
+		return errors.Temporary("failed to write message", err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.API("SMTP server rejected the message", err)
+	}
+	return c.Quit()
+}
+
+// mimeMessage formats m as a MIME message with a base64-encoded HTML body.
+func mimeMessage(m *Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(k, v string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/html; charset="UTF-8"`)
+	header("Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(m.HTML))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
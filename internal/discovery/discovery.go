@@ -0,0 +1,150 @@
+// Package discovery finds channels related to a set of seed channels, to
+// propose additions to the tracked channel list. Candidates come from the
+// channels the seeds feature on their home pages and, optionally, from
+// channel searches; a candidate found through several seeds or searches
+// ranks higher.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// Client is the part of youtube.Client discovery uses.
+type Client interface {
+	FeaturedChannels(ctx context.Context, channelID string) ([]string, error)
+	SearchChannels(ctx context.Context, query string, maxResults int64) ([]string, error)
+	ChannelDetails(ctx context.Context, channelIDs []string) ([]*youtube.ChannelInfo, error)
+}
+
+// Source prefixes recorded in Candidate.Sources.
+const (
+	SourceFeatured = "featured:"
+	SourceSearch   = "search:"
+)
+
+// Options configures a discovery.
+type Options struct {
+	// Seeds are the channels whose featured channels are candidates.
+	Seeds []string
+	// Queries are channel searches whose results are candidates. Each
+	// costs youtube.SearchListCost quota units.
+	Queries []string
+	// SearchResults is the number of channels taken from each search
+	// (at most 50).
+	SearchResults int64
+	// Exclude lists channels never proposed, normally every channel of the
+	// configuration including disabled ones. Seeds are always excluded.
+	Exclude []string
+	// MinSubscribers drops candidates with fewer subscribers. Channels that
+	// hide their count are kept.
+	MinSubscribers int64
+	// Limit caps the number of candidates returned; 0 returns all.
+	Limit int
+}
+
+// Candidate is a proposed channel.
+type Candidate struct {
+	youtube.ChannelInfo
+	// Sources are where the channel was found, e.g. "featured:UC..." or
+	// "search:<query>", in discovery order.
+	Sources []string
+}
+
+// Score is the number of seeds and searches that found the candidate.
+func (c *Candidate) Score() int {
+	return len(c.Sources)
+}
+
+// Discover returns the candidates for opts, best first: by score, then by
+// subscribers. It costs one channelSections.list call per seed, one
+// search.list call per query and one channels.list call per 50 candidates.
+func Discover(ctx context.Context, client Client, opts Options) ([]*Candidate, error) {
+	excluded := make(map[string]bool, len(opts.Exclude)+len(opts.Seeds))
+	for _, id := range opts.Exclude {
+		excluded[id] = true
+	}
+	for _, id := range opts.Seeds {
+		excluded[id] = true
+	}
+
+	sources := map[string][]string{}
+	var order []string
+	add := func(id, source string) {
+		if excluded[id] {
+			return
+		}
+		if _, ok := sources[id]; !ok {
+			order = append(order, id)
+		}
+		if !slices.Contains(sources[id], source) {
+			sources[id] = append(sources[id], source)
+		}
+	}
+
+	for _, seed := range opts.Seeds {
+		ids, err := client.FeaturedChannels(ctx, seed)
+		if err != nil {
+			return nil, fmt.Errorf("featured channels of %s: %w", seed, err)
+		}
+		for _, id := range ids {
+			add(id, SourceFeatured+seed)
+		}
+	}
+	for _, query := range opts.Queries {
+		ids, err := client.SearchChannels(ctx, query, opts.SearchResults)
+		if err != nil {
+			return nil, fmt.Errorf("channel search %q: %w", query, err)
+		}
+		for _, id := range ids {
+			add(id, SourceSearch+query)
+		}
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	infos, err := client.ChannelDetails(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for _, info := range infos {
+		if !info.SubscribersHidden && info.Subscribers < opts.MinSubscribers {
+			continue
+		}
+		candidates = append(candidates, &Candidate{ChannelInfo: *info, Sources: sources[info.ID]})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score() != b.Score() {
+			return a.Score() > b.Score()
+		}
+		return a.Subscribers > b.Subscribers
+	})
+	if opts.Limit > 0 && len(candidates) > opts.Limit {
+		candidates = candidates[:opts.Limit]
+	}
+	return candidates, nil
+}
+
+// ChannelConfigs returns the candidates as entries of the channels section
+// of the configuration, with how each was found as the description.
+func ChannelConfigs(candidates []*Candidate) []config.ChannelConfig {
+	channels := make([]config.ChannelConfig, len(candidates))
+	for i, c := range candidates {
+		channels[i] = config.ChannelConfig{
+			ID:          c.ID,
+			Name:        c.Title,
+			Description: "Discovered via " + strings.Join(c.Sources, ", "),
+			Enabled:     true,
+		}
+	}
+	return channels
+}
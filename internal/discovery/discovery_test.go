@@ -0,0 +1,98 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type fakeClient struct {
+	featured map[string][]string
+	searches map[string][]string
+	infos    map[string]*youtube.ChannelInfo
+	err      error
+}
+
+func (f *fakeClient) FeaturedChannels(ctx context.Context, channelID string) ([]string, error) {
+	return f.featured[channelID], f.err
+}
+
+func (f *fakeClient) SearchChannels(ctx context.Context, query string, maxResults int64) ([]string, error) {
+	return f.searches[query], nil
+}
+
+func (f *fakeClient) ChannelDetails(ctx context.Context, channelIDs []string) ([]*youtube.ChannelInfo, error) {
+	var infos []*youtube.ChannelInfo
+	for _, id := range channelIDs {
+		if info, ok := f.infos[id]; ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func TestDiscover(t *testing.T) {
+	client := &fakeClient{
+		featured: map[string][]string{
+			"UCseed1": {"UCa", "UCb", "UCtracked", "UCseed2"},
+			"UCseed2": {"UCb", "UCsmall"},
+		},
+		searches: map[string][]string{"ai": {"UCc", "UCa"}},
+		infos: map[string]*youtube.ChannelInfo{
+			"UCa":     {ID: "UCa", Title: "A", Subscribers: 5000},
+			"UCb":     {ID: "UCb", Title: "B", Subscribers: 1000},
+			"UCc":     {ID: "UCc", Title: "C", Subscribers: 90000},
+			"UCsmall": {ID: "UCsmall", Title: "Small", Subscribers: 10},
+		},
+	}
+	got, err := Discover(context.Background(), client, Options{
+		Seeds:          []string{"UCseed1", "UCseed2"},
+		Queries:        []string{"ai"},
+		Exclude:        []string{"UCtracked"},
+		MinSubscribers: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	// UCa and UCb were found twice; UCa has more subscribers.
+	if want := []string{"UCa", "UCb", "UCc"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Discover() = %v, want %v", ids, want)
+	}
+	if want := []string{"featured:UCseed1", "search:ai"}; !reflect.DeepEqual(got[0].Sources, want) {
+		t.Errorf("sources of UCa = %v, want %v", got[0].Sources, want)
+	}
+
+	got, err = Discover(context.Background(), client, Options{Seeds: []string{"UCseed1"}, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "UCa" {
+		t.Errorf("Discover() with Limit 1 = %+v, want UCa", got)
+	}
+}
+
+func TestDiscover_Error(t *testing.T) {
+	client := &fakeClient{err: errors.New("quota exceeded")}
+	if _, err := Discover(context.Background(), client, Options{Seeds: []string{"UCseed"}}); err == nil {
+		t.Error("Discover() error = nil, want the client error")
+	}
+}
+
+func TestChannelConfigs(t *testing.T) {
+	got := ChannelConfigs([]*Candidate{{
+		ChannelInfo: youtube.ChannelInfo{ID: "UCa", Title: "A"},
+		Sources:     []string{"featured:UCseed", "search:ai"},
+	}})
+	if len(got) != 1 || got[0].ID != "UCa" || got[0].Name != "A" || !got[0].Enabled ||
+		got[0].Description != "Discovered via featured:UCseed, search:ai" {
+		t.Errorf("ChannelConfigs() = %+v", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Package enrich derives searchable attributes from video text at write
+// time: the language a title is written in and its normalized keywords.
+// Both are heuristics meant for aggregation across many videos, not for
+// judging a single title.
+package enrich
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Title is what enrichment derives from a video title.
+type Title struct {
+	// Language is the detected language as a BCP 47 code ("ja", "en",
+	// ...), or "" when the title gives too little to go on.
+	Language string
+	// Keywords are the normalized keywords of the title, in order of first
+	// appearance; see Keywords.
+	Keywords []string
+}
+
+// EnrichTitle detects the language of a title and extracts its keywords.
+func EnrichTitle(title string) Title {
+	return Title{Language: DetectLanguage(title), Keywords: Keywords(title)}
+}
+
+// normalize folds text to the form keywords are compared in: NFKC (so
+// full-width "ＡＩ" matches "ai") and lower case.
+func normalize(text string) string {
+	return strings.ToLower(norm.NFKC.String(text))
+}
+
+// script is the writing system class of a rune as far as tokenization is
+// concerned.
+type script int
+
+const (
+	scriptOther    script = iota // punctuation, symbols, spaces
+	scriptWord                   // letters and digits of space-delimited scripts
+	scriptHan                    // kanji / hanzi
+	scriptHiragana               // Japanese hiragana
+	scriptKatakana               // Japanese katakana, including the long vowel mark
+)
+
+func scriptOf(r rune) script {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return scriptHan
+	case unicode.Is(unicode.Hiragana, r):
+		return scriptHiragana
+	case unicode.Is(unicode.Katakana, r), r == 'ー':
+		return scriptKatakana
+	case unicode.IsLetter(r), unicode.IsDigit(r), unicode.Is(unicode.Mn, r):
+		return scriptWord
+	}
+	return scriptOther
+}
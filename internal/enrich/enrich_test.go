@@ -0,0 +1,49 @@
+package enrich
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"AI技術の最新ニュース", []string{"ai", "技術", "ニュース"}},
+		{"【ゆっくり解説】ＧＰＴ－５がすごい！ #shorts", []string{"gpt"}},
+		{"How to Build a Gaming PC in 2026 (Step by Step)", []string{"build", "gaming", "pc", "step"}},
+		{"Taylor Swift - Anti-Hero (Official Music Video)", []string{"taylor", "swift", "anti", "hero", "music"}},
+		{"아이유 라이브 IU Live", []string{"아이유", "라이브", "iu"}},
+		{"東京 vs 大阪 ラーメン対決 東京", []string{"東京", "大阪", "ラーメン", "対決"}},
+		{"🔥🔥🔥", nil},
+	}
+	for _, tt := range tests {
+		if got := Keywords(tt.title); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Keywords(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"AI技術の最新ニュース", "ja"},
+		{"【公式】ラーメン", "ja"},
+		{"東京旅遊攻略", "zh"},
+		{"아이유 라이브", "ko"},
+		{"How to build a gaming PC", "en"},
+		{"Cómo hacer la mejor paella de la abuela", "es"},
+		{"Les meilleurs moments du match", "fr"},
+		{"Лучшие моменты матча", "ru"},
+		{"iPhone 17 Pro Max", ""},
+		{"🔥🔥🔥", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
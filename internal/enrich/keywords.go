@@ -0,0 +1,82 @@
+package enrich
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// stopWords are dropped from keywords: function words of the languages
+// common among tracked channels and words that mark a video's format
+// rather than its subject.
+var stopWords = setOf(
+	// English
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from", "how", "i", "in", "is", "it",
+	"its", "me", "my", "of", "on", "or", "our", "so", "that", "the", "this", "to", "vs", "was", "we",
+	"what", "when", "who", "why", "will", "with", "you", "your",
+	// Spanish, Portuguese, French, German
+	"de", "del", "el", "en", "la", "las", "los", "por", "que", "un", "una", "y", "da", "do", "e", "o",
+	"os", "para", "um", "uma", "des", "du", "et", "le", "les", "pour", "une", "das", "der", "die",
+	"mit", "und", "von", "zu",
+	// Format markers
+	"shorts", "short", "official", "video", "mv", "ft", "feat", "live", "ep", "part", "vol",
+	// Japanese words written in kanji or katakana that say little
+	"動画", "公式", "今日", "今回", "最新", "解説", "紹介", "シリーズ", "ショート",
+)
+
+func setOf(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// Keywords returns the normalized keywords of a title, in order of first
+// appearance without duplicates. The title is NFKC-normalized and
+// lower-cased, then split at spaces, punctuation and changes of script, so
+// that "AI技術の最新ニュース" yields "ai", "技術", "ニュース" without a
+// dictionary: kanji and katakana runs are kept as words, while hiragana
+// runs, which are mostly particles and inflections, are dropped. Words of
+// space-delimited scripts (Latin, Cyrillic, Hangul, ...) are kept when they
+// have at least two characters, are not only digits and are not stop
+// words; single kanji are too ambiguous to count and are dropped as well.
+func Keywords(title string) []string {
+	var keywords []string
+	seen := make(map[string]bool)
+	add := func(word string, s script) {
+		if utf8.RuneCountInString(word) < 2 || stopWords[word] || seen[word] {
+			return
+		}
+		if s == scriptWord && onlyDigits(word) {
+			return
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+
+	runes := []rune(normalize(title))
+	start, cur := 0, scriptOther
+	for i, r := range runes {
+		s := scriptOf(r)
+		if s == cur {
+			continue
+		}
+		if cur != scriptOther && cur != scriptHiragana {
+			add(string(runes[start:i]), cur)
+		}
+		start, cur = i, s
+	}
+	if cur != scriptOther && cur != scriptHiragana {
+		add(string(runes[start:]), cur)
+	}
+	return keywords
+}
+
+func onlyDigits(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
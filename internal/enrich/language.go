@@ -0,0 +1,97 @@
+package enrich
+
+import (
+	"strings"
+	"unicode"
+)
+
+// latinStopWords are frequent function words of languages written in the
+// Latin script; a title's language is the one whose words it uses most.
+var latinStopWords = map[string]map[string]bool{
+	"en": setOf("the", "and", "of", "to", "in", "is", "for", "with", "on", "my", "you", "how", "this", "what", "i", "a", "it", "at", "your", "we"),
+	"es": setOf("el", "la", "los", "las", "de", "del", "que", "y", "en", "un", "una", "por", "con", "para", "es", "mi", "como", "lo"),
+	"pt": setOf("o", "os", "as", "de", "da", "do", "das", "dos", "que", "e", "em", "um", "uma", "para", "com", "no", "na", "não", "meu"),
+	"fr": setOf("le", "la", "les", "de", "des", "du", "et", "en", "un", "une", "pour", "avec", "est", "dans", "sur", "ce", "je", "mon"),
+	"de": setOf("der", "die", "das", "und", "ist", "mit", "von", "zu", "ein", "eine", "für", "auf", "ich", "nicht", "den", "im"),
+	"id": setOf("yang", "dan", "di", "ini", "itu", "dengan", "untuk", "ke", "dari", "aku", "kamu", "tidak", "ada", "cara"),
+}
+
+// latinLanguages fixes the order in which ties are broken.
+var latinLanguages = []string{"en", "es", "pt", "fr", "de", "id"}
+
+// DetectLanguage guesses the language of a short text such as a title and
+// returns its BCP 47 code, or "" when it cannot tell. Scripts decide most
+// languages: any kana makes Japanese, otherwise Hangul makes Korean and Han
+// Chinese. Latin-script text is attributed by its function words, which a
+// title may not have, so emoji, names and bare nouns return "".
+func DetectLanguage(text string) string {
+	var kana, han, hangul, latin, cyrillic, arabic, thai, devanagari, letters int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	switch {
+	case kana > 0:
+		return "ja"
+	case hangul > 0 && hangul >= han:
+		return "ko"
+	case han > 0 && han >= latin:
+		return "zh"
+	case thai*2 >= letters:
+		return "th"
+	case cyrillic*2 >= letters:
+		return "ru"
+	case arabic*2 >= letters:
+		return "ar"
+	case devanagari*2 >= letters:
+		return "hi"
+	case latin*2 >= letters:
+		return detectLatin(text)
+	}
+	return ""
+}
+
+// detectLatin attributes Latin-script text to the language with the most
+// function words in it, or "" if it has none.
+func detectLatin(text string) string {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for lang, words := range latinStopWords {
+			if words[word] {
+				counts[lang]++
+			}
+		}
+	}
+	best, bestCount := "", 0
+	for _, lang := range latinLanguages {
+		if counts[lang] > bestCount {
+			best, bestCount = lang, counts[lang]
+		}
+	}
+	return best
+}
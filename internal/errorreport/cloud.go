@@ -0,0 +1,86 @@
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"google.golang.org/api/clouderrorreporting/v1beta1"
+)
+
+// cloudBackend reports to Cloud Error Reporting using the
+// projects.events.report API.
+type cloudBackend struct {
+	events  *clouderrorreporting.ProjectsEventsService
+	project string
+	service *clouderrorreporting.ServiceContext
+}
+
+func newCloudBackend(ctx context.Context, svc Service) (*cloudBackend, error) {
+	if svc.ProjectID == "" {
+		return nil, errors.Config("Cloud Error Reporting requires a project ID", nil)
+	}
+	s, err := clouderrorreporting.NewService(ctx)
+	if err != nil {
+		return nil, errors.Config("failed to create Error Reporting client", err)
+	}
+	return &cloudBackend{
+		events:  s.Projects.Events,
+		project: "projects/" + svc.ProjectID,
+		service: &clouderrorreporting.ServiceContext{Service: svc.Name, Version: svc.Version},
+	}, nil
+}
+
+func (b *cloudBackend) send(ctx context.Context, r *Report) error {
+	_, err := b.events.Report(b.project, cloudEvent(r, b.service)).Context(ctx).Do()
+	return errors.FromGoogleAPI("failed to report error event", err)
+}
+
+// cloudEvent converts a report to an Error Reporting event. The API has no
+// fields for the error type, context or labels, so they are appended to the
+// message line above the stack trace.
+func cloudEvent(r *Report, service *clouderrorreporting.ServiceContext) *clouderrorreporting.ReportedErrorEvent {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%s: %s", r.Message, r.Error)
+	if details := describe(r); details != "" {
+		fmt.Fprintf(&msg, " (%s)", details)
+	}
+	msg.WriteString("\n\n")
+	msg.WriteString(goStack(r.Stack))
+
+	ev := &clouderrorreporting.ReportedErrorEvent{
+		EventTime:      r.Time.UTC().Format("2006-01-02T15:04:05.999999999Z"),
+		Message:        msg.String(),
+		ServiceContext: service,
+	}
+	if len(r.Stack) > 0 {
+		top := r.Stack[0]
+		ev.Context = &clouderrorreporting.ErrorContext{
+			ReportLocation: &clouderrorreporting.SourceLocation{
+				FilePath:     top.File,
+				LineNumber:   int64(top.Line),
+				FunctionName: top.Function,
+			},
+		}
+	}
+	return ev
+}
+
+// describe renders the error type, context and labels as sorted key=value
+// pairs.
+func describe(r *Report) string {
+	var pairs []string
+	for k, v := range r.Context {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	for k, v := range r.Labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	if r.Type != "" {
+		pairs = append([]string{"type=" + string(r.Type)}, pairs...)
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,182 @@
+// Package errorreport forwards error log entries to Cloud Error Reporting or
+// Sentry so that recurring failures are grouped and can be alerted on.
+package errorreport
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// queueSize bounds the reports waiting to be sent; further reports are
+// dropped rather than blocking the logging call.
+const queueSize = 64
+
+// sendTimeout bounds a single delivery to the backend.
+const sendTimeout = 10 * time.Second
+
+// Service identifies the running service in reports.
+type Service struct {
+	ProjectID   string
+	Name        string
+	Version     string
+	Environment string
+}
+
+// Report is a single error as delivered to a backend.
+type Report struct {
+	Time     time.Time
+	Severity string
+	// Message is the log message and Error the text of the logged error.
+	Message string
+	Error   string
+	// Type and Context come from the first AppError in the error chain and
+	// are empty for other errors.
+	Type    errors.ErrorType
+	Context map[string]interface{}
+	Labels  map[string]string
+	// Stack is where the AppError was created, or where it was logged for
+	// other errors; innermost frame first.
+	Stack []runtime.Frame
+}
+
+// backend delivers reports to an error tracking service.
+type backend interface {
+	send(ctx context.Context, r *Report) error
+}
+
+// Sink is a logger.Reporter that delivers reports in the background.
+type Sink struct {
+	backend backend
+	queue   chan *Report
+	pending sync.WaitGroup
+}
+
+var _ logger.Reporter = (*Sink)(nil)
+
+// New returns a Sink for the provider configured in cfg, or nil if error
+// reporting is disabled.
+func New(ctx context.Context, cfg config.LoggingConfig, svc Service) (*Sink, error) {
+	var b backend
+	var err error
+	switch cfg.ErrorReporting {
+	case "":
+		return nil, nil
+	case config.ErrorReportingCloud:
+		b, err = newCloudBackend(ctx, svc)
+	case config.ErrorReportingSentry:
+		b, err = newSentryBackend(cfg.SentryDSN, svc)
+	default:
+		return nil, fmt.Errorf("unknown error reporting provider %q", cfg.ErrorReporting)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSink(b), nil
+}
+
+func newSink(b backend) *Sink {
+	s := &Sink{backend: b, queue: make(chan *Report, queueSize)}
+	go s.run()
+	return s
+}
+
+func (s *Sink) run() {
+	for r := range s.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		if err := s.backend.send(ctx, r); err != nil {
+			// Logging the failure through the logger would report it again.
+			fmt.Fprintf(os.Stderr, "error reporting: failed to send report: %v\n", err)
+		}
+		cancel()
+		s.pending.Done()
+	}
+}
+
+// Report queues the event for delivery. It never blocks; if the queue is
+// full the event is dropped.
+func (s *Sink) Report(e logger.ErrorEvent) {
+	s.pending.Add(1)
+	select {
+	case s.queue <- newReport(e):
+	default:
+		s.pending.Done()
+		fmt.Fprintf(os.Stderr, "error reporting: queue full, dropping report %q\n", e.Message)
+	}
+}
+
+// Flush waits up to timeout for queued reports to be delivered.
+func (s *Sink) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+func newReport(e logger.ErrorEvent) *Report {
+	r := &Report{
+		Time:     e.Time,
+		Severity: e.Severity,
+		Message:  e.Message,
+		Error:    e.Err.Error(),
+		Labels:   e.Labels,
+	}
+	pcs := e.PCs
+	var appErr *errors.AppError
+	if stderrors.As(e.Err, &appErr) {
+		r.Type = appErr.Type
+		r.Context = appErr.Context
+		if len(appErr.Stack) > 0 {
+			pcs = appErr.Stack
+		}
+	}
+	r.Stack = frames(pcs)
+	return r
+}
+
+// errorsPkg prefixes the function names of frames inside the errors package,
+// which are dropped from the top of AppError stacks.
+var errorsPkg = reflect.TypeOf(errors.AppError{}).PkgPath() + "."
+
+// frames resolves program counters, dropping the errors package frames at
+// the top of the stack.
+func frames(pcs []uintptr) []runtime.Frame {
+	var out []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if f.Function != "" && (len(out) > 0 || !strings.HasPrefix(f.Function, errorsPkg)) {
+			out = append(out, f)
+		}
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// goStack formats frames like a Go panic trace, the form Cloud Error
+// Reporting parses to group errors.
+func goStack(frames []runtime.Frame) string {
+	var b strings.Builder
+	b.WriteString("goroutine 1 [running]:\n")
+	for _, f := range frames {
+		fmt.Fprintf(&b, "%s()\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
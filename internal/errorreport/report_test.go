@@ -0,0 +1,159 @@
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+func callerPCs() []uintptr {
+	var pcs [8]uintptr
+	n := runtime.Callers(2, pcs[:])
+	return pcs[:n]
+}
+
+func TestNewReport_AppError(t *testing.T) {
+	appErr := errors.NewWithContext(errors.ErrTypeStorage, "insert failed", nil, map[string]interface{}{"table": "video_statistics"})
+	r := newReport(logger.ErrorEvent{
+		Severity: "ERROR",
+		Message:  "Job failed",
+		Err:      fmt.Errorf("run: %w", appErr),
+		PCs:      callerPCs(),
+	})
+
+	if r.Type != errors.ErrTypeStorage {
+		t.Errorf("Type = %q, want %q", r.Type, errors.ErrTypeStorage)
+	}
+	if r.Context["table"] != "video_statistics" {
+		t.Errorf("Context = %v", r.Context)
+	}
+	if len(r.Stack) == 0 || !strings.HasSuffix(r.Stack[0].Function, "TestNewReport_AppError") {
+		t.Errorf("Stack should start where the AppError was created, got %+v", r.Stack)
+	}
+}
+
+func TestNewReport_PlainError(t *testing.T) {
+	r := newReport(logger.ErrorEvent{Message: "failed", Err: stderrors.New("boom"), PCs: callerPCs()})
+	if r.Type != "" || r.Context != nil {
+		t.Errorf("plain errors should have no type or context, got %q %v", r.Type, r.Context)
+	}
+	if len(r.Stack) == 0 || !strings.HasSuffix(r.Stack[0].Function, "TestNewReport_PlainError") {
+		t.Errorf("Stack should be the logging call site, got %+v", r.Stack)
+	}
+}
+
+func TestCloudEvent(t *testing.T) {
+	r := &Report{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "Job failed",
+		Error:   "[API] quota",
+		Type:    errors.ErrTypeAPI,
+		Context: map[string]interface{}{"channel_id": "UC1"},
+		Labels:  map[string]string{"run_id": "r1"},
+		Stack:   []runtime.Frame{{Function: "main.runJob", File: "/src/job.go", Line: 32}},
+	}
+	ev := cloudEvent(r, nil)
+
+	firstLine, stack, _ := strings.Cut(ev.Message, "\n\n")
+	if want := "Job failed: [API] quota (type=API, channel_id=UC1, run_id=r1)"; firstLine != want {
+		t.Errorf("first line = %q, want %q", firstLine, want)
+	}
+	if want := "goroutine 1 [running]:\nmain.runJob()\n\t/src/job.go:32\n"; stack != want {
+		t.Errorf("stack = %q, want %q", stack, want)
+	}
+	if loc := ev.Context.ReportLocation; loc.FunctionName != "main.runJob" || loc.LineNumber != 32 {
+		t.Errorf("ReportLocation = %+v", loc)
+	}
+	if ev.EventTime != "2024-01-02T03:04:05Z" {
+		t.Errorf("EventTime = %q", ev.EventTime)
+	}
+}
+
+func TestNewSentryBackend_DSN(t *testing.T) {
+	b, err := newSentryBackend("https://abc@o1.ingest.sentry.io/42", Service{Version: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.endpoint != "https://o1.ingest.sentry.io/api/42/store/" {
+		t.Errorf("endpoint = %q", b.endpoint)
+	}
+	if !strings.Contains(b.auth, "sentry_key=abc") {
+		t.Errorf("auth = %q", b.auth)
+	}
+
+	for _, dsn := range []string{"https://o1.ingest.sentry.io/42", "https://abc@o1.ingest.sentry.io/", "not a dsn"} {
+		if _, err := newSentryBackend(dsn, Service{}); err == nil {
+			t.Errorf("newSentryBackend(%q) should fail", dsn)
+		}
+	}
+}
+
+func TestSink_Sentry(t *testing.T) {
+	var got map[string]interface{}
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("X-Sentry-Auth")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	b, err := newSentryBackend(strings.Replace(srv.URL, "://", "://key@", 1)+"/7", Service{Name: "ytt", Environment: "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := newSink(b)
+	sink.Report(logger.ErrorEvent{
+		Severity: "CRITICAL",
+		Message:  "Failed to load configuration",
+		Err:      errors.Config("bad value", nil),
+		Labels:   map[string]string{"run_id": "r1"},
+		PCs:      callerPCs(),
+	})
+	sink.Flush(5 * time.Second)
+
+	if path != "/api/7/store/" || !strings.Contains(auth, "sentry_key=key") {
+		t.Fatalf("request to %q with auth %q", path, auth)
+	}
+	if got["level"] != "fatal" || got["environment"] != "production" {
+		t.Errorf("level/environment = %v/%v", got["level"], got["environment"])
+	}
+	tags := got["tags"].(map[string]interface{})
+	if tags["error_type"] != "CONFIG" || tags["run_id"] != "r1" {
+		t.Errorf("tags = %v", tags)
+	}
+	exc := got["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})
+	frames := exc["stacktrace"].(map[string]interface{})["frames"].([]interface{})
+	last := frames[len(frames)-1].(map[string]interface{})
+	if !strings.HasSuffix(last["function"].(string), "TestSink_Sentry") || last["in_app"] != true {
+		t.Errorf("innermost frame = %v, want the test function last", last)
+	}
+}
+
+type failingBackend struct{ calls int }
+
+func (b *failingBackend) send(context.Context, *Report) error {
+	b.calls++
+	return stderrors.New("unavailable")
+}
+
+func TestSink_FlushAfterFailure(t *testing.T) {
+	b := &failingBackend{}
+	sink := newSink(b)
+	for i := 0; i < 3; i++ {
+		sink.Report(logger.ErrorEvent{Message: "x", Err: stderrors.New("boom")})
+	}
+	sink.Flush(5 * time.Second)
+	if b.calls != 3 {
+		t.Errorf("backend called %d times, want 3", b.calls)
+	}
+}
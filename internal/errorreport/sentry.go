@@ -0,0 +1,131 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+)
+
+// sentryBackend posts events to Sentry's store endpoint.
+type sentryBackend struct {
+	client   *http.Client
+	endpoint string
+	auth     string
+	svc      Service
+}
+
+// newSentryBackend parses a DSN of the form
+// https://<public_key>@<host>[/<path>]/<project_id>.
+func newSentryBackend(dsn string, svc Service) (*sentryBackend, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Config("invalid Sentry DSN", err)
+	}
+	key := u.User.Username()
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if key == "" || u.Host == "" || i < 0 || path[i+1:] == "" {
+		return nil, errors.Config("invalid Sentry DSN: expected https://<key>@<host>/<project_id>", nil)
+	}
+	return &sentryBackend{
+		client:   &http.Client{},
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:i], path[i+1:]),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=youtube-trend-tracker/%s, sentry_key=%s", svc.Version, key),
+		svc:      svc,
+	}, nil
+}
+
+func (b *sentryBackend) send(ctx context.Context, r *Report) error {
+	body, err := json.Marshal(sentryEvent(r, b.svc))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", b.auth)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return errors.Temporary("failed to send Sentry event", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.API(fmt.Sprintf("Sentry returned %s", resp.Status), nil)
+	}
+	return nil
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// sentryEvent converts a report to a Sentry event. The AppError type becomes
+// the exception type and a tag, and the AppError context goes to extra.
+func sentryEvent(r *Report, svc Service) map[string]interface{} {
+	// Sentry expects frames oldest first.
+	frames := make([]sentryFrame, len(r.Stack))
+	for i, f := range r.Stack {
+		frames[len(frames)-1-i] = sentryFrame{
+			Function: f.Function,
+			Filename: f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, "github.com/lancelop89/youtube-trend-tracker/"),
+		}
+	}
+
+	excType := "error"
+	tags := make(map[string]string, len(r.Labels)+1)
+	for k, v := range r.Labels {
+		tags[k] = v
+	}
+	if r.Type != "" {
+		excType = string(r.Type)
+		tags["error_type"] = string(r.Type)
+	}
+
+	level := "error"
+	if r.Severity == "CRITICAL" {
+		level = "fatal"
+	}
+
+	return map[string]interface{}{
+		"event_id":    eventID(),
+		"timestamp":   r.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"level":       level,
+		"platform":    "go",
+		"logger":      svc.Name,
+		"release":     svc.Version,
+		"environment": svc.Environment,
+		"message":     map[string]string{"formatted": r.Message},
+		"tags":        tags,
+		"extra":       r.Context,
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{{
+				"type":       excType,
+				"value":      r.Error,
+				"stacktrace": map[string]interface{}{"frames": frames},
+			}},
+		},
+	}
+}
+
+// eventID returns a random 32 character hex ID.
+func eventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
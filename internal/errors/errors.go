@@ -1,7 +1,9 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
+	"runtime"
 	"time"
 )
 
@@ -21,6 +23,18 @@ const (
 	ErrTypeTemporary ErrorType = "TEMPORARY"
 )
 
+// Sentinel errors for conditions callers commonly branch on. AppErrors
+// carrying one match it with errors.Is, whatever their message.
+var (
+	// ErrQuotaExceeded means the daily API quota is used up; retrying before
+	// the quota resets is pointless.
+	ErrQuotaExceeded = stderrors.New("quota exceeded")
+	// ErrNotFound means the requested resource does not exist.
+	ErrNotFound = stderrors.New("not found")
+	// ErrRateLimited means requests are being throttled and may succeed later.
+	ErrRateLimited = stderrors.New("rate limited")
+)
+
 // AppError represents a structured application error
 type AppError struct {
 	Type      ErrorType
@@ -29,6 +43,11 @@ type AppError struct {
 	Timestamp time.Time
 	Context   map[string]interface{}
 	Retriable bool
+	// Sentinel, if set, is one of the Err* sentinels this error matches.
+	Sentinel error
+	// Stack is the call stack where the error was created, innermost frame
+	// first. It starts inside this package.
+	Stack []uintptr
 }
 
 // Error implements the error interface
@@ -44,6 +63,18 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is the error's sentinel, so that
+// errors.Is(err, ErrQuotaExceeded) works on wrapped AppErrors.
+func (e *AppError) Is(target error) bool {
+	return e.Sentinel != nil && e.Sentinel == target
+}
+
+// WithSentinel sets the sentinel the error matches and returns the error.
+func (e *AppError) WithSentinel(sentinel error) *AppError {
+	e.Sentinel = sentinel
+	return e
+}
+
 // IsRetriable returns whether the error is retriable
 func (e *AppError) IsRetriable() bool {
 	return e.Retriable
@@ -58,6 +89,7 @@ func New(errType ErrorType, message string, err error) *AppError {
 		Timestamp: time.Now(),
 		Context:   make(map[string]interface{}),
 		Retriable: errType == ErrTypeTemporary,
+		Stack:     callers(),
 	}
 }
 
@@ -70,9 +102,17 @@ func NewWithContext(errType ErrorType, message string, err error, context map[st
 		Timestamp: time.Now(),
 		Context:   context,
 		Retriable: errType == ErrTypeTemporary,
+		Stack:     callers(),
 	}
 }
 
+// callers records the current call stack for AppError.Stack.
+func callers() []uintptr {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
 // Config creates a configuration error
 func Config(message string, err error) *AppError {
 	return New(ErrTypeConfig, message, err)
@@ -100,15 +140,16 @@ func Temporary(message string, err error) *AppError {
 	return e
 }
 
-// IsAppError checks if an error is an AppError
+// IsAppError checks if an error is, or wraps, an AppError
 func IsAppError(err error) bool {
-	_, ok := err.(*AppError)
-	return ok
+	var appErr *AppError
+	return stderrors.As(err, &appErr)
 }
 
-// GetType returns the error type if it's an AppError
+// GetType returns the error type of the first AppError in err's chain
 func GetType(err error) (ErrorType, bool) {
-	if appErr, ok := err.(*AppError); ok {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
 		return appErr.Type, true
 	}
 	return "", false
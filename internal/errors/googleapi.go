@@ -0,0 +1,60 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Reasons reported in googleapi.Error items for quota and rate limits.
+var (
+	quotaReasons     = map[string]bool{"quotaExceeded": true, "dailyLimitExceeded": true}
+	rateLimitReasons = map[string]bool{"rateLimitExceeded": true, "userRateLimitExceeded": true}
+)
+
+// Classify returns the sentinel matching a Google API error: ErrQuotaExceeded,
+// ErrRateLimited or ErrNotFound. It returns nil for any other error. This is
+// the single place where googleapi status codes and reasons are interpreted.
+func Classify(err error) error {
+	var gerr *googleapi.Error
+	if !stderrors.As(err, &gerr) {
+		return nil
+	}
+	for _, item := range gerr.Errors {
+		switch {
+		case quotaReasons[item.Reason]:
+			return ErrQuotaExceeded
+		case rateLimitReasons[item.Reason]:
+			return ErrRateLimited
+		}
+	}
+	switch gerr.Code {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FromGoogleAPI wraps a Google API error in an AppError. Rate limits and
+// server errors are Temporary so retry.Do retries them; quota exhaustion,
+// missing resources and other client errors are API errors. The sentinel
+// from Classify is attached so callers can test it with errors.Is. Errors
+// that are not googleapi.Errors are returned unchanged.
+func FromGoogleAPI(message string, err error) error {
+	var gerr *googleapi.Error
+	if !stderrors.As(err, &gerr) {
+		return err
+	}
+
+	sentinel := Classify(err)
+	var appErr *AppError
+	if sentinel == ErrRateLimited || gerr.Code >= 500 {
+		appErr = Temporary(message, err)
+	} else {
+		appErr = API(message, err)
+	}
+	return appErr.WithSentinel(sentinel)
+}
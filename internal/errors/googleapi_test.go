@@ -0,0 +1,96 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestFromGoogleAPI(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		retriable bool
+	}{
+		{
+			name:     "quota exceeded",
+			err:      &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}},
+			sentinel: ErrQuotaExceeded,
+		},
+		{
+			name:      "rate limit reason",
+			err:       &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}},
+			sentinel:  ErrRateLimited,
+			retriable: true,
+		},
+		{
+			name:      "too many requests",
+			err:       &googleapi.Error{Code: 429},
+			sentinel:  ErrRateLimited,
+			retriable: true,
+		},
+		{
+			name:     "not found",
+			err:      &googleapi.Error{Code: 404},
+			sentinel: ErrNotFound,
+		},
+		{
+			name:      "server error",
+			err:       &googleapi.Error{Code: 503},
+			retriable: true,
+		},
+		{
+			name: "forbidden",
+			err:  &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromGoogleAPI("YouTube API error", tt.err)
+			wrapped := fmt.Errorf("videos.list: %w", err)
+
+			for _, s := range []error{ErrQuotaExceeded, ErrRateLimited, ErrNotFound} {
+				if got, want := stderrors.Is(wrapped, s), s == tt.sentinel; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", s, got, want)
+				}
+			}
+
+			var appErr *AppError
+			if !stderrors.As(wrapped, &appErr) {
+				t.Fatalf("errors.As(err, *AppError) failed for %v", err)
+			}
+			if appErr.IsRetriable() != tt.retriable {
+				t.Errorf("IsRetriable() = %v, want %v", appErr.IsRetriable(), tt.retriable)
+			}
+
+			var gerr *googleapi.Error
+			if !stderrors.As(wrapped, &gerr) {
+				t.Error("the googleapi.Error should stay reachable with errors.As")
+			}
+		})
+	}
+}
+
+func TestFromGoogleAPI_OtherErrors(t *testing.T) {
+	plain := stderrors.New("connection reset")
+	if got := FromGoogleAPI("YouTube API error", plain); got != plain {
+		t.Errorf("FromGoogleAPI() = %v, want the error unchanged", got)
+	}
+	if Classify(plain) != nil {
+		t.Error("Classify() of a non-API error should be nil")
+	}
+}
+
+func TestGetType_Wrapped(t *testing.T) {
+	err := fmt.Errorf("context: %w", Storage("insert failed", nil))
+	if typ, ok := GetType(err); !ok || typ != ErrTypeStorage {
+		t.Errorf("GetType() = %v, %v, want %v, true", typ, ok, ErrTypeStorage)
+	}
+	if !IsAppError(err) {
+		t.Error("IsAppError() = false for a wrapped AppError")
+	}
+}
@@ -0,0 +1,305 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// ErrQuotaBudgetExhausted is returned by Backfill when the configured quota
+// budget ran out before all channels were complete. Progress is checkpointed
+// and a later run resumes where this one stopped.
+var ErrQuotaBudgetExhausted = stderrors.New("backfill quota budget exhausted")
+
+// Quota cost in units of the YouTube Data API calls made by a backfill or
+// a regular fetch.
+const (
+	costChannelsList      = 1
+	costPlaylistItemsList = 1
+	costVideosList        = 1
+	videosPerListCall     = 50
+)
+
+// BackfillClient is the subset of the YouTube client used for backfills.
+type BackfillClient interface {
+	UploadsPlaylist(ctx context.Context, channelID string) (string, string, error)
+	ListPlaylistPage(ctx context.Context, playlistID, pageToken string, pageSize int64) ([]string, string, error)
+	FetchVideosByID(ctx context.Context, videoIDs []string) ([]*youtube.Video, error)
+}
+
+// BackfillLoader writes backfilled records, typically via load jobs.
+type BackfillLoader interface {
+	LoadVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error
+}
+
+// Checkpoint records how far the backfill of a channel has progressed.
+type Checkpoint struct {
+	ChannelID    string    `json:"channel_id"`
+	PageToken    string    `json:"page_token,omitempty"`
+	VideosLoaded int       `json:"videos_loaded"`
+	Done         bool      `json:"done"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CheckpointStore persists backfill progress between runs.
+type CheckpointStore interface {
+	Load(channelID string) (*Checkpoint, error)
+	Save(cp *Checkpoint) error
+}
+
+// BackfillOptions tunes a backfill run.
+type BackfillOptions struct {
+	// QuotaBudget is the maximum number of API units to spend (0 = unlimited).
+	QuotaBudget int
+	// PageDelay throttles requests by sleeping between playlist pages.
+	PageDelay time.Duration
+	// FlushSize is the number of records buffered per load job.
+	FlushSize int
+	// Location is the timezone used for the dt partition (JST when nil).
+	Location *time.Location
+	// Playlists marks the IDs passed to Backfill that are playlists rather
+	// than channels; the playlist itself is walked instead of an uploads
+	// playlist.
+	Playlists map[string]bool
+	// CategoryNames maps video category IDs to the names stored in
+	// category_name. Nil leaves the names empty.
+	CategoryNames map[string]string
+}
+
+// BackfillResult summarizes a backfill run.
+type BackfillResult struct {
+	CompletedChannels []string
+	VideosLoaded      int
+	QuotaUsed         int
+}
+
+// Backfiller walks channels' entire uploads playlists and loads every video.
+type Backfiller struct {
+	ytClient    BackfillClient
+	loader      BackfillLoader
+	checkpoints CheckpointStore
+	opts        BackfillOptions
+	quotaUsed   int
+}
+
+// NewBackfiller creates a new Backfiller.
+func NewBackfiller(ytClient BackfillClient, loader BackfillLoader, checkpoints CheckpointStore, opts BackfillOptions) *Backfiller {
+	if opts.FlushSize <= 0 {
+		opts.FlushSize = 1000
+	}
+	return &Backfiller{
+		ytClient:    ytClient,
+		loader:      loader,
+		checkpoints: checkpoints,
+		opts:        opts,
+	}
+}
+
+// Backfill loads the full upload history of each channel, resuming from any
+// saved checkpoint.
+func (b *Backfiller) Backfill(ctx context.Context, channelIDs []string) (*BackfillResult, error) {
+	result := &BackfillResult{}
+	for _, channelID := range channelIDs {
+		loaded, err := b.backfillChannel(ctx, channelID)
+		result.VideosLoaded += loaded
+		result.QuotaUsed = b.quotaUsed
+		if err != nil {
+			return result, err
+		}
+		result.CompletedChannels = append(result.CompletedChannels, channelID)
+	}
+	return result, nil
+}
+
+func (b *Backfiller) backfillChannel(ctx context.Context, channelID string) (int, error) {
+	log := logger.FromContext(ctx).With(map[string]string{"channel_id": channelID})
+	ctx = logger.WithContext(ctx, log)
+
+	cp, err := b.checkpoints.Load(channelID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load checkpoint for %s: %w", channelID, err)
+	}
+	if cp.Done {
+		log.Info(fmt.Sprintf("Backfill already complete for channel %s", channelID), nil)
+		return 0, nil
+	}
+
+	playlist := b.opts.Playlists[channelID]
+	playlistID := channelID
+	if !playlist {
+		if !b.spend(costChannelsList) {
+			return 0, ErrQuotaBudgetExhausted
+		}
+		playlistID, _, err = b.ytClient.UploadsPlaylist(ctx, channelID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	loaded := 0
+	var buffer []*storage.VideoStatsRecord
+	pageToken := cp.PageToken
+	snapshotTs := time.Now()
+	dt := snapshotDate(snapshotTs, b.opts.Location)
+
+	flush := func(next string, done bool) error {
+		if err := b.loader.LoadVideoStats(ctx, buffer); err != nil {
+			return fmt.Errorf("failed to load backfill records for %s: %w", channelID, err)
+		}
+		loaded += len(buffer)
+		cp.VideosLoaded += len(buffer)
+		buffer = buffer[:0]
+
+		cp.PageToken = next
+		cp.Done = done
+		cp.UpdatedAt = time.Now()
+		return b.checkpoints.Save(cp)
+	}
+
+	for {
+		if !b.spend(costPlaylistItemsList) {
+			return loaded, b.stopForBudget(ctx, cp, pageToken, buffer, flush)
+		}
+		ids, next, err := b.ytClient.ListPlaylistPage(ctx, playlistID, pageToken, 0)
+		if err != nil {
+			return loaded, err
+		}
+
+		if !b.spend(costVideosList * ((len(ids) + videosPerListCall - 1) / videosPerListCall)) {
+			return loaded, b.stopForBudget(ctx, cp, pageToken, buffer, flush)
+		}
+		videos, err := b.ytClient.FetchVideosByID(ctx, ids)
+		if err != nil {
+			return loaded, err
+		}
+
+		now := time.Now()
+		for _, v := range videos {
+			var r *storage.VideoStatsRecord
+			if playlist {
+				r = newPlaylistVideoStatsRecord(channelID, v, dt, snapshotTs, now)
+			} else {
+				r = newVideoStatsRecord(channelID, v, dt, snapshotTs, now)
+			}
+			r.CategoryName = b.opts.CategoryNames[v.CategoryID]
+			buffer = append(buffer, r)
+		}
+		pageToken = next
+
+		if next == "" {
+			if err := flush("", true); err != nil {
+				return loaded, err
+			}
+			log.Info(fmt.Sprintf("Backfill complete for channel %s: %d videos", channelID, cp.VideosLoaded), nil)
+			return loaded, nil
+		}
+		if len(buffer) >= b.opts.FlushSize {
+			if err := flush(next, false); err != nil {
+				return loaded, err
+			}
+			log.Info(fmt.Sprintf("Backfill checkpoint for channel %s: %d videos", channelID, cp.VideosLoaded), nil)
+		}
+
+		if b.opts.PageDelay > 0 {
+			select {
+			case <-time.After(b.opts.PageDelay):
+			case <-ctx.Done():
+				return loaded, ctx.Err()
+			}
+		}
+	}
+}
+
+// stopForBudget flushes buffered records so that the checkpoint points at the
+// first page that has not been loaded yet.
+func (b *Backfiller) stopForBudget(ctx context.Context, cp *Checkpoint, pageToken string, buffer []*storage.VideoStatsRecord, flush func(string, bool) error) error {
+	if len(buffer) > 0 {
+		if err := flush(pageToken, false); err != nil {
+			return err
+		}
+	}
+	logger.FromContext(ctx).Warning("Backfill stopped: quota budget exhausted", nil, map[string]string{
+		"quota_used": fmt.Sprintf("%d", b.quotaUsed),
+	})
+	return ErrQuotaBudgetExhausted
+}
+
+// spend reserves quota units, returning false if the budget would be exceeded.
+func (b *Backfiller) spend(units int) bool {
+	if b.opts.QuotaBudget > 0 && b.quotaUsed+units > b.opts.QuotaBudget {
+		return false
+	}
+	b.quotaUsed += units
+	return true
+}
+
+// FileCheckpointStore keeps checkpoints for all channels in a JSON file.
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointStore creates a checkpoint store backed by the given file.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load returns the checkpoint for a channel, or a fresh one if none exists.
+func (s *FileCheckpointStore) Load(channelID string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	if cp, ok := all[channelID]; ok {
+		return cp, nil
+	}
+	return &Checkpoint{ChannelID: channelID}, nil
+}
+
+// Save persists the checkpoint, replacing any previous one for the channel.
+func (s *FileCheckpointStore) Save(cp *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	all[cp.ChannelID] = cp
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileCheckpointStore) readAll() (map[string]*Checkpoint, error) {
+	all := make(map[string]*Checkpoint)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoints %s: %w", s.path, err)
+	}
+	return all, nil
+}
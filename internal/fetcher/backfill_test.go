@@ -0,0 +1,148 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// mockBackfillClient serves a playlist split into pages of pageSize IDs.
+type mockBackfillClient struct {
+	ids      []string
+	pageSize int
+	pages    int
+}
+
+func (m *mockBackfillClient) UploadsPlaylist(ctx context.Context, channelID string) (string, string, error) {
+	return "UU" + channelID, channelID, nil
+}
+
+func (m *mockBackfillClient) ListPlaylistPage(ctx context.Context, playlistID, pageToken string, pageSize int64) ([]string, string, error) {
+	m.pages++
+	start := 0
+	if pageToken != "" {
+		fmt.Sscanf(pageToken, "p%d", &start)
+	}
+	end := start + m.pageSize
+	next := fmt.Sprintf("p%d", end)
+	if end >= len(m.ids) {
+		end = len(m.ids)
+		next = ""
+	}
+	return m.ids[start:end], next, nil
+}
+
+func (m *mockBackfillClient) FetchVideosByID(ctx context.Context, videoIDs []string) ([]*youtube.Video, error) {
+	var videos []*youtube.Video
+	for _, id := range videoIDs {
+		videos = append(videos, &youtube.Video{ID: id})
+	}
+	return videos, nil
+}
+
+type mockLoader struct {
+	loads   int
+	records []*storage.VideoStatsRecord
+}
+
+func (m *mockLoader) LoadVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	m.loads++
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func makeIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%03d", i)
+	}
+	return ids
+}
+
+func TestBackfill_FullHistory(t *testing.T) {
+	yt := &mockBackfillClient{ids: makeIDs(120), pageSize: 50}
+	loader := &mockLoader{}
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "cp.json"))
+
+	b := NewBackfiller(yt, loader, store, BackfillOptions{FlushSize: 100})
+	result, err := b.Backfill(context.Background(), []string{"ch1"})
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if result.VideosLoaded != 120 || len(loader.records) != 120 {
+		t.Errorf("loaded %d (%d records), want 120", result.VideosLoaded, len(loader.records))
+	}
+	if loader.loads != 2 {
+		t.Errorf("ran %d load jobs, want 2 (flush at 100 + final)", loader.loads)
+	}
+	// 1 channels.list + 3 playlistItems pages + 3 videos.list calls
+	if result.QuotaUsed != 7 {
+		t.Errorf("QuotaUsed = %d, want 7", result.QuotaUsed)
+	}
+
+	cp, _ := store.Load("ch1")
+	if !cp.Done || cp.VideosLoaded != 120 {
+		t.Errorf("checkpoint = %+v, want done with 120 videos", cp)
+	}
+
+	// A second run is a no-op
+	again, err := NewBackfiller(yt, loader, store, BackfillOptions{}).Backfill(context.Background(), []string{"ch1"})
+	if err != nil || again.VideosLoaded != 0 {
+		t.Errorf("second Backfill() = %+v, %v; want no-op", again, err)
+	}
+}
+
+func TestBackfill_ResumesAfterBudgetExhausted(t *testing.T) {
+	yt := &mockBackfillClient{ids: makeIDs(150), pageSize: 50}
+	loader := &mockLoader{}
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "cp.json"))
+
+	// 1 (channel) + 2 (page 1) + 2 (page 2) = 5 units, then stop
+	_, err := NewBackfiller(yt, loader, store, BackfillOptions{QuotaBudget: 5}).Backfill(context.Background(), []string{"ch1"})
+	if !errors.Is(err, ErrQuotaBudgetExhausted) {
+		t.Fatalf("Backfill() error = %v, want ErrQuotaBudgetExhausted", err)
+	}
+	if len(loader.records) != 100 {
+		t.Fatalf("loaded %d records before stopping, want 100", len(loader.records))
+	}
+
+	cp, _ := store.Load("ch1")
+	if cp.Done || cp.PageToken != "p100" {
+		t.Fatalf("checkpoint = %+v, want resume token p100", cp)
+	}
+
+	result, err := NewBackfiller(yt, loader, store, BackfillOptions{}).Backfill(context.Background(), []string{"ch1"})
+	if err != nil {
+		t.Fatalf("resumed Backfill() error = %v", err)
+	}
+	if result.VideosLoaded != 50 || len(loader.records) != 150 {
+		t.Errorf("resumed run loaded %d (total %d), want 50 (150)", result.VideosLoaded, len(loader.records))
+	}
+}
+
+func TestBackfill_Playlist(t *testing.T) {
+	yt := &mockBackfillClient{ids: makeIDs(60), pageSize: 50}
+	loader := &mockLoader{}
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "cp.json"))
+
+	b := NewBackfiller(yt, loader, store, BackfillOptions{Playlists: map[string]bool{"PLbest": true}})
+	result, err := b.Backfill(context.Background(), []string{"PLbest"})
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	// No channels.list: 2 playlistItems pages + 2 videos.list calls
+	if result.VideosLoaded != 60 || result.QuotaUsed != 4 {
+		t.Errorf("result = %+v, want 60 videos for 4 units", result)
+	}
+	if r := loader.records[0]; r.PlaylistID != "PLbest" {
+		t.Errorf("record playlist_id = %q, want PLbest", r.PlaylistID)
+	}
+}
@@ -0,0 +1,40 @@
+package fetcher
+
+// QuotaBudget makes a run defer its remaining channels once the quota left
+// is predicted not to cover the next one. Channels are processed in the
+// order given, so with the highest priority first the deferred ones are the
+// lowest-priority channels.
+type QuotaBudget struct {
+	// Remaining is the API quota available when the run starts.
+	Remaining int64
+	// Used returns the units spent since the run started, e.g. the YouTube
+	// client's QuotaUsed.
+	Used func() int64
+	// ChannelCost is the expected cost of one channel (see
+	// EstimateChannelCost). Once channels have been attempted, the run's
+	// average cost per channel is used instead when it is higher.
+	ChannelCost int64
+}
+
+// EstimateChannelCost returns the quota cost of fetching one channel's
+// latest maxVideos videos: one channels.list call and one playlistItems.list
+// and videos.list call per 50 videos. Unlimited fetches are estimated as one
+// page; the run's average takes over after the first channel.
+func EstimateChannelCost(maxVideos int64) int64 {
+	pages := (maxVideos + videosPerListCall - 1) / videosPerListCall
+	if pages < 1 {
+		pages = 1
+	}
+	return costChannelsList + pages*(costPlaylistItemsList+costVideosList)
+}
+
+// exhausted reports whether the quota left is predicted to run out during
+// the next channel, after attempted channels.
+func (b *QuotaBudget) exhausted(attempted int) bool {
+	used := b.Used()
+	cost := b.ChannelCost
+	if attempted > 0 {
+		cost = max(cost, used/int64(attempted))
+	}
+	return b.Remaining-used < cost
+}
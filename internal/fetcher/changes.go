@@ -0,0 +1,99 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// metadataLookbackDays bounds how far back the previous snapshot is searched
+// when detecting metadata changes.
+const metadataLookbackDays = 30
+
+// MetadataChangeStore reads previous video metadata and records changes.
+type MetadataChangeStore interface {
+	LatestMetadata(ctx context.Context, channelID string, videoIDs []string, since civil.Date) (map[string]*storage.VideoMetadata, error)
+	InsertMetadataChanges(ctx context.Context, records []*storage.MetadataChangeRecord) error
+}
+
+// Metadata fields tracked for change history.
+const (
+	FieldTitle        = "title"
+	FieldDescription  = "description"
+	FieldTags         = "tags"
+	FieldThumbnailURL = "thumbnail_url"
+)
+
+// detectMetadataChanges compares the fetched videos with their previous
+// snapshots and returns one change record per modified field, dated dt, the
+// run's snapshot date. Previous snapshots are looked up from dt back.
+func (f *Fetcher) detectMetadataChanges(ctx context.Context, channelID string, videos []*youtube.Video, dt civil.Date) ([]*storage.MetadataChangeRecord, error) {
+	ids := make([]string, 0, len(videos))
+	for _, v := range videos {
+		ids = append(ids, v.ID)
+	}
+
+	previous, err := f.opts.MetadataChanges.LatestMetadata(ctx, channelID, ids, dt.AddDays(-metadataLookbackDays))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var changes []*storage.MetadataChangeRecord
+	for _, v := range videos {
+		prev, ok := previous[v.ID]
+		if !ok {
+			continue
+		}
+		for _, c := range diffMetadata(prev, v) {
+			c.Dt = dt
+			c.DetectedAt = now
+			c.ChannelID = channelID
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// diffMetadata returns the fields that differ between a previous snapshot and
+// the current video. Fields that were never captured previously are ignored so
+// that newly added columns do not produce spurious changes.
+func diffMetadata(prev *storage.VideoMetadata, cur *youtube.Video) []*storage.MetadataChangeRecord {
+	var changes []*storage.MetadataChangeRecord
+	add := func(field, oldValue, newValue string) {
+		changes = append(changes, &storage.MetadataChangeRecord{
+			VideoID:  cur.ID,
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	if prev.Title != cur.Title {
+		add(FieldTitle, prev.Title, cur.Title)
+	}
+	if prev.Description != "" && prev.Description != cur.Description {
+		add(FieldDescription, prev.Description, cur.Description)
+	}
+	if !slices.Equal(prev.Tags, cur.Tags) {
+		add(FieldTags, encodeTags(prev.Tags), encodeTags(cur.Tags))
+	}
+	if prev.ThumbnailURL != "" && prev.ThumbnailURL != cur.ThumbnailURL {
+		add(FieldThumbnailURL, prev.ThumbnailURL, cur.ThumbnailURL)
+	}
+	return changes
+}
+
+// encodeTags renders a tag list as a JSON array for storage in a string column.
+func encodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	b, _ := json.Marshal(tags)
+	return string(b)
+}
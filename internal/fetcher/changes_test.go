@@ -0,0 +1,141 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type mockMetadataStore struct {
+	previous map[string]*storage.VideoMetadata
+	changes  []*storage.MetadataChangeRecord
+	since    civil.Date
+}
+
+func (m *mockMetadataStore) LatestMetadata(ctx context.Context, channelID string, videoIDs []string, since civil.Date) (map[string]*storage.VideoMetadata, error) {
+	m.since = since
+	return m.previous, nil
+}
+
+func (m *mockMetadataStore) InsertMetadataChanges(ctx context.Context, records []*storage.MetadataChangeRecord) error {
+	m.changes = append(m.changes, records...)
+	return nil
+}
+
+func TestDiffMetadata(t *testing.T) {
+	prev := &storage.VideoMetadata{
+		VideoID:      "v1",
+		Title:        "Old title",
+		Description:  "desc",
+		Tags:         []string{"a", "b"},
+		ThumbnailURL: "https://i.ytimg.com/vi/v1/old.jpg",
+	}
+
+	tests := []struct {
+		name   string
+		cur    *youtube.Video
+		fields []string
+	}{
+		{
+			name:   "unchanged",
+			cur:    &youtube.Video{ID: "v1", Title: "Old title", Description: "desc", Tags: []string{"a", "b"}, ThumbnailURL: prev.ThumbnailURL},
+			fields: nil,
+		},
+		{
+			name:   "title and tags",
+			cur:    &youtube.Video{ID: "v1", Title: "New title", Description: "desc", Tags: []string{"a"}, ThumbnailURL: prev.ThumbnailURL},
+			fields: []string{FieldTitle, FieldTags},
+		},
+		{
+			name:   "thumbnail",
+			cur:    &youtube.Video{ID: "v1", Title: "Old title", Description: "desc", Tags: []string{"a", "b"}, ThumbnailURL: "https://i.ytimg.com/vi/v1/new.jpg"},
+			fields: []string{FieldThumbnailURL},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffMetadata(prev, tt.cur)
+			if len(changes) != len(tt.fields) {
+				t.Fatalf("diffMetadata() returned %d changes, want %d", len(changes), len(tt.fields))
+			}
+			for i, field := range tt.fields {
+				if changes[i].Field != field {
+					t.Errorf("change[%d].Field = %q, want %q", i, changes[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffMetadata_IgnoresUncapturedFields(t *testing.T) {
+	// Snapshots written before description/thumbnail were captured have
+	// empty values; these must not be reported as changes.
+	prev := &storage.VideoMetadata{VideoID: "v1", Title: "t"}
+	cur := &youtube.Video{ID: "v1", Title: "t", Description: "now captured", ThumbnailURL: "https://example.com/x.jpg"}
+
+	if changes := diffMetadata(prev, cur); len(changes) != 0 {
+		t.Errorf("diffMetadata() = %d changes, want 0", len(changes))
+	}
+}
+
+func TestDiffMetadata_TagsEncoding(t *testing.T) {
+	prev := &storage.VideoMetadata{VideoID: "v1", Title: "t"}
+	cur := &youtube.Video{ID: "v1", Title: "t", Tags: []string{"go"}}
+
+	changes := diffMetadata(prev, cur)
+	if len(changes) != 1 {
+		t.Fatalf("diffMetadata() = %d changes, want 1", len(changes))
+	}
+	if changes[0].OldValue != "[]" || changes[0].NewValue != `["go"]` {
+		t.Errorf("tags change = %q -> %q", changes[0].OldValue, changes[0].NewValue)
+	}
+}
+
+func TestFetchAndStore_MetadataChanges(t *testing.T) {
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ch1": {{ID: "v1", Title: "B title"}, {ID: "v2", Title: "new video"}}},
+	}
+	store := &mockMetadataStore{previous: map[string]*storage.VideoMetadata{
+		"v1": {VideoID: "v1", Title: "A title"},
+	}}
+
+	f := NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{MetadataChanges: store})
+	if err := f.FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+
+	if len(store.changes) != 1 {
+		t.Fatalf("recorded %d changes, want 1", len(store.changes))
+	}
+	c := store.changes[0]
+	if c.ChannelID != "ch1" || c.VideoID != "v1" || c.OldValue != "A title" || c.NewValue != "B title" {
+		t.Errorf("unexpected change record: %+v", c)
+	}
+}
+
+func TestFetchAndStore_MetadataChangesSnapshotDate(t *testing.T) {
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ch1": {{ID: "v1", Title: "B title"}}},
+	}
+	store := &mockMetadataStore{previous: map[string]*storage.VideoMetadata{
+		"v1": {VideoID: "v1", Title: "A title"},
+	}}
+
+	// A catch-up run dates its changes, like its snapshots, on the missed day.
+	missed := civil.Date{Year: 2025, Month: 8, Day: 1}
+	f := NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{MetadataChanges: store, SnapshotDate: missed})
+	if err := f.FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+
+	if len(store.changes) != 1 || store.changes[0].Dt != missed {
+		t.Fatalf("changes = %+v, want one dated %s", store.changes, missed)
+	}
+	if want := missed.AddDays(-metadataLookbackDays); store.since != want {
+		t.Errorf("previous snapshots looked up since %s, want %s", store.since, want)
+	}
+}
@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// CommentClient is the subset of the YouTube client used to capture comments.
+type CommentClient interface {
+	FetchTopComments(ctx context.Context, videoID string, maxResults int64) ([]*youtube.Comment, error)
+}
+
+// CommentStore stores captured comments.
+type CommentStore interface {
+	InsertVideoComments(ctx context.Context, records []*storage.VideoCommentRecord) error
+}
+
+// CommentCapture configures top-comment capture. It costs one quota unit per
+// video, so it only runs for the channels listed in Channels.
+type CommentCapture struct {
+	Client   CommentClient
+	Store    CommentStore
+	Channels map[string]bool
+	// PerVideo is the number of top comments stored per video.
+	PerVideo int64
+}
+
+// captureComments stores the top comments of each video. Videos that fail are
+// logged and skipped so one bad video does not drop the rest.
+func (f *Fetcher) captureComments(ctx context.Context, channelID string, videos []*youtube.Video, dt civil.Date, snapshotTs time.Time) (int, error) {
+	cc := f.opts.Comments
+	var records []*storage.VideoCommentRecord
+	for _, v := range videos {
+		comments, err := cc.Client.FetchTopComments(ctx, v.ID, cc.PerVideo)
+		if err != nil {
+			logger.FromContext(ctx).Warning("Failed to fetch comments", err, map[string]string{"video_id": v.ID})
+			continue
+		}
+
+		// Videos of a tracked playlist belong to their own channels.
+		videoChannel := channelID
+		if v.ChannelID != "" {
+			videoChannel = v.ChannelID
+		}
+		now := time.Now()
+		for i, c := range comments {
+			records = append(records, &storage.VideoCommentRecord{
+				Dt:              dt,
+				SnapshotTs:      snapshotTs,
+				ChannelID:       videoChannel,
+				VideoID:         v.ID,
+				CommentID:       c.ID,
+				Rank:            int64(i + 1),
+				AuthorName:      c.AuthorName,
+				AuthorChannelID: c.AuthorChannelID,
+				Text:            c.Text,
+				Likes:           c.Likes,
+				ReplyCount:      c.ReplyCount,
+				PublishedAt:     c.PublishedAt,
+				CreatedAt:       now,
+			})
+		}
+	}
+
+	if err := cc.Store.InsertVideoComments(ctx, records); err != nil {
+		return 0, fmt.Errorf("failed to store comments for channel %s: %w", channelID, err)
+	}
+	return len(records), nil
+}
@@ -0,0 +1,65 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type mockCommentClient struct {
+	comments  map[string][]*youtube.Comment
+	err       map[string]error
+	requested []string
+}
+
+func (m *mockCommentClient) FetchTopComments(ctx context.Context, videoID string, maxResults int64) ([]*youtube.Comment, error) {
+	m.requested = append(m.requested, videoID)
+	if err := m.err[videoID]; err != nil {
+		return nil, err
+	}
+	return m.comments[videoID], nil
+}
+
+type mockCommentStore struct {
+	records []*storage.VideoCommentRecord
+}
+
+func (m *mockCommentStore) InsertVideoComments(ctx context.Context, records []*storage.VideoCommentRecord) error {
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func TestFetchAndStore_Comments(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{
+		"tracked": {{ID: "v1"}, {ID: "v2"}},
+		"skipped": {{ID: "v3"}},
+	}}
+	cc := &mockCommentClient{
+		comments: map[string][]*youtube.Comment{"v1": {{ID: "c1", Text: "first"}, {ID: "c2", Likes: 5}}},
+		err:      map[string]error{"v2": errors.New("boom")},
+	}
+	store := &mockCommentStore{}
+
+	f := NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{Comments: &CommentCapture{
+		Client:   cc,
+		Store:    store,
+		Channels: map[string]bool{"tracked": true},
+		PerVideo: 20,
+	}})
+	if err := f.FetchAndStore(context.Background(), []string{"tracked", "skipped"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+
+	if len(cc.requested) != 2 {
+		t.Errorf("requested comments for %v, want only the tracked channel's videos", cc.requested)
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("stored %d comments, want 2", len(store.records))
+	}
+	if r := store.records[1]; r.ChannelID != "tracked" || r.VideoID != "v1" || r.Rank != 2 || r.Likes != 5 {
+		t.Errorf("unexpected comment record: %+v", r)
+	}
+}
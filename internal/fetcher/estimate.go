@@ -0,0 +1,100 @@
+package fetcher
+
+import "github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+
+// Quota cost in units of the API calls a run makes besides the fetch of
+// each channel's videos.
+const (
+	costCommentThreadsList = 1
+	channelsPerListCall    = 50
+)
+
+// PlannedChannel is a channel or playlist a run will fetch.
+type PlannedChannel struct {
+	ID string
+	// Playlist is set for a tracked playlist, whose ID needs no
+	// channels.list call.
+	Playlist bool
+	// Comments is set when the top comments of its videos are captured.
+	Comments bool
+}
+
+// RunPlan is the work a run will do, in the order it will do it.
+type RunPlan struct {
+	Channels  []PlannedChannel
+	MaxVideos int64
+	// StatusLookback is set when missing videos are re-requested by ID.
+	StatusLookback bool
+	// Keywords is the number of keyword searches.
+	Keywords int
+	// ChannelStats is set when the statistics of the run's channels are
+	// fetched, e.g. for subscriber milestones.
+	ChannelStats bool
+}
+
+// RunEstimate is the predicted quota cost of a run.
+type RunEstimate struct {
+	Units int64 `json:"units"`
+	// ByMethod breaks Units down by API method, e.g. "videos.list".
+	ByMethod map[string]int64 `json:"by_method"`
+	// channelUnits is the cost of each planned channel, in order.
+	channelUnits []int64
+}
+
+// EstimateRun predicts the quota cost of plan: per channel, what
+// EstimateChannelCost counts (without channels.list for playlists), one
+// more videos.list call with StatusLookback and one commentThreads.list
+// call per video with Comments; per keyword, a search.list and a
+// videos.list call; and with ChannelStats one channels.list call per 50
+// channels. videos.list costs one unit whatever parts are requested, so
+// disabled parts do not lower the estimate. Lookups cached across runs,
+// such as video categories, are left out.
+func EstimateRun(plan RunPlan) *RunEstimate {
+	e := &RunEstimate{ByMethod: make(map[string]int64), channelUnits: make([]int64, len(plan.Channels))}
+	add := func(method string, units int64) int64 {
+		if units > 0 {
+			e.ByMethod[method] += units
+			e.Units += units
+		}
+		return units
+	}
+
+	pages := max((plan.MaxVideos+videosPerListCall-1)/videosPerListCall, 1)
+	for i, ch := range plan.Channels {
+		var units int64
+		if !ch.Playlist {
+			units += add("channels.list", costChannelsList)
+		}
+		units += add("playlistItems.list", pages*costPlaylistItemsList)
+		units += add("videos.list", pages*costVideosList)
+		if plan.StatusLookback {
+			units += add("videos.list", costVideosList)
+		}
+		if ch.Comments {
+			units += add("commentThreads.list", max(plan.MaxVideos, 1)*costCommentThreadsList)
+		}
+		e.channelUnits[i] = units
+	}
+	add("search.list", int64(plan.Keywords)*youtube.SearchListCost)
+	add("videos.list", int64(plan.Keywords)*costVideosList)
+	if plan.ChannelStats {
+		add("channels.list", int64((len(plan.Channels)+channelsPerListCall-1)/channelsPerListCall)*costChannelsList)
+	}
+	return e
+}
+
+// Fit returns how many of the planned channels, in order, can be fetched
+// within budget together with the run's other calls.
+func (e *RunEstimate) Fit(budget int64) int {
+	spent := e.Units
+	for _, u := range e.channelUnits {
+		spent -= u
+	}
+	for i, u := range e.channelUnits {
+		if spent+u > budget {
+			return i
+		}
+		spent += u
+	}
+	return len(e.channelUnits)
+}
@@ -0,0 +1,52 @@
+package fetcher
+
+import "testing"
+
+func TestEstimateRun(t *testing.T) {
+	plan := RunPlan{
+		Channels: []PlannedChannel{
+			{ID: "UC1"},
+			{ID: "PL1", Playlist: true},
+			{ID: "UC2", Comments: true},
+		},
+		MaxVideos:      120,
+		StatusLookback: true,
+		Keywords:       2,
+		ChannelStats:   true,
+	}
+	e := EstimateRun(plan)
+
+	// Three pages of 50 videos per channel.
+	want := map[string]int64{
+		"channels.list":       2 + 1,
+		"playlistItems.list":  3 * 3,
+		"videos.list":         3*(3+1) + 2,
+		"commentThreads.list": 120,
+		"search.list":         200,
+	}
+	var total int64
+	for method, units := range want {
+		if e.ByMethod[method] != units {
+			t.Errorf("%s = %d units, want %d", method, e.ByMethod[method], units)
+		}
+		total += units
+	}
+	if e.Units != total || len(e.ByMethod) != len(want) {
+		t.Errorf("estimate = %d units %v, want %d", e.Units, e.ByMethod, total)
+	}
+
+	// The keywords and channel statistics (203 units) are always spent;
+	// UC1 costs 8 units, PL1 7 and UC2 128.
+	for budget, n := range map[int64]int{e.Units: 3, e.Units - 1: 2, 203 + 15: 2, 203 + 14: 1, 203 + 7: 0, 100: 0} {
+		if got := e.Fit(budget); got != n {
+			t.Errorf("Fit(%d) = %d, want %d", budget, got, n)
+		}
+	}
+}
+
+func TestEstimateRun_Unlimited(t *testing.T) {
+	e := EstimateRun(RunPlan{Channels: []PlannedChannel{{ID: "UC1"}}})
+	if e.Units != EstimateChannelCost(0) {
+		t.Errorf("estimate = %d, want %d like EstimateChannelCost", e.Units, EstimateChannelCost(0))
+	}
+}
@@ -2,30 +2,108 @@ package fetcher
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
 
 	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/enrich"
 	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
 	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
 	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
 	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
 )
 
-// Initialize logger
-var log = logger.New()
+// VideoClient is the subset of the YouTube client used by the Fetcher.
+type VideoClient interface {
+	FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]*youtube.Video, error)
+	FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]*youtube.Video, error)
+	FetchVideosByID(ctx context.Context, videoIDs []string) ([]*youtube.Video, error)
+}
+
+// StatsWriter is the subset of the storage layer used by the Fetcher.
+type StatsWriter interface {
+	InsertVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error
+	InsertTombstones(ctx context.Context, records []*storage.VideoTombstoneRecord) error
+	KnownVideoIDs(ctx context.Context, channelID string, since civil.Date) ([]string, error)
+}
+
+// Options tunes optional fetcher behaviour.
+type Options struct {
+	// StatusLookbackDays enables tracking of deleted/private videos. Videos
+	// stored within this many days that are missing from the latest uploads
+	// are re-requested by ID; those no longer returned get a tombstone row.
+	// Zero disables the check.
+	StatusLookbackDays int
+
+	// MetadataChanges enables title/description/tags/thumbnail change
+	// history when set.
+	MetadataChanges MetadataChangeStore
+
+	// Comments enables top-comment capture for selected channels when set.
+	Comments *CommentCapture
+
+	// Thumbnails enables thumbnail archival when set.
+	Thumbnails *ThumbnailArchive
+
+	// Location is the timezone used to derive the dt partition of each
+	// snapshot. Nil defaults to JST.
+	Location *time.Location
+
+	// QuotaBudget defers the remaining channels when the quota left will
+	// not cover them. Nil processes every channel.
+	QuotaBudget *QuotaBudget
+
+	// Playlists marks the IDs passed to FetchAndStore that are playlists
+	// rather than channels. Their videos are stored under the channel that
+	// uploaded them, with the playlist in playlist_id. Status and metadata
+	// change tracking, which look up previous rows by channel, are skipped
+	// for them.
+	Playlists map[string]bool
+
+	// CategoryNames maps video category IDs to the names stored in
+	// category_name. Nil leaves the names empty.
+	CategoryNames map[string]string
+
+	// SnapshotDate, when set, is the dt partition of every record instead
+	// of the date of the snapshot, for a run catching up a date whose
+	// scheduled runs were missed. snapshot_ts stays the actual time.
+	SnapshotDate civil.Date
+}
 
 // Fetcher orchestrates the data fetching and storing process.
 type Fetcher struct {
-	ytClient *youtube.Client
-	bqWriter *storage.BigQueryWriter
+	ytClient VideoClient
+	bqWriter StatsWriter
+	opts     Options
+	metrics  *metrics.Metrics
 }
 
 // NewFetcher creates a new Fetcher.
-func NewFetcher(ytClient *youtube.Client, bqWriter *storage.BigQueryWriter) *Fetcher {
+func NewFetcher(ytClient VideoClient, bqWriter StatsWriter) *Fetcher {
+	return NewFetcherWithOptions(ytClient, bqWriter, Options{})
+}
+
+// NewFetcherWithOptions creates a new Fetcher with optional behaviour enabled.
+func NewFetcherWithOptions(ytClient VideoClient, bqWriter StatsWriter, opts Options) *Fetcher {
 	return &Fetcher{
 		ytClient: ytClient,
 		bqWriter: bqWriter,
+		opts:     opts,
+	}
+}
+
+// SetMetrics makes the fetcher record per-channel outcomes and durations
+// (ytt_channel_*).
+func (f *Fetcher) SetMetrics(m *metrics.Metrics) {
+	f.metrics = m
+}
+
+// recordChannel records the outcome of one channel when metrics are set.
+func (f *Fetcher) recordChannel(channelID string, videos int, failed bool, start time.Time) {
+	if f.metrics != nil {
+		f.metrics.RecordChannelFetch(channelID, videos, failed, time.Since(start))
 	}
 }
 
@@ -34,10 +112,27 @@ type FetchResult struct {
 	SuccessfulChannels []string
 	FailedChannels     map[string]error
 	TotalVideos        int
+	// FailedRows counts records BigQuery rejected individually after retries.
+	FailedRows int
+	// SkippedChannels are the channels not attempted because the daily API
+	// quota ran out, in order.
+	SkippedChannels []string
+	// DeferredChannels are the channels not attempted because the quota
+	// budget was predicted to run out, in order. Unlike skipped channels
+	// they are not failures.
+	DeferredChannels []string
 }
 
 // FetchAndStore fetches video statistics from YouTube and stores them in BigQuery.
 func (f *Fetcher) FetchAndStore(ctx context.Context, channelIDs []string, maxVideosPerChannel int64) error {
+	_, err := f.FetchAndStoreResult(ctx, channelIDs, maxVideosPerChannel)
+	return err
+}
+
+// FetchAndStoreResult is FetchAndStore that also returns the per-channel
+// outcome. The result is returned even when the run fails.
+func (f *Fetcher) FetchAndStoreResult(ctx context.Context, channelIDs []string, maxVideosPerChannel int64) (*FetchResult, error) {
+	log := logger.FromContext(ctx)
 	log.Info("Starting fetch and store process...", nil)
 
 	result := &FetchResult{
@@ -45,49 +140,157 @@ func (f *Fetcher) FetchAndStore(ctx context.Context, channelIDs []string, maxVid
 		FailedChannels:     make(map[string]error),
 	}
 
-	for _, channelID := range channelIDs {
-		log.Info(fmt.Sprintf("Processing channel: %s", channelID), map[string]string{"channel_id": channelID})
+	// All records of a run share one snapshot time and date, even if the run
+	// crosses midnight.
+	snapshotTs := time.Now()
+	dt := snapshotDate(snapshotTs, f.opts.Location)
+	if !f.opts.SnapshotDate.IsZero() {
+		dt = f.opts.SnapshotDate
+	}
+
+	var quotaErr error
+
+	for i, channelID := range channelIDs {
+		if b := f.opts.QuotaBudget; b != nil && b.exhausted(i) {
+			result.DeferredChannels = append(result.DeferredChannels, channelIDs[i:]...)
+			log.Warning("YouTube API quota budget running low; deferring remaining channels", nil, map[string]string{
+				"deferred_channels": fmt.Sprintf("%d", len(result.DeferredChannels)),
+				"quota_left":        fmt.Sprintf("%d", b.Remaining-b.Used()),
+			})
+			break
+		}
+
+		// Everything logged for this channel, including retries in the
+		// YouTube client, carries its ID.
+		chLog := log.With(map[string]string{"channel_id": channelID})
+		ctx := logger.WithContext(ctx, chLog)
+		chLog.Info(fmt.Sprintf("Processing channel: %s", channelID), nil)
+		start := time.Now()
 
-		// Use the unified FetchChannelVideos method
-		videos, err := f.ytClient.FetchChannelVideos(ctx, channelID, maxVideosPerChannel) // Fetch latest N videos
+		playlist := f.opts.Playlists[channelID]
+		var videos []*youtube.Video
+		var err error
+		if playlist {
+			videos, err = f.ytClient.FetchPlaylistVideos(ctx, channelID, maxVideosPerChannel)
+		} else {
+			videos, err = f.ytClient.FetchChannelVideos(ctx, channelID, maxVideosPerChannel) // Fetch latest N videos
+		}
 		if err != nil {
 			appErr := errors.API(fmt.Sprintf("Error fetching videos for channel %s", channelID), err)
-			log.Error(appErr.Message, appErr, map[string]string{"channel_id": channelID})
+			chLog.Error(appErr.Message, appErr, nil)
 			result.FailedChannels[channelID] = appErr
+			f.recordChannel(channelID, 0, true, start)
+			if stderrors.Is(err, errors.ErrQuotaExceeded) {
+				// Every further call fails the same way until the quota resets.
+				result.SkippedChannels = append(result.SkippedChannels, channelIDs[i+1:]...)
+				log.Error("YouTube API quota exceeded; skipping remaining channels", err, map[string]string{
+					"skipped_channels": fmt.Sprintf("%d", len(result.SkippedChannels)),
+				})
+				quotaErr = err
+				break
+			}
 			continue
 		}
 
+		var tombstones []*storage.VideoTombstoneRecord
+		if f.opts.StatusLookbackDays > 0 && !playlist {
+			previous, missing, err := f.fetchPreviouslySeen(ctx, channelID, videos)
+			if err != nil {
+				chLog.Warning("Failed to check previously tracked videos", err, nil)
+			}
+			videos = append(videos, previous...)
+			for _, videoID := range missing {
+				tombstones = append(tombstones, &storage.VideoTombstoneRecord{
+					Dt:        dt,
+					ChannelID: channelID,
+					VideoID:   videoID,
+					CreatedAt: time.Now(),
+					Status:    storage.VideoStatusUnavailable,
+				})
+			}
+		}
+
 		var records []*storage.VideoStatsRecord
+		now := time.Now()
 		for _, video := range videos {
-			records = append(records, &storage.VideoStatsRecord{
-				CreatedAt:      time.Now(),
-				Dt:             todayJST(),
-				ChannelID:      channelID,
-				VideoID:        video.ID,
-				Title:          video.Title,
-				ChannelName:    video.ChannelName,
-				Tags:           video.Tags,
-				IsShort:        video.IsShort,
-				Views:          int64(video.Views),
-				Likes:          int64(video.Likes),
-				Comments:       int64(video.Comments),
-				PublishedAt:    video.PublishedAt,
-				DurationSec:    video.DurationSec,
-				ContentDetails: video.ContentDetails,
-				TopicDetails:   video.TopicDetails,
-			})
+			var r *storage.VideoStatsRecord
+			if playlist {
+				r = newPlaylistVideoStatsRecord(channelID, video, dt, snapshotTs, now)
+			} else {
+				r = newVideoStatsRecord(channelID, video, dt, snapshotTs, now)
+			}
+			r.CategoryName = f.opts.CategoryNames[video.CategoryID]
+			records = append(records, r)
 		}
 
+		// Detect metadata changes before the new snapshot is written, so the
+		// comparison is against the previous one.
+		var changes []*storage.MetadataChangeRecord
+		if f.opts.MetadataChanges != nil && !playlist {
+			changes, err = f.detectMetadataChanges(ctx, channelID, videos, dt)
+			if err != nil {
+				chLog.Warning("Failed to detect metadata changes", err, nil)
+			}
+		}
+
+		stored := len(records)
 		if err := f.bqWriter.InsertVideoStats(ctx, records); err != nil {
-			appErr := errors.Storage("Error inserting video stats to BigQuery", err)
-			log.Error(appErr.Message, appErr, map[string]string{"channel_id": channelID})
-			result.FailedChannels[channelID] = appErr
-			continue
+			var partial *storage.PartialInsertError
+			if !stderrors.As(err, &partial) || partial.Failed == len(records) {
+				appErr := errors.Storage("Error inserting video stats to BigQuery", err)
+				chLog.Error(appErr.Message, appErr, nil)
+				result.FailedChannels[channelID] = appErr
+				f.recordChannel(channelID, 0, true, start)
+				continue
+			}
+			// Some rows made it; keep the channel successful but report the rest.
+			chLog.Warning("Some video stats rows were rejected by BigQuery", err, map[string]string{
+				"failed_rows": fmt.Sprintf("%d", partial.Failed),
+			})
+			result.FailedRows += partial.Failed
+			stored -= partial.Failed
+		}
+
+		if err := f.bqWriter.InsertTombstones(ctx, tombstones); err != nil {
+			appErr := errors.Storage("Error inserting video tombstones to BigQuery", err)
+			chLog.Error(appErr.Message, appErr, nil)
+		} else if len(tombstones) > 0 {
+			chLog.Info(fmt.Sprintf("Marked %d videos as unavailable for channel %s", len(tombstones), channelID), nil)
+		}
+
+		if f.opts.MetadataChanges != nil && !playlist {
+			if err := f.opts.MetadataChanges.InsertMetadataChanges(ctx, changes); err != nil {
+				appErr := errors.Storage("Error inserting metadata changes to BigQuery", err)
+				chLog.Error(appErr.Message, appErr, nil)
+			} else if len(changes) > 0 {
+				chLog.Info(fmt.Sprintf("Recorded %d metadata changes for channel %s", len(changes), channelID), nil)
+			}
+		}
+
+		if f.opts.Comments != nil && f.opts.Comments.Channels[channelID] {
+			n, err := f.captureComments(ctx, channelID, videos, dt, snapshotTs)
+			if err != nil {
+				appErr := errors.Storage("Error inserting video comments to BigQuery", err)
+				chLog.Error(appErr.Message, appErr, nil)
+			} else {
+				chLog.Info(fmt.Sprintf("Captured %d comments for channel %s", n, channelID), nil)
+			}
+		}
+
+		if f.opts.Thumbnails != nil {
+			n, err := f.archiveThumbnails(ctx, channelID, videos, dt, snapshotTs)
+			if err != nil {
+				appErr := errors.Storage("Error archiving video thumbnails", err)
+				chLog.Error(appErr.Message, appErr, nil)
+			} else if n > 0 {
+				chLog.Info(fmt.Sprintf("Archived %d new or changed thumbnails for channel %s", n, channelID), nil)
+			}
 		}
 
 		result.SuccessfulChannels = append(result.SuccessfulChannels, channelID)
-		result.TotalVideos += len(records)
-		log.Info(fmt.Sprintf("Successfully stored %d records for channel %s", len(records), channelID), map[string]string{"channel_id": channelID})
+		result.TotalVideos += stored
+		f.recordChannel(channelID, stored, false, start)
+		chLog.Info(fmt.Sprintf("Successfully stored %d records for channel %s", stored, channelID), nil)
 	}
 
 	// Log summary
@@ -97,17 +300,153 @@ func (f *Fetcher) FetchAndStore(ctx context.Context, channelIDs []string, maxVid
 			"successful_channels": fmt.Sprintf("%d", len(result.SuccessfulChannels)),
 			"failed_channels":     fmt.Sprintf("%d", len(result.FailedChannels)),
 			"total_videos":        fmt.Sprintf("%d", result.TotalVideos),
+			"failed_rows":         fmt.Sprintf("%d", result.FailedRows),
+			"skipped_channels":    fmt.Sprintf("%d", len(result.SkippedChannels)),
+			"deferred_channels":   fmt.Sprintf("%d", len(result.DeferredChannels)),
 		})
 
-	// Return error if all channels failed
-	if len(result.FailedChannels) == len(channelIDs) {
-		return errors.New(errors.ErrTypeAPI, "All channels failed to process", nil)
+	// Return error if all channels failed; channels skipped after the quota
+	// ran out count as failed, and the quota error stays matchable. A run
+	// that deferred every channel attempted nothing and did not fail.
+	if len(result.SuccessfulChannels) == 0 && (len(result.DeferredChannels) == 0 || len(result.FailedChannels) > 0) {
+		return result, errors.New(errors.ErrTypeAPI, "All channels failed to process", quotaErr)
+	}
+
+	return result, nil
+}
+
+// newVideoStatsRecord converts a fetched video into a snapshot record,
+// enriched with the language and keywords of its title.
+func newVideoStatsRecord(channelID string, video *youtube.Video, dt civil.Date, snapshotTs, createdAt time.Time) *storage.VideoStatsRecord {
+	title := enrich.EnrichTitle(video.Title)
+	return &storage.VideoStatsRecord{
+		CreatedAt:        createdAt,
+		Dt:               dt,
+		SnapshotTs:       snapshotTs,
+		ChannelID:        channelID,
+		VideoID:          video.ID,
+		Title:            video.Title,
+		ChannelName:      video.ChannelName,
+		Tags:             video.Tags,
+		IsShort:          video.IsShort,
+		Views:            int64(video.Views),
+		Likes:            int64(video.Likes),
+		Comments:         int64(video.Comments),
+		PublishedAt:      video.PublishedAt,
+		DurationSec:      video.DurationSec,
+		ContentDetails:   video.ContentDetails,
+		RegionAllowed:    video.RegionAllowed,
+		RegionBlocked:    video.RegionBlocked,
+		ContentRating:    contentRatingRecords(video.ContentRatings),
+		TopicDetails:     video.TopicDetails,
+		Status:           video.Status,
+		PrivacyStatus:    video.Status,
+		MadeForKids:      video.MadeForKids,
+		License:          video.License,
+		Embeddable:       video.Embeddable,
+		PaidPromotion:    video.PaidPromotion,
+		Description:      video.Description,
+		Chapters:         chapterRecords(video.Chapters),
+		ThumbnailURL:     video.ThumbnailURL,
+		CategoryID:       video.CategoryID,
+		DefaultLanguage:  video.DefaultLanguage,
+		TitleLanguage:    title.Language,
+		TitleKeywords:    title.Keywords,
+		ShortsConfidence: video.ShortsConfidence,
+	}
+}
+
+// contentRatingRecords converts content ratings to their column values.
+func contentRatingRecords(ratings []youtube.ContentRating) []storage.ContentRating {
+	if len(ratings) == 0 {
+		return nil
+	}
+	records := make([]storage.ContentRating, len(ratings))
+	for i, r := range ratings {
+		records[i] = storage.ContentRating{System: r.System, Rating: r.Rating}
+	}
+	return records
+}
+
+// chapterRecords converts chapters to their column values.
+func chapterRecords(chapters []youtube.Chapter) []storage.Chapter {
+	if len(chapters) == 0 {
+		return nil
+	}
+	records := make([]storage.Chapter, len(chapters))
+	for i, c := range chapters {
+		records[i] = storage.Chapter{StartSec: c.StartSec, Title: c.Title}
+	}
+	return records
+}
+
+// newPlaylistVideoStatsRecord converts a video of a tracked playlist into a
+// snapshot record attributed to the video's own channel.
+func newPlaylistVideoStatsRecord(playlistID string, video *youtube.Video, dt civil.Date, snapshotTs, createdAt time.Time) *storage.VideoStatsRecord {
+	channelID := video.ChannelID
+	if channelID == "" {
+		channelID = playlistID
+	}
+	r := newVideoStatsRecord(channelID, video, dt, snapshotTs, createdAt)
+	r.PlaylistID = playlistID
+	return r
+}
+
+// fetchPreviouslySeen re-requests videos stored within the lookback window that
+// were not part of the latest uploads. It returns the videos still available
+// and the IDs of those that the API no longer returns.
+func (f *Fetcher) fetchPreviouslySeen(ctx context.Context, channelID string, latest []*youtube.Video) ([]*youtube.Video, []string, error) {
+	since := f.today().AddDays(-f.opts.StatusLookbackDays)
+	known, err := f.bqWriter.KnownVideoIDs(ctx, channelID, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(latest))
+	for _, v := range latest {
+		seen[v.ID] = true
+	}
+	var recheck []string
+	for _, id := range known {
+		if !seen[id] {
+			recheck = append(recheck, id)
+		}
+	}
+	if len(recheck) == 0 {
+		return nil, nil, nil
 	}
 
-	return nil
+	videos, err := f.ytClient.FetchVideosByID(ctx, recheck)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string]bool, len(videos))
+	for _, v := range videos {
+		found[v.ID] = true
+	}
+	var missing []string
+	for _, id := range recheck {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return videos, missing, nil
+}
+
+// defaultLocation is used for snapshot dates when no timezone is configured.
+var defaultLocation = time.FixedZone("JST", 9*60*60)
+
+// snapshotDate returns the calendar date of t in loc (JST when nil), which
+// is used as the dt partition.
+func snapshotDate(t time.Time, loc *time.Location) civil.Date {
+	if loc == nil {
+		loc = defaultLocation
+	}
+	return civil.DateOf(t.In(loc))
 }
 
-func todayJST() civil.Date {
-	t := time.Now()
-	return civil.DateOf(t)
+// today returns the current snapshot date in the fetcher's timezone.
+func (f *Fetcher) today() civil.Date {
+	return snapshotDate(time.Now(), f.opts.Location)
 }
@@ -1,75 +1,417 @@
 package fetcher
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/civil"
+	apperrors "github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
-// Mock implementations are commented out until dependency injection is refactored
-// These will be needed when Fetcher is updated to accept interfaces
-
-// // Mock YouTube Client
-// type mockYouTubeClient struct {
-// 	videos []*youtube.Video
-// 	err    error
-// }
-
-// func (m *mockYouTubeClient) FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]*youtube.Video, error) {
-// 	if m.err != nil {
-// 		return nil, m.err
-// 	}
-// 	return m.videos, nil
-// }
-
-// // Mock BigQuery Writer
-// type mockBigQueryWriter struct {
-// 	insertedRecords []*storage.VideoStatsRecord
-// 	err             error
-// }
-
-// func (m *mockBigQueryWriter) InsertVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error {
-// 	if m.err != nil {
-// 		return m.err
-// 	}
-// 	m.insertedRecords = append(m.insertedRecords, records...)
-// 	return nil
-// }
-
-// func (m *mockBigQueryWriter) EnsureTableExists(ctx context.Context) error {
-// 	return nil
-// }
+// Mock YouTube Client
+type mockYouTubeClient struct {
+	videos       map[string][]*youtube.Video
+	byID         map[string]*youtube.Video
+	err          map[string]error
+	requestedIDs []string
+	fetched      []string
+}
+
+func (m *mockYouTubeClient) FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]*youtube.Video, error) {
+	m.fetched = append(m.fetched, channelID)
+	if err := m.err[channelID]; err != nil {
+		return nil, err
+	}
+	return m.videos[channelID], nil
+}
+
+func (m *mockYouTubeClient) FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]*youtube.Video, error) {
+	m.fetched = append(m.fetched, "playlist:"+playlistID)
+	if err := m.err[playlistID]; err != nil {
+		return nil, err
+	}
+	return m.videos[playlistID], nil
+}
+
+func (m *mockYouTubeClient) FetchVideosByID(ctx context.Context, videoIDs []string) ([]*youtube.Video, error) {
+	m.requestedIDs = append(m.requestedIDs, videoIDs...)
+	var videos []*youtube.Video
+	for _, id := range videoIDs {
+		if v, ok := m.byID[id]; ok {
+			videos = append(videos, v)
+		}
+	}
+	return videos, nil
+}
+
+// Mock BigQuery Writer
+type mockBigQueryWriter struct {
+	insertedRecords []*storage.VideoStatsRecord
+	tombstones      []*storage.VideoTombstoneRecord
+	known           map[string][]string
+	err             error
+}
+
+func (m *mockBigQueryWriter) InsertVideoStats(ctx context.Context, records []*storage.VideoStatsRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.insertedRecords = append(m.insertedRecords, records...)
+	return nil
+}
+
+func (m *mockBigQueryWriter) InsertTombstones(ctx context.Context, records []*storage.VideoTombstoneRecord) error {
+	m.tombstones = append(m.tombstones, records...)
+	return nil
+}
+
+func (m *mockBigQueryWriter) KnownVideoIDs(ctx context.Context, channelID string, since civil.Date) ([]string, error) {
+	return m.known[channelID], nil
+}
 
 func TestFetchAndStore_Success(t *testing.T) {
-	// This test demonstrates the need for dependency injection
-	// Currently, the Fetcher is tightly coupled with concrete implementations
-	// Making it difficult to unit test without actual YouTube/BigQuery connections
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{
+			"ch1": {{ID: "v1", Title: "one", Views: 10}, {ID: "v2", Title: "two", Views: 20}},
+		},
+	}
+	bq := &mockBigQueryWriter{}
 
-	// TODO: Refactor Fetcher to accept interfaces instead of concrete types
-	// This would allow proper mocking and unit testing
-	t.Skip("Skipping - requires refactoring for dependency injection")
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	if len(bq.insertedRecords) != 2 {
+		t.Fatalf("inserted %d records, want 2", len(bq.insertedRecords))
+	}
+	if bq.insertedRecords[1].Views != 20 || bq.insertedRecords[1].ChannelID != "ch1" {
+		t.Errorf("unexpected record: %+v", bq.insertedRecords[1])
+	}
 }
 
 func TestFetchAndStore_PartialFailure(t *testing.T) {
 	// Test when some channels succeed and others fail
-	// This would require proper mocking support
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ok": {{ID: "v1"}}},
+		err:    map[string]error{"bad": errors.New("not found")},
+	}
+	bq := &mockBigQueryWriter{}
+
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ok", "bad"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v, want nil on partial failure", err)
+	}
+	if len(bq.insertedRecords) != 1 {
+		t.Errorf("inserted %d records, want 1", len(bq.insertedRecords))
+	}
+}
+
+func TestFetchAndStore_ChannelLogLabels(t *testing.T) {
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ok": {{ID: "v1"}}},
+		err:    map[string]error{"bad": errors.New("not found")},
+	}
+	ctx := logger.WithContext(context.Background(), logger.New().With(map[string]string{"run_id": "r1"}))
+
+	// Capture output
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := NewFetcher(yt, &mockBigQueryWriter{}).FetchAndStore(ctx, []string{"ok", "bad"}, 10)
+	w.Close()
+	os.Stdout = old
+	if err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry logger.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if !strings.HasPrefix(entry.Message, "Processing channel: ") {
+			continue
+		}
+		channelID := strings.TrimPrefix(entry.Message, "Processing channel: ")
+		if entry.Labels["channel_id"] != channelID || entry.Labels["run_id"] != "r1" {
+			t.Errorf("labels of %q = %v, want channel_id %s and run_id r1", entry.Message, entry.Labels, channelID)
+		}
+		seen[channelID] = true
+	}
+	if !seen["ok"] || !seen["bad"] {
+		t.Errorf("per-channel entries logged for %v, want ok and bad", seen)
+	}
+}
+
+func TestFetchAndStore_ChannelMetrics(t *testing.T) {
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ok": {{ID: "v1"}, {ID: "v2"}}},
+		err:    map[string]error{"bad": errors.New("not found")},
+	}
+	m := metrics.NewMetrics()
+	f := NewFetcher(yt, &mockBigQueryWriter{})
+	f.SetMetrics(m)
+
+	if err := f.FetchAndStore(context.Background(), []string{"ok", "bad"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	if got := testutil.ToFloat64(m.ChannelVideosProcessed.WithLabelValues("ok")); got != 2 {
+		t.Errorf("videos processed for ok = %g, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.ChannelFetchFailures.WithLabelValues("bad")); got != 1 {
+		t.Errorf("fetch failures for bad = %g, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ChannelFetchDuration); got != 2 {
+		t.Errorf("duration series = %d, want one per channel", got)
+	}
 }
 
 func TestFetchAndStore_AllChannelsFail(t *testing.T) {
 	// Test when all channels fail to fetch
-	// This would require proper mocking support
+	yt := &mockYouTubeClient{
+		err: map[string]error{"a": errors.New("boom"), "b": errors.New("boom")},
+	}
+
+	if err := NewFetcher(yt, &mockBigQueryWriter{}).FetchAndStore(context.Background(), []string{"a", "b"}, 10); err == nil {
+		t.Error("FetchAndStore() expected error when all channels fail")
+	}
+}
+
+func TestFetchAndStore_QuotaExceeded(t *testing.T) {
+	quotaErr := apperrors.API("YouTube API error", errors.New("403")).WithSentinel(apperrors.ErrQuotaExceeded)
+	yt := &mockYouTubeClient{
+		err: map[string]error{"a": fmt.Errorf("playlistItems.list: %w", quotaErr)},
+	}
+
+	result, err := NewFetcher(yt, &mockBigQueryWriter{}).FetchAndStoreResult(context.Background(), []string{"a", "b", "c"}, 10)
+	if !errors.Is(err, apperrors.ErrQuotaExceeded) {
+		t.Errorf("FetchAndStore() error = %v, want ErrQuotaExceeded", err)
+	}
+	if len(yt.fetched) != 1 {
+		t.Errorf("fetched channels %v, want only the first after the quota ran out", yt.fetched)
+	}
+	if _, ok := result.FailedChannels["a"]; !ok || len(result.FailedChannels) != 1 {
+		t.Errorf("FailedChannels = %v, want only a", result.FailedChannels)
+	}
+	if fmt.Sprint(result.SkippedChannels) != "[b c]" {
+		t.Errorf("SkippedChannels = %v, want [b c]", result.SkippedChannels)
+	}
 }
 
-func TestTodayJST(t *testing.T) {
-	// Test the todayJST function
-	result := todayJST()
+func TestFetchAndStore_QuotaBudget(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{
+		"a": {{ID: "v1"}}, "b": {{ID: "v2"}}, "c": {{ID: "v3"}}, "d": {{ID: "v4"}},
+	}}
+	// Each channel costs 3 units; 7 units cover two channels.
+	budget := &QuotaBudget{
+		Remaining:   7,
+		Used:        func() int64 { return int64(3 * len(yt.fetched)) },
+		ChannelCost: 3,
+	}
 
-	// Should return today's date in JST
-	now := time.Now()
-	expected := civil.DateOf(now)
+	result, err := NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{QuotaBudget: budget}).
+		FetchAndStoreResult(context.Background(), []string{"a", "b", "c", "d"}, 10)
+	if err != nil {
+		t.Fatalf("FetchAndStoreResult() error = %v", err)
+	}
+	if fmt.Sprint(result.SuccessfulChannels) != "[a b]" {
+		t.Errorf("SuccessfulChannels = %v, want [a b]", result.SuccessfulChannels)
+	}
+	if fmt.Sprint(result.DeferredChannels) != "[c d]" {
+		t.Errorf("DeferredChannels = %v, want [c d]", result.DeferredChannels)
+	}
 
-	if result != expected {
-		t.Errorf("todayJST() = %v, want %v", result, expected)
+	// Deferring every channel is not a failure.
+	budget.Remaining = 1
+	yt.fetched = nil
+	result, err = NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{QuotaBudget: budget}).
+		FetchAndStoreResult(context.Background(), []string{"a", "b"}, 10)
+	if err != nil || len(result.DeferredChannels) != 2 {
+		t.Errorf("all deferred: DeferredChannels = %v, err = %v; want [a b] and no error", result.DeferredChannels, err)
+	}
+}
+
+func TestEstimateChannelCost(t *testing.T) {
+	for _, tt := range []struct {
+		maxVideos int64
+		want      int64
+	}{{10, 3}, {50, 3}, {51, 5}, {200, 9}, {0, 3}} {
+		if got := EstimateChannelCost(tt.maxVideos); got != tt.want {
+			t.Errorf("EstimateChannelCost(%d) = %d, want %d", tt.maxVideos, got, tt.want)
+		}
+	}
+}
+
+func TestFetchAndStore_PartialInsertFailure(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{"ch1": {{ID: "v1"}, {ID: "v2"}}}}
+	bq := &mockBigQueryWriter{err: &storage.PartialInsertError{Table: "t", Total: 2, Failed: 1, Reasons: map[string]int{"invalid": 1}}}
+
+	// Some rows landed, so the channel is not treated as failed
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Errorf("FetchAndStore() error = %v, want nil on partial insert failure", err)
+	}
+
+	bq.err = &storage.PartialInsertError{Table: "t", Total: 2, Failed: 2, Reasons: map[string]int{"invalid": 2}}
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ch1"}, 10); err == nil {
+		t.Error("FetchAndStore() expected error when every row was rejected")
+	}
+}
+
+func TestFetchAndStore_StatusTracking(t *testing.T) {
+	yt := &mockYouTubeClient{
+		videos: map[string][]*youtube.Video{"ch1": {{ID: "new", Status: storage.VideoStatusPublic}}},
+		byID:   map[string]*youtube.Video{"old": {ID: "old", Status: storage.VideoStatusUnlisted}},
+	}
+	bq := &mockBigQueryWriter{known: map[string][]string{"ch1": {"new", "old", "gone"}}}
+
+	f := NewFetcherWithOptions(yt, bq, Options{StatusLookbackDays: 30})
+	if err := f.FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+
+	if len(yt.requestedIDs) != 2 {
+		t.Errorf("re-requested %v, want only videos missing from latest uploads", yt.requestedIDs)
+	}
+	if len(bq.insertedRecords) != 2 {
+		t.Errorf("inserted %d records, want 2 (latest + still available)", len(bq.insertedRecords))
+	}
+	if len(bq.tombstones) != 1 || bq.tombstones[0].VideoID != "gone" {
+		t.Fatalf("tombstones = %+v, want one for 'gone'", bq.tombstones)
+	}
+	if bq.tombstones[0].Status != storage.VideoStatusUnavailable {
+		t.Errorf("tombstone status = %q, want %q", bq.tombstones[0].Status, storage.VideoStatusUnavailable)
+	}
+}
+
+func TestFetchAndStore_StatusTrackingDisabled(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{"ch1": {{ID: "new"}}}}
+	bq := &mockBigQueryWriter{known: map[string][]string{"ch1": {"gone"}}}
+
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	if len(yt.requestedIDs) != 0 || len(bq.tombstones) != 0 {
+		t.Errorf("status tracking ran while disabled: requested=%v tombstones=%d", yt.requestedIDs, len(bq.tombstones))
+	}
+}
+
+func TestFetchAndStore_Playlist(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{
+		"PLbest": {{ID: "v1", ChannelID: "UCa"}, {ID: "v2", ChannelID: "UCb"}},
+	}}
+	bq := &mockBigQueryWriter{known: map[string][]string{"PLbest": {"gone"}}}
+
+	f := NewFetcherWithOptions(yt, bq, Options{StatusLookbackDays: 30, Playlists: map[string]bool{"PLbest": true}})
+	result, err := f.FetchAndStoreResult(context.Background(), []string{"PLbest"}, 10)
+	if err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	if len(yt.fetched) != 1 || yt.fetched[0] != "playlist:PLbest" {
+		t.Errorf("fetched %v, want the playlist", yt.fetched)
+	}
+	if len(result.SuccessfulChannels) != 1 || result.SuccessfulChannels[0] != "PLbest" {
+		t.Errorf("SuccessfulChannels = %v, want [PLbest]", result.SuccessfulChannels)
+	}
+	if len(bq.insertedRecords) != 2 {
+		t.Fatalf("inserted %d records, want 2", len(bq.insertedRecords))
+	}
+	for i, want := range []string{"UCa", "UCb"} {
+		if r := bq.insertedRecords[i]; r.ChannelID != want || r.PlaylistID != "PLbest" {
+			t.Errorf("record %d channel/playlist = %q/%q, want %q/PLbest", i, r.ChannelID, r.PlaylistID, want)
+		}
+	}
+	// Status tracking looks up rows by channel, so it is skipped.
+	if len(yt.requestedIDs) != 0 || len(bq.tombstones) != 0 {
+		t.Errorf("status tracking ran for a playlist: requested=%v tombstones=%d", yt.requestedIDs, len(bq.tombstones))
+	}
+}
+
+func TestSnapshotDate(t *testing.T) {
+	// 16:00 UTC is already the next day in JST
+	ts := time.Date(2025, 1, 1, 16, 0, 0, 0, time.UTC)
+
+	if got, want := snapshotDate(ts, nil), (civil.Date{Year: 2025, Month: 1, Day: 2}); got != want {
+		t.Errorf("snapshotDate(default) = %v, want %v", got, want)
+	}
+	if got, want := snapshotDate(ts, time.UTC), (civil.Date{Year: 2025, Month: 1, Day: 1}); got != want {
+		t.Errorf("snapshotDate(UTC) = %v, want %v", got, want)
+	}
+}
+
+func TestFetchAndStore_SnapshotTimestamp(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{
+		"ch1": {{ID: "v1"}},
+		"ch2": {{ID: "v2"}},
+	}}
+	bq := &mockBigQueryWriter{}
+
+	if err := NewFetcher(yt, bq).FetchAndStore(context.Background(), []string{"ch1", "ch2"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	first, second := bq.insertedRecords[0], bq.insertedRecords[1]
+	if first.SnapshotTs.IsZero() || !first.SnapshotTs.Equal(second.SnapshotTs) {
+		t.Errorf("snapshot_ts = %v / %v, want one non-zero time per run", first.SnapshotTs, second.SnapshotTs)
+	}
+	if first.Dt != snapshotDate(first.SnapshotTs, nil) {
+		t.Errorf("dt = %v, want JST date of %v", first.Dt, first.SnapshotTs)
+	}
+}
+
+func TestFetchAndStore_BackdatedSnapshot(t *testing.T) {
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{"ch1": {{ID: "v1"}}}}
+	bq := &mockBigQueryWriter{}
+	missed := civil.Date{Year: 2026, Month: 10, Day: 15}
+
+	f := NewFetcherWithOptions(yt, bq, Options{SnapshotDate: missed})
+	if err := f.FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	if rec := bq.insertedRecords[0]; rec.Dt != missed || rec.SnapshotTs.IsZero() {
+		t.Errorf("dt = %v, snapshot_ts = %v, want dt %v and the actual time", rec.Dt, rec.SnapshotTs, missed)
+	}
+}
+
+func TestFetchAndStore_SnippetFields(t *testing.T) {
+	video := &youtube.Video{
+		ID: "v1", Title: "AI技術の最新ニュース", Description: "desc", ThumbnailURL: "https://i.ytimg.com/vi/v1/maxresdefault.jpg",
+		CategoryID: "28", DefaultLanguage: "ja",
+		Status: "unlisted", MadeForKids: true, License: "creativeCommon", PaidPromotion: true,
+		Chapters: []youtube.Chapter{{StartSec: 0, Title: "Intro"}, {StartSec: 60, Title: "Demo"}, {StartSec: 120, Title: "Outro"}},
+	}
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{"ch1": {video}}}
+	bq := &mockBigQueryWriter{}
+
+	f := NewFetcherWithOptions(yt, bq, Options{CategoryNames: map[string]string{"28": "Science & Technology"}})
+	if err := f.FetchAndStore(context.Background(), []string{"ch1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+	r := bq.insertedRecords[0]
+	if r.Description != "desc" || r.ThumbnailURL != video.ThumbnailURL || r.CategoryID != "28" || r.DefaultLanguage != "ja" {
+		t.Errorf("record = %+v", r)
+	}
+	if r.CategoryName != "Science & Technology" {
+		t.Errorf("category_name = %q, want the resolved name", r.CategoryName)
+	}
+	if r.PrivacyStatus != "unlisted" || !r.MadeForKids || r.License != "creativeCommon" || r.Embeddable || !r.PaidPromotion {
+		t.Errorf("status flags = %+v", r)
+	}
+	if len(r.Chapters) != 3 || r.Chapters[1] != (storage.Chapter{StartSec: 60, Title: "Demo"}) {
+		t.Errorf("chapters = %+v", r.Chapters)
+	}
+	if r.TitleLanguage != "ja" || strings.Join(r.TitleKeywords, ",") != "ai,技術,ニュース" {
+		t.Errorf("title enrichment = %q %q", r.TitleLanguage, r.TitleKeywords)
 	}
 }
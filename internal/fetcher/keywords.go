@@ -0,0 +1,112 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// KeywordClient is the subset of the YouTube client used for keyword tracking.
+type KeywordClient interface {
+	FetchKeywordVideos(ctx context.Context, query string, maxResults int64) ([]*youtube.Video, error)
+}
+
+// KeywordTrendStore stores keyword search results.
+type KeywordTrendStore interface {
+	InsertKeywordTrends(ctx context.Context, records []*storage.KeywordTrendRecord) error
+}
+
+// Keyword is a search query whose top results are tracked.
+type Keyword struct {
+	Query      string
+	MaxResults int64
+}
+
+// KeywordTracker stores the top search results of keywords on each run.
+type KeywordTracker struct {
+	ytClient KeywordClient
+	store    KeywordTrendStore
+	location *time.Location
+}
+
+// NewKeywordTracker creates a new KeywordTracker. loc determines the dt
+// partition (JST when nil).
+func NewKeywordTracker(ytClient KeywordClient, store KeywordTrendStore, loc *time.Location) *KeywordTracker {
+	return &KeywordTracker{
+		ytClient: ytClient,
+		store:    store,
+		location: loc,
+	}
+}
+
+// Track searches each keyword and stores its ranked results. Keywords that
+// fail are logged and skipped; an error is returned only if all of them fail.
+func (k *KeywordTracker) Track(ctx context.Context, keywords []Keyword) error {
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	log := logger.FromContext(ctx)
+	snapshotTs := time.Now()
+	dt := snapshotDate(snapshotTs, k.location)
+	failed, stored, quota := 0, 0, 0
+
+	for _, kw := range keywords {
+		kwLog := log.With(map[string]string{"keyword": kw.Query})
+		ctx := logger.WithContext(ctx, kwLog)
+
+		quota += youtube.SearchListCost + 1
+		videos, err := k.ytClient.FetchKeywordVideos(ctx, kw.Query, kw.MaxResults)
+		if err != nil {
+			appErr := errors.API(fmt.Sprintf("Error searching videos for keyword %q", kw.Query), err)
+			kwLog.Error(appErr.Message, appErr, nil)
+			failed++
+			continue
+		}
+
+		now := time.Now()
+		records := make([]*storage.KeywordTrendRecord, 0, len(videos))
+		for i, v := range videos {
+			records = append(records, &storage.KeywordTrendRecord{
+				Dt:          dt,
+				SnapshotTs:  snapshotTs,
+				Keyword:     kw.Query,
+				Rank:        int64(i + 1),
+				VideoID:     v.ID,
+				ChannelID:   v.ChannelID,
+				ChannelName: v.ChannelName,
+				Title:       v.Title,
+				Views:       int64(v.Views),
+				Likes:       int64(v.Likes),
+				Comments:    int64(v.Comments),
+				PublishedAt: v.PublishedAt,
+				CreatedAt:   now,
+			})
+		}
+
+		if err := k.store.InsertKeywordTrends(ctx, records); err != nil {
+			appErr := errors.Storage("Error inserting keyword trends to BigQuery", err)
+			kwLog.Error(appErr.Message, appErr, nil)
+			failed++
+			continue
+		}
+		stored += len(records)
+	}
+
+	log.Info(fmt.Sprintf("Keyword tracking completed. Success: %d/%d keywords, Total videos: %d",
+		len(keywords)-failed, len(keywords), stored),
+		map[string]string{
+			"failed_keywords": fmt.Sprintf("%d", failed),
+			"quota_units":     fmt.Sprintf("%d", quota),
+		})
+
+	if failed == len(keywords) {
+		return errors.New(errors.ErrTypeAPI, "All keywords failed to process", nil)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type mockKeywordClient struct {
+	results map[string][]*youtube.Video
+	err     map[string]error
+}
+
+func (m *mockKeywordClient) FetchKeywordVideos(ctx context.Context, query string, maxResults int64) ([]*youtube.Video, error) {
+	if err := m.err[query]; err != nil {
+		return nil, err
+	}
+	return m.results[query], nil
+}
+
+type mockKeywordStore struct {
+	records []*storage.KeywordTrendRecord
+}
+
+func (m *mockKeywordStore) InsertKeywordTrends(ctx context.Context, records []*storage.KeywordTrendRecord) error {
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func TestKeywordTracker_Track(t *testing.T) {
+	yt := &mockKeywordClient{
+		results: map[string][]*youtube.Video{
+			"go": {{ID: "v1", ChannelID: "ch1", Views: 100}, {ID: "v2", ChannelID: "ch2", Views: 50}},
+		},
+		err: map[string]error{"broken": errors.New("quota exceeded")},
+	}
+	store := &mockKeywordStore{}
+
+	err := NewKeywordTracker(yt, store, nil).Track(context.Background(), []Keyword{{Query: "go", MaxResults: 10}, {Query: "broken"}})
+	if err != nil {
+		t.Fatalf("Track() error = %v, want nil on partial failure", err)
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("stored %d records, want 2", len(store.records))
+	}
+	if r := store.records[1]; r.Keyword != "go" || r.Rank != 2 || r.VideoID != "v2" || r.ChannelID != "ch2" {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	if store.records[0].SnapshotTs.IsZero() {
+		t.Error("snapshot_ts not set")
+	}
+}
+
+func TestKeywordTracker_AllFail(t *testing.T) {
+	yt := &mockKeywordClient{err: map[string]error{"a": errors.New("boom")}}
+
+	if err := NewKeywordTracker(yt, &mockKeywordStore{}, nil).Track(context.Background(), []Keyword{{Query: "a"}}); err == nil {
+		t.Error("Track() expected error when all keywords fail")
+	}
+}
@@ -0,0 +1,140 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+// maxThumbnailBytes bounds a downloaded thumbnail; YouTube's largest are
+// well under 1 MiB.
+const maxThumbnailBytes = 4 << 20
+
+// ThumbnailBucket stores thumbnail images.
+type ThumbnailBucket interface {
+	PutThumbnail(ctx context.Context, videoID, sha256, contentType string, data []byte) (string, error)
+}
+
+// ThumbnailStore records archived thumbnail versions.
+type ThumbnailStore interface {
+	LatestThumbnailHashes(ctx context.Context, videoIDs []string) (map[string]string, error)
+	InsertVideoThumbnails(ctx context.Context, records []*storage.VideoThumbnailRecord) error
+}
+
+// ThumbnailArchive configures thumbnail archival. Each video's thumbnail is
+// downloaded on every run; a version not archived before is stored in
+// Bucket and recorded in Store.
+type ThumbnailArchive struct {
+	// HTTPClient downloads the images; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+	Bucket     ThumbnailBucket
+	Store      ThumbnailStore
+}
+
+// archiveThumbnails archives the thumbnails of videos that are new or
+// changed since they were last archived and returns how many were. Videos
+// whose thumbnail fails to download or upload are logged and skipped.
+func (f *Fetcher) archiveThumbnails(ctx context.Context, channelID string, videos []*youtube.Video, dt civil.Date, snapshotTs time.Time) (int, error) {
+	ta := f.opts.Thumbnails
+	ids := make([]string, 0, len(videos))
+	for _, v := range videos {
+		if v.ThumbnailURL != "" {
+			ids = append(ids, v.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	latest, err := ta.Store.LatestThumbnailHashes(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archived thumbnails for channel %s: %w", channelID, err)
+	}
+
+	client := ta.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var records []*storage.VideoThumbnailRecord
+	for _, v := range videos {
+		if v.ThumbnailURL == "" {
+			continue
+		}
+		data, contentType, err := downloadThumbnail(ctx, client, v.ThumbnailURL)
+		if err != nil {
+			logger.FromContext(ctx).Warning("Failed to download thumbnail", err, map[string]string{"video_id": v.ID})
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		prev, seen := latest[v.ID]
+		if hash == prev {
+			continue
+		}
+		gcsPath, err := ta.Bucket.PutThumbnail(ctx, v.ID, hash, contentType, data)
+		if err != nil {
+			logger.FromContext(ctx).Warning("Failed to archive thumbnail", err, map[string]string{"video_id": v.ID})
+			continue
+		}
+
+		// Videos of a tracked playlist belong to their own channels.
+		videoChannel := channelID
+		if v.ChannelID != "" {
+			videoChannel = v.ChannelID
+		}
+		records = append(records, &storage.VideoThumbnailRecord{
+			Dt:             dt,
+			ArchivedAt:     snapshotTs,
+			ChannelID:      videoChannel,
+			VideoID:        v.ID,
+			ThumbnailURL:   v.ThumbnailURL,
+			SHA256:         hash,
+			PreviousSHA256: bigquery.NullString{StringVal: prev, Valid: seen},
+			GCSPath:        gcsPath,
+			ContentType:    contentType,
+			Bytes:          int64(len(data)),
+		})
+	}
+
+	if err := ta.Store.InsertVideoThumbnails(ctx, records); err != nil {
+		return 0, fmt.Errorf("failed to store thumbnails for channel %s: %w", channelID, err)
+	}
+	return len(records), nil
+}
+
+// downloadThumbnail returns the image at url and its content type.
+func downloadThumbnail(ctx context.Context, client *http.Client, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxThumbnailBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("GET %s: %w", url, err)
+	}
+	if len(data) > maxThumbnailBytes {
+		return nil, "", fmt.Errorf("GET %s: thumbnail larger than %d bytes", url, maxThumbnailBytes)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
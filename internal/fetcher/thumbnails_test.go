@@ -0,0 +1,89 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+)
+
+type mockThumbnailBucket struct {
+	objects map[string][]byte
+}
+
+func (m *mockThumbnailBucket) PutThumbnail(ctx context.Context, videoID, sha256, contentType string, data []byte) (string, error) {
+	name := videoID + "/" + sha256 + ".jpg"
+	m.objects[name] = data
+	return "gs://thumbs/" + name, nil
+}
+
+type mockThumbnailStore struct {
+	latest  map[string]string
+	records []*storage.VideoThumbnailRecord
+}
+
+func (m *mockThumbnailStore) LatestThumbnailHashes(ctx context.Context, videoIDs []string) (map[string]string, error) {
+	return m.latest, nil
+}
+
+func (m *mockThumbnailStore) InsertVideoThumbnails(ctx context.Context, records []*storage.VideoThumbnailRecord) error {
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func TestFetchAndStore_Thumbnails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("image of " + r.URL.Path))
+	}))
+	defer srv.Close()
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	yt := &mockYouTubeClient{videos: map[string][]*youtube.Video{
+		"UC1": {
+			{ID: "new", ThumbnailURL: srv.URL + "/new.jpg"},
+			{ID: "same", ThumbnailURL: srv.URL + "/same.jpg"},
+			{ID: "changed", ThumbnailURL: srv.URL + "/changed.jpg"},
+			{ID: "missing", ThumbnailURL: srv.URL + "/missing.jpg"},
+			{ID: "none"},
+		},
+	}}
+	bucket := &mockThumbnailBucket{objects: map[string][]byte{}}
+	store := &mockThumbnailStore{latest: map[string]string{
+		"same":    hash("image of /same.jpg"),
+		"changed": hash("old image"),
+	}}
+
+	f := NewFetcherWithOptions(yt, &mockBigQueryWriter{}, Options{Thumbnails: &ThumbnailArchive{
+		HTTPClient: srv.Client(),
+		Bucket:     bucket,
+		Store:      store,
+	}})
+	if err := f.FetchAndStore(context.Background(), []string{"UC1"}, 10); err != nil {
+		t.Fatalf("FetchAndStore() error = %v", err)
+	}
+
+	if len(store.records) != 2 || len(bucket.objects) != 2 {
+		t.Fatalf("archived %d thumbnails (%d objects), want the new and the changed one: %+v", len(store.records), len(bucket.objects), store.records)
+	}
+	first, changed := store.records[0], store.records[1]
+	if first.VideoID != "new" || first.ChannelID != "UC1" || first.PreviousSHA256.Valid || first.SHA256 != hash("image of /new.jpg") ||
+		first.GCSPath != "gs://thumbs/new/"+first.SHA256+".jpg" || first.ContentType != "image/jpeg" || first.Bytes != int64(len("image of /new.jpg")) {
+		t.Errorf("new thumbnail = %+v", first)
+	}
+	if changed.VideoID != "changed" || changed.PreviousSHA256.StringVal != hash("old image") || !changed.PreviousSHA256.Valid {
+		t.Errorf("changed thumbnail = %+v", changed)
+	}
+}
@@ -1,9 +1,14 @@
 package logger
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,78 +23,277 @@ const (
 	FATAL   LogLevel = "fatal"
 )
 
-// Entry represents a structured log entry
+// levelFatal sits above slog.LevelError and is reported as CRITICAL.
+const levelFatal = slog.Level(12)
+
+// Keys understood by Cloud Logging when parsing structured JSON from stdout.
+const (
+	keySeverity       = "severity"
+	keyMessage        = "message"
+	keyLabels         = "logging.googleapis.com/labels"
+	keyTrace          = "logging.googleapis.com/trace"
+	keySpanID         = "logging.googleapis.com/spanId"
+	keySourceLocation = "logging.googleapis.com/sourceLocation"
+)
+
+// Entry represents a structured log entry as written to stdout
 type Entry struct {
-	Timestamp string            `json:"timestamp"`
-	Level     string            `json:"level"`
-	Message   string            `json:"message"`
-	Error     string            `json:"error,omitempty"`
-	Labels    map[string]string `json:"labels,omitempty"`
+	Time           string            `json:"time"`
+	Severity       string            `json:"severity"`
+	Message        string            `json:"message"`
+	Error          string            `json:"error,omitempty"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	Trace          string            `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string            `json:"logging.googleapis.com/spanId,omitempty"`
+	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation,omitempty"`
 }
 
-// Logger provides structured logging functionality
+// SourceLocation identifies the call site of a log entry
+type SourceLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+// Logger provides structured logging functionality on top of log/slog
 type Logger struct {
-	minLevel LogLevel
+	handler slog.Handler
+	// labels are added to every entry; per-call labels take precedence.
+	labels map[string]string
 }
 
 // New creates a new logger instance
 func New() *Logger {
-	levelStr := os.Getenv("LOG_LEVEL")
-	if levelStr == "" {
-		levelStr = "info"
-	}
-
-	var minLevel LogLevel
-	switch levelStr {
+	var minLevel slog.Level
+	switch os.Getenv("LOG_LEVEL") {
 	case "debug":
-		minLevel = DEBUG
+		minLevel = slog.LevelDebug
 	case "warning":
-		minLevel = WARNING
+		minLevel = slog.LevelWarn
 	case "error":
-		minLevel = ERROR
+		minLevel = slog.LevelError
 	case "fatal":
-		minLevel = FATAL
+		minLevel = levelFatal
+	default:
+		minLevel = slog.LevelInfo
+	}
+
+	return &Logger{handler: NewCloudLoggingHandler(stdout{}, minLevel)}
+}
+
+// NewCloudLoggingHandler returns a slog JSON handler whose output follows the
+// Cloud Logging structured logging format.
+func NewCloudLoggingHandler(w interface{ Write([]byte) (int, error) }, minLevel slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       minLevel,
+		ReplaceAttr: replaceAttr,
+	})
+}
+
+// replaceAttr renames slog's built-in keys to the Cloud Logging ones.
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		return slog.String(keySeverity, severity(a.Value.Any().(slog.Level)))
+	case slog.MessageKey:
+		a.Key = keyMessage
+	case slog.SourceKey:
+		a.Key = keySourceLocation
+	}
+	return a
+}
+
+// severity maps a slog level to a Cloud Logging severity.
+func severity(level slog.Level) string {
+	switch {
+	case level >= levelFatal:
+		return "CRITICAL"
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// stdout writes to the current os.Stdout, so redirecting it after the logger
+// is created still captures output.
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// slogLevel converts a LogLevel to its slog equivalent.
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case DEBUG:
+		return slog.LevelDebug
+	case WARNING:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	case FATAL:
+		return levelFatal
 	default:
-		minLevel = INFO
+		return slog.LevelInfo
+	}
+}
+
+// WithTrace returns a logger that attaches the given Cloud Trace resource name
+// (projects/PROJECT/traces/TRACE_ID) and span ID to every entry.
+func (l *Logger) WithTrace(trace, spanID string) *Logger {
+	var attrs []slog.Attr
+	if trace != "" {
+		attrs = append(attrs, slog.String(keyTrace, trace))
+	}
+	if spanID != "" {
+		attrs = append(attrs, slog.String(keySpanID, spanID))
+	}
+	if len(attrs) == 0 {
+		return l
+	}
+	return &Logger{handler: l.handler.WithAttrs(attrs), labels: l.labels}
+}
+
+// With returns a logger that adds the given labels to every entry.
+func (l *Logger) With(labels map[string]string) *Logger {
+	merged := make(map[string]string, len(l.labels)+len(labels))
+	for k, v := range l.labels {
+		merged[k] = v
 	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return &Logger{handler: l.handler, labels: merged}
+}
 
-	return &Logger{
-		minLevel: minLevel,
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying the logger. Packages further
+// down the call chain retrieve it with FromContext, so labels such as a
+// request or run ID are attached once and included everywhere.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or a default logger if there
+// is none.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
 	}
+	return defaultLogger
+}
+
+// Handler returns the underlying slog handler.
+func (l *Logger) Handler() slog.Handler {
+	return l.handler
 }
 
-// shouldLog determines if a message should be logged based on level
-func (l *Logger) shouldLog(level LogLevel) bool {
-	levels := map[LogLevel]int{
-		DEBUG:   0,
-		INFO:    1,
-		WARNING: 2,
-		ERROR:   3,
-		FATAL:   4,
+// TraceFromRequest extracts the trace and span IDs from the
+// X-Cloud-Trace-Context or traceparent header of a request. The trace is
+// returned as a Cloud Trace resource name for the given project.
+func TraceFromRequest(r *http.Request, projectID string) (trace, spanID string) {
+	var traceID string
+	if h := r.Header.Get("X-Cloud-Trace-Context"); h != "" {
+		// TRACE_ID/SPAN_ID;o=OPTIONS
+		traceID, spanID, _ = strings.Cut(h, "/")
+		spanID, _, _ = strings.Cut(spanID, ";")
+	} else if h := r.Header.Get("traceparent"); h != "" {
+		// VERSION-TRACE_ID-SPAN_ID-FLAGS
+		if parts := strings.Split(h, "-"); len(parts) == 4 {
+			traceID, spanID = parts[1], parts[2]
+		}
 	}
+	if traceID == "" || projectID == "" {
+		return "", ""
+	}
+	return fmt.Sprintf("projects/%s/traces/%s", projectID, traceID), spanID
+}
+
+// ErrorEvent is an ERROR or FATAL entry that carries an error, as passed to
+// a Reporter.
+type ErrorEvent struct {
+	Time     time.Time
+	Severity string
+	Message  string
+	Err      error
+	Labels   map[string]string
+	// PCs is the call stack of the log call, innermost frame first.
+	PCs []uintptr
+}
+
+// Reporter forwards error entries to an external error tracking service.
+// Report is called synchronously from the logging call and must not block.
+type Reporter interface {
+	Report(e ErrorEvent)
+	// Flush waits up to timeout for queued reports to be delivered.
+	Flush(timeout time.Duration)
+}
+
+var reporter atomic.Pointer[Reporter]
 
-	return levels[level] >= levels[l.minLevel]
+// SetReporter installs r to receive every ERROR and FATAL entry that has an
+// error attached, from all loggers. A nil r removes the reporter.
+func SetReporter(r Reporter) {
+	if r == nil {
+		reporter.Store(nil)
+		return
+	}
+	reporter.Store(&r)
 }
 
 // log outputs a structured log entry
 func (l *Logger) log(level LogLevel, msg string, err error, labels map[string]string) {
-	if !l.shouldLog(level) {
+	ctx := context.Background()
+	lvl := slogLevel(level)
+	if !l.handler.Enabled(ctx, lvl) {
 		return
 	}
 
-	entry := Entry{
-		Timestamp: time.Now().Format(time.RFC3339),
-		Level:     string(level),
-		Message:   msg,
-		Labels:    labels,
-	}
+	// Skip runtime.Callers, log and the exported method to report the caller.
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
 
+	r := slog.NewRecord(time.Now(), lvl, msg, pcs[0])
 	if err != nil {
-		entry.Error = err.Error()
+		r.AddAttrs(slog.String("error", err.Error()))
+	}
+	if len(l.labels) > 0 {
+		merged := make(map[string]string, len(l.labels)+len(labels))
+		for k, v := range l.labels {
+			merged[k] = v
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		labels = merged
 	}
+	if len(labels) > 0 {
+		r.AddAttrs(slog.Any(keyLabels, labels))
+	}
+	_ = l.handler.Handle(ctx, r)
 
-	jsonBytes, _ := json.Marshal(entry)
-	fmt.Println(string(jsonBytes))
+	if rep := reporter.Load(); rep != nil && err != nil && lvl >= slog.LevelError {
+		(*rep).Report(ErrorEvent{
+			Time:     r.Time,
+			Severity: severity(lvl),
+			Message:  msg,
+			Err:      err,
+			Labels:   labels,
+			PCs:      append([]uintptr(nil), pcs[:n]...),
+		})
+	}
 }
 
 // Debug logs a debug message
@@ -115,6 +319,9 @@ func (l *Logger) Error(msg string, err error, labels map[string]string) {
 // Fatal logs a fatal message and exits
 func (l *Logger) Fatal(msg string, err error, labels map[string]string) {
 	l.log(FATAL, msg, err, labels)
+	if rep := reporter.Load(); rep != nil {
+		(*rep).Flush(5 * time.Second)
+	}
 	os.Exit(1)
 }
 
@@ -2,10 +2,17 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogLevels(t *testing.T) {
@@ -66,8 +73,8 @@ func TestLogLevels(t *testing.T) {
 				if entry.Message != "test message" {
 					t.Errorf("Expected message 'test message', got '%s'", entry.Message)
 				}
-				if entry.Level != string(tt.logLevel) {
-					t.Errorf("Expected level '%s', got '%s'", tt.logLevel, entry.Level)
+				if want := strings.ToUpper(string(tt.logLevel)); entry.Severity != want {
+					t.Errorf("Expected severity '%s', got '%s'", want, entry.Severity)
 				}
 			}
 		})
@@ -89,22 +96,22 @@ func TestLoggerMethods(t *testing.T) {
 		{
 			name:   "Debug method",
 			method: func() { l.Debug("debug message", nil) },
-			level:  "debug",
+			level:  "DEBUG",
 		},
 		{
 			name:   "Info method",
 			method: func() { l.Info("info message", nil) },
-			level:  "info",
+			level:  "INFO",
 		},
 		{
 			name:   "Warning method",
 			method: func() { l.Warning("warning message", nil, nil) },
-			level:  "warning",
+			level:  "WARNING",
 		},
 		{
 			name:   "Error method",
 			method: func() { l.Error("error message", nil, nil) },
-			level:  "error",
+			level:  "ERROR",
 		},
 	}
 
@@ -132,8 +139,8 @@ func TestLoggerMethods(t *testing.T) {
 			if err := json.Unmarshal([]byte(output), &entry); err != nil {
 				t.Errorf("Invalid JSON output: %v", err)
 			}
-			if entry.Level != tt.level {
-				t.Errorf("Expected level '%s', got '%s'", tt.level, entry.Level)
+			if entry.Severity != tt.level {
+				t.Errorf("Expected severity '%s', got '%s'", tt.level, entry.Severity)
 			}
 		})
 	}
@@ -178,3 +185,119 @@ func TestLoggerWithLabels(t *testing.T) {
 		t.Errorf("Expected label video_count='10', got '%s'", entry.Labels["video_count"])
 	}
 }
+
+func TestCloudLoggingFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{handler: NewCloudLoggingHandler(&buf, slog.LevelInfo)}
+	l = l.WithTrace("projects/p/traces/abc", "123")
+
+	l.Error("boom", errors.New("cause"), map[string]string{"channel_id": "ch1"})
+
+	var entry Entry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON output: %v (%s)", err, buf.String())
+	}
+	if entry.Severity != "ERROR" || entry.Message != "boom" || entry.Error != "cause" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Time == "" {
+		t.Error("time not set")
+	}
+	if entry.Trace != "projects/p/traces/abc" || entry.SpanID != "123" {
+		t.Errorf("trace = %q span = %q", entry.Trace, entry.SpanID)
+	}
+	if entry.Labels["channel_id"] != "ch1" {
+		t.Errorf("labels = %v", entry.Labels)
+	}
+	if entry.SourceLocation == nil || !strings.HasSuffix(entry.SourceLocation.File, "logger_test.go") {
+		t.Errorf("sourceLocation = %+v, want the caller in logger_test.go", entry.SourceLocation)
+	}
+}
+
+func TestTraceFromRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		wantTrace string
+		wantSpan  string
+	}{
+		{"cloud trace", "X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1", "projects/p/traces/105445aa7843bc8bf206b12000100000", "1"},
+		{"traceparent", "traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "projects/p/traces/0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"},
+		{"none", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			trace, span := TraceFromRequest(r, "p")
+			if trace != tt.wantTrace || span != tt.wantSpan {
+				t.Errorf("TraceFromRequest() = %q, %q; want %q, %q", trace, span, tt.wantTrace, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	base := &Logger{handler: NewCloudLoggingHandler(&buf, slog.LevelInfo)}
+	ctx := WithContext(context.Background(), base.With(map[string]string{"request_id": "req-1", "run_id": "run-1"}))
+
+	FromContext(ctx).Info("hello", map[string]string{"channel_id": "ch1", "run_id": "override"})
+
+	var entry Entry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	want := map[string]string{"request_id": "req-1", "run_id": "override", "channel_id": "ch1"}
+	for k, v := range want {
+		if entry.Labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, entry.Labels[k], v)
+		}
+	}
+
+	if FromContext(context.Background()) == nil {
+		t.Error("FromContext() without logger returned nil")
+	}
+}
+
+type recordingReporter struct {
+	events []ErrorEvent
+}
+
+func (r *recordingReporter) Report(e ErrorEvent) { r.events = append(r.events, e) }
+func (r *recordingReporter) Flush(time.Duration) {}
+
+func TestSetReporter(t *testing.T) {
+	rep := &recordingReporter{}
+	SetReporter(rep)
+	defer SetReporter(nil)
+
+	var buf bytes.Buffer
+	l := (&Logger{handler: NewCloudLoggingHandler(&buf, slog.LevelDebug)}).With(map[string]string{"run_id": "r1"})
+
+	l.Warning("retrying", errors.New("timeout"), nil)
+	l.Error("no error attached", nil, nil)
+	l.Error("fetch failed", errors.New("boom"), map[string]string{"channel_id": "UC1"})
+
+	if len(rep.events) != 1 {
+		t.Fatalf("reported %d events, want 1: %+v", len(rep.events), rep.events)
+	}
+	e := rep.events[0]
+	if e.Message != "fetch failed" || e.Severity != "ERROR" || e.Err.Error() != "boom" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e.Labels["run_id"] != "r1" || e.Labels["channel_id"] != "UC1" {
+		t.Errorf("labels = %v, want logger and call labels merged", e.Labels)
+	}
+	if len(e.PCs) == 0 {
+		t.Fatal("event has no stack")
+	}
+	frame, _ := runtime.CallersFrames(e.PCs).Next()
+	if !strings.HasSuffix(frame.Function, "TestSetReporter") {
+		t.Errorf("top frame = %s, want the caller of Error", frame.Function)
+	}
+}
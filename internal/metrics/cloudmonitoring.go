@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	dto "github.com/prometheus/client_model/go"
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+// CloudMetricPrefix is prepended to the Prometheus name of every metric
+// exported to Cloud Monitoring.
+const CloudMetricPrefix = "custom.googleapis.com/ytt/"
+
+// cloudExported lists the metrics pushed to Cloud Monitoring: the ones
+// needed to alert on a Cloud Run deployment without a Prometheus scraper.
+var cloudExported = map[string]bool{
+	"ytt_videos_processed_total":       true,
+	"ytt_errors_total":                 true,
+	"ytt_bigquery_failed_rows_total":   true,
+	"ytt_channels_auto_disabled_total": true,
+	"ytt_api_quota_remaining":          true,
+	"ytt_last_run_timestamp":           true,
+}
+
+// maxTimeSeriesPerRequest is the Cloud Monitoring limit for one
+// timeSeries.create call.
+const maxTimeSeriesPerRequest = 200
+
+// MonitoredTask identifies the process in the generic_task monitored
+// resource. TaskID must be unique per instance: Cloud Monitoring rejects
+// points written to the same time series by two writers.
+type MonitoredTask struct {
+	ProjectID string
+	Location  string
+	Namespace string
+	Job       string
+	TaskID    string
+}
+
+// CloudMonitoringExporter pushes the key application metrics to Cloud
+// Monitoring as custom metrics, for deployments where nothing scrapes
+// /metrics. Counters are exported as cumulative metrics starting when the
+// exporter was created, gauges as gauge metrics.
+type CloudMonitoringExporter struct {
+	timeSeries *monitoring.ProjectsTimeSeriesService
+	project    string
+	resource   *monitoring.MonitoredResource
+	metrics    *Metrics
+	start      time.Time
+}
+
+// NewCloudMonitoringExporter creates an exporter for m using the default
+// credentials, which need roles/monitoring.metricWriter.
+func NewCloudMonitoringExporter(ctx context.Context, m *Metrics, task MonitoredTask) (*CloudMonitoringExporter, error) {
+	if task.ProjectID == "" {
+		return nil, errors.Config("Cloud Monitoring requires a project ID", nil)
+	}
+	svc, err := monitoring.NewService(ctx)
+	if err != nil {
+		return nil, errors.Config("failed to create Cloud Monitoring client", err)
+	}
+	return &CloudMonitoringExporter{
+		timeSeries: svc.Projects.TimeSeries,
+		project:    "projects/" + task.ProjectID,
+		resource: &monitoring.MonitoredResource{
+			Type: "generic_task",
+			Labels: map[string]string{
+				"project_id": task.ProjectID,
+				"location":   task.Location,
+				"namespace":  task.Namespace,
+				"job":        task.Job,
+				"task_id":    task.TaskID,
+			},
+		},
+		metrics: m,
+		start:   time.Now(),
+	}, nil
+}
+
+// Export writes the current value of every exported metric. Cloud
+// Monitoring accepts one point per time series every 5 seconds at most, so
+// callers export after a run rather than continuously.
+func (e *CloudMonitoringExporter) Export(ctx context.Context) error {
+	families, err := e.metrics.registry.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	series := cloudTimeSeries(families, e.resource, e.start, time.Now())
+	for i := 0; i < len(series); i += maxTimeSeriesPerRequest {
+		end := min(i+maxTimeSeriesPerRequest, len(series))
+		req := &monitoring.CreateTimeSeriesRequest{TimeSeries: series[i:end]}
+		if _, err := e.timeSeries.Create(e.project, req).Context(ctx).Do(); err != nil {
+			return errors.FromGoogleAPI("failed to write metrics to Cloud Monitoring", err)
+		}
+	}
+	return nil
+}
+
+// cloudTimeSeries converts the exported metric families to time series, in
+// the order Gather returns them (sorted by name). Gauges that are still zero
+// have never been set (e.g. no run has finished yet) and are left out rather
+// than reported as a misleading zero.
+func cloudTimeSeries(families []*dto.MetricFamily, resource *monitoring.MonitoredResource, start, now time.Time) []*monitoring.TimeSeries {
+	end := now.UTC().Format(time.RFC3339Nano)
+	var series []*monitoring.TimeSeries
+	for _, f := range families {
+		if !cloudExported[f.GetName()] {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			ts := &monitoring.TimeSeries{
+				Metric:    &monitoring.Metric{Type: CloudMetricPrefix + f.GetName(), Labels: labels(m)},
+				Resource:  resource,
+				ValueType: "DOUBLE",
+			}
+			var value float64
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				value = m.GetCounter().GetValue()
+				ts.MetricKind = "CUMULATIVE"
+				ts.Points = []*monitoring.Point{point(start.UTC().Format(time.RFC3339Nano), end, value)}
+			case dto.MetricType_GAUGE:
+				value = m.GetGauge().GetValue()
+				if value == 0 {
+					continue
+				}
+				ts.MetricKind = "GAUGE"
+				ts.Points = []*monitoring.Point{point("", end, value)}
+			default:
+				continue
+			}
+			series = append(series, ts)
+		}
+	}
+	return series
+}
+
+func labels(m *dto.Metric) map[string]string {
+	if len(m.GetLabel()) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		out[l.GetName()] = l.GetValue()
+	}
+	return out
+}
+
+func point(start, end string, value float64) *monitoring.Point {
+	return &monitoring.Point{
+		Interval: &monitoring.TimeInterval{StartTime: start, EndTime: end},
+		Value:    &monitoring.TypedValue{DoubleValue: &value},
+	}
+}
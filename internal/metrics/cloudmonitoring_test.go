@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+func TestCloudTimeSeries(t *testing.T) {
+	m := NewMetrics()
+	m.RecordVideosProcessed(40)
+	m.RecordError("fetch", "channel")
+	m.RecordError("fetch", "channel")
+	m.SetAPIQuotaRemaining(9000)
+	m.RecordAPICall("youtube", "videos.list", "ok", time.Second) // not exported
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+	series := cloudTimeSeries(families, &monitoring.MonitoredResource{Type: "generic_task"}, start, now)
+
+	byType := make(map[string]*monitoring.TimeSeries)
+	for _, ts := range series {
+		byType[ts.Metric.Type] = ts
+	}
+	// The last run timestamp was never set, so it is not exported.
+	if len(series) != 3 || byType[CloudMetricPrefix+"ytt_last_run_timestamp"] != nil {
+		t.Fatalf("got %d series %v, want videos processed, errors and quota remaining", len(series), byType)
+	}
+
+	videos := byType[CloudMetricPrefix+"ytt_videos_processed_total"]
+	if videos.MetricKind != "CUMULATIVE" || *videos.Points[0].Value.DoubleValue != 40 ||
+		videos.Points[0].Interval.StartTime != "2025-08-01T00:00:00Z" || videos.Points[0].Interval.EndTime != "2025-08-01T01:00:00Z" {
+		t.Errorf("videos processed = %+v, %+v", videos, videos.Points[0].Interval)
+	}
+	errs := byType[CloudMetricPrefix+"ytt_errors_total"]
+	if errs.Metric.Labels["component"] != "fetch" || errs.Metric.Labels["type"] != "channel" || *errs.Points[0].Value.DoubleValue != 2 {
+		t.Errorf("errors = %+v", errs.Metric)
+	}
+	quota := byType[CloudMetricPrefix+"ytt_api_quota_remaining"]
+	if quota.MetricKind != "GAUGE" || quota.Points[0].Interval.StartTime != "" || *quota.Points[0].Value.DoubleValue != 9000 {
+		t.Errorf("quota remaining = %+v", quota)
+	}
+}
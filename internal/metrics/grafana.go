@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// GrafanaOptions configures the generated Grafana dashboard and alert rules.
+type GrafanaOptions struct {
+	// Title of the dashboard.
+	Title string
+	// DatasourceUID is the UID of the Prometheus datasource. Empty makes the
+	// dashboard ask for one through a template variable.
+	DatasourceUID string
+	// QuotaWarning fires the quota alert below this many remaining units.
+	QuotaWarning float64
+	// StaleAfterSeconds fires the stale-run alert when the last successful
+	// run is older than this.
+	StaleAfterSeconds float64
+}
+
+// DefaultGrafanaOptions returns options suited to the default hourly schedule
+// and 10,000 unit daily quota.
+func DefaultGrafanaOptions() GrafanaOptions {
+	return GrafanaOptions{
+		Title:             "YouTube Trend Tracker",
+		QuotaWarning:      1000,
+		StaleAfterSeconds: 2 * 60 * 60,
+	}
+}
+
+// grafanaPanel describes one time series panel.
+type grafanaPanel struct {
+	title  string
+	unit   string
+	expr   string
+	legend string
+}
+
+// dashboardPanels lists the panels in display order, two per row. Every
+// expression must only use metrics registered in NewMetrics.
+var dashboardPanels = []grafanaPanel{
+	{"YouTube API calls", "reqps", `sum by (api, method, status) (rate(ytt_api_calls_total[5m]))`, "{{api}} {{method}} {{status}}"},
+	{"YouTube API latency (p95)", "s", `histogram_quantile(0.95, sum by (le, api, method) (rate(ytt_api_call_duration_seconds_bucket[5m])))`, "{{api}} {{method}}"},
+	{"Errors", "ops", `sum by (component, type) (rate(ytt_errors_total[5m]))`, "{{component}} {{type}}"},
+	{"Videos processed per hour", "short", `sum(increase(ytt_videos_processed_total[1h]))`, "videos"},
+	{"API quota remaining", "short", `min(ytt_api_quota_remaining)`, "remaining"},
+	{"Time since last successful run", "s", `time() - max(ytt_last_run_timestamp)`, "age"},
+	{"BigQuery inserts", "ops", `sum by (table, status) (rate(ytt_bigquery_inserts_total[5m]))`, "{{table}} {{status}}"},
+	{"BigQuery failed rows per hour", "short", `sum by (table, reason) (increase(ytt_bigquery_failed_rows_total[1h]))`, "{{table}} {{reason}}"},
+	{"Retries", "ops", `sum by (component, reason) (rate(ytt_retries_total[5m]))`, "{{component}} {{reason}}"},
+	{"Attempts per operation (p95)", "short", `histogram_quantile(0.95, sum by (le, component) (rate(ytt_retry_attempts_bucket[5m])))`, "{{component}}"},
+	{"Slowest channels (p95, top 10)", "s", `topk(10, histogram_quantile(0.95, sum by (le, channel_id) (rate(ytt_channel_fetch_duration_seconds_bucket[1h]))))`, "{{channel_id}}"},
+	{"Failing channels per hour (top 10)", "short", `topk(10, sum by (channel_id) (increase(ytt_channel_fetch_failures_total[1h])) > 0)`, "{{channel_id}}"},
+	{"API rate limiter saturation", "percentunit", `max(ytt_api_rate_limit_saturation)`, "saturation"},
+}
+
+// GrafanaDashboard returns a Grafana dashboard JSON model for the ytt_*
+// metrics, ready for "Import dashboard".
+func GrafanaDashboard(opts GrafanaOptions) ([]byte, error) {
+	datasource := map[string]interface{}{"type": "prometheus", "uid": "${datasource}"}
+	templating := []interface{}{map[string]interface{}{
+		"name":  "datasource",
+		"label": "Prometheus",
+		"type":  "datasource",
+		"query": "prometheus",
+	}}
+	if opts.DatasourceUID != "" {
+		datasource["uid"] = opts.DatasourceUID
+		templating = []interface{}{}
+	}
+
+	panels := make([]interface{}, 0, len(dashboardPanels))
+	for i, p := range dashboardPanels {
+		panels = append(panels, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": p.unit},
+				"overrides": []interface{}{},
+			},
+			"targets": []interface{}{map[string]interface{}{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         p.expr,
+				"legendFormat": p.legend,
+			}},
+		})
+	}
+
+	return marshalJSON(map[string]interface{}{
+		"uid":           "ytt-overview",
+		"title":         opts.Title,
+		"tags":          []string{"youtube-trend-tracker"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-24h", "to": "now"},
+		"templating":    map[string]interface{}{"list": templating},
+		"panels":        panels,
+	})
+}
+
+// grafanaAlert describes one alert rule that fires when expr is true.
+type grafanaAlert struct {
+	uid     string
+	title   string
+	expr    string
+	summary string
+}
+
+func alertRules(opts GrafanaOptions) []grafanaAlert {
+	return []grafanaAlert{
+		{"ytt-errors", "Fetch errors", `sum(increase(ytt_errors_total[15m])) > 0`, "The fetcher reported errors in the last 15 minutes."},
+		{"ytt-quota-low", "YouTube API quota low", fmt.Sprintf(`min(ytt_api_quota_remaining) < %g`, opts.QuotaWarning), fmt.Sprintf("Fewer than %g YouTube API quota units remain today.", opts.QuotaWarning)},
+		{"ytt-stale-run", "No successful run", fmt.Sprintf(`time() - max(ytt_last_run_timestamp) > %g`, opts.StaleAfterSeconds), fmt.Sprintf("No fetch has succeeded for %g seconds.", opts.StaleAfterSeconds)},
+		{"ytt-bigquery-failed-rows", "BigQuery rows rejected", `sum(increase(ytt_bigquery_failed_rows_total[1h])) > 0`, "BigQuery rejected rows after retries in the last hour."},
+		{"ytt-channel-disabled", "Channel disabled", `sum(increase(ytt_channels_auto_disabled_total[1h])) > 0`, "A channel was disabled after failing repeatedly; re-enable it with POST /channels/{id}/enable once fixed."},
+	}
+}
+
+// GrafanaAlertRules returns Grafana alert rules for the ytt_* metrics in the
+// file provisioning format (provisioning/alerting/*.json). Provisioned rules
+// need a concrete datasource, so DatasourceUID is required.
+func GrafanaAlertRules(opts GrafanaOptions) ([]byte, error) {
+	if opts.DatasourceUID == "" {
+		return nil, fmt.Errorf("alert rules require a Prometheus datasource UID")
+	}
+
+	var rules []interface{}
+	for _, a := range alertRules(opts) {
+		rules = append(rules, map[string]interface{}{
+			"uid":       a.uid,
+			"title":     a.title,
+			"condition": "A",
+			"for":       "5m",
+			"data": []interface{}{map[string]interface{}{
+				"refId":             "A",
+				"datasourceUid":     opts.DatasourceUID,
+				"relativeTimeRange": map[string]int{"from": 900, "to": 0},
+				"model": map[string]interface{}{
+					"refId":   "A",
+					"expr":    a.expr,
+					"instant": true,
+				},
+			}},
+			"noDataState":  "OK",
+			"execErrState": "Error",
+			"annotations":  map[string]string{"summary": a.summary},
+			"labels":       map[string]string{"service": "youtube-trend-tracker"},
+		})
+	}
+
+	return marshalJSON(map[string]interface{}{
+		"apiVersion": 1,
+		"groups": []interface{}{map[string]interface{}{
+			"orgId":    1,
+			"name":     "youtube-trend-tracker",
+			"folder":   "YouTube Trend Tracker",
+			"interval": "1m",
+			"rules":    rules,
+		}},
+	})
+}
+
+// marshalJSON indents v without escaping <, > and &, which appear in PromQL.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// registeredNames returns the names of the metrics NewMetrics registers.
+func registeredNames(m *Metrics) map[string]bool {
+	collectors := []prometheus.Collector{
+		m.VideosProcessed, m.APICallsTotal, m.BigQueryInserts, m.BigQueryFailed,
+		m.ErrorsTotal, m.RetriesTotal, m.APICallDuration, m.BigQueryDuration,
+		m.ProcessingDuration, m.RetryAttempts,
+		m.ChannelVideosProcessed, m.ChannelFetchFailures, m.ChannelsAutoDisabled, m.ChannelFetchDuration,
+		m.LastRunTimestamp, m.APIQuotaRemaining, m.APIRateLimitSaturation, m.ActiveConnections,
+	}
+	ch := make(chan *prometheus.Desc, len(collectors))
+	names := make(map[string]bool)
+	re := regexp.MustCompile(`fqName: "([^"]+)"`)
+	for _, c := range collectors {
+		c.Describe(ch)
+		if match := re.FindStringSubmatch((<-ch).String()); match != nil {
+			names[match[1]] = true
+		}
+	}
+	return names
+}
+
+func TestGrafanaDashboard_UsesRegisteredMetrics(t *testing.T) {
+	data, err := GrafanaDashboard(DefaultGrafanaOptions())
+	if err != nil {
+		t.Fatalf("GrafanaDashboard() error = %v", err)
+	}
+
+	var dash struct {
+		Panels []struct {
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(data, &dash); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+	if len(dash.Panels) != len(dashboardPanels) {
+		t.Fatalf("dashboard has %d panels, want %d", len(dash.Panels), len(dashboardPanels))
+	}
+
+	names := registeredNames(NewMetrics())
+	re := regexp.MustCompile(`ytt_[a-z_]+`)
+	exprs := make([]string, 0, len(dash.Panels))
+	for _, p := range dash.Panels {
+		exprs = append(exprs, p.Targets[0].Expr)
+	}
+	for _, a := range alertRules(DefaultGrafanaOptions()) {
+		exprs = append(exprs, a.expr)
+	}
+	for _, expr := range exprs {
+		for _, name := range re.FindAllString(expr, -1) {
+			if !names[strings.TrimSuffix(name, "_bucket")] {
+				t.Errorf("expression %q uses unregistered metric %s", expr, name)
+			}
+		}
+	}
+}
+
+func TestGrafanaAlertRules(t *testing.T) {
+	if _, err := GrafanaAlertRules(DefaultGrafanaOptions()); err == nil {
+		t.Error("GrafanaAlertRules() without a datasource UID should fail")
+	}
+
+	opts := DefaultGrafanaOptions()
+	opts.DatasourceUID = "prom"
+	data, err := GrafanaAlertRules(opts)
+	if err != nil {
+		t.Fatalf("GrafanaAlertRules() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"datasourceUid": "prom"`) || !strings.Contains(string(data), "ytt_api_quota_remaining) < 1000") {
+		t.Errorf("unexpected alert rules: %s", data)
+	}
+}
@@ -1,6 +1,9 @@
 // Package metrics provides Prometheus metrics for the YouTube Trend Tracker application.
-// This package will be integrated in a future update for monitoring and observability.
-// TODO: Integrate with main application in Issue #28
+// The fetcher serves them on /metrics and can push the key ones to Cloud
+// Monitoring (see CloudMonitoringExporter); so far BigQuery insert outcomes,
+// YouTube API retries, run outcomes and per-channel fetch outcomes are
+// recorded.
+// TODO: Record the remaining metrics (Issue #28)
 package metrics
 
 import (
@@ -15,26 +18,48 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// MaxChannelLabels caps the number of distinct channel_id label values of
+// the per-channel metrics. Channels seen after the cap is reached are
+// recorded under OtherChannels, so a misconfigured or very large channel
+// list cannot blow up the number of time series.
+const MaxChannelLabels = 200
+
+// OtherChannels is the channel_id label value shared by channels beyond
+// MaxChannelLabels.
+const OtherChannels = "other"
+
 // Metrics holds all application metrics
 type Metrics struct {
 	// Counters
 	VideosProcessed prometheus.Counter
 	APICallsTotal   *prometheus.CounterVec
 	BigQueryInserts *prometheus.CounterVec
+	BigQueryFailed  *prometheus.CounterVec
 	ErrorsTotal     *prometheus.CounterVec
+	RetriesTotal    *prometheus.CounterVec
+
+	// Per-channel counters, labelled by channel_id (see MaxChannelLabels)
+	ChannelVideosProcessed *prometheus.CounterVec
+	ChannelFetchFailures   *prometheus.CounterVec
+	ChannelsAutoDisabled   *prometheus.CounterVec
 
 	// Histograms for latency
-	APICallDuration    *prometheus.HistogramVec
-	BigQueryDuration   *prometheus.HistogramVec
-	ProcessingDuration prometheus.Histogram
+	APICallDuration      *prometheus.HistogramVec
+	BigQueryDuration     *prometheus.HistogramVec
+	ProcessingDuration   prometheus.Histogram
+	RetryAttempts        *prometheus.HistogramVec
+	ChannelFetchDuration *prometheus.HistogramVec
 
 	// Gauges
-	LastRunTimestamp  prometheus.Gauge
-	APIQuotaRemaining prometheus.Gauge
-	ActiveConnections prometheus.Gauge
-
-	mu       sync.RWMutex
-	registry *prometheus.Registry
+	LastRunTimestamp       prometheus.Gauge
+	APIQuotaRemaining      prometheus.Gauge
+	APIRateLimitSaturation prometheus.Gauge
+	ActiveConnections      prometheus.Gauge
+
+	mu          sync.RWMutex
+	channels    map[string]bool // channel_id label values in use
+	maxChannels int
+	registry    *prometheus.Registry
 }
 
 // NewMetrics creates and registers all metrics
@@ -42,7 +67,9 @@ func NewMetrics() *Metrics {
 	registry := prometheus.NewRegistry()
 
 	m := &Metrics{
-		registry: registry,
+		registry:    registry,
+		channels:    make(map[string]bool),
+		maxChannels: MaxChannelLabels,
 
 		VideosProcessed: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "ytt_videos_processed_total",
@@ -65,6 +92,14 @@ func NewMetrics() *Metrics {
 			[]string{"dataset", "table", "status"},
 		),
 
+		BigQueryFailed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ytt_bigquery_failed_rows_total",
+				Help: "Total number of rows BigQuery rejected after retries",
+			},
+			[]string{"dataset", "table", "reason"},
+		),
+
 		ErrorsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "ytt_errors_total",
@@ -73,6 +108,38 @@ func NewMetrics() *Metrics {
 			[]string{"component", "type"},
 		),
 
+		RetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ytt_retries_total",
+				Help: "Total number of retried attempts",
+			},
+			[]string{"component", "reason"},
+		),
+
+		ChannelVideosProcessed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ytt_channel_videos_processed_total",
+				Help: "Total number of videos processed per channel",
+			},
+			[]string{"channel_id"},
+		),
+
+		ChannelFetchFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ytt_channel_fetch_failures_total",
+				Help: "Total number of failed channel fetches",
+			},
+			[]string{"channel_id"},
+		),
+
+		ChannelsAutoDisabled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ytt_channels_auto_disabled_total",
+				Help: "Total number of channels disabled after repeated failures",
+			},
+			[]string{"channel_id"},
+		),
+
 		APICallDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "ytt_api_call_duration_seconds",
@@ -99,6 +166,24 @@ func NewMetrics() *Metrics {
 			},
 		),
 
+		RetryAttempts: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ytt_retry_attempts",
+				Help:    "Number of attempts made per retried operation",
+				Buckets: prometheus.LinearBuckets(1, 1, 10),
+			},
+			[]string{"component"},
+		),
+
+		ChannelFetchDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ytt_channel_fetch_duration_seconds",
+				Help:    "Duration of fetching and storing one channel in seconds",
+				Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+			},
+			[]string{"channel_id"},
+		),
+
 		LastRunTimestamp: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "ytt_last_run_timestamp",
@@ -113,6 +198,13 @@ func NewMetrics() *Metrics {
 			},
 		),
 
+		APIRateLimitSaturation: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "ytt_api_rate_limit_saturation",
+				Help: "Share of the YouTube API rate limiter burst in use (1 when requests wait)",
+			},
+		),
+
 		ActiveConnections: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "ytt_active_connections",
@@ -126,12 +218,20 @@ func NewMetrics() *Metrics {
 		m.VideosProcessed,
 		m.APICallsTotal,
 		m.BigQueryInserts,
+		m.BigQueryFailed,
 		m.ErrorsTotal,
+		m.RetriesTotal,
+		m.ChannelVideosProcessed,
+		m.ChannelFetchFailures,
+		m.ChannelsAutoDisabled,
 		m.APICallDuration,
 		m.BigQueryDuration,
 		m.ProcessingDuration,
+		m.RetryAttempts,
+		m.ChannelFetchDuration,
 		m.LastRunTimestamp,
 		m.APIQuotaRemaining,
+		m.APIRateLimitSaturation,
 		m.ActiveConnections,
 	)
 
@@ -161,16 +261,71 @@ func (m *Metrics) RecordBigQueryOp(operation, dataset, table, status string, dur
 	m.BigQueryDuration.WithLabelValues(operation, dataset, table).Observe(duration.Seconds())
 }
 
+// RecordFailedRows records rows that could not be inserted
+func (m *Metrics) RecordFailedRows(dataset, table, reason string, count int) {
+	m.BigQueryFailed.WithLabelValues(dataset, table, reason).Add(float64(count))
+}
+
 // RecordError records an error occurrence
 func (m *Metrics) RecordError(component, errorType string) {
 	m.ErrorsTotal.WithLabelValues(component, errorType).Inc()
 }
 
+// RecordRetry records a failed attempt that will be retried
+func (m *Metrics) RecordRetry(component, reason string) {
+	m.RetriesTotal.WithLabelValues(component, reason).Inc()
+}
+
+// RecordAttempts records how many attempts an operation took
+func (m *Metrics) RecordAttempts(component string, attempts int) {
+	m.RetryAttempts.WithLabelValues(component).Observe(float64(attempts))
+}
+
 // RecordVideosProcessed increments the videos processed counter
 func (m *Metrics) RecordVideosProcessed(count int) {
 	m.VideosProcessed.Add(float64(count))
 }
 
+// RecordChannelFetch records the outcome of fetching and storing one
+// channel: the videos stored, or a failure, and how long it took
+func (m *Metrics) RecordChannelFetch(channelID string, videos int, failed bool, duration time.Duration) {
+	label := m.channelLabel(channelID)
+	if failed {
+		m.ChannelFetchFailures.WithLabelValues(label).Inc()
+	} else {
+		m.ChannelVideosProcessed.WithLabelValues(label).Add(float64(videos))
+	}
+	m.ChannelFetchDuration.WithLabelValues(label).Observe(duration.Seconds())
+}
+
+// RecordChannelDisabled records that a channel was disabled after failing
+// repeatedly
+func (m *Metrics) RecordChannelDisabled(channelID string) {
+	m.ChannelsAutoDisabled.WithLabelValues(m.channelLabel(channelID)).Inc()
+}
+
+// channelLabel returns the channel_id label value for channelID: the ID
+// itself for the first MaxChannelLabels channels, OtherChannels after that.
+func (m *Metrics) channelLabel(channelID string) string {
+	m.mu.RLock()
+	known := m.channels[channelID]
+	m.mu.RUnlock()
+	if known {
+		return channelID
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.channels[channelID] {
+		return channelID
+	}
+	if len(m.channels) >= m.maxChannels {
+		return OtherChannels
+	}
+	m.channels[channelID] = true
+	return channelID
+}
+
 // SetLastRunTimestamp updates the last run timestamp
 func (m *Metrics) SetLastRunTimestamp() {
 	m.LastRunTimestamp.SetToCurrentTime()
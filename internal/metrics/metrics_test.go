@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordChannelFetch(t *testing.T) {
+	m := NewMetrics()
+	m.RecordChannelFetch("UC1", 3, false, 2*time.Second)
+	m.RecordChannelFetch("UC1", 2, false, time.Second)
+	m.RecordChannelFetch("UC2", 0, true, time.Second)
+
+	if got := testutil.ToFloat64(m.ChannelVideosProcessed.WithLabelValues("UC1")); got != 5 {
+		t.Errorf("videos processed for UC1 = %g, want 5", got)
+	}
+	if got := testutil.ToFloat64(m.ChannelFetchFailures.WithLabelValues("UC2")); got != 1 {
+		t.Errorf("failures for UC2 = %g, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ChannelFetchDuration); got != 2 {
+		t.Errorf("duration series = %d, want 2", got)
+	}
+}
+
+func TestRecordChannelFetch_CardinalityGuard(t *testing.T) {
+	m := NewMetrics()
+	m.maxChannels = 2
+	for _, id := range []string{"UC1", "UC2", "UC3", "UC4", "UC1"} {
+		m.RecordChannelFetch(id, 1, false, time.Second)
+	}
+
+	if got := testutil.CollectAndCount(m.ChannelVideosProcessed); got != 3 {
+		t.Errorf("videos processed series = %d, want 3 (two channels and %q)", got, OtherChannels)
+	}
+	if got := testutil.ToFloat64(m.ChannelVideosProcessed.WithLabelValues("UC1")); got != 2 {
+		t.Errorf("videos processed for UC1 = %g, want 2: known channels keep their label", got)
+	}
+	if got := testutil.ToFloat64(m.ChannelVideosProcessed.WithLabelValues(OtherChannels)); got != 2 {
+		t.Errorf("videos processed for %q = %g, want 2", OtherChannels, got)
+	}
+}
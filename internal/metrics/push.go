@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Pusher pushes the application metrics to a Prometheus Pushgateway, for
+// one-shot runs that exit before anything can scrape /metrics.
+type Pusher struct {
+	pusher *push.Pusher
+}
+
+// NewPusher returns a Pusher that sends the ytt_* metrics of m to the
+// Pushgateway at url under the given job and grouping labels. Go runtime and
+// process metrics are left out: they describe a process that is gone by the
+// time anyone looks at them.
+func NewPusher(m *Metrics, url, job string, grouping map[string]string, client *http.Client) *Pusher {
+	p := push.New(url, job).Gatherer(applicationMetrics(m.registry))
+	for name, value := range grouping {
+		p = p.Grouping(name, value)
+	}
+	if client != nil {
+		p = p.Client(client)
+	}
+	return &Pusher{pusher: p}
+}
+
+// Push sends the current values to the Pushgateway group, replacing the
+// metrics of the same name pushed by earlier runs and keeping the others.
+// Together with leaving out unset gauges this keeps ytt_last_run_timestamp
+// of the last successful run when a run fails.
+func (p *Pusher) Push(ctx context.Context) error {
+	if err := p.pusher.AddContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	return nil
+}
+
+// applicationMetrics returns a Gatherer for the ytt_* metrics of g, without
+// gauges that are still zero because they were never set in this process.
+func applicationMetrics(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		out := families[:0]
+		for _, f := range families {
+			if !strings.HasPrefix(f.GetName(), "ytt_") {
+				continue
+			}
+			if f.GetType() == dto.MetricType_GAUGE && unset(f) {
+				continue
+			}
+			out = append(out, f)
+		}
+		return out, err
+	})
+}
+
+// unset reports whether every series of a gauge family is zero.
+func unset(f *dto.MetricFamily) bool {
+	for _, m := range f.GetMetric() {
+		if m.GetGauge().GetValue() != 0 {
+			return false
+		}
+	}
+	return true
+}
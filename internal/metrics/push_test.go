@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPusher_Push(t *testing.T) {
+	var method, path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := NewMetrics()
+	m.RecordVideosProcessed(3)
+	p := NewPusher(m, srv.URL, "ytt", map[string]string{"instance": "0"}, srv.Client())
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if method != http.MethodPost {
+		t.Errorf("method = %s, want POST so metrics not pushed are kept", method)
+	}
+	if path != "/metrics/job/ytt/instance/0" {
+		t.Errorf("path = %s, want /metrics/job/ytt/instance/0", path)
+	}
+	// The body is in the protobuf exposition format; metric names appear
+	// verbatim in it.
+	if !strings.Contains(body, "ytt_videos_processed_total") {
+		t.Error("pushed metrics do not include ytt_videos_processed_total")
+	}
+	if strings.Contains(body, "go_goroutines") {
+		t.Error("pushed metrics include Go runtime metrics")
+	}
+	if strings.Contains(body, "ytt_last_run_timestamp") {
+		t.Error("pushed metrics include ytt_last_run_timestamp, which was never set")
+	}
+}
+
+func TestPusher_PushError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(NewMetrics(), srv.URL, "ytt", nil, srv.Client())
+	if err := p.Push(context.Background()); err == nil {
+		t.Error("Push() expected an error when the Pushgateway rejects the push")
+	}
+}
@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/digest"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// httpTimeout bounds each request of the Slack and webhook actions.
+const httpTimeout = 30 * time.Second
+
+// NewActions creates the configured actions by name.
+func NewActions(ctx context.Context, c *config.Config) (map[string]Action, error) {
+	actions := make(map[string]Action, len(c.Notifications.Actions))
+	for _, a := range c.Notifications.Actions {
+		action, err := NewAction(ctx, a, c)
+		if err != nil {
+			return nil, fmt.Errorf("notification action %q: %w", a.Name, err)
+		}
+		actions[a.Name] = action
+	}
+	return actions, nil
+}
+
+// NewAction creates one action. Email actions send through the digest
+// provider of c, and Pub/Sub actions publish to topics of gcp.project_id.
+func NewAction(ctx context.Context, a config.NotifyActionConfig, c *config.Config) (Action, error) {
+	client := &http.Client{Timeout: httpTimeout}
+	switch a.Type {
+	case config.NotifyActionSlack:
+		return &SlackAction{client: client, url: a.URL}, nil
+	case config.NotifyActionWebhook:
+		return &WebhookAction{client: client, url: a.URL}, nil
+	case config.NotifyActionPubSub:
+		return NewPubSubAction(ctx, c.GCP.ProjectID, a.Topic)
+	case config.NotifyActionEmail:
+		sender, err := digest.NewSender(c.Digest)
+		if err != nil {
+			return nil, err
+		}
+		return &EmailAction{sender: sender, from: c.Digest.From, to: a.To}, nil
+	default:
+		return nil, fmt.Errorf("unsupported action type %q", a.Type)
+	}
+}
+
+// postJSON POSTs body as JSON to url and fails unless the response is 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Temporary("failed to POST notification", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.API(fmt.Sprintf("notification endpoint returned %s: %s", resp.Status, bytes.TrimSpace(detail)), nil)
+	}
+	return nil
+}
+
+// SlackAction posts the events as one message to a Slack incoming webhook.
+type SlackAction struct {
+	client *http.Client
+	url    string
+}
+
+// maxSlackEvents limits the events listed in one Slack message.
+const maxSlackEvents = 20
+
+// Notify implements Action.
+func (a *SlackAction) Notify(ctx context.Context, events []Event) error {
+	return postJSON(ctx, a.client, a.url, map[string]string{"text": slackText(events)})
+}
+
+// slackText formats events as Slack mrkdwn, one line per video. New
+// uploads have no views worth reporting yet.
+func slackText(events []Event) string {
+	var b strings.Builder
+	for i, e := range events {
+		if i == maxSlackEvents {
+			fmt.Fprintf(&b, "…and %d more\n", len(events)-maxSlackEvents)
+			break
+		}
+		v := e.Video
+		if e.Rule == config.NewUploadsRuleName {
+			fmt.Fprintf(&b, "*new upload* <%s|%s> (%s)\n", e.URL, slackEscape(v.Title), slackEscape(v.ChannelName))
+			continue
+		}
+		fmt.Fprintf(&b, "*%s* <%s|%s> (%s) %s views, +%s in 24h\n",
+			slackEscape(e.Rule), e.URL, slackEscape(v.Title), slackEscape(v.ChannelName), digest.FormatNumber(v.Views), digest.FormatNumber(v.ViewsGained24h))
+	}
+	return b.String()
+}
+
+// slackEscape escapes the characters Slack treats as markup.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// WebhookAction POSTs the events as JSON ({"events": [...]}) to a URL.
+type WebhookAction struct {
+	client *http.Client
+	url    string
+}
+
+// webhookPayload is the body of a webhook action.
+type webhookPayload struct {
+	Events []Event `json:"events"`
+}
+
+// Notify implements Action.
+func (a *WebhookAction) Notify(ctx context.Context, events []Event) error {
+	return postJSON(ctx, a.client, a.url, webhookPayload{Events: events})
+}
+
+// PubSubAction publishes one JSON message per event, with the rule in the
+// "rule" attribute.
+type PubSubAction struct {
+	service *pubsub.Service
+	topic   string
+}
+
+// NewPubSubAction creates an action publishing to topicID. If
+// PUBSUB_EMULATOR_HOST is set, the emulator is used without authentication.
+func NewPubSubAction(ctx context.Context, projectID, topicID string, opts ...option.ClientOption) (*PubSubAction, error) {
+	if host := os.Getenv("PUBSUB_EMULATOR_HOST"); host != "" {
+		opts = append(opts, option.WithEndpoint("http://"+host+"/"), option.WithoutAuthentication())
+	}
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub.NewService: %w", err)
+	}
+	return &PubSubAction{service: svc, topic: fmt.Sprintf("projects/%s/topics/%s", projectID, topicID)}, nil
+}
+
+// maxMessagesPerPublish is the Pub/Sub limit on messages per publish request.
+const maxMessagesPerPublish = 1000
+
+// Notify implements Action.
+func (a *PubSubAction) Notify(ctx context.Context, events []Event) error {
+	for i := 0; i < len(events); i += maxMessagesPerPublish {
+		req := &pubsub.PublishRequest{}
+		for _, e := range events[i:min(i+maxMessagesPerPublish, len(events))] {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			req.Messages = append(req.Messages, &pubsub.PubsubMessage{
+				Data:       base64.StdEncoding.EncodeToString(data),
+				Attributes: map[string]string{"rule": e.Rule},
+			})
+		}
+		if _, err := a.service.Projects.Topics.Publish(a.topic, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", a.topic, err)
+		}
+	}
+	return nil
+}
+
+// EmailAction emails the events as an HTML table through the digest
+// provider.
+type EmailAction struct {
+	sender digest.Sender
+	from   string
+	to     []string
+}
+
+// Notify implements Action.
+func (a *EmailAction) Notify(ctx context.Context, events []Event) error {
+	var buf bytes.Buffer
+	if err := emailPage.Execute(&buf, events); err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+	return a.sender.Send(ctx, &digest.Message{
+		From:    a.from,
+		To:      a.to,
+		Subject: fmt.Sprintf("YouTube トレンド通知 (%d 件)", len(events)),
+		HTML:    buf.String(),
+	})
+}
+
+var emailPage = template.Must(template.New("notification").Funcs(template.FuncMap{
+	"num": digest.FormatNumber,
+}).Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="UTF-8"><title>YouTube トレンド通知</title></head>
+<body style="font-family: sans-serif; color: #202124;">
+<table cellpadding="6" cellspacing="0" style="border-collapse: collapse; font-size: 14px;">
+<tr style="background: #f1f3f4; text-align: left;">
+<th>ルール</th><th>動画</th><th>チャンネル</th><th style="text-align: right;">再生回数</th><th style="text-align: right;">24 時間の増加</th>
+</tr>
+{{- range .}}
+<tr style="border-top: 1px solid #dadce0;">
+<td>{{.Rule}}</td>
+<td><a href="{{.URL}}">{{.Video.Title}}</a></td>
+<td>{{.Video.ChannelName}}</td>
+<td style="text-align: right;">{{num .Video.Views}}</td>
+<td style="text-align: right;">+{{num .Video.ViewsGained24h}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
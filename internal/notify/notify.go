@@ -0,0 +1,205 @@
+// Package notify evaluates the notification rules of the configuration
+// for the videos of a run and delivers the matches to their actions: Slack,
+// webhooks, Pub/Sub or email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/analytics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/rules"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// Event is a video matched by a rule.
+type Event struct {
+	Rule  string             `json:"rule"`
+	URL   string             `json:"url"`
+	Video storage.VideoFacts `json:"video"`
+}
+
+// Action delivers the events of a run matched by the rules using it.
+type Action interface {
+	Notify(ctx context.Context, events []Event) error
+}
+
+// Rule is a compiled notification rule.
+type Rule struct {
+	Name     string
+	When     *rules.Expr
+	Actions  []string
+	Cooldown time.Duration
+	// Channels limits the rule to the videos of these channels; empty
+	// matches every channel.
+	Channels []string
+}
+
+// newUploadCooldown is the cooldown of the new_uploads rule: a video is new
+// once, for at most a week after its publication.
+const newUploadCooldown = 7 * 24 * time.Hour
+
+// Compile parses the conditions of the configured rules. With
+// notifications.new_uploads enabled, it adds the rule
+// config.NewUploadsRuleName matching the new uploads of its channels.
+func Compile(c config.NotificationsConfig) ([]Rule, error) {
+	compiled := make([]Rule, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		when, err := rules.Parse(r.When, rules.VideoKinds)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		cooldown := r.Cooldown
+		if cooldown == 0 {
+			cooldown = config.DefaultNotifyCooldown
+		}
+		compiled = append(compiled, Rule{Name: r.Name, When: when, Actions: r.Actions, Cooldown: cooldown})
+	}
+	if u := c.NewUploads; u.Enabled {
+		when, err := rules.Parse("new_upload", rules.VideoKinds)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, Rule{
+			Name:     config.NewUploadsRuleName,
+			When:     when,
+			Actions:  u.Actions,
+			Cooldown: newUploadCooldown,
+			Channels: u.Channels,
+		})
+	}
+	return compiled, nil
+}
+
+// MaxCooldown returns the longest cooldown of rs: how far back notifications
+// must be looked up to apply them.
+func MaxCooldown(rs []Rule) time.Duration {
+	var longest time.Duration
+	for _, r := range rs {
+		longest = max(longest, r.Cooldown)
+	}
+	return longest
+}
+
+// Vars returns the variables a condition is evaluated with for v at now.
+func Vars(v storage.VideoFacts, now time.Time) rules.Vars {
+	return rules.Vars{
+		"channel_id":         v.ChannelID,
+		"channel_name":       v.ChannelName,
+		"video_id":           v.VideoID,
+		"title":              v.Title,
+		"is_short":           v.IsShort,
+		"new_upload":         v.NewUpload,
+		"views":              v.Views,
+		"likes":              v.Likes,
+		"comments":           v.Comments,
+		"duration_sec":       v.DurationSec,
+		"age_hours":          now.Sub(v.PublishedAt).Hours(),
+		"views_gained_24h":   v.ViewsGained24h,
+		"forecast_views_7d":  forecastViews(v, analytics.ForecastHorizon7d),
+		"forecast_views_30d": forecastViews(v, analytics.ForecastHorizon30d),
+	}
+}
+
+// forecastViews projects v's views h ahead from its latest snapshot and
+// the snapshot its 24-hour gain counts from.
+func forecastViews(v storage.VideoFacts, h time.Duration) int64 {
+	points := []analytics.ViewPoint{{At: v.SnapshotTs, Views: v.Views}}
+	if v.BaselineTs.After(v.PublishedAt) && v.BaselineTs.Before(v.SnapshotTs) {
+		points = append([]analytics.ViewPoint{{At: v.BaselineTs, Views: v.Views - v.ViewsGained24h}}, points...)
+	}
+	return analytics.ForecastViews(v.PublishedAt, points, h)
+}
+
+// Match returns the events of the videos matching rs, by rule then in the
+// order of videos. A video a rule notified within its cooldown, according
+// to sent, is left out.
+func Match(rs []Rule, videos []storage.VideoFacts, sent []storage.NotificationRecord, now time.Time) []Event {
+	lastSent := make(map[[2]string]time.Time, len(sent))
+	for _, n := range sent {
+		key := [2]string{n.Rule, n.VideoID}
+		if n.SentAt.After(lastSent[key]) {
+			lastSent[key] = n.SentAt
+		}
+	}
+
+	var events []Event
+	for _, r := range rs {
+		for _, v := range videos {
+			if len(r.Channels) > 0 && !slices.Contains(r.Channels, v.ChannelID) {
+				continue
+			}
+			if at, ok := lastSent[[2]string{r.Name, v.VideoID}]; ok && now.Sub(at) < r.Cooldown {
+				continue
+			}
+			if r.When.Eval(Vars(v, now)) {
+				events = append(events, Event{Rule: r.Name, URL: VideoURL(v.VideoID), Video: v})
+			}
+		}
+	}
+	return events
+}
+
+// VideoURL returns the watch page of a video.
+func VideoURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + videoID
+}
+
+// Dispatch sends each action the events of the rules using it. It returns
+// the records of the events delivered by at least one action, and the
+// errors of the actions that failed by action name.
+func Dispatch(ctx context.Context, rs []Rule, actions map[string]Action, events []Event, now time.Time) ([]*storage.NotificationRecord, map[string]error) {
+	byRule := make(map[string]Rule, len(rs))
+	for _, r := range rs {
+		byRule[r.Name] = r
+	}
+	byAction := make(map[string][]Event)
+	for _, e := range events {
+		for _, name := range byRule[e.Rule].Actions {
+			byAction[name] = append(byAction[name], e)
+		}
+	}
+	names := make([]string, 0, len(byAction))
+	for name := range byAction {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	delivered := make(map[[2]string][]string)
+	failed := make(map[string]error)
+	for _, name := range names {
+		action, ok := actions[name]
+		if !ok {
+			failed[name] = fmt.Errorf("action %q is not configured", name)
+			continue
+		}
+		if err := action.Notify(ctx, byAction[name]); err != nil {
+			failed[name] = err
+			continue
+		}
+		for _, e := range byAction[name] {
+			key := [2]string{e.Rule, e.Video.VideoID}
+			delivered[key] = append(delivered[key], name)
+		}
+	}
+
+	var records []*storage.NotificationRecord
+	for _, e := range events {
+		key := [2]string{e.Rule, e.Video.VideoID}
+		if sentBy, ok := delivered[key]; ok {
+			records = append(records, &storage.NotificationRecord{
+				SentAt:    now,
+				Rule:      e.Rule,
+				ChannelID: e.Video.ChannelID,
+				VideoID:   e.Video.VideoID,
+				Actions:   sentBy,
+			})
+			delete(delivered, key)
+		}
+	}
+	return records, failed
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+var now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func compile(t *testing.T, rules ...config.NotifyRuleConfig) []Rule {
+	t.Helper()
+	rs, err := Compile(config.NotificationsConfig{Rules: rules})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return rs
+}
+
+func TestMatch(t *testing.T) {
+	rs := compile(t,
+		config.NotifyRuleConfig{Name: "viral", When: "views_gained_24h > 100000", Actions: []string{"slack"}},
+		config.NotifyRuleConfig{Name: "new-short", When: "new_upload and is_short and age_hours < 6", Actions: []string{"slack"}, Cooldown: time.Hour},
+	)
+	videos := []storage.VideoFacts{
+		{VideoID: "v1", ViewsGained24h: 250000},
+		{VideoID: "v2", ViewsGained24h: 5000, NewUpload: true, IsShort: true, PublishedAt: now.Add(-2 * time.Hour)},
+		{VideoID: "v3", ViewsGained24h: 150000, NewUpload: true, IsShort: true, PublishedAt: now.Add(-10 * time.Hour)},
+	}
+	sent := []storage.NotificationRecord{
+		// Within the default 24h cooldown of viral.
+		{Rule: "viral", VideoID: "v3", SentAt: now.Add(-23 * time.Hour)},
+		// Past the 1h cooldown of new-short.
+		{Rule: "new-short", VideoID: "v2", SentAt: now.Add(-2 * time.Hour)},
+	}
+
+	var got []string
+	for _, e := range Match(rs, videos, sent, now) {
+		got = append(got, e.Rule+":"+e.Video.VideoID)
+		if e.URL != "https://www.youtube.com/watch?v="+e.Video.VideoID {
+			t.Errorf("URL = %q", e.URL)
+		}
+	}
+	if want := []string{"viral:v1", "new-short:v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+	if d := MaxCooldown(rs); d != config.DefaultNotifyCooldown {
+		t.Errorf("MaxCooldown() = %v, want %v", d, config.DefaultNotifyCooldown)
+	}
+}
+
+func TestMatch_NewUploads(t *testing.T) {
+	rs, err := Compile(config.NotificationsConfig{NewUploads: config.NewUploadsConfig{
+		Enabled: true, Channels: []string{"UC1"}, Actions: []string{"slack"},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	videos := []storage.VideoFacts{
+		{ChannelID: "UC1", VideoID: "v1", NewUpload: true},
+		{ChannelID: "UC1", VideoID: "v2"},
+		// Not a watched channel.
+		{ChannelID: "UC2", VideoID: "v3", NewUpload: true},
+		// Notified two days ago, within the week of a new upload.
+		{ChannelID: "UC1", VideoID: "v4", NewUpload: true},
+	}
+	sent := []storage.NotificationRecord{{Rule: config.NewUploadsRuleName, VideoID: "v4", SentAt: now.Add(-48 * time.Hour)}}
+
+	events := Match(rs, videos, sent, now)
+	if len(events) != 1 || events[0].Rule != config.NewUploadsRuleName || events[0].Video.VideoID != "v1" {
+		t.Errorf("Match() = %+v, want new_upload for v1", events)
+	}
+	if got, want := slackText(events), "*new upload* <https://www.youtube.com/watch?v=v1|> ()\n"; got != want {
+		t.Errorf("slackText() = %q, want %q", got, want)
+	}
+}
+
+// fakeAction records the events it is sent, or fails.
+type fakeAction struct {
+	events []Event
+	err    error
+}
+
+func (f *fakeAction) Notify(ctx context.Context, events []Event) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, events...)
+	return nil
+}
+
+func TestMatch_Forecast(t *testing.T) {
+	rs := compile(t, config.NotifyRuleConfig{Name: "projected", When: "forecast_views_7d > 20000", Actions: []string{"slack"}})
+	published := now.Add(-48 * time.Hour)
+	videos := []storage.VideoFacts{
+		// 5,000 views in its first day, 5,000 more in the second.
+		{VideoID: "fast", Views: 10000, ViewsGained24h: 5000, PublishedAt: published, SnapshotTs: now, BaselineTs: now.Add(-24 * time.Hour)},
+		// Most views in its first day.
+		{VideoID: "slow", Views: 10000, ViewsGained24h: 500, PublishedAt: published, SnapshotTs: now, BaselineTs: now.Add(-24 * time.Hour)},
+	}
+
+	events := Match(rs, videos, nil, now)
+	if len(events) != 1 || events[0].Video.VideoID != "fast" {
+		t.Errorf("Match() = %+v, want the fast-growing video", events)
+	}
+	if v := Vars(videos[1], now); v["forecast_views_30d"].(int64) < v["forecast_views_7d"].(int64) {
+		t.Errorf("forecasts = %v, %v", v["forecast_views_7d"], v["forecast_views_30d"])
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	rs := compile(t,
+		config.NotifyRuleConfig{Name: "a", When: "views > 0", Actions: []string{"ok", "broken"}},
+		config.NotifyRuleConfig{Name: "b", When: "views > 0", Actions: []string{"broken"}},
+	)
+	ok, broken := &fakeAction{}, &fakeAction{err: errors.New("down")}
+	events := []Event{
+		{Rule: "a", Video: storage.VideoFacts{ChannelID: "UC1", VideoID: "v1"}},
+		{Rule: "b", Video: storage.VideoFacts{ChannelID: "UC1", VideoID: "v1"}},
+	}
+
+	records, failed := Dispatch(context.Background(), rs, map[string]Action{"ok": ok, "broken": broken}, events, now)
+	want := []*storage.NotificationRecord{{SentAt: now, Rule: "a", ChannelID: "UC1", VideoID: "v1", Actions: []string{"ok"}}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v, want only rule a through ok", records)
+	}
+	if len(failed) != 1 || failed["broken"] == nil {
+		t.Errorf("failed = %v, want broken", failed)
+	}
+	if len(ok.events) != 1 {
+		t.Errorf("ok got %d events, want 1", len(ok.events))
+	}
+}
+
+func TestSlackAndWebhookActions(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	events := []Event{{Rule: "viral", URL: VideoURL("v1"), Video: storage.VideoFacts{VideoID: "v1", Title: "A <b> & c", ChannelName: "Ch", Views: 1234567, ViewsGained24h: 150000}}}
+	ctx := context.Background()
+
+	slack, err := NewAction(ctx, config.NotifyActionConfig{Type: config.NotifyActionSlack, URL: srv.URL}, config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := slack.Notify(ctx, events); err != nil {
+		t.Fatalf("slack Notify() error = %v", err)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal([]byte(bodies[0]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if want := "*viral* <https://www.youtube.com/watch?v=v1|A &lt;b&gt; &amp; c> (Ch) 1,234,567 views, +150,000 in 24h\n"; msg["text"] != want {
+		t.Errorf("slack text = %q, want %q", msg["text"], want)
+	}
+
+	webhook, _ := NewAction(ctx, config.NotifyActionConfig{Type: config.NotifyActionWebhook, URL: srv.URL}, config.DefaultConfig())
+	if err := webhook.Notify(ctx, events); err != nil {
+		t.Fatalf("webhook Notify() error = %v", err)
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal([]byte(bodies[1]), &payload); err != nil || len(payload.Events) != 1 || payload.Events[0].Video.ViewsGained24h != 150000 {
+		t.Errorf("webhook payload = %s", bodies[1])
+	}
+
+	failing, _ := NewAction(ctx, config.NotifyActionConfig{Type: config.NotifyActionWebhook, URL: srv.URL + "/fail"}, config.DefaultConfig())
+	if err := failing.Notify(ctx, events); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Notify() to a failing endpoint error = %v, want the status", err)
+	}
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+)
+
+// builtin are the schemas of types whose JSON form is not their Go
+// structure.
+var builtin = map[reflect.Type]*Schema{
+	reflect.TypeOf(time.Time{}):       {Type: "string", Format: "date-time"},
+	reflect.TypeOf(civil.Date{}):      {Type: "string", Format: "date"},
+	reflect.TypeOf(civil.DateTime{}):  {Type: "string", Format: "date-time"},
+	reflect.TypeOf(json.RawMessage{}): {},
+}
+
+// Define sets the schema used for the type of v, for types that marshal
+// themselves (e.g. bigquery.NullInt64 as a number or null).
+func (d *Document) Define(v interface{}, schema *Schema) {
+	if d.defined == nil {
+		d.defined = make(map[reflect.Type]*Schema)
+	}
+	d.defined[reflect.TypeOf(v)] = schema
+}
+
+// SchemaOf returns the schema of the JSON encoding of v. Named struct types
+// are added to the components and referenced; a field is required unless
+// it is omitempty.
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schema(reflect.TypeOf(v))
+}
+
+func (d *Document) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if s, ok := d.defined[t]; ok {
+		return copySchema(s)
+	}
+	if s, ok := builtin[t]; ok {
+		return copySchema(s)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + d.component(t)}
+	default:
+		// Interfaces, and anything encoding/json would reject.
+		return &Schema{}
+	}
+}
+
+// component adds the schema of the named struct type t to the components
+// and returns its name there.
+func (d *Document) component(t reflect.Type) string {
+	if d.names == nil {
+		d.names = make(map[reflect.Type]string)
+	}
+	if name, ok := d.names[t]; ok {
+		return name
+	}
+	name := exported(t.Name())
+	if _, taken := d.Components.Schemas[name]; taken {
+		name = exported(path.Base(t.PkgPath())) + name
+	}
+	d.names[t] = name
+	// Register before recursing so self-referencing types terminate.
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.structSchema(t)
+	return name
+}
+
+// structSchema describes the fields encoding/json writes for t, with the
+// fields of embedded structs promoted.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := d.structSchema(ft)
+				for k, v := range embedded.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = d.schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// exported upper-cases the first letter of name, so that unexported Go
+// types get conventional schema names.
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+func copySchema(s *Schema) *Schema {
+	c := *s
+	return &c
+}
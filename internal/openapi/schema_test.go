@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type item struct {
+	base
+	Date     civil.Date        `json:"date"`
+	At       time.Time         `json:"at,omitempty"`
+	Count    int64             `json:"count"`
+	Rate     *float64          `json:"rate"`
+	Tags     []string          `json:"tags,omitempty"`
+	Counts   map[string]int    `json:"counts"`
+	Children []*item           `json:"children"`
+	Score    nullable          `json:"score"`
+	Raw      interface{}       `json:"raw"`
+	Skipped  string            `json:"-"`
+	internal string            // not encoded
+	Extra    map[string]string `json:",omitempty"`
+}
+
+type nullable struct{ V float64 }
+
+func TestSchemaOf(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	d.Define(nullable{}, &Schema{Type: "number", Nullable: true})
+
+	ref := d.SchemaOf([]item{})
+	if ref.Type != "array" || ref.Items.Ref != "#/components/schemas/Item" {
+		t.Fatalf("SchemaOf([]item) = %+v", ref)
+	}
+
+	s := d.Components.Schemas["Item"]
+	if s == nil {
+		t.Fatal("Item is not in the components")
+	}
+	want := map[string]Schema{
+		"id":       {Type: "string"},
+		"date":     {Type: "string", Format: "date"},
+		"at":       {Type: "string", Format: "date-time"},
+		"count":    {Type: "integer", Format: "int64"},
+		"rate":     {Type: "number", Format: "double", Nullable: true},
+		"score":    {Type: "number", Nullable: true},
+		"raw":      {},
+		"counts":   {Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}},
+		"tags":     {Type: "array", Items: &Schema{Type: "string"}},
+		"Extra":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/Item"}},
+	}
+	if len(s.Properties) != len(want) {
+		t.Errorf("properties = %v, want %d", keys(s.Properties), len(want))
+	}
+	for name, w := range want {
+		if got := s.Properties[name]; got == nil || !reflect.DeepEqual(*got, w) {
+			t.Errorf("property %s = %+v, want %+v", name, got, w)
+		}
+	}
+	wantRequired := []string{"id", "date", "count", "rate", "counts", "children", "score", "raw"}
+	if !reflect.DeepEqual(s.Required, wantRequired) {
+		t.Errorf("required = %v, want %v", s.Required, wantRequired)
+	}
+
+	if _, err := json.Marshal(d); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+func TestSchemaOf_NameCollision(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	d.Components.Schemas["Item"] = &Schema{Type: "string"}
+
+	if got := d.SchemaOf(item{}).Ref; got != "#/components/schemas/OpenapiItem" {
+		t.Errorf("ref = %q, want the package-qualified name", got)
+	}
+}
+
+func keys(m map[string]*Schema) []string {
+	var k []string
+	for name := range m {
+		k = append(k, name)
+	}
+	return k
+}
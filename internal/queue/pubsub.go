@@ -0,0 +1,109 @@
+// Package queue fans a fetch run out into per-channel tasks delivered through
+// Pub/Sub, so that many worker instances can each process a single channel.
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// maxMessagesPerPublish is the Pub/Sub limit on messages per publish request.
+const maxMessagesPerPublish = 1000
+
+// ChannelTask is the payload of a single per-channel work item.
+type ChannelTask struct {
+	ChannelID string `json:"channel_id"`
+	MaxVideos int64  `json:"max_videos,omitempty"`
+	RunID     string `json:"run_id,omitempty"`
+}
+
+// Publisher publishes channel tasks to a Pub/Sub topic.
+type Publisher struct {
+	service *pubsub.Service
+	topic   string
+}
+
+// NewPublisher creates a publisher for the given topic. If PUBSUB_EMULATOR_HOST
+// is set, the emulator is used without authentication.
+func NewPublisher(ctx context.Context, projectID, topicID string, opts ...option.ClientOption) (*Publisher, error) {
+	if host := os.Getenv("PUBSUB_EMULATOR_HOST"); host != "" {
+		opts = append(opts, option.WithEndpoint("http://"+host+"/"), option.WithoutAuthentication())
+	}
+
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub.NewService: %w", err)
+	}
+	return &Publisher{
+		service: svc,
+		topic:   fmt.Sprintf("projects/%s/topics/%s", projectID, topicID),
+	}, nil
+}
+
+// PublishChannelTasks publishes one message per task and returns the message IDs.
+func (p *Publisher) PublishChannelTasks(ctx context.Context, tasks []ChannelTask) ([]string, error) {
+	var ids []string
+	for i := 0; i < len(tasks); i += maxMessagesPerPublish {
+		end := i + maxMessagesPerPublish
+		if end > len(tasks) {
+			end = len(tasks)
+		}
+
+		req := &pubsub.PublishRequest{}
+		for _, task := range tasks[i:end] {
+			data, err := json.Marshal(task)
+			if err != nil {
+				return ids, fmt.Errorf("failed to encode task for channel %s: %w", task.ChannelID, err)
+			}
+			req.Messages = append(req.Messages, &pubsub.PubsubMessage{
+				Data:       base64.StdEncoding.EncodeToString(data),
+				Attributes: map[string]string{"channel_id": task.ChannelID},
+			})
+		}
+
+		resp, err := p.service.Projects.Topics.Publish(p.topic, req).Context(ctx).Do()
+		if err != nil {
+			return ids, fmt.Errorf("failed to publish to %s: %w", p.topic, err)
+		}
+		ids = append(ids, resp.MessageIds...)
+	}
+	return ids, nil
+}
+
+// pushEnvelope is the body of a Pub/Sub push delivery.
+type pushEnvelope struct {
+	Message struct {
+		Data      string `json:"data"`
+		MessageID string `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// DecodePushRequest parses a Pub/Sub push request body into a ChannelTask.
+func DecodePushRequest(r io.Reader) (*ChannelTask, error) {
+	var env pushEnvelope
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("invalid push envelope: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(env.Message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message data encoding: %w", err)
+	}
+
+	var task ChannelTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("invalid channel task: %w", err)
+	}
+	if task.ChannelID == "" {
+		return nil, fmt.Errorf("channel task %s has no channel_id", env.Message.MessageID)
+	}
+	return &task, nil
+}
@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+func TestPublishChannelTasks(t *testing.T) {
+	var got []ChannelTask
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/projects/p/topics/t:publish") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req pubsub.PublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		var ids []string
+		for i, m := range req.Messages {
+			data, _ := base64.StdEncoding.DecodeString(m.Data)
+			var task ChannelTask
+			json.Unmarshal(data, &task)
+			got = append(got, task)
+			ids = append(ids, string(rune('a'+i)))
+		}
+		json.NewEncoder(w).Encode(pubsub.PublishResponse{MessageIds: ids})
+	}))
+	defer srv.Close()
+
+	p, err := NewPublisher(context.Background(), "p", "t", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+
+	ids, err := p.PublishChannelTasks(context.Background(), []ChannelTask{
+		{ChannelID: "ch1", MaxVideos: 5, RunID: "r1"},
+		{ChannelID: "ch2", MaxVideos: 5, RunID: "r1"},
+	})
+	if err != nil {
+		t.Fatalf("PublishChannelTasks() error = %v", err)
+	}
+	if len(ids) != 2 || len(got) != 2 {
+		t.Fatalf("published %d messages (%d ids), want 2", len(got), len(ids))
+	}
+	if got[1].ChannelID != "ch2" || got[1].RunID != "r1" {
+		t.Errorf("unexpected task: %+v", got[1])
+	}
+}
+
+func TestDecodePushRequest(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"channel_id":"UC123","max_videos":10}`))
+	body := `{"message":{"data":"` + data + `","messageId":"1"},"subscription":"projects/p/subscriptions/s"}`
+
+	task, err := DecodePushRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("DecodePushRequest() error = %v", err)
+	}
+	if task.ChannelID != "UC123" || task.MaxVideos != 10 {
+		t.Errorf("DecodePushRequest() = %+v", task)
+	}
+}
+
+func TestDecodePushRequest_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not json":        `nope`,
+		"bad base64":      `{"message":{"data":"%%%"}}`,
+		"missing channel": `{"message":{"data":"` + base64.StdEncoding.EncodeToString([]byte(`{}`)) + `"}}`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodePushRequest(strings.NewReader(body)); err == nil {
+				t.Error("DecodePushRequest() expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+const gcsScheme = "gs://"
+
+// GCSDestination writes each table as <prefix>/<date>/<id>.csv.
+type GCSDestination struct {
+	service *gcs.Service
+	bucket  string
+	prefix  string
+}
+
+// NewGCSDestination creates a destination for gs://<bucket>[/<prefix>].
+func NewGCSDestination(ctx context.Context, uri string, opts ...option.ClientOption) (*GCSDestination, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, gcsScheme), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("report destination must be gs://<bucket>[/<prefix>]: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(gcs.DevstorageReadWriteScope))
+	svc, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewService: %w", err)
+	}
+	return &GCSDestination{service: svc, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// Write uploads one CSV object per table, overwriting earlier runs of the
+// same date.
+func (d *GCSDestination) Write(ctx context.Context, date civil.Date, tables []*Table) error {
+	for _, t := range tables {
+		data, err := encodeCSV(t)
+		if err != nil {
+			return err
+		}
+		name := path.Join(d.prefix, date.String(), t.ID+".csv")
+		obj := &gcs.Object{Name: name, ContentType: "text/csv; charset=utf-8"}
+		if _, err := d.service.Objects.Insert(d.bucket, obj).Media(bytes.NewReader(data)).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("storage.objects.insert %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// encodeCSV renders a table as CSV with a UTF-8 byte order mark, so that
+// Excel shows Japanese titles correctly.
+func encodeCSV(t *Table) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(t.Header); err != nil {
+		return nil, err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = csvCell(v)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvCell formats a value, prefixing text that a spreadsheet would treat as
+// a formula with an apostrophe.
+func csvCell(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
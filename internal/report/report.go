@@ -0,0 +1,148 @@
+// Package report writes the daily top-N video report for non-technical
+// stakeholders to a Google Sheet or to CSV files in Cloud Storage.
+package report
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// Source provides the videos a report lists.
+type Source interface {
+	TopGainers(ctx context.Context, date civil.Date, shortsOnly bool, limit int) ([]storage.VideoGain, error)
+}
+
+// PublishTimeSource provides the view velocity of the channels' videos by
+// publish slot, best first within each channel.
+type PublishTimeSource interface {
+	PublishTimeStats(ctx context.Context, date civil.Date, channelID string) ([]storage.PublishTimeStatsRecord, error)
+}
+
+// Destination stores the tables of a report.
+type Destination interface {
+	Write(ctx context.Context, date civil.Date, tables []*Table) error
+}
+
+// Table is one report table, written as a sheet tab or a CSV file.
+type Table struct {
+	// ID names the CSV file; Title names the sheet tab.
+	ID     string
+	Title  string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// header is shared by all tables.
+var header = []string{"順位", "タイトル", "チャンネル", "再生増加数", "総再生回数", "公開日", "URL"}
+
+// Build queries the report tables for date: the top videos by views gained
+// and the top Shorts by views gained.
+func Build(ctx context.Context, src Source, date civil.Date, topN int) ([]*Table, error) {
+	specs := []struct {
+		id, title  string
+		shortsOnly bool
+	}{
+		{"top-views-gained", fmt.Sprintf("再生増加 Top %d", topN), false},
+		{"top-shorts", fmt.Sprintf("ショート Top %d", topN), true},
+	}
+
+	tables := make([]*Table, 0, len(specs))
+	for _, spec := range specs {
+		videos, err := src.TopGainers(ctx, date, spec.shortsOnly, topN)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.id, err)
+		}
+		t := &Table{ID: spec.id, Title: spec.title, Header: header}
+		for i, v := range videos {
+			t.Rows = append(t.Rows, []interface{}{
+				i + 1,
+				v.Title,
+				v.ChannelName,
+				v.ViewsGained,
+				v.Views,
+				v.PublishedAt.Format("2006-01-02"),
+				"https://www.youtube.com/watch?v=" + v.VideoID,
+			})
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// publishSlotsPerChannel is the number of publish slots listed per channel.
+const publishSlotsPerChannel = 3
+
+// weekdays are the Japanese names of the days, from Sunday.
+var weekdays = []string{"日", "月", "火", "水", "木", "金", "土"}
+
+// BuildPublishTimes queries the table of the best publish slots of each
+// channel as of date: the slots whose videos gained the most views per hour
+// in their first day relative to the channel's average.
+func BuildPublishTimes(ctx context.Context, src PublishTimeSource, date civil.Date) (*Table, error) {
+	stats, err := src.PublishTimeStats(ctx, date, "")
+	if err != nil {
+		return nil, fmt.Errorf("best-publish-times: %w", err)
+	}
+	t := &Table{
+		ID:     "best-publish-times",
+		Title:  "投稿時間帯",
+		Header: []string{"チャンネル", "順位", "曜日", "時間帯", "動画数", "24時間の再生/時", "72時間の再生/時", "平均比"},
+	}
+	listed := make(map[string]int)
+	for _, s := range stats {
+		if s.Videos == 0 || listed[s.ChannelID] == publishSlotsPerChannel {
+			continue
+		}
+		listed[s.ChannelID]++
+		var perHour72h interface{} = ""
+		if s.ViewsPerHour72h.Valid {
+			perHour72h = math.Round(s.ViewsPerHour72h.Float64)
+		}
+		t.Rows = append(t.Rows, []interface{}{
+			s.ChannelName,
+			listed[s.ChannelID],
+			weekdays[s.Weekday],
+			fmt.Sprintf("%d時台", s.Hour),
+			s.Videos,
+			math.Round(s.ViewsPerHour24h),
+			perHour72h,
+			math.Round(s.Lift24h*100) / 100,
+		})
+	}
+	return t, nil
+}
+
+// Run builds the report for date and writes it to dst. With publishTimes,
+// the report also lists the best publish times of each channel.
+func Run(ctx context.Context, src Source, dst Destination, date civil.Date, topN int, publishTimes PublishTimeSource) error {
+	tables, err := Build(ctx, src, date, topN)
+	if err != nil {
+		return err
+	}
+	if publishTimes != nil {
+		t, err := BuildPublishTimes(ctx, publishTimes, date)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t)
+	}
+	return dst.Write(ctx, date, tables)
+}
+
+// NewDestination returns the destination for a sheets://<spreadsheetId> or
+// gs://<bucket>[/<prefix>] URI, using Application Default Credentials.
+func NewDestination(ctx context.Context, uri string) (Destination, error) {
+	switch {
+	case strings.HasPrefix(uri, sheetsScheme):
+		return NewSheetsDestination(ctx, uri)
+	case strings.HasPrefix(uri, gcsScheme):
+		return NewGCSDestination(ctx, uri)
+	default:
+		return nil, fmt.Errorf("unsupported report destination %q", uri)
+	}
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+type fakeSource struct {
+	calls []bool
+}
+
+func (f *fakeSource) TopGainers(ctx context.Context, date civil.Date, shortsOnly bool, limit int) ([]storage.VideoGain, error) {
+	f.calls = append(f.calls, shortsOnly)
+	if shortsOnly {
+		return nil, nil
+	}
+	return []storage.VideoGain{
+		{VideoID: "v1", Title: "=HYPERLINK(\"x\")", ChannelName: "Ch", Views: 12000, ViewsGained: 3400,
+			PublishedAt: time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC)},
+		{VideoID: "v2", Title: "二本目", ChannelName: "Ch", Views: 500, ViewsGained: 100},
+	}, nil
+}
+
+type recordingDestination struct {
+	tables []*Table
+}
+
+func (d *recordingDestination) Write(ctx context.Context, date civil.Date, tables []*Table) error {
+	d.tables = tables
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	src := &fakeSource{}
+	dst := &recordingDestination{}
+	if err := Run(context.Background(), src, dst, civil.Date{Year: 2025, Month: 8, Day: 1}, 20, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(src.calls) != 2 || src.calls[0] || !src.calls[1] {
+		t.Errorf("TopGainers calls (shortsOnly) = %v, want [false true]", src.calls)
+	}
+	if len(dst.tables) != 2 {
+		t.Fatalf("wrote %d tables, want 2", len(dst.tables))
+	}
+	top, shorts := dst.tables[0], dst.tables[1]
+	if top.Title != "再生増加 Top 20" || shorts.ID != "top-shorts" || len(shorts.Rows) != 0 {
+		t.Errorf("tables = %+v, %+v", top, shorts)
+	}
+	row := top.Rows[0]
+	if row[0] != 1 || row[3] != int64(3400) || row[5] != "2025-07-30" || row[6] != "https://www.youtube.com/watch?v=v1" {
+		t.Errorf("first row = %v", row)
+	}
+}
+
+type fakePublishTimeSource struct{}
+
+func (fakePublishTimeSource) PublishTimeStats(ctx context.Context, date civil.Date, channelID string) ([]storage.PublishTimeStatsRecord, error) {
+	slot := func(channel string, weekday, hour int64, lift float64) storage.PublishTimeStatsRecord {
+		return storage.PublishTimeStatsRecord{ChannelID: channel, ChannelName: channel + " name", Weekday: weekday, Hour: hour,
+			Videos: 2, ViewsPerHour24h: 300.4, Lift24h: lift}
+	}
+	return []storage.PublishTimeStatsRecord{
+		slot("UC1", 5, 18, 1.456),
+		slot("UC1", 6, 9, 1.2),
+		// Only videos with a 72-hour velocity.
+		{ChannelID: "UC1", Weekday: 0, Hour: 7},
+		slot("UC1", 1, 12, 0.9),
+		slot("UC1", 2, 12, 0.5),
+		slot("UC2", 0, 20, 1),
+	}, nil
+}
+
+func TestRun_PublishTimes(t *testing.T) {
+	dst := &recordingDestination{}
+	if err := Run(context.Background(), &fakeSource{}, dst, civil.Date{Year: 2025, Month: 8, Day: 1}, 20, fakePublishTimeSource{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.tables) != 3 {
+		t.Fatalf("wrote %d tables, want 3", len(dst.tables))
+	}
+	table := dst.tables[2]
+	// Three slots for UC1, one for UC2.
+	if table.ID != "best-publish-times" || len(table.Rows) != 4 {
+		t.Fatalf("table = %+v", table)
+	}
+	row := table.Rows[0]
+	if row[0] != "UC1 name" || row[1] != 1 || row[2] != "金" || row[3] != "18時台" || row[5] != 300.0 || row[6] != "" || row[7] != 1.46 {
+		t.Errorf("first row = %v", row)
+	}
+	if row := table.Rows[3]; row[0] != "UC2 name" || row[1] != 1 || row[2] != "日" {
+		t.Errorf("last row = %v", row)
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	table := &Table{
+		ID:     "top",
+		Header: []string{"順位", "タイトル"},
+		Rows:   [][]interface{}{{1, "=cmd()"}, {2, "普通, の\"題\""}},
+	}
+	data, err := encodeCSV(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\ufeff順位,タイトル\n1,'=cmd()\n2,\"普通, の\"\"題\"\"\"\n"
+	if string(data) != want {
+		t.Errorf("csv = %q, want %q", data, want)
+	}
+}
+
+func TestSheetValues(t *testing.T) {
+	table := &Table{Title: "ショート Top 20", Header: []string{"順位"}, Rows: [][]interface{}{{1}}}
+	values := sheetValues(table, civil.Date{Year: 2025, Month: 8, Day: 1})
+	if len(values) != 3 || values[0][0] != "ショート Top 20（2025-08-01 時点）" || values[1][0] != "順位" || values[2][0] != 1 {
+		t.Errorf("values = %v", values)
+	}
+	if got := quoteSheet("Bob's"); got != "'Bob''s'" {
+		t.Errorf("quoteSheet = %s", got)
+	}
+}
+
+func TestNewDestination_Invalid(t *testing.T) {
+	for _, uri := range []string{"s3://bucket", "sheets://", "sheets://id/Sheet1", "gs://"} {
+		if _, err := NewDestination(context.Background(), uri); err == nil {
+			t.Errorf("NewDestination(%q) should fail", uri)
+		} else if strings.Contains(err.Error(), "NewService") {
+			t.Errorf("NewDestination(%q) should fail before creating a client: %v", uri, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+const sheetsScheme = "sheets://"
+
+// SheetsDestination writes each table to its own tab of a spreadsheet,
+// replacing the tab's contents. The spreadsheet must be shared with the
+// service account as an editor.
+type SheetsDestination struct {
+	service       *sheets.Service
+	spreadsheetID string
+}
+
+// NewSheetsDestination creates a destination for sheets://<spreadsheetId>.
+func NewSheetsDestination(ctx context.Context, uri string, opts ...option.ClientOption) (*SheetsDestination, error) {
+	id := strings.TrimSuffix(strings.TrimPrefix(uri, sheetsScheme), "/")
+	if id == "" || strings.Contains(id, "/") {
+		return nil, fmt.Errorf("report destination must be sheets://<spreadsheetId>: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(sheets.SpreadsheetsScope))
+	svc, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("sheets.NewService: %w", err)
+	}
+	return &SheetsDestination{service: svc, spreadsheetID: id}, nil
+}
+
+// Write replaces the contents of one tab per table, creating missing tabs.
+// Values are written RAW so titles starting with "=" are not evaluated.
+func (d *SheetsDestination) Write(ctx context.Context, date civil.Date, tables []*Table) error {
+	sheetIDs, err := d.ensureTabs(ctx, tables)
+	if err != nil {
+		return err
+	}
+
+	data := make([]*sheets.ValueRange, 0, len(tables))
+	var clear []string
+	for _, t := range tables {
+		clear = append(clear, quoteSheet(t.Title))
+		data = append(data, &sheets.ValueRange{
+			Range:  quoteSheet(t.Title) + "!A1",
+			Values: sheetValues(t, date),
+		})
+	}
+	if _, err := d.service.Spreadsheets.Values.BatchClear(d.spreadsheetID, &sheets.BatchClearValuesRequest{Ranges: clear}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("spreadsheets.values.batchClear: %w", err)
+	}
+	if _, err := d.service.Spreadsheets.Values.BatchUpdate(d.spreadsheetID, &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: "RAW",
+		Data:             data,
+	}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("spreadsheets.values.batchUpdate: %w", err)
+	}
+
+	var requests []*sheets.Request
+	for _, t := range tables {
+		requests = append(requests, formatRequests(sheetIDs[t.Title], len(t.Header))...)
+	}
+	if _, err := d.service.Spreadsheets.BatchUpdate(d.spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("spreadsheets.batchUpdate (format): %w", err)
+	}
+	return nil
+}
+
+// ensureTabs returns the sheet ID of each table's tab, adding missing tabs.
+func (d *SheetsDestination) ensureTabs(ctx context.Context, tables []*Table) (map[string]int64, error) {
+	ss, err := d.service.Spreadsheets.Get(d.spreadsheetID).Fields("sheets.properties").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("spreadsheets.get: %w", err)
+	}
+	ids := make(map[string]int64)
+	for _, s := range ss.Sheets {
+		ids[s.Properties.Title] = s.Properties.SheetId
+	}
+
+	var add []*sheets.Request
+	for _, t := range tables {
+		if _, ok := ids[t.Title]; !ok {
+			add = append(add, &sheets.Request{AddSheet: &sheets.AddSheetRequest{
+				Properties: &sheets.SheetProperties{Title: t.Title},
+			}})
+		}
+	}
+	if len(add) == 0 {
+		return ids, nil
+	}
+	resp, err := d.service.Spreadsheets.BatchUpdate(d.spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: add}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("spreadsheets.batchUpdate (add sheets): %w", err)
+	}
+	for _, r := range resp.Replies {
+		if r.AddSheet != nil {
+			ids[r.AddSheet.Properties.Title] = r.AddSheet.Properties.SheetId
+		}
+	}
+	return ids, nil
+}
+
+// sheetValues lays a table out as a caption row, a header row and the data.
+func sheetValues(t *Table, date civil.Date) [][]interface{} {
+	values := make([][]interface{}, 0, len(t.Rows)+2)
+	values = append(values, []interface{}{fmt.Sprintf("%s（%s 時点）", t.Title, date)})
+	head := make([]interface{}, len(t.Header))
+	for i, h := range t.Header {
+		head[i] = h
+	}
+	values = append(values, head)
+	return append(values, t.Rows...)
+}
+
+// formatRequests makes the caption and header bold, freezes them, formats
+// the count columns with thousands separators and fits the column widths.
+func formatRequests(sheetID int64, columns int) []*sheets.Request {
+	bold := &sheets.CellFormat{TextFormat: &sheets.TextFormat{Bold: true}}
+	return []*sheets.Request{
+		{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Properties: &sheets.SheetProperties{SheetId: sheetID, GridProperties: &sheets.GridProperties{FrozenRowCount: 2}},
+			Fields:     "gridProperties.frozenRowCount",
+		}},
+		{RepeatCell: &sheets.RepeatCellRequest{
+			Range:  &sheets.GridRange{SheetId: sheetID, StartRowIndex: 0, EndRowIndex: 2},
+			Cell:   &sheets.CellData{UserEnteredFormat: bold},
+			Fields: "userEnteredFormat.textFormat.bold",
+		}},
+		{RepeatCell: &sheets.RepeatCellRequest{
+			// 再生増加数 and 総再生回数
+			Range: &sheets.GridRange{SheetId: sheetID, StartRowIndex: 2, StartColumnIndex: 3, EndColumnIndex: 5},
+			Cell: &sheets.CellData{UserEnteredFormat: &sheets.CellFormat{
+				NumberFormat: &sheets.NumberFormat{Type: "NUMBER", Pattern: "#,##0"},
+			}},
+			Fields: "userEnteredFormat.numberFormat",
+		}},
+		{AutoResizeDimensions: &sheets.AutoResizeDimensionsRequest{
+			Dimensions: &sheets.DimensionRange{SheetId: sheetID, Dimension: "COLUMNS", StartIndex: 0, EndIndex: int64(columns)},
+		}},
+	}
+}
+
+// quoteSheet quotes a tab name for use in A1 notation.
+func quoteSheet(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
@@ -2,22 +2,30 @@ package retry
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
 	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
 )
 
-var log = logger.New()
-
 // Config holds retry configuration
 type Config struct {
 	MaxAttempts  int
 	InitialDelay time.Duration
 	MaxDelay     time.Duration
 	Multiplier   float64
+
+	// OnRetry, if set, is called after a failed attempt that will be retried,
+	// with the attempt number, its error and the delay before the next one.
+	OnRetry func(attempt int, err error, delay time.Duration)
+	// OnDone, if set, is called once per operation with the number of
+	// attempts made and the final error (nil on success).
+	OnDone func(attempts int, err error)
 }
 
 // DefaultConfig returns a default retry configuration
@@ -44,9 +52,15 @@ func Do(operation Operation, config Config) error {
 }
 
 // DoWithContext executes an operation with retry logic and context
-func DoWithContext(ctx context.Context, operation OperationWithContext, config Config) error {
+func DoWithContext(ctx context.Context, operation OperationWithContext, config Config) (err error) {
+	attempts := 0
+	if config.OnDone != nil {
+		defer func() { config.OnDone(attempts, err) }()
+	}
+
 	var lastErr error
 	delay := config.InitialDelay
+	log := logger.FromContext(ctx)
 
 	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
 		// Check context cancellation
@@ -57,6 +71,7 @@ func DoWithContext(ctx context.Context, operation OperationWithContext, config C
 		}
 
 		// Execute operation
+		attempts = attempt
 		err := operation(ctx)
 		if err == nil {
 			if attempt > 1 {
@@ -85,6 +100,9 @@ func DoWithContext(ctx context.Context, operation OperationWithContext, config C
 			"attempt": fmt.Sprintf("%d", attempt),
 			"delay":   delay.String(),
 		})
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, err, delay)
+		}
 
 		// Wait before retry
 		select {
@@ -103,6 +121,45 @@ func DoWithContext(ctx context.Context, operation OperationWithContext, config C
 	return fmt.Errorf("operation failed after %d attempts: %w", config.MaxAttempts, lastErr)
 }
 
+// Reason classifies an error for metric labels: the lower-cased AppError
+// type, "canceled" or "deadline_exceeded" for context errors, or "unknown".
+func Reason(err error) string {
+	var appErr *errors.AppError
+	switch {
+	case stderrors.As(err, &appErr):
+		return strings.ToLower(string(appErr.Type))
+	case stderrors.Is(err, context.Canceled):
+		return "canceled"
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "unknown"
+	}
+}
+
+// WithMetrics returns config with hooks that count retries in
+// ytt_retries_total and record attempts per operation in
+// ytt_retry_attempts, labelled with component. Existing hooks still run.
+func WithMetrics(config Config, m *metrics.Metrics, component string) Config {
+	if m == nil {
+		return config
+	}
+	onRetry, onDone := config.OnRetry, config.OnDone
+	config.OnRetry = func(attempt int, err error, delay time.Duration) {
+		m.RecordRetry(component, Reason(err))
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+	config.OnDone = func(attempts int, err error) {
+		m.RecordAttempts(component, attempts)
+		if onDone != nil {
+			onDone(attempts, err)
+		}
+	}
+	return config
+}
+
 // WithExponentialBackoff is a helper function for common exponential backoff retry
 func WithExponentialBackoff(operation Operation) error {
 	return Do(operation, DefaultConfig())
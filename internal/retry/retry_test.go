@@ -2,10 +2,13 @@ package retry
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestRetrySuccess(t *testing.T) {
@@ -152,3 +155,86 @@ func TestWithExponentialBackoff(t *testing.T) {
 		t.Errorf("Expected at least 2 attempts, got %d", attempts)
 	}
 }
+
+func TestRetryHooks(t *testing.T) {
+	var retried []int
+	var doneAttempts int
+	var doneErr error
+
+	config := Config{
+		MaxAttempts:  3,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+		Multiplier:   2.0,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retried = append(retried, attempt)
+		},
+		OnDone: func(attempts int, err error) {
+			doneAttempts, doneErr = attempts, err
+		},
+	}
+
+	err := Do(func() error { return errors.Temporary("temporary error", nil) }, config)
+	if err == nil {
+		t.Fatal("Expected error after max attempts")
+	}
+	if len(retried) != 2 || retried[0] != 1 || retried[1] != 2 {
+		t.Errorf("OnRetry attempts = %v, want [1 2]", retried)
+	}
+	if doneAttempts != 3 || doneErr != err {
+		t.Errorf("OnDone(%d, %v), want (3, %v)", doneAttempts, doneErr, err)
+	}
+
+	// A non-retriable error finishes after one attempt without retrying.
+	retried = nil
+	Do(func() error { return errors.API("bad request", nil) }, config)
+	if len(retried) != 0 || doneAttempts != 1 {
+		t.Errorf("non-retriable: retried %v, attempts %d, want none and 1", retried, doneAttempts)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := metrics.NewMetrics()
+	config := WithMetrics(Config{
+		MaxAttempts:  3,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+		Multiplier:   2.0,
+	}, m, "youtube")
+
+	attempts := 0
+	err := Do(func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.Temporary("temporary error", nil)
+		}
+		return nil
+	}, config)
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.RetriesTotal.WithLabelValues("youtube", "temporary")); got != 2 {
+		t.Errorf("ytt_retries_total = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.RetryAttempts); got != 1 {
+		t.Errorf("ytt_retry_attempts series = %d, want 1", got)
+	}
+}
+
+func TestReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.Temporary("x", nil), "temporary"},
+		{fmt.Errorf("wrapped: %w", errors.API("x", nil)), "api"},
+		{context.DeadlineExceeded, "deadline_exceeded"},
+		{fmt.Errorf("other"), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := Reason(tt.err); got != tt.want {
+			t.Errorf("Reason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+// Package rules parses and evaluates the conditions of notification rules:
+// boolean expressions over the facts of a video, such as
+// "views_gained_24h > 100000" or "new_upload and is_short".
+//
+// An expression combines comparisons (>, >=, <, <=, ==, !=) of numbers,
+// strings and variables with and, or and not (also &&, || and !) and
+// parentheses. Boolean variables can be used on their own. Expressions are
+// type-checked when parsed, so evaluating one cannot fail.
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the type of a value in an expression.
+type Kind int
+
+const (
+	Number Kind = iota
+	Bool
+	String
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case Bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// Vars are the values of the variables an expression is evaluated with:
+// int64 or float64 numbers, bools and strings. A missing variable is the
+// zero value of its kind.
+type Vars map[string]interface{}
+
+// Expr is a parsed, type-checked condition.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string { return e.src }
+
+// Eval reports whether the condition holds for vars.
+func (e *Expr) Eval(vars Vars) bool {
+	return e.root.eval(vars).(bool)
+}
+
+// Parse parses src as a condition over the variables in kinds, which map
+// each name to its type.
+func Parse(src string, kinds map[string]Kind) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, kinds: kinds}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	if root.kind() != Bool {
+		return nil, fmt.Errorf("condition is a %s, not a boolean", root.kind())
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// --- Lexer ---
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], src[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			toks = append(toks, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == '_') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] >= '0' && src[j] <= '9' || unicode.IsLetter(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{">=", "<=", "==", "!=", "&&", "||", ">", "<", "!"} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "end of condition", len(src)}), nil
+}
+
+// --- Parser ---
+//
+//	or      = and { ("or" | "||") and }
+//	and     = not { ("and" | "&&") not }
+//	not     = ("not" | "!") not | compare
+//	compare = operand [ (">" | ">=" | "<" | "<=" | "==" | "!=") operand ]
+//	operand = number | string | "true" | "false" | variable | "(" or ")"
+
+type parser struct {
+	toks  []token
+	pos   int
+	kinds map[string]Kind
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords.
+func (p *parser) accept(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return false
+	}
+	for _, w := range words {
+		if t.text == w {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or", "||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical("or", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and", "&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical("and", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (node, error) {
+	if p.accept("not", "!") {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != Bool {
+			return nil, fmt.Errorf("not applied to a %s", operand.kind())
+		}
+		return notNode{operand}, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp || !isComparison(t.text) {
+		return left, nil
+	}
+	p.next()
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("%s compares a %s with a %s", t.text, left.kind(), right.kind())
+	}
+	if left.kind() != Number && t.text != "==" && t.text != "!=" {
+		return nil, fmt.Errorf("%s compares %ss; only numbers are ordered", t.text, left.kind())
+	}
+	return compareNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) operand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(strings.ReplaceAll(t.text, "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literal{v}, nil
+	case tokString:
+		return literal{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		}
+		k, ok := p.kinds[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q at offset %d", t.text, t.pos)
+		}
+		return variable{name: t.text, k: k}, nil
+	case tokLParen:
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing ) for ( at offset %d", t.pos)
+		}
+		return inner, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+func logical(op string, left, right node) (node, error) {
+	if left.kind() != Bool || right.kind() != Bool {
+		return nil, fmt.Errorf("%s combines a %s with a %s; both must be booleans", op, left.kind(), right.kind())
+	}
+	return logicalNode{and: op == "and", left: left, right: right}, nil
+}
+
+// --- Evaluation ---
+
+type node interface {
+	kind() Kind
+	// eval returns a float64, a bool or a string, as given by kind.
+	eval(vars Vars) interface{}
+}
+
+type literal struct{ v interface{} }
+
+func (l literal) kind() Kind {
+	switch l.v.(type) {
+	case float64:
+		return Number
+	case bool:
+		return Bool
+	default:
+		return String
+	}
+}
+
+func (l literal) eval(Vars) interface{} { return l.v }
+
+type variable struct {
+	name string
+	k    Kind
+}
+
+func (v variable) kind() Kind { return v.k }
+
+func (v variable) eval(vars Vars) interface{} {
+	switch x := vars[v.name].(type) {
+	case int64:
+		return float64(x)
+	case int:
+		return float64(x)
+	case float64, bool, string:
+		return x
+	}
+	return zero(v.k)
+}
+
+func zero(k Kind) interface{} {
+	switch k {
+	case Number:
+		return float64(0)
+	case Bool:
+		return false
+	default:
+		return ""
+	}
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) kind() Kind { return Bool }
+
+func (n notNode) eval(vars Vars) interface{} { return !n.operand.eval(vars).(bool) }
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n logicalNode) kind() Kind { return Bool }
+
+func (n logicalNode) eval(vars Vars) interface{} {
+	l := n.left.eval(vars).(bool)
+	if n.and {
+		return l && n.right.eval(vars).(bool)
+	}
+	return l || n.right.eval(vars).(bool)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) kind() Kind { return Bool }
+
+func (n compareNode) eval(vars Vars) interface{} {
+	l, r := n.left.eval(vars), n.right.eval(vars)
+	switch n.op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	}
+	a, b := l.(float64), r.(float64)
+	switch n.op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	default:
+		return a <= b
+	}
+}
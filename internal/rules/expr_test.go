@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEval(t *testing.T) {
+	vars := Vars{
+		"views_gained_24h": int64(150000),
+		"views":            int64(2_000_000),
+		"is_short":         true,
+		"new_upload":       false,
+		"channel_id":       "UC1",
+		"age_hours":        3.5,
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"views_gained_24h > 100000", true},
+		{"views_gained_24h > 100_000 and not is_short", false},
+		{"new_upload and is_short", false},
+		{"new_upload || is_short", true},
+		{"!new_upload && views >= 2000000", true},
+		{"(new_upload or is_short) and age_hours < 4", true},
+		{"channel_id == 'UC1'", true},
+		{`channel_id != "UC1"`, false},
+		{"likes == 0", true}, // missing variables are zero
+		{"is_short == true", true},
+		{"views > 1 or views_gained_24h < 0 and false", true}, // and binds tighter
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr, VideoKinds)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := e.Eval(vars); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"views_gained > 1", `unknown variable "views_gained"`},
+		{"views", "not a boolean"},
+		{"views > 'a'", "compares a number with a string"},
+		{"title > 'a'", "only numbers are ordered"},
+		{"views > 1 and likes", "both must be booleans"},
+		{"not views", "not applied to a number"},
+		{"(is_short", "missing )"},
+		{"is_short is_short", "unexpected"},
+		{"title == 'abc", "unterminated string"},
+		{"views > 1..2", "invalid number"},
+		{"views > 1 # comment", "unexpected"},
+		{"", "unexpected"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr, VideoKinds)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package rules
+
+// VideoKinds are the variables of the conditions of notification rules,
+// evaluated for each video snapshotted by a run.
+var VideoKinds = map[string]Kind{
+	"channel_id":   String,
+	"channel_name": String,
+	"video_id":     String,
+	"title":        String,
+	"is_short":     Bool,
+	// new_upload is true for a video first snapshotted in the run and
+	// published in the last week.
+	"new_upload":   Bool,
+	"views":        Number,
+	"likes":        Number,
+	"comments":     Number,
+	"duration_sec": Number,
+	// age_hours is the time since the video was published.
+	"age_hours": Number,
+	// views_gained_24h counts from the latest snapshot at least 24 hours
+	// older: from 0 for a video published in the last day, and from the
+	// first snapshot for one tracked for less than a day.
+	"views_gained_24h": Number,
+	// forecast_views_7d and forecast_views_30d are the views projected 7
+	// and 30 days ahead from the growth since publication and over the last
+	// day (see analytics.ForecastViews).
+	"forecast_views_7d":  Number,
+	"forecast_views_30d": Number,
+}
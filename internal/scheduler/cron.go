@@ -1,11 +1,241 @@
+// Package scheduler evaluates the cron schedules of fetch runs and
+// channels.
 package scheduler
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// GetCronExpression generates a cron expression for the scheduler.
+// GetCronExpression generates a cron expression for the scheduler that runs
+// daily at the wall-clock time of t, in t's location. Use CronExpressionIn
+// for a schedule evaluated in another time zone.
 func GetCronExpression(t time.Time) string {
 	return fmt.Sprintf("%d %d * * *", t.Minute(), t.Hour())
 }
+
+// Schedule is a parsed 5-field cron expression: minute, hour, day of month,
+// month and day of week. Each field is a bit set of the values it matches.
+type Schedule struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// domAny and dowAny record a "*" day field. As in cron, when both day
+	// fields are restricted a day matches if either does.
+	domAny, dowAny bool
+}
+
+// field describes the range of a cron field.
+type field struct {
+	name     string
+	min, max int
+	// names are the values' names, from min; "JAN" is month 1.
+	names []string
+}
+
+var (
+	monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	dayNames   = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+var fields = [5]field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	{"day of week", 0, 7, dayNames}, // 0 and 7 are both Sunday
+}
+
+// macros are the predefined schedules accepted in place of an expression.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a 5-field cron expression such as "0 */6 * * *". Each field
+// is "*" or a comma-separated list of values, ranges ("1-5") and steps
+// ("*/15", "10-50/20"). Months and days of the week may also be given by
+// their English abbreviations ("JAN", "MON-FRI", case-insensitive), and
+// the whole expression may be one of the macros @yearly (@annually),
+// @monthly, @weekly, @daily (@midnight) and @hourly.
+func Parse(expr string) (*Schedule, error) {
+	if m, ok := macros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = m
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q: want 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(parts))
+	}
+	var sets [5]uint64
+	for i, part := range parts {
+		parts[i] = replaceNames(part, fields[i])
+		set, err := parseField(parts[i], fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	s := &Schedule{
+		expr:   strings.Join(parts, " "),
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseField returns the bit set of the values a field matches.
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step in %q", f.name, item)
+			}
+			rng, step = item[:i], n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			var err error
+			if i := strings.IndexByte(rng, '-'); i >= 0 {
+				if lo, err = parseValue(rng[:i], f); err == nil {
+					hi, err = parseValue(rng[i+1:], f)
+				}
+			} else if lo, err = parseValue(rng, f); err == nil {
+				hi = lo
+				if step > 1 {
+					// "5/15" means from 5 to the end in steps of 15.
+					hi = f.max
+				}
+			}
+			if err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// replaceNames replaces the month or day names in a field with their
+// numbers, so that "MON-FRI" reads "1-5".
+func replaceNames(s string, f field) string {
+	if f.names == nil {
+		return s
+	}
+	upper := strings.ToUpper(s)
+	for i, name := range f.names {
+		upper = strings.ReplaceAll(upper, name, strconv.Itoa(f.min+i))
+	}
+	return upper
+}
+
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not a number between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from, with macros
+// expanded and names replaced by numbers, in the plain form every cron
+// implementation, Cloud Scheduler included, accepts.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// matchDay reports whether the schedule runs on the day of t.
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// searchLimit bounds the search for a matching time; an expression such as
+// "0 0 30 2 *" never matches.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the schedule runs, in t's location,
+// or the zero time if it never runs.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(searchLimit)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Prev returns the last time at or before t the schedule ran, in t's
+// location, or the zero time if it never ran.
+func (s *Schedule) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	end := t.Add(-searchLimit)
+	for t.After(end) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Due reports whether the schedule runs in the window (from, to].
+func (s *Schedule) Due(from, to time.Time) bool {
+	next := s.Next(from)
+	return !next.IsZero() && !next.After(to)
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestScheduleNextPrev(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, tokyo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		expr       string
+		from       string
+		next, prev string
+	}{
+		{"0 * * * *", "2026-10-17 09:30", "2026-10-17 10:00", "2026-10-17 09:00"},
+		{"0 */6 * * *", "2026-10-17 09:30", "2026-10-17 12:00", "2026-10-17 06:00"},
+		{"0 */6 * * *", "2026-10-17 06:00", "2026-10-17 12:00", "2026-10-17 06:00"},
+		{"30 7 * * 1-5", "2026-10-17 09:30", "2026-10-19 07:30", "2026-10-16 07:30"}, // Saturday
+		{"0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00", "2026-12-01 00:00"},
+		{"0 9 13 * 5", "2026-10-17 09:30", "2026-10-23 09:00", "2026-10-16 09:00"}, // the 13th or Fridays
+		{"15,45 8-9 * * 7", "2026-10-17 09:30", "2026-10-18 08:15", "2026-10-11 09:45"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Next(at(tt.from)); !got.Equal(at(tt.next)) {
+			t.Errorf("%q Next(%s) = %s, want %s", tt.expr, tt.from, got, tt.next)
+		}
+		if got := s.Prev(at(tt.from)); !got.Equal(at(tt.prev)) {
+			t.Errorf("%q Prev(%s) = %s, want %s", tt.expr, tt.from, got, tt.prev)
+		}
+	}
+
+	never, _ := Parse("0 0 30 2 *")
+	if got := never.Next(at("2026-10-17 09:30")); !got.IsZero() {
+		t.Errorf("Next() of a schedule that never runs = %s, want zero", got)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	s, _ := Parse("0 */6 * * *")
+	base := time.Date(2026, 10, 17, 5, 0, 0, 0, time.UTC)
+	if !s.Due(base, base.Add(time.Hour)) {
+		t.Error("06:00 should be due in (05:00, 06:00]")
+	}
+	if s.Due(base.Add(time.Hour), base.Add(2*time.Hour)) {
+		t.Error("nothing should be due in (06:00, 07:00]")
+	}
+}
+
+func TestParse_NamesAndMacros(t *testing.T) {
+	tests := map[string]string{
+		"30 7 * * mon-FRI":     "30 7 * * 1-5",
+		"0 0 1 jan,Jul *":      "0 0 1 1,7 *",
+		"@hourly":              "0 * * * *",
+		"@Daily":               "0 0 * * *",
+		"@weekly":              "0 0 * * 0",
+		"0 12 * JUN-AUG/2 SAT": "0 12 * 6-8/2 6",
+	}
+	for expr, want := range tests {
+		s, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", expr, err)
+		}
+		if got := s.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", expr, got, want)
+		}
+	}
+	if _, err := Parse("@every5m"); err == nil {
+		t.Error("Parse(@every5m) succeeded, want an error")
+	}
+}
+
+func TestScheduleDescribe(t *testing.T) {
+	tests := map[string]string{
+		"* * * * *":                "every minute",
+		"*/15 * * * *":             "every 15 minutes",
+		"0 * * * *":                "every hour at minute 0",
+		"0 */6 * * *":              "every 6 hours at minute 0",
+		"0 9 * * *":                "at 09:00 every day",
+		"0 9,18 * * *":             "at 09:00 and 18:00 every day",
+		"30 7 * * 1-5":             "at 07:30 on Monday through Friday",
+		"0 0 1 * *":                "at 00:00 on day 1 of the month",
+		"0 9 13 * 5":               "at 09:00 on day 13 of the month or on Friday",
+		"15,45 8-9 * * 7":          "at minutes 15 and 45 during hours 8 and 9 on Sunday",
+		"0 0 1 1,7 *":              "at 00:00 on day 1 of the month in January and July",
+		"*/10 9-17 * * *":          "every 10 minutes during hours 9 through 17",
+		"5,20 * * * *":             "at minutes 5 and 20 of every hour",
+		"0 8 * * sat,sun":          "at 08:00 on Sunday and Saturday",
+		"0 0 1,15 * *":             "at 00:00 on days 1 and 15 of the month",
+		"0 0,1,2,3,12,18,21 * * *": "at minute 0 during hours 0 through 3, 12, 18 and 21",
+	}
+	for expr, want := range tests {
+		s, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", expr, err)
+		}
+		if got := s.Describe(); got != want {
+			t.Errorf("%q Describe() = %q, want %q", expr, got, want)
+		}
+	}
+}
+
+func TestScheduleInZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := Parse("0 9 * * *")
+	now := time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC) // 10:00 in Tokyo
+	if got, want := s.NextIn(now, tokyo), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("NextIn = %s, want %s", got, want)
+	}
+	if got, want := s.PrevIn(now, tokyo), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("PrevIn = %s, want %s", got, want)
+	}
+	if got := CronExpressionIn(time.Date(2026, 10, 17, 0, 30, 0, 0, time.UTC), tokyo); got != "30 9 * * *" {
+		t.Errorf("CronExpressionIn = %q, want %q", got, "30 9 * * *")
+	}
+	if got := s.NextN(now, 3); len(got) != 3 || got[2].Sub(got[0]) != 48*time.Hour {
+		t.Errorf("NextN = %v, want three daily runs", got)
+	}
+	if err := Validate("0 9 * * *", "Asia/Tokyo"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate("0 9 * * *", "Mars/Olympus"); err == nil {
+		t.Error("Validate() accepted an unknown time zone")
+	}
+}
@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	monthLongNames = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	dayLongNames   = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// Describe returns an English description of the schedule, e.g. "every 6
+// hours at minute 0" for "0 */6 * * *" or "at 07:30 on Monday through
+// Friday" for "30 7 * * 1-5".
+func (s *Schedule) Describe() string {
+	desc, atTimes := s.describeTime()
+	if days := s.describeDays(); days != "" {
+		desc += " " + days
+	} else if atTimes {
+		desc += " every day"
+	}
+	if months := values(s.month, 1, 12); len(months) < 12 {
+		desc += " in " + list(months, func(v int) string { return monthLongNames[v-1] })
+	}
+	return desc
+}
+
+// describeTime describes the minute and hour fields, and reports whether it
+// named the times of the day the schedule runs at.
+func (s *Schedule) describeTime() (string, bool) {
+	minutes, hours := values(s.minute, 0, 59), values(s.hour, 0, 23)
+	allHours := len(hours) == 24
+
+	if len(minutes) == 1 {
+		m := minutes[0]
+		switch d := step(hours, 0, 23); {
+		case allHours:
+			return fmt.Sprintf("every hour at minute %d", m), false
+		case d > 1:
+			return fmt.Sprintf("every %d hours at minute %d", d, m), false
+		case len(hours) <= 6:
+			times := make([]int, len(hours))
+			for i, h := range hours {
+				times[i] = h*60 + m
+			}
+			return "at " + list(times, func(v int) string { return fmt.Sprintf("%02d:%02d", v/60, v%60) }), true
+		}
+	}
+
+	var desc string
+	switch d := step(minutes, 0, 59); {
+	case len(minutes) == 60:
+		desc = "every minute"
+	case d > 1:
+		desc = fmt.Sprintf("every %d minutes", d)
+	case len(minutes) == 1:
+		desc = fmt.Sprintf("at minute %d", minutes[0])
+	default:
+		desc = "at minutes " + list(minutes, strconv.Itoa)
+	}
+	if !allHours {
+		desc += " during hours " + list(hours, strconv.Itoa)
+	} else if len(minutes) < 60 && step(minutes, 0, 59) <= 1 {
+		desc += " of every hour"
+	}
+	return desc, false
+}
+
+// describeDays describes the day fields; it is empty for a schedule that
+// runs every day.
+func (s *Schedule) describeDays() string {
+	var dom, dow string
+	if !s.domAny {
+		days := values(s.dom, 1, 31)
+		noun := "day"
+		if len(days) > 1 {
+			noun = "days"
+		}
+		dom = fmt.Sprintf("on %s %s of the month", noun, list(days, strconv.Itoa))
+	}
+	if !s.dowAny {
+		dow = "on " + list(values(s.dow, 0, 6), func(v int) string { return dayLongNames[v] })
+	}
+	switch {
+	case dom != "" && dow != "":
+		return dom + " or " + dow
+	case dom != "":
+		return dom
+	case dow != "":
+		return dow
+	}
+	return ""
+}
+
+// values returns the values of a bit set between min and max.
+func values(set uint64, min, max int) []int {
+	var vs []int
+	for v := min; v <= max; v++ {
+		if set&(1<<v) != 0 {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// step returns d when vs is every d-th value from min to max ("*/d"), and 0
+// otherwise.
+func step(vs []int, min, max int) int {
+	if len(vs) < 2 || vs[0] != min {
+		return 0
+	}
+	d := vs[1] - vs[0]
+	for i := 2; i < len(vs); i++ {
+		if vs[i]-vs[i-1] != d {
+			return 0
+		}
+	}
+	if vs[len(vs)-1]+d <= max {
+		return 0
+	}
+	return d
+}
+
+// list names the values in English, collapsing runs of three or more
+// consecutive values into "first through last".
+func list(vs []int, name func(int) string) string {
+	var items []string
+	for i := 0; i < len(vs); {
+		j := i
+		for j+1 < len(vs) && vs[j+1] == vs[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			items = append(items, name(vs[i])+" through "+name(vs[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				items = append(items, name(vs[k]))
+			}
+		}
+		i = j + 1
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
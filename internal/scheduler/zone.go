@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Validate checks a cron expression and the IANA time zone it is evaluated
+// in, such as "Asia/Tokyo"; an empty zone is UTC.
+func Validate(expr, tz string) error {
+	if _, err := Parse(expr); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", tz, err)
+	}
+	return nil
+}
+
+// CronExpressionIn returns a cron expression, evaluated in loc, that runs
+// daily at the same instant of the day as t, e.g. 00:30 UTC becomes
+// "30 9 * * *" in Asia/Tokyo.
+func CronExpressionIn(t time.Time, loc *time.Location) string {
+	return GetCronExpression(t.In(loc))
+}
+
+// NextIn returns the first time after t the schedule runs when it is
+// evaluated in loc, e.g. "0 9 * * *" in Asia/Tokyo runs at 00:00 UTC. The
+// result is in loc.
+func (s *Schedule) NextIn(t time.Time, loc *time.Location) time.Time {
+	return s.Next(t.In(loc))
+}
+
+// PrevIn returns the last time at or before t the schedule ran when it is
+// evaluated in loc. The result is in loc.
+func (s *Schedule) PrevIn(t time.Time, loc *time.Location) time.Time {
+	return s.Prev(t.In(loc))
+}
+
+// NextN returns up to n times after t the schedule runs, in t's location;
+// fewer if it stops running.
+func (s *Schedule) NextN(t time.Time, n int) []time.Time {
+	var times []time.Time
+	for len(times) < n {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
@@ -0,0 +1,60 @@
+// Package sentiment scores the sentiment of short texts such as video
+// comments.
+package sentiment
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	language "google.golang.org/api/language/v1"
+	"google.golang.org/api/option"
+)
+
+// ErrUnsupported is returned for texts an analyzer cannot score, such as
+// ones in an unsupported language or without any words.
+var ErrUnsupported = stderrors.New("text not supported by the sentiment analyzer")
+
+// Analyzer scores the sentiment of a text from -1 (negative) to 1
+// (positive).
+type Analyzer interface {
+	Sentiment(ctx context.Context, text string) (float64, error)
+}
+
+// NaturalLanguage scores texts with the Cloud Natural Language API. Each
+// text is one request, billed per started 1,000 characters.
+type NaturalLanguage struct {
+	documents *language.DocumentsService
+}
+
+// NewNaturalLanguage creates an analyzer using the default credentials. The
+// Cloud Natural Language API must be enabled in the project.
+func NewNaturalLanguage(ctx context.Context, opts ...option.ClientOption) (*NaturalLanguage, error) {
+	svc, err := language.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("language.NewService: %w", err)
+	}
+	return &NaturalLanguage{documents: svc.Documents}, nil
+}
+
+// Sentiment returns the document sentiment score of text. The API rejects
+// texts in languages it does not support with 400 Bad Request; those are
+// reported as ErrUnsupported.
+func (n *NaturalLanguage) Sentiment(ctx context.Context, text string) (float64, error) {
+	resp, err := n.documents.AnalyzeSentiment(&language.AnalyzeSentimentRequest{
+		Document: &language.Document{Type: "PLAIN_TEXT", Content: text},
+	}).Context(ctx).Do()
+	if err != nil {
+		var gerr *googleapi.Error
+		if stderrors.As(err, &gerr) && gerr.Code == http.StatusBadRequest {
+			return 0, fmt.Errorf("%w: %s", ErrUnsupported, gerr.Message)
+		}
+		return 0, fmt.Errorf("failed to analyze sentiment: %w", err)
+	}
+	if resp.DocumentSentiment == nil {
+		return 0, ErrUnsupported
+	}
+	return resp.DocumentSentiment.Score, nil
+}
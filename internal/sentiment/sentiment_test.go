@@ -0,0 +1,49 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestNaturalLanguage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Document struct{ Content string } `json:"document"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		switch req.Document.Content {
+		case "最高の動画でした":
+			w.Write([]byte(`{"documentSentiment": {"score": 0.9, "magnitude": 0.9}, "language": "ja"}`))
+		case "xyzzy":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 400, "message": "The language xx is not supported for document_sentiment analysis."}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "message": "API not enabled"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	n, err := NewNaturalLanguage(ctx, option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score, err := n.Sentiment(ctx, "最高の動画でした"); err != nil || score != 0.9 {
+		t.Errorf("Sentiment() = %v, %v, want 0.9", score, err)
+	}
+	if _, err := n.Sentiment(ctx, "xyzzy"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("unsupported language error = %v, want ErrUnsupported", err)
+	}
+	if _, err := n.Sentiment(ctx, "other"); err == nil || errors.Is(err, ErrUnsupported) {
+		t.Errorf("forbidden error = %v", err)
+	}
+}
@@ -2,14 +2,16 @@ package storage
 
 import (
 	"context"
+	_ "embed"
 	"fmt"
-	"net/http"
 	"os"
 	"time"
 
 	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/civil"
-	"google.golang.org/api/googleapi"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -18,32 +20,198 @@ type BigQueryWriter struct {
 	client    *bigquery.Client
 	datasetID string
 	tableID   string
+	metrics   *metrics.Metrics
+	// schemaJSON overrides the embedded video trends schema; see
+	// SetSchemaFile.
+	schemaJSON []byte
+	retention  RetentionPolicy
+	spill      SpillBuffer
 }
 
 // VideoStatsRecord represents a record to be inserted into BigQuery.
+// The json tags mirror the column names so records can also be written as
+// newline-delimited JSON for load jobs.
 type VideoStatsRecord struct {
-	Dt             civil.Date `bigquery:"dt"`
-	ChannelID      string     `bigquery:"channel_id"`
-	VideoID        string     `bigquery:"video_id"`
-	Title          string     `bigquery:"title"`
-	ChannelName    string     `bigquery:"channel_name"`
-	Tags           []string   `bigquery:"tags"`
-	IsShort        bool       `bigquery:"is_short"`
-	Views          int64      `bigquery:"views"`
-	Likes          int64      `bigquery:"likes"`
-	Comments       int64      `bigquery:"comments"`
-	PublishedAt    time.Time  `bigquery:"published_at"`
-	CreatedAt      time.Time  `bigquery:"created_at"`
-	DurationSec    int64      `bigquery:"duration_sec"`
-	ContentDetails string     `bigquery:"content_details"`
-	TopicDetails   []string   `bigquery:"topic_details"`
+	Dt             civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID      string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID        string     `bigquery:"video_id" json:"video_id"`
+	Title          string     `bigquery:"title" json:"title"`
+	ChannelName    string     `bigquery:"channel_name" json:"channel_name"`
+	Tags           []string   `bigquery:"tags" json:"tags"`
+	IsShort        bool       `bigquery:"is_short" json:"is_short"`
+	Views          int64      `bigquery:"views" json:"views"`
+	Likes          int64      `bigquery:"likes" json:"likes"`
+	Comments       int64      `bigquery:"comments" json:"comments"`
+	PublishedAt    time.Time  `bigquery:"published_at" json:"published_at"`
+	CreatedAt      time.Time  `bigquery:"created_at" json:"created_at"`
+	DurationSec    int64      `bigquery:"duration_sec" json:"duration_sec"`
+	ContentDetails string     `bigquery:"content_details" json:"content_details"`
+	// RegionAllowed and RegionBlocked are contentDetails.regionRestriction:
+	// the only regions where the video is viewable, or the regions where it
+	// is blocked.
+	RegionAllowed []string `bigquery:"region_allowed" json:"region_allowed"`
+	RegionBlocked []string `bigquery:"region_blocked" json:"region_blocked"`
+	// ContentRating is contentDetails.contentRating, one entry per rating
+	// system the video is rated under.
+	ContentRating []ContentRating `bigquery:"content_rating" json:"content_rating"`
+	TopicDetails  []string        `bigquery:"topic_details" json:"topic_details"`
+	Status        string          `bigquery:"status" json:"status"`
+	Description   string          `bigquery:"description" json:"description"`
+	ThumbnailURL  string          `bigquery:"thumbnail_url" json:"thumbnail_url"`
+	// Chapters are the chapters listed in the description.
+	Chapters []Chapter `bigquery:"chapters" json:"chapters"`
+	// PrivacyStatus is the API's status.privacyStatus as fetched. Unlike
+	// Status it is never "unavailable": tombstones leave it NULL.
+	PrivacyStatus string `bigquery:"privacy_status" json:"privacy_status"`
+	MadeForKids   bool   `bigquery:"made_for_kids" json:"made_for_kids"`
+	License       string `bigquery:"license" json:"license"`
+	Embeddable    bool   `bigquery:"embeddable" json:"embeddable"`
+	// PaidPromotion is set when the video declares paid product placement.
+	PaidPromotion bool `bigquery:"paid_promotion" json:"paid_promotion"`
+	// CategoryID is the YouTube video category ID, and CategoryName its
+	// title as resolved at write time (empty if unresolved).
+	CategoryID      string `bigquery:"category_id" json:"category_id"`
+	CategoryName    string `bigquery:"category_name" json:"category_name"`
+	DefaultLanguage string `bigquery:"default_language" json:"default_language"`
+	// TitleLanguage and TitleKeywords are derived from the title when the
+	// record is built (see package enrich).
+	TitleLanguage string   `bigquery:"title_language" json:"title_language"`
+	TitleKeywords []string `bigquery:"title_keywords" json:"title_keywords"`
+	// PlaylistID is set on rows of a tracked playlist, whose ChannelID is
+	// the channel that uploaded the video.
+	PlaylistID string `bigquery:"playlist_id" json:"playlist_id"`
+	// ShortsConfidence is the classifier score in [0, 1] behind IsShort.
+	ShortsConfidence float64 `bigquery:"shorts_confidence" json:"shorts_confidence"`
+	// SnapshotTs is when the fetch run that produced this row started; it
+	// gives sub-day precision on top of the dt partition.
+	SnapshotTs time.Time `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	// Derived fields, set by SetDerived on the copy of the record that is
+	// written; the writers leave the caller's record as it is. They are
+	// NULL where undefined: without views, or without a publication time.
+	LikeRate     bigquery.NullFloat64 `bigquery:"like_rate" json:"like_rate"`
+	CommentRate  bigquery.NullFloat64 `bigquery:"comment_rate" json:"comment_rate"`
+	ViewsPerHour bigquery.NullFloat64 `bigquery:"views_per_hour" json:"views_per_hour"`
+}
+
+// ContentRating is the rating of a video under one rating system, e.g.
+// {"yt", "ytAgeRestricted"}.
+type ContentRating struct {
+	System string `bigquery:"system" json:"system"`
+	Rating string `bigquery:"rating" json:"rating"`
+}
+
+// Chapter is a chapter of a video: its start offset and title.
+type Chapter struct {
+	StartSec int64  `bigquery:"start_sec" json:"start_sec"`
+	Title    string `bigquery:"title" json:"title"`
+}
+
+// minViewsPerHourAge keeps views_per_hour of just-published videos from
+// exploding: ages below one hour count as one hour.
+const minViewsPerHourAge = time.Hour
+
+// SetDerived computes the derived fields from the counters: likes and
+// comments per view, and views per hour since publication as of the
+// snapshot (or creation) time.
+func (r *VideoStatsRecord) SetDerived() {
+	r.LikeRate = ratio(float64(r.Likes), float64(r.Views))
+	r.CommentRate = ratio(float64(r.Comments), float64(r.Views))
+
+	r.ViewsPerHour = bigquery.NullFloat64{}
+	at := r.SnapshotTs
+	if at.IsZero() {
+		at = r.CreatedAt
+	}
+	if r.PublishedAt.Unix() > 0 && !at.IsZero() {
+		age := max(at.Sub(r.PublishedAt), minViewsPerHourAge)
+		r.ViewsPerHour = ratio(float64(r.Views), age.Hours())
+	}
+}
+
+// withDerived returns copies of records with the derived fields set, so the
+// writers do not change records the caller may still use, e.g. to hand them
+// to another writer.
+func withDerived(records []*VideoStatsRecord) []*VideoStatsRecord {
+	derived := make([]*VideoStatsRecord, len(records))
+	for i, r := range records {
+		d := *r
+		d.SetDerived()
+		derived[i] = &d
+	}
+	return derived
+}
+
+// ratio returns num/den, or NULL when den is not positive.
+func ratio(num, den float64) bigquery.NullFloat64 {
+	if den <= 0 {
+		return bigquery.NullFloat64{}
+	}
+	return bigquery.NullFloat64{Float64: num / den, Valid: true}
+}
+
+// InsertID returns the streaming insert ID used to deduplicate the record. It
+// is derived from (snapshot_ts, video_id, playlist_id) so that several
+// snapshots per day are kept, as is the row of a video tracked both as a
+// channel upload and in a playlist, while retries of the same snapshot are
+// dropped. Records without a snapshot time get an empty ID, letting BigQuery
+// generate one.
+func (r *VideoStatsRecord) InsertID() string {
+	if r.SnapshotTs.IsZero() {
+		return ""
+	}
+	id := r.SnapshotTs.UTC().Format(time.RFC3339Nano) + "/" + r.VideoID
+	if r.PlaylistID != "" {
+		id += "/" + r.PlaylistID
+	}
+	return id
+}
+
+// Video availability statuses stored in the status column. Public, unlisted
+// and private mirror the API's privacyStatus; unavailable marks a previously
+// tracked video that videos.list no longer returns (deleted or made private).
+const (
+	VideoStatusPublic      = "public"
+	VideoStatusUnlisted    = "unlisted"
+	VideoStatusPrivate     = "private"
+	VideoStatusUnavailable = "unavailable"
+)
+
+// VideoTombstoneRecord marks a previously tracked video as no longer
+// available. Metric columns are left NULL so aggregations are not skewed.
+type VideoTombstoneRecord struct {
+	Dt        civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID   string     `bigquery:"video_id" json:"video_id"`
+	CreatedAt time.Time  `bigquery:"created_at" json:"created_at"`
+	Status    string     `bigquery:"status" json:"status"`
 }
 
 // EnsureTableExists checks if the dataset and table exist, and creates them if they don't.
+// An existing table missing columns of the current schema is migrated; see Migrate.
+// After the first success the process skips the check (two metadata calls)
+// for the same table, schema and retention policy; see SetForceEnsure.
 func (w *BigQueryWriter) EnsureTableExists(ctx context.Context) error {
+	if _, ok := ensuredTables.Load(w.ensuredTableKey()); ok && !forceEnsure.Load() {
+		return nil
+	}
+	_, err := w.Migrate(ctx)
+	return err
+}
+
+// CheckDataset verifies that the writer's dataset exists and is readable
+// with the current credentials.
+func (w *BigQueryWriter) CheckDataset(ctx context.Context) error {
+	if _, err := w.client.Dataset(w.datasetID).Metadata(ctx); err != nil {
+		return errors.Storage(fmt.Sprintf("cannot access dataset %s", w.datasetID), err)
+	}
+	return nil
+}
+
+// ensureDataset creates the writer's dataset if it does not exist.
+func (w *BigQueryWriter) ensureDataset(ctx context.Context) error {
 	_, err := w.client.Dataset(w.datasetID).Metadata(ctx)
 	if err != nil {
-		if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusNotFound {
+		if errors.Classify(err) == errors.ErrNotFound {
 			// Dataset doesn't exist, create it.
 			if err := w.client.Dataset(w.datasetID).Create(ctx, &bigquery.DatasetMetadata{}); err != nil {
 				return fmt.Errorf("failed to create dataset: %w", err)
@@ -52,56 +220,91 @@ func (w *BigQueryWriter) EnsureTableExists(ctx context.Context) error {
 			return fmt.Errorf("failed to get dataset metadata: %w", err)
 		}
 	}
+	return nil
+}
 
-	table := w.client.Dataset(w.datasetID).Table(w.tableID)
+// ensureTable creates a day-partitioned table in the writer's dataset if it
+// does not exist. The dataset itself must already exist.
+func (w *BigQueryWriter) ensureTable(ctx context.Context, tableID string, schemaJSON []byte, partitionField string, clusterFields []string) error {
+	table := w.client.Dataset(w.datasetID).Table(tableID)
 	if _, err := table.Metadata(ctx); err != nil {
-		if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusNotFound {
+		if errors.Classify(err) == errors.ErrNotFound {
 			// Table doesn't exist, create it.
-			schema, err := bigquery.SchemaFromJSON(getSchemaJSON())
+			schema, err := bigquery.SchemaFromJSON(schemaJSON)
 			if err != nil {
-				return fmt.Errorf("failed to load schema: %w", err)
+				return fmt.Errorf("failed to load schema for %s: %w", tableID, err)
 			}
 			tableMetadata := &bigquery.TableMetadata{
 				Schema: schema,
-				TimePartitioning: &bigquery.TimePartitioning{
-					Field:      "dt",
+			}
+			if partitionField != "" {
+				tableMetadata.TimePartitioning = &bigquery.TimePartitioning{
+					Field:      partitionField,
 					Type:       "DAY",
 					Expiration: 0, // No expiration
-				},
-				Clustering: &bigquery.Clustering{
-					Fields: []string{"channel_id", "video_id"},
-				},
+				}
+			}
+			if len(clusterFields) > 0 {
+				tableMetadata.Clustering = &bigquery.Clustering{
+					Fields: clusterFields,
+				}
 			}
 			if err := table.Create(ctx, tableMetadata); err != nil {
-				return fmt.Errorf("failed to create table: %w", err)
+				return fmt.Errorf("failed to create table %s: %w", tableID, err)
 			}
 		} else {
-			return fmt.Errorf("failed to get table metadata: %w", err)
+			return fmt.Errorf("failed to get table metadata for %s: %w", tableID, err)
 		}
 	}
 	return nil
 }
 
+// videoTrendsSchemaJSON is the schema of the video trends table. Every
+// column has a field in VideoStatsRecord and a migration in
+// videoTrendsMigrations; tests check both.
+//
+//go:embed schema/video_trends.json
+var videoTrendsSchemaJSON []byte
+
 func getSchemaJSON() []byte {
-	// In a real application, you would load this from a file.
-	// For simplicity here, it's embedded.
-	return []byte(`[
-	  {"name": "dt",               "type": "DATE",      "mode": "REQUIRED"},
-	  {"name": "channel_id",       "type": "STRING",    "mode": "REQUIRED"},
-	  {"name": "video_id",         "type": "STRING",    "mode": "REQUIRED"},
-	  {"name": "title",            "type": "STRING",    "mode": "NULLABLE"},
-	  {"name": "channel_name",     "type": "STRING",    "mode": "NULLABLE"},
-	  {"name": "tags",             "type": "STRING",    "mode": "REPEATED"},
-	  {"name": "is_short",         "type": "BOOLEAN",   "mode": "NULLABLE"},
-	  {"name": "views",            "type": "INTEGER",   "mode": "NULLABLE"},
-	  {"name": "likes",            "type": "INTEGER",   "mode": "NULLABLE"},
-	  {"name": "comments",         "type": "INTEGER",   "mode": "NULLABLE"},
-	  {"name": "published_at",     "type": "TIMESTAMP", "mode": "NULLABLE"},
-	  {"name": "created_at",       "type": "TIMESTAMP", "mode": "REQUIRED"},
-	  {"name": "duration_sec",     "type": "INTEGER",   "mode": "NULLABLE"},
-	  {"name": "content_details",  "type": "STRING",    "mode": "NULLABLE"},
-	  {"name": "topic_details",    "type": "STRING",    "mode": "REPEATED"}
-	]`)
+	return videoTrendsSchemaJSON
+}
+
+// SetSchemaFile makes the writer create and migrate the video trends table
+// with the schema in path, a BigQuery JSON schema file, instead of the
+// embedded one. Custom deployments use it to add columns of their own; the
+// file must still contain every column of the embedded schema with the same
+// type and mode, since the writer inserts them.
+func (w *BigQueryWriter) SetSchemaFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Config("failed to read BigQuery schema file", err)
+	}
+	custom, err := bigquery.SchemaFromJSON(data)
+	if err != nil {
+		return errors.Config(fmt.Sprintf("invalid BigQuery schema file %s", path), err)
+	}
+	embedded, err := bigquery.SchemaFromJSON(getSchemaJSON())
+	if err != nil {
+		return fmt.Errorf("failed to load embedded schema: %w", err)
+	}
+	missing, err := missingColumns(custom, embedded)
+	if err == nil && len(missing) > 0 {
+		err = fmt.Errorf("column %s is missing", missing[0].Name)
+	}
+	if err != nil {
+		return errors.Config(fmt.Sprintf("BigQuery schema file %s does not match the video trends schema", path), err)
+	}
+	w.schemaJSON = data
+	return nil
+}
+
+// tableSchemaJSON returns the schema of the video trends table.
+func (w *BigQueryWriter) tableSchemaJSON() []byte {
+	if w.schemaJSON != nil {
+		return w.schemaJSON
+	}
+	return getSchemaJSON()
 }
 
 // NewBigQueryWriter creates a new BigQuery writer.
@@ -111,6 +314,17 @@ func NewBigQueryWriter(ctx context.Context, projectID string) (*BigQueryWriter,
 
 // NewBigQueryWriterWithConfig creates a new BigQuery writer with custom dataset and table IDs.
 func NewBigQueryWriterWithConfig(ctx context.Context, projectID, datasetID, tableID string) (*BigQueryWriter, error) {
+	client, err := NewBigQueryClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return NewBigQueryWriterWithClient(client, datasetID, tableID), nil
+}
+
+// NewBigQueryClient creates a BigQuery client for projectID, connecting to
+// the emulator without authentication if BIGQUERY_EMULATOR_HOST is set. The
+// client is safe for concurrent use and can be shared by writers.
+func NewBigQueryClient(ctx context.Context, projectID string) (*bigquery.Client, error) {
 	var opts []option.ClientOption
 	if host := os.Getenv("BIGQUERY_EMULATOR_HOST"); host != "" {
 		// For connecting to the emulator's HTTP endpoint
@@ -123,11 +337,27 @@ func NewBigQueryWriterWithConfig(ctx context.Context, projectID, datasetID, tabl
 	if err != nil {
 		return nil, fmt.Errorf("bigquery.NewClient: %w", err)
 	}
+	return client, nil
+}
+
+// NewBigQueryWriterWithClient creates a writer for the dataset and table
+// using an existing client, which the writer does not own.
+func NewBigQueryWriterWithClient(client *bigquery.Client, datasetID, tableID string) *BigQueryWriter {
 	return &BigQueryWriter{
 		client:    client,
 		datasetID: datasetID,
 		tableID:   tableID,
-	}, nil
+	}
+}
+
+// DatasetID returns the dataset the writer writes to.
+func (w *BigQueryWriter) DatasetID() string {
+	return w.datasetID
+}
+
+// SetMetrics makes the writer record insert outcomes and failed rows.
+func (w *BigQueryWriter) SetMetrics(m *metrics.Metrics) {
+	w.metrics = m
 }
 
 // InsertVideoStats inserts video statistics into the BigQuery table.
@@ -136,10 +366,93 @@ func (w *BigQueryWriter) InsertVideoStats(ctx context.Context, records []*VideoS
 		return nil // No records to insert
 	}
 
-	inserter := w.client.Dataset(w.datasetID).Table(w.tableID).Inserter()
-	if err := inserter.Put(ctx, records); err != nil {
+	// Rows carry an insert ID so that retried inserts of the same snapshot
+	// are deduplicated on (snapshot_ts, video_id).
+	savers := make([]*bigquery.StructSaver, len(records))
+	for i, r := range withDerived(records) {
+		savers[i] = &bigquery.StructSaver{Struct: r, InsertID: r.InsertID()}
+	}
+
+	err := w.insertRows(ctx, w.tableID, savers)
+	if err := w.spillFailed(ctx, w.tableID, savers, err); err != nil {
 		return fmt.Errorf("failed to insert records into BigQuery: %w", err)
 	}
 
 	return nil
 }
+
+// InsertTombstones records videos that are no longer available.
+func (w *BigQueryWriter) InsertTombstones(ctx context.Context, records []*VideoTombstoneRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(w.tableID).Inserter()
+	err := putWithRetry(ctx, w.insertRetryConfig(), func(ctx context.Context) error {
+		return inserter.Put(ctx, records)
+	})
+	if err != nil {
+		if w.spill != nil {
+			spill := make([]interface{}, len(records))
+			for i, r := range records {
+				spill[i] = r
+			}
+			if w.spillRows(ctx, w.tableID, spill, err) {
+				return nil
+			}
+		}
+		return fmt.Errorf("failed to insert tombstones into BigQuery: %w", err)
+	}
+
+	return nil
+}
+
+// KnownVideoIDs returns the IDs of videos stored for a channel since the given
+// date whose most recent snapshot is not already a tombstone.
+func (w *BigQueryWriter) KnownVideoIDs(ctx context.Context, channelID string, since civil.Date) ([]string, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT video_id
+		FROM %s
+		WHERE channel_id = @channel_id AND dt >= @since AND `+channelRows+`
+		GROUP BY video_id
+		HAVING ARRAY_AGG(IFNULL(status, @public) ORDER BY created_at DESC LIMIT 1)[OFFSET(0)] != @unavailable`,
+		w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "channel_id", Value: channelID},
+		{Name: "since", Value: since},
+		{Name: "public", Value: VideoStatusPublic},
+		{Name: "unavailable", Value: VideoStatusUnavailable},
+	}
+
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query known videos: %w", err)
+	}
+
+	var ids []string
+	for {
+		var row struct {
+			VideoID string `bigquery:"video_id"`
+		}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known videos: %w", err)
+		}
+		ids = append(ids, row.VideoID)
+	}
+	return ids, nil
+}
+
+// channelRows is the condition that keeps only the rows of tracked channels.
+// Rows of tracked playlists repeat videos under the channel that uploaded
+// them, so per-channel aggregates and lookups of previously stored videos
+// leave them out. Rows written before playlist_id existed have it NULL.
+const channelRows = "IFNULL(playlist_id, '') = ''"
+
+// tableRef returns the fully-qualified, quoted table name for use in SQL.
+func (w *BigQueryWriter) tableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, w.tableID)
+}
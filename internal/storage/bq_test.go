@@ -1,10 +1,15 @@
 package storage
 
 import (
-	"context"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/civil"
 )
 
@@ -69,20 +74,185 @@ func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr || len(s) > len(substr) && contains(s[1:], substr)
 }
 
-// Integration test - requires BigQuery emulator or actual connection
-func TestBigQueryWriter_Integration(t *testing.T) {
-	// Skip if not in integration test mode
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
+func TestEncodeNDJSON(t *testing.T) {
+	records := []*VideoStatsRecord{
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ChannelID: "c", VideoID: "v1", Views: 10},
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ChannelID: "c", VideoID: "v2", Views: 20},
 	}
 
-	ctx := context.Background()
+	data, err := encodeNDJSON(records)
+	if err != nil {
+		t.Fatalf("encodeNDJSON() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("encodeNDJSON() produced %d lines, want 2", len(lines))
+	}
+
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("line is not valid JSON: %v", err)
+	}
+	// Keys must match the BigQuery column names
+	if row["dt"] != "2025-08-01" || row["video_id"] != "v1" || row["views"] != float64(10) {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestVideoStatsRecord_InsertID(t *testing.T) {
+	morning := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	evening := morning.Add(12 * time.Hour)
+
+	a := &VideoStatsRecord{VideoID: "v1", SnapshotTs: morning}
+	b := &VideoStatsRecord{VideoID: "v1", SnapshotTs: morning.In(time.FixedZone("JST", 9*60*60))}
+	c := &VideoStatsRecord{VideoID: "v1", SnapshotTs: evening}
+
+	if a.InsertID() != b.InsertID() {
+		t.Errorf("same snapshot in different zones: %q != %q", a.InsertID(), b.InsertID())
+	}
+	if a.InsertID() == c.InsertID() {
+		t.Errorf("snapshots of the same day share insert ID %q", a.InsertID())
+	}
+	// The same snapshot of a video in two playlists, or in a playlist and in
+	// its channel's uploads, is kept once per source.
+	p1 := &VideoStatsRecord{VideoID: "v1", SnapshotTs: morning, PlaylistID: "PL1"}
+	p2 := &VideoStatsRecord{VideoID: "v1", SnapshotTs: morning, PlaylistID: "PL2"}
+	if p1.InsertID() == p2.InsertID() {
+		t.Errorf("rows of different playlists share insert ID %q", p1.InsertID())
+	}
+	if a.InsertID() == p1.InsertID() {
+		t.Errorf("channel and playlist rows share insert ID %q", a.InsertID())
+	}
+	if id := (&VideoStatsRecord{VideoID: "v1"}).InsertID(); id != "" {
+		t.Errorf("InsertID() without snapshot_ts = %q, want empty", id)
+	}
+}
+
+func TestVideoStatsRecord_SetDerived(t *testing.T) {
+	published := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	r := &VideoStatsRecord{Views: 2000, Likes: 100, Comments: 10, PublishedAt: published, SnapshotTs: published.Add(40 * time.Hour)}
+	r.SetDerived()
+	if r.LikeRate.Float64 != 0.05 || r.CommentRate.Float64 != 0.005 || r.ViewsPerHour.Float64 != 50 {
+		t.Errorf("derived = %v %v %v, want 0.05 0.005 50", r.LikeRate, r.CommentRate, r.ViewsPerHour)
+	}
+
+	// Just published: the age is clamped to an hour.
+	r = &VideoStatsRecord{Views: 30, PublishedAt: published, CreatedAt: published.Add(time.Minute)}
+	r.SetDerived()
+	if r.ViewsPerHour.Float64 != 30 || r.LikeRate.Float64 != 0 || !r.LikeRate.Valid {
+		t.Errorf("just published: views_per_hour = %v, like_rate = %v", r.ViewsPerHour, r.LikeRate)
+	}
+
+	// No views and no publication time: everything is NULL, also in JSON.
+	r = &VideoStatsRecord{Likes: 3, CreatedAt: published}
+	r.SetDerived()
+	if r.LikeRate.Valid || r.CommentRate.Valid || r.ViewsPerHour.Valid {
+		t.Errorf("without views: %v %v %v, want NULL", r.LikeRate, r.CommentRate, r.ViewsPerHour)
+	}
+	data, err := encodeNDJSON([]*VideoStatsRecord{r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"like_rate":null`) {
+		t.Errorf("NDJSON = %s, want like_rate null", data)
+	}
+}
+
+func TestWithDerived(t *testing.T) {
+	published := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	records := []*VideoStatsRecord{{VideoID: "v1", Views: 2000, Likes: 100, PublishedAt: published, SnapshotTs: published.Add(40 * time.Hour)}}
+	derived := withDerived(records)
+	if derived[0].LikeRate.Float64 != 0.05 || derived[0].ViewsPerHour.Float64 != 50 {
+		t.Errorf("derived = %v %v, want 0.05 50", derived[0].LikeRate, derived[0].ViewsPerHour)
+	}
+	// The writers derive on copies; the caller's records are unchanged.
+	if records[0].LikeRate.Valid || records[0].ViewsPerHour.Valid {
+		t.Errorf("caller's record was changed: %+v", records[0])
+	}
+}
+
+// TestSchema_MatchesVideoStatsRecord keeps the embedded schema and the
+// bigquery tags of VideoStatsRecord in sync: every column is written by a
+// field of the same type, and every field has a column.
+func TestSchema_MatchesVideoStatsRecord(t *testing.T) {
+	schema, err := bigquery.SchemaFromJSON(getSchemaJSON())
+	if err != nil {
+		t.Fatalf("embedded schema: %v", err)
+	}
+	inferred, err := bigquery.InferSchema(VideoStatsRecord{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make(map[string]*bigquery.FieldSchema)
+	for _, f := range inferred {
+		fields[f.Name] = f
+	}
+	for _, col := range schema {
+		f, ok := fields[col.Name]
+		if !ok {
+			t.Errorf("column %s has no VideoStatsRecord field", col.Name)
+			continue
+		}
+		if f.Type != col.Type || f.Repeated != col.Repeated {
+			t.Errorf("column %s is %s, but its field is %s", col.Name, describeField(col), describeField(f))
+		}
+		delete(fields, col.Name)
+	}
+	for name := range fields {
+		t.Errorf("VideoStatsRecord field %s has no column in schema/video_trends.json", name)
+	}
+}
 
-	// This would require actual BigQuery connection or emulator
-	// For now, just test that NewBigQueryWriter doesn't panic
-	// with invalid project ID
-	_, err := NewBigQueryWriter(ctx, "test-project")
-	if err == nil {
-		t.Skip("Skipping - BigQuery client created unexpectedly")
+func TestSchema_DeploymentCopy(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "deployments", "bq", "schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, getSchemaJSON()) {
+		t.Error("deployments/bq/schema.json differs from internal/storage/schema/video_trends.json; copy it over")
+	}
+}
+
+func TestSetSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var cols []map[string]any
+	if err := json.Unmarshal(getSchemaJSON(), &cols); err != nil {
+		t.Fatal(err)
+	}
+	extended, _ := json.Marshal(append(cols, map[string]any{"name": "team", "type": "STRING", "mode": "NULLABLE"}))
+	truncated, _ := json.Marshal(cols[:len(cols)-1])
+
+	w := &BigQueryWriter{}
+	if err := w.SetSchemaFile(write("extended.json", string(extended))); err != nil {
+		t.Fatalf("SetSchemaFile(extended) error = %v", err)
+	}
+	if !strings.Contains(string(w.tableSchemaJSON()), `"team"`) {
+		t.Error("tableSchemaJSON() does not use the schema file")
+	}
+
+	for name, content := range map[string]string{
+		"truncated.json": string(truncated),
+		"invalid.json":   "{",
+	} {
+		w := &BigQueryWriter{}
+		if err := w.SetSchemaFile(write(name, content)); err == nil {
+			t.Errorf("SetSchemaFile(%s) succeeded, want error", name)
+		}
+		if !bytes.Equal(w.tableSchemaJSON(), getSchemaJSON()) {
+			t.Errorf("SetSchemaFile(%s) failed but changed the schema", name)
+		}
+	}
+	if err := w.SetSchemaFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("SetSchemaFile(missing) succeeded, want error")
 	}
 }
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// UploadCadenceDays is how far back UploadTimes looks for uploads: 13 weeks.
+const UploadCadenceDays = 91
+
+// UploadTime is when a tracked video was published.
+type UploadTime struct {
+	ChannelID   string    `bigquery:"channel_id"`
+	VideoID     string    `bigquery:"video_id"`
+	PublishedAt time.Time `bigquery:"published_at"`
+}
+
+// UploadTimes returns the videos published in the UploadCadenceDays up to
+// date, as seen in their snapshots. It reaches a day further back so that
+// callers can cut the range in their own timezone.
+func (w *BigQueryWriter) UploadTimes(ctx context.Context, date civil.Date) ([]UploadTime, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT channel_id, video_id, ANY_VALUE(published_at) AS published_at
+		FROM %s
+		WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND @date
+			AND published_at >= TIMESTAMP(DATE_SUB(@date, INTERVAL %[2]d DAY))
+			AND `+channelRows+`
+		GROUP BY channel_id, video_id`,
+		w.tableRef(), UploadCadenceDays+1))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[UploadTime](ctx, q, "upload times")
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// ChannelDailyStatsTableID is the table that stores daily per-channel
+// aggregates.
+const ChannelDailyStatsTableID = "channel_daily_stats"
+
+// ChannelStatsInput is a video's latest snapshot on a date.
+type ChannelStatsInput struct {
+	ChannelID   string                 `bigquery:"channel_id"`
+	ChannelName string                 `bigquery:"channel_name"`
+	VideoID     string                 `bigquery:"video_id"`
+	IsShort     bool                   `bigquery:"is_short"`
+	Views       int64                  `bigquery:"views"`
+	Likes       int64                  `bigquery:"likes"`
+	Comments    int64                  `bigquery:"comments"`
+	PublishedAt bigquery.NullTimestamp `bigquery:"published_at"`
+}
+
+// ChannelDailyStatsRecord aggregates a channel's tracked videos on a date.
+type ChannelDailyStatsRecord struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	// Videos counts the channel's videos snapshotted on the date, Uploads
+	// those of them published on the date and Shorts those classified as
+	// shorts.
+	Videos  int64 `bigquery:"videos" json:"videos"`
+	Uploads int64 `bigquery:"uploads" json:"uploads"`
+	Shorts  int64 `bigquery:"shorts" json:"shorts"`
+	// ShortsShare is Shorts / Videos.
+	ShortsShare   float64 `bigquery:"shorts_share" json:"shorts_share"`
+	TotalViews    int64   `bigquery:"total_views" json:"total_views"`
+	TotalLikes    int64   `bigquery:"total_likes" json:"total_likes"`
+	TotalComments int64   `bigquery:"total_comments" json:"total_comments"`
+	// UploadsPerWeek, AvgUploadGapDays, UploadStreakWeeks and LastUploadAt
+	// describe the channel's upload cadence up to the date (see
+	// analytics.Cadence). AvgUploadGapDays is NULL with fewer than two
+	// uploads and LastUploadAt without any.
+	UploadsPerWeek    float64                `bigquery:"uploads_per_week" json:"uploads_per_week"`
+	AvgUploadGapDays  bigquery.NullFloat64   `bigquery:"avg_upload_gap_days" json:"avg_upload_gap_days"`
+	UploadStreakWeeks int64                  `bigquery:"upload_streak_weeks" json:"upload_streak_weeks"`
+	LastUploadAt      bigquery.NullTimestamp `bigquery:"last_upload_at" json:"last_upload_at"`
+	ComputedAt        time.Time              `bigquery:"computed_at" json:"computed_at"`
+}
+
+func getChannelDailyStatsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",                  "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "channel_id",          "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_name",        "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "videos",              "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "uploads",             "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "shorts",              "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "shorts_share",        "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "total_views",         "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "total_likes",         "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "total_comments",      "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "computed_at",         "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "uploads_per_week",    "type": "FLOAT",     "mode": "NULLABLE"},
+	  {"name": "avg_upload_gap_days", "type": "FLOAT",     "mode": "NULLABLE"},
+	  {"name": "upload_streak_weeks", "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "last_upload_at",      "type": "TIMESTAMP", "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureChannelDailyStatsTable creates the channel daily stats table if
+// needed and adds the upload cadence columns to tables created without them.
+func (w *BigQueryWriter) EnsureChannelDailyStatsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	if err := w.ensureTable(ctx, ChannelDailyStatsTableID, getChannelDailyStatsSchemaJSON(), "dt", []string{"channel_id"}); err != nil {
+		return err
+	}
+	return w.addMissingColumns(ctx, ChannelDailyStatsTableID, getChannelDailyStatsSchemaJSON())
+}
+
+// ChannelStatsInputs returns the latest snapshot on date of every video.
+// Tombstones are left out.
+func (w *BigQueryWriter) ChannelStatsInputs(ctx context.Context, date civil.Date) ([]ChannelStatsInput, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT channel_id, IFNULL(channel_name, '') AS channel_name, video_id,
+			IFNULL(is_short, FALSE) AS is_short, views,
+			IFNULL(likes, 0) AS likes, IFNULL(comments, 0) AS comments, published_at
+		FROM %s
+		WHERE dt = @date AND views IS NOT NULL AND `+channelRows+`
+		QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1`,
+		w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[ChannelStatsInput](ctx, q, "channel stats inputs")
+}
+
+// ReplaceChannelDailyStats replaces the date's partition of the channel
+// daily stats table with records, like ReplaceTagTrends. Every record must
+// be for date.
+func (w *BigQueryWriter) ReplaceChannelDailyStats(ctx context.Context, date civil.Date, records []*ChannelDailyStatsRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if r.Dt != date {
+			return fmt.Errorf("channel stats for %s are dated %s, not %s", r.ChannelID, r.Dt, date)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode channel stats for %s: %w", r.ChannelID, err)
+		}
+	}
+	return w.replacePartition(ctx, ChannelDailyStatsTableID, date, buf.Bytes())
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// ChannelHealthTableID is the table that tracks consecutive fetch failures,
+// one row per channel that has failed.
+const ChannelHealthTableID = "channel_health"
+
+// ChannelHealth is the failure streak of one channel.
+type ChannelHealth struct {
+	ChannelID string `bigquery:"channel_id" json:"channel_id"`
+	// ConsecutiveFailures counts the runs that failed the channel since it
+	// last succeeded or was re-enabled.
+	ConsecutiveFailures int64     `bigquery:"consecutive_failures" json:"consecutive_failures"`
+	LastError           string    `bigquery:"last_error" json:"last_error,omitempty"`
+	LastFailedAt        time.Time `bigquery:"last_failed_at" json:"last_failed_at"`
+	// DisabledAt is when the channel was disabled for failing too often;
+	// NULL while it is fetched.
+	DisabledAt bigquery.NullTimestamp `bigquery:"disabled_at" json:"disabled_at"`
+}
+
+// Disabled reports whether the channel was disabled for failing.
+func (h ChannelHealth) Disabled() bool {
+	return h.DisabledAt.Valid
+}
+
+func getChannelHealthSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "channel_id",           "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "consecutive_failures", "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "last_error",           "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "last_failed_at",       "type": "TIMESTAMP", "mode": "NULLABLE"},
+	  {"name": "disabled_at",          "type": "TIMESTAMP", "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureChannelHealthTable creates the channel health table if needed.
+func (w *BigQueryWriter) EnsureChannelHealthTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, ChannelHealthTableID, getChannelHealthSchemaJSON(), "", nil)
+}
+
+// ChannelHealth returns the failure streaks of all channels that have one
+// or are disabled, ordered by channel ID.
+func (w *BigQueryWriter) ChannelHealth(ctx context.Context) ([]ChannelHealth, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT channel_id, consecutive_failures, IFNULL(last_error, '') AS last_error, last_failed_at, disabled_at
+		FROM %s
+		WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+		ORDER BY channel_id`, w.channelHealthTableRef()))
+	return readAll[ChannelHealth](ctx, q, "channel health")
+}
+
+// SaveChannelHealth replaces the rows of the given channels.
+func (w *BigQueryWriter) SaveChannelHealth(ctx context.Context, rows []ChannelHealth) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING UNNEST(@rows) AS s
+		ON t.channel_id = s.channel_id
+		WHEN MATCHED THEN UPDATE SET
+			consecutive_failures = s.consecutive_failures,
+			last_error = s.last_error,
+			last_failed_at = s.last_failed_at,
+			disabled_at = s.disabled_at
+		WHEN NOT MATCHED THEN
+			INSERT (channel_id, consecutive_failures, last_error, last_failed_at, disabled_at)
+			VALUES (s.channel_id, s.consecutive_failures, s.last_error, s.last_failed_at, s.disabled_at)`,
+		w.channelHealthTableRef()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "rows", Value: rows}}
+	if _, err := runDML(ctx, q); err != nil {
+		return fmt.Errorf("failed to save channel health: %w", err)
+	}
+	return nil
+}
+
+// EnableChannel clears the failure streak of a disabled channel, so that it
+// is fetched again, and reports whether it was disabled.
+func (w *BigQueryWriter) EnableChannel(ctx context.Context, channelID string) (bool, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		UPDATE %s
+		SET consecutive_failures = 0, disabled_at = NULL
+		WHERE channel_id = @channel_id AND disabled_at IS NOT NULL`, w.channelHealthTableRef()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "channel_id", Value: channelID}}
+	affected, err := runDML(ctx, q)
+	if err != nil {
+		return false, fmt.Errorf("failed to enable channel %s: %w", channelID, err)
+	}
+	return affected > 0, nil
+}
+
+func (w *BigQueryWriter) channelHealthTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, ChannelHealthTableID)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// Tables of the subscriber milestones: ChannelSnapshotsTableID stores the
+// subscriber counts of the channels at each run, and
+// ChannelMilestonesTableID the milestones they reached.
+const (
+	ChannelSnapshotsTableID  = "channel_snapshots"
+	ChannelMilestonesTableID = "channel_milestones"
+)
+
+// subscriberLookbackDays is how far back LatestSubscribers looks for the
+// previous subscriber count of a channel.
+const subscriberLookbackDays = 30
+
+// ChannelSnapshotRecord is the statistics of a channel at a run.
+type ChannelSnapshotRecord struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	SnapshotTs  time.Time  `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	// Subscribers is NULL when the channel hides its subscriber count.
+	Subscribers bigquery.NullInt64 `bigquery:"subscribers" json:"subscribers"`
+	Videos      int64              `bigquery:"videos" json:"videos"`
+	Views       int64              `bigquery:"views" json:"views"`
+}
+
+// ChannelSubscribers is the latest known subscriber count of a channel.
+type ChannelSubscribers struct {
+	ChannelID   string    `bigquery:"channel_id"`
+	Subscribers int64     `bigquery:"subscribers"`
+	SnapshotTs  time.Time `bigquery:"snapshot_ts"`
+}
+
+// ChannelMilestoneRecord is a subscriber count a channel reached.
+type ChannelMilestoneRecord struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	ReachedAt   time.Time  `bigquery:"reached_at" json:"reached_at"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	Milestone   int64      `bigquery:"milestone" json:"milestone"`
+	// Subscribers is the count of the snapshot that reached the milestone,
+	// and PreviousSubscribers that of the snapshot before it.
+	Subscribers         int64 `bigquery:"subscribers" json:"subscribers"`
+	PreviousSubscribers int64 `bigquery:"previous_subscribers" json:"previous_subscribers"`
+}
+
+func getChannelSnapshotsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",           "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "snapshot_ts",  "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",   "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_name", "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "subscribers",  "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "videos",       "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "views",        "type": "INTEGER",   "mode": "NULLABLE"}
+	]`)
+}
+
+func getChannelMilestonesSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",                   "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "reached_at",           "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",           "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_name",         "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "milestone",            "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "subscribers",          "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "previous_subscribers", "type": "INTEGER",   "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureChannelMilestoneTables creates the channel snapshots and milestones
+// tables if needed.
+func (w *BigQueryWriter) EnsureChannelMilestoneTables(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	if err := w.ensureTable(ctx, ChannelSnapshotsTableID, getChannelSnapshotsSchemaJSON(), "dt", []string{"channel_id"}); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, ChannelMilestonesTableID, getChannelMilestonesSchemaJSON(), "dt", []string{"channel_id"})
+}
+
+// InsertChannelSnapshots stores channel snapshots.
+func (w *BigQueryWriter) InsertChannelSnapshots(ctx context.Context, records []*ChannelSnapshotRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	inserter := w.client.Dataset(w.datasetID).Table(ChannelSnapshotsTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert channel snapshots into BigQuery: %w", err)
+	}
+	return nil
+}
+
+// LatestSubscribers returns the subscriber count of the latest snapshot of
+// each of the given channels in the subscriberLookbackDays up to date.
+// Channels without one, or hiding their count, are absent.
+func (w *BigQueryWriter) LatestSubscribers(ctx context.Context, date civil.Date, channelIDs []string) ([]ChannelSubscribers, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT channel_id, subscribers, snapshot_ts
+		FROM %s
+		WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %d DAY) AND @date
+			AND channel_id IN UNNEST(@channels) AND subscribers IS NOT NULL
+		QUALIFY ROW_NUMBER() OVER (PARTITION BY channel_id ORDER BY snapshot_ts DESC) = 1`,
+		w.channelTableRef(ChannelSnapshotsTableID), subscriberLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+		{Name: "channels", Value: channelIDs},
+	}
+	return readAll[ChannelSubscribers](ctx, q, "channel subscribers")
+}
+
+// ChannelMilestones returns the milestones the given channels reached,
+// latest first.
+func (w *BigQueryWriter) ChannelMilestones(ctx context.Context, channelIDs []string) ([]ChannelMilestoneRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT dt, reached_at, channel_id, IFNULL(channel_name, '') AS channel_name,
+			milestone, subscribers, previous_subscribers
+		FROM %s
+		WHERE channel_id IN UNNEST(@channels)
+		ORDER BY reached_at DESC, milestone DESC`, w.channelTableRef(ChannelMilestonesTableID)))
+	q.Parameters = []bigquery.QueryParameter{{Name: "channels", Value: channelIDs}}
+	return readAll[ChannelMilestoneRecord](ctx, q, "channel milestones")
+}
+
+// InsertChannelMilestones records reached milestones.
+func (w *BigQueryWriter) InsertChannelMilestones(ctx context.Context, records []*ChannelMilestoneRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	inserter := w.client.Dataset(w.datasetID).Table(ChannelMilestonesTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert channel milestones into BigQuery: %w", err)
+	}
+	return nil
+}
+
+func (w *BigQueryWriter) channelTableRef(tableID string) string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, tableID)
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+)
+
+// ChannelsTableID is the table that holds the tracked channel list when
+// channels are managed in BigQuery instead of the configuration file.
+const ChannelsTableID = "channels"
+
+// ChannelRecord is one tracked channel.
+type ChannelRecord struct {
+	ChannelID     string    `bigquery:"channel_id"`
+	Name          string    `bigquery:"name"`
+	Description   string    `bigquery:"description"`
+	Enabled       bool      `bigquery:"enabled"`
+	TrackComments bool      `bigquery:"track_comments"`
+	UpdatedAt     time.Time `bigquery:"updated_at"`
+}
+
+func getChannelsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "channel_id",     "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "name",           "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "description",    "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "enabled",        "type": "BOOLEAN",   "mode": "NULLABLE"},
+	  {"name": "track_comments", "type": "BOOLEAN",   "mode": "NULLABLE"},
+	  {"name": "updated_at",     "type": "TIMESTAMP", "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureChannelsTable creates the channels table if needed.
+func (w *BigQueryWriter) EnsureChannelsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, ChannelsTableID, getChannelsSchemaJSON(), "", nil)
+}
+
+// ReadChannels returns every row of the channels table ordered by channel ID.
+// Rows edited by hand may leave columns NULL: enabled then defaults to true
+// and track_comments to false.
+func (w *BigQueryWriter) ReadChannels(ctx context.Context) ([]ChannelRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			channel_id,
+			IFNULL(name, '') AS name,
+			IFNULL(description, '') AS description,
+			IFNULL(enabled, TRUE) AS enabled,
+			IFNULL(track_comments, FALSE) AS track_comments,
+			IFNULL(updated_at, TIMESTAMP_SECONDS(0)) AS updated_at
+		FROM %s
+		WHERE channel_id IS NOT NULL AND channel_id != ''
+		ORDER BY channel_id`, w.channelsTableRef()))
+
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channels: %w", err)
+	}
+	var records []ChannelRecord
+	for {
+		var r ChannelRecord
+		err := it.Next(&r)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read channels: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// UpsertChannels inserts new channels and updates existing ones, matched on
+// channel_id. Channels missing from records are left untouched. A MERGE is
+// used instead of streaming inserts so the rows are editable immediately.
+func (w *BigQueryWriter) UpsertChannels(ctx context.Context, records []ChannelRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING UNNEST(@rows) AS s
+		ON t.channel_id = s.channel_id
+		WHEN MATCHED THEN UPDATE SET
+			name = s.name,
+			description = s.description,
+			enabled = s.enabled,
+			track_comments = s.track_comments,
+			updated_at = s.updated_at
+		WHEN NOT MATCHED THEN
+			INSERT (channel_id, name, description, enabled, track_comments, updated_at)
+			VALUES (s.channel_id, s.name, s.description, s.enabled, s.track_comments, s.updated_at)`,
+		w.channelsTableRef()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "rows", Value: records}}
+
+	job, err := q.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert channels: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert channels: %w", err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("failed to upsert channels: %w", err)
+	}
+	return nil
+}
+
+// ReplaceChannels makes the channels table hold exactly records: new
+// channels are inserted, existing ones updated and the others deleted, in
+// one statement.
+func (w *BigQueryWriter) ReplaceChannels(ctx context.Context, records []ChannelRecord) error {
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING UNNEST(@rows) AS s
+		ON t.channel_id = s.channel_id
+		WHEN MATCHED THEN UPDATE SET
+			name = s.name,
+			description = s.description,
+			enabled = s.enabled,
+			track_comments = s.track_comments,
+			updated_at = s.updated_at
+		WHEN NOT MATCHED BY TARGET THEN
+			INSERT (channel_id, name, description, enabled, track_comments, updated_at)
+			VALUES (s.channel_id, s.name, s.description, s.enabled, s.track_comments, s.updated_at)
+		WHEN NOT MATCHED BY SOURCE THEN DELETE`,
+		w.channelsTableRef()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "rows", Value: records}}
+	if _, err := runDML(ctx, q); err != nil {
+		return fmt.Errorf("failed to replace channels: %w", err)
+	}
+	return nil
+}
+
+func (w *BigQueryWriter) channelsTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, ChannelsTableID)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// CommentSentimentTableID is the table that stores the sentiment of each
+// video's top comments per day.
+const CommentSentimentTableID = "video_comment_sentiment"
+
+// CommentText is a top comment captured at a video's latest snapshot of a
+// date.
+type CommentText struct {
+	ChannelID string `bigquery:"channel_id"`
+	VideoID   string `bigquery:"video_id"`
+	CommentID string `bigquery:"comment_id"`
+	Text      string `bigquery:"text"`
+}
+
+// CommentSentimentRecord is the sentiment of a video's top comments on a
+// date.
+type CommentSentimentRecord struct {
+	Dt        civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID   string     `bigquery:"video_id" json:"video_id"`
+	// Comments counts the comments that could be scored; Positive and
+	// Negative those above and below the neutral band, and the ratios are
+	// relative to Comments.
+	Comments      int64   `bigquery:"comments" json:"comments"`
+	Positive      int64   `bigquery:"positive" json:"positive"`
+	Negative      int64   `bigquery:"negative" json:"negative"`
+	PositiveRatio float64 `bigquery:"positive_ratio" json:"positive_ratio"`
+	NegativeRatio float64 `bigquery:"negative_ratio" json:"negative_ratio"`
+	// AvgScore is the average score, from -1 (negative) to 1 (positive).
+	AvgScore   float64   `bigquery:"avg_score" json:"avg_score"`
+	ComputedAt time.Time `bigquery:"computed_at" json:"computed_at"`
+}
+
+func getCommentSentimentSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",             "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "channel_id",     "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_id",       "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "comments",       "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "positive",       "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "negative",       "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "positive_ratio", "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "negative_ratio", "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "avg_score",      "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "computed_at",    "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureCommentSentimentTable creates the comment sentiment table if needed.
+func (w *BigQueryWriter) EnsureCommentSentimentTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, CommentSentimentTableID, getCommentSentimentSchemaJSON(), "dt", []string{"channel_id", "video_id"})
+}
+
+// CommentTexts returns the non-empty top comments captured at each video's
+// latest snapshot of date, ordered by video and rank.
+func (w *BigQueryWriter) CommentTexts(ctx context.Context, date civil.Date) ([]CommentText, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT channel_id, video_id, comment_id, text
+		FROM %s
+		WHERE dt = @date AND IFNULL(TRIM(text), '') != ''
+		QUALIFY snapshot_ts = MAX(snapshot_ts) OVER (PARTITION BY video_id)
+		ORDER BY video_id, rank`,
+		w.channelTableRef(VideoCommentsTableID)))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[CommentText](ctx, q, "comment texts")
+}
+
+// ReplaceCommentSentiment replaces the date's partition of the comment
+// sentiment table with records, like ReplaceTagTrends. Every record must be
+// for date.
+func (w *BigQueryWriter) ReplaceCommentSentiment(ctx context.Context, date civil.Date, records []*CommentSentimentRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if r.Dt != date {
+			return fmt.Errorf("comment sentiment for %s is dated %s, not %s", r.VideoID, r.Dt, date)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode comment sentiment for %s: %w", r.VideoID, err)
+		}
+	}
+	return w.replacePartition(ctx, CommentSentimentTableID, date, buf.Bytes())
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// VideoCommentsTableID is the table that stores top comments of tracked videos.
+const VideoCommentsTableID = "video_comments"
+
+// VideoCommentRecord is one top-level comment captured at a snapshot.
+type VideoCommentRecord struct {
+	Dt              civil.Date `bigquery:"dt" json:"dt"`
+	SnapshotTs      time.Time  `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	ChannelID       string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID         string     `bigquery:"video_id" json:"video_id"`
+	CommentID       string     `bigquery:"comment_id" json:"comment_id"`
+	Rank            int64      `bigquery:"rank" json:"rank"`
+	AuthorName      string     `bigquery:"author_name" json:"author_name"`
+	AuthorChannelID string     `bigquery:"author_channel_id" json:"author_channel_id"`
+	Text            string     `bigquery:"text" json:"text"`
+	Likes           int64      `bigquery:"likes" json:"likes"`
+	ReplyCount      int64      `bigquery:"reply_count" json:"reply_count"`
+	PublishedAt     time.Time  `bigquery:"published_at" json:"published_at"`
+	CreatedAt       time.Time  `bigquery:"created_at" json:"created_at"`
+}
+
+func getVideoCommentsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",                "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "snapshot_ts",       "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",        "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_id",          "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "comment_id",        "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "rank",              "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "author_name",       "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "author_channel_id", "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "text",              "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "likes",             "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "reply_count",       "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "published_at",      "type": "TIMESTAMP", "mode": "NULLABLE"},
+	  {"name": "created_at",        "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureVideoCommentsTable creates the video comments table if needed.
+func (w *BigQueryWriter) EnsureVideoCommentsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, VideoCommentsTableID, getVideoCommentsSchemaJSON(), "dt", []string{"channel_id", "video_id"})
+}
+
+// InsertVideoComments inserts captured comments.
+func (w *BigQueryWriter) InsertVideoComments(ctx context.Context, records []*VideoCommentRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(VideoCommentsTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert video comments into BigQuery: %w", err)
+	}
+
+	return nil
+}
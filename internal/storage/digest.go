@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// ChannelPerformance summarises a channel's snapshots over a date range.
+type ChannelPerformance struct {
+	ChannelID   string `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string `bigquery:"channel_name" json:"channel_name"`
+	// NewUploads counts the videos published within the range.
+	NewUploads  int64 `bigquery:"new_uploads" json:"new_uploads"`
+	ViewsGained int64 `bigquery:"views_gained" json:"views_gained"`
+	// The video with the most views gained.
+	BestVideoID     string `bigquery:"best_video_id" json:"best_video_id"`
+	BestTitle       string `bigquery:"best_title" json:"best_title"`
+	BestViewsGained int64  `bigquery:"best_views_gained" json:"best_views_gained"`
+}
+
+// ChannelPerformance returns the performance of every channel snapshotted
+// between from and to (inclusive), most views gained first. A video's gain is
+// its latest views in the range minus its latest views before from (looked up
+// at most 7 days back), or minus its first views in the range if it has no
+// earlier snapshot. Videos published within the range count all their views.
+// Dates are in timezone, the zone dt is derived in.
+func (w *BigQueryWriter) ChannelPerformance(ctx context.Context, from, to civil.Date, timezone string) ([]ChannelPerformance, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH snaps AS (
+			SELECT channel_id, channel_name, video_id, title, views, published_at, dt,
+				IFNULL(snapshot_ts, created_at) AS ts
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@from, INTERVAL %[2]d DAY) AND @to AND views IS NOT NULL
+				AND `+channelRows+`
+		),
+		videos AS (
+			SELECT channel_id, video_id,
+				ARRAY_AGG(IF(dt >= @from, STRUCT(views, title, channel_name, published_at), NULL)
+					IGNORE NULLS ORDER BY ts DESC LIMIT 1)[SAFE_OFFSET(0)] AS cur,
+				ARRAY_AGG(IF(dt < @from, views, NULL) IGNORE NULLS ORDER BY ts DESC LIMIT 1)[SAFE_OFFSET(0)] AS prev_views,
+				ARRAY_AGG(IF(dt >= @from, views, NULL) IGNORE NULLS ORDER BY ts LIMIT 1)[SAFE_OFFSET(0)] AS first_views
+			FROM snaps
+			GROUP BY channel_id, video_id
+		),
+		gains AS (
+			SELECT channel_id, video_id, cur.title, cur.channel_name, is_new,
+				GREATEST(cur.views - IF(is_new, 0, IFNULL(prev_views, first_views)), 0) AS views_gained
+			FROM (
+				SELECT *, IFNULL(cur.published_at >= TIMESTAMP(@from, @tz), FALSE) AS is_new
+				FROM videos
+				WHERE cur IS NOT NULL
+			)
+		),
+		channels AS (
+			SELECT channel_id,
+				IFNULL(ANY_VALUE(channel_name), '') AS channel_name,
+				COUNTIF(is_new) AS new_uploads,
+				SUM(views_gained) AS views_gained,
+				ARRAY_AGG(STRUCT(video_id, IFNULL(title, '') AS title, views_gained)
+					ORDER BY views_gained DESC LIMIT 1)[OFFSET(0)] AS best
+			FROM gains
+			GROUP BY channel_id
+		)
+		SELECT channel_id, channel_name, new_uploads, views_gained,
+			best.video_id AS best_video_id, best.title AS best_title, best.views_gained AS best_views_gained
+		FROM channels
+		ORDER BY views_gained DESC`, w.tableRef(), trendBaselineDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+		{Name: "tz", Value: timezone},
+	}
+	return readAll[ChannelPerformance](ctx, q, "channel performance")
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// DiscoveredChannelsTableID is the table that holds the channels proposed
+// by `fetcher discover`, for review before they are added to the channel
+// list.
+const DiscoveredChannelsTableID = "discovered_channels"
+
+// DiscoveredChannelRecord is one proposed channel.
+type DiscoveredChannelRecord struct {
+	ChannelID   string   `bigquery:"channel_id"`
+	Title       string   `bigquery:"title"`
+	Country     string   `bigquery:"country"`
+	Subscribers int64    `bigquery:"subscribers"`
+	Videos      int64    `bigquery:"videos"`
+	Views       int64    `bigquery:"views"`
+	Sources     []string `bigquery:"sources"`
+	Score       int64    `bigquery:"score"`
+	// DiscoveredAt is when the channel was last found. The first time is
+	// kept in first_discovered_at.
+	DiscoveredAt time.Time `bigquery:"discovered_at"`
+}
+
+func getDiscoveredChannelsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "channel_id",          "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "title",               "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "country",             "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "subscribers",         "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "videos",              "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "views",               "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "sources",             "type": "STRING",    "mode": "REPEATED"},
+	  {"name": "score",               "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "first_discovered_at", "type": "TIMESTAMP", "mode": "NULLABLE"},
+	  {"name": "discovered_at",       "type": "TIMESTAMP", "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureDiscoveredChannelsTable creates the discovered channels table if
+// needed.
+func (w *BigQueryWriter) EnsureDiscoveredChannelsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, DiscoveredChannelsTableID, getDiscoveredChannelsSchemaJSON(), "", nil)
+}
+
+// UpsertDiscoveredChannels records the proposed channels, one row per
+// channel: a channel found again has its statistics, sources and score
+// replaced, keeping the time it was first discovered.
+func (w *BigQueryWriter) UpsertDiscoveredChannels(ctx context.Context, records []DiscoveredChannelRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING UNNEST(@rows) AS s
+		ON t.channel_id = s.channel_id
+		WHEN MATCHED THEN UPDATE SET
+			title = s.title,
+			country = s.country,
+			subscribers = s.subscribers,
+			videos = s.videos,
+			views = s.views,
+			sources = s.sources,
+			score = s.score,
+			discovered_at = s.discovered_at
+		WHEN NOT MATCHED THEN
+			INSERT (channel_id, title, country, subscribers, videos, views, sources, score, first_discovered_at, discovered_at)
+			VALUES (s.channel_id, s.title, s.country, s.subscribers, s.videos, s.views, s.sources, s.score, s.discovered_at, s.discovered_at)`,
+		fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, DiscoveredChannelsTableID)))
+	q.Parameters = []bigquery.QueryParameter{{Name: "rows", Value: records}}
+
+	job, err := q.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert discovered channels: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert discovered channels: %w", err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("failed to upsert discovered channels: %w", err)
+	}
+	return nil
+}
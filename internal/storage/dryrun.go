@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// DryRunWriter collects the records a run would write instead of writing them.
+// Lookups of previously stored data are delegated to a reader when one is
+// set, so change detection behaves as in a real run; without a reader they
+// return nothing.
+type DryRunWriter struct {
+	reader *BigQueryWriter
+
+	mu              sync.Mutex
+	VideoStats      []*VideoStatsRecord     `json:"video_stats"`
+	Tombstones      []*VideoTombstoneRecord `json:"tombstones"`
+	MetadataChanges []*MetadataChangeRecord `json:"metadata_changes"`
+	Comments        []*VideoCommentRecord   `json:"comments"`
+	KeywordTrends   []*KeywordTrendRecord   `json:"keyword_trends"`
+	// QuotaEstimate is the YouTube API quota the run was estimated to
+	// cost before it started, broken down by API method.
+	QuotaEstimate         int64            `json:"-"`
+	QuotaEstimateByMethod map[string]int64 `json:"-"`
+}
+
+// NewDryRunWriter creates an empty DryRunWriter.
+func NewDryRunWriter() *DryRunWriter {
+	return &DryRunWriter{}
+}
+
+// SetReader makes lookups read from BigQuery. Nothing is ever written to it.
+func (d *DryRunWriter) SetReader(r *BigQueryWriter) {
+	d.reader = r
+}
+
+// SetQuotaEstimate records the run's estimated quota cost.
+func (d *DryRunWriter) SetQuotaEstimate(units int64, byMethod map[string]int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.QuotaEstimate = units
+	d.QuotaEstimateByMethod = byMethod
+}
+
+// Counts returns the number of collected records per table.
+func (d *DryRunWriter) Counts() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return map[string]int{
+		"video_stats":      len(d.VideoStats),
+		"tombstones":       len(d.Tombstones),
+		"metadata_changes": len(d.MetadataChanges),
+		"comments":         len(d.Comments),
+		"keyword_trends":   len(d.KeywordTrends),
+	}
+}
+
+// logRows logs each row that would have been written.
+func logRows[T any](ctx context.Context, table string, rows []T) {
+	log := logger.FromContext(ctx)
+	for _, row := range rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			b = []byte(fmt.Sprintf("%+v", row))
+		}
+		log.Info(fmt.Sprintf("Dry run: would insert into %s", table), map[string]string{
+			"table": table,
+			"row":   string(b),
+		})
+	}
+}
+
+// InsertVideoStats records video stats without writing them.
+func (d *DryRunWriter) InsertVideoStats(ctx context.Context, records []*VideoStatsRecord) error {
+	records = withDerived(records)
+	logRows(ctx, "video_stats", records)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.VideoStats = append(d.VideoStats, records...)
+	return nil
+}
+
+// InsertTombstones records tombstones without writing them.
+func (d *DryRunWriter) InsertTombstones(ctx context.Context, records []*VideoTombstoneRecord) error {
+	logRows(ctx, "tombstones", records)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Tombstones = append(d.Tombstones, records...)
+	return nil
+}
+
+// InsertMetadataChanges records metadata changes without writing them.
+func (d *DryRunWriter) InsertMetadataChanges(ctx context.Context, records []*MetadataChangeRecord) error {
+	logRows(ctx, MetadataChangesTableID, records)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.MetadataChanges = append(d.MetadataChanges, records...)
+	return nil
+}
+
+// InsertVideoComments records comments without writing them.
+func (d *DryRunWriter) InsertVideoComments(ctx context.Context, records []*VideoCommentRecord) error {
+	logRows(ctx, VideoCommentsTableID, records)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Comments = append(d.Comments, records...)
+	return nil
+}
+
+// InsertKeywordTrends records keyword results without writing them.
+func (d *DryRunWriter) InsertKeywordTrends(ctx context.Context, records []*KeywordTrendRecord) error {
+	logRows(ctx, KeywordTrendsTableID, records)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.KeywordTrends = append(d.KeywordTrends, records...)
+	return nil
+}
+
+// KnownVideoIDs delegates to the reader, if any.
+func (d *DryRunWriter) KnownVideoIDs(ctx context.Context, channelID string, since civil.Date) ([]string, error) {
+	if d.reader == nil {
+		return nil, nil
+	}
+	return d.reader.KnownVideoIDs(ctx, channelID, since)
+}
+
+// LatestMetadata delegates to the reader, if any.
+func (d *DryRunWriter) LatestMetadata(ctx context.Context, channelID string, videoIDs []string, since civil.Date) (map[string]*VideoMetadata, error) {
+	if d.reader == nil {
+		return map[string]*VideoMetadata{}, nil
+	}
+	return d.reader.LatestMetadata(ctx, channelID, videoIDs, since)
+}
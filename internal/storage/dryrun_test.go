@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"cloud.google.com/go/civil"
+)
+
+func TestDryRunWriter(t *testing.T) {
+	d := NewDryRunWriter()
+	ctx := context.Background()
+
+	if err := d.InsertVideoStats(ctx, []*VideoStatsRecord{{VideoID: "v1"}, {VideoID: "v2"}}); err != nil {
+		t.Fatalf("InsertVideoStats() error = %v", err)
+	}
+	if err := d.InsertTombstones(ctx, []*VideoTombstoneRecord{{VideoID: "gone"}}); err != nil {
+		t.Fatalf("InsertTombstones() error = %v", err)
+	}
+
+	counts := d.Counts()
+	if counts["video_stats"] != 2 || counts["tombstones"] != 1 || counts["comments"] != 0 {
+		t.Errorf("Counts() = %v", counts)
+	}
+
+	// Lookups without a reader return nothing instead of failing
+	if ids, err := d.KnownVideoIDs(ctx, "ch1", civil.Date{Year: 2025, Month: 8, Day: 1}); err != nil || len(ids) != 0 {
+		t.Errorf("KnownVideoIDs() = %v, %v; want empty", ids, err)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string][]json.RawMessage
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(decoded["video_stats"]) != 2 {
+		t.Errorf("encoded video_stats = %d rows, want 2", len(decoded["video_stats"]))
+	}
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// emulatorWriter returns a writer for a fresh dataset on the BigQuery
+// emulator at BIGQUERY_EMULATOR_HOST, deleted when the test ends. The test
+// is skipped in short mode or when no emulator is configured; run
+// `make test-integration` to start one.
+func emulatorWriter(t *testing.T) *BigQueryWriter {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	if os.Getenv("BIGQUERY_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: BIGQUERY_EMULATOR_HOST is not set")
+	}
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		project = "test-project"
+	}
+
+	ctx := context.Background()
+	dataset := fmt.Sprintf("it_%d", time.Now().UnixNano())
+	w, err := NewBigQueryWriterWithConfig(ctx, project, dataset, "video_trends")
+	if err != nil {
+		t.Fatalf("NewBigQueryWriterWithConfig() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := w.client.Dataset(dataset).DeleteWithContents(ctx); err != nil {
+			t.Logf("failed to delete dataset %s: %v", dataset, err)
+		}
+		w.client.Close()
+	})
+	return w
+}
+
+func TestBigQueryWriter_Integration(t *testing.T) {
+	w := emulatorWriter(t)
+	ctx := context.Background()
+
+	// Creating the table twice must be a no-op the second time.
+	for i := 0; i < 2; i++ {
+		if err := w.EnsureTableExists(ctx); err != nil {
+			t.Fatalf("EnsureTableExists() call %d error = %v", i+1, err)
+		}
+	}
+
+	meta, err := w.client.Dataset(w.datasetID).Table(w.tableID).Metadata(ctx)
+	if err != nil {
+		t.Fatalf("table metadata: %v", err)
+	}
+	want, err := bigquery.SchemaFromJSON(getSchemaJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, wantCols := schemaColumns(meta.Schema), schemaColumns(want); !reflect.DeepEqual(got, wantCols) {
+		t.Errorf("table schema = %v, want %v", got, wantCols)
+	}
+
+	dt := civil.Date{Year: 2025, Month: 8, Day: 1}
+	snapshot := time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC)
+	records := []*VideoStatsRecord{
+		{
+			Dt: dt, ChannelID: "UC1", VideoID: "v1", Title: "First", ChannelName: "One",
+			Tags: []string{"ai", "news"}, Views: 1000, Likes: 50, Comments: 5,
+			PublishedAt: snapshot.Add(-10 * time.Hour), CreatedAt: snapshot, SnapshotTs: snapshot,
+			DurationSec: 300, Status: "public", CategoryID: "28", DefaultLanguage: "ja",
+		},
+		{
+			Dt: dt, ChannelID: "UC1", VideoID: "v2", Title: "Second", ChannelName: "One",
+			IsShort: true, PublishedAt: snapshot.Add(-time.Hour), CreatedAt: snapshot, SnapshotTs: snapshot,
+		},
+	}
+	if err := w.InsertVideoStats(ctx, records); err != nil {
+		t.Fatalf("InsertVideoStats() error = %v", err)
+	}
+
+	q := w.client.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY video_id", w.tableRef()))
+	rows, err := readAll[VideoStatsRecord](ctx, q, "video stats")
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("read back %d rows, want 2", len(rows))
+	}
+
+	v1, v2 := rows[0], rows[1]
+	if v1.VideoID != "v1" || v1.Dt != dt || v1.Views != 1000 || v1.Likes != 50 || v1.DurationSec != 300 ||
+		v1.CategoryID != "28" || v1.DefaultLanguage != "ja" || !reflect.DeepEqual(v1.Tags, []string{"ai", "news"}) {
+		t.Errorf("v1 = %+v", v1)
+	}
+	if !v1.SnapshotTs.Equal(snapshot) || !v1.PublishedAt.Equal(records[0].PublishedAt) {
+		t.Errorf("v1 timestamps = %s, %s", v1.SnapshotTs, v1.PublishedAt)
+	}
+	// Derived fields are computed on insert: 50/1000 likes per view and
+	// 1000 views over 10 hours.
+	if !v1.LikeRate.Valid || v1.LikeRate.Float64 != 0.05 || !v1.ViewsPerHour.Valid || v1.ViewsPerHour.Float64 != 100 {
+		t.Errorf("v1 derived = %v, %v", v1.LikeRate, v1.ViewsPerHour)
+	}
+	if v2.VideoID != "v2" || !v2.IsShort || len(v2.Tags) != 0 || v2.LikeRate.Valid {
+		t.Errorf("v2 = %+v, want a Short without tags or like rate", v2)
+	}
+}
+
+// schemaColumns flattens a schema to "name TYPE MODE" strings.
+func schemaColumns(schema bigquery.Schema) []string {
+	cols := make([]string, 0, len(schema))
+	for _, f := range schema {
+		mode := "NULLABLE"
+		switch {
+		case f.Repeated:
+			mode = "REPEATED"
+		case f.Required:
+			mode = "REQUIRED"
+		}
+		cols = append(cols, fmt.Sprintf("%s %s %s", f.Name, f.Type, mode))
+	}
+	return cols
+}
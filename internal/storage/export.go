@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+)
+
+// Export formats
+const (
+	ExportCSV     = "csv"
+	ExportJSONL   = "jsonl"
+	ExportParquet = "parquet"
+)
+
+// exportFormats maps each export format to the EXPORT DATA format option.
+var exportFormats = map[string]string{
+	ExportCSV:     "CSV",
+	ExportJSONL:   "JSON",
+	ExportParquet: "PARQUET",
+}
+
+// tableIDPattern matches the table names an export may interpolate into SQL.
+var tableIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExportOptions selects what an export writes.
+type ExportOptions struct {
+	// Format is one of the Export* constants.
+	Format string
+	// Table is a dt-partitioned table of the writer's dataset; empty
+	// exports the video trends table.
+	Table string
+	// From and To bound dt, both inclusive.
+	From civil.Date
+	To   civil.Date
+}
+
+func (o ExportOptions) validate() error {
+	if _, ok := exportFormats[o.Format]; !ok {
+		return fmt.Errorf("unsupported export format %q (must be %s, %s or %s)", o.Format, ExportCSV, ExportJSONL, ExportParquet)
+	}
+	if o.Table != "" && !tableIDPattern.MatchString(o.Table) {
+		return fmt.Errorf("invalid table name %q", o.Table)
+	}
+	if o.To.Before(o.From) {
+		return fmt.Errorf("export range ends (%s) before it starts (%s)", o.To, o.From)
+	}
+	return nil
+}
+
+func (w *BigQueryWriter) exportTableRef(o ExportOptions) string {
+	if o.Table == "" {
+		return w.tableRef()
+	}
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, o.Table)
+}
+
+// exportURI returns the EXPORT DATA URI for out: out itself when it has the
+// required * wildcard, otherwise files named after the table under out.
+func exportURI(out, table, format string) string {
+	if strings.Contains(out, "*") {
+		return out
+	}
+	ext := format
+	if format == ExportJSONL {
+		ext = "jsonl"
+	}
+	return strings.TrimSuffix(out, "/") + "/" + path.Base(table) + "-*." + ext
+}
+
+// ExportToGCS writes the rows of the date range to gs:// files with an
+// EXPORT DATA statement, so BigQuery writes them directly and large exports
+// never pass through this process. It returns the URI pattern written.
+// Files are overwritten, which makes re-running an export safe.
+func (w *BigQueryWriter) ExportToGCS(ctx context.Context, out string, o ExportOptions) (string, error) {
+	if err := o.validate(); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(out, gcsSinkScheme) || strings.ContainsAny(out, "'\\\n") {
+		return "", fmt.Errorf("export destination must be gs://<bucket>/<path>: %q", out)
+	}
+	table := o.Table
+	if table == "" {
+		table = w.tableID
+	}
+	uri := exportURI(out, table, o.Format)
+
+	options := fmt.Sprintf("uri='%s', format='%s', overwrite=true", uri, exportFormats[o.Format])
+	if o.Format == ExportCSV {
+		options += ", header=true"
+	}
+	q := w.client.Query(fmt.Sprintf(`
+		EXPORT DATA OPTIONS(%s) AS
+		SELECT * FROM %s
+		WHERE dt BETWEEN @from AND @to`,
+		options, w.exportTableRef(o)))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "from", Value: o.From},
+		{Name: "to", Value: o.To},
+	}
+	job, err := q.Run(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start export: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed waiting for export job %s: %w", job.ID(), err)
+	}
+	if err := status.Err(); err != nil {
+		return "", fmt.Errorf("export job %s failed: %w", job.ID(), err)
+	}
+	return uri, nil
+}
+
+// ExportRows streams the rows of the date range to out as CSV or JSONL, in
+// dt order, and returns how many were written. Parquet needs ExportToGCS.
+// In CSV, repeated and record columns are written as JSON.
+func (w *BigQueryWriter) ExportRows(ctx context.Context, out io.Writer, o ExportOptions) (int64, error) {
+	if err := o.validate(); err != nil {
+		return 0, err
+	}
+	if o.Format == ExportParquet {
+		return 0, fmt.Errorf("parquet exports are written by BigQuery and need a gs:// destination")
+	}
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT * FROM %s
+		WHERE dt BETWEEN @from AND @to
+		ORDER BY dt`,
+		w.exportTableRef(o)))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "from", Value: o.From},
+		{Name: "to", Value: o.To},
+	}
+	it, err := q.Read(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query export rows: %w", err)
+	}
+
+	bw := bufio.NewWriter(out)
+	var csvw *csv.Writer
+	var n int64
+	for {
+		var row []bigquery.Value
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return n, fmt.Errorf("failed to read export rows: %w", err)
+		}
+		switch o.Format {
+		case ExportCSV:
+			if csvw == nil {
+				csvw = csv.NewWriter(bw)
+				header := make([]string, len(it.Schema))
+				for i, f := range it.Schema {
+					header[i] = f.Name
+				}
+				if err := csvw.Write(header); err != nil {
+					return n, err
+				}
+			}
+			if err := csvw.Write(csvRecord(it.Schema, row)); err != nil {
+				return n, err
+			}
+		case ExportJSONL:
+			b, err := json.Marshal(rowObject(it.Schema, row))
+			if err != nil {
+				return n, fmt.Errorf("failed to encode export row: %w", err)
+			}
+			bw.Write(b)
+			bw.WriteByte('\n')
+		}
+		n++
+	}
+	if csvw != nil {
+		csvw.Flush()
+		if err := csvw.Error(); err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// csvRecord formats a row for CSV: NULL as an empty cell, nested values as
+// JSON.
+func csvRecord(schema bigquery.Schema, row []bigquery.Value) []string {
+	record := make([]string, len(row))
+	for i, v := range row {
+		f := schema[i]
+		switch {
+		case v == nil:
+		case f.Repeated || f.Type == bigquery.RecordFieldType:
+			b, _ := json.Marshal(jsonValue(f, v))
+			record[i] = string(b)
+		default:
+			record[i] = csvScalar(v)
+		}
+	}
+	return record
+}
+
+// csvScalar formats a scalar the way pandas and DuckDB parse it.
+func csvScalar(v bigquery.Value) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// orderedObject is a JSON object that keeps the schema's column order.
+type orderedObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// rowObject converts a row to a JSON object keyed by column name.
+func rowObject(schema bigquery.Schema, row []bigquery.Value) orderedObject {
+	obj := orderedObject{keys: make([]string, len(schema)), values: make([]interface{}, len(schema))}
+	for i, f := range schema {
+		obj.keys[i] = f.Name
+		if i < len(row) {
+			obj.values[i] = jsonValue(f, row[i])
+		}
+	}
+	return obj
+}
+
+// jsonValue converts a value of field f for JSON: records, which the query
+// iterator returns as positional values, become objects.
+func jsonValue(f *bigquery.FieldSchema, v bigquery.Value) interface{} {
+	if v == nil {
+		return nil
+	}
+	if f.Repeated {
+		items, ok := v.([]bigquery.Value)
+		if !ok {
+			return v
+		}
+		elem := *f
+		elem.Repeated = false
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = jsonValue(&elem, item)
+		}
+		return out
+	}
+	if f.Type == bigquery.RecordFieldType {
+		if fields, ok := v.([]bigquery.Value); ok {
+			return rowObject(f.Schema, fields)
+		}
+	}
+	return v
+}
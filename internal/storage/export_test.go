@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+func TestExportURI(t *testing.T) {
+	tests := []struct {
+		out, format, want string
+	}{
+		{"gs://bucket/path", ExportParquet, "gs://bucket/path/video_trends-*.parquet"},
+		{"gs://bucket/path/", ExportJSONL, "gs://bucket/path/video_trends-*.jsonl"},
+		{"gs://bucket/path/part-*.csv", ExportCSV, "gs://bucket/path/part-*.csv"},
+	}
+	for _, tt := range tests {
+		if got := exportURI(tt.out, "video_trends", tt.format); got != tt.want {
+			t.Errorf("exportURI(%q, %q) = %q, want %q", tt.out, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestExportRowEncoding(t *testing.T) {
+	schema := bigquery.Schema{
+		{Name: "dt", Type: bigquery.DateFieldType},
+		{Name: "video_id", Type: bigquery.StringFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+		{Name: "views", Type: bigquery.IntegerFieldType},
+		{Name: "created_at", Type: bigquery.TimestampFieldType},
+		{Name: "content_details", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "duration", Type: bigquery.StringFieldType},
+			{Name: "definition", Type: bigquery.StringFieldType},
+		}},
+	}
+	row := []bigquery.Value{
+		civil.Date{Year: 2025, Month: 1, Day: 2},
+		"v1",
+		[]bigquery.Value{"go", "bigquery"},
+		nil,
+		time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		[]bigquery.Value{"PT1M", "hd"},
+	}
+
+	got := csvRecord(schema, row)
+	want := []string{"2025-01-02", "v1", `["go","bigquery"]`, "", "2025-01-02T03:04:05Z", `{"duration":"PT1M","definition":"hd"}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("csvRecord() = %q, want %q", got, want)
+	}
+
+	b, err := json.Marshal(rowObject(schema, row))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"dt":"2025-01-02","video_id":"v1","tags":["go","bigquery"],"views":null,"created_at":"2025-01-02T03:04:05Z","content_details":{"duration":"PT1M","definition":"hd"}}`
+	if string(b) != wantJSON {
+		t.Errorf("rowObject() = %s\nwant %s", b, wantJSON)
+	}
+}
+
+func TestExportOptionsValidate(t *testing.T) {
+	from := civil.Date{Year: 2025, Month: 1, Day: 1}
+	for _, o := range []ExportOptions{
+		{Format: "xlsx", From: from, To: from},
+		{Format: ExportCSV, Table: "x`; DROP TABLE y", From: from, To: from},
+		{Format: ExportCSV, From: from, To: from.AddDays(-1)},
+	} {
+		if err := o.validate(); err == nil {
+			t.Errorf("%+v: validate() succeeded, want error", o)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+)
+
+const firestoreSinkScheme = "firestore://"
+
+// maxWritesPerCommit is the Firestore limit on writes per commit.
+const maxWritesPerCommit = 500
+
+// firestoreDailyCollection is the subcollection of a video document holding
+// one document of stats per snapshot date.
+const firestoreDailyCollection = "daily"
+
+// firestoreDailyFields are the columns kept in the daily stats documents.
+var firestoreDailyFields = []string{"dt", "views", "likes", "comments", "snapshot_ts"}
+
+// FirestoreSink keeps a serving-friendly copy of the video trends table in
+// Firestore, for small deployments and web UIs that read documents directly
+// instead of querying BigQuery:
+//
+//	<collection>/<video_id>                the latest snapshot of the video
+//	<collection>/<video_id>/daily/<dt>     views, likes and comments per day
+//
+// Rows of the other tables are not written. A tombstone only updates the
+// columns it has, such as status, of the video document.
+type FirestoreSink struct {
+	service    *firestore.Service
+	uri        string
+	database   string
+	collection string
+}
+
+// NewFirestoreSink creates a sink for
+// firestore://<collection>[?database=<database>], in projectID's (default)
+// database unless another is given. If FIRESTORE_EMULATOR_HOST is set, the
+// emulator is used without authentication.
+func NewFirestoreSink(ctx context.Context, projectID, uri string, opts ...option.ClientOption) (*FirestoreSink, error) {
+	collection, query, _ := strings.Cut(strings.TrimPrefix(uri, firestoreSinkScheme), "?")
+	if collection == "" || strings.Contains(collection, "/") {
+		return nil, fmt.Errorf("Firestore sink must be firestore://<collection>: %q", uri)
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Firestore sink options %q: %w", query, err)
+	}
+	database := "(default)"
+	for key := range params {
+		if key != "database" {
+			return nil, fmt.Errorf("unknown Firestore sink option %q", key)
+		}
+		database = params.Get(key)
+	}
+	if host := os.Getenv("FIRESTORE_EMULATOR_HOST"); host != "" {
+		opts = append(opts, option.WithEndpoint("http://"+host+"/"), option.WithoutAuthentication())
+	}
+	svc, err := firestore.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("firestore.NewService: %w", err)
+	}
+	return &FirestoreSink{
+		service:    svc,
+		uri:        uri,
+		database:   fmt.Sprintf("projects/%s/databases/%s", projectID, database),
+		collection: collection,
+	}, nil
+}
+
+// Name returns the sink URI.
+func (s *FirestoreSink) Name() string {
+	return s.uri
+}
+
+// WriteRows upserts the video documents and their daily stats, in commits
+// of at most 500 writes. Each commit is atomic, but a failure leaves the
+// earlier commits in place.
+func (s *FirestoreSink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	switch table {
+	case MetadataChangesTableID, VideoCommentsTableID, KeywordTrendsTableID:
+		return nil
+	}
+	types := firestoreFieldTypes(table)
+	var writes []*firestore.Write
+	for _, row := range rows {
+		w, err := s.rowWrites(row, types)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, w...)
+	}
+	for i := 0; i < len(writes); i += maxWritesPerCommit {
+		req := &firestore.CommitRequest{Writes: writes[i:min(i+maxWritesPerCommit, len(writes))]}
+		if _, err := s.service.Projects.Databases.Documents.Commit(s.database, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to write %s rows to Firestore %s: %w", table, s.collection, err)
+		}
+	}
+	return nil
+}
+
+// rowWrites returns the writes for one row: an update of the video document
+// limited to the row's columns, and for stats rows the daily document.
+func (s *FirestoreSink) rowWrites(row json.RawMessage, types map[string]bigquery.FieldType) ([]*firestore.Write, error) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(string(row)))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to decode row for Firestore: %w", err)
+	}
+	videoID, _ := fields["video_id"].(string)
+	if videoID == "" {
+		return nil, nil
+	}
+	doc := fmt.Sprintf("%s/documents/%s/%s", s.database, s.collection, videoID)
+
+	latest := &firestore.Document{Name: doc, Fields: map[string]firestore.Value{}}
+	mask := &firestore.DocumentMask{}
+	for name, v := range fields {
+		latest.Fields[name] = firestoreValue(v, types[name])
+		mask.FieldPaths = append(mask.FieldPaths, name)
+	}
+	writes := []*firestore.Write{{Update: latest, UpdateMask: mask}}
+
+	dt, _ := fields["dt"].(string)
+	if _, ok := fields["views"]; ok && dt != "" {
+		daily := &firestore.Document{
+			Name:   fmt.Sprintf("%s/%s/%s", doc, firestoreDailyCollection, dt),
+			Fields: map[string]firestore.Value{},
+		}
+		for _, name := range firestoreDailyFields {
+			if v, ok := fields[name]; ok {
+				daily.Fields[name] = firestoreValue(v, types[name])
+			}
+		}
+		writes = append(writes, &firestore.Write{Update: daily})
+	}
+	return writes, nil
+}
+
+// firestoreFieldTypes returns the column types of the rows written to
+// table, so that timestamps are stored as Firestore timestamps.
+func firestoreFieldTypes(table string) map[string]bigquery.FieldType {
+	types := map[string]bigquery.FieldType{}
+	if schema, err := bigquery.SchemaFromJSON(tableSchemaJSON(table)); err == nil {
+		for _, f := range schema {
+			types[f.Name] = f.Type
+		}
+	}
+	return types
+}
+
+// firestoreValue converts a JSON value decoded with UseNumber. Zero values
+// must be sent explicitly, since the client omits them otherwise.
+func firestoreValue(v interface{}, t bigquery.FieldType) firestore.Value {
+	switch v := v.(type) {
+	case nil:
+		return firestore.Value{NullValue: "NULL_VALUE"}
+	case bool:
+		return firestore.Value{BooleanValue: v, ForceSendFields: []string{"BooleanValue"}}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return firestore.Value{IntegerValue: i, ForceSendFields: []string{"IntegerValue"}}
+		}
+		f, _ := v.Float64()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return firestore.Value{NullValue: "NULL_VALUE"}
+		}
+		return firestore.Value{DoubleValue: f, ForceSendFields: []string{"DoubleValue"}}
+	case string:
+		if t == bigquery.TimestampFieldType && v != "" {
+			return firestore.Value{TimestampValue: v}
+		}
+		return firestore.Value{StringValue: v, ForceSendFields: []string{"StringValue"}}
+	case []interface{}:
+		arr := &firestore.ArrayValue{}
+		for _, item := range v {
+			arr.Values = append(arr.Values, ptr(firestoreValue(item, "")))
+		}
+		return firestore.Value{ArrayValue: arr, ForceSendFields: []string{"ArrayValue"}}
+	case map[string]interface{}:
+		m := &firestore.MapValue{Fields: map[string]firestore.Value{}}
+		for name, item := range v {
+			m.Fields[name] = firestoreValue(item, "")
+		}
+		return firestore.Value{MapValue: m, ForceSendFields: []string{"MapValue"}}
+	default:
+		return firestore.Value{StringValue: fmt.Sprint(v), ForceSendFields: []string{"StringValue"}}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+)
+
+func TestFirestoreSink(t *testing.T) {
+	var commits []firestore.CommitRequest
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/projects/p/databases/(default)/documents:commit") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		var req firestore.CommitRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		commits = append(commits, req)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewFirestoreSink(context.Background(), "p", "firestore://videos", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []json.RawMessage{
+		[]byte(`{"dt":"2025-08-01","video_id":"v1","views":0,"is_short":false,"tags":["go"],"snapshot_ts":"2025-08-01T06:00:00Z"}`),
+		[]byte(`{"dt":"2025-08-02","channel_id":"UC1","video_id":"v2","status":"deleted"}`),
+	}
+	if err := s.WriteRows(context.Background(), "video_trends", rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteRows(context.Background(), KeywordTrendsTableID, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(commits))
+	}
+	writes := commits[0].Writes
+	if len(writes) != 3 {
+		t.Fatalf("got %d writes, want video and daily documents for v1 and the v2 document", len(writes))
+	}
+	latest := writes[0].Update
+	if !strings.HasSuffix(latest.Name, "/documents/videos/v1") {
+		t.Errorf("latest document = %s", latest.Name)
+	}
+	if !strings.Contains(body, `"views":{"integerValue":"0"}`) || !strings.Contains(body, `"is_short":{"booleanValue":false}`) {
+		t.Errorf("zero values were not sent explicitly: %s", body)
+	}
+	if latest.Fields["snapshot_ts"].TimestampValue != "2025-08-01T06:00:00Z" {
+		t.Errorf("snapshot_ts = %+v, want a timestamp", latest.Fields["snapshot_ts"])
+	}
+	if daily := writes[1].Update; !strings.HasSuffix(daily.Name, "/videos/v1/daily/2025-08-01") || len(daily.Fields) != 3 {
+		t.Errorf("daily document = %s with %d fields", daily.Name, len(daily.Fields))
+	}
+	mask := writes[2].UpdateMask.FieldPaths
+	sort.Strings(mask)
+	if strings.Join(mask, ",") != "channel_id,dt,status,video_id" {
+		t.Errorf("tombstone mask = %v, want only its columns", mask)
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// VideoGain is a video's latest snapshot on a date with the views gained
+// since its latest snapshot from an earlier date.
+type VideoGain struct {
+	ChannelID   string    `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string    `bigquery:"channel_name" json:"channel_name"`
+	VideoID     string    `bigquery:"video_id" json:"video_id"`
+	Title       string    `bigquery:"title" json:"title"`
+	IsShort     bool      `bigquery:"is_short" json:"is_short"`
+	Views       int64     `bigquery:"views" json:"views"`
+	ViewsGained int64     `bigquery:"views_gained" json:"views_gained"`
+	PublishedAt time.Time `bigquery:"published_at" json:"published_at"`
+}
+
+// TopGainers returns up to limit videos with the most views gained on date,
+// optionally only Shorts. Videos first seen on date count all their views as
+// gained. The baseline is looked up at most 7 days back.
+func (w *BigQueryWriter) TopGainers(ctx context.Context, date civil.Date, shortsOnly bool, limit int) ([]VideoGain, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH cur AS (
+			SELECT channel_id, IFNULL(channel_name, '') AS channel_name, video_id,
+				IFNULL(title, '') AS title, IFNULL(is_short, FALSE) AS is_short, views,
+				IFNULL(published_at, TIMESTAMP_SECONDS(0)) AS published_at
+			FROM %[1]s
+			WHERE dt = @date AND views IS NOT NULL
+				AND (NOT @shorts_only OR IFNULL(is_short, FALSE))
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		prev AS (
+			SELECT video_id, views AS prev_views
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND DATE_SUB(@date, INTERVAL 1 DAY)
+				AND views IS NOT NULL
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		)
+		SELECT cur.*, GREATEST(cur.views - IFNULL(prev.prev_views, 0), 0) AS views_gained
+		FROM cur
+		LEFT JOIN prev USING (video_id)
+		ORDER BY views_gained DESC, views DESC
+		LIMIT @limit`, w.tableRef(), trendBaselineDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+		{Name: "shorts_only", Value: shortsOnly},
+		{Name: "limit", Value: limit},
+	}
+	return readAll[VideoGain](ctx, q, "top gainers")
+}
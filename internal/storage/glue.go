@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// GlueCatalog registers the tables written by an S3Sink in the AWS Glue
+// Data Catalog, so Athena can query them. Requests are made directly to
+// the Glue JSON API.
+type GlueCatalog struct {
+	database    string
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewGlueCatalog returns a catalog registering tables in database, in the
+// region and with the credentials of awsCfg.
+func NewGlueCatalog(awsCfg aws.Config, database string) *GlueCatalog {
+	return &GlueCatalog{
+		database:    database,
+		region:      awsCfg.Region,
+		endpoint:    fmt.Sprintf("https://glue.%s.amazonaws.com/", awsCfg.Region),
+		credentials: awsCfg.Credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// glueColumn is a column of a Glue table.
+type glueColumn struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"`
+}
+
+// EnsureTable creates table as an external table of gzipped JSONL under
+// location, partitioned by dt with partition projection. An existing table
+// is left unchanged.
+func (g *GlueCatalog) EnsureTable(ctx context.Context, table, location string, schemaJSON []byte) error {
+	schema, err := bigquery.SchemaFromJSON(schemaJSON)
+	if err != nil {
+		return fmt.Errorf("failed to load schema for %s: %w", table, err)
+	}
+	var columns []glueColumn
+	for _, f := range schema {
+		if f.Name == "dt" {
+			// The partition key; Athena rejects it as a column too.
+			continue
+		}
+		columns = append(columns, glueColumn{Name: f.Name, Type: hiveType(f)})
+	}
+	input := map[string]interface{}{
+		"DatabaseName": g.database,
+		"TableInput": map[string]interface{}{
+			"Name":      table,
+			"TableType": "EXTERNAL_TABLE",
+			"Parameters": map[string]string{
+				"EXTERNAL":                    "TRUE",
+				"classification":              "json",
+				"compressionType":             "gzip",
+				"projection.enabled":          "true",
+				"projection.dt.type":          "date",
+				"projection.dt.format":        "yyyy-MM-dd",
+				"projection.dt.range":         "2020-01-01,NOW",
+				"projection.dt.interval":      "1",
+				"projection.dt.interval.unit": "DAYS",
+				"storage.location.template":   location + "dt=${dt}/",
+			},
+			"PartitionKeys": []glueColumn{{Name: "dt", Type: "string"}},
+			"StorageDescriptor": map[string]interface{}{
+				"Columns":      columns,
+				"Location":     location,
+				"InputFormat":  "org.apache.hadoop.mapred.TextInputFormat",
+				"OutputFormat": "org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat",
+				"SerdeInfo": map[string]interface{}{
+					"SerializationLibrary": "org.openx.data.jsonserde.JsonSerDe",
+				},
+			},
+		},
+	}
+	err = g.call(ctx, "CreateTable", input)
+	if err != nil && strings.Contains(err.Error(), "AlreadyExistsException") {
+		return nil
+	}
+	return err
+}
+
+// call makes a SigV4 signed request to the Glue JSON API.
+func (g *GlueCatalog) call(ctx context.Context, operation string, input interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSGlue."+operation)
+
+	creds, err := g.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := g.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "glue", g.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign Glue request: %w", err)
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("glue %s: %w", operation, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("glue %s: %s: %s", operation, resp.Status, msg)
+	}
+	return nil
+}
+
+// hiveType returns the Athena (Hive DDL) type of a BigQuery column.
+func hiveType(f *bigquery.FieldSchema) string {
+	var t string
+	switch f.Type {
+	case bigquery.IntegerFieldType:
+		t = "bigint"
+	case bigquery.FloatFieldType:
+		t = "double"
+	case bigquery.BooleanFieldType:
+		t = "boolean"
+	case bigquery.DateFieldType:
+		t = "date"
+	case bigquery.NumericFieldType:
+		t = "decimal(38,9)"
+	case bigquery.RecordFieldType:
+		fields := make([]string, len(f.Schema))
+		for i, sub := range f.Schema {
+			fields[i] = sub.Name + ":" + hiveType(sub)
+		}
+		t = "struct<" + strings.Join(fields, ",") + ">"
+	default:
+		// Including TIMESTAMP: rows hold RFC 3339 with a zone offset, which
+		// the JSON SerDe cannot parse; query with from_iso8601_timestamp.
+		t = "string"
+	}
+	if f.Repeated {
+		return "array<" + t + ">"
+	}
+	return t
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	"google.golang.org/api/googleapi"
+)
+
+// Retry settings for rows that fail individually within a streaming insert.
+const (
+	insertMaxAttempts  = 3
+	insertInitialDelay = time.Second
+)
+
+// reasonInvalid marks rows BigQuery rejected because of their content; those
+// fail again on retry. Rows stopped only because another row in the same
+// request was invalid are reported with reason "stopped" and are retried.
+const reasonInvalid = "invalid"
+
+// PartialInsertError reports rows that could not be inserted after retries.
+// The remaining rows of the batch were inserted.
+type PartialInsertError struct {
+	Table   string
+	Total   int
+	Failed  int
+	Reasons map[string]int // failed row count by BigQuery error reason
+
+	// rows are the rows that failed, with their reasons.
+	rows []failedRow
+}
+
+// failedRow is a row that could not be inserted and the reason why.
+type failedRow struct {
+	saver  *bigquery.StructSaver
+	reason string
+}
+
+func (e *PartialInsertError) Error() string {
+	reasons := make([]string, 0, len(e.Reasons))
+	for r, n := range e.Reasons {
+		reasons = append(reasons, fmt.Sprintf("%s=%d", r, n))
+	}
+	sort.Strings(reasons)
+	return fmt.Sprintf("%d of %d rows failed to insert into %s (%s)", e.Failed, e.Total, e.Table, strings.Join(reasons, ", "))
+}
+
+// putFunc writes a batch of rows, returning a bigquery.PutMultiError when only
+// some of them failed.
+type putFunc func(ctx context.Context, rows []*bigquery.StructSaver) error
+
+// insertRows streams rows into a table. Requests that fail as a whole with a
+// temporary error are retried, and when BigQuery rejects individual rows
+// only those are retried, both with exponential backoff; rows are
+// deduplicated by their insert IDs, so rows that did succeed are never
+// written twice.
+func (w *BigQueryWriter) insertRows(ctx context.Context, tableID string, rows []*bigquery.StructSaver) error {
+	inserter := w.client.Dataset(w.datasetID).Table(tableID).Inserter()
+	config := w.insertRetryConfig()
+	err := retryFailedRows(ctx, tableID, rows, func(ctx context.Context, rows []*bigquery.StructSaver) error {
+		return putWithRetry(ctx, config, func(ctx context.Context) error {
+			return inserter.Put(ctx, rows)
+		})
+	}, insertInitialDelay)
+	if tableID == w.tableID && stderrors.Is(err, errors.ErrNotFound) {
+		w.forgetEnsuredTable()
+	}
+
+	if w.metrics != nil {
+		status := "success"
+		var partial *PartialInsertError
+		if stderrors.As(err, &partial) {
+			status = "partial"
+			for reason, n := range partial.Reasons {
+				w.metrics.RecordFailedRows(w.datasetID, tableID, reason, n)
+			}
+		} else if err != nil {
+			status = "error"
+		}
+		w.metrics.BigQueryInserts.WithLabelValues(w.datasetID, tableID, status).Inc()
+	}
+	return err
+}
+
+// insertRetryConfig returns the retry configuration for failed insert
+// requests, counting retries under the "bigquery" component.
+func (w *BigQueryWriter) insertRetryConfig() retry.Config {
+	return retry.WithMetrics(retry.DefaultConfig(), w.metrics, "bigquery")
+}
+
+// putWithRetry calls put until it succeeds or fails with an error that is
+// not temporary. Per-row failures (bigquery.PutMultiError) are returned
+// without retrying: retryFailedRows re-sends only the failed rows.
+func putWithRetry(ctx context.Context, config retry.Config, put func(ctx context.Context) error) error {
+	var rowErrs error
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		rowErrs = nil
+		err := put(ctx)
+		var multi bigquery.PutMultiError
+		if stderrors.As(err, &multi) {
+			rowErrs = err
+			return nil
+		}
+		return classifyInsertError(err)
+	}, config)
+	if err != nil {
+		return err
+	}
+	return rowErrs
+}
+
+// classifyInsertError wraps a failed insert request in an AppError that is
+// Temporary for server errors (500, 503 backendError), rate limits
+// (rateLimitExceeded) and dropped connections, and a non-retriable error
+// otherwise, such as an invalid request or a row that does not match the
+// schema.
+func classifyInsertError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *googleapi.Error
+	if stderrors.As(err, &apiErr) {
+		return errors.FromGoogleAPI("BigQuery insert failed", err)
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) || stderrors.Is(err, io.ErrUnexpectedEOF) || stderrors.Is(err, io.EOF) {
+		return errors.Temporary("BigQuery insert failed", err)
+	}
+	return errors.Storage("BigQuery insert failed", err)
+}
+
+// retryFailedRows calls put and re-sends the rows reported as failed until
+// they succeed, are rejected as invalid, or the attempts run out. Errors that
+// are not per-row failures are returned as is.
+func retryFailedRows(ctx context.Context, tableID string, rows []*bigquery.StructSaver, put putFunc, delay time.Duration) error {
+	log := logger.FromContext(ctx)
+	pending := rows
+	reasons := make(map[string]int)
+	var failed []failedRow
+	fail := func(row *bigquery.StructSaver, reason string) {
+		reasons[reason]++
+		failed = append(failed, failedRow{saver: row, reason: reason})
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		err := put(ctx, pending)
+		if err == nil {
+			break
+		}
+		var multi bigquery.PutMultiError
+		if !stderrors.As(err, &multi) {
+			if len(failed) == 0 && len(pending) == len(rows) {
+				return err
+			}
+			// Earlier attempts already landed some rows; count the rest as failed.
+			for _, row := range pending {
+				fail(row, "error")
+			}
+			break
+		}
+
+		var retry []*bigquery.StructSaver
+		for _, rowErr := range multi {
+			if rowErr.RowIndex < 0 || rowErr.RowIndex >= len(pending) {
+				continue
+			}
+			reason, message := rowErrorReason(rowErr)
+			log.Warning("BigQuery rejected row", nil, map[string]string{
+				"table":     tableID,
+				"insert_id": pending[rowErr.RowIndex].InsertID,
+				"reason":    reason,
+				"detail":    message,
+				"attempt":   fmt.Sprintf("%d", attempt),
+			})
+			if reason == reasonInvalid || attempt == insertMaxAttempts {
+				fail(pending[rowErr.RowIndex], reason)
+				continue
+			}
+			retry = append(retry, pending[rowErr.RowIndex])
+		}
+		pending = retry
+
+		if len(pending) > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				for _, row := range pending {
+					fail(row, "canceled")
+				}
+				pending = nil
+			}
+			delay *= 2
+		}
+	}
+
+	if len(failed) > 0 {
+		return &PartialInsertError{Table: tableID, Total: len(rows), Failed: len(failed), Reasons: reasons, rows: failed}
+	}
+	return nil
+}
+
+// rowErrorReason returns the reason and message of the first error of a row.
+func rowErrorReason(rowErr bigquery.RowInsertionError) (string, string) {
+	for _, err := range rowErr.Errors {
+		var bqErr *bigquery.Error
+		if stderrors.As(err, &bqErr) {
+			return bqErr.Reason, bqErr.Message
+		}
+	}
+	return "unknown", rowErr.Errors.Error()
+}
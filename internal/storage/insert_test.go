@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/api/googleapi"
+)
+
+func savers(ids ...string) []*bigquery.StructSaver {
+	rows := make([]*bigquery.StructSaver, len(ids))
+	for i, id := range ids {
+		rows[i] = &bigquery.StructSaver{InsertID: id}
+	}
+	return rows
+}
+
+func rowError(index int, reason string) bigquery.RowInsertionError {
+	return bigquery.RowInsertionError{
+		RowIndex: index,
+		Errors:   bigquery.MultiError{&bigquery.Error{Reason: reason, Message: reason}},
+	}
+}
+
+func TestRetryFailedRows_RetriesOnlyFailedRows(t *testing.T) {
+	var calls [][]string
+	put := func(ctx context.Context, rows []*bigquery.StructSaver) error {
+		var ids []string
+		for _, r := range rows {
+			ids = append(ids, r.InsertID)
+		}
+		calls = append(calls, ids)
+		if len(calls) == 1 {
+			// b is invalid; c was stopped because of it
+			return bigquery.PutMultiError{rowError(1, "invalid"), rowError(2, "stopped")}
+		}
+		return nil
+	}
+
+	err := retryFailedRows(context.Background(), "t", savers("a", "b", "c"), put, 0)
+
+	var partial *PartialInsertError
+	if !errors.As(err, &partial) {
+		t.Fatalf("retryFailedRows() error = %v, want PartialInsertError", err)
+	}
+	if partial.Failed != 1 || partial.Total != 3 || partial.Reasons["invalid"] != 1 {
+		t.Errorf("partial = %+v, want 1 invalid row of 3", partial)
+	}
+	if len(calls) != 2 || len(calls[1]) != 1 || calls[1][0] != "c" {
+		t.Errorf("put calls = %v, want retry of only the stopped row", calls)
+	}
+}
+
+func TestRetryFailedRows_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	put := func(ctx context.Context, rows []*bigquery.StructSaver) error {
+		attempts++
+		return bigquery.PutMultiError{rowError(0, "backendError")}
+	}
+
+	err := retryFailedRows(context.Background(), "t", savers("a", "b"), put, 0)
+
+	var partial *PartialInsertError
+	if !errors.As(err, &partial) || partial.Failed != 1 || partial.Reasons["backendError"] != 1 {
+		t.Fatalf("retryFailedRows() error = %v, want one failed backendError row", err)
+	}
+	if attempts != insertMaxAttempts {
+		t.Errorf("put called %d times, want %d", attempts, insertMaxAttempts)
+	}
+}
+
+func TestRetryFailedRows_RequestError(t *testing.T) {
+	want := errors.New("connection reset")
+	put := func(ctx context.Context, rows []*bigquery.StructSaver) error { return want }
+
+	if err := retryFailedRows(context.Background(), "t", savers("a"), put, 0); !errors.Is(err, want) {
+		t.Errorf("retryFailedRows() error = %v, want %v", err, want)
+	}
+}
+
+func TestPutWithRetry(t *testing.T) {
+	unavailable := &googleapi.Error{Code: http.StatusServiceUnavailable, Errors: []googleapi.ErrorItem{{Reason: "backendError"}}}
+	rateLimited := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}
+	invalid := &googleapi.Error{Code: http.StatusBadRequest, Errors: []googleapi.ErrorItem{{Reason: "invalid"}}}
+	rowErrs := bigquery.PutMultiError{rowError(0, "backendError")}
+
+	tests := []struct {
+		name         string
+		errs         []error // returned by successive attempts, then nil
+		wantAttempts int
+		wantErr      bool
+		wantRetries  float64
+	}{
+		{"success", nil, 1, false, 0},
+		{"server errors", []error{unavailable, &googleapi.Error{Code: http.StatusInternalServerError}}, 3, false, 2},
+		{"rate limit", []error{rateLimited}, 2, false, 1},
+		{"invalid request", []error{invalid}, 1, true, 0},
+		{"schema mismatch", []error{errors.New("bigquery: schema field views of type INTEGER is not assignable")}, 1, true, 0},
+		{"row errors", []error{rowErrs}, 1, true, 0},
+		{"persistent outage", []error{unavailable, unavailable, unavailable}, 3, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.NewMetrics()
+			config := retry.WithMetrics(retry.Config{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2}, m, "bigquery")
+			attempts := 0
+			err := putWithRetry(context.Background(), config, func(ctx context.Context) error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			})
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+			// Row errors are left to retryFailedRows.
+			var multi bigquery.PutMultiError
+			if tt.name == "row errors" && !errors.As(err, &multi) {
+				t.Errorf("error = %v, want the PutMultiError", err)
+			}
+			var retries float64
+			for _, reason := range []string{"temporary", "storage", "api"} {
+				retries += testutil.ToFloat64(m.RetriesTotal.WithLabelValues("bigquery", reason))
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("retries recorded = %v, want %v", retries, tt.wantRetries)
+			}
+		})
+	}
+}
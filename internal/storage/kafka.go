@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const kafkaSinkScheme = "kafka://"
+
+// kafkaBalancers are the partitioners a Kafka sink can use. The hashing
+// ones keep every message of a video in the same partition, so consumers
+// see its stats in order; murmur2 matches the Java client's default.
+var kafkaBalancers = map[string]func() kafka.Balancer{
+	"hash":       func() kafka.Balancer { return &kafka.Hash{} },
+	"murmur2":    func() kafka.Balancer { return kafka.Murmur2Balancer{} },
+	"crc32":      func() kafka.Balancer { return kafka.CRC32Balancer{} },
+	"roundrobin": func() kafka.Balancer { return &kafka.RoundRobin{} },
+	"leastbytes": func() kafka.Balancer { return &kafka.LeastBytes{} },
+}
+
+// kafkaWriter is the part of kafka.Writer the sink uses.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaSink publishes each row as a JSON message keyed by its video_id,
+// with a "table" header, for existing streaming pipelines.
+type KafkaSink struct {
+	writer kafkaWriter
+	uri    string
+	tables map[string]bool
+}
+
+// kafkaSinkOptions is a parsed kafka:// URI.
+type kafkaSinkOptions struct {
+	brokers     []string
+	topic       string
+	partitioner string
+	tables      []string
+}
+
+// parseKafkaURI parses
+// kafka://<broker>[;<broker>...]/<topic>[?partitioner=<name>][&tables=<t1>;<t2>].
+// Lists use ";" because sinks are themselves a comma-separated list in
+// SINKS, and brokers are listed in the host part, which net/url does not
+// accept.
+func parseKafkaURI(uri string) (kafkaSinkOptions, error) {
+	rest, query, _ := strings.Cut(strings.TrimPrefix(uri, kafkaSinkScheme), "?")
+	brokers, topic, _ := strings.Cut(rest, "/")
+	o := kafkaSinkOptions{topic: strings.Trim(topic, "/"), partitioner: "murmur2"}
+	for _, b := range strings.Split(brokers, ";") {
+		if b = strings.TrimSpace(b); b != "" {
+			o.brokers = append(o.brokers, b)
+		}
+	}
+	if len(o.brokers) == 0 || o.topic == "" || strings.Contains(o.topic, "/") {
+		return o, fmt.Errorf("Kafka sink must be kafka://<broker>[;<broker>...]/<topic>: %q", uri)
+	}
+	// url.ParseQuery rejects the ";" of the lists.
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		key, v, _ := strings.Cut(param, "=")
+		v, err := url.QueryUnescape(v)
+		if err != nil {
+			return o, fmt.Errorf("invalid Kafka sink option %q: %w", param, err)
+		}
+		switch key {
+		case "partitioner":
+			if _, ok := kafkaBalancers[v]; !ok {
+				return o, fmt.Errorf("unsupported Kafka partitioner %q (must be hash, murmur2, crc32, roundrobin or leastbytes)", v)
+			}
+			o.partitioner = v
+		case "tables":
+			for _, t := range strings.Split(v, ";") {
+				if t = strings.TrimSpace(t); t != "" {
+					o.tables = append(o.tables, t)
+				}
+			}
+		default:
+			return o, fmt.Errorf("unknown Kafka sink option %q", key)
+		}
+	}
+	return o, nil
+}
+
+// NewKafkaSink creates a sink for a kafka:// URI (see parseKafkaURI). The
+// partitioner defaults to murmur2 on the video_id key; tables limits the
+// sink to rows of those tables, e.g. tables=video_trends for the video
+// stats only.
+func NewKafkaSink(uri string) (*KafkaSink, error) {
+	o, err := parseKafkaURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(o.brokers...),
+		Topic:        o.topic,
+		Balancer:     kafkaBalancers[o.partitioner](),
+		RequiredAcks: kafka.RequireAll,
+		// A run writes a batch at a time; don't wait for more.
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	return newKafkaSink(w, uri, o.tables), nil
+}
+
+func newKafkaSink(w kafkaWriter, uri string, tables []string) *KafkaSink {
+	s := &KafkaSink{writer: w, uri: uri}
+	if len(tables) > 0 {
+		s.tables = make(map[string]bool, len(tables))
+		for _, t := range tables {
+			s.tables[t] = true
+		}
+	}
+	return s
+}
+
+// Name returns the sink URI.
+func (s *KafkaSink) Name() string {
+	return s.uri
+}
+
+// WriteRows publishes one message per row and waits for every broker
+// acknowledgement. Rows without a video_id are published without a key.
+func (s *KafkaSink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	if len(rows) == 0 || (s.tables != nil && !s.tables[table]) {
+		return nil
+	}
+	headers := []kafka.Header{{Key: "table", Value: []byte(table)}}
+	msgs := make([]kafka.Message, len(rows))
+	for i, row := range rows {
+		msgs[i] = kafka.Message{Key: rowKey(row), Value: row, Headers: headers}
+	}
+	if err := s.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("failed to publish %s rows to %s: %w", table, s.uri, err)
+	}
+	return nil
+}
+
+// rowKey returns the video_id of an encoded row, or nil.
+func rowKey(row json.RawMessage) []byte {
+	var key struct {
+		VideoID string `json:"video_id"`
+	}
+	if json.Unmarshal(row, &key) != nil || key.VideoID == "" {
+		return nil
+	}
+	return []byte(key.VideoID)
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeKafkaWriter struct {
+	msgs []kafka.Message
+}
+
+func (f *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.msgs = append(f.msgs, msgs...)
+	return nil
+}
+
+func TestParseKafkaURI(t *testing.T) {
+	o, err := parseKafkaURI("kafka://b1:9092;b2:9092/stats?partitioner=roundrobin&tables=video_trends;video_tombstones")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := kafkaSinkOptions{
+		brokers:     []string{"b1:9092", "b2:9092"},
+		topic:       "stats",
+		partitioner: "roundrobin",
+		tables:      []string{"video_trends", "video_tombstones"},
+	}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("parseKafkaURI() = %+v, want %+v", o, want)
+	}
+
+	if o, err := parseKafkaURI("kafka://b1:9092/stats"); err != nil || o.partitioner != "murmur2" || o.tables != nil {
+		t.Errorf("parseKafkaURI() defaults = %+v, %v", o, err)
+	}
+
+	for _, bad := range []string{
+		"kafka://b1:9092",
+		"kafka:///stats",
+		"kafka://b1:9092/a/b",
+		"kafka://b1:9092/stats?partitioner=random",
+		"kafka://b1:9092/stats?acks=1",
+	} {
+		if _, err := parseKafkaURI(bad); err == nil {
+			t.Errorf("parseKafkaURI(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestKafkaSink(t *testing.T) {
+	w := &fakeKafkaWriter{}
+	s := newKafkaSink(w, "kafka://b1:9092/stats", []string{"video_trends"})
+	rows := []json.RawMessage{[]byte(`{"video_id":"v1","views":1}`), []byte(`{"channel_id":"c1"}`)}
+
+	if err := s.WriteRows(context.Background(), "keyword_trends", rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.msgs) != 0 {
+		t.Fatalf("published %d rows of a table not listed in tables", len(w.msgs))
+	}
+
+	if err := s.WriteRows(context.Background(), "video_trends", rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.msgs) != 2 {
+		t.Fatalf("published %d messages, want 2", len(w.msgs))
+	}
+	if string(w.msgs[0].Key) != "v1" || w.msgs[1].Key != nil {
+		t.Errorf("keys = %q, %q; want v1 and none", w.msgs[0].Key, w.msgs[1].Key)
+	}
+	if string(w.msgs[0].Value) != string(rows[0]) {
+		t.Errorf("value = %s, want the row", w.msgs[0].Value)
+	}
+	if h := w.msgs[0].Headers; len(h) != 1 || h[0].Key != "table" || string(h[0].Value) != "video_trends" {
+		t.Errorf("headers = %+v, want table=video_trends", h)
+	}
+}
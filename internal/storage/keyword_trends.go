@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// KeywordTrendsTableID is the table that stores top search results per keyword.
+const KeywordTrendsTableID = "keyword_trends"
+
+// KeywordTrendRecord is one search result of a tracked keyword at a snapshot.
+type KeywordTrendRecord struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	SnapshotTs  time.Time  `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	Keyword     string     `bigquery:"keyword" json:"keyword"`
+	Rank        int64      `bigquery:"rank" json:"rank"`
+	VideoID     string     `bigquery:"video_id" json:"video_id"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	Title       string     `bigquery:"title" json:"title"`
+	Views       int64      `bigquery:"views" json:"views"`
+	Likes       int64      `bigquery:"likes" json:"likes"`
+	Comments    int64      `bigquery:"comments" json:"comments"`
+	PublishedAt time.Time  `bigquery:"published_at" json:"published_at"`
+	CreatedAt   time.Time  `bigquery:"created_at" json:"created_at"`
+}
+
+func getKeywordTrendsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",           "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "snapshot_ts",  "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "keyword",      "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "rank",         "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "video_id",     "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_id",   "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "channel_name", "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "title",        "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "views",        "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "likes",        "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "comments",     "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "published_at", "type": "TIMESTAMP", "mode": "NULLABLE"},
+	  {"name": "created_at",   "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureKeywordTrendsTable creates the keyword trends table if needed.
+func (w *BigQueryWriter) EnsureKeywordTrendsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, KeywordTrendsTableID, getKeywordTrendsSchemaJSON(), "dt", []string{"keyword", "video_id"})
+}
+
+// InsertKeywordTrends inserts keyword search results.
+func (w *BigQueryWriter) InsertKeywordTrends(ctx context.Context, records []*KeywordTrendRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(KeywordTrendsTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert keyword trends into BigQuery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// LoadVideoStats appends records to the table with a BigQuery load job
+// instead of streaming inserts. Load jobs are free and not subject to the
+// streaming buffer, which makes them the better fit for large backfills.
+func (w *BigQueryWriter) LoadVideoStats(ctx context.Context, records []*VideoStatsRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	data, err := encodeNDJSON(withDerived(records))
+	if err != nil {
+		return err
+	}
+
+	return w.appendNDJSON(ctx, w.tableID, data)
+}
+
+// appendNDJSON appends newline-delimited JSON rows to an existing table with
+// a load job.
+func (w *BigQueryWriter) appendNDJSON(ctx context.Context, tableID string, data []byte) error {
+	source := bigquery.NewReaderSource(bytes.NewReader(data))
+	source.SourceFormat = bigquery.JSON
+
+	loader := w.client.Dataset(w.datasetID).Table(tableID).LoaderFrom(source)
+	loader.WriteDisposition = bigquery.WriteAppend
+	loader.CreateDisposition = bigquery.CreateNever
+
+	job, err := loader.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start load job: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for load job %s: %w", job.ID(), err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("load job %s failed: %w", job.ID(), err)
+	}
+
+	return nil
+}
+
+// encodeNDJSON encodes records as newline-delimited JSON.
+func encodeNDJSON(records []*VideoStatsRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("failed to encode record for video %s: %w", r.VideoID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
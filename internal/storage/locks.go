@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// RunLocksTableID is the table that holds run leases, one row per lock name.
+const RunLocksTableID = "run_locks"
+
+func getRunLocksSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "name",        "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "holder",      "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "acquired_at", "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "expires_at",  "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureRunLocksTable creates the run locks table if needed.
+func (w *BigQueryWriter) EnsureRunLocksTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, RunLocksTableID, getRunLocksSchemaJSON(), "", nil)
+}
+
+// AcquireLease takes the lease called name for holder unless another holder
+// has an unexpired one, and reports whether it was acquired. The lease
+// expires after ttl so a crashed run cannot block later runs forever.
+//
+// BigQuery runs DML on a table one statement at a time, so of two
+// concurrent MERGEs only one can see the lease as free; the other either
+// matches nothing or fails with a concurrent update error, and both mean
+// the lease is held.
+func (w *BigQueryWriter) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING (SELECT @name AS name) AS s
+		ON t.name = s.name
+		WHEN MATCHED AND t.expires_at < CURRENT_TIMESTAMP() THEN UPDATE SET
+			holder = @holder,
+			acquired_at = CURRENT_TIMESTAMP(),
+			expires_at = TIMESTAMP_ADD(CURRENT_TIMESTAMP(), INTERVAL @ttl_ms MILLISECOND)
+		WHEN NOT MATCHED THEN
+			INSERT (name, holder, acquired_at, expires_at)
+			VALUES (@name, @holder, CURRENT_TIMESTAMP(), TIMESTAMP_ADD(CURRENT_TIMESTAMP(), INTERVAL @ttl_ms MILLISECOND))`,
+		w.runLocksTableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "name", Value: name},
+		{Name: "holder", Value: holder},
+		{Name: "ttl_ms", Value: ttl.Milliseconds()},
+	}
+
+	affected, err := runDML(ctx, q)
+	if err != nil {
+		if strings.Contains(err.Error(), "concurrent update") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return affected == 1, nil
+}
+
+// ReleaseLease removes the lease called name if holder still holds it.
+func (w *BigQueryWriter) ReleaseLease(ctx context.Context, name, holder string) error {
+	q := w.client.Query(fmt.Sprintf(`DELETE FROM %s WHERE name = @name AND holder = @holder`, w.runLocksTableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "name", Value: name},
+		{Name: "holder", Value: holder},
+	}
+	if _, err := runDML(ctx, q); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// runDML runs a DML statement and returns the number of rows it changed.
+func runDML(ctx context.Context, q *bigquery.Query) (int64, error) {
+	job, err := q.Run(ctx)
+	if err != nil {
+		return 0, err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := status.Err(); err != nil {
+		return 0, err
+	}
+	if stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics); ok {
+		return stats.NumDMLAffectedRows, nil
+	}
+	return 0, nil
+}
+
+func (w *BigQueryWriter) runLocksTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, RunLocksTableID)
+}
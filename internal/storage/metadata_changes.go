@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+)
+
+// MetadataChangesTableID is the table that stores video metadata change events.
+const MetadataChangesTableID = "video_metadata_changes"
+
+// MetadataChangeRecord is a single detected change of a video's metadata field.
+type MetadataChangeRecord struct {
+	Dt         civil.Date `bigquery:"dt" json:"dt"`
+	DetectedAt time.Time  `bigquery:"detected_at" json:"detected_at"`
+	ChannelID  string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID    string     `bigquery:"video_id" json:"video_id"`
+	Field      string     `bigquery:"field" json:"field"`
+	OldValue   string     `bigquery:"old_value" json:"old_value"`
+	NewValue   string     `bigquery:"new_value" json:"new_value"`
+}
+
+// VideoMetadata is the metadata of a video as of its most recent snapshot.
+type VideoMetadata struct {
+	VideoID      string   `bigquery:"video_id"`
+	Title        string   `bigquery:"title"`
+	Description  string   `bigquery:"description"`
+	Tags         []string `bigquery:"tags"`
+	ThumbnailURL string   `bigquery:"thumbnail_url"`
+}
+
+func getMetadataChangesSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",          "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "detected_at", "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",  "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_id",    "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "field",       "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "old_value",   "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "new_value",   "type": "STRING",    "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureMetadataChangesTable creates the metadata change history table if needed.
+func (w *BigQueryWriter) EnsureMetadataChangesTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, MetadataChangesTableID, getMetadataChangesSchemaJSON(), "dt", []string{"channel_id", "video_id"})
+}
+
+// InsertMetadataChanges inserts metadata change events.
+func (w *BigQueryWriter) InsertMetadataChanges(ctx context.Context, records []*MetadataChangeRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(MetadataChangesTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert metadata changes into BigQuery: %w", err)
+	}
+
+	return nil
+}
+
+// LatestMetadata returns the most recently stored metadata for the given
+// videos, looking back to the given date. Videos without a snapshot in that
+// window are absent from the result.
+func (w *BigQueryWriter) LatestMetadata(ctx context.Context, channelID string, videoIDs []string, since civil.Date) (map[string]*VideoMetadata, error) {
+	result := make(map[string]*VideoMetadata)
+	if len(videoIDs) == 0 {
+		return result, nil
+	}
+
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT latest.*
+		FROM (
+			SELECT ARRAY_AGG(STRUCT(video_id, title, description, tags, thumbnail_url) ORDER BY created_at DESC LIMIT 1)[OFFSET(0)] AS latest
+			FROM %s
+			WHERE channel_id = @channel_id
+				AND video_id IN UNNEST(@video_ids)
+				AND dt >= @since
+				AND title IS NOT NULL
+				AND `+channelRows+`
+			GROUP BY video_id
+		)`, w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "channel_id", Value: channelID},
+		{Name: "video_ids", Value: videoIDs},
+		{Name: "since", Value: since},
+	}
+
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest metadata: %w", err)
+	}
+	for {
+		var m VideoMetadata
+		err := it.Next(&m)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read latest metadata: %w", err)
+		}
+		result[m.VideoID] = &m
+	}
+	return result, nil
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+)
+
+// SchemaVersionLabel is the label on the video trends table that records
+// the version of the schema it was migrated to.
+const SchemaVersionLabel = "schema_version"
+
+// schemaMigration is one version of the video trends schema: the columns it
+// added to the previous one. Columns can only be added, never changed or
+// removed, so that old rows and older binaries keep working.
+type schemaMigration struct {
+	version     int
+	description string
+	columns     []string
+}
+
+// videoTrendsMigrations lists every version of the video trends schema, in
+// order. A new column goes into schema/video_trends.json and a new entry
+// here, with a matching note in the migration history of docs/schema.sql.
+var videoTrendsMigrations = []schemaMigration{
+	{1, "initial schema", []string{
+		"dt", "channel_id", "video_id", "title", "channel_name", "tags", "is_short",
+		"views", "likes", "comments", "published_at", "created_at", "duration_sec",
+		"content_details", "topic_details",
+	}},
+	{2, "video status for tombstones", []string{"status"}},
+	{3, "metadata change history", []string{"description", "thumbnail_url"}},
+	{4, "snapshot time", []string{"snapshot_ts"}},
+	{5, "shorts classifier confidence", []string{"shorts_confidence"}},
+	{6, "derived rates", []string{"like_rate", "comment_rate", "views_per_hour"}},
+	{7, "category and language", []string{"category_id", "default_language"}},
+	{8, "tracked playlist", []string{"playlist_id"}},
+	{9, "category name", []string{"category_name"}},
+	{10, "region restrictions and content rating", []string{"region_allowed", "region_blocked", "content_rating"}},
+	{11, "status flags", []string{"privacy_status", "made_for_kids", "license", "embeddable", "paid_promotion"}},
+	{12, "description chapters", []string{"chapters"}},
+	{13, "title language and keywords", []string{"title_language", "title_keywords"}},
+}
+
+// SchemaVersion is the version of the embedded video trends schema.
+var SchemaVersion = videoTrendsMigrations[len(videoTrendsMigrations)-1].version
+
+// MigrationResult describes what Migrate did.
+type MigrationResult struct {
+	// FromVersion is the table's schema_version label before the
+	// migration, 0 for tables created before versioning or just created.
+	FromVersion int
+	ToVersion   int
+	// Created is set when the table did not exist.
+	Created bool
+	// Added lists the columns added to the table.
+	Added []string
+	// Applied describes the migrations whose columns were added, e.g.
+	// "7: category and language".
+	Applied []string
+	// RetentionUpdated is set when the table's expiration or partition
+	// filter options were changed to match the retention policy.
+	RetentionUpdated bool
+}
+
+// Migrate creates the dataset and video trends table if needed and brings
+// the table up to its schema (the embedded one unless SetSchemaFile gave
+// another): columns missing from the live table are added, the equivalent
+// of ALTER TABLE ADD COLUMN, and the schema_version label is set to
+// SchemaVersion. A column whose type or mode differs from the schema cannot
+// be fixed by adding columns and is reported as an error without changing
+// the table. The retention policy (see SetRetention) is applied in the same
+// update; nothing is updated when the table already matches. A migration
+// forgets that EnsureTableExists brought the table up to date, and a
+// successful one records it again.
+func (w *BigQueryWriter) Migrate(ctx context.Context) (*MigrationResult, error) {
+	key := w.ensuredTableKey()
+	ensuredTables.Delete(key)
+	result, err := w.migrate(ctx)
+	if err == nil {
+		ensuredTables.Store(key, struct{}{})
+	}
+	return result, err
+}
+
+// migrate does the work of Migrate.
+func (w *BigQueryWriter) migrate(ctx context.Context) (*MigrationResult, error) {
+	if err := w.ensureDataset(ctx); err != nil {
+		return nil, err
+	}
+	result := &MigrationResult{ToVersion: SchemaVersion}
+	table := w.client.Dataset(w.datasetID).Table(w.tableID)
+	md, err := table.Metadata(ctx)
+	if err != nil && errors.Classify(err) == errors.ErrNotFound {
+		if err := w.ensureTable(ctx, w.tableID, w.tableSchemaJSON(), "dt", []string{"channel_id", "video_id"}); err != nil {
+			return nil, err
+		}
+		result.Created = true
+		md, err = table.Metadata(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table metadata for %s: %w", w.tableID, err)
+	}
+	want, err := bigquery.SchemaFromJSON(w.tableSchemaJSON())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema for %s: %w", w.tableID, err)
+	}
+	missing, err := missingColumns(md.Schema, want)
+	if err != nil {
+		return nil, fmt.Errorf("cannot migrate table %s: %w", w.tableID, err)
+	}
+	result.FromVersion, _ = strconv.Atoi(md.Labels[SchemaVersionLabel])
+
+	var update bigquery.TableMetadataToUpdate
+	result.RetentionUpdated = w.retention.apply(md, &update)
+	if len(missing) == 0 && result.FromVersion == SchemaVersion && !result.RetentionUpdated {
+		return result, nil
+	}
+
+	if len(missing) > 0 {
+		update.Schema = append(append(bigquery.Schema{}, md.Schema...), missing...)
+		for _, f := range missing {
+			result.Added = append(result.Added, f.Name)
+		}
+		result.Applied = appliedMigrations(result.Added)
+	}
+	update.SetLabel(SchemaVersionLabel, strconv.Itoa(SchemaVersion))
+	// The ETag makes the update fail rather than overwrite a concurrent
+	// schema change.
+	if _, err := table.Update(ctx, update, md.ETag); err != nil {
+		return nil, fmt.Errorf("failed to migrate table %s: %w", w.tableID, err)
+	}
+	if len(result.Added) > 0 {
+		logger.FromContext(ctx).Info("Added missing columns to BigQuery table", map[string]string{
+			"table":   w.tableID,
+			"columns": strings.Join(result.Added, ","),
+			"version": strconv.Itoa(SchemaVersion),
+		})
+	}
+	if result.RetentionUpdated {
+		logger.FromContext(ctx).Info("Updated BigQuery table retention", map[string]string{
+			"table":                    w.tableID,
+			"partition_expiration":     w.retention.PartitionExpiration.String(),
+			"expiration_time":          formatExpiration(w.retention.ExpirationTime),
+			"require_partition_filter": strconv.FormatBool(w.retention.RequirePartitionFilter),
+		})
+	}
+	return result, nil
+}
+
+// ensuredTables holds the keys (see ensuredTableKey) of the video trends
+// tables migrated successfully in this process, which EnsureTableExists
+// does not check again unless SetForceEnsure is on.
+var ensuredTables sync.Map
+
+// forceEnsure makes EnsureTableExists migrate the table on every call.
+var forceEnsure atomic.Bool
+
+// SetForceEnsure makes EnsureTableExists check and migrate the table on
+// every call instead of once per process, e.g. when the table may be
+// dropped or altered while the service runs.
+func SetForceEnsure(force bool) {
+	forceEnsure.Store(force)
+}
+
+// ensuredTableKey identifies the writer's table together with the schema
+// and retention policy it is migrated to, so that a writer with another
+// schema file or retention policy migrates the table again.
+func (w *BigQueryWriter) ensuredTableKey() string {
+	sum := sha256.Sum256(w.tableSchemaJSON())
+	return fmt.Sprintf("%s.%s.%s %x %s %s %t", w.client.Project(), w.datasetID, w.tableID, sum[:8],
+		w.retention.PartitionExpiration, formatExpiration(w.retention.ExpirationTime), w.retention.RequirePartitionFilter)
+}
+
+// forgetEnsuredTable makes the next EnsureTableExists migrate the table
+// again, after an insert found it missing.
+func (w *BigQueryWriter) forgetEnsuredTable() {
+	ensuredTables.Delete(w.ensuredTableKey())
+}
+
+func formatExpiration(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// appliedMigrations describes the migrations that added any of columns.
+func appliedMigrations(columns []string) []string {
+	added := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		added[c] = true
+	}
+	var applied []string
+	for _, m := range videoTrendsMigrations {
+		for _, c := range m.columns {
+			if added[c] {
+				applied = append(applied, fmt.Sprintf("%d: %s", m.version, m.description))
+				break
+			}
+		}
+	}
+	return applied
+}
+
+// addMissingColumns adds the columns of schemaJSON that tableID lacks, for
+// the analytics tables whose schema grew after they were created. Like
+// Migrate, it only adds NULLABLE and REPEATED columns.
+func (w *BigQueryWriter) addMissingColumns(ctx context.Context, tableID string, schemaJSON []byte) error {
+	table := w.client.Dataset(w.datasetID).Table(tableID)
+	md, err := table.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table metadata for %s: %w", tableID, err)
+	}
+	want, err := bigquery.SchemaFromJSON(schemaJSON)
+	if err != nil {
+		return fmt.Errorf("failed to load schema for %s: %w", tableID, err)
+	}
+	missing, err := missingColumns(md.Schema, want)
+	if err != nil {
+		return fmt.Errorf("cannot migrate table %s: %w", tableID, err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	update := bigquery.TableMetadataToUpdate{Schema: append(append(bigquery.Schema{}, md.Schema...), missing...)}
+	if _, err := table.Update(ctx, update, md.ETag); err != nil {
+		return fmt.Errorf("failed to migrate table %s: %w", tableID, err)
+	}
+	return nil
+}
+
+// missingColumns returns the fields of want that live lacks, in want's
+// order. Adding them is only possible for NULLABLE and REPEATED columns, and
+// only if the columns both schemas share agree on type and mode.
+func missingColumns(live, want bigquery.Schema) (bigquery.Schema, error) {
+	existing := make(map[string]*bigquery.FieldSchema, len(live))
+	for _, f := range live {
+		existing[strings.ToLower(f.Name)] = f
+	}
+
+	var missing bigquery.Schema
+	for _, f := range want {
+		got, ok := existing[strings.ToLower(f.Name)]
+		if !ok {
+			if f.Required {
+				return nil, fmt.Errorf("column %s is REQUIRED and cannot be added to an existing table", f.Name)
+			}
+			missing = append(missing, f)
+			continue
+		}
+		if got.Type != f.Type || got.Repeated != f.Repeated {
+			return nil, fmt.Errorf("column %s is %s, want %s", f.Name, describeField(got), describeField(f))
+		}
+	}
+	return missing, nil
+}
+
+func describeField(f *bigquery.FieldSchema) string {
+	if f.Repeated {
+		return "REPEATED " + string(f.Type)
+	}
+	return string(f.Type)
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/option"
+)
+
+func TestVideoTrendsMigrations_MatchSchema(t *testing.T) {
+	schema, err := bigquery.SchemaFromJSON(getSchemaJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inSchema := make(map[string]bool)
+	for _, f := range schema {
+		inSchema[f.Name] = true
+	}
+
+	seen := make(map[string]int)
+	for i, m := range videoTrendsMigrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.version, i+1)
+		}
+		for _, c := range m.columns {
+			if !inSchema[c] {
+				t.Errorf("migration %d adds %s, which is not in the schema", m.version, c)
+			}
+			if v, ok := seen[c]; ok {
+				t.Errorf("column %s is added by migrations %d and %d", c, v, m.version)
+			}
+			seen[c] = m.version
+		}
+	}
+	for name := range inSchema {
+		if _, ok := seen[name]; !ok {
+			t.Errorf("column %s is in the schema but not added by any migration", name)
+		}
+	}
+}
+
+func TestMissingColumns(t *testing.T) {
+	want := bigquery.Schema{
+		{Name: "dt", Type: bigquery.DateFieldType, Required: true},
+		{Name: "title", Type: bigquery.StringFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+		{Name: "like_rate", Type: bigquery.FloatFieldType},
+	}
+
+	t.Run("adds nullable and repeated columns", func(t *testing.T) {
+		live := bigquery.Schema{
+			{Name: "dt", Type: bigquery.DateFieldType, Required: true},
+			{Name: "Title", Type: bigquery.StringFieldType},
+		}
+		missing, err := missingColumns(live, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range missing {
+			names = append(names, f.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"tags", "like_rate"}) {
+			t.Errorf("missing = %v, want [tags like_rate]", names)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		missing, err := missingColumns(want, want)
+		if err != nil || len(missing) != 0 {
+			t.Errorf("missingColumns() = %v, %v; want none", missing, err)
+		}
+	})
+
+	t.Run("required column", func(t *testing.T) {
+		_, err := missingColumns(bigquery.Schema{want[1]}, want)
+		if err == nil || !strings.Contains(err.Error(), "REQUIRED") {
+			t.Errorf("err = %v, want REQUIRED column error", err)
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		live := bigquery.Schema{want[0], {Name: "title", Type: bigquery.IntegerFieldType}}
+		_, err := missingColumns(live, want)
+		if err == nil || !strings.Contains(err.Error(), "title") {
+			t.Errorf("err = %v, want type mismatch on title", err)
+		}
+	})
+}
+
+func TestAppliedMigrations(t *testing.T) {
+	got := appliedMigrations([]string{"category_id", "default_language", "like_rate"})
+	want := []string{"6: derived rates", "7: category and language"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("appliedMigrations() = %v, want %v", got, want)
+	}
+}
+
+func TestEnsureTableExists_Cached(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":403,"message":"denied","errors":[{"reason":"accessDenied"}]}}`))
+	}))
+	defer srv.Close()
+	client, err := bigquery.NewClient(context.Background(), "p", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewBigQueryWriterWithClient(client, "youtube", "video_trends_cache_test")
+	ctx := context.Background()
+
+	// A table migrated earlier in the process is not checked again.
+	ensuredTables.Store(w.ensuredTableKey(), struct{}{})
+	if err := w.EnsureTableExists(ctx); err != nil || requests != 0 {
+		t.Fatalf("cached table: error %v after %d requests", err, requests)
+	}
+
+	// Another retention policy is a different migration.
+	other := NewBigQueryWriterWithClient(client, "youtube", "video_trends_cache_test")
+	other.SetRetention(RetentionPolicy{PartitionExpiration: 24 * time.Hour})
+	if other.ensuredTableKey() == w.ensuredTableKey() {
+		t.Error("retention policy is not part of the key")
+	}
+
+	// --force-ensure checks anyway, and the failed migration forgets the
+	// table.
+	SetForceEnsure(true)
+	err = w.EnsureTableExists(ctx)
+	SetForceEnsure(false)
+	if err == nil || requests == 0 {
+		t.Fatalf("forced check: error %v after %d requests", err, requests)
+	}
+	requests = 0
+	if err := w.EnsureTableExists(ctx); err == nil || requests == 0 {
+		t.Errorf("after a failed migration: error %v after %d requests", err, requests)
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/civil"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+)
+
+// MultiWriter writes the records of a run to BigQuery and fans them out to
+// additional sinks. BigQuery stays the primary store: lookups read from it
+// and its errors are returned as before. Each sink is written independently
+// of BigQuery and of the other sinks; a failing sink is logged and counted
+// in ytt_errors_total{component="sink"} but never fails the run.
+//
+// Without a primary, records are only written to the sinks and lookups of
+// previous snapshots return nothing.
+type MultiWriter struct {
+	primary *BigQueryWriter
+	tableID string
+	sinks   []RecordSink
+	metrics *metrics.Metrics
+}
+
+// NewMultiWriter creates a writer that fans the records written to primary
+// out to sinks.
+func NewMultiWriter(primary *BigQueryWriter, sinks ...RecordSink) *MultiWriter {
+	return &MultiWriter{primary: primary, tableID: primary.tableID, sinks: sinks}
+}
+
+// NewSinkWriter creates a writer without BigQuery, which writes the video
+// trends rows to the sinks as table tableID.
+func NewSinkWriter(tableID string, sinks ...RecordSink) *MultiWriter {
+	return &MultiWriter{tableID: tableID, sinks: sinks}
+}
+
+// SetMetrics makes the writer count sink failures.
+func (m *MultiWriter) SetMetrics(mt *metrics.Metrics) {
+	m.metrics = mt
+}
+
+// InsertVideoStats writes video stats to BigQuery and the sinks. Records
+// are sent to the sinks even when BigQuery rejects them.
+func (m *MultiWriter) InsertVideoStats(ctx context.Context, records []*VideoStatsRecord) error {
+	var err error
+	if m.primary != nil {
+		err = m.primary.InsertVideoStats(ctx, records)
+	}
+	fanOut(ctx, m, m.tableID, withDerived(records))
+	return err
+}
+
+// InsertTombstones writes tombstones to BigQuery and the sinks.
+func (m *MultiWriter) InsertTombstones(ctx context.Context, records []*VideoTombstoneRecord) error {
+	var err error
+	if m.primary != nil {
+		err = m.primary.InsertTombstones(ctx, records)
+	}
+	fanOut(ctx, m, m.tableID, records)
+	return err
+}
+
+// InsertMetadataChanges writes metadata changes to BigQuery and the sinks.
+func (m *MultiWriter) InsertMetadataChanges(ctx context.Context, records []*MetadataChangeRecord) error {
+	var err error
+	if m.primary != nil {
+		err = m.primary.InsertMetadataChanges(ctx, records)
+	}
+	fanOut(ctx, m, MetadataChangesTableID, records)
+	return err
+}
+
+// InsertVideoComments writes comments to BigQuery and the sinks.
+func (m *MultiWriter) InsertVideoComments(ctx context.Context, records []*VideoCommentRecord) error {
+	var err error
+	if m.primary != nil {
+		err = m.primary.InsertVideoComments(ctx, records)
+	}
+	fanOut(ctx, m, VideoCommentsTableID, records)
+	return err
+}
+
+// InsertKeywordTrends writes keyword results to BigQuery and the sinks.
+func (m *MultiWriter) InsertKeywordTrends(ctx context.Context, records []*KeywordTrendRecord) error {
+	var err error
+	if m.primary != nil {
+		err = m.primary.InsertKeywordTrends(ctx, records)
+	}
+	fanOut(ctx, m, KeywordTrendsTableID, records)
+	return err
+}
+
+// KnownVideoIDs reads from BigQuery.
+func (m *MultiWriter) KnownVideoIDs(ctx context.Context, channelID string, since civil.Date) ([]string, error) {
+	if m.primary == nil {
+		return nil, nil
+	}
+	return m.primary.KnownVideoIDs(ctx, channelID, since)
+}
+
+// LatestMetadata reads from BigQuery.
+func (m *MultiWriter) LatestMetadata(ctx context.Context, channelID string, videoIDs []string, since civil.Date) (map[string]*VideoMetadata, error) {
+	if m.primary == nil {
+		return nil, nil
+	}
+	return m.primary.LatestMetadata(ctx, channelID, videoIDs, since)
+}
+
+// fanOut encodes records once and writes them to every sink concurrently.
+func fanOut[T any](ctx context.Context, m *MultiWriter, table string, records []T) {
+	if len(m.sinks) == 0 || len(records) == 0 {
+		return
+	}
+	log := logger.FromContext(ctx)
+	rows := make([]json.RawMessage, 0, len(records))
+	for _, r := range records {
+		b, err := json.Marshal(r)
+		if err != nil {
+			log.Warning("Failed to encode row for sinks", err, map[string]string{"table": table})
+			continue
+		}
+		rows = append(rows, b)
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range m.sinks {
+		wg.Add(1)
+		go func(s RecordSink) {
+			defer wg.Done()
+			if err := s.WriteRows(ctx, table, rows); err != nil {
+				log.Warning("Failed to write rows to sink", err, map[string]string{
+					"sink":  s.Name(),
+					"table": table,
+					"rows":  strconv.Itoa(len(rows)),
+				})
+				if m.metrics != nil {
+					m.metrics.RecordError("sink", "write")
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/civil"
+)
+
+type fakeSink struct {
+	name string
+	err  error
+
+	mu   sync.Mutex
+	rows map[string][]json.RawMessage
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rows == nil {
+		f.rows = make(map[string][]json.RawMessage)
+	}
+	f.rows[table] = append(f.rows[table], rows...)
+	return f.err
+}
+
+func TestFanOut_IsolatesFailingSinks(t *testing.T) {
+	failing := &fakeSink{name: "failing", err: fmt.Errorf("unavailable")}
+	archive := &fakeSink{name: "archive"}
+	m := NewSinkWriter("video_trends", failing, archive)
+
+	records := []*VideoTombstoneRecord{
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ChannelID: "UC1", VideoID: "v1", Status: "deleted"},
+		{Dt: civil.Date{Year: 2025, Month: 8, Day: 1}, ChannelID: "UC1", VideoID: "v2", Status: "private"},
+	}
+	fanOut(context.Background(), m, "video_trends", records)
+
+	rows := archive.rows["video_trends"]
+	if len(rows) != 2 {
+		t.Fatalf("archive got %d rows, want 2", len(rows))
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(rows[1], &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["video_id"] != "v2" || row["dt"] != "2025-08-01" {
+		t.Errorf("row = %v, want column names as keys", row)
+	}
+	if len(failing.rows["video_trends"]) != 2 {
+		t.Errorf("failing sink got %d rows, want 2", len(failing.rows["video_trends"]))
+	}
+}
+
+func TestSinkWriter_VideoStatsDerived(t *testing.T) {
+	archive := &fakeSink{name: "archive"}
+	m := NewSinkWriter("video_trends", archive)
+
+	records := []*VideoStatsRecord{{VideoID: "v1", Views: 200, Likes: 10}}
+	if err := m.InsertVideoStats(context.Background(), records); err != nil {
+		t.Fatal(err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(archive.rows["video_trends"][0], &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["like_rate"] != 0.05 {
+		t.Errorf("sink row like_rate = %v, want 0.05", row["like_rate"])
+	}
+	if records[0].LikeRate.Valid {
+		t.Errorf("caller's record was changed: %+v", records[0])
+	}
+}
+
+func TestSinkWriter(t *testing.T) {
+	archive := &fakeSink{name: "archive"}
+	m := NewSinkWriter("trends", archive)
+	ctx := context.Background()
+
+	if err := m.InsertVideoStats(ctx, []*VideoStatsRecord{{VideoID: "v1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InsertKeywordTrends(ctx, []*KeywordTrendRecord{{Keyword: "go"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.rows["trends"]) != 1 || len(archive.rows[KeywordTrendsTableID]) != 1 {
+		t.Errorf("archive got %v, want one row per table", archive.rows)
+	}
+	if ids, err := m.KnownVideoIDs(ctx, "UC1", civil.Date{}); ids != nil || err != nil {
+		t.Errorf("KnownVideoIDs() = %v, %v; want nothing without BigQuery", ids, err)
+	}
+}
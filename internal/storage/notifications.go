@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// NotificationsTableID is the table that records the videos notified by
+// each notification rule, so that a rule does not notify a video again
+// within its cooldown.
+const NotificationsTableID = "notifications"
+
+// newUploadDays is how long after its publication a video first
+// snapshotted is a new upload, and how far back VideoFacts looks at the
+// snapshots of a video.
+const newUploadDays = 7
+
+// NotificationRecord is one video notified by a rule.
+type NotificationRecord struct {
+	SentAt    time.Time `bigquery:"sent_at" json:"sent_at"`
+	Rule      string    `bigquery:"rule" json:"rule"`
+	ChannelID string    `bigquery:"channel_id" json:"channel_id"`
+	VideoID   string    `bigquery:"video_id" json:"video_id"`
+	// Actions are the actions that delivered the notification.
+	Actions []string `bigquery:"actions" json:"actions"`
+}
+
+// VideoFacts are the values the conditions of notification rules are
+// evaluated with, for one video as of its latest snapshot.
+type VideoFacts struct {
+	ChannelID    string    `bigquery:"channel_id" json:"channel_id"`
+	ChannelName  string    `bigquery:"channel_name" json:"channel_name"`
+	VideoID      string    `bigquery:"video_id" json:"video_id"`
+	Title        string    `bigquery:"title" json:"title"`
+	IsShort      bool      `bigquery:"is_short" json:"is_short"`
+	Views        int64     `bigquery:"views" json:"views"`
+	Likes        int64     `bigquery:"likes" json:"likes"`
+	Comments     int64     `bigquery:"comments" json:"comments"`
+	DurationSec  int64     `bigquery:"duration_sec" json:"duration_sec"`
+	PublishedAt  time.Time `bigquery:"published_at" json:"published_at"`
+	ThumbnailURL string    `bigquery:"thumbnail_url" json:"thumbnail_url,omitempty"`
+	SnapshotTs   time.Time `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	// NewUpload is set for a video first snapshotted at SnapshotTs and
+	// published in the week before.
+	NewUpload bool `bigquery:"new_upload" json:"new_upload"`
+	// ViewsGained24h counts from the latest snapshot at least 24 hours
+	// before SnapshotTs. Without one, it counts from 0 for a video
+	// published in the day before, and from its first snapshot otherwise.
+	ViewsGained24h int64 `bigquery:"views_gained_24h" json:"views_gained_24h"`
+	// BaselineTs is when ViewsGained24h counts from: the snapshot it
+	// subtracts, PublishedAt when it counts from 0, or SnapshotTs when the
+	// video has no earlier snapshot.
+	BaselineTs time.Time `bigquery:"baseline_ts" json:"-"`
+}
+
+func getNotificationsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "sent_at",    "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "rule",       "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_id", "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "video_id",   "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "actions",    "type": "STRING",    "mode": "REPEATED"}
+	]`)
+}
+
+// EnsureNotificationsTable creates the notifications table if needed.
+func (w *BigQueryWriter) EnsureNotificationsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, NotificationsTableID, getNotificationsSchemaJSON(), "sent_at", []string{"rule"})
+}
+
+// InsertNotifications records notified videos.
+func (w *BigQueryWriter) InsertNotifications(ctx context.Context, records []*NotificationRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	inserter := w.client.Dataset(w.datasetID).Table(NotificationsTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert notifications into BigQuery: %w", err)
+	}
+	return nil
+}
+
+// NotificationsSince returns the notifications sent at or after since,
+// latest first.
+func (w *BigQueryWriter) NotificationsSince(ctx context.Context, since time.Time) ([]NotificationRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT sent_at, rule, IFNULL(channel_id, '') AS channel_id, video_id, actions
+		FROM %s
+		WHERE sent_at >= @since
+		ORDER BY sent_at DESC`, w.notificationsTableRef()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "since", Value: since}}
+	return readAll[NotificationRecord](ctx, q, "notifications")
+}
+
+// VideoFacts returns the facts of the videos of the given channels as of
+// their latest snapshot on date.
+func (w *BigQueryWriter) VideoFacts(ctx context.Context, date civil.Date, channelIDs []string) ([]VideoFacts, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH cur AS (
+			SELECT channel_id, IFNULL(channel_name, '') AS channel_name, video_id,
+				IFNULL(title, '') AS title, IFNULL(is_short, FALSE) AS is_short,
+				IFNULL(views, 0) AS views, IFNULL(likes, 0) AS likes, IFNULL(comments, 0) AS comments,
+				IFNULL(duration_sec, 0) AS duration_sec,
+				IFNULL(published_at, TIMESTAMP_SECONDS(0)) AS published_at,
+				IFNULL(thumbnail_url, '') AS thumbnail_url,
+				IFNULL(snapshot_ts, created_at) AS snapshot_ts
+			FROM %[1]s
+			WHERE dt = @date AND channel_id IN UNNEST(@channels) AND `+channelRows+`
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		history AS (
+			SELECT cur.video_id,
+				ARRAY_AGG(IF(h.ts <= TIMESTAMP_SUB(cur.snapshot_ts, INTERVAL 24 HOUR), h.views, NULL) IGNORE NULLS
+					ORDER BY h.ts DESC LIMIT 1)[SAFE_OFFSET(0)] AS day_ago_views,
+				MAX(IF(h.ts <= TIMESTAMP_SUB(cur.snapshot_ts, INTERVAL 24 HOUR), h.ts, NULL)) AS day_ago_ts,
+				ARRAY_AGG(h.views ORDER BY h.ts LIMIT 1)[SAFE_OFFSET(0)] AS first_views,
+				MIN(h.ts) AS first_ts,
+				COUNTIF(h.ts < cur.snapshot_ts) AS earlier
+			FROM cur
+			JOIN (
+				SELECT video_id, IFNULL(snapshot_ts, created_at) AS ts, views
+				FROM %[1]s
+				WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND @date
+					AND channel_id IN UNNEST(@channels) AND views IS NOT NULL AND `+channelRows+`
+			) AS h USING (video_id)
+			GROUP BY cur.video_id
+		)
+		SELECT cur.*,
+			IFNULL(history.earlier, 0) = 0
+				AND cur.published_at >= TIMESTAMP_SUB(cur.snapshot_ts, INTERVAL %[2]d DAY) AS new_upload,
+			GREATEST(cur.views - COALESCE(
+				history.day_ago_views,
+				IF(cur.published_at >= TIMESTAMP_SUB(cur.snapshot_ts, INTERVAL 24 HOUR), 0, history.first_views),
+				cur.views), 0) AS views_gained_24h,
+			CASE
+				WHEN history.day_ago_views IS NOT NULL THEN history.day_ago_ts
+				WHEN cur.published_at >= TIMESTAMP_SUB(cur.snapshot_ts, INTERVAL 24 HOUR) THEN cur.published_at
+				ELSE IFNULL(history.first_ts, cur.snapshot_ts)
+			END AS baseline_ts
+		FROM cur
+		LEFT JOIN history USING (video_id)
+		ORDER BY channel_id, video_id`, w.tableRef(), newUploadDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+		{Name: "channels", Value: channelIDs},
+	}
+	return readAll[VideoFacts](ctx, q, "video facts")
+}
+
+func (w *BigQueryWriter) notificationsTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, NotificationsTableID)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// PublishTimeStatsTableID is the table that stores the view velocity of
+// each channel's videos by publish weekday and hour.
+const PublishTimeStatsTableID = "publish_time_stats"
+
+// publishTimeDays is how far back PublishTimeInputs looks for publications,
+// and publishTimeStatsLookbackDays how far back PublishTimeStats looks for
+// the latest computed stats.
+const (
+	publishTimeDays              = 90
+	publishTimeStatsLookbackDays = 7
+)
+
+// PublishTimeInput is a video published in the publishTimeDays up to a
+// date with its views per hour early in its life. Velocity24h is measured
+// at its latest snapshot 12 to 24 hours after publication and Velocity72h
+// at its latest snapshot 36 to 72 hours after; either is NULL without one.
+type PublishTimeInput struct {
+	ChannelID   string               `bigquery:"channel_id"`
+	ChannelName string               `bigquery:"channel_name"`
+	VideoID     string               `bigquery:"video_id"`
+	PublishedAt time.Time            `bigquery:"published_at"`
+	Velocity24h bigquery.NullFloat64 `bigquery:"velocity_24h"`
+	Velocity72h bigquery.NullFloat64 `bigquery:"velocity_72h"`
+}
+
+// PublishTimeStatsRecord is the view velocity of a channel's videos
+// published in one weekday and hour slot, in the app timezone.
+type PublishTimeStatsRecord struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	// Weekday is 0 for Sunday to 6 for Saturday, and Hour 0 to 23.
+	Weekday int64 `bigquery:"weekday" json:"weekday"`
+	Hour    int64 `bigquery:"hour" json:"hour"`
+	// Videos counts the videos with a 24-hour velocity, and
+	// ViewsPerHour24h is their average.
+	Videos          int64   `bigquery:"videos" json:"videos"`
+	ViewsPerHour24h float64 `bigquery:"views_per_hour_24h" json:"views_per_hour_24h"`
+	// Lift24h is ViewsPerHour24h relative to the average of all the
+	// channel's videos: above 1 the slot does better than usual.
+	Lift24h float64 `bigquery:"lift_24h" json:"lift_24h"`
+	// Videos72h counts the videos with a 72-hour velocity, and
+	// ViewsPerHour72h is their average, NULL without any.
+	Videos72h       int64                `bigquery:"videos_72h" json:"videos_72h"`
+	ViewsPerHour72h bigquery.NullFloat64 `bigquery:"views_per_hour_72h" json:"views_per_hour_72h"`
+	ComputedAt      time.Time            `bigquery:"computed_at" json:"computed_at"`
+}
+
+func getPublishTimeStatsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",                 "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "channel_id",         "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channel_name",       "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "weekday",            "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "hour",               "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "videos",             "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "views_per_hour_24h", "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "lift_24h",           "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "videos_72h",         "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "views_per_hour_72h", "type": "FLOAT",     "mode": "NULLABLE"},
+	  {"name": "computed_at",        "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsurePublishTimeStatsTable creates the publish time stats table if
+// needed.
+func (w *BigQueryWriter) EnsurePublishTimeStatsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, PublishTimeStatsTableID, getPublishTimeStatsSchemaJSON(), "dt", []string{"channel_id"})
+}
+
+// PublishTimeInputs returns the videos published in the publishTimeDays up
+// to date with their early view velocity. Videos without a snapshot in
+// either window are left out.
+func (w *BigQueryWriter) PublishTimeInputs(ctx context.Context, date civil.Date) ([]PublishTimeInput, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH snaps AS (
+			SELECT channel_id, channel_name, video_id, published_at, views,
+				IFNULL(snapshot_ts, created_at) AS ts,
+				TIMESTAMP_DIFF(IFNULL(snapshot_ts, created_at), published_at, SECOND) / 3600 AS age_hours
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND @date
+				AND views IS NOT NULL AND published_at IS NOT NULL
+				AND published_at >= TIMESTAMP(DATE_SUB(@date, INTERVAL %[2]d DAY))
+				AND `+channelRows+`
+		),
+		videos AS (
+			SELECT channel_id, IFNULL(ANY_VALUE(channel_name), '') AS channel_name, video_id,
+				ANY_VALUE(published_at) AS published_at,
+				ARRAY_AGG(IF(age_hours BETWEEN 12 AND 24, views / age_hours, NULL) IGNORE NULLS
+					ORDER BY ts DESC LIMIT 1)[SAFE_OFFSET(0)] AS velocity_24h,
+				ARRAY_AGG(IF(age_hours BETWEEN 36 AND 72, views / age_hours, NULL) IGNORE NULLS
+					ORDER BY ts DESC LIMIT 1)[SAFE_OFFSET(0)] AS velocity_72h
+			FROM snaps
+			GROUP BY channel_id, video_id
+		)
+		SELECT * FROM videos
+		WHERE velocity_24h IS NOT NULL OR velocity_72h IS NOT NULL`,
+		w.tableRef(), publishTimeDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[PublishTimeInput](ctx, q, "publish time inputs")
+}
+
+// ReplacePublishTimeStats replaces the date's partition of the publish time
+// stats table with records, like ReplaceTagTrends. Every record must be for
+// date.
+func (w *BigQueryWriter) ReplacePublishTimeStats(ctx context.Context, date civil.Date, records []*PublishTimeStatsRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if r.Dt != date {
+			return fmt.Errorf("publish time stats for %s are dated %s, not %s", r.ChannelID, r.Dt, date)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode publish time stats for %s: %w", r.ChannelID, err)
+		}
+	}
+	return w.replacePartition(ctx, PublishTimeStatsTableID, date, buf.Bytes())
+}
+
+// PublishTimeStats returns the stats of the latest date computed in the
+// publishTimeStatsLookbackDays up to date, for one channel or, with an
+// empty channelID, for all of them. They are ordered by channel then by
+// Lift24h, highest first.
+func (w *BigQueryWriter) PublishTimeStats(ctx context.Context, date civil.Date, channelID string) ([]PublishTimeStatsRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH recent AS (
+			SELECT * FROM %s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %d DAY) AND @date
+				AND (@channel = '' OR channel_id = @channel)
+		)
+		SELECT dt, channel_id, IFNULL(channel_name, '') AS channel_name, weekday, hour,
+			videos, views_per_hour_24h, lift_24h, videos_72h, views_per_hour_72h, computed_at
+		FROM recent
+		WHERE dt = (SELECT MAX(dt) FROM recent)
+		ORDER BY channel_id, lift_24h DESC, videos DESC, weekday, hour`,
+		w.channelTableRef(PublishTimeStatsTableID), publishTimeStatsLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+		{Name: "channel", Value: channelID},
+	}
+	return readAll[PublishTimeStatsRecord](ctx, q, "publish time stats")
+}
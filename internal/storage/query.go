@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/iterator"
+)
+
+// TopMetrics are the columns TopVideos can rank by. Column names cannot be
+// query parameters, so only these are interpolated into SQL.
+var TopMetrics = map[string]bool{
+	"views":    true,
+	"likes":    true,
+	"comments": true,
+}
+
+// VideoSummary is the latest stored snapshot of a video.
+type VideoSummary struct {
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	VideoID     string     `bigquery:"video_id" json:"video_id"`
+	Title       string     `bigquery:"title" json:"title"`
+	IsShort     bool       `bigquery:"is_short" json:"is_short"`
+	Views       int64      `bigquery:"views" json:"views"`
+	Likes       int64      `bigquery:"likes" json:"likes"`
+	Comments    int64      `bigquery:"comments" json:"comments"`
+	PublishedAt time.Time  `bigquery:"published_at" json:"published_at"`
+}
+
+// TimeseriesPoint is one snapshot of a video's counters.
+type TimeseriesPoint struct {
+	Dt         civil.Date `bigquery:"dt" json:"dt"`
+	SnapshotTs time.Time  `bigquery:"snapshot_ts" json:"snapshot_ts"`
+	Views      int64      `bigquery:"views" json:"views"`
+	Likes      int64      `bigquery:"likes" json:"likes"`
+	Comments   int64      `bigquery:"comments" json:"comments"`
+}
+
+// summaryColumns selects VideoSummary columns; NULLs become zero values.
+const summaryColumns = `
+	dt, channel_id,
+	IFNULL(channel_name, '') AS channel_name,
+	video_id,
+	IFNULL(title, '') AS title,
+	IFNULL(is_short, FALSE) AS is_short,
+	IFNULL(views, 0) AS views,
+	IFNULL(likes, 0) AS likes,
+	IFNULL(comments, 0) AS comments,
+	IFNULL(published_at, TIMESTAMP_SECONDS(0)) AS published_at`
+
+// ChannelSummary aggregates a channel's latest snapshots on a date.
+type ChannelSummary struct {
+	ChannelID   string `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string `bigquery:"channel_name" json:"channel_name"`
+	Videos      int64  `bigquery:"videos" json:"videos"`
+	Views       int64  `bigquery:"views" json:"views"`
+}
+
+// ChannelSummaries returns the number of videos stored per channel on a date
+// and their total views, using the last snapshot of the day.
+func (w *BigQueryWriter) ChannelSummaries(ctx context.Context, date civil.Date) ([]ChannelSummary, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			channel_id,
+			IFNULL(ANY_VALUE(channel_name), '') AS channel_name,
+			COUNT(*) AS videos,
+			IFNULL(SUM(views), 0) AS views
+		FROM (
+			SELECT channel_id, channel_name, views
+			FROM %s
+			WHERE dt = @dt
+				AND views IS NOT NULL
+				AND `+channelRows+`
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+		)
+		GROUP BY channel_id
+		ORDER BY views DESC`, w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "dt", Value: date},
+	}
+	return readAll[ChannelSummary](ctx, q, "channel summaries")
+}
+
+// ChannelVideos returns the latest snapshot between from and to (inclusive)
+// of each video of a channel, most viewed first. Tombstones are excluded.
+func (w *BigQueryWriter) ChannelVideos(ctx context.Context, channelID string, from, to civil.Date, limit int) ([]VideoSummary, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE channel_id = @channel_id
+			AND dt BETWEEN @from AND @to
+			AND views IS NOT NULL
+			AND `+channelRows+`
+		QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+		ORDER BY views DESC
+		LIMIT @limit`, summaryColumns, w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "channel_id", Value: channelID},
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+		{Name: "limit", Value: limit},
+	}
+	return readAll[VideoSummary](ctx, q, "channel videos")
+}
+
+// VideoTimeseries returns every snapshot of a video between from and to
+// (inclusive), oldest first.
+func (w *BigQueryWriter) VideoTimeseries(ctx context.Context, videoID string, from, to civil.Date) ([]TimeseriesPoint, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			dt,
+			COALESCE(snapshot_ts, created_at) AS snapshot_ts,
+			IFNULL(views, 0) AS views,
+			IFNULL(likes, 0) AS likes,
+			IFNULL(comments, 0) AS comments
+		FROM %s
+		WHERE video_id = @video_id
+			AND dt BETWEEN @from AND @to
+			AND views IS NOT NULL
+		ORDER BY snapshot_ts`, w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "video_id", Value: videoID},
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+	}
+	return readAll[TimeseriesPoint](ctx, q, "video timeseries")
+}
+
+// TopVideos returns the videos with the highest metric on a date, using the
+// last snapshot of the day. metric must be a key of TopMetrics.
+func (w *BigQueryWriter) TopVideos(ctx context.Context, date civil.Date, metric string, limit int) ([]VideoSummary, error) {
+	if !TopMetrics[metric] {
+		return nil, fmt.Errorf("unsupported metric %q", metric)
+	}
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE dt = @dt
+			AND views IS NOT NULL
+		QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+		ORDER BY %s DESC
+		LIMIT @limit`, summaryColumns, w.tableRef(), metric))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "dt", Value: date},
+		{Name: "limit", Value: limit},
+	}
+	return readAll[VideoSummary](ctx, q, "top videos")
+}
+
+// ChannelDayStats is a channel's activity on one date. ViewsGained and
+// EngagementRate are NULL on dates without a snapshot of the channel.
+type ChannelDayStats struct {
+	ChannelID   string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName string     `bigquery:"channel_name" json:"channel_name"`
+	Dt          civil.Date `bigquery:"dt" json:"dt"`
+	// Uploads counts the videos published on the date.
+	Uploads     int64              `bigquery:"uploads" json:"uploads"`
+	ViewsGained bigquery.NullInt64 `bigquery:"views_gained" json:"views_gained"`
+	// EngagementRate is (likes + comments) / views over the channel's videos.
+	EngagementRate bigquery.NullFloat64 `bigquery:"engagement_rate" json:"engagement_rate"`
+}
+
+// CompareChannels returns one row per channel and date between from and to
+// (inclusive), ordered by channel and date, so the channels' series align.
+// Views gained on a date are measured against each video's previous stored
+// day (looked up at most 7 days back); a video without one counts all its
+// views if it was published within those 7 days. Dates are in timezone.
+func (w *BigQueryWriter) CompareChannels(ctx context.Context, channelIDs []string, from, to civil.Date, timezone string) ([]ChannelDayStats, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH snaps AS (
+			SELECT dt, channel_id, channel_name, video_id, views,
+				IFNULL(likes, 0) AS likes, IFNULL(comments, 0) AS comments, published_at
+			FROM %[1]s
+			WHERE channel_id IN UNNEST(@channels)
+				AND dt BETWEEN DATE_SUB(@from, INTERVAL %[2]d DAY) AND @to
+				AND views IS NOT NULL
+				AND `+channelRows+`
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id, dt ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+		),
+		deltas AS (
+			SELECT *, views - LAG(views) OVER (PARTITION BY video_id ORDER BY dt) AS delta
+			FROM snaps
+		),
+		daily AS (
+			SELECT channel_id, dt,
+				ANY_VALUE(channel_name) AS channel_name,
+				SUM(GREATEST(IFNULL(delta,
+					IF(published_at >= TIMESTAMP(DATE_SUB(dt, INTERVAL %[2]d DAY), @tz), views, 0)), 0)) AS views_gained,
+				SAFE_DIVIDE(SUM(likes + comments), SUM(views)) AS engagement_rate
+			FROM deltas
+			WHERE dt >= @from
+			GROUP BY channel_id, dt
+		),
+		uploads AS (
+			SELECT channel_id, DATE(published_at, @tz) AS dt, COUNT(DISTINCT video_id) AS uploads
+			FROM snaps
+			WHERE published_at IS NOT NULL
+			GROUP BY channel_id, dt
+		)
+		SELECT
+			c AS channel_id,
+			d AS dt,
+			IFNULL(daily.channel_name, '') AS channel_name,
+			IFNULL(uploads.uploads, 0) AS uploads,
+			daily.views_gained,
+			daily.engagement_rate
+		FROM UNNEST(@channels) AS c
+		CROSS JOIN UNNEST(GENERATE_DATE_ARRAY(@from, @to)) AS d
+		LEFT JOIN daily ON daily.channel_id = c AND daily.dt = d
+		LEFT JOIN uploads ON uploads.channel_id = c AND uploads.dt = d
+		ORDER BY channel_id, dt`, w.tableRef(), trendBaselineDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "channels", Value: channelIDs},
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+		{Name: "tz", Value: timezone},
+	}
+	return readAll[ChannelDayStats](ctx, q, "channel comparison")
+}
+
+// CohortPoint is the cumulative views of the videos published in one week,
+// at one age in days.
+type CohortPoint struct {
+	// PublishWeek is the Monday the week of publication starts on, and
+	// CohortVideos counts the videos published in it.
+	PublishWeek  civil.Date `bigquery:"publish_week" json:"publish_week"`
+	CohortVideos int64      `bigquery:"cohort_videos" json:"cohort_videos"`
+	AgeDays      int64      `bigquery:"age_days" json:"age_days"`
+	// Videos counts the videos with a snapshot at AgeDays, and AvgViews and
+	// MedianViews are their views in the last of them.
+	Videos      int64   `bigquery:"videos" json:"videos"`
+	AvgViews    float64 `bigquery:"avg_views" json:"avg_views"`
+	MedianViews int64   `bigquery:"median_views" json:"median_views"`
+}
+
+// PublishWeekCohorts returns, for the videos published in the weeks from the
+// Monday from to the Sunday after the Monday to, their views at each age from
+// 0 to maxAge days, ordered by week and age. Ages are whole days since
+// publication and weeks are in timezone. An empty channelID means every
+// channel.
+func (w *BigQueryWriter) PublishWeekCohorts(ctx context.Context, channelID string, from, to civil.Date, maxAge int, timezone string) ([]CohortPoint, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH snaps AS (
+			SELECT video_id, published_at, views, age_days
+			FROM (
+				SELECT *, DIV(TIMESTAMP_DIFF(IFNULL(snapshot_ts, created_at), published_at, HOUR), 24) AS age_days
+				FROM %s
+				WHERE dt BETWEEN @from AND DATE_ADD(@to, INTERVAL @max_age + 7 DAY)
+					AND (@channel = '' OR channel_id = @channel)
+					AND views IS NOT NULL
+					AND published_at >= TIMESTAMP(@from, @tz)
+					AND published_at < TIMESTAMP(DATE_ADD(@to, INTERVAL 7 DAY), @tz)
+					AND `+channelRows+`
+			)
+			WHERE age_days BETWEEN 0 AND @max_age
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id, age_days ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		weeks AS (
+			SELECT video_id, DATE_TRUNC(DATE(ANY_VALUE(published_at), @tz), WEEK(MONDAY)) AS publish_week
+			FROM snaps
+			GROUP BY video_id
+		)
+		SELECT publish_week,
+			ANY_VALUE(cohort_videos) AS cohort_videos,
+			age_days,
+			COUNT(*) AS videos,
+			AVG(views) AS avg_views,
+			APPROX_QUANTILES(views, 2)[OFFSET(1)] AS median_views
+		FROM snaps
+		JOIN weeks USING (video_id)
+		JOIN (
+			SELECT publish_week, COUNT(*) AS cohort_videos FROM weeks GROUP BY publish_week
+		) USING (publish_week)
+		GROUP BY publish_week, age_days
+		ORDER BY publish_week, age_days`, w.tableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "channel", Value: channelID},
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+		{Name: "max_age", Value: maxAge},
+		{Name: "tz", Value: timezone},
+	}
+	return readAll[CohortPoint](ctx, q, "publish week cohorts")
+}
+
+// readAll runs q and loads every row into a T.
+func readAll[T any](ctx context.Context, q *bigquery.Query, what string) ([]T, error) {
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	rows := []T{}
+	for {
+		var row T
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", what, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+// quotaUsedField is the Firestore field and GCS object metadata key holding
+// the units spent on a quota day.
+const quotaUsedField = "quota_used"
+
+// maxLedgerAttempts bounds how often a GCS ledger update is retried when
+// another instance updated the day's object first.
+const maxLedgerAttempts = 10
+
+// QuotaLedger keeps the YouTube API quota spent on each quota day, shared
+// by every instance and manual run that uses the same API key.
+type QuotaLedger interface {
+	// Name identifies the ledger in logs.
+	Name() string
+	// AddQuota adds units to the total of day (YYYY-MM-DD) and returns the
+	// new total; 0 units only reads it.
+	AddQuota(ctx context.Context, day string, units int64) (int64, error)
+}
+
+// NewQuotaLedger returns the ledger for a firestore://<collection> or
+// gs://<bucket>[/<prefix>] URI, keeping one document or object per quota
+// day.
+func NewQuotaLedger(ctx context.Context, projectID, uri string) (QuotaLedger, error) {
+	switch {
+	case strings.HasPrefix(uri, firestoreSinkScheme):
+		return NewFirestoreQuotaLedger(ctx, projectID, uri)
+	case strings.HasPrefix(uri, gcsSinkScheme):
+		return NewGCSQuotaLedger(ctx, uri)
+	default:
+		return nil, fmt.Errorf("unsupported quota ledger %q", uri)
+	}
+}
+
+// FirestoreQuotaLedger keeps the total of each quota day in the document
+// <collection>/<day>, updated with atomic increments.
+type FirestoreQuotaLedger struct {
+	service    *firestore.Service
+	uri        string
+	database   string
+	collection string
+}
+
+// NewFirestoreQuotaLedger creates a ledger for
+// firestore://<collection>[?database=<database>]. If FIRESTORE_EMULATOR_HOST
+// is set, the emulator is used without authentication.
+func NewFirestoreQuotaLedger(ctx context.Context, projectID, uri string, opts ...option.ClientOption) (*FirestoreQuotaLedger, error) {
+	collection, query, _ := strings.Cut(strings.TrimPrefix(uri, firestoreSinkScheme), "?")
+	if collection == "" || strings.Contains(collection, "/") {
+		return nil, fmt.Errorf("Firestore quota ledger must be firestore://<collection>: %q", uri)
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Firestore quota ledger options %q: %w", query, err)
+	}
+	database := "(default)"
+	for key := range params {
+		if key != "database" {
+			return nil, fmt.Errorf("unknown Firestore quota ledger option %q", key)
+		}
+		database = params.Get(key)
+	}
+	if host := os.Getenv("FIRESTORE_EMULATOR_HOST"); host != "" {
+		opts = append(opts, option.WithEndpoint("http://"+host+"/"), option.WithoutAuthentication())
+	}
+	svc, err := firestore.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("firestore.NewService: %w", err)
+	}
+	return &FirestoreQuotaLedger{
+		service:    svc,
+		uri:        uri,
+		database:   fmt.Sprintf("projects/%s/databases/%s", projectID, database),
+		collection: collection,
+	}, nil
+}
+
+// Name returns the ledger URI.
+func (l *FirestoreQuotaLedger) Name() string {
+	return l.uri
+}
+
+// AddQuota increments the day's document, creating it on the first write,
+// and returns the total Firestore computed.
+func (l *FirestoreQuotaLedger) AddQuota(ctx context.Context, day string, units int64) (int64, error) {
+	doc := fmt.Sprintf("%s/documents/%s/%s", l.database, l.collection, day)
+	req := &firestore.CommitRequest{Writes: []*firestore.Write{{
+		Transform: &firestore.DocumentTransform{
+			Document: doc,
+			FieldTransforms: []*firestore.FieldTransform{
+				{FieldPath: quotaUsedField, Increment: &firestore.Value{IntegerValue: units, ForceSendFields: []string{"IntegerValue"}}},
+				{FieldPath: "updated_at", SetToServerValue: "REQUEST_TIME"},
+			},
+		},
+	}}}
+	resp, err := l.service.Projects.Databases.Documents.Commit(l.database, req).Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update quota ledger %s: %w", doc, err)
+	}
+	if len(resp.WriteResults) != 1 || len(resp.WriteResults[0].TransformResults) == 0 {
+		return 0, fmt.Errorf("quota ledger %s: commit returned no total", doc)
+	}
+	return resp.WriteResults[0].TransformResults[0].IntegerValue, nil
+}
+
+// GCSQuotaLedger keeps the total of each quota day in the object
+// <prefix>/<day>.json. Updates are read-modify-write with a generation
+// precondition, so concurrent updates retry instead of losing units.
+type GCSQuotaLedger struct {
+	service *gcs.Service
+	uri     string
+	bucket  string
+	prefix  string
+}
+
+// NewGCSQuotaLedger creates a ledger for gs://<bucket>[/<prefix>].
+func NewGCSQuotaLedger(ctx context.Context, uri string, opts ...option.ClientOption) (*GCSQuotaLedger, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, gcsSinkScheme), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("GCS quota ledger must be gs://<bucket>[/<prefix>]: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(gcs.DevstorageReadWriteScope))
+	svc, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewService: %w", err)
+	}
+	return &GCSQuotaLedger{service: svc, uri: uri, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// Name returns the ledger URI.
+func (l *GCSQuotaLedger) Name() string {
+	return l.uri
+}
+
+// AddQuota reads the day's total from the object metadata and, unless units
+// is 0, writes the new total if the object has not changed since.
+func (l *GCSQuotaLedger) AddQuota(ctx context.Context, day string, units int64) (int64, error) {
+	name := path.Join(l.prefix, day+".json")
+	for attempt := 0; attempt < maxLedgerAttempts; attempt++ {
+		used, generation, err := l.read(ctx, name)
+		if err != nil || units == 0 {
+			return used, err
+		}
+		used += units
+		body, _ := json.Marshal(map[string]interface{}{"quota_day": day, quotaUsedField: used})
+		obj := &gcs.Object{
+			Name:        name,
+			ContentType: "application/json",
+			Metadata:    map[string]string{quotaUsedField: strconv.FormatInt(used, 10)},
+		}
+		_, err = l.service.Objects.Insert(l.bucket, obj).IfGenerationMatch(generation).Media(bytes.NewReader(body)).Context(ctx).Do()
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("storage.objects.insert %s: %w", name, err)
+		}
+		return used, nil
+	}
+	return 0, fmt.Errorf("quota ledger %s changed concurrently %d times", name, maxLedgerAttempts)
+}
+
+// read returns the total and generation of the object, or 0 and generation
+// 0 (which only matches a missing object) if there is none yet.
+func (l *GCSQuotaLedger) read(ctx context.Context, name string) (used, generation int64, err error) {
+	obj, err := l.service.Objects.Get(l.bucket, name).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("storage.objects.get %s: %w", name, err)
+	}
+	if v := obj.Metadata[quotaUsedField]; v != "" {
+		if used, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("quota ledger %s: invalid %s %q", name, quotaUsedField, v)
+		}
+	}
+	return used, obj.Generation, nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+)
+
+func TestFirestoreQuotaLedger(t *testing.T) {
+	var req firestore.CommitRequest
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Write([]byte(`{"writeResults":[{"transformResults":[{"integerValue":"1234"},{"timestampValue":"2025-08-01T06:00:00Z"}]}]}`))
+	}))
+	defer srv.Close()
+
+	l, err := NewFirestoreQuotaLedger(context.Background(), "p", "firestore://quota", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, err := l.AddQuota(context.Background(), "2025-08-01", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 1234 {
+		t.Errorf("used = %d, want 1234", used)
+	}
+	tr := req.Writes[0].Transform
+	if tr == nil || !strings.HasSuffix(tr.Document, "/documents/quota/2025-08-01") {
+		t.Fatalf("transform = %+v, want the day's document", tr)
+	}
+	// Reading sends an explicit increment of zero.
+	if !strings.Contains(body, `"increment":{"integerValue":"0"}`) {
+		t.Errorf("request does not increment by zero:\n%s", body)
+	}
+
+	if _, err := NewFirestoreQuotaLedger(context.Background(), "p", "firestore://a/b"); err == nil {
+		t.Error("nested collection accepted")
+	}
+}
+
+func TestGCSQuotaLedger(t *testing.T) {
+	var (
+		used       int64 = 100
+		generation int64 = 1
+		inserts    int
+		conflict   = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if !strings.HasSuffix(r.URL.Path, "/b/bucket/o/ledger/2025-08-01.json") {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"code":404}}`))
+				return
+			}
+			fmt.Fprintf(w, `{"generation":"%d","metadata":{"quota_used":"%d"}}`, generation, used)
+			return
+		}
+		inserts++
+		// Another instance writes between the first read and insert.
+		if conflict {
+			conflict = false
+			used, generation = used+5, generation+1
+		}
+		if r.URL.Query().Get("ifGenerationMatch") != fmt.Sprint(generation) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"error":{"code":412}}`))
+			return
+		}
+		var obj struct {
+			Metadata map[string]string `json:"metadata"`
+		}
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		part, err := multipart.NewReader(r.Body, params["boundary"]).NextPart()
+		if err != nil {
+			t.Fatalf("read upload: %v", err)
+		}
+		b, _ := io.ReadAll(part)
+		if err := json.Unmarshal(b, &obj); err != nil {
+			t.Fatalf("decode object: %v", err)
+		}
+		fmt.Sscan(obj.Metadata["quota_used"], &used)
+		generation++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	l, err := NewGCSQuotaLedger(context.Background(), "gs://bucket/ledger", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := l.AddQuota(context.Background(), "2025-08-01", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 115 || used != 115 {
+		t.Errorf("total = %d, stored %d, want 115 including the concurrent 5", total, used)
+	}
+	if inserts != 2 {
+		t.Errorf("inserts = %d, want a retry after the conflict", inserts)
+	}
+
+	if total, err := l.AddQuota(context.Background(), "2025-08-01", 0); err != nil || total != 115 {
+		t.Errorf("read = %d, %v, want 115", total, err)
+	}
+	if inserts != 2 {
+		t.Error("reading wrote the object")
+	}
+	if total, err := l.AddQuota(context.Background(), "2025-08-02", 0); err != nil || total != 0 {
+		t.Errorf("new day = %d, %v, want 0", total, err)
+	}
+}
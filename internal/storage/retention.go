@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// RetentionPolicy controls how long the video trends table keeps data and
+// whether queries must prune partitions. The zero value keeps everything
+// and allows unfiltered queries.
+type RetentionPolicy struct {
+	// PartitionExpiration deletes a dt partition this long after its date;
+	// 0 keeps partitions forever.
+	PartitionExpiration time.Duration
+	// ExpirationTime deletes the whole table at that time; the zero time
+	// never does.
+	ExpirationTime time.Time
+	// RequirePartitionFilter makes BigQuery reject queries on the table
+	// without a filter on dt.
+	RequirePartitionFilter bool
+}
+
+// SetRetention sets the policy Migrate (and so EnsureTableExists) applies
+// to the video trends table. The policy is the source of truth: options
+// changed outside the application are set back to it.
+func (w *BigQueryWriter) SetRetention(p RetentionPolicy) {
+	w.retention = p
+}
+
+// apply adds to update the table options of md that differ from the policy
+// and reports whether there were any.
+func (p RetentionPolicy) apply(md *bigquery.TableMetadata, update *bigquery.TableMetadataToUpdate) bool {
+	changed := false
+	if tp := md.TimePartitioning; tp != nil && tp.Expiration != p.PartitionExpiration {
+		// The partitioning is sent as a whole; only the expiration changes.
+		// The deprecated per-partitioning filter flag is kept in step with
+		// the table-level one.
+		updated := *tp
+		updated.Expiration = p.PartitionExpiration
+		updated.RequirePartitionFilter = p.RequirePartitionFilter
+		update.TimePartitioning = &updated
+		changed = true
+	}
+	if !md.ExpirationTime.Truncate(time.Millisecond).Equal(p.ExpirationTime.Truncate(time.Millisecond)) {
+		if p.ExpirationTime.IsZero() {
+			update.ExpirationTime = bigquery.NeverExpire
+		} else {
+			update.ExpirationTime = p.ExpirationTime
+		}
+		changed = true
+	}
+	if md.RequirePartitionFilter != p.RequirePartitionFilter {
+		update.RequirePartitionFilter = p.RequirePartitionFilter
+		changed = true
+	}
+	return changed
+}
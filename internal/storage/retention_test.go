@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func TestRetentionPolicy_Apply(t *testing.T) {
+	expires := time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC)
+	table := func() *bigquery.TableMetadata {
+		return &bigquery.TableMetadata{
+			TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType, Field: "dt"},
+		}
+	}
+
+	t.Run("default policy on a default table", func(t *testing.T) {
+		var update bigquery.TableMetadataToUpdate
+		if (RetentionPolicy{}).apply(table(), &update) {
+			t.Errorf("apply() changed %+v, want no change", update)
+		}
+	})
+
+	t.Run("sets every option", func(t *testing.T) {
+		p := RetentionPolicy{PartitionExpiration: 90 * 24 * time.Hour, ExpirationTime: expires, RequirePartitionFilter: true}
+		var update bigquery.TableMetadataToUpdate
+		if !p.apply(table(), &update) {
+			t.Fatal("apply() = false, want true")
+		}
+		if tp := update.TimePartitioning; tp == nil || tp.Expiration != p.PartitionExpiration || tp.Field != "dt" {
+			t.Errorf("TimePartitioning = %+v, want dt partitions expiring after 90 days", tp)
+		}
+		if !update.ExpirationTime.Equal(expires) {
+			t.Errorf("ExpirationTime = %v, want %v", update.ExpirationTime, expires)
+		}
+		if update.RequirePartitionFilter != true {
+			t.Errorf("RequirePartitionFilter = %v, want true", update.RequirePartitionFilter)
+		}
+	})
+
+	t.Run("already applied", func(t *testing.T) {
+		p := RetentionPolicy{PartitionExpiration: 90 * 24 * time.Hour, ExpirationTime: expires, RequirePartitionFilter: true}
+		md := table()
+		md.TimePartitioning.Expiration = p.PartitionExpiration
+		md.ExpirationTime = expires
+		md.RequirePartitionFilter = true
+		var update bigquery.TableMetadataToUpdate
+		if p.apply(md, &update) {
+			t.Errorf("apply() changed %+v, want no change", update)
+		}
+	})
+
+	t.Run("clears options set outside the application", func(t *testing.T) {
+		md := table()
+		md.TimePartitioning.Expiration = time.Hour
+		md.ExpirationTime = expires
+		var update bigquery.TableMetadataToUpdate
+		if !(RetentionPolicy{}).apply(md, &update) {
+			t.Fatal("apply() = false, want true")
+		}
+		if update.TimePartitioning == nil || update.TimePartitioning.Expiration != 0 {
+			t.Errorf("TimePartitioning = %+v, want no expiration", update.TimePartitioning)
+		}
+		if update.ExpirationTime != bigquery.NeverExpire {
+			t.Errorf("ExpirationTime = %v, want NeverExpire", update.ExpirationTime)
+		}
+		if update.RequirePartitionFilter != nil {
+			t.Errorf("RequirePartitionFilter = %v, want unchanged", update.RequirePartitionFilter)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// FetchRunsTableID is the table that records every fetch run.
+const FetchRunsTableID = "fetch_runs"
+
+// Fetch run statuses stored in the status column.
+const (
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+// FetchRunRecord summarises one fetch run.
+type FetchRunRecord struct {
+	RunID             string    `bigquery:"run_id" json:"run_id"`
+	Scope             string    `bigquery:"scope" json:"scope"`
+	StartedAt         time.Time `bigquery:"started_at" json:"started_at"`
+	FinishedAt        time.Time `bigquery:"finished_at" json:"finished_at"`
+	Status            string    `bigquery:"status" json:"status"`
+	ChannelsSucceeded int64     `bigquery:"channels_succeeded" json:"channels_succeeded"`
+	ChannelsFailed    int64     `bigquery:"channels_failed" json:"channels_failed"`
+	VideosWritten     int64     `bigquery:"videos_written" json:"videos_written"`
+	QuotaUnits        int64     `bigquery:"quota_units" json:"quota_units"`
+	Error             string    `bigquery:"error" json:"error,omitempty"`
+	// FailedChannels lists the channels that failed or were skipped.
+	FailedChannels []string `bigquery:"failed_channels" json:"failed_channels,omitempty"`
+}
+
+func getFetchRunsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "run_id",             "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "scope",              "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "started_at",         "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "finished_at",        "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "status",             "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "channels_succeeded", "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "channels_failed",    "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "videos_written",     "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "quota_units",        "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "error",              "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "failed_channels",    "type": "STRING",    "mode": "REPEATED"}
+	]`)
+}
+
+// EnsureFetchRunsTable creates the fetch runs table if needed.
+func (w *BigQueryWriter) EnsureFetchRunsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, FetchRunsTableID, getFetchRunsSchemaJSON(), "started_at", []string{"scope"})
+}
+
+// InsertFetchRun records a finished run.
+func (w *BigQueryWriter) InsertFetchRun(ctx context.Context, record *FetchRunRecord) error {
+	inserter := w.client.Dataset(w.datasetID).Table(FetchRunsTableID).Inserter()
+	if err := inserter.Put(ctx, record); err != nil {
+		return fmt.Errorf("failed to insert fetch run into BigQuery: %w", err)
+	}
+	return nil
+}
+
+// fetchRunsLookbackDays limits how many partitions RecentRuns scans.
+const fetchRunsLookbackDays = 90
+
+// RecentRuns returns up to limit runs from the last 90 days, newest first.
+func (w *BigQueryWriter) RecentRuns(ctx context.Context, limit int) ([]FetchRunRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			run_id, scope, started_at, finished_at, status,
+			IFNULL(channels_succeeded, 0) AS channels_succeeded,
+			IFNULL(channels_failed, 0) AS channels_failed,
+			IFNULL(videos_written, 0) AS videos_written,
+			IFNULL(quota_units, 0) AS quota_units,
+			IFNULL(error, '') AS error
+		FROM %s
+		WHERE started_at >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL %d DAY)
+		ORDER BY started_at DESC
+		LIMIT @limit`, w.fetchRunsTableRef(), fetchRunsLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "limit", Value: limit},
+	}
+	return readAll[FetchRunRecord](ctx, q, "fetch runs")
+}
+
+// LastSuccessfulRun returns the latest successful run of all channels from
+// the last 90 days: a scheduled run (scope "all") or a catch-up run (scope
+// "catchup:<date>"). It returns nil when there is none.
+func (w *BigQueryWriter) LastSuccessfulRun(ctx context.Context) (*FetchRunRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			run_id, scope, started_at, finished_at, status,
+			IFNULL(channels_succeeded, 0) AS channels_succeeded,
+			IFNULL(channels_failed, 0) AS channels_failed,
+			IFNULL(videos_written, 0) AS videos_written,
+			IFNULL(quota_units, 0) AS quota_units,
+			IFNULL(error, '') AS error
+		FROM %s
+		WHERE started_at >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL %d DAY)
+			AND status = @success
+			AND (scope = 'all' OR STARTS_WITH(scope, 'catchup:'))
+		ORDER BY started_at DESC
+		LIMIT 1`, w.fetchRunsTableRef(), fetchRunsLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "success", Value: RunStatusSuccess},
+	}
+	runs, err := readAll[FetchRunRecord](ctx, q, "last successful run")
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// FailedRuns returns the runs started in [from, to) that failed or had a
+// failed channel, oldest first.
+func (w *BigQueryWriter) FailedRuns(ctx context.Context, from, to time.Time) ([]FetchRunRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			run_id, scope, started_at, finished_at, status,
+			IFNULL(channels_succeeded, 0) AS channels_succeeded,
+			IFNULL(channels_failed, 0) AS channels_failed,
+			IFNULL(videos_written, 0) AS videos_written,
+			IFNULL(quota_units, 0) AS quota_units,
+			IFNULL(error, '') AS error,
+			failed_channels
+		FROM %s
+		WHERE started_at >= @from AND started_at < @to
+			AND (status = @failed OR IFNULL(channels_failed, 0) > 0)
+		ORDER BY started_at`, w.fetchRunsTableRef()))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "from", Value: from},
+		{Name: "to", Value: to},
+		{Name: "failed", Value: RunStatusFailed},
+	}
+	return readAll[FetchRunRecord](ctx, q, "failed fetch runs")
+}
+
+// RunRecords returns the rows recorded for runID in the last 90 days,
+// oldest first. A run dispatched over Pub/Sub has one row per channel task;
+// an unknown run ID gives an empty slice.
+func (w *BigQueryWriter) RunRecords(ctx context.Context, runID string) ([]FetchRunRecord, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT
+			run_id, scope, started_at, finished_at, status,
+			IFNULL(channels_succeeded, 0) AS channels_succeeded,
+			IFNULL(channels_failed, 0) AS channels_failed,
+			IFNULL(videos_written, 0) AS videos_written,
+			IFNULL(quota_units, 0) AS quota_units,
+			IFNULL(error, '') AS error,
+			failed_channels
+		FROM %s
+		WHERE started_at >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL %d DAY)
+			AND run_id = @run_id
+		ORDER BY started_at`, w.fetchRunsTableRef(), fetchRunsLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "run_id", Value: runID},
+	}
+	return readAll[FetchRunRecord](ctx, q, "fetch run")
+}
+
+func (w *BigQueryWriter) fetchRunsTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, FetchRunsTableID)
+}
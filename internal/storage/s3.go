@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const s3SinkScheme = "s3://"
+
+// s3Putter is the part of the S3 client the sink uses.
+type s3Putter interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink writes rows to S3 in a layout Athena can query: gzipped JSONL
+// objects under <prefix>/<table>/dt=<date>/, one partition per snapshot
+// date. With a Glue database it also registers each table, using partition
+// projection so new dates need no registration.
+type S3Sink struct {
+	client s3Putter
+	glue   *GlueCatalog
+	uri    string
+	bucket string
+	prefix string
+	seq    atomic.Int64
+
+	// registered holds the tables already registered with Glue.
+	registered sync.Map
+}
+
+// parseS3URI parses s3://<bucket>[/<prefix>][?region=<region>][&glue_database=<db>].
+func parseS3URI(uri string) (bucket, prefix string, params url.Values, err error) {
+	rest, query, _ := strings.Cut(strings.TrimPrefix(uri, s3SinkScheme), "?")
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", nil, fmt.Errorf("S3 sink must be s3://<bucket>[/<prefix>]: %q", uri)
+	}
+	params, err = url.ParseQuery(query)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid S3 sink options %q: %w", query, err)
+	}
+	for key := range params {
+		if key != "region" && key != "glue_database" {
+			return "", "", nil, fmt.Errorf("unknown S3 sink option %q", key)
+		}
+	}
+	return bucket, strings.Trim(prefix, "/"), params, nil
+}
+
+// NewS3Sink creates a sink for an s3:// URI (see parseS3URI), using the
+// default AWS credential chain (environment, shared config, or the
+// instance/task role). The region defaults to AWS_REGION; glue_database
+// enables registration of the tables in the Glue Data Catalog.
+func NewS3Sink(ctx context.Context, uri string) (*S3Sink, error) {
+	bucket, prefix, params, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if region := params.Get("region"); region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	s := newS3Sink(s3.NewFromConfig(awsCfg), uri, bucket, prefix)
+	if db := params.Get("glue_database"); db != "" {
+		s.glue = NewGlueCatalog(awsCfg, db)
+	}
+	return s, nil
+}
+
+func newS3Sink(client s3Putter, uri, bucket, prefix string) *S3Sink {
+	return &S3Sink{client: client, uri: uri, bucket: bucket, prefix: prefix}
+}
+
+// Name returns the sink URI.
+func (s *S3Sink) Name() string {
+	return s.uri
+}
+
+// WriteRows uploads the rows of each snapshot date as a new object in its
+// dt= partition. A table is registered with Glue before its first write; a
+// failed registration is retried on the next write.
+func (s *S3Sink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if s.glue != nil {
+		if _, ok := s.registered.Load(table); !ok {
+			if err := s.glue.EnsureTable(ctx, table, s.tableLocation(table), tableSchemaJSON(table)); err != nil {
+				return err
+			}
+			s.registered.Store(table, true)
+		}
+	}
+
+	byDate := map[string][]json.RawMessage{}
+	var dates []string
+	for _, row := range rows {
+		dt := rowDate(row)
+		if _, ok := byDate[dt]; !ok {
+			dates = append(dates, dt)
+		}
+		byDate[dt] = append(byDate[dt], row)
+	}
+	for _, dt := range dates {
+		body, err := gzipRows(byDate[dt])
+		if err != nil {
+			return err
+		}
+		key := path.Join(s.prefix, table, "dt="+dt,
+			fmt.Sprintf("%s-%d.json.gz", time.Now().UTC().Format("150405.000000000"), s.seq.Add(1)))
+		if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(s.bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(body),
+			ContentType: aws.String("application/x-ndjson"),
+		}); err != nil {
+			return fmt.Errorf("s3 PutObject %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// tableLocation returns the S3 location of table, the parent of its dt=
+// partitions.
+func (s *S3Sink) tableLocation(table string) string {
+	return s3SinkScheme + path.Join(s.bucket, s.prefix, table) + "/"
+}
+
+// tableSchemaJSON returns the BigQuery schema of the rows written to table:
+// the video trends schema for the main table, whose name is configurable,
+// and that of the table otherwise.
+func tableSchemaJSON(table string) []byte {
+	switch table {
+	case MetadataChangesTableID:
+		return getMetadataChangesSchemaJSON()
+	case VideoCommentsTableID:
+		return getVideoCommentsSchemaJSON()
+	case KeywordTrendsTableID:
+		return getKeywordTrendsSchemaJSON()
+	default:
+		return getSchemaJSON()
+	}
+}
+
+// rowDate returns the dt of an encoded row, or today's UTC date for rows
+// without one.
+func rowDate(row json.RawMessage) string {
+	var r struct {
+		Dt string `json:"dt"`
+	}
+	if json.Unmarshal(row, &r) == nil {
+		if d, err := civil.ParseDate(r.Dt); err == nil {
+			return d.String()
+		}
+	}
+	return civil.DateOf(time.Now().UTC()).String()
+}
+
+// gzipRows encodes rows as gzipped JSONL.
+func gzipRows(rows []json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(encodeRows(rows)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
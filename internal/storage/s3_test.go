@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestParseS3URI(t *testing.T) {
+	bucket, prefix, params, err := parseS3URI("s3://ytt/raw/?region=ap-northeast-1&glue_database=ytt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bucket != "ytt" || prefix != "raw" || params.Get("region") != "ap-northeast-1" || params.Get("glue_database") != "ytt" {
+		t.Errorf("parseS3URI() = %q, %q, %v", bucket, prefix, params)
+	}
+	for _, bad := range []string{"s3://", "s3:///raw", "s3://ytt?acl=public-read"} {
+		if _, _, _, err := parseS3URI(bad); err == nil {
+			t.Errorf("parseS3URI(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestS3Sink(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("id", "secret", ""),
+	})
+	s := newS3Sink(client, "s3://ytt/raw", "ytt", "raw")
+	rows := []json.RawMessage{
+		[]byte(`{"dt":"2025-08-01","video_id":"v1"}`),
+		[]byte(`{"dt":"2025-08-02","video_id":"v2"}`),
+		[]byte(`{"dt":"2025-08-01","video_id":"v3"}`),
+	}
+	if err := s.WriteRows(context.Background(), "video_trends", rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("wrote %d objects, want one per dt", len(objects))
+	}
+	for key, body := range objects {
+		if !strings.HasPrefix(key, "/ytt/raw/video_trends/dt=2025-08-0") || !strings.HasSuffix(key, ".json.gz") {
+			t.Errorf("unexpected object %s", key)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		data, _ := io.ReadAll(zr)
+		if strings.Contains(key, "dt=2025-08-01") && string(data) != string(rows[0])+"\n"+string(rows[2])+"\n" {
+			t.Errorf("%s = %q, want v1 and v3", key, data)
+		}
+	}
+}
+
+func TestGlueCatalogEnsureTable(t *testing.T) {
+	var target string
+	var req map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("request is not signed")
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	g := NewGlueCatalog(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+	}, "ytt")
+	g.endpoint = srv.URL
+	if err := g.EnsureTable(context.Background(), "video_trends", "s3://ytt/raw/video_trends/", getSchemaJSON()); err != nil {
+		t.Fatal(err)
+	}
+	if target != "AWSGlue.CreateTable" {
+		t.Errorf("X-Amz-Target = %q", target)
+	}
+	input := req["TableInput"].(map[string]interface{})
+	params := input["Parameters"].(map[string]interface{})
+	if params["storage.location.template"] != "s3://ytt/raw/video_trends/dt=${dt}/" {
+		t.Errorf("location template = %v", params["storage.location.template"])
+	}
+	for _, c := range input["StorageDescriptor"].(map[string]interface{})["Columns"].([]interface{}) {
+		if c.(map[string]interface{})["Name"] == "dt" {
+			t.Errorf("dt is both a column and the partition key")
+		}
+	}
+}
+
+func TestHiveType(t *testing.T) {
+	f := &bigquery.FieldSchema{Name: "details", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "duration", Type: bigquery.StringFieldType},
+		{Name: "views", Type: bigquery.IntegerFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+	}}
+	if got, want := hiveType(f), "array<struct<duration:string,views:bigint,tags:array<string>>>"; got != want {
+		t.Errorf("hiveType() = %s, want %s", got, want)
+	}
+}
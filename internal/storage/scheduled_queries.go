@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	datatransfer "cloud.google.com/go/bigquery/datatransfer/apiv1"
+	"cloud.google.com/go/bigquery/datatransfer/apiv1/datatransferpb"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// scheduledQueryDataSource is the Data Transfer data source of scheduled
+// queries.
+const scheduledQueryDataSource = "scheduled_query"
+
+// ScheduledQuery is a BigQuery scheduled query managed by
+// EnsureScheduledQueries, e.g. a nightly dedup or rollup.
+type ScheduledQuery struct {
+	// Name is the display name that identifies the query in the project.
+	Name string
+	// Query is the SQL to run. ${PROJECT_ID}, ${DATASET_ID} and ${TABLE_ID}
+	// are replaced with the writer's project, dataset and video trends
+	// table.
+	Query string
+	// Schedule is a Data Transfer schedule such as "every 24 hours" or
+	// "every day 03:00"; empty uses the service default.
+	Schedule string
+	// DestinationTable, when set, is the table in the writer's dataset the
+	// results are written to, and may contain templates such as
+	// {run_date}. DML queries leave it empty.
+	DestinationTable string
+	// WriteDisposition is WRITE_TRUNCATE or WRITE_APPEND for queries with a
+	// destination table.
+	WriteDisposition string
+	// PartitioningField partitions a new destination table by that column.
+	PartitioningField string
+	// Disabled pauses the query without deleting it.
+	Disabled bool
+}
+
+// ScheduledQueryResult lists the scheduled queries EnsureScheduledQueries
+// changed, by name.
+type ScheduledQueryResult struct {
+	Created []string
+	Updated []string
+}
+
+// EnsureScheduledQueries creates the scheduled queries missing from the
+// project's location and updates those whose query, schedule, destination
+// or state differs from queries. Existing queries are matched by display
+// name; scheduled queries that are not in queries are left alone.
+func (w *BigQueryWriter) EnsureScheduledQueries(ctx context.Context, location string, queries []ScheduledQuery) (*ScheduledQueryResult, error) {
+	result := &ScheduledQueryResult{}
+	if len(queries) == 0 {
+		return result, nil
+	}
+
+	client, err := datatransfer.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("datatransfer.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	parent := fmt.Sprintf("projects/%s/locations/%s", w.client.Project(), location)
+	existing := make(map[string]*datatransferpb.TransferConfig)
+	it := client.ListTransferConfigs(ctx, &datatransferpb.ListTransferConfigsRequest{
+		Parent:        parent,
+		DataSourceIds: []string{scheduledQueryDataSource},
+	})
+	for {
+		tc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scheduled queries in %s: %w", parent, err)
+		}
+		if _, ok := existing[tc.DisplayName]; ok {
+			return nil, fmt.Errorf("several scheduled queries are named %q; rename or delete all but one", tc.DisplayName)
+		}
+		existing[tc.DisplayName] = tc
+	}
+
+	log := logger.FromContext(ctx)
+	for _, q := range queries {
+		want, err := w.transferConfig(q)
+		if err != nil {
+			return nil, err
+		}
+		current, ok := existing[q.Name]
+		if !ok {
+			if _, err := client.CreateTransferConfig(ctx, &datatransferpb.CreateTransferConfigRequest{
+				Parent:         parent,
+				TransferConfig: want,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to create scheduled query %q: %w", q.Name, err)
+			}
+			result.Created = append(result.Created, q.Name)
+			log.Info("Created scheduled query", map[string]string{"name": q.Name, "schedule": q.Schedule})
+			continue
+		}
+
+		paths := transferConfigChanges(current, want)
+		if len(paths) == 0 {
+			continue
+		}
+		want.Name = current.Name
+		if _, err := client.UpdateTransferConfig(ctx, &datatransferpb.UpdateTransferConfigRequest{
+			TransferConfig: want,
+			UpdateMask:     &fieldmaskpb.FieldMask{Paths: paths},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update scheduled query %q: %w", q.Name, err)
+		}
+		result.Updated = append(result.Updated, q.Name)
+		log.Info("Updated scheduled query", map[string]string{"name": q.Name, "fields": strings.Join(paths, ",")})
+	}
+	return result, nil
+}
+
+// transferConfig returns the transfer config of q in the writer's project
+// and dataset.
+func (w *BigQueryWriter) transferConfig(q ScheduledQuery) (*datatransferpb.TransferConfig, error) {
+	params := map[string]interface{}{
+		"query": strings.NewReplacer(
+			"${PROJECT_ID}", w.client.Project(),
+			"${DATASET_ID}", w.datasetID,
+			"${TABLE_ID}", w.tableID,
+		).Replace(q.Query),
+	}
+	tc := &datatransferpb.TransferConfig{
+		DisplayName:  q.Name,
+		DataSourceId: scheduledQueryDataSource,
+		Schedule:     q.Schedule,
+		Disabled:     q.Disabled,
+	}
+	if q.DestinationTable != "" {
+		params["destination_table_name_template"] = q.DestinationTable
+		if q.WriteDisposition != "" {
+			params["write_disposition"] = q.WriteDisposition
+		}
+		if q.PartitioningField != "" {
+			params["partitioning_field"] = q.PartitioningField
+		}
+		tc.Destination = &datatransferpb.TransferConfig_DestinationDatasetId{DestinationDatasetId: w.datasetID}
+	}
+	var err error
+	if tc.Params, err = structpb.NewStruct(params); err != nil {
+		return nil, fmt.Errorf("invalid parameters for scheduled query %q: %w", q.Name, err)
+	}
+	return tc, nil
+}
+
+// transferConfigChanges returns the update mask paths of the fields in
+// which current differs from want. An empty schedule leaves the current one
+// alone, since the service fills in its default.
+func transferConfigChanges(current, want *datatransferpb.TransferConfig) []string {
+	var paths []string
+	if !proto.Equal(current.GetParams(), want.GetParams()) {
+		paths = append(paths, "params")
+	}
+	if want.GetSchedule() != "" && current.GetSchedule() != want.GetSchedule() {
+		paths = append(paths, "schedule")
+	}
+	if current.GetDestinationDatasetId() != want.GetDestinationDatasetId() {
+		paths = append(paths, "destination_dataset_id")
+	}
+	if current.GetDisabled() != want.GetDisabled() {
+		paths = append(paths, "disabled")
+	}
+	return paths
+}
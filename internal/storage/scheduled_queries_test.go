@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/option"
+)
+
+func TestScheduledQueryTransferConfig(t *testing.T) {
+	client, err := bigquery.NewClient(context.Background(), "proj", option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	w := &BigQueryWriter{client: client, datasetID: "ds", tableID: "trends"}
+
+	rollup := ScheduledQuery{
+		Name:              "rollup",
+		Query:             "SELECT * FROM `${PROJECT_ID}.${DATASET_ID}.${TABLE_ID}` WHERE dt = @run_date",
+		Schedule:          "every day 03:00",
+		DestinationTable:  "rollup${run_date}",
+		WriteDisposition:  "WRITE_TRUNCATE",
+		PartitioningField: "dt",
+	}
+	tc, err := w.transferConfig(rollup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tc.Params.Fields["query"].GetStringValue(), "SELECT * FROM `proj.ds.trends` WHERE dt = @run_date"; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+	if tc.GetDestinationDatasetId() != "ds" || tc.Params.Fields["partitioning_field"].GetStringValue() != "dt" {
+		t.Errorf("transfer config = %v, want destination ds partitioned by dt", tc)
+	}
+
+	dedup, err := w.transferConfig(ScheduledQuery{Name: "dedup", Query: "DELETE FROM x WHERE TRUE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dedup.Destination != nil || len(dedup.Params.Fields) != 1 {
+		t.Errorf("DML transfer config = %v, want only a query", dedup)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		current, _ := w.transferConfig(rollup)
+		current.Name = "projects/proj/locations/l/transferConfigs/1"
+		if paths := transferConfigChanges(current, tc); len(paths) != 0 {
+			t.Errorf("changes = %v, want none", paths)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		changed := rollup
+		changed.Query = "SELECT 1"
+		changed.Schedule = "every 6 hours"
+		changed.Disabled = true
+		want, _ := w.transferConfig(changed)
+		if paths := transferConfigChanges(tc, want); !reflect.DeepEqual(paths, []string{"params", "schedule", "disabled"}) {
+			t.Errorf("changes = %v, want [params schedule disabled]", paths)
+		}
+	})
+
+	t.Run("default schedule", func(t *testing.T) {
+		noSchedule := rollup
+		noSchedule.Schedule = ""
+		want, _ := w.transferConfig(noSchedule)
+		if paths := transferConfigChanges(tc, want); len(paths) != 0 {
+			t.Errorf("changes = %v, want none", paths)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+const (
+	gcsSinkScheme    = "gs://"
+	pubsubSinkScheme = "pubsub://"
+)
+
+// maxRowsPerPublish is the Pub/Sub limit on messages per publish request.
+const maxRowsPerPublish = 1000
+
+// RecordSink is an additional destination for the rows written by a run,
+// such as an archive or a stream. Rows are the JSON encoded records of one
+// insert into table, with the column names as keys.
+type RecordSink interface {
+	// Name identifies the sink in logs and metrics.
+	Name() string
+	WriteRows(ctx context.Context, table string, rows []json.RawMessage) error
+}
+
+// NewSink returns the sink for a gs://<bucket>[/<prefix>], pubsub://<topic>,
+// kafka://<brokers>/<topic>, s3://<bucket>[/<prefix>] or
+// firestore://<collection> URI, using Application Default Credentials for
+// Google Cloud and the default credential chain for AWS. Pub/Sub topics
+// are in projectID unless given as projects/<project>/topics/<topic>.
+func NewSink(ctx context.Context, projectID, uri string) (RecordSink, error) {
+	switch {
+	case strings.HasPrefix(uri, gcsSinkScheme):
+		return NewGCSSink(ctx, uri)
+	case strings.HasPrefix(uri, pubsubSinkScheme):
+		return NewPubSubSink(ctx, projectID, uri)
+	case strings.HasPrefix(uri, kafkaSinkScheme):
+		return NewKafkaSink(uri)
+	case strings.HasPrefix(uri, s3SinkScheme):
+		return NewS3Sink(ctx, uri)
+	case strings.HasPrefix(uri, firestoreSinkScheme):
+		return NewFirestoreSink(ctx, projectID, uri)
+	default:
+		return nil, fmt.Errorf("unsupported sink %q", uri)
+	}
+}
+
+// GCSSink archives each insert as a newline-delimited JSON object under
+// <prefix>/<table>/<UTC date>/. It also serves as a SpillBuffer.
+type GCSSink struct {
+	service *gcs.Service
+	uri     string
+	bucket  string
+	prefix  string
+	seq     atomic.Int64
+}
+
+// NewGCSSink creates a sink for gs://<bucket>[/<prefix>].
+func NewGCSSink(ctx context.Context, uri string, opts ...option.ClientOption) (*GCSSink, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, gcsSinkScheme), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("GCS sink must be gs://<bucket>[/<prefix>]: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(gcs.DevstorageReadWriteScope))
+	svc, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewService: %w", err)
+	}
+	return &GCSSink{service: svc, uri: uri, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// Name returns the sink URI.
+func (s *GCSSink) Name() string {
+	return s.uri
+}
+
+// WriteRows uploads rows as a new object. Object names start with the time
+// of the write, so a listing of a day is in write order.
+func (s *GCSSink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	name := s.objectName(spillFileName(table, s.seq.Add(1)))
+	obj := &gcs.Object{Name: name, ContentType: "application/x-ndjson"}
+	if _, err := s.service.Objects.Insert(s.bucket, obj).Media(bytes.NewReader(encodeRows(rows))).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("storage.objects.insert %s: %w", name, err)
+	}
+	return nil
+}
+
+// PubSubSink publishes each row as a message with a "table" attribute.
+type PubSubSink struct {
+	service *pubsub.Service
+	topic   string
+}
+
+// NewPubSubSink creates a sink for pubsub://<topic>. If PUBSUB_EMULATOR_HOST
+// is set, the emulator is used without authentication.
+func NewPubSubSink(ctx context.Context, projectID, uri string, opts ...option.ClientOption) (*PubSubSink, error) {
+	topic := strings.TrimPrefix(uri, pubsubSinkScheme)
+	if topic == "" {
+		return nil, fmt.Errorf("Pub/Sub sink must be pubsub://<topic>: %q", uri)
+	}
+	if !strings.HasPrefix(topic, "projects/") {
+		topic = fmt.Sprintf("projects/%s/topics/%s", projectID, topic)
+	}
+	if host := os.Getenv("PUBSUB_EMULATOR_HOST"); host != "" {
+		opts = append(opts, option.WithEndpoint("http://"+host+"/"), option.WithoutAuthentication())
+	}
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub.NewService: %w", err)
+	}
+	return &PubSubSink{service: svc, topic: topic}, nil
+}
+
+// Name returns the topic.
+func (s *PubSubSink) Name() string {
+	return s.topic
+}
+
+// WriteRows publishes one message per row.
+func (s *PubSubSink) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	for i := 0; i < len(rows); i += maxRowsPerPublish {
+		end := min(i+maxRowsPerPublish, len(rows))
+		req := &pubsub.PublishRequest{}
+		for _, row := range rows[i:end] {
+			req.Messages = append(req.Messages, &pubsub.PubsubMessage{
+				Data:       base64.StdEncoding.EncodeToString(row),
+				Attributes: map[string]string{"table": table},
+			})
+		}
+		if _, err := s.service.Projects.Topics.Publish(s.topic, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to publish %s rows to %s: %w", table, s.topic, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+func TestPubSubSink(t *testing.T) {
+	var got []*pubsub.PubsubMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/projects/p/topics/rows:publish") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req pubsub.PublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		got = append(got, req.Messages...)
+		json.NewEncoder(w).Encode(pubsub.PublishResponse{MessageIds: []string{"1"}})
+	}))
+	defer srv.Close()
+
+	s, err := NewPubSubSink(context.Background(), "p", "pubsub://rows", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteRows(context.Background(), "video_trends", []json.RawMessage{[]byte(`{"video_id":"v1"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Attributes["table"] != "video_trends" {
+		t.Fatalf("published %+v, want one video_trends message", got)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(got[0].Data); string(data) != `{"video_id":"v1"}` {
+		t.Errorf("data = %s", data)
+	}
+}
+
+func TestGCSSink(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewGCSSink(context.Background(), "gs://bucket/archive/", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []json.RawMessage{[]byte(`{"video_id":"v1"}`), []byte(`{"video_id":"v2"}`)}
+	if err := s.WriteRows(context.Background(), "video_trends", rows); err != nil {
+		t.Fatal(err)
+	}
+	// The multipart upload carries the object metadata and the rows.
+	if !strings.Contains(body, `"name":"archive/video_trends/`) || !strings.Contains(body, `.jsonl"`) {
+		t.Errorf("upload does not name a JSONL object under archive/video_trends:\n%s", body)
+	}
+	if !strings.Contains(body, "{\"video_id\":\"v1\"}\n{\"video_id\":\"v2\"}\n") {
+		t.Errorf("body does not contain the rows:\n%s", body)
+	}
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"google.golang.org/api/googleapi"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+// SpillBuffer keeps rows that could not be written to BigQuery until they
+// are replayed with ReplaySpilled. Rows are stored as JSONL files named
+// <table>/<UTC date>/<time>-<n>.jsonl.
+type SpillBuffer interface {
+	RecordSink
+	// Spilled lists the names of the buffered files in write order.
+	Spilled(ctx context.Context) ([]string, error)
+	ReadSpilled(ctx context.Context, name string) ([]byte, error)
+	RemoveSpilled(ctx context.Context, name string) error
+}
+
+// NewSpillBuffer returns the buffer for a gs://<bucket>[/<prefix>] URI or a
+// local directory.
+func NewSpillBuffer(ctx context.Context, location string) (SpillBuffer, error) {
+	if strings.HasPrefix(location, gcsSinkScheme) {
+		return NewGCSSink(ctx, location)
+	}
+	return NewLocalSpillBuffer(location), nil
+}
+
+// spillFileName names a new file of rows for table. The name sorts by write
+// time within the table.
+func spillFileName(table string, seq int64) string {
+	now := time.Now().UTC()
+	return path.Join(table, now.Format(time.DateOnly), fmt.Sprintf("%s-%d.jsonl", now.Format("150405.000000000"), seq))
+}
+
+// spillTable returns the table a spilled file belongs to.
+func spillTable(name string) string {
+	table, _, _ := strings.Cut(name, "/")
+	return table
+}
+
+// encodeRows encodes rows as JSONL.
+func encodeRows(rows []json.RawMessage) []byte {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		buf.Write(row)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// LocalSpillBuffer keeps spilled rows in a local directory. On Cloud Run
+// the filesystem is lost with the instance, so prefer a GCS buffer there.
+type LocalSpillBuffer struct {
+	dir string
+	seq atomic.Int64
+}
+
+// NewLocalSpillBuffer creates a buffer in dir, which is created on the first
+// write.
+func NewLocalSpillBuffer(dir string) *LocalSpillBuffer {
+	return &LocalSpillBuffer{dir: dir}
+}
+
+// Name returns the directory.
+func (b *LocalSpillBuffer) Name() string {
+	return b.dir
+}
+
+// WriteRows writes rows to a new file. The file is written under a
+// temporary name and renamed, so a crash never leaves half a file to replay.
+func (b *LocalSpillBuffer) WriteRows(ctx context.Context, table string, rows []json.RawMessage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	name := filepath.Join(b.dir, filepath.FromSlash(spillFileName(table, b.seq.Add(1))))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, encodeRows(rows), 0o644); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return nil
+}
+
+// Spilled lists the JSONL files in the directory.
+func (b *LocalSpillBuffer) Spilled(ctx context.Context) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(b.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".jsonl") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.dir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spill directory: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadSpilled returns the content of a file.
+func (b *LocalSpillBuffer) ReadSpilled(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(b.dir, filepath.FromSlash(name)))
+}
+
+// RemoveSpilled deletes a file.
+func (b *LocalSpillBuffer) RemoveSpilled(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(b.dir, filepath.FromSlash(name)))
+}
+
+// objectName returns the object of a spilled file.
+func (s *GCSSink) objectName(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+// Spilled lists the JSONL objects under the prefix.
+func (s *GCSSink) Spilled(ctx context.Context) ([]string, error) {
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+	var names []string
+	err := s.service.Objects.List(s.bucket).Prefix(prefix).Pages(ctx, func(objs *gcs.Objects) error {
+		for _, o := range objs.Items {
+			if strings.HasSuffix(o.Name, ".jsonl") {
+				names = append(names, strings.TrimPrefix(o.Name, prefix))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage.objects.list gs://%s/%s: %w", s.bucket, prefix, err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadSpilled downloads an object.
+func (s *GCSSink) ReadSpilled(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.service.Objects.Get(s.bucket, s.objectName(name)).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("storage.objects.get %s: %w", s.objectName(name), err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// RemoveSpilled deletes an object. An object that is already gone, e.g.
+// removed by a concurrent replay, is not an error.
+func (s *GCSSink) RemoveSpilled(ctx context.Context, name string) error {
+	err := s.service.Objects.Delete(s.bucket, s.objectName(name)).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if stderrors.As(err, &apiErr) && apiErr.Code == 404 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("storage.objects.delete %s: %w", s.objectName(name), err)
+	}
+	return nil
+}
+
+// SetSpillBuffer makes the writer keep the video stats and tombstone rows
+// it could not insert in b instead of failing, until ReplaySpilled loads
+// them. Rows BigQuery rejected as invalid are not spilled; they would fail
+// again.
+func (w *BigQueryWriter) SetSpillBuffer(b SpillBuffer) {
+	w.spill = b
+}
+
+// spillFailed spills the rows of a failed insert into tableID that can
+// succeed later and returns what remains of err: nil when every failed row
+// was spilled, the invalid rows when some were rejected, or err itself
+// when there is no buffer or spilling fails too.
+func (w *BigQueryWriter) spillFailed(ctx context.Context, tableID string, rows []*bigquery.StructSaver, err error) error {
+	if err == nil || w.spill == nil {
+		return err
+	}
+
+	var remaining error
+	spill := rows
+	var partial *PartialInsertError
+	if stderrors.As(err, &partial) {
+		spill = nil
+		invalid := &PartialInsertError{Table: partial.Table, Total: partial.Total, Reasons: map[string]int{}}
+		for _, r := range partial.rows {
+			if r.reason == reasonInvalid {
+				invalid.Failed++
+				invalid.Reasons[r.reason]++
+				invalid.rows = append(invalid.rows, r)
+				continue
+			}
+			spill = append(spill, r.saver)
+		}
+		if invalid.Failed > 0 {
+			remaining = invalid
+		}
+	}
+	if len(spill) == 0 {
+		return err
+	}
+
+	records := make([]interface{}, len(spill))
+	for i, r := range spill {
+		records[i] = r.Struct
+	}
+	if !w.spillRows(ctx, tableID, records, err) {
+		return err
+	}
+	return remaining
+}
+
+// spillRows writes records to the spill buffer and reports whether they
+// were kept. cause is the insert error being worked around.
+func (w *BigQueryWriter) spillRows(ctx context.Context, tableID string, records []interface{}, cause error) bool {
+	log := logger.FromContext(ctx)
+	rows := make([]json.RawMessage, len(records))
+	for i, r := range records {
+		b, err := json.Marshal(r)
+		if err != nil {
+			log.Error("Failed to encode row for the spill buffer", err, map[string]string{"table": tableID})
+			return false
+		}
+		rows[i] = b
+	}
+	if err := w.spill.WriteRows(ctx, tableID, rows); err != nil {
+		log.Error("Failed to spill rows that could not be inserted", err, map[string]string{
+			"table":  tableID,
+			"buffer": w.spill.Name(),
+		})
+		return false
+	}
+	log.Warning("Spilled rows that could not be inserted; replay them with flush", cause, map[string]string{
+		"table":  tableID,
+		"rows":   strconv.Itoa(len(rows)),
+		"buffer": w.spill.Name(),
+	})
+	if w.metrics != nil {
+		w.metrics.RecordFailedRows(w.datasetID, tableID, "spilled", len(rows))
+	}
+	return true
+}
+
+// ReplayResult describes what ReplaySpilled loaded.
+type ReplayResult struct {
+	Files int
+	Rows  int
+}
+
+// ReplaySpilled loads the files of b into their tables with load jobs, in
+// write order, removing each file once it is loaded. It stops at the first
+// failure, leaving that file and the later ones for the next replay. Files
+// for tables the writer does not spill are skipped.
+func (w *BigQueryWriter) ReplaySpilled(ctx context.Context, b SpillBuffer) (*ReplayResult, error) {
+	log := logger.FromContext(ctx)
+	names, err := b.Spilled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReplayResult{}
+	for _, name := range names {
+		table := spillTable(name)
+		if table != w.tableID {
+			log.Warning("Skipping spilled file for another table", nil, map[string]string{"file": name, "table": table})
+			continue
+		}
+		data, err := b.ReadSpilled(ctx, name)
+		if err != nil {
+			return result, fmt.Errorf("failed to read spilled file %s: %w", name, err)
+		}
+		if err := w.appendNDJSON(ctx, table, data); err != nil {
+			return result, fmt.Errorf("failed to replay spilled file %s: %w", name, err)
+		}
+		if err := b.RemoveSpilled(ctx, name); err != nil {
+			// Loaded but still buffered: the next replay would load it again.
+			return result, fmt.Errorf("replayed spilled file %s but failed to remove it: %w", name, err)
+		}
+		result.Files++
+		result.Rows += bytes.Count(data, []byte("\n"))
+		log.Info("Replayed spilled rows", map[string]string{"file": name, "table": table})
+	}
+	return result, nil
+}
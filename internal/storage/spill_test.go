@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+)
+
+func TestLocalSpillBuffer(t *testing.T) {
+	ctx := context.Background()
+	b := NewLocalSpillBuffer(filepath.Join(t.TempDir(), "spill"))
+
+	if names, err := b.Spilled(ctx); err != nil || len(names) != 0 {
+		t.Fatalf("Spilled() on a missing directory = %v, %v; want none", names, err)
+	}
+	for _, id := range []string{"v1", "v2"} {
+		if err := b.WriteRows(ctx, "video_trends", []json.RawMessage{[]byte(`{"video_id":"` + id + `"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := b.Spilled(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || spillTable(names[0]) != "video_trends" || !strings.HasSuffix(names[0], ".jsonl") {
+		t.Fatalf("Spilled() = %v, want two video_trends files", names)
+	}
+	data, err := b.ReadSpilled(ctx, names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"video_id\":\"v1\"}\n" {
+		t.Errorf("first file = %q, want the first write", data)
+	}
+	if err := b.RemoveSpilled(ctx, names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := b.Spilled(ctx); !reflect.DeepEqual(rest, names[1:]) {
+		t.Errorf("Spilled() after remove = %v, want %v", rest, names[1:])
+	}
+}
+
+type fakeSpillBuffer struct {
+	fakeSink
+}
+
+func (f *fakeSpillBuffer) Spilled(ctx context.Context) ([]string, error) { return nil, nil }
+func (f *fakeSpillBuffer) ReadSpilled(ctx context.Context, name string) ([]byte, error) {
+	return nil, nil
+}
+func (f *fakeSpillBuffer) RemoveSpilled(ctx context.Context, name string) error { return nil }
+
+func TestSpillFailed(t *testing.T) {
+	ctx := context.Background()
+	rows := []*bigquery.StructSaver{
+		{Struct: &VideoStatsRecord{VideoID: "v1"}},
+		{Struct: &VideoStatsRecord{VideoID: "v2"}},
+		{Struct: &VideoStatsRecord{VideoID: "v3"}},
+	}
+
+	t.Run("partial failure", func(t *testing.T) {
+		buf := &fakeSpillBuffer{}
+		w := &BigQueryWriter{spill: buf}
+		err := w.spillFailed(ctx, "trends", rows, &PartialInsertError{
+			Table: "trends", Total: 3, Failed: 2,
+			Reasons: map[string]int{reasonInvalid: 1, "backendError": 1},
+			rows:    []failedRow{{rows[0], reasonInvalid}, {rows[2], "backendError"}},
+		})
+		var partial *PartialInsertError
+		if !stderrors.As(err, &partial) || partial.Failed != 1 || partial.Reasons[reasonInvalid] != 1 {
+			t.Fatalf("err = %v, want only the invalid row", err)
+		}
+		spilled := buf.rows["trends"]
+		if len(spilled) != 1 || !strings.Contains(string(spilled[0]), `"video_id":"v3"`) {
+			t.Errorf("spilled %s, want v3", spilled)
+		}
+	})
+
+	t.Run("request failure", func(t *testing.T) {
+		buf := &fakeSpillBuffer{}
+		w := &BigQueryWriter{spill: buf}
+		if err := w.spillFailed(ctx, "trends", rows, fmt.Errorf("503 backend error")); err != nil {
+			t.Errorf("err = %v, want nil once every row is spilled", err)
+		}
+		if len(buf.rows["trends"]) != 3 {
+			t.Errorf("spilled %d rows, want 3", len(buf.rows["trends"]))
+		}
+	})
+
+	t.Run("buffer failure", func(t *testing.T) {
+		cause := fmt.Errorf("503 backend error")
+		w := &BigQueryWriter{spill: &fakeSpillBuffer{fakeSink{err: fmt.Errorf("disk full")}}}
+		if err := w.spillFailed(ctx, "trends", rows, cause); err != cause {
+			t.Errorf("err = %v, want the insert error", err)
+		}
+	})
+}
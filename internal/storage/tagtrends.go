@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// TagTrendsTableID is the table that stores daily per-tag aggregates.
+const TagTrendsTableID = "tag_trends"
+
+// TagInput is a video's latest snapshot on a date with the text its tags
+// are taken from and its views on the latest earlier snapshot.
+type TagInput struct {
+	ChannelID   string   `bigquery:"channel_id"`
+	VideoID     string   `bigquery:"video_id"`
+	Title       string   `bigquery:"title"`
+	Description string   `bigquery:"description"`
+	Tags        []string `bigquery:"tags"`
+	Views       int64    `bigquery:"views"`
+	// PrevViews is NULL for videos first seen on the date.
+	PrevViews bigquery.NullInt64 `bigquery:"prev_views"`
+}
+
+// TagTrendRecord aggregates the videos carrying a tag on a date.
+type TagTrendRecord struct {
+	Dt           civil.Date `bigquery:"dt" json:"dt"`
+	Tag          string     `bigquery:"tag" json:"tag"`
+	VideoCount   int64      `bigquery:"video_count" json:"video_count"`
+	ChannelCount int64      `bigquery:"channel_count" json:"channel_count"`
+	TotalViews   int64      `bigquery:"total_views" json:"total_views"`
+	ViewsGained  int64      `bigquery:"views_gained" json:"views_gained"`
+	ComputedAt   time.Time  `bigquery:"computed_at" json:"computed_at"`
+}
+
+func getTagTrendsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",            "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "tag",           "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_count",   "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "channel_count", "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "total_views",   "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "views_gained",  "type": "INTEGER",   "mode": "REQUIRED"},
+	  {"name": "computed_at",   "type": "TIMESTAMP", "mode": "REQUIRED"}
+	]`)
+}
+
+// EnsureTagTrendsTable creates the tag trends table if needed.
+func (w *BigQueryWriter) EnsureTagTrendsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, TagTrendsTableID, getTagTrendsSchemaJSON(), "dt", []string{"tag"})
+}
+
+// TagInputs returns the latest snapshot on date of every video, with its
+// views on the latest snapshot from the 7 days before.
+func (w *BigQueryWriter) TagInputs(ctx context.Context, date civil.Date) ([]TagInput, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH cur AS (
+			SELECT channel_id, video_id, IFNULL(title, '') AS title,
+				IFNULL(description, '') AS description, tags, views
+			FROM %[1]s
+			WHERE dt = @date AND views IS NOT NULL
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		prev AS (
+			SELECT video_id, views AS prev_views
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND DATE_SUB(@date, INTERVAL 1 DAY)
+				AND views IS NOT NULL
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		)
+		SELECT cur.*, prev.prev_views
+		FROM cur
+		LEFT JOIN prev USING (video_id)`, w.tableRef(), trendBaselineDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[TagInput](ctx, q, "tag inputs")
+}
+
+// ReplaceTagTrends replaces the date's partition of the tag trends table
+// with records using a load job, so recomputing a date never duplicates
+// rows. Every record must be for date.
+func (w *BigQueryWriter) ReplaceTagTrends(ctx context.Context, date civil.Date, records []*TagTrendRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if r.Dt != date {
+			return fmt.Errorf("tag trend for %q is dated %s, not %s", r.Tag, r.Dt, date)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode tag trend %q: %w", r.Tag, err)
+		}
+	}
+
+	return w.replacePartition(ctx, TagTrendsTableID, date, buf.Bytes())
+}
+
+// replacePartition replaces the date's partition of tableID with data, as
+// newline-delimited JSON rows, using a load job. Loads are atomic, so a
+// failed load leaves the previous rows in place.
+func (w *BigQueryWriter) replacePartition(ctx context.Context, tableID string, date civil.Date, data []byte) error {
+	source := bigquery.NewReaderSource(bytes.NewReader(data))
+	source.SourceFormat = bigquery.JSON
+
+	partition := fmt.Sprintf("%s$%04d%02d%02d", tableID, date.Year, date.Month, date.Day)
+	loader := w.client.Dataset(w.datasetID).Table(partition).LoaderFrom(source)
+	loader.WriteDisposition = bigquery.WriteTruncate
+	loader.CreateDisposition = bigquery.CreateNever
+
+	job, err := loader.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start %s load job: %w", tableID, err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed waiting for load job %s: %w", job.ID(), err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("load job %s failed: %w", job.ID(), err)
+	}
+	return nil
+}
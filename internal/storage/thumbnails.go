@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+)
+
+// VideoThumbnailsTableID is the table that records every version of a
+// video's thumbnail archived to Cloud Storage.
+const VideoThumbnailsTableID = "video_thumbnails"
+
+// VideoThumbnailRecord is a thumbnail version first seen at a snapshot.
+type VideoThumbnailRecord struct {
+	Dt           civil.Date `bigquery:"dt" json:"dt"`
+	ArchivedAt   time.Time  `bigquery:"archived_at" json:"archived_at"`
+	ChannelID    string     `bigquery:"channel_id" json:"channel_id"`
+	VideoID      string     `bigquery:"video_id" json:"video_id"`
+	ThumbnailURL string     `bigquery:"thumbnail_url" json:"thumbnail_url"`
+	// SHA256 is the hex digest of the image, and PreviousSHA256 that of the
+	// version it replaced, NULL for the first version archived.
+	SHA256         string              `bigquery:"sha256" json:"sha256"`
+	PreviousSHA256 bigquery.NullString `bigquery:"previous_sha256" json:"previous_sha256"`
+	// GCSPath is the gs://<bucket>/<object> the image is stored at.
+	GCSPath     string `bigquery:"gcs_path" json:"gcs_path"`
+	ContentType string `bigquery:"content_type" json:"content_type"`
+	Bytes       int64  `bigquery:"bytes" json:"bytes"`
+}
+
+func getVideoThumbnailsSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",              "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "archived_at",     "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",      "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_id",        "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "thumbnail_url",   "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "sha256",          "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "previous_sha256", "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "gcs_path",        "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "content_type",    "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "bytes",           "type": "INTEGER",   "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureVideoThumbnailsTable creates the video thumbnails table if needed.
+func (w *BigQueryWriter) EnsureVideoThumbnailsTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, VideoThumbnailsTableID, getVideoThumbnailsSchemaJSON(), "dt", []string{"channel_id", "video_id"})
+}
+
+// LatestThumbnailHashes returns the SHA256 of the latest archived thumbnail
+// of each of videoIDs that has one.
+func (w *BigQueryWriter) LatestThumbnailHashes(ctx context.Context, videoIDs []string) (map[string]string, error) {
+	if len(videoIDs) == 0 {
+		return nil, nil
+	}
+	q := w.client.Query(fmt.Sprintf(`
+		SELECT video_id, ARRAY_AGG(sha256 ORDER BY archived_at DESC LIMIT 1)[OFFSET(0)] AS sha256
+		FROM %s
+		WHERE video_id IN UNNEST(@ids)
+		GROUP BY video_id`,
+		w.channelTableRef(VideoThumbnailsTableID)))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "ids", Value: videoIDs},
+	}
+	rows, err := readAll[struct {
+		VideoID string `bigquery:"video_id"`
+		SHA256  string `bigquery:"sha256"`
+	}](ctx, q, "thumbnail hashes")
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(rows))
+	for _, r := range rows {
+		hashes[r.VideoID] = r.SHA256
+	}
+	return hashes, nil
+}
+
+// InsertVideoThumbnails inserts archived thumbnail versions.
+func (w *BigQueryWriter) InsertVideoThumbnails(ctx context.Context, records []*VideoThumbnailRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(VideoThumbnailsTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert video thumbnails into BigQuery: %w", err)
+	}
+
+	return nil
+}
+
+// GCSThumbnailArchive stores thumbnail images under
+// <prefix>/<video_id>/<sha256>.<ext> in a bucket. Objects are named after
+// their content, so a version is uploaded once and never overwritten.
+type GCSThumbnailArchive struct {
+	service *gcs.Service
+	bucket  string
+	prefix  string
+}
+
+// NewGCSThumbnailArchive creates an archive for gs://<bucket>[/<prefix>].
+func NewGCSThumbnailArchive(ctx context.Context, uri string, opts ...option.ClientOption) (*GCSThumbnailArchive, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(uri, gcsSinkScheme), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("thumbnail archive must be gs://<bucket>[/<prefix>]: %q", uri)
+	}
+	opts = append(opts, option.WithScopes(gcs.DevstorageReadWriteScope))
+	svc, err := gcs.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewService: %w", err)
+	}
+	return &GCSThumbnailArchive{service: svc, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// PutThumbnail uploads an image unless the object already exists and
+// returns its gs:// path.
+func (a *GCSThumbnailArchive) PutThumbnail(ctx context.Context, videoID, sha256, contentType string, data []byte) (string, error) {
+	name := path.Join(a.prefix, videoID, sha256+thumbnailExt(contentType))
+	obj := &gcs.Object{Name: name, ContentType: contentType}
+	_, err := a.service.Objects.Insert(a.bucket, obj).IfGenerationMatch(0).Media(bytes.NewReader(data)).Context(ctx).Do()
+	var gerr *googleapi.Error
+	if err != nil && !(stderrors.As(err, &gerr) && gerr.Code == http.StatusPreconditionFailed) {
+		return "", fmt.Errorf("storage.objects.insert %s: %w", name, err)
+	}
+	return "gs://" + a.bucket + "/" + name, nil
+}
+
+// thumbnailExt returns the file extension of an image content type.
+func thumbnailExt(contentType string) string {
+	switch strings.TrimSpace(strings.Split(contentType, ";")[0]) {
+	case "image/webp":
+		return ".webp"
+	case "image/png":
+		return ".png"
+	default:
+		return ".jpg"
+	}
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+)
+
+// VideoTrendScoresTableID is the table that stores computed trend scores.
+const VideoTrendScoresTableID = "video_trend_scores"
+
+// trendBaselineDays is how far back TrendInputs looks for a video's previous
+// snapshot.
+const trendBaselineDays = 7
+
+// TrendInput is a video's latest snapshot on a date together with its most
+// recent snapshot from an earlier date, the baseline for view velocity.
+type TrendInput struct {
+	ChannelID   string    `bigquery:"channel_id"`
+	VideoID     string    `bigquery:"video_id"`
+	Title       string    `bigquery:"title"`
+	Views       int64     `bigquery:"views"`
+	SnapshotTs  time.Time `bigquery:"snapshot_ts"`
+	PublishedAt time.Time `bigquery:"published_at"`
+	// PrevViews and PrevSnapshotTs are NULL for videos first seen on the date.
+	PrevViews      bigquery.NullInt64     `bigquery:"prev_views"`
+	PrevSnapshotTs bigquery.NullTimestamp `bigquery:"prev_snapshot_ts"`
+	// ChannelMedianViews is the median views of the channel's videos on the
+	// date, a measure of channel size.
+	ChannelMedianViews int64 `bigquery:"channel_median_views"`
+}
+
+// TrendScoreRecord is the trend score of a video on a date.
+type TrendScoreRecord struct {
+	Dt                 civil.Date `bigquery:"dt"`
+	ComputedAt         time.Time  `bigquery:"computed_at"`
+	ChannelID          string     `bigquery:"channel_id"`
+	VideoID            string     `bigquery:"video_id"`
+	Title              string     `bigquery:"title"`
+	Formula            string     `bigquery:"formula"`
+	Score              float64    `bigquery:"score"`
+	Views              int64      `bigquery:"views"`
+	ViewsGained        int64      `bigquery:"views_gained"`
+	Hours              float64    `bigquery:"hours"`
+	AgeHours           float64    `bigquery:"age_hours"`
+	ChannelMedianViews int64      `bigquery:"channel_median_views"`
+	// ForecastViews7d and ForecastViews30d are the views projected 7 and 30
+	// days after the snapshot, NULL unless analytics.forecast is enabled.
+	ForecastViews7d  bigquery.NullInt64 `bigquery:"forecast_views_7d"`
+	ForecastViews30d bigquery.NullInt64 `bigquery:"forecast_views_30d"`
+}
+
+func getVideoTrendScoresSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "dt",                   "type": "DATE",      "mode": "REQUIRED"},
+	  {"name": "computed_at",          "type": "TIMESTAMP", "mode": "REQUIRED"},
+	  {"name": "channel_id",           "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "video_id",             "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "title",                "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "formula",              "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "score",                "type": "FLOAT",     "mode": "REQUIRED"},
+	  {"name": "views",                "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "views_gained",         "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "hours",                "type": "FLOAT",     "mode": "NULLABLE"},
+	  {"name": "age_hours",            "type": "FLOAT",     "mode": "NULLABLE"},
+	  {"name": "channel_median_views", "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "forecast_views_7d",    "type": "INTEGER",   "mode": "NULLABLE"},
+	  {"name": "forecast_views_30d",   "type": "INTEGER",   "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureVideoTrendScoresTable creates the trend scores table if needed and
+// adds the forecast columns to tables created without them.
+func (w *BigQueryWriter) EnsureVideoTrendScoresTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	if err := w.ensureTable(ctx, VideoTrendScoresTableID, getVideoTrendScoresSchemaJSON(), "dt", []string{"channel_id", "video_id"}); err != nil {
+		return err
+	}
+	return w.addMissingColumns(ctx, VideoTrendScoresTableID, getVideoTrendScoresSchemaJSON())
+}
+
+// TrendInputs returns the inputs for scoring every video with a snapshot on
+// date. Rows written before snapshot_ts existed fall back to created_at.
+func (w *BigQueryWriter) TrendInputs(ctx context.Context, date civil.Date) ([]TrendInput, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH cur AS (
+			SELECT channel_id, video_id, IFNULL(title, '') AS title, views,
+				IFNULL(snapshot_ts, created_at) AS snapshot_ts,
+				IFNULL(published_at, TIMESTAMP_SECONDS(0)) AS published_at
+			FROM %[1]s
+			WHERE dt = @date AND views IS NOT NULL
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		prev AS (
+			SELECT video_id, views AS prev_views, IFNULL(snapshot_ts, created_at) AS prev_snapshot_ts
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[2]d DAY) AND DATE_SUB(@date, INTERVAL 1 DAY)
+				AND views IS NOT NULL
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		),
+		med AS (
+			SELECT channel_id, APPROX_QUANTILES(views, 2)[OFFSET(1)] AS channel_median_views
+			FROM (
+				SELECT channel_id, views
+				FROM %[1]s
+				WHERE dt = @date AND views IS NOT NULL AND `+channelRows+`
+				QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+			)
+			GROUP BY channel_id
+		)
+		SELECT cur.*, prev.prev_views, prev.prev_snapshot_ts, IFNULL(med.channel_median_views, 0) AS channel_median_views
+		FROM cur
+		LEFT JOIN prev USING (video_id)
+		LEFT JOIN med USING (channel_id)`, w.tableRef(), trendBaselineDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+	}
+	return readAll[TrendInput](ctx, q, "trend inputs")
+}
+
+// InsertTrendScores inserts computed trend scores. Each computation appends
+// rows; readers should take the latest computed_at per video and date.
+func (w *BigQueryWriter) InsertTrendScores(ctx context.Context, records []*TrendScoreRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	inserter := w.client.Dataset(w.datasetID).Table(VideoTrendScoresTableID).Inserter()
+	if err := inserter.Put(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert trend scores into BigQuery: %w", err)
+	}
+	return nil
+}
+
+// trendingLookbackDays is how far back TrendingVideos looks for the latest
+// date with trend scores, e.g. when the scorer has not run yet today.
+const trendingLookbackDays = 7
+
+// TrendingVideo is a video ranked by its latest trend score on a date,
+// with the details of its latest snapshot that day.
+type TrendingVideo struct {
+	Dt           civil.Date `bigquery:"dt" json:"dt"`
+	ComputedAt   time.Time  `bigquery:"computed_at" json:"computed_at"`
+	ChannelID    string     `bigquery:"channel_id" json:"channel_id"`
+	ChannelName  string     `bigquery:"channel_name" json:"channel_name"`
+	VideoID      string     `bigquery:"video_id" json:"video_id"`
+	Title        string     `bigquery:"title" json:"title"`
+	Score        float64    `bigquery:"score" json:"score"`
+	Views        int64      `bigquery:"views" json:"views"`
+	ViewsGained  int64      `bigquery:"views_gained" json:"views_gained"`
+	PublishedAt  time.Time  `bigquery:"published_at" json:"published_at"`
+	ThumbnailURL string     `bigquery:"thumbnail_url" json:"thumbnail_url"`
+}
+
+// TrendingVideos returns the limit videos with the highest trend score on
+// the latest date with scores in the trendingLookbackDays up to date,
+// highest first. It returns no videos if nothing was scored in that time.
+func (w *BigQueryWriter) TrendingVideos(ctx context.Context, date civil.Date, limit int) ([]TrendingVideo, error) {
+	q := w.client.Query(fmt.Sprintf(`
+		WITH scores AS (
+			SELECT *
+			FROM %[1]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[3]d DAY) AND @date
+		),
+		latest AS (
+			SELECT * FROM scores
+			WHERE dt = (SELECT MAX(dt) FROM scores)
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id ORDER BY computed_at DESC) = 1
+		),
+		snaps AS (
+			SELECT video_id, dt, IFNULL(channel_name, '') AS channel_name,
+				IFNULL(published_at, TIMESTAMP_SECONDS(0)) AS published_at,
+				IFNULL(thumbnail_url, '') AS thumbnail_url
+			FROM %[2]s
+			WHERE dt BETWEEN DATE_SUB(@date, INTERVAL %[3]d DAY) AND @date
+			QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id, dt ORDER BY IFNULL(snapshot_ts, created_at) DESC) = 1
+		)
+		SELECT latest.dt, latest.computed_at, latest.channel_id, IFNULL(snaps.channel_name, '') AS channel_name,
+			latest.video_id, IFNULL(latest.title, '') AS title, latest.score,
+			IFNULL(latest.views, 0) AS views, IFNULL(latest.views_gained, 0) AS views_gained,
+			IFNULL(snaps.published_at, TIMESTAMP_SECONDS(0)) AS published_at,
+			IFNULL(snaps.thumbnail_url, '') AS thumbnail_url
+		FROM latest
+		LEFT JOIN snaps USING (video_id, dt)
+		ORDER BY latest.score DESC, latest.video_id
+		LIMIT @limit`, w.trendScoresTableRef(), w.tableRef(), trendingLookbackDays))
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "date", Value: date},
+		{Name: "limit", Value: limit},
+	}
+	return readAll[TrendingVideo](ctx, q, "trending videos")
+}
+
+func (w *BigQueryWriter) trendScoresTableRef() string {
+	return fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, VideoTrendScoresTableID)
+}
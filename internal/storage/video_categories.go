@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// VideoCategoriesTableID is the dimension table of YouTube video categories,
+// for joining category_id to a name per region.
+const VideoCategoriesTableID = "video_categories"
+
+// VideoCategoryRecord is one category of one region.
+type VideoCategoryRecord struct {
+	RegionCode string    `bigquery:"region_code"`
+	CategoryID string    `bigquery:"category_id"`
+	Title      string    `bigquery:"title"`
+	Assignable bool      `bigquery:"assignable"`
+	UpdatedAt  time.Time `bigquery:"updated_at"`
+}
+
+func getVideoCategoriesSchemaJSON() []byte {
+	return []byte(`[
+	  {"name": "region_code", "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "category_id", "type": "STRING",    "mode": "REQUIRED"},
+	  {"name": "title",       "type": "STRING",    "mode": "NULLABLE"},
+	  {"name": "assignable",  "type": "BOOLEAN",   "mode": "NULLABLE"},
+	  {"name": "updated_at",  "type": "TIMESTAMP", "mode": "NULLABLE"}
+	]`)
+}
+
+// EnsureVideoCategoriesTable creates the video categories table if needed.
+func (w *BigQueryWriter) EnsureVideoCategoriesTable(ctx context.Context) error {
+	if err := w.ensureDataset(ctx); err != nil {
+		return err
+	}
+	return w.ensureTable(ctx, VideoCategoriesTableID, getVideoCategoriesSchemaJSON(), "", nil)
+}
+
+// UpsertVideoCategories inserts new categories and updates existing ones,
+// matched on region_code and category_id. Categories YouTube no longer
+// returns are kept, since old videos may still refer to them.
+func (w *BigQueryWriter) UpsertVideoCategories(ctx context.Context, records []VideoCategoryRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	q := w.client.Query(fmt.Sprintf(`
+		MERGE %s AS t
+		USING UNNEST(@rows) AS s
+		ON t.region_code = s.region_code AND t.category_id = s.category_id
+		WHEN MATCHED THEN UPDATE SET
+			title = s.title,
+			assignable = s.assignable,
+			updated_at = s.updated_at
+		WHEN NOT MATCHED THEN
+			INSERT (region_code, category_id, title, assignable, updated_at)
+			VALUES (s.region_code, s.category_id, s.title, s.assignable, s.updated_at)`,
+		fmt.Sprintf("`%s.%s.%s`", w.client.Project(), w.datasetID, VideoCategoriesTableID)))
+	q.Parameters = []bigquery.QueryParameter{{Name: "rows", Value: records}}
+
+	job, err := q.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert video categories: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to upsert video categories: %w", err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("failed to upsert video categories: %w", err)
+	}
+	return nil
+}
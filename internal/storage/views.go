@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+)
+
+// DailyViewID returns the name of the view that keeps only the last
+// snapshot of each video per day.
+func (w *BigQueryWriter) DailyViewID() string {
+	return w.tableID + "_daily"
+}
+
+// dailyViewQuery selects the latest snapshot per (dt, video_id). Rows written
+// before snapshot_ts existed fall back to created_at.
+func (w *BigQueryWriter) dailyViewQuery() string {
+	return fmt.Sprintf(`
+SELECT * EXCEPT(snapshot_rank)
+FROM (
+  SELECT
+    *,
+    ROW_NUMBER() OVER (
+      PARTITION BY dt, video_id
+      ORDER BY COALESCE(snapshot_ts, created_at) DESC
+    ) AS snapshot_rank
+  FROM %s
+)
+WHERE snapshot_rank = 1`, w.tableRef())
+}
+
+// EnsureDailyView creates the daily view over the snapshot table if it does
+// not exist, so hourly fetching keeps one-row-per-day semantics for readers.
+func (w *BigQueryWriter) EnsureDailyView(ctx context.Context) error {
+	return w.ensureView(ctx, w.DailyViewID(), w.dailyViewQuery())
+}
+
+// Analysis views created by EnsureViews next to the snapshot table.
+const (
+	LatestSnapshotViewID     = "latest_snapshot"
+	DailyDeltasViewID        = "daily_deltas"
+	ChannelDailyRollupViewID = "channel_daily_rollup"
+	TitleKeywordDailyViewID  = "title_keyword_daily"
+	PublishWeekCohortsViewID = "publish_week_cohorts"
+)
+
+// analysisViewDays bounds the partitions latest_snapshot and daily_deltas
+// read. Their window functions run over whole videos, so a filter on dt
+// in the outer query cannot prune partitions; without a bound every query
+// would scan the full table (or fail when a partition filter is required).
+const analysisViewDays = 90
+
+// cohortViewDays bounds the partitions and publications publish_week_cohorts
+// reads: 26 weeks, so that older cohorts can be compared with recent ones.
+const cohortViewDays = 182
+
+// analysisViews returns the ID and query of each analysis view.
+func (w *BigQueryWriter) analysisViews() map[string]string {
+	return map[string]string{
+		// The most recent snapshot of every video seen in the last 90 days,
+		// including tombstones (status = 'unavailable').
+		LatestSnapshotViewID: fmt.Sprintf(`
+SELECT * EXCEPT(snapshot_rank)
+FROM (
+  SELECT
+    *,
+    ROW_NUMBER() OVER (
+      PARTITION BY video_id
+      ORDER BY dt DESC, COALESCE(snapshot_ts, created_at) DESC
+    ) AS snapshot_rank
+  FROM %s
+  WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL %d DAY)
+)
+WHERE snapshot_rank = 1`, w.tableRef(), analysisViewDays),
+
+		// Day-over-day growth of every video: its last snapshot of each day
+		// compared with the previous day it was seen on (prev_dt). The
+		// deltas are NULL on the first day a video appears in the window.
+		DailyDeltasViewID: fmt.Sprintf(`
+WITH daily AS (
+  SELECT dt, channel_id, channel_name, video_id, title, is_short, views, likes, comments
+  FROM %s
+  WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL %d DAY)
+    AND views IS NOT NULL
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+SELECT
+  *,
+  LAG(dt) OVER video AS prev_dt,
+  views - LAG(views) OVER video AS views_delta,
+  likes - LAG(likes) OVER video AS likes_delta,
+  comments - LAG(comments) OVER video AS comments_delta
+FROM daily
+WINDOW video AS (PARTITION BY video_id ORDER BY dt)`, w.tableRef(), analysisViewDays),
+
+		// Per channel and day, totals over the last snapshot of each
+		// tracked video. Filters on dt reach the table, so queries on the
+		// view only scan the days they ask for.
+		ChannelDailyRollupViewID: fmt.Sprintf(`
+SELECT
+  dt,
+  channel_id,
+  ANY_VALUE(channel_name) AS channel_name,
+  COUNT(*) AS videos,
+  COUNTIF(is_short) AS shorts,
+  SUM(views) AS views,
+  SUM(likes) AS likes,
+  SUM(comments) AS comments
+FROM (
+  SELECT dt, channel_id, channel_name, video_id, is_short, views, likes, comments
+  FROM %s
+  WHERE views IS NOT NULL AND `+channelRows+`
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+GROUP BY dt, channel_id`, w.tableRef()),
+
+		// Per title keyword and day, how many videos and channels use it and
+		// their views, over the last snapshot of each video. Like
+		// channel_daily_rollup, filters on dt reach the table.
+		TitleKeywordDailyViewID: fmt.Sprintf(`
+SELECT
+  dt,
+  keyword,
+  COUNT(*) AS videos,
+  COUNT(DISTINCT channel_id) AS channels,
+  SUM(views) AS views
+FROM (
+  SELECT dt, channel_id, video_id, title_keywords, views
+  FROM %s
+  WHERE views IS NOT NULL
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY dt, video_id ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+), UNNEST(title_keywords) AS keyword
+GROUP BY dt, keyword`, w.tableRef()),
+
+		// Per channel, week of publication (from Monday, in UTC) and age in
+		// days, the cumulative views of the videos published in the last 26
+		// weeks, from the last snapshot of each video at each age. videos
+		// counts the videos with a snapshot at that age, so averages stay
+		// comparable while tracking catches up.
+		PublishWeekCohortsViewID: fmt.Sprintf(`
+SELECT
+  DATE_TRUNC(DATE(published_at), WEEK(MONDAY)) AS publish_week,
+  channel_id,
+  age_days,
+  COUNT(*) AS videos,
+  SUM(views) AS views,
+  AVG(views) AS avg_views
+FROM (
+  SELECT channel_id, video_id, published_at, views, age_days
+  FROM (
+    SELECT *, DIV(TIMESTAMP_DIFF(COALESCE(snapshot_ts, created_at), published_at, HOUR), 24) AS age_days
+    FROM %s
+    WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL %[2]d DAY)
+      AND views IS NOT NULL
+      AND published_at >= TIMESTAMP(DATE_SUB(CURRENT_DATE(), INTERVAL %[2]d DAY))
+      AND `+channelRows+`
+  )
+  WHERE age_days >= 0
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY video_id, age_days ORDER BY COALESCE(snapshot_ts, created_at) DESC) = 1
+)
+GROUP BY publish_week, channel_id, age_days`, w.tableRef(), cohortViewDays),
+	}
+}
+
+// EnsureViews creates the daily view and the analysis views
+// (latest_snapshot, daily_deltas, channel_daily_rollup, title_keyword_daily,
+// publish_week_cohorts) in the writer's dataset, and updates those whose
+// query differs from the current one, e.g. after an upgrade. All views are
+// attempted; the errors are joined.
+func (w *BigQueryWriter) EnsureViews(ctx context.Context) error {
+	errs := []error{w.EnsureDailyView(ctx)}
+	views := w.analysisViews()
+	for _, id := range []string{LatestSnapshotViewID, DailyDeltasViewID, ChannelDailyRollupViewID, TitleKeywordDailyViewID, PublishWeekCohortsViewID} {
+		errs = append(errs, w.ensureView(ctx, id, views[id]))
+	}
+	return stderrors.Join(errs...)
+}
+
+// ensureView creates the view id with the given query, or replaces the
+// query of an existing view if it differs.
+func (w *BigQueryWriter) ensureView(ctx context.Context, id, query string) error {
+	view := w.client.Dataset(w.datasetID).Table(id)
+	md, err := view.Metadata(ctx)
+	if err != nil {
+		if errors.Classify(err) == errors.ErrNotFound {
+			if err := view.Create(ctx, &bigquery.TableMetadata{ViewQuery: query}); err != nil {
+				return fmt.Errorf("failed to create view %s: %w", id, err)
+			}
+			return nil
+		}
+		return fmt.Errorf("failed to get view metadata for %s: %w", id, err)
+	}
+	if md.ViewQuery == query {
+		return nil
+	}
+	if _, err := view.Update(ctx, bigquery.TableMetadataToUpdate{ViewQuery: query}, md.ETag); err != nil {
+		return fmt.Errorf("failed to update view %s: %w", id, err)
+	}
+	return nil
+}
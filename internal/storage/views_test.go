@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/option"
+)
+
+func TestAnalysisViews(t *testing.T) {
+	client, err := bigquery.NewClient(context.Background(), "proj", option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	w := &BigQueryWriter{client: client, datasetID: "ds", tableID: "trends"}
+
+	views := w.analysisViews()
+	for _, id := range []string{LatestSnapshotViewID, DailyDeltasViewID, ChannelDailyRollupViewID, TitleKeywordDailyViewID, PublishWeekCohortsViewID} {
+		query, ok := views[id]
+		if !ok {
+			t.Errorf("no query for view %s", id)
+			continue
+		}
+		if !strings.Contains(query, "`proj.ds.trends`") {
+			t.Errorf("view %s does not read the configured table:\n%s", id, query)
+		}
+	}
+	// The views whose window functions defeat partition pruning bound the
+	// partitions they read themselves.
+	for _, id := range []string{LatestSnapshotViewID, DailyDeltasViewID} {
+		if !strings.Contains(views[id], "WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL 90 DAY)") {
+			t.Errorf("view %s does not bound the partitions it reads:\n%s", id, views[id])
+		}
+	}
+	if !strings.Contains(views[PublishWeekCohortsViewID], "WHERE dt >= DATE_SUB(CURRENT_DATE(), INTERVAL 182 DAY)") {
+		t.Errorf("view %s does not bound the partitions it reads:\n%s", PublishWeekCohortsViewID, views[PublishWeekCohortsViewID])
+	}
+	// The per-channel views leave out the rows of tracked playlists.
+	for _, id := range []string{ChannelDailyRollupViewID, PublishWeekCohortsViewID} {
+		if !strings.Contains(views[id], channelRows) {
+			t.Errorf("view %s counts playlist rows:\n%s", id, views[id])
+		}
+	}
+}
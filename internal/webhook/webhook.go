@@ -0,0 +1,198 @@
+// Package webhook POSTs events, such as run summaries and detected trends,
+// as JSON to the configured endpoints. Deliveries are signed with
+// HMAC-SHA256, retried with exponential backoff, and kept in a dead-letter
+// log when every attempt fails.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/logger"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	"github.com/lancelop89/youtube-trend-tracker/internal/storage"
+)
+
+// Headers of each delivery. The signature is "sha256=" followed by the hex
+// HMAC-SHA256, keyed by the endpoint secret, of the timestamp, a dot and
+// the body.
+const (
+	EventHeader     = "X-Webhook-Event"
+	IDHeader        = "X-Webhook-ID"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// DeadLetterTable names the dead-letter files, as a spill buffer does with
+// the rows of a table.
+const DeadLetterTable = "webhook_dead_letters"
+
+// httpTimeout bounds each delivery attempt.
+const httpTimeout = 30 * time.Second
+
+// Event is the body of a delivery.
+type Event struct {
+	// ID is the same for every endpoint and attempt, so receivers can
+	// ignore repeated deliveries.
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// NewEvent returns an event of eventType with a new ID.
+func NewEvent(eventType string, data interface{}) Event {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return Event{ID: hex.EncodeToString(b), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+}
+
+// DeadLetter is a delivery that failed every attempt.
+type DeadLetter struct {
+	FailedAt time.Time `json:"failed_at"`
+	URL      string    `json:"url"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Event    Event     `json:"event"`
+}
+
+// Dispatcher delivers events to the endpoints subscribed to them.
+type Dispatcher struct {
+	client     *http.Client
+	endpoints  []config.WebhookEndpointConfig
+	retry      retry.Config
+	deadLetter storage.RecordSink
+}
+
+// New creates a dispatcher for c. Retries are counted in m, which may be
+// nil.
+func New(ctx context.Context, c config.WebhooksConfig, m *metrics.Metrics) (*Dispatcher, error) {
+	d := &Dispatcher{
+		client:    &http.Client{Timeout: httpTimeout},
+		endpoints: c.Endpoints,
+		retry: retry.WithMetrics(retry.Config{
+			MaxAttempts:  c.MaxAttempts,
+			InitialDelay: c.InitialBackoff,
+			MaxDelay:     c.MaxBackoff,
+			Multiplier:   2,
+		}, m, "webhook"),
+	}
+	if c.DeadLetter != "" {
+		buf, err := storage.NewSpillBuffer(ctx, c.DeadLetter)
+		if err != nil {
+			return nil, fmt.Errorf("webhook dead letter: %w", err)
+		}
+		d.deadLetter = buf
+	}
+	return d, nil
+}
+
+// Send delivers e to every endpoint subscribed to its type. A delivery that
+// fails every attempt is logged and written to the dead-letter log; Send
+// returns the errors of those deliveries.
+func (d *Dispatcher) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	var errs []error
+	for _, ep := range d.endpoints {
+		if len(ep.Events) > 0 && !slices.Contains(ep.Events, e.Type) {
+			continue
+		}
+		attempts := 0
+		err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+			attempts++
+			return d.post(ctx, ep, e, body)
+		}, d.retry)
+		if err != nil {
+			d.deadLetterDelivery(ctx, ep.URL, attempts, err, e)
+			errs = append(errs, fmt.Errorf("%s: %w", ep.URL, err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// post makes one delivery attempt. Network errors, 429 and 5xx responses
+// are temporary, and so retried; other responses are not.
+func (d *Dispatcher) post(ctx context.Context, ep config.WebhookEndpointConfig, e Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Validation("invalid webhook request", err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, e.Type)
+	req.Header.Set(IDHeader, e.ID)
+	req.Header.Set(TimestampHeader, ts)
+	if ep.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(ep.Secret, ts, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Temporary("failed to POST webhook", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	msg := fmt.Sprintf("webhook endpoint returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return errors.Temporary(msg, nil)
+	}
+	return errors.API(msg, nil)
+}
+
+// deadLetterDelivery logs a failed delivery and keeps it in the dead-letter
+// log, if any.
+func (d *Dispatcher) deadLetterDelivery(ctx context.Context, url string, attempts int, err error, e Event) {
+	log := logger.FromContext(ctx)
+	labels := map[string]string{"event": e.Type, "event_id": e.ID, "attempts": strconv.Itoa(attempts)}
+	log.Error("Webhook delivery failed", err, labels)
+	if d.deadLetter == nil {
+		return
+	}
+	row, jerr := json.Marshal(DeadLetter{FailedAt: time.Now().UTC(), URL: url, Attempts: attempts, Error: err.Error(), Event: e})
+	if jerr == nil {
+		// The caller's context may have run out with the retries.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), httpTimeout)
+		defer cancel()
+		jerr = d.deadLetter.WriteRows(ctx, DeadLetterTable, []json.RawMessage{row})
+	}
+	if jerr != nil {
+		log.Error("Failed to write webhook dead letter", jerr, labels)
+	}
+}
+
+// Sign returns the signature header value of body sent at timestamp, in
+// Unix seconds.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body sent at
+// timestamp, for receivers written in Go. Receivers should also reject old
+// timestamps to prevent replays.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/config"
+)
+
+func testConfig(deadLetter string, endpoints ...config.WebhookEndpointConfig) config.WebhooksConfig {
+	return config.WebhooksConfig{
+		Endpoints:      endpoints,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		DeadLetter:     deadLetter,
+	}
+}
+
+func TestSend_SignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("s3cret", r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body) {
+			t.Errorf("signature %q does not verify", r.Header.Get(SignatureHeader))
+		}
+		if got := r.Header.Get(EventHeader); got != config.WebhookEventRunCompleted {
+			t.Errorf("%s = %q", EventHeader, got)
+		}
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil || e.ID != r.Header.Get(IDHeader) {
+			t.Errorf("body %s does not match %s %q", body, IDHeader, r.Header.Get(IDHeader))
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	d, err := New(context.Background(), testConfig("", config.WebhookEndpointConfig{URL: srv.URL, Secret: "s3cret"}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Send(context.Background(), NewEvent(config.WebhookEventRunCompleted, map[string]int{"videos_written": 3})); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("endpoint called %d times, want 3", calls.Load())
+	}
+}
+
+func TestSend_DeadLetter(t *testing.T) {
+	var rejected, failing atomic.Int32
+	reject := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejected.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer reject.Close()
+	fail := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer fail.Close()
+	var other atomic.Int32
+	unsubscribed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { other.Add(1) }))
+	defer unsubscribed.Close()
+
+	dir := t.TempDir()
+	d, err := New(context.Background(), testConfig(dir,
+		config.WebhookEndpointConfig{URL: reject.URL},
+		config.WebhookEndpointConfig{URL: fail.URL},
+		config.WebhookEndpointConfig{URL: unsubscribed.URL, Events: []string{config.WebhookEventRunCompleted}},
+	), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Send(context.Background(), NewEvent(config.WebhookEventTrendDetected, nil)); err == nil {
+		t.Fatal("Send() error = nil, want the failed deliveries")
+	}
+	// 4xx responses are not retried.
+	if rejected.Load() != 1 || failing.Load() != 3 || other.Load() != 0 {
+		t.Errorf("calls = %d, %d, %d; want 1, 3, 0", rejected.Load(), failing.Load(), other.Load())
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, DeadLetterTable, "*", "*.jsonl"))
+	var letters []DeadLetter
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for sc := bufio.NewScanner(f); sc.Scan(); {
+			var l DeadLetter
+			if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+				t.Fatal(err)
+			}
+			letters = append(letters, l)
+		}
+		f.Close()
+	}
+	if len(letters) != 2 {
+		t.Fatalf("dead letters = %+v, want 2", letters)
+	}
+	for _, l := range letters {
+		if l.Event.Type != config.WebhookEventTrendDetected || l.Error == "" {
+			t.Errorf("dead letter = %+v", l)
+		}
+		if l.URL == fail.URL && l.Attempts != 3 {
+			t.Errorf("attempts = %d, want 3", l.Attempts)
+		}
+	}
+}
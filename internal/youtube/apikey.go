@@ -0,0 +1,105 @@
+package youtube
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// APIKeyError reports an API key the Data API refused, with what to do
+// about it.
+type APIKeyError struct {
+	// Reason is the error reason from the API, e.g. "keyInvalid",
+	// "accessNotConfigured" or "API_KEY_SERVICE_BLOCKED".
+	Reason string
+	// Hint tells the operator how to fix the key.
+	Hint string
+	Err  error
+}
+
+func (e *APIKeyError) Error() string {
+	return fmt.Sprintf("YouTube API key rejected (%s): %s", e.Reason, e.Hint)
+}
+
+func (e *APIKeyError) Unwrap() error { return e.Err }
+
+// Fixes for the API key problems.
+const (
+	hintKeyInvalid = "the key does not exist or was deleted; check YOUTUBE_API_KEY (or its Secret Manager secret) " +
+		"against the Credentials page of the Cloud console"
+	hintKeyExpired  = "the key has expired; create a new one and update YOUTUBE_API_KEY"
+	hintAPIDisabled = "the YouTube Data API v3 is not enabled in the key's project; " +
+		"run `gcloud services enable youtube.googleapis.com --project <key's project>` and retry after a few minutes"
+	hintAPIBlocked      = "the key's API restrictions do not include the YouTube Data API v3; add it under the key's API restrictions"
+	hintReferrerBlocked = "the key is restricted to HTTP referrers, which server requests do not send; " +
+		"use a key restricted by IP address or not at all"
+	hintIPBlocked = "the key's IP address restrictions do not include this server's egress address"
+)
+
+// apiKeyHints maps the reasons the API refuses a key with to their fix.
+// Newer responses carry an ErrorInfo reason in the details, older ones
+// only the legacy reason of the error item.
+var apiKeyHints = map[string]string{
+	"keyInvalid":                    hintKeyInvalid,
+	"API_KEY_INVALID":               hintKeyInvalid,
+	"keyExpired":                    hintKeyExpired,
+	"API_KEY_EXPIRED":               hintKeyExpired,
+	"accessNotConfigured":           hintAPIDisabled,
+	"SERVICE_DISABLED":              hintAPIDisabled,
+	"API_KEY_SERVICE_BLOCKED":       hintAPIBlocked,
+	"ipRefererBlocked":              hintReferrerBlocked,
+	"API_KEY_HTTP_REFERRER_BLOCKED": hintReferrerBlocked,
+	"API_KEY_IP_ADDRESS_BLOCKED":    hintIPBlocked,
+}
+
+// VerifyAPIKey makes one i18nLanguages.list call (1 quota unit) to confirm
+// that the API key is valid and enabled for the YouTube Data API. A key the
+// API refuses is reported as an *APIKeyError. A key whose daily quota is
+// exhausted is valid. Other failures, such as a network error, are returned
+// as they are: they say nothing about the key.
+func (c *Client) VerifyAPIKey(ctx context.Context) error {
+	if err := c.acquire(ctx, 1); err != nil {
+		return err
+	}
+	_, err := c.service.I18nLanguages.List([]string{"id"}).Context(ctx).Do()
+	if err == nil {
+		return nil
+	}
+	var gerr *googleapi.Error
+	if !stderrors.As(err, &gerr) {
+		return fmt.Errorf("i18nLanguages.list: %w", err)
+	}
+	for _, reason := range errorReasons(gerr) {
+		if hint, ok := apiKeyHints[reason]; ok {
+			return &APIKeyError{Reason: reason, Hint: hint, Err: err}
+		}
+		if reason == "quotaExceeded" || reason == "dailyLimitExceeded" {
+			return nil
+		}
+	}
+	if gerr.Code == http.StatusBadRequest && strings.Contains(gerr.Message, "API key not valid") {
+		return &APIKeyError{Reason: "keyInvalid", Hint: hintKeyInvalid, Err: err}
+	}
+	return c.apiError("YouTube API key check failed", err)
+}
+
+// errorReasons returns the ErrorInfo reasons in the details of gerr,
+// followed by the reasons of its error items.
+func errorReasons(gerr *googleapi.Error) []string {
+	var reasons []string
+	for _, d := range gerr.Details {
+		if m, ok := d.(map[string]interface{}); ok {
+			if r, ok := m["reason"].(string); ok && r != "" {
+				reasons = append(reasons, r)
+			}
+		}
+	}
+	for _, item := range gerr.Errors {
+		reasons = append(reasons, item.Reason)
+	}
+	return reasons
+}
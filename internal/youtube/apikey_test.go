@@ -0,0 +1,53 @@
+package youtube
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		body       string
+		wantReason string // "" for a valid key
+		wantErr    bool
+	}{
+		{"valid", 200, `{"items":[]}`, "", false},
+		{"invalid key", 400, `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","errors":[{"reason":"badRequest"}],
+			"details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"API_KEY_INVALID"}]}}`, "API_KEY_INVALID", true},
+		{"api not enabled", 403, `{"error":{"code":403,"message":"YouTube Data API v3 has not been used in project 1 before or it is disabled.",
+			"errors":[{"reason":"accessNotConfigured"}]}}`, "accessNotConfigured", true},
+		{"quota exhausted", 403, `{"error":{"code":403,"message":"quota","errors":[{"reason":"quotaExceeded"}]}}`, "", false},
+		{"server error", 500, `{"error":{"code":500,"message":"backend error"}}`, "", true},
+	}
+	for _, tt := range tests {
+		var path string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tt.code)
+			w.Write([]byte(tt.body))
+		}))
+		c, err := NewClientWithOptions(context.Background(), "key", ClientOptions{HTTPClient: srv.Client(), Endpoint: srv.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.VerifyAPIKey(context.Background())
+		srv.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: VerifyAPIKey() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		var keyErr *APIKeyError
+		if got := stderrors.As(err, &keyErr); got != (tt.wantReason != "") || (got && keyErr.Reason != tt.wantReason) {
+			t.Errorf("%s: VerifyAPIKey() error = %v, want reason %q", tt.name, err, tt.wantReason)
+		}
+		if path != "/youtube/v3/i18nLanguages" || c.QuotaUsed() != 1 {
+			t.Errorf("%s: requested %s for %d units, want i18nLanguages.list for 1", tt.name, path, c.QuotaUsed())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	yt "google.golang.org/api/youtube/v3"
+)
+
+// VideoCategory is a video category of a region, e.g. "22" People & Blogs.
+type VideoCategory struct {
+	ID    string
+	Title string
+	// Assignable is false for categories uploaders can no longer choose;
+	// older videos may still have them.
+	Assignable bool
+}
+
+// VideoCategories returns the video categories of a region (an ISO 3166-1
+// alpha-2 code such as "JP") with titles in language hl (e.g. "ja"; empty
+// for English). It costs one videoCategories.list call.
+func (c *Client) VideoCategories(ctx context.Context, regionCode, hl string) ([]*VideoCategory, error) {
+	call := c.service.VideoCategories.List([]string{"snippet"}).RegionCode(regionCode)
+	if hl != "" {
+		call = call.Hl(hl)
+	}
+
+	var resp *yt.VideoCategoryListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		if err := c.acquire(ctx, 1); err != nil {
+			return err
+		}
+		var apiErr error
+		resp, apiErr = call.Context(ctx).Do()
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
+	if err != nil {
+		return nil, fmt.Errorf("videoCategories.list: %w", err)
+	}
+
+	categories := make([]*VideoCategory, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		cat := &VideoCategory{ID: item.Id}
+		if item.Snippet != nil {
+			cat.Title = item.Snippet.Title
+			cat.Assignable = item.Snippet.Assignable
+		}
+		categories = append(categories, cat)
+	}
+	return categories, nil
+}
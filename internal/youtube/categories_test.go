@@ -0,0 +1,26 @@
+package youtube_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube/youtubetest"
+)
+
+func TestVideoCategories_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddVideoCategory("JP", "22", "ブログ")
+	srv.AddVideoCategory("JP", "28", "科学と技術")
+
+	client := srv.NewClient(t)
+	got, err := client.VideoCategories(context.Background(), "JP", "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != "22" || got[0].Title != "ブログ" || !got[0].Assignable {
+		t.Errorf("VideoCategories() = %+v", got)
+	}
+	if client.QuotaUsed() != 1 {
+		t.Errorf("QuotaUsed() = %d, want 1", client.QuotaUsed())
+	}
+}
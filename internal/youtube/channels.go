@@ -0,0 +1,134 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	yt "google.golang.org/api/youtube/v3"
+)
+
+// ChannelInfo is the public profile and statistics of a channel.
+type ChannelInfo struct {
+	ID          string
+	Title       string
+	Description string
+	Country     string
+	// Subscribers is zero when the channel hides its subscriber count.
+	Subscribers       int64
+	SubscribersHidden bool
+	Videos            int64
+	Views             int64
+}
+
+// FeaturedChannels returns the channels a channel lists in the sections of
+// its home page ("Featured channels" and other channel shelves), in section
+// order without duplicates or the channel itself. It costs one
+// channelSections.list call.
+func (c *Client) FeaturedChannels(ctx context.Context, channelID string) ([]string, error) {
+	var resp *yt.ChannelSectionListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		if err := c.acquire(ctx, 1); err != nil {
+			return err
+		}
+		var apiErr error
+		resp, apiErr = c.service.ChannelSections.List([]string{"contentDetails"}).ChannelId(channelID).Context(ctx).Do()
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
+	if err != nil {
+		return nil, fmt.Errorf("channelSections.list: %w", err)
+	}
+
+	seen := map[string]bool{channelID: true}
+	var ids []string
+	for _, section := range resp.Items {
+		if section.ContentDetails == nil {
+			continue
+		}
+		for _, id := range section.ContentDetails.Channels {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// SearchChannels returns the top maxResults channels (at most 50) for a
+// search query, in search rank order. It costs one search.list call
+// (SearchListCost units).
+func (c *Client) SearchChannels(ctx context.Context, query string, maxResults int64) ([]string, error) {
+	if maxResults <= 0 || maxResults > maxPlaylistPageSize {
+		maxResults = maxPlaylistPageSize
+	}
+	call := c.service.Search.List([]string{"id"}).Q(query).Type("channel").MaxResults(maxResults)
+
+	var resp *yt.SearchListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		if err := c.acquire(ctx, SearchListCost); err != nil {
+			return err
+		}
+		var apiErr error
+		resp, apiErr = call.Context(ctx).Do()
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
+	if err != nil {
+		return nil, fmt.Errorf("search.list: %w", err)
+	}
+
+	ids := make([]string, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		if item.Id != nil && item.Id.ChannelId != "" {
+			ids = append(ids, item.Id.ChannelId)
+		}
+	}
+	return ids, nil
+}
+
+// ChannelDetails returns the profile and statistics of the given channels.
+// Channels that do not exist are absent from the result. It costs one
+// channels.list call per 50 IDs.
+func (c *Client) ChannelDetails(ctx context.Context, channelIDs []string) ([]*ChannelInfo, error) {
+	var infos []*ChannelInfo
+	for i := 0; i < len(channelIDs); i += 50 {
+		batch := channelIDs[i:min(i+50, len(channelIDs))]
+		var resp *yt.ChannelListResponse
+		err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+			if err := c.acquire(ctx, 1); err != nil {
+				return err
+			}
+			var apiErr error
+			resp, apiErr = c.service.Channels.List([]string{"snippet", "statistics"}).Id(batch...).Context(ctx).Do()
+			if apiErr != nil {
+				return c.apiError("YouTube API error", apiErr)
+			}
+			return nil
+		}, c.retryConfig())
+		if err != nil {
+			return nil, fmt.Errorf("channels.list: %w", err)
+		}
+		for _, ch := range resp.Items {
+			info := &ChannelInfo{ID: ch.Id}
+			if s := ch.Snippet; s != nil {
+				info.Title = s.Title
+				info.Description = s.Description
+				info.Country = s.Country
+			}
+			if s := ch.Statistics; s != nil {
+				info.Subscribers = int64(s.SubscriberCount)
+				info.SubscribersHidden = s.HiddenSubscriberCount
+				info.Videos = int64(s.VideoCount)
+				info.Views = int64(s.ViewCount)
+			}
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
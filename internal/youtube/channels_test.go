@@ -0,0 +1,84 @@
+package youtube_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube/youtubetest"
+)
+
+func TestFeaturedChannels_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.SetFeaturedChannels("UC1", "UC2", "UC1", "UC3", "UC2")
+
+	client := srv.NewClient(t)
+	got, err := client.FeaturedChannels(context.Background(), "UC1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"UC2", "UC3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FeaturedChannels() = %v, want %v", got, want)
+	}
+	if client.QuotaUsed() != 1 {
+		t.Errorf("QuotaUsed() = %d, want 1", client.QuotaUsed())
+	}
+}
+
+func TestSearchChannels_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.SetChannelSearch("tech news", "UC2", "UC3", "UC4")
+
+	client := srv.NewClient(t)
+	got, err := client.SearchChannels(context.Background(), "tech news", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"UC2", "UC3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchChannels() = %v, want %v", got, want)
+	}
+	if client.QuotaUsed() != youtube.SearchListCost {
+		t.Errorf("QuotaUsed() = %d, want %d", client.QuotaUsed(), youtube.SearchListCost)
+	}
+}
+
+func TestChannelDetails_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddChannel("UC1", "Channel One")
+	srv.SetSubscribers("UC1", 12000)
+
+	got, err := srv.NewClient(t).ChannelDetails(context.Background(), []string{"UC1", "UCgone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "UC1" || got[0].Title != "Channel One" || got[0].Subscribers != 12000 {
+		t.Errorf("ChannelDetails() = %+v, want only UC1 with 12000 subscribers", got)
+	}
+}
+
+func TestFetchPlaylistVideos_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddPlaylist("PLbest",
+		youtubetest.Video("v2", "UCb", "Second channel"),
+		youtubetest.Video("v1", "UCa", "First channel"),
+		youtubetest.Video("v3", "UCa", "Not fetched"))
+	srv.RemoveVideo("v1")
+
+	client := srv.NewClient(t)
+	got, err := client.FetchPlaylistVideos(context.Background(), "PLbest", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "v2" || got[0].ChannelID != "UCb" {
+		t.Errorf("FetchPlaylistVideos() = %+v, want only v2 of UCb", got)
+	}
+
+	found, err := client.ExistingPlaylists(context.Background(), []string{"PLbest", "PLgone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found["PLbest"] || found["PLgone"] {
+		t.Errorf("ExistingPlaylists() = %v, want only PLbest", found)
+	}
+}
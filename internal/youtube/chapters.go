@@ -0,0 +1,81 @@
+package youtube
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Chapter is a chapter of a video as listed in its description.
+type Chapter struct {
+	// StartSec is the offset of the chapter from the start of the video.
+	StartSec int64
+	Title    string
+}
+
+// YouTube only turns description timestamps into chapters when there are at
+// least minChapters of them, the first at 0:00, in ascending order and each
+// at least minChapterSec long.
+const (
+	minChapters   = 3
+	minChapterSec = 10
+)
+
+var (
+	// leadingTimestamp matches "0:00 Intro", "(1:02:03) - Outro" or
+	// "- 12:30 | Q&A": a timestamp starting the line, optionally after a
+	// bullet or bracket, followed by the title.
+	leadingTimestamp = regexp.MustCompile(`^[\s\-–—*•・▶►]*[(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[)\]]?\s*[-–—:|.)\]]?\s*(.*)$`)
+	// trailingTimestamp matches "Intro - 0:00": the title, then a timestamp
+	// ending the line.
+	trailingTimestamp = regexp.MustCompile(`^[\s\-–—*•・▶►]*(.*?)\s*[-–—:|]?\s*[(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[)\]]?$`)
+)
+
+// ParseChapters returns the chapters listed in a video description, one
+// per line with a timestamp at its start or end, when they form chapters
+// as YouTube displays them: at least three, the first at 0:00, in
+// ascending order and each at least ten seconds long. Otherwise, including
+// when timestamps are merely mentioned, it returns nil.
+func ParseChapters(description string) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		var stamp, title string
+		if m := leadingTimestamp.FindStringSubmatch(line); m != nil {
+			stamp, title = m[1], m[2]
+		} else if m := trailingTimestamp.FindStringSubmatch(line); m != nil {
+			stamp, title = m[2], m[1]
+		} else {
+			continue
+		}
+		sec, ok := parseTimestamp(stamp)
+		if !ok {
+			continue
+		}
+		chapters = append(chapters, Chapter{StartSec: sec, Title: strings.TrimSpace(title)})
+	}
+
+	if len(chapters) < minChapters || chapters[0].StartSec != 0 {
+		return nil
+	}
+	for i := 1; i < len(chapters); i++ {
+		if chapters[i].StartSec-chapters[i-1].StartSec < minChapterSec {
+			return nil
+		}
+	}
+	return chapters
+}
+
+// parseTimestamp converts "m:ss" or "h:mm:ss" to seconds.
+func parseTimestamp(s string) (int64, bool) {
+	var sec int64
+	parts := strings.Split(s, ":")
+	for i, p := range parts {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, false
+		}
+		sec = sec*60 + n
+	}
+	return sec, true
+}
@@ -0,0 +1,58 @@
+package youtube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        []Chapter
+	}{
+		{
+			name:        "leading timestamps",
+			description: "今日は新作のレビューです。\n\n0:00 Intro\n1:30 - 開封\n(12:05) 使ってみた感想\n1:02:03 | まとめ\n\n#review",
+			want: []Chapter{
+				{0, "Intro"}, {90, "開封"}, {725, "使ってみた感想"}, {3723, "まとめ"},
+			},
+		},
+		{
+			name:        "trailing timestamps and bullets",
+			description: "• Intro - 00:00\n• Setup 0:45\n• Demo (3:10)",
+			want:        []Chapter{{0, "Intro"}, {45, "Setup"}, {190, "Demo"}},
+		},
+		{
+			name:        "not starting at zero",
+			description: "0:05 Intro\n1:00 Body\n2:00 Outro",
+		},
+		{
+			name:        "too few",
+			description: "0:00 Intro\n5:00 Outro",
+		},
+		{
+			name:        "too short",
+			description: "0:00 Intro\n0:05 Body\n2:00 Outro",
+		},
+		{
+			name:        "out of order",
+			description: "0:00 Intro\n3:00 Body\n2:00 Outro",
+		},
+		{
+			name:        "mentioned in text",
+			description: "The best part is at 3:15, don't miss it!\nSee 0:00 for context.",
+		},
+		{
+			name:        "invalid seconds",
+			description: "0:00 Intro\n1:75 Body\n3:00 Outro",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseChapters(tt.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChapters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
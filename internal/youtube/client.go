@@ -3,23 +3,40 @@ package youtube
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
 	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
-	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	yt "google.golang.org/api/youtube/v3"
 )
 
 type Client struct {
 	service *yt.Service
+	// shortsHTTP probes /shorts/ URLs when set (see EnableShortsURLCheck).
+	shortsHTTP *http.Client
+	metrics    *metrics.Metrics
+	// quotaUsed counts the API units spent by this client, retries included.
+	quotaUsed atomic.Int64
+	// cache answers conditional requests when set (see SetResponseCache).
+	cache       *ResponseCache
+	notModified atomic.Int64
+	// disabledParts holds the videos.list parts not to request, by channel
+	// ID; "" applies to every video.
+	disabledParts map[string]map[string]bool
+	// limiter paces requests when set (see SetRateLimiter).
+	limiter *RateLimiter
 }
 
 type Video struct {
 	ID             string
+	ChannelID      string
 	Title          string
 	ChannelName    string
 	Tags           []string
@@ -30,17 +47,226 @@ type Video struct {
 	PublishedAt    time.Time
 	DurationSec    int64
 	ContentDetails string
+	// RegionAllowed lists the only regions (ISO 3166-1 alpha-2) where the
+	// video is viewable, and RegionBlocked the regions where it is not;
+	// both are empty for a video viewable everywhere.
+	RegionAllowed []string
+	RegionBlocked []string
+	// ContentRatings are the ratings the video has under rating systems,
+	// including YouTube's own age restriction ("yt", "ytAgeRestricted").
+	ContentRatings []ContentRating
 	TopicDetails   []string
+	Status         string // privacyStatus: public, unlisted or private
+	// MadeForKids is the audience setting (status.madeForKids), which turns
+	// off comments and personalized ads.
+	MadeForKids bool
+	// License is "youtube" (standard) or "creativeCommon".
+	License    string
+	Embeddable bool
+	// PaidPromotion is set when the uploader declared paid product
+	// placement (paidProductPlacementDetails.hasPaidProductPlacement).
+	PaidPromotion bool
+	Description   string
+	// Chapters are the chapters listed in the description (see
+	// ParseChapters), nil when it has none.
+	Chapters     []Chapter
+	ThumbnailURL string
+	// CategoryID is the video category (e.g. "22" People & Blogs), see
+	// videoCategories.list.
+	CategoryID string
+	// DefaultLanguage is the language of the title and description as set
+	// by the uploader; often empty.
+	DefaultLanguage string
+	// ShortsConfidence is the classifier score behind IsShort (see ShortConfidence).
+	ShortsConfidence float64
 }
 
+// ClientOptions tunes how the client talks to the YouTube Data API.
+type ClientOptions struct {
+	// HTTPClient sends the API requests; the API key is added to each
+	// request. Nil uses the default Google API transport.
+	HTTPClient *http.Client
+
+	// Timeout bounds each API request, retries being separate requests.
+	// Zero keeps the HTTP client's timeout (none for the default transport).
+	Timeout time.Duration
+
+	// UserAgent is appended to the User-Agent header of API requests.
+	UserAgent string
+
+	// Endpoint overrides the API base URL, e.g. an httptest server in tests.
+	Endpoint string
+}
+
+// NewClient creates a client using the default Google API transport.
 func NewClient(ctx context.Context, apiKey string) (*Client, error) {
-	svc, err := yt.NewService(ctx, option.WithAPIKey(apiKey))
+	return NewClientWithOptions(ctx, apiKey, ClientOptions{})
+}
+
+// NewClientWithOptions creates a client with a custom HTTP client, request
+// timeout, user agent or endpoint.
+func NewClientWithOptions(ctx context.Context, apiKey string, opts ClientOptions) (*Client, error) {
+	var clientOpts []option.ClientOption
+	if opts.HTTPClient == nil && opts.Timeout == 0 {
+		clientOpts = append(clientOpts, option.WithAPIKey(apiKey))
+	} else {
+		// WithHTTPClient bypasses the transport that adds the API key, so
+		// the key is added by a transport of our own.
+		hc := &http.Client{}
+		if opts.HTTPClient != nil {
+			copied := *opts.HTTPClient
+			hc = &copied
+		}
+		hc.Transport = &apiKeyTransport{key: apiKey, base: hc.Transport}
+		if opts.Timeout > 0 {
+			hc.Timeout = opts.Timeout
+		}
+		clientOpts = append(clientOpts, option.WithHTTPClient(hc))
+	}
+	if opts.Endpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(opts.Endpoint))
+	}
+
+	svc, err := yt.NewService(ctx, clientOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("youtube.NewService: %w", err)
 	}
+	svc.UserAgent = opts.UserAgent
 	return &Client{service: svc}, nil
 }
 
+// Clone returns a client sharing c's API service and connections but none
+// of its state: the quota counters start at zero, and the metrics, response
+// cache, rate limiter, disabled parts and Shorts URL check are unset. A
+// long-lived client can so serve runs that each count their own quota.
+func (c *Client) Clone() *Client {
+	return &Client{service: c.service}
+}
+
+// apiKeyTransport adds the API key to every request.
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// RoundTrippers must not modify the request they are given.
+	req = req.Clone(req.Context())
+	q := req.URL.Query()
+	q.Set("key", t.key)
+	req.URL.RawQuery = q.Encode()
+	return base.RoundTrip(req)
+}
+
+// SetMetrics makes API retries count towards ytt_retries_total and
+// ytt_retry_attempts.
+func (c *Client) SetMetrics(m *metrics.Metrics) {
+	c.metrics = m
+}
+
+// SetResponseCache makes channels.list and playlistItems.list requests
+// conditional on the ETag of the response cached for them. The cache may be
+// shared by several clients.
+func (c *Client) SetResponseCache(cache *ResponseCache) {
+	c.cache = cache
+}
+
+// SetRateLimiter makes every API request wait for the limiter, which may be
+// shared by several clients, and makes rate limit errors trip it.
+func (c *Client) SetRateLimiter(l *RateLimiter) {
+	c.limiter = l
+}
+
+// NotModified returns how many requests were answered from the response
+// cache after a 304 Not Modified.
+func (c *Client) NotModified() int64 {
+	return c.notModified.Load()
+}
+
+// DisableParts stops requesting the given optional videos.list parts for
+// the videos of channelID, or for every video when channelID is "". Call it
+// before the client is used.
+func (c *Client) DisableParts(channelID string, parts ...string) {
+	if len(parts) == 0 {
+		return
+	}
+	if c.disabledParts == nil {
+		c.disabledParts = make(map[string]map[string]bool)
+	}
+	if c.disabledParts[channelID] == nil {
+		c.disabledParts[channelID] = make(map[string]bool)
+	}
+	for _, p := range parts {
+		c.disabledParts[channelID][p] = true
+	}
+}
+
+// videoParts are the parts requested from videos.list, in request order.
+// contentDetails and topicDetails can be disabled with DisableParts.
+var videoParts = []string{"snippet", "statistics", "contentDetails", "topicDetails", "status", "player", "paidProductPlacementDetails"}
+
+// videoPartsFor returns the videos.list parts to request for a video of
+// channelID, or for a video of any channel when channelID is "".
+func (c *Client) videoPartsFor(channelID string) []string {
+	parts := make([]string, 0, len(videoParts))
+	for _, p := range videoParts {
+		if !c.disabledParts[""][p] && !c.disabledParts[channelID][p] {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// QuotaUsed returns the API quota units spent by this client so far. Every
+// request counts, including failed ones and retries, as the API bills them.
+func (c *Client) QuotaUsed() int64 {
+	return c.quotaUsed.Load()
+}
+
+// acquire waits for the rate limiter, if any, and records the quota cost of
+// a request about to be made. Failing to wait (the context ends first) is
+// not retriable.
+func (c *Client) acquire(ctx context.Context, units int64) error {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return errors.API("YouTube API rate limiter", err)
+		}
+	}
+	c.quotaUsed.Add(units)
+	return nil
+}
+
+// apiError converts an API error with errors.FromGoogleAPI, which tells
+// the 4xx errors apart: rate limiting (429, rateLimitExceeded) is temporary
+// and retried with backoff, while daily quota exhaustion (quotaExceeded) and
+// other client errors are not. Rate limiting also trips the rate limiter.
+func (c *Client) apiError(message string, err error) error {
+	appErr := errors.FromGoogleAPI(message, err)
+	if c.limiter != nil && stderrors.Is(appErr, errors.ErrRateLimited) {
+		c.limiter.Trip()
+	}
+	return appErr
+}
+
+// retryConfig returns the retry configuration for API calls.
+func (c *Client) retryConfig() retry.Config {
+	return retry.WithMetrics(retry.DefaultConfig(), c.metrics, "youtube")
+}
+
+// EnableShortsURLCheck makes Shorts classification probe youtube.com/shorts/{id}
+// for videos short enough to be a Short. This is the most reliable signal but
+// costs one HTTP request per such video. A nil client uses a 5s timeout.
+func (c *Client) EnableShortsURLCheck(httpClient *http.Client) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	c.shortsHTTP = httpClient
+}
+
 // parseISODuration converts a YouTube ISO 8601 duration (e.g., "PT1M30S") into a time.Duration.
 func parseISODuration(isoDuration string) (time.Duration, error) {
 	// Go's time.ParseDuration doesn't support the "P" or "T" prefixes of ISO 8601.
@@ -51,81 +277,331 @@ func parseISODuration(isoDuration string) (time.Duration, error) {
 	return time.ParseDuration(s)
 }
 
-// FetchChannelVideos returns latest N videos with snippet/statistics.
+// bestThumbnailURL returns the URL of the highest resolution thumbnail available.
+func bestThumbnailURL(t *yt.ThumbnailDetails) string {
+	if t == nil {
+		return ""
+	}
+	for _, th := range []*yt.Thumbnail{t.Maxres, t.Standard, t.High, t.Medium, t.Default} {
+		if th != nil && th.Url != "" {
+			return th.Url
+		}
+	}
+	return ""
+}
+
+// playerMaxWidth is passed to videos.list so that player.embedWidth and
+// embedHeight reflect the video's aspect ratio.
+const playerMaxWidth = 720
+
+// maxPlaylistPageSize is the largest page playlistItems.list will return.
+const maxPlaylistPageSize = 50
+
+// playlistPageSize returns the page size to request given the overall limit
+// and the number of IDs already collected. A limit <= 0 means "no limit".
+func playlistPageSize(maxResults, fetched int64) int64 {
+	if maxResults <= 0 {
+		return maxPlaylistPageSize
+	}
+	remaining := maxResults - fetched
+	if remaining > maxPlaylistPageSize {
+		return maxPlaylistPageSize
+	}
+	if remaining < 1 {
+		return 1
+	}
+	return remaining
+}
+
+// FetchChannelVideos returns the latest maxResults videos with
+// snippet/statistics. A maxResults <= 0 walks the entire uploads playlist.
 func (c *Client) FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]*Video, error) {
-	ch, err := c.service.Channels.List([]string{"contentDetails", "snippet"}).Id(channelID).Do()
-	if err != nil || len(ch.Items) == 0 {
-		return nil, fmt.Errorf("channels.list: %w", err)
+	uploads, channelName, err := c.UploadsPlaylist(ctx, channelID)
+	if err != nil {
+		return nil, err
 	}
-	channelName := ch.Items[0].Snippet.Title
-	uploads := ch.Items[0].ContentDetails.RelatedPlaylists.Uploads
+	return c.fetchPlaylistVideos(ctx, uploads, channelName, c.videoPartsFor(channelID), maxResults)
+}
+
+// FetchPlaylistVideos returns the first maxResults videos of any playlist,
+// in playlist order, with snippet/statistics. A maxResults <= 0 walks the
+// entire playlist. Videos of the playlist that are deleted or private are
+// absent. Parts disabled for playlistID with DisableParts are not requested.
+func (c *Client) FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]*Video, error) {
+	return c.fetchPlaylistVideos(ctx, playlistID, "", c.videoPartsFor(playlistID), maxResults)
+}
 
+// fetchPlaylistVideos walks a playlist for up to maxResults video IDs and
+// returns their details in playlist order.
+func (c *Client) fetchPlaylistVideos(ctx context.Context, playlistID, channelName string, parts []string, maxResults int64) ([]*Video, error) {
 	var allVideoIDs []string
 	nextPageToken := ""
 
 	for {
-		itCall := c.service.PlaylistItems.List([]string{"contentDetails"}).PlaylistId(uploads).MaxResults(maxResults)
-		if nextPageToken != "" {
-			itCall = itCall.PageToken(nextPageToken)
+		ids, next, err := c.ListPlaylistPage(ctx, playlistID, nextPageToken, playlistPageSize(maxResults, int64(len(allVideoIDs))))
+		if err != nil {
+			return nil, err
 		}
 
-		var itResp *yt.PlaylistItemListResponse
-		err := retry.Do(func() error {
+		for _, id := range ids {
+			if maxResults > 0 && int64(len(allVideoIDs)) >= maxResults {
+				break
+			}
+			allVideoIDs = append(allVideoIDs, id)
+		}
+
+		nextPageToken = next
+		if nextPageToken == "" || (maxResults > 0 && int64(len(allVideoIDs)) >= maxResults) {
+			break
+		}
+	}
+
+	if len(allVideoIDs) == 0 {
+		return nil, nil
+	}
+
+	videos, err := c.fetchVideoDetails(ctx, allVideoIDs, channelName, parts)
+	if err != nil {
+		return nil, err
+	}
+	return orderByID(videos, allVideoIDs), nil
+}
+
+// UploadsPlaylist returns the ID of a channel's uploads playlist and the
+// channel's title.
+func (c *Client) UploadsPlaylist(ctx context.Context, channelID string) (string, string, error) {
+	var ch *yt.ChannelListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		if err := c.acquire(ctx, 1); err != nil {
+			return err
+		}
+		var apiErr error
+		ch, apiErr = conditional(c, "channels.list/contentDetails,snippet/"+channelID, func(etag string) (*yt.ChannelListResponse, error) {
+			return c.service.Channels.List([]string{"contentDetails", "snippet"}).Id(channelID).IfNoneMatch(etag).Context(ctx).Do()
+		}, func(r *yt.ChannelListResponse) string { return r.Etag })
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
+	if err != nil {
+		return "", "", fmt.Errorf("channels.list: %w", err)
+	}
+	if len(ch.Items) == 0 {
+		return "", "", errors.API(fmt.Sprintf("channel %s not found", channelID), nil).WithSentinel(errors.ErrNotFound)
+	}
+	return ch.Items[0].ContentDetails.RelatedPlaylists.Uploads, ch.Items[0].Snippet.Title, nil
+}
+
+// ExistingChannels returns the subset of channelIDs that resolve to a channel.
+// It costs one channels.list call per 50 IDs.
+func (c *Client) ExistingChannels(ctx context.Context, channelIDs []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(channelIDs))
+	for i := 0; i < len(channelIDs); i += 50 {
+		batch := channelIDs[i:min(i+50, len(channelIDs))]
+		var resp *yt.ChannelListResponse
+		err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+			if err := c.acquire(ctx, 1); err != nil {
+				return err
+			}
 			var apiErr error
-			itResp, apiErr = itCall.Do()
+			resp, apiErr = c.service.Channels.List([]string{"id"}).Id(batch...).Context(ctx).Do()
 			if apiErr != nil {
-				if e, ok := apiErr.(*googleapi.Error); ok {
-					if e.Code == 429 || (e.Code >= 500 && e.Code < 600) {
-						return errors.Temporary("YouTube API temporary error", apiErr)
-					}
-					return errors.API("YouTube API error", apiErr)
-				}
-				return apiErr
+				return c.apiError("YouTube API error", apiErr)
 			}
 			return nil
-		}, retry.DefaultConfig())
+		}, c.retryConfig())
+		if err != nil {
+			return nil, fmt.Errorf("channels.list: %w", err)
+		}
+		for _, ch := range resp.Items {
+			found[ch.Id] = true
+		}
+	}
+	return found, nil
+}
 
+// ExistingPlaylists returns the subset of playlistIDs that resolve to a
+// playlist visible with the API key. It costs one playlists.list call per
+// 50 IDs.
+func (c *Client) ExistingPlaylists(ctx context.Context, playlistIDs []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(playlistIDs))
+	for i := 0; i < len(playlistIDs); i += 50 {
+		batch := playlistIDs[i:min(i+50, len(playlistIDs))]
+		var resp *yt.PlaylistListResponse
+		err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+			if err := c.acquire(ctx, 1); err != nil {
+				return err
+			}
+			var apiErr error
+			resp, apiErr = c.service.Playlists.List([]string{"id"}).Id(batch...).MaxResults(50).Context(ctx).Do()
+			if apiErr != nil {
+				return c.apiError("YouTube API error", apiErr)
+			}
+			return nil
+		}, c.retryConfig())
 		if err != nil {
-			return nil, fmt.Errorf("playlistItems.list: %w", err)
+			return nil, fmt.Errorf("playlists.list: %w", err)
+		}
+		for _, p := range resp.Items {
+			found[p.Id] = true
 		}
+	}
+	return found, nil
+}
 
-		for _, it := range itResp.Items {
-			allVideoIDs = append(allVideoIDs, it.ContentDetails.VideoId)
+// Ping makes the cheapest authenticated API call (i18nRegions.list, 1 quota
+// unit) to verify that the API key is valid and the API is enabled.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.acquire(ctx, 1); err != nil {
+		return err
+	}
+	if _, err := c.service.I18nRegions.List([]string{"id"}).Context(ctx).Do(); err != nil {
+		return c.apiError("YouTube API check failed", err)
+	}
+	return nil
+}
+
+// ListPlaylistPage returns the video IDs on one page of a playlist and the
+// token of the next page, which is empty on the last page. pageSize is
+// clamped to the API maximum of 50.
+func (c *Client) ListPlaylistPage(ctx context.Context, playlistID, pageToken string, pageSize int64) ([]string, string, error) {
+	if pageSize <= 0 || pageSize > maxPlaylistPageSize {
+		pageSize = maxPlaylistPageSize
+	}
+	itCall := c.service.PlaylistItems.List([]string{"contentDetails"}).PlaylistId(playlistID).MaxResults(pageSize)
+	if pageToken != "" {
+		itCall = itCall.PageToken(pageToken)
+	}
+
+	key := fmt.Sprintf("playlistItems.list/%s/%s/%d", playlistID, pageToken, pageSize)
+
+	var itResp *yt.PlaylistItemListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		var apiErr error
+		if err := c.acquire(ctx, 1); err != nil {
+			return err
 		}
+		// IfNoneMatch("") sends no condition.
+		itResp, apiErr = conditional(c, key, func(etag string) (*yt.PlaylistItemListResponse, error) {
+			return itCall.IfNoneMatch(etag).Context(ctx).Do()
+		}, func(r *yt.PlaylistItemListResponse) string { return r.Etag })
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
 
-		nextPageToken = itResp.NextPageToken
-		if nextPageToken == "" || int64(len(allVideoIDs)) >= maxResults {
-			break
+	if err != nil {
+		return nil, "", fmt.Errorf("playlistItems.list: %w", err)
+	}
+
+	ids := make([]string, 0, len(itResp.Items))
+	for _, it := range itResp.Items {
+		ids = append(ids, it.ContentDetails.VideoId)
+	}
+	return ids, itResp.NextPageToken, nil
+}
+
+// SearchListCost is the quota cost in units of a single search.list call.
+const SearchListCost = 100
+
+// FetchKeywordVideos returns the top maxResults videos (at most 50) for a
+// search query, in search rank order. It costs one search.list call plus one
+// videos.list call.
+func (c *Client) FetchKeywordVideos(ctx context.Context, query string, maxResults int64) ([]*Video, error) {
+	if maxResults <= 0 || maxResults > maxPlaylistPageSize {
+		maxResults = maxPlaylistPageSize
+	}
+	call := c.service.Search.List([]string{"id"}).Q(query).Type("video").MaxResults(maxResults)
+
+	var resp *yt.SearchListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		var apiErr error
+		if err := c.acquire(ctx, SearchListCost); err != nil {
+			return err
+		}
+		resp, apiErr = call.Context(ctx).Do()
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
 		}
+		return nil
+	}, c.retryConfig())
+
+	if err != nil {
+		return nil, fmt.Errorf("search.list: %w", err)
 	}
 
-	if len(allVideoIDs) == 0 {
+	ids := make([]string, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		if item.Id != nil && item.Id.VideoId != "" {
+			ids = append(ids, item.Id.VideoId)
+		}
+	}
+	if len(ids) == 0 {
 		return nil, nil
 	}
 
+	videos, err := c.fetchVideoDetails(ctx, ids, "", c.videoPartsFor(""))
+	if err != nil {
+		return nil, err
+	}
+	return orderByID(videos, ids), nil
+}
+
+// orderByID returns videos sorted to match the order of ids. IDs without a
+// matching video are skipped.
+func orderByID(videos []*Video, ids []string) []*Video {
+	byID := make(map[string]*Video, len(videos))
+	for _, v := range videos {
+		byID[v.ID] = v
+	}
+	ordered := make([]*Video, 0, len(videos))
+	for _, id := range ids {
+		if v, ok := byID[id]; ok {
+			ordered = append(ordered, v)
+		}
+	}
+	return ordered
+}
+
+// FetchVideosByID returns snippet/statistics for the given video IDs.
+// Videos that were deleted or made private are silently absent from the result.
+func (c *Client) FetchVideosByID(ctx context.Context, videoIDs []string) ([]*Video, error) {
+	if len(videoIDs) == 0 {
+		return nil, nil
+	}
+	return c.fetchVideoDetails(ctx, videoIDs, "", c.videoPartsFor(""))
+}
+
+// fetchVideoDetails calls videos.list for parts in batches of 50 IDs. If
+// channelName is empty the channel title from each video's snippet is used
+// instead.
+func (c *Client) fetchVideoDetails(ctx context.Context, videoIDs []string, channelName string, parts []string) ([]*Video, error) {
 	var allVideos []*Video
-	for i := 0; i < len(allVideoIDs); i += 50 {
+	for i := 0; i < len(videoIDs); i += 50 {
 		end := i + 50
-		if end > len(allVideoIDs) {
-			end = len(allVideoIDs)
+		if end > len(videoIDs) {
+			end = len(videoIDs)
 		}
-		batchIDs := allVideoIDs[i:end]
+		batchIDs := videoIDs[i:end]
 
 		var vResp *yt.VideoListResponse
-		err := retry.Do(func() error {
+		err := retry.DoWithContext(ctx, func(ctx context.Context) error {
 			var apiErr error
-			vResp, apiErr = c.service.Videos.List([]string{"snippet", "statistics", "contentDetails", "topicDetails"}).Id(batchIDs...).Do()
+			if err := c.acquire(ctx, 1); err != nil {
+				return err
+			}
+			vResp, apiErr = c.service.Videos.List(parts).
+				Id(batchIDs...).
+				MaxWidth(playerMaxWidth).
+				Context(ctx).
+				Do()
 			if apiErr != nil {
-				if e, ok := apiErr.(*googleapi.Error); ok {
-					if e.Code == 429 || (e.Code >= 500 && e.Code < 600) {
-						return errors.Temporary("YouTube API temporary error", apiErr)
-					}
-					return errors.API("YouTube API error", apiErr)
-				}
-				return apiErr
+				return c.apiError("YouTube API error", apiErr)
 			}
 			return nil
-		}, retry.DefaultConfig())
+		}, c.retryConfig())
 
 		if err != nil {
 			return nil, fmt.Errorf("videos.list: %w", err)
@@ -141,21 +617,54 @@ func (c *Client) FetchChannelVideos(ctx context.Context, channelID string, maxRe
 			pub, _ := time.Parse(time.RFC3339, item.Snippet.PublishedAt)
 
 			var durationSec int64
-			var isShort bool
 			var contentDetailsJSON string
+			var regionAllowed, regionBlocked []string
+			var ratings []ContentRating
+			signals := ShortSignals{
+				Hashtag: hasShortsHashtag(item.Snippet.Title, item.Snippet.Description, item.Snippet.Tags),
+			}
+			if item.Player != nil && item.Player.EmbedWidth > 0 {
+				signals.Vertical = item.Player.EmbedHeight > item.Player.EmbedWidth
+			}
 			if item.ContentDetails != nil {
 				duration, err := parseISODuration(item.ContentDetails.Duration)
 				if err == nil {
 					durationSec = int64(duration.Seconds())
-					if duration <= 60*time.Second {
-						isShort = true
-					}
+					signals.Duration = duration
 				}
 
 				cd, err := json.Marshal(item.ContentDetails)
 				if err == nil {
 					contentDetailsJSON = string(cd)
 				}
+				regionAllowed, regionBlocked = regionRestriction(item.ContentDetails.RegionRestriction)
+				ratings = contentRatings(item.ContentDetails.ContentRating)
+			}
+
+			if c.shortsHTTP != nil && signals.Duration > 0 && signals.Duration <= maxShortDuration {
+				isShort, err := checkShortsURL(ctx, c.shortsHTTP, shortsBaseURL, item.Id)
+				if err == nil {
+					signals.URLChecked = true
+					signals.URLIsShort = isShort
+				}
+			}
+			shortsConfidence := ShortConfidence(signals)
+
+			var status, license string
+			var madeForKids, embeddable, paidPromotion bool
+			if item.Status != nil {
+				status = item.Status.PrivacyStatus
+				madeForKids = item.Status.MadeForKids
+				license = item.Status.License
+				embeddable = item.Status.Embeddable
+			}
+			if item.PaidProductPlacementDetails != nil {
+				paidPromotion = item.PaidProductPlacementDetails.HasPaidProductPlacement
+			}
+
+			name := channelName
+			if name == "" {
+				name = item.Snippet.ChannelTitle
 			}
 
 			var topicDetails []string
@@ -164,18 +673,33 @@ func (c *Client) FetchChannelVideos(ctx context.Context, channelID string, maxRe
 			}
 
 			allVideos = append(allVideos, &Video{
-				ID:             item.Id,
-				Title:          item.Snippet.Title,
-				ChannelName:    channelName,
-				Tags:           item.Snippet.Tags,
-				IsShort:        isShort,
-				Views:          views,
-				Likes:          likes,
-				Comments:       comments,
-				PublishedAt:    pub,
-				DurationSec:    durationSec,
-				ContentDetails: contentDetailsJSON,
-				TopicDetails:   topicDetails,
+				ID:               item.Id,
+				ChannelID:        item.Snippet.ChannelId,
+				Title:            item.Snippet.Title,
+				ChannelName:      name,
+				Tags:             item.Snippet.Tags,
+				IsShort:          shortsConfidence >= ShortThreshold,
+				Views:            views,
+				Likes:            likes,
+				Comments:         comments,
+				PublishedAt:      pub,
+				DurationSec:      durationSec,
+				ContentDetails:   contentDetailsJSON,
+				RegionAllowed:    regionAllowed,
+				RegionBlocked:    regionBlocked,
+				ContentRatings:   ratings,
+				TopicDetails:     topicDetails,
+				Status:           status,
+				MadeForKids:      madeForKids,
+				License:          license,
+				Embeddable:       embeddable,
+				PaidPromotion:    paidPromotion,
+				Description:      item.Snippet.Description,
+				Chapters:         ParseChapters(item.Snippet.Description),
+				ThumbnailURL:     bestThumbnailURL(item.Snippet.Thumbnails),
+				CategoryID:       item.Snippet.CategoryId,
+				DefaultLanguage:  item.Snippet.DefaultLanguage,
+				ShortsConfidence: shortsConfidence,
 			})
 		}
 	}
@@ -2,8 +2,12 @@ package youtube
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -15,6 +19,46 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestPlaylistPageSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxResults int64
+		fetched    int64
+		want       int64
+	}{
+		{"small limit", 10, 0, 10},
+		{"limit above API cap", 200, 0, 50},
+		{"last partial page", 120, 100, 20},
+		{"exact boundary", 100, 50, 50},
+		{"unlimited", 0, 0, 50},
+		{"negative means unlimited", -1, 500, 50},
+		{"already satisfied", 10, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := playlistPageSize(tt.maxResults, tt.fetched); got != tt.want {
+				t.Errorf("playlistPageSize(%d, %d) = %d, want %d", tt.maxResults, tt.fetched, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderByID(t *testing.T) {
+	videos := []*Video{{ID: "b"}, {ID: "c"}, {ID: "a"}}
+	got := orderByID(videos, []string{"a", "missing", "b", "c"})
+
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("orderByID() returned %d videos, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("orderByID()[%d] = %q, want %q", i, got[i].ID, id)
+		}
+	}
+}
+
 // TestFetchChannelVideos requires a valid YouTube API key set in the YOUTUBE_API_KEY environment variable.
 // This is an integration test and will be skipped if the API key is not provided.
 func TestFetchChannelVideos_Integration(t *testing.T) {
@@ -51,3 +95,70 @@ func TestFetchChannelVideos_Integration(t *testing.T) {
 		t.Logf("Found video: %s (%s)", video.Title, video.ID)
 	}
 }
+
+func TestVideoPartsFor(t *testing.T) {
+	c := &Client{}
+	c.DisableParts("", "topicDetails")
+	c.DisableParts("UC1", "contentDetails")
+
+	if got, want := strings.Join(c.videoPartsFor("UC1"), ","), "snippet,statistics,status,player,paidProductPlacementDetails"; got != want {
+		t.Errorf("videoPartsFor(UC1) = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(c.videoPartsFor("UC2"), ","), "snippet,statistics,contentDetails,status,player,paidProductPlacementDetails"; got != want {
+		t.Errorf("videoPartsFor(UC2) = %s, want %s", got, want)
+	}
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	var key, userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, userAgent = r.URL.Query().Get("key"), r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClientWithOptions(context.Background(), "test-key", ClientOptions{
+		HTTPClient: srv.Client(),
+		Timeout:    time.Second,
+		UserAgent:  "tracker-test/1.0",
+		Endpoint:   srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if key != "test-key" || !strings.HasSuffix(userAgent, " tracker-test/1.0") {
+		t.Errorf("request had key %q, User-Agent %q", key, userAgent)
+	}
+	if srv.Client().Timeout != 0 {
+		t.Error("the given http.Client was modified")
+	}
+}
+
+func TestClone(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	base, err := NewClientWithOptions(context.Background(), "test-key", ClientOptions{Timeout: time.Second, Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := base.Clone(), base.Clone()
+	if err := first.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if first.QuotaUsed() != 1 || second.QuotaUsed() != 0 || base.QuotaUsed() != 0 {
+		t.Errorf("quota used = %d, %d, base %d; want each clone to count its own", first.QuotaUsed(), second.QuotaUsed(), base.QuotaUsed())
+	}
+	if first.service != base.service || requests != 1 {
+		t.Errorf("clone does not share the service (%d requests)", requests)
+	}
+}
@@ -0,0 +1,102 @@
+package youtube
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/retry"
+	"google.golang.org/api/googleapi"
+	yt "google.golang.org/api/youtube/v3"
+)
+
+// maxCommentThreadsPageSize is the largest page commentThreads.list returns.
+const maxCommentThreadsPageSize = 100
+
+// Comment is a top-level comment on a video.
+type Comment struct {
+	ID              string
+	VideoID         string
+	AuthorName      string
+	AuthorChannelID string
+	Text            string
+	Likes           int64
+	ReplyCount      int64
+	PublishedAt     time.Time
+}
+
+// FetchTopComments returns up to maxResults (at most 100) top-level comments
+// of a video ordered by relevance, costing one commentThreads.list call.
+// Videos with comments disabled return no comments and no error.
+func (c *Client) FetchTopComments(ctx context.Context, videoID string, maxResults int64) ([]*Comment, error) {
+	if maxResults <= 0 || maxResults > maxCommentThreadsPageSize {
+		maxResults = maxCommentThreadsPageSize
+	}
+	call := c.service.CommentThreads.List([]string{"snippet"}).
+		VideoId(videoID).
+		Order("relevance").
+		TextFormat("plainText").
+		MaxResults(maxResults)
+
+	var resp *yt.CommentThreadListResponse
+	err := retry.DoWithContext(ctx, func(ctx context.Context) error {
+		var apiErr error
+		if err := c.acquire(ctx, 1); err != nil {
+			return err
+		}
+		resp, apiErr = call.Context(ctx).Do()
+		if apiErr != nil {
+			return c.apiError("YouTube API error", apiErr)
+		}
+		return nil
+	}, c.retryConfig())
+
+	if err != nil {
+		if commentsDisabled(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("commentThreads.list: %w", err)
+	}
+
+	comments := make([]*Comment, 0, len(resp.Items))
+	for _, thread := range resp.Items {
+		if thread.Snippet == nil || thread.Snippet.TopLevelComment == nil || thread.Snippet.TopLevelComment.Snippet == nil {
+			continue
+		}
+		s := thread.Snippet.TopLevelComment.Snippet
+		published, _ := time.Parse(time.RFC3339, s.PublishedAt)
+
+		var authorChannelID string
+		if s.AuthorChannelId != nil {
+			authorChannelID = s.AuthorChannelId.Value
+		}
+
+		comments = append(comments, &Comment{
+			ID:              thread.Id,
+			VideoID:         videoID,
+			AuthorName:      s.AuthorDisplayName,
+			AuthorChannelID: authorChannelID,
+			Text:            s.TextDisplay,
+			Likes:           s.LikeCount,
+			ReplyCount:      thread.Snippet.TotalReplyCount,
+			PublishedAt:     published,
+		})
+	}
+	return comments, nil
+}
+
+// commentsDisabled reports whether err is the 403 returned for videos whose
+// comments are turned off.
+func commentsDisabled(err error) bool {
+	var gerr *googleapi.Error
+	if !stderrors.As(err, &gerr) || gerr.Code != 403 {
+		return false
+	}
+	for _, item := range gerr.Errors {
+		if item.Reason == "commentsDisabled" {
+			return true
+		}
+	}
+	return false
+}
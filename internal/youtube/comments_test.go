@@ -0,0 +1,33 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestCommentsDisabled(t *testing.T) {
+	disabled := &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "commentsDisabled"}}}
+	quota := &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"comments disabled", disabled, true},
+		{"wrapped", fmt.Errorf("operation failed after 1 attempts: %w", disabled), true},
+		{"quota exceeded", quota, false},
+		{"other error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commentsDisabled(tt.err); got != tt.want {
+				t.Errorf("commentsDisabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package youtube
+
+import (
+	"container/list"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/api/googleapi"
+)
+
+// ResponseCache is a least-recently-used cache of API responses together
+// with their ETags. Requests for a cached response are sent with
+// If-None-Match, and a 304 Not Modified is answered from the cache, which
+// saves the response transfer and decoding for data that has not changed
+// (channel details and most pages of an uploads playlist). It is safe for
+// concurrent use.
+type ResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// cacheEntry is a cached response body, also the on-disk format.
+type cacheEntry struct {
+	Key  string          `json:"key"`
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+// NewResponseCache returns an empty cache holding at most size responses.
+func NewResponseCache(size int) *ResponseCache {
+	if size < 1 {
+		size = 1
+	}
+	return &ResponseCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// LoadResponseCache returns a cache of the given size filled from a file
+// written by Save. A missing file gives an empty cache.
+func LoadResponseCache(path string, size int) (*ResponseCache, error) {
+	c := NewResponseCache(size)
+	data, err := os.ReadFile(path)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read response cache: %w", err)
+	}
+	var entries []cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return c, fmt.Errorf("failed to parse response cache %s: %w", path, err)
+	}
+	// Entries are saved least recently used first, so adding them in order
+	// restores the recency order.
+	for _, e := range entries {
+		c.put(e.Key, e.ETag, e.Body)
+	}
+	return c, nil
+}
+
+// Save writes the cache to path, replacing the file atomically so that a
+// concurrent or interrupted save never leaves a truncated file behind.
+func (c *ResponseCache) Save(path string) error {
+	c.mu.Lock()
+	entries := make([]cacheEntry, 0, c.order.Len())
+	for el := c.order.Back(); el != nil; el = el.Prev() {
+		entries = append(entries, *el.Value.(*cacheEntry))
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode response cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create response cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write response cache: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of cached responses.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *ResponseCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	c.order.MoveToFront(el)
+	return *el.Value.(*cacheEntry), true
+}
+
+func (c *ResponseCache) put(key, etag string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{Key: key, ETag: etag, Body: body}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{Key: key, ETag: etag, Body: body})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+func (c *ResponseCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// conditional makes the request done by call, sending the ETag of the
+// response cached under key if there is one, and returns the cached
+// response when the API answers 304 Not Modified. Fresh responses that carry
+// an ETag replace the cached one. Without a cache it just calls call("").
+func conditional[T any](c *Client, key string, call func(etag string) (*T, error), etagOf func(*T) string) (*T, error) {
+	if c.cache == nil {
+		return call("")
+	}
+	cached, ok := c.cache.get(key)
+	resp, err := call(cached.ETag)
+	if ok && googleapi.IsNotModified(err) {
+		var r T
+		if err := json.Unmarshal(cached.Body, &r); err == nil {
+			c.notModified.Add(1)
+			return &r, nil
+		}
+		// An unreadable entry (e.g. from an older version) is dropped and
+		// the request repeated without a condition.
+		c.cache.remove(key)
+		resp, err = call("")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if etag := etagOf(resp); etag != "" {
+		if body, err := json.Marshal(resp); err == nil {
+			c.cache.put(key, etag, body)
+		}
+	}
+	return resp, nil
+}
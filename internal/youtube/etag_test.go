@@ -0,0 +1,87 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := NewResponseCache(2)
+	c.put("a", "1", []byte(`{}`))
+	c.put("b", "2", []byte(`{}`))
+	c.get("a") // b is now the least recently used
+	c.put("c", "3", []byte(`{}`))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("a was evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestResponseCacheSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "responses.json")
+	if c, err := LoadResponseCache(path, 10); err != nil || c.Len() != 0 {
+		t.Fatalf("missing file: got %d entries, err %v", c.Len(), err)
+	}
+
+	c := NewResponseCache(10)
+	c.put("a", "1", []byte(`{"x":1}`))
+	c.put("b", "2", []byte(`{"x":2}`))
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading into a smaller cache keeps the most recently used entry.
+	loaded, err := LoadResponseCache(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := loaded.get("b"); !ok || e.ETag != "2" || string(e.Body) != `{"x":2}` {
+		t.Errorf("b = %+v, %v", e, ok)
+	}
+	if _, ok := loaded.get("a"); ok {
+		t.Error("a was kept over b")
+	}
+}
+
+func TestUploadsPlaylistConditional(t *testing.T) {
+	var requests, conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"e1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"etag":"\"e1\"","items":[{"id":"UC1","snippet":{"title":"One"},"contentDetails":{"relatedPlaylists":{"uploads":"UU1"}}}]}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClientWithOptions(context.Background(), "k", ClientOptions{HTTPClient: srv.Client(), Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetResponseCache(NewResponseCache(10))
+
+	for i := 0; i < 2; i++ {
+		uploads, title, err := c.UploadsPlaylist(context.Background(), "UC1")
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if uploads != "UU1" || title != "One" {
+			t.Errorf("call %d = %q, %q", i, uploads, title)
+		}
+	}
+	if requests != 2 || conditional != 1 || c.NotModified() != 1 {
+		t.Errorf("requests = %d, conditional = %d, NotModified() = %d; want 2, 1, 1", requests, conditional, c.NotModified())
+	}
+}
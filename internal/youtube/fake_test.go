@@ -0,0 +1,149 @@
+package youtube_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/errors"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube/youtubetest"
+	yt "google.golang.org/api/youtube/v3"
+)
+
+func TestFetchChannelVideos_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	var videos []*yt.Video
+	for i := 0; i < 60; i++ {
+		videos = append(videos, youtubetest.Video(fmt.Sprintf("v%02d", i), "UC1", fmt.Sprintf("Video %d", i)))
+	}
+	videos[0].Snippet.Tags = []string{"#shorts"}
+	videos[0].ContentDetails.Duration = "PT45S"
+	srv.AddChannel("UC1", "Channel One", videos...)
+
+	client := srv.NewClient(t)
+	got, err := client.FetchChannelVideos(context.Background(), "UC1", 55)
+	if err != nil {
+		t.Fatalf("FetchChannelVideos() error = %v", err)
+	}
+	if len(got) != 55 {
+		t.Fatalf("got %d videos, want 55", len(got))
+	}
+	if n := srv.Requests("playlistItems.list"); n != 2 {
+		t.Errorf("playlistItems.list requests = %d, want 2", n)
+	}
+
+	v := got[0]
+	if v.ID != "v00" || v.ChannelName != "Channel One" || v.Views != 1000 || v.Likes != 100 || v.DurationSec != 45 || !v.IsShort {
+		t.Errorf("first video = %+v", v)
+	}
+	if !v.PublishedAt.Equal(youtubetest.PublishedAt) || v.Status != "public" || v.ThumbnailURL == "" {
+		t.Errorf("first video = %+v", v)
+	}
+	if client.QuotaUsed() != 1+2+2 {
+		t.Errorf("QuotaUsed() = %d, want 5", client.QuotaUsed())
+	}
+}
+
+func TestFetchChannelVideos_DisabledParts(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddChannel("UC1", "Channel One", youtubetest.Video("v1", "UC1", "Video"))
+
+	client := srv.NewClient(t)
+	client.DisableParts("UC1", "contentDetails")
+	got, err := client.FetchChannelVideos(context.Background(), "UC1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].DurationSec != 0 || got[0].ContentDetails != "" {
+		t.Errorf("got %+v, want a video without content details", got[0])
+	}
+}
+
+func TestFetchVideosByID_Fake(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	v1 := youtubetest.Video("v1", "UC1", "Kept")
+	v1.ContentDetails.RegionRestriction = &yt.VideoContentDetailsRegionRestriction{Blocked: []string{"DE", "US"}}
+	v1.ContentDetails.ContentRating = &yt.ContentRating{YtRating: "ytAgeRestricted"}
+	v1.Status.MadeForKids = true
+	v1.PaidProductPlacementDetails.HasPaidProductPlacement = true
+	srv.AddVideo(v1)
+	srv.AddVideo(youtubetest.Video("v2", "UC1", "Deleted"))
+	srv.RemoveVideo("v2")
+
+	got, err := srv.NewClient(t).FetchVideosByID(context.Background(), []string{"v1", "v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "v1" || got[0].ChannelName != "UC1" {
+		t.Fatalf("FetchVideosByID() = %+v, want only v1", got)
+	}
+	if v := got[0]; len(v.RegionAllowed) != 0 || len(v.RegionBlocked) != 2 || v.RegionBlocked[1] != "US" ||
+		len(v.ContentRatings) != 1 || v.ContentRatings[0] != (youtube.ContentRating{System: "yt", Rating: "ytAgeRestricted"}) {
+		t.Errorf("restrictions = %v %v %v", v.RegionAllowed, v.RegionBlocked, v.ContentRatings)
+	}
+	if v := got[0]; !v.MadeForKids || !v.PaidPromotion || !v.Embeddable || v.License != "youtube" || v.Status != "public" {
+		t.Errorf("status = %+v", v)
+	}
+}
+
+func TestFetchChannelVideos_Errors(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddChannel("UC1", "Channel One", youtubetest.Video("v1", "UC1", "Video"))
+	client := srv.NewClient(t)
+
+	if _, err := client.FetchChannelVideos(context.Background(), "UCmissing", 10); !stderrors.Is(err, errors.ErrNotFound) {
+		t.Errorf("unknown channel: err = %v, want ErrNotFound", err)
+	}
+
+	srv.FailNext("videos.list", 403, "quotaExceeded")
+	if _, err := client.FetchChannelVideos(context.Background(), "UC1", 10); !stderrors.Is(err, errors.ErrQuotaExceeded) {
+		t.Errorf("quota exceeded: err = %v, want ErrQuotaExceeded", err)
+	}
+	if n := srv.Requests("videos.list"); n != 1 {
+		t.Errorf("videos.list requests = %d, want 1 (quota errors are not retried)", n)
+	}
+}
+
+func TestUploadsPlaylist_ETag(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddChannel("UC1", "Channel One")
+
+	client := srv.NewClient(t)
+	client.SetResponseCache(youtube.NewResponseCache(10))
+	for i := 0; i < 2; i++ {
+		uploads, _, err := client.UploadsPlaylist(context.Background(), "UC1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uploads != youtubetest.UploadsPlaylistID("UC1") {
+			t.Errorf("uploads = %q", uploads)
+		}
+	}
+	if client.NotModified() != 1 {
+		t.Errorf("NotModified() = %d, want 1", client.NotModified())
+	}
+}
+
+func TestUploadsPlaylist_RateLimitAndQuota(t *testing.T) {
+	srv := youtubetest.NewServer(t)
+	srv.AddChannel("UC1", "Channel One")
+	client := srv.NewClient(t)
+
+	srv.FailNext("channels.list", 403, "rateLimitExceeded")
+	if _, _, err := client.UploadsPlaylist(context.Background(), "UC1"); err != nil {
+		t.Fatalf("rate limited: err = %v, want success after a retry", err)
+	}
+	if n := srv.Requests("channels.list"); n != 2 {
+		t.Errorf("channels.list requests = %d, want 2 (rate limits are retried)", n)
+	}
+
+	srv.FailNext("channels.list", 403, "quotaExceeded")
+	if _, _, err := client.UploadsPlaylist(context.Background(), "UC1"); !stderrors.Is(err, errors.ErrQuotaExceeded) {
+		t.Errorf("quota exceeded: err = %v, want ErrQuotaExceeded", err)
+	}
+	if n := srv.Requests("channels.list"); n != 3 {
+		t.Errorf("channels.list requests = %d, want 3 (quota errors are not retried)", n)
+	}
+}
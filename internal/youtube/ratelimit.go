@@ -0,0 +1,94 @@
+package youtube
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/metrics"
+	"golang.org/x/time/rate"
+)
+
+// DefaultRateLimitCooldown is how long a RateLimiter holds every request
+// back after the API reported rate limiting.
+const DefaultRateLimitCooldown = 30 * time.Second
+
+// RateLimiter spaces out YouTube API requests with a token bucket that can
+// be shared by every client of the process, so that parallel channel fetches
+// together stay under the configured rate. When the API rate limits anyway,
+// Trip opens the circuit: all requests wait for the cooldown instead of each
+// worker retrying on its own into a storm of 429s. It is safe for concurrent
+// use.
+type RateLimiter struct {
+	limiter  *rate.Limiter
+	cooldown time.Duration
+	metrics  *metrics.Metrics
+
+	mu        sync.Mutex
+	openUntil time.Time
+}
+
+// NewRateLimiter returns a limiter allowing qps requests per second on
+// average and bursts of up to burst requests. A burst below 1 is raised to 1.
+func NewRateLimiter(qps float64, burst int, cooldown time.Duration) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{limiter: rate.NewLimiter(rate.Limit(qps), burst), cooldown: cooldown}
+}
+
+// SetMetrics makes the limiter report its saturation as
+// ytt_api_rate_limit_saturation.
+func (l *RateLimiter) SetMetrics(m *metrics.Metrics) {
+	l.metrics = m
+}
+
+// Wait blocks until a request may be made: until the circuit is closed again
+// and a token is available. It returns early with the context's error.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	pause := time.Until(l.openUntil)
+	l.mu.Unlock()
+	if pause > 0 {
+		l.report()
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	err := l.limiter.Wait(ctx)
+	l.report()
+	return err
+}
+
+// Trip opens the circuit for the cooldown, holding back every request made
+// through the limiter.
+func (l *RateLimiter) Trip() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(l.cooldown); until.After(l.openUntil) {
+		l.openUntil = until
+	}
+}
+
+// Saturation returns how much of the burst is in use, from 0 (all tokens
+// available) to 1 (requests are waiting, or the circuit is open).
+func (l *RateLimiter) Saturation() float64 {
+	l.mu.Lock()
+	open := time.Now().Before(l.openUntil)
+	l.mu.Unlock()
+	if open {
+		return 1
+	}
+	s := 1 - l.limiter.Tokens()/float64(l.limiter.Burst())
+	return min(max(s, 0), 1)
+}
+
+func (l *RateLimiter) report() {
+	if l.metrics != nil {
+		l.metrics.APIRateLimitSaturation.Set(l.Saturation())
+	}
+}
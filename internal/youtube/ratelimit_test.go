@@ -0,0 +1,64 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestRateLimiterSaturation(t *testing.T) {
+	l := NewRateLimiter(0.001, 2, time.Minute)
+	if got := l.Saturation(); got != 0 {
+		t.Errorf("Saturation() = %g before any request, want 0", got)
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Saturation(); got < 0.49 || got > 0.51 {
+		t.Errorf("Saturation() = %g with half the burst used, want 0.5", got)
+	}
+	l.Trip()
+	if got := l.Saturation(); got != 1 {
+		t.Errorf("Saturation() = %g with the circuit open, want 1", got)
+	}
+}
+
+func TestRateLimiterTrip(t *testing.T) {
+	l := NewRateLimiter(1000, 10, 50*time.Millisecond)
+	l.Trip()
+
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want it to wait for the cooldown", elapsed)
+	}
+
+	l.Trip()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait() with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestClientTripsRateLimiter(t *testing.T) {
+	l := NewRateLimiter(1000, 10, time.Minute)
+	c := &Client{limiter: l}
+
+	c.apiError("YouTube API error", &googleapi.Error{Code: http.StatusNotFound})
+	if l.Saturation() == 1 {
+		t.Error("a 404 tripped the rate limiter")
+	}
+	c.apiError("YouTube API error", &googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}},
+	})
+	if l.Saturation() != 1 {
+		t.Error("rateLimitExceeded did not trip the rate limiter")
+	}
+}
@@ -0,0 +1,56 @@
+package youtube
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	yt "google.golang.org/api/youtube/v3"
+)
+
+// ContentRating is the rating of a video under one rating system, e.g.
+// {"yt", "ytAgeRestricted"} or {"mpaa", "mpaaPg13"}.
+type ContentRating struct {
+	// System is the name of the contentRating field without its "Rating"
+	// suffix: "yt", "mpaa", "eirin", ...
+	System string
+	Rating string
+}
+
+// contentRatings flattens contentDetails.contentRating, which has one field
+// per rating system, into the systems the video is rated under, sorted by
+// system. Rating reasons (djctqRatingReasons, fpbRatingReasons) are left
+// out.
+func contentRatings(r *yt.ContentRating) []ContentRating {
+	if r == nil {
+		return nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+	var ratings []ContentRating
+	for name, value := range fields {
+		rating, ok := value.(string)
+		if !ok || rating == "" || !strings.HasSuffix(name, "Rating") {
+			continue
+		}
+		ratings = append(ratings, ContentRating{System: strings.TrimSuffix(name, "Rating"), Rating: rating})
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].System < ratings[j].System })
+	return ratings
+}
+
+// regionRestriction returns the regions a video is restricted to (allowed)
+// or blocked in. At most one of the lists is set; both are empty for a
+// video viewable everywhere.
+func regionRestriction(r *yt.VideoContentDetailsRegionRestriction) (allowed, blocked []string) {
+	if r == nil {
+		return nil, nil
+	}
+	return r.Allowed, r.Blocked
+}
@@ -0,0 +1,26 @@
+package youtube
+
+import (
+	"reflect"
+	"testing"
+
+	yt "google.golang.org/api/youtube/v3"
+)
+
+func TestContentRatings(t *testing.T) {
+	got := contentRatings(&yt.ContentRating{
+		YtRating:           "ytAgeRestricted",
+		MpaaRating:         "mpaaPg13",
+		DjctqRatingReasons: []string{"djctqViolence"},
+	})
+	want := []ContentRating{{System: "mpaa", Rating: "mpaaPg13"}, {System: "yt", Rating: "ytAgeRestricted"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contentRatings() = %+v, want %+v", got, want)
+	}
+	if got := contentRatings(&yt.ContentRating{}); got != nil {
+		t.Errorf("contentRatings(empty) = %+v, want nil", got)
+	}
+	if got := contentRatings(nil); got != nil {
+		t.Errorf("contentRatings(nil) = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,94 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Shorts may be up to three minutes long since October 2024.
+const maxShortDuration = 3 * time.Minute
+
+// ShortThreshold is the confidence at or above which a video is a Short.
+const ShortThreshold = 0.5
+
+// shortsBaseURL serves /shorts/{id} with 200 for Shorts and redirects to
+// /watch for regular videos.
+const shortsBaseURL = "https://www.youtube.com/shorts/"
+
+// ShortSignals are the observations used to classify a video as a Short.
+type ShortSignals struct {
+	Duration time.Duration
+	// Vertical is true when the player reports a portrait aspect ratio.
+	Vertical bool
+	// Hashtag is true when #shorts appears in the title, description or tags.
+	Hashtag bool
+	// URLChecked is set when the /shorts/ URL was probed; URLIsShort is the
+	// result of that probe.
+	URLChecked bool
+	URLIsShort bool
+}
+
+// ShortConfidence returns a score in [0, 1] that the video is a Short. A
+// /shorts/ URL probe is authoritative; otherwise the other signals are
+// weighted, so a 45-second landscape teaser scores below the threshold
+// while a 3-minute portrait video scores above it.
+func ShortConfidence(s ShortSignals) float64 {
+	if s.URLChecked {
+		if s.URLIsShort {
+			return 1
+		}
+		return 0
+	}
+	if s.Duration <= 0 || s.Duration > maxShortDuration {
+		return 0
+	}
+
+	score := 0.2
+	if s.Vertical {
+		score += 0.5
+	}
+	if s.Hashtag {
+		score += 0.2
+	}
+	if s.Duration <= 60*time.Second {
+		score += 0.1
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score
+}
+
+// hasShortsHashtag reports whether #shorts appears in any of the texts or tags.
+func hasShortsHashtag(title, description string, tags []string) bool {
+	if strings.Contains(strings.ToLower(title), "#shorts") || strings.Contains(strings.ToLower(description), "#shorts") {
+		return true
+	}
+	for _, tag := range tags {
+		if strings.EqualFold(strings.TrimPrefix(tag, "#"), "shorts") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkShortsURL probes the /shorts/ URL of a video without following
+// redirects. It returns true when YouTube serves the Shorts player.
+func checkShortsURL(ctx context.Context, httpClient *http.Client, baseURL, videoID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL+videoID, nil)
+	if err != nil {
+		return false, err
+	}
+	client := *httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShortConfidence(t *testing.T) {
+	tests := []struct {
+		name      string
+		signals   ShortSignals
+		wantShort bool
+	}{
+		{"landscape teaser", ShortSignals{Duration: 45 * time.Second}, false},
+		{"teaser with hashtag", ShortSignals{Duration: 45 * time.Second, Hashtag: true}, true},
+		{"three minute portrait", ShortSignals{Duration: 3 * time.Minute, Vertical: true}, true},
+		{"long portrait", ShortSignals{Duration: 4 * time.Minute, Vertical: true, Hashtag: true}, false},
+		{"url probe wins", ShortSignals{Duration: 30 * time.Second, Vertical: true, URLChecked: true}, false},
+		{"url probe short", ShortSignals{Duration: 170 * time.Second, URLChecked: true, URLIsShort: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ShortConfidence(tt.signals)
+			if c < 0 || c > 1 {
+				t.Fatalf("ShortConfidence() = %v, out of range", c)
+			}
+			if got := c >= ShortThreshold; got != tt.wantShort {
+				t.Errorf("ShortConfidence() = %v, short = %v, want %v", c, got, tt.wantShort)
+			}
+		})
+	}
+}
+
+func TestHasShortsHashtag(t *testing.T) {
+	if !hasShortsHashtag("Cat #Shorts", "", nil) {
+		t.Error("hashtag in title not detected")
+	}
+	if !hasShortsHashtag("", "", []string{"#shorts"}) {
+		t.Error("hashtag in tags not detected")
+	}
+	if hasShortsHashtag("shortstop highlights", "", []string{"shortstop"}) {
+		t.Error("false positive on shortstop")
+	}
+}
+
+func TestCheckShortsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/shorts/short" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.Redirect(w, r, "/watch?v=regular", http.StatusSeeOther)
+	}))
+	defer srv.Close()
+
+	base := srv.URL + "/shorts/"
+	if ok, err := checkShortsURL(context.Background(), srv.Client(), base, "short"); err != nil || !ok {
+		t.Errorf("checkShortsURL(short) = %v, %v; want true", ok, err)
+	}
+	if ok, err := checkShortsURL(context.Background(), srv.Client(), base, "regular"); err != nil || ok {
+		t.Errorf("checkShortsURL(regular) = %v, %v; want false", ok, err)
+	}
+}
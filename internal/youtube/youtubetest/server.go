@@ -0,0 +1,448 @@
+// Package youtubetest provides a fake YouTube Data API server for hermetic
+// tests of code built on the youtube package.
+//
+// The server answers channels.list, channelSections.list, playlists.list,
+// playlistItems.list, search.list (channel searches), videos.list,
+// videoCategories.list and i18nRegions.list from canned data, honours the part parameter of
+// videos.list, pages playlists like the real API and supports ETags and
+// If-None-Match:
+//
+//	srv := youtubetest.NewServer(t)
+//	srv.AddChannel("UC1", "Channel One", youtubetest.Video("v1", "UC1", "First"))
+//	client := srv.NewClient(t)
+//	videos, err := client.FetchChannelVideos(ctx, "UC1", 10)
+package youtubetest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancelop89/youtube-trend-tracker/internal/youtube"
+	yt "google.golang.org/api/youtube/v3"
+)
+
+// APIKey is the API key clients created by NewClient send.
+const APIKey = "youtubetest-key"
+
+// PublishedAt is the publish time of videos created by Video.
+var PublishedAt = time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+
+// Server is a fake YouTube Data API. Its methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	channels   map[string]*yt.Channel
+	playlists  map[string][]string // playlist ID -> video IDs, newest first
+	videos     map[string]*yt.Video
+	featured   map[string][]string            // channel ID -> featured channel IDs
+	searches   map[string][]string            // query -> channel IDs
+	categories map[string][]*yt.VideoCategory // region -> categories
+	requests   map[string]int
+	failures   map[string][]apiError
+}
+
+type apiError struct {
+	code   int
+	reason string
+}
+
+// NewServer starts a fake API server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		channels:   make(map[string]*yt.Channel),
+		playlists:  make(map[string][]string),
+		videos:     make(map[string]*yt.Video),
+		featured:   make(map[string][]string),
+		searches:   make(map[string][]string),
+		categories: make(map[string][]*yt.VideoCategory),
+		requests:   make(map[string]int),
+		failures:   make(map[string][]apiError),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewClient returns a youtube.Client that sends its requests to the server.
+func (s *Server) NewClient(t testing.TB) *youtube.Client {
+	c, err := youtube.NewClientWithOptions(context.Background(), APIKey, youtube.ClientOptions{
+		HTTPClient: s.Client(),
+		Endpoint:   s.URL + "/",
+	})
+	if err != nil {
+		t.Fatalf("youtubetest: creating client: %v", err)
+	}
+	return c
+}
+
+// UploadsPlaylistID returns the ID of the uploads playlist AddChannel
+// creates for channelID, following YouTube's UC... -> UU... convention.
+func UploadsPlaylistID(channelID string) string {
+	return "UU" + strings.TrimPrefix(channelID, "UC")
+}
+
+// AddChannel adds a channel whose uploads playlist holds videos, newest
+// first. Adding a channel again replaces it.
+func (s *Server) AddChannel(id, title string, videos ...*yt.Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uploads := UploadsPlaylistID(id)
+	s.channels[id] = &yt.Channel{
+		Id:      id,
+		Snippet: &yt.ChannelSnippet{Title: title},
+		ContentDetails: &yt.ChannelContentDetails{
+			RelatedPlaylists: &yt.ChannelContentDetailsRelatedPlaylists{Uploads: uploads},
+		},
+	}
+	ids := make([]string, 0, len(videos))
+	for _, v := range videos {
+		ids = append(ids, v.Id)
+		s.videos[v.Id] = v
+	}
+	s.playlists[uploads] = ids
+}
+
+// AddPlaylist adds a playlist holding videos, in order, which can belong to
+// any channel. Adding a playlist again replaces it.
+func (s *Server) AddPlaylist(id string, videos ...*yt.Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(videos))
+	for _, v := range videos {
+		ids = append(ids, v.Id)
+		s.videos[v.Id] = v
+	}
+	s.playlists[id] = ids
+}
+
+// SetSubscribers sets the subscriber count of a channel added with
+// AddChannel, returned in its statistics part.
+func (s *Server) SetSubscribers(channelID string, subscribers uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch, ok := s.channels[channelID]; ok {
+		ch.Statistics = &yt.ChannelStatistics{SubscriberCount: subscribers}
+	}
+}
+
+// SetFeaturedChannels sets the channels listed in a "Featured channels"
+// section of channelID's home page.
+func (s *Server) SetFeaturedChannels(channelID string, featured ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.featured[channelID] = featured
+}
+
+// SetChannelSearch sets the channels a channel search for query returns,
+// in rank order.
+func (s *Server) SetChannelSearch(query string, channelIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches[query] = channelIDs
+}
+
+// AddVideoCategory adds an assignable video category to a region.
+func (s *Server) AddVideoCategory(regionCode, id, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories[regionCode] = append(s.categories[regionCode], &yt.VideoCategory{
+		Id:      id,
+		Snippet: &yt.VideoCategorySnippet{Title: title, Assignable: true},
+	})
+}
+
+// AddVideo adds or replaces a video that is not on any playlist, e.g. one
+// only found by ID.
+func (s *Server) AddVideo(v *yt.Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.videos[v.Id] = v
+}
+
+// RemoveVideo makes a video unavailable, as when it is deleted or made
+// private. It stays on its playlists, as it does on YouTube for a while.
+func (s *Server) RemoveVideo(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.videos, id)
+}
+
+// FailNext makes the next request to method (e.g. "videos.list") fail with
+// the given HTTP status and error reason, such as 403 "quotaExceeded".
+// Several calls queue several failures.
+func (s *Server) FailNext(method string, code int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], apiError{code, reason})
+}
+
+// Requests returns how many requests were made to method, e.g.
+// "playlistItems.list", including failed and not modified ones.
+func (s *Server) Requests(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method]
+}
+
+// Video returns a public, 16:9, five minute video with the given ID,
+// channel and title, published at PublishedAt with 1,000 views, 100 likes
+// and 10 comments. Tests adjust the fields they care about.
+func Video(id, channelID, title string) *yt.Video {
+	return &yt.Video{
+		Id: id,
+		Snippet: &yt.VideoSnippet{
+			ChannelId:    channelID,
+			ChannelTitle: channelID,
+			Title:        title,
+			PublishedAt:  PublishedAt.Format(time.RFC3339),
+			Thumbnails: &yt.ThumbnailDetails{
+				High: &yt.Thumbnail{Url: "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg"},
+			},
+		},
+		Statistics:                  &yt.VideoStatistics{ViewCount: 1000, LikeCount: 100, CommentCount: 10},
+		ContentDetails:              &yt.VideoContentDetails{Duration: "PT5M"},
+		TopicDetails:                &yt.VideoTopicDetails{},
+		Status:                      &yt.VideoStatus{PrivacyStatus: "public", License: "youtube", Embeddable: true},
+		Player:                      &yt.VideoPlayer{EmbedWidth: 720, EmbedHeight: 405},
+		PaidProductPlacementDetails: &yt.VideoPaidProductPlacementDetails{},
+	}
+}
+
+// methods maps request paths to API method names.
+var methods = map[string]string{
+	"/youtube/v3/channels":        "channels.list",
+	"/youtube/v3/channelSections": "channelSections.list",
+	"/youtube/v3/playlists":       "playlists.list",
+	"/youtube/v3/playlistItems":   "playlistItems.list",
+	"/youtube/v3/search":          "search.list",
+	"/youtube/v3/videos":          "videos.list",
+	"/youtube/v3/videoCategories": "videoCategories.list",
+	"/youtube/v3/i18nRegions":     "i18nRegions.list",
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	method, ok := methods[r.URL.Path]
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound", "unknown path "+r.URL.Path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[method]++
+
+	if r.URL.Query().Get("key") != APIKey {
+		writeError(w, http.StatusBadRequest, "keyInvalid", "API key not valid. Please pass a valid API key.")
+		return
+	}
+	if queued := s.failures[method]; len(queued) > 0 {
+		s.failures[method] = queued[1:]
+		writeError(w, queued[0].code, queued[0].reason, "youtubetest: injected failure")
+		return
+	}
+
+	var resp interface{}
+	var err *apiError
+	q := r.URL.Query()
+	switch method {
+	case "channels.list":
+		resp = s.listChannels(splitValues(q["id"]))
+	case "channelSections.list":
+		resp = s.listChannelSections(q.Get("channelId"))
+	case "search.list":
+		resp = s.search(q.Get("type"), q.Get("q"), q.Get("maxResults"))
+	case "playlists.list":
+		resp = s.listPlaylists(splitValues(q["id"]))
+	case "playlistItems.list":
+		resp, err = s.listPlaylistItems(q.Get("playlistId"), q.Get("pageToken"), q.Get("maxResults"))
+	case "videos.list":
+		resp = s.listVideos(splitValues(q["id"]), splitValues(q["part"]))
+	case "videoCategories.list":
+		resp = &yt.VideoCategoryListResponse{Kind: "youtube#videoCategoryListResponse", Items: s.categories[q.Get("regionCode")]}
+	case "i18nRegions.list":
+		resp = &yt.I18nRegionListResponse{Kind: "youtube#i18nRegionListResponse"}
+	}
+	if err != nil {
+		writeError(w, err.code, err.reason, err.reason)
+		return
+	}
+	writeResponse(w, r, resp)
+}
+
+func (s *Server) listChannels(ids []string) *yt.ChannelListResponse {
+	resp := &yt.ChannelListResponse{Kind: "youtube#channelListResponse", Items: []*yt.Channel{}}
+	for _, id := range ids {
+		if ch, ok := s.channels[id]; ok {
+			resp.Items = append(resp.Items, ch)
+		}
+	}
+	return resp
+}
+
+func (s *Server) listChannelSections(channelID string) *yt.ChannelSectionListResponse {
+	resp := &yt.ChannelSectionListResponse{Kind: "youtube#channelSectionListResponse", Items: []*yt.ChannelSection{}}
+	if featured := s.featured[channelID]; len(featured) > 0 {
+		resp.Items = append(resp.Items, &yt.ChannelSection{
+			Id:             channelID + ".featured",
+			ContentDetails: &yt.ChannelSectionContentDetails{Channels: featured},
+		})
+	}
+	return resp
+}
+
+// search answers a channel search. Other searches return nothing.
+func (s *Server) search(typ, query, maxResults string) *yt.SearchListResponse {
+	resp := &yt.SearchListResponse{Kind: "youtube#searchListResponse", Items: []*yt.SearchResult{}}
+	if typ != "channel" {
+		return resp
+	}
+	ids := s.searches[query]
+	if size, _ := strconv.Atoi(maxResults); size > 0 && size < len(ids) {
+		ids = ids[:size]
+	}
+	for _, id := range ids {
+		resp.Items = append(resp.Items, &yt.SearchResult{
+			Id: &yt.ResourceId{Kind: "youtube#channel", ChannelId: id},
+		})
+	}
+	return resp
+}
+
+func (s *Server) listPlaylists(ids []string) *yt.PlaylistListResponse {
+	resp := &yt.PlaylistListResponse{Kind: "youtube#playlistListResponse", Items: []*yt.Playlist{}}
+	for _, id := range ids {
+		if _, ok := s.playlists[id]; ok {
+			resp.Items = append(resp.Items, &yt.Playlist{Id: id})
+		}
+	}
+	return resp
+}
+
+func (s *Server) listPlaylistItems(playlistID, pageToken, maxResults string) (*yt.PlaylistItemListResponse, *apiError) {
+	ids, ok := s.playlists[playlistID]
+	if !ok {
+		return nil, &apiError{http.StatusNotFound, "playlistNotFound"}
+	}
+	size, _ := strconv.Atoi(maxResults)
+	if size <= 0 {
+		size = 5
+	}
+	// Page tokens are the offset of the page, which is enough for a fake.
+	start, _ := strconv.Atoi(pageToken)
+	start = min(max(start, 0), len(ids))
+	end := min(start+size, len(ids))
+
+	resp := &yt.PlaylistItemListResponse{Kind: "youtube#playlistItemListResponse", Items: []*yt.PlaylistItem{}}
+	for _, id := range ids[start:end] {
+		resp.Items = append(resp.Items, &yt.PlaylistItem{
+			Id:             playlistID + "." + id,
+			ContentDetails: &yt.PlaylistItemContentDetails{VideoId: id},
+		})
+	}
+	if end < len(ids) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+func (s *Server) listVideos(ids, part []string) *yt.VideoListResponse {
+	parts := make(map[string]bool)
+	for _, p := range part {
+		parts[p] = true
+	}
+	resp := &yt.VideoListResponse{Kind: "youtube#videoListResponse", Items: []*yt.Video{}}
+	for _, id := range ids {
+		v, ok := s.videos[id]
+		if !ok {
+			continue
+		}
+		// Like the API, return only the parts asked for.
+		item := &yt.Video{Id: v.Id}
+		if parts["snippet"] {
+			item.Snippet = v.Snippet
+		}
+		if parts["statistics"] {
+			item.Statistics = v.Statistics
+		}
+		if parts["contentDetails"] {
+			item.ContentDetails = v.ContentDetails
+		}
+		if parts["topicDetails"] {
+			item.TopicDetails = v.TopicDetails
+		}
+		if parts["status"] {
+			item.Status = v.Status
+		}
+		if parts["player"] {
+			item.Player = v.Player
+		}
+		if parts["paidProductPlacementDetails"] {
+			item.PaidProductPlacementDetails = v.PaidProductPlacementDetails
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp
+}
+
+// splitValues returns the values of a list parameter, which clients send
+// either repeated (id=a&id=b) or comma-separated (id=a,b).
+func splitValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// writeResponse writes resp as JSON with an ETag derived from its content,
+// or 304 Not Modified when the request's If-None-Match matches it.
+func writeResponse(w http.ResponseWriter, r *http.Request, resp interface{}) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "backendError", err.Error())
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	// The API also returns the ETag in the body, which is what clients read.
+	var fields map[string]interface{}
+	json.Unmarshal(body, &fields)
+	fields["etag"] = etag
+	body, _ = json.Marshal(fields)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("ETag", etag)
+	w.Write(body)
+}
+
+// writeError writes an error in the API's JSON error format.
+func writeError(w http.ResponseWriter, code int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"errors": []map[string]string{
+				{"domain": "youtube.api", "reason": reason, "message": message},
+			},
+		},
+	})
+}
@@ -0,0 +1,237 @@
+// Package client is a Go client for the tracker's read-only query API
+// (/api/v1/... and /runs), so other services can read stored trends without
+// hand-rolling HTTP calls or BigQuery queries.
+//
+// The service usually runs on Cloud Run with authentication required; pass
+// an HTTP client that adds an ID token for the service URL:
+//
+//	hc, err := idtoken.NewClient(ctx, serviceURL)
+//	if err != nil { ... }
+//	c, err := client.New(serviceURL, client.WithHTTPClient(hc))
+//	videos, err := c.TopVideos(ctx, civil.DateOf(time.Now()), client.Metric("likes"), client.Limit(10))
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/civil"
+)
+
+// Client calls the query API of one tracker service. It is safe for
+// concurrent use.
+type Client struct {
+	baseURL   *url.URL
+	http      *http.Client
+	userAgent string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, e.g. one from
+// idtoken.NewClient. The default is http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New returns a client for the service at baseURL, e.g.
+// "https://trend-tracker-xxxx.a.run.app".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q (want http(s)://host)", baseURL)
+	}
+	u.Path, u.RawPath = strings.TrimSuffix(u.Path, "/"), ""
+	c := &Client{baseURL: u, http: http.DefaultClient, userAgent: "youtube-trend-tracker-client"}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError is returned for a response other than 200. Message is the body
+// the service wrote, e.g. "limit must be between 1 and 500".
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tracker API: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// QueryOption sets an optional query parameter. Options that a method does
+// not use are ignored by the service.
+type QueryOption func(url.Values)
+
+// From sets the first date of a range. The service defaults it to 30 days
+// before the last date.
+func From(d civil.Date) QueryOption {
+	return func(v url.Values) { v.Set("from", d.String()) }
+}
+
+// To sets the last date of a range. The service defaults it to today in its
+// timezone.
+func To(d civil.Date) QueryOption {
+	return func(v url.Values) { v.Set("to", d.String()) }
+}
+
+// Limit sets the maximum number of items returned (the service allows 1 to
+// 500 and defaults to 50).
+func Limit(n int) QueryOption {
+	return func(v url.Values) { v.Set("limit", strconv.Itoa(n)) }
+}
+
+// Metric sets the ranking metric of TopVideos: "views" (the default),
+// "likes" or "comments".
+func Metric(m string) QueryOption {
+	return func(v url.Values) { v.Set("metric", m) }
+}
+
+// Status returns the latest run handled by the instance that served the
+// request, and the number of enabled channels and keywords.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var s Status
+	if err := c.get(ctx, "/api/v1/status", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Channels returns the number of videos and total views of each channel on
+// date, most viewed first. A zero date means today.
+func (c *Client) Channels(ctx context.Context, date civil.Date) ([]ChannelSummary, error) {
+	var resp struct {
+		Channels []ChannelSummary `json:"channels"`
+	}
+	err := c.get(ctx, "/api/v1/channels", dateValues(date), &resp)
+	return resp.Channels, err
+}
+
+// ChannelVideos returns the latest snapshot of each video of a channel in a
+// date range (From, To, Limit), most viewed first.
+func (c *Client) ChannelVideos(ctx context.Context, channelID string, opts ...QueryOption) ([]Video, error) {
+	var resp struct {
+		Videos []Video `json:"videos"`
+	}
+	err := c.get(ctx, "/api/v1/channels/"+url.PathEscape(channelID)+"/videos", values(opts), &resp)
+	return resp.Videos, err
+}
+
+// VideoTimeseries returns every snapshot of a video in a date range (From,
+// To), oldest first.
+func (c *Client) VideoTimeseries(ctx context.Context, videoID string, opts ...QueryOption) ([]Point, error) {
+	var resp struct {
+		Points []Point `json:"points"`
+	}
+	err := c.get(ctx, "/api/v1/videos/"+url.PathEscape(videoID)+"/timeseries", values(opts), &resp)
+	return resp.Points, err
+}
+
+// TopVideos returns the top videos on date (Metric, Limit). A zero date
+// means today.
+func (c *Client) TopVideos(ctx context.Context, date civil.Date, opts ...QueryOption) ([]Video, error) {
+	v := values(opts)
+	if date.IsValid() {
+		v.Set("date", date.String())
+	}
+	var resp struct {
+		Videos []Video `json:"videos"`
+	}
+	err := c.get(ctx, "/api/v1/top", v, &resp)
+	return resp.Videos, err
+}
+
+// Compare returns the daily uploads, views gained and engagement rate of up
+// to 10 channels in a date range (From, To), aligned on the same dates.
+func (c *Client) Compare(ctx context.Context, channelIDs []string, opts ...QueryOption) (*Comparison, error) {
+	v := values(opts)
+	v.Set("channels", strings.Join(channelIDs, ","))
+	var cmp Comparison
+	if err := c.get(ctx, "/api/v1/compare", v, &cmp); err != nil {
+		return nil, err
+	}
+	return &cmp, nil
+}
+
+// Runs returns the runs recorded in the last 90 days (Limit), newest first.
+func (c *Client) Runs(ctx context.Context, opts ...QueryOption) ([]Run, error) {
+	var resp struct {
+		Runs []Run `json:"runs"`
+	}
+	err := c.get(ctx, "/runs", values(opts), &resp)
+	return resp.Runs, err
+}
+
+func values(opts []QueryOption) url.Values {
+	v := url.Values{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+func dateValues(date civil.Date) url.Values {
+	v := url.Values{}
+	if date.IsValid() {
+		v.Set("date", date.String())
+	}
+	return v
+}
+
+// maxErrorBody bounds how much of an error response is kept in APIError.
+const maxErrorBody = 4 << 10
+
+// get sends a GET request for path, which is escaped, with the query v and
+// decodes the JSON response into out.
+func (c *Client) get(ctx context.Context, path string, v url.Values, out interface{}) error {
+	u := *c.baseURL
+	u.RawPath = c.baseURL.EscapedPath() + path
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	u.RawQuery = v.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracker API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("tracker API: failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
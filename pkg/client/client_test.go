@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloud.google.com/go/civil"
+)
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, u := range []string{"", "trend-tracker.a.run.app", "ftp://host", "https://"} {
+		if _, err := New(u); err == nil {
+			t.Errorf("New(%q) error = nil", u)
+		}
+	}
+}
+
+func TestClient_Requests(t *testing.T) {
+	var gotURL, gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL, gotUA = r.URL.String(), r.UserAgent()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/base/api/v1/top":
+			w.Write([]byte(`{"date":"2025-08-01","metric":"likes","videos":[{"dt":"2025-08-01","video_id":"v1","views":10,"published_at":"2025-07-30T12:00:00Z"}]}`))
+		case "/base/api/v1/videos/v 1/timeseries":
+			w.Write([]byte(`{"points":[{"dt":"2025-08-01","views":1},{"dt":"2025-08-02","views":3}]}`))
+		case "/base/api/v1/compare":
+			w.Write([]byte(`{"from":"2025-08-01","to":"2025-08-02","dates":["2025-08-01","2025-08-02"],"channels":[{"channel_id":"UC1","uploads":[1,0],"views_gained":[500,null],"engagement_rate":[0.05,null]}]}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL+"/base/", WithUserAgent("test-agent"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	aug1 := civil.Date{Year: 2025, Month: 8, Day: 1}
+
+	videos, err := c.TopVideos(ctx, aug1, Metric("likes"), Limit(5))
+	if err != nil {
+		t.Fatalf("TopVideos() error = %v", err)
+	}
+	if gotURL != "/base/api/v1/top?date=2025-08-01&limit=5&metric=likes" || gotUA != "test-agent" {
+		t.Errorf("request = %s (%s)", gotURL, gotUA)
+	}
+	if len(videos) != 1 || videos[0].VideoID != "v1" || videos[0].Dt != aug1 || videos[0].PublishedAt.Day() != 30 {
+		t.Errorf("videos = %+v", videos)
+	}
+
+	points, err := c.VideoTimeseries(ctx, "v 1", From(aug1))
+	if err != nil {
+		t.Fatalf("VideoTimeseries() error = %v", err)
+	}
+	if gotURL != "/base/api/v1/videos/v%201/timeseries?from=2025-08-01" || len(points) != 2 || points[1].Views != 3 {
+		t.Errorf("request = %s, points = %+v", gotURL, points)
+	}
+
+	cmp, err := c.Compare(ctx, []string{"UC1", "UC2"}, From(aug1), To(aug1.AddDays(1)))
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if gotURL != "/base/api/v1/compare?channels=UC1%2CUC2&from=2025-08-01&to=2025-08-02" {
+		t.Errorf("request = %s", gotURL)
+	}
+	s := cmp.Channels[0]
+	if len(cmp.Dates) != 2 || *s.ViewsGained[0] != 500 || s.ViewsGained[1] != nil || s.EngagementRate[1] != nil {
+		t.Errorf("comparison = %+v", cmp)
+	}
+
+	_, err = c.Runs(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "not found" || !IsNotFound(err) {
+		t.Errorf("Runs() error = %v, want a 404 APIError", err)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// Status is the response of Status.
+type Status struct {
+	// LastRun is nil when the instance has not run a fetch yet.
+	LastRun  *RunStatus `json:"last_run"`
+	Channels int        `json:"channels"`
+	Keywords int        `json:"keywords"`
+	Version  string     `json:"version"`
+}
+
+// RunStatus is a run as tracked in the memory of one instance.
+type RunStatus struct {
+	RunID     string    `json:"run_id"`
+	Scope     string    `json:"scope"`
+	DryRun    bool      `json:"dry_run"`
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is nil while the run is in progress.
+	FinishedAt        *time.Time `json:"finished_at"`
+	Running           bool       `json:"running"`
+	Error             string     `json:"error"`
+	ChannelsSucceeded int64      `json:"channels_succeeded"`
+	ChannelsFailed    int64      `json:"channels_failed"`
+	ChannelsSkipped   int64      `json:"channels_skipped"`
+	ChannelsDeferred  int64      `json:"channels_deferred"`
+	DeferredChannels  []string   `json:"deferred_channels"`
+	VideosWritten     int64      `json:"videos_written"`
+	QuotaUnits        int64      `json:"quota_units"`
+	FailedChannels    []string   `json:"failed_channels"`
+}
+
+// Run is a run recorded in the fetch_runs table.
+type Run struct {
+	RunID      string    `json:"run_id"`
+	Scope      string    `json:"scope"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Status is "success" or "failed".
+	Status            string `json:"status"`
+	ChannelsSucceeded int64  `json:"channels_succeeded"`
+	ChannelsFailed    int64  `json:"channels_failed"`
+	VideosWritten     int64  `json:"videos_written"`
+	QuotaUnits        int64  `json:"quota_units"`
+	Error             string `json:"error"`
+}
+
+// ChannelSummary is a channel's number of videos and total views on a date.
+type ChannelSummary struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	Videos      int64  `json:"videos"`
+	Views       int64  `json:"views"`
+}
+
+// Video is the latest snapshot of a video on a date.
+type Video struct {
+	Dt          civil.Date `json:"dt"`
+	ChannelID   string     `json:"channel_id"`
+	ChannelName string     `json:"channel_name"`
+	VideoID     string     `json:"video_id"`
+	Title       string     `json:"title"`
+	IsShort     bool       `json:"is_short"`
+	Views       int64      `json:"views"`
+	Likes       int64      `json:"likes"`
+	Comments    int64      `json:"comments"`
+	PublishedAt time.Time  `json:"published_at"`
+}
+
+// Point is one snapshot of a video's counters.
+type Point struct {
+	Dt         civil.Date `json:"dt"`
+	SnapshotTs time.Time  `json:"snapshot_ts"`
+	Views      int64      `json:"views"`
+	Likes      int64      `json:"likes"`
+	Comments   int64      `json:"comments"`
+}
+
+// Comparison is the response of Compare. Every series of Channels has one
+// value per entry of Dates.
+type Comparison struct {
+	From     civil.Date      `json:"from"`
+	To       civil.Date      `json:"to"`
+	Dates    []civil.Date    `json:"dates"`
+	Channels []ChannelSeries `json:"channels"`
+}
+
+// ChannelSeries is one channel's daily values in a Comparison. Values are
+// nil on days without snapshots.
+type ChannelSeries struct {
+	ChannelID      string     `json:"channel_id"`
+	ChannelName    string     `json:"channel_name"`
+	Uploads        []int64    `json:"uploads"`
+	ViewsGained    []*int64   `json:"views_gained"`
+	EngagementRate []*float64 `json:"engagement_rate"`
+}
@@ -38,26 +38,29 @@ if ! gcloud run services get-iam-policy "$SERVICE" --region="$REGION" --project=
     echo "Please run: ./scripts/setup-service-accounts.sh $PROJECT_ID $REGION $SERVICE"
 fi
 
-# Check if the job already exists
-if gcloud scheduler jobs describe trend-tracker-hourly --location="$REGION" --project="$PROJECT_ID" >/dev/null 2>&1; then
-    echo "Updating existing Cloud Scheduler job 'trend-tracker-hourly' to trigger $SERVICE..."
-    gcloud scheduler jobs update http trend-tracker-hourly \
-        --schedule="0 * * * *" \
-        --uri="$CRON_SVC_URL" \
-        --http-method=POST \
-        --oidc-service-account-email="$SCHEDULER_SA" \
-        --location="$REGION" \
-        --project="$PROJECT_ID"
-    echo "Cloud Scheduler job updated."
-else
-    echo "Creating new Cloud Scheduler job 'trend-tracker-hourly' to trigger $SERVICE..."
-    gcloud scheduler jobs create http trend-tracker-hourly \
-        --schedule="0 * * * *" \
-        --uri="$CRON_SVC_URL" \
+# Create or update the fetch job from the scheduler section of the config
+# (schedule from app.schedule, retry policy, service account)
+echo "Provisioning the Cloud Scheduler fetch job to trigger $SERVICE..."
+GOOGLE_CLOUD_PROJECT="$PROJECT_ID" REGION="$REGION" SCHEDULER_SERVICE_ACCOUNT="$SCHEDULER_SA" \
+    go run ./cmd/fetcher setup scheduler -config "${CONFIG_PATH:-configs/config.yaml}" -url "$CRON_SVC_URL"
+
+# Optional: email digest (see DIGEST_* in docs/ENVIRONMENT_VARIABLES.md)
+if [ -n "${DIGEST_SCHEDULE:-}" ]; then
+    DIGEST_URI="$CRON_SVC_URL/digest?period=${DIGEST_PERIOD:-daily}"
+    if gcloud scheduler jobs describe trend-tracker-digest --location="$REGION" --project="$PROJECT_ID" >/dev/null 2>&1; then
+        ACTION=update
+    else
+        ACTION=create
+    fi
+    echo "Running '$ACTION' for Cloud Scheduler job 'trend-tracker-digest' ($DIGEST_SCHEDULE)..."
+    gcloud scheduler jobs "$ACTION" http trend-tracker-digest \
+        --schedule="$DIGEST_SCHEDULE" \
+        --time-zone="${DIGEST_TIME_ZONE:-Asia/Tokyo}" \
+        --uri="$DIGEST_URI" \
         --http-method=POST \
         --oidc-service-account-email="$SCHEDULER_SA" \
+        --oidc-token-audience="$CRON_SVC_URL" \
         --location="$REGION" \
         --project="$PROJECT_ID"
-    echo "Cloud Scheduler job created."
+    echo "Cloud Scheduler job 'trend-tracker-digest' ready."
 fi
-"
\ No newline at end of file
@@ -44,7 +44,7 @@ gcloud run deploy "$SERVICE" \
   --region "$REGION" \
   --service-account "$SERVICE_ACCOUNT" \
   --set-secrets YOUTUBE_API_KEY=youtube-api-key:latest \
-  --set-env-vars GOOGLE_CLOUD_PROJECT="${PROJECT_ID}",MAX_VIDEOS_PER_CHANNEL=200 \
+  --set-env-vars GOOGLE_CLOUD_PROJECT="${PROJECT_ID}",MAX_VIDEOS_PER_CHANNEL=200,RUN_LOCK=true \
   --no-allow-unauthenticated \
   --port 8080 \
   --memory 512Mi \
@@ -49,4 +49,4 @@ fi
 # --- Run Application ---
 echo "Starting Go application..."
 cd "$PROJECT_ROOT/cmd/fetcher"
-go run ./main.go --config "$PROJECT_ROOT/configs/config.yaml"
+go run . --config "$PROJECT_ROOT/configs/config.yaml"
@@ -0,0 +1,47 @@
+#!/bin/bash
+# Creates the Pub/Sub topic and push subscription used by the fan-out worker
+# mode: POST /dispatch publishes one message per channel and each message is
+# pushed to /tasks/channel on the Cloud Run service.
+set -euo pipefail
+
+if [ $# -lt 3 ]; then
+  echo "Usage: $0 <project_id> <region> <service_name> [topic_name]"
+  exit 1
+fi
+
+PROJECT_ID="$1"
+REGION="$2"
+SERVICE="$3"
+TOPIC="${4:-channel-tasks}"
+SUBSCRIPTION="${TOPIC}-push"
+SCHEDULER_SA="scheduler-sa@${PROJECT_ID}.iam.gserviceaccount.com"
+
+SERVICE_URL=$(gcloud run services describe "$SERVICE" --region="$REGION" --format="value(status.url)" --project="$PROJECT_ID")
+if [ -z "$SERVICE_URL" ]; then
+  echo "Error: Could not retrieve Cloud Run service URL for $SERVICE."
+  exit 1
+fi
+
+# Topic（なければ作成）
+gcloud pubsub topics describe "$TOPIC" --project="$PROJECT_ID" >/dev/null 2>&1 || \
+  gcloud pubsub topics create "$TOPIC" --project="$PROJECT_ID"
+
+# Push subscription (OIDC token from scheduler-sa, which already has run.invoker)
+if gcloud pubsub subscriptions describe "$SUBSCRIPTION" --project="$PROJECT_ID" >/dev/null 2>&1; then
+  gcloud pubsub subscriptions update "$SUBSCRIPTION" \
+    --project="$PROJECT_ID" \
+    --push-endpoint="${SERVICE_URL}/tasks/channel" \
+    --push-auth-service-account="$SCHEDULER_SA"
+else
+  gcloud pubsub subscriptions create "$SUBSCRIPTION" \
+    --project="$PROJECT_ID" \
+    --topic="$TOPIC" \
+    --push-endpoint="${SERVICE_URL}/tasks/channel" \
+    --push-auth-service-account="$SCHEDULER_SA" \
+    --ack-deadline=600 \
+    --min-retry-delay=10s \
+    --max-retry-delay=600s
+fi
+
+echo "==> Topic: $TOPIC / Subscription: $SUBSCRIPTION -> ${SERVICE_URL}/tasks/channel"
+echo "Set PUBSUB_TOPIC=$TOPIC on the service and point Cloud Scheduler at ${SERVICE_URL}/dispatch"